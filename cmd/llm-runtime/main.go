@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 
@@ -13,6 +14,10 @@ func main() {
 
 func run() int {
 	if err := cli.Execute(); err != nil {
+		if errors.Is(err, cli.ErrInterrupted) {
+			// The exit code of a process stopped by SIGINT
+			return 130
+		}
 		log.Printf("Error: %v", err)
 		return 1
 	}
@@ -0,0 +1,236 @@
+// Package errors defines the error codes commands fail with. Every failed
+// command reports an error whose message starts with its code, as in
+// "PATH_SECURITY: path is not within repository: ../x"; the code can also
+// be read from the error itself with CodeOf, so callers need not parse the
+// message, and the error marshals to JSON with its code and causes.
+//
+// Is and As are those of the standard library, so a file can import this
+// package in its place.
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+)
+
+// Code identifies the class of a failure
+type Code string
+
+// Path and file access
+const (
+	PathSecurity       Code = "PATH_SECURITY"       // Path outside the repository, excluded, or ignored
+	ExtensionDenied    Code = "EXTENSION_DENIED"    // File extension not in the allowed list
+	ModeDenied         Code = "MODE_DENIED"         // Requested file mode is invalid or not in the allowed list
+	FileNotFound       Code = "FILE_NOT_FOUND"      // File to open does not exist
+	PermissionDenied   Code = "PERMISSION_DENIED"   // File cannot be read
+	ResourceLimit      Code = "RESOURCE_LIMIT"      // File or content over its size limit
+	InvalidRange       Code = "INVALID_RANGE"       // Line range is malformed or past the end of the file
+	InvalidCursor      Code = "INVALID_CURSOR"      // Chunk cursor is malformed or the file changed since it was issued
+	EncodingError      Code = "ENCODING_ERROR"      // Encoded write content cannot be decoded
+	SyntaxError        Code = "SYNTAX_ERROR"        // Written content does not parse
+	FormattingError    Code = "FORMATTING_ERROR"    // Written content cannot be formatted
+	BackupFailed       Code = "BACKUP_FAILED"       // Backup before a write failed
+	Conflict           Code = "CONFLICT"            // File changed on disk since it was opened
+	AppendOnly         Code = "APPEND_ONLY"         // Write to an append-only file removes or changes lines
+	FollowDisabled     Code = "FOLLOW_DISABLED"     // Tail follow requested outside interactive mode
+	ReadContainer      Code = "READ_CONTAINER"      // Reading through the I/O container failed
+	WriteContainer     Code = "WRITE_CONTAINER"     // Writing through the I/O container failed
+	ArchiveError       Code = "ARCHIVE_ERROR"       // Archive is malformed, unsupported, or cannot be written
+	OutlineUnsupported Code = "OUTLINE_UNSUPPORTED" // No outline for the file's language
+	ScratchDisabled    Code = "SCRATCH_DISABLED"    // Path under /scratch without a scratch space
+)
+
+// exec
+const (
+	ExecValidation    Code = "EXEC_VALIDATION"    // Command not allowed by the whitelist
+	ExecError         Code = "EXEC_ERROR"         // Command could not be run
+	ExecFailed        Code = "EXEC_FAILED"        // Command exited non-zero
+	ExecTimeout       Code = "EXEC_TIMEOUT"       // Command stopped by its timeout
+	ExecInterrupted   Code = "EXEC_INTERRUPTED"   // Command stopped by an interrupt
+	DockerUnavailable Code = "DOCKER_UNAVAILABLE" // Docker daemon cannot be reached
+	DockerImage       Code = "DOCKER_IMAGE"       // Container image cannot be pulled
+	REPLDisabled      Code = "REPL_DISABLED"      // REPL language not enabled
+)
+
+// fetch
+const (
+	FetchDisabled Code = "FETCH_DISABLED" // No domains are allowed
+	FetchDenied   Code = "FETCH_DENIED"   // URL, host, or content type not allowed
+	FetchFailed   Code = "FETCH_FAILED"   // Request failed or returned a non-2xx status
+)
+
+// sql
+const (
+	SQLDisabled Code = "SQL_DISABLED" // No databases are configured
+	SQLDenied   Code = "SQL_DENIED"   // Unknown database or statement type not allowed
+	SQLFailed   Code = "SQL_FAILED"   // Connection or query failed
+)
+
+// deps
+const (
+	DepsFailed Code = "DEPS_FAILED" // No Go module, unknown package, or packages could not be loaded
+)
+
+// def, refs, and rename-symbol
+const (
+	LSPUnavailable Code = "LSP_UNAVAILABLE" // No language server for the language, or it is not installed
+	LSPFailed      Code = "LSP_FAILED"      // No symbol or several match, or the language server failed
+	RenameDenied   Code = "RENAME_DENIED"   // Rename would change files outside its scope, excluded, or ignored
+)
+
+// coverage
+const (
+	CoverageFailed Code = "COVERAGE_FAILED" // No coverage profile, or one in an unknown format
+)
+
+// git-branch, git-commit, pr
+const (
+	GitDisabled Code = "GIT_DISABLED" // Git write commands are not enabled
+	GitDenied   Code = "GIT_DENIED"   // Protected branch, detached HEAD, or invalid branch name
+	GitFailed   Code = "GIT_FAILED"   // Not a repository, nothing to commit, or git failed
+	PRFailed    Code = "PR_FAILED"    // No remote or token, the push was rejected, or the forge refused the request
+)
+
+// issue
+const (
+	IssueDisabled Code = "ISSUE_DISABLED" // Reading issues is not enabled
+	IssueFailed   Code = "ISSUE_FAILED"   // No remote, no such issue, or the forge refused the request
+)
+
+// note and memory
+const (
+	MemoryDisabled Code = "MEMORY_DISABLED" // The session keeps no memory document
+	MemoryFailed   Code = "MEMORY_FAILED"   // The memory document cannot be read or written
+)
+
+// search
+const (
+	SearchDisabled   Code = "SEARCH_DISABLED"
+	SearchInitFailed Code = "SEARCH_INIT_FAILED"
+	SearchFailed     Code = "SEARCH_FAILED"
+)
+
+// Commands, pipes, guards, plugins, and hooks
+const (
+	ParseError       Code = "PARSE_ERROR"       // Command is malformed
+	UnknownCommand   Code = "UNKNOWN_COMMAND"   // No command or plugin of that name
+	CommandTooLarge  Code = "COMMAND_TOO_LARGE" // Command body over its size limit
+	VariableError    Code = "VARIABLE_ERROR"    // Malformed <set> or built-in redefined
+	PipeError        Code = "PIPE_ERROR"        // Pipe is malformed
+	PipeFailed       Code = "PIPE_FAILED"       // A step of a pipe failed
+	GuardError       Code = "GUARD_ERROR"       // Guard block is malformed
+	GuardFailed      Code = "GUARD_FAILED"      // A command in a guard block failed
+	PluginValidation Code = "PLUGIN_VALIDATION" // Plugin rejected its arguments
+	PluginFailed     Code = "PLUGIN_FAILED"     // Plugin failed
+	HookDenied       Code = "HOOK_DENIED"       // A pre hook refused the command
+)
+
+// Error is a failure with a code. Its message is the code followed by
+// Message and the cause's message, each after a colon.
+type Error struct {
+	Code    Code
+	Message string // May be empty if Err says it all
+	Err     error  // Cause, if any
+}
+
+// New returns an error with code and message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf returns an error with code and a formatted message
+func Newf(code Code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap returns err with code. An error that already has code is returned
+// as is, so a code set where the failure happened is not repeated by the
+// callers that pass it on.
+func Wrap(code Code, err error) *Error {
+	if e, ok := err.(*Error); ok && e.Code == code {
+		return e
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Wrapf returns err with code and a formatted message before it
+func Wrapf(code Code, err error, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Detail()
+}
+
+// Detail returns the message without the code. The code of a cause is
+// left out too; it is still available from the cause.
+func (e *Error) Detail() string {
+	cause := ""
+	if e.Err != nil {
+		cause = detail(e.Err)
+	}
+	switch {
+	case e.Message == "":
+		return cause
+	case cause == "":
+		return e.Message
+	}
+	return e.Message + ": " + cause
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON encodes the error as its code, detail, and cause
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encode(e))
+}
+
+// jsonError is the JSON form of an error
+type jsonError struct {
+	Code    Code       `json:"code,omitempty"`
+	Message string     `json:"message"`
+	Cause   *jsonError `json:"cause,omitempty"`
+}
+
+func encode(err error) *jsonError {
+	e, ok := err.(*Error)
+	if !ok {
+		return &jsonError{Message: err.Error()}
+	}
+	j := &jsonError{Code: e.Code, Message: e.Detail()}
+	if e.Err != nil {
+		j.Cause = encode(e.Err)
+	}
+	return j
+}
+
+// detail returns the message of err without its code, if it has one
+func detail(err error) string {
+	if e, ok := err.(*Error); ok {
+		return e.Detail()
+	}
+	return err.Error()
+}
+
+// CodeOf returns the code of the outermost coded error in err's chain, or
+// "" if there is none
+func CodeOf(err error) Code {
+	var e *Error
+	if As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// Is reports whether any error in err's chain matches target
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target
+func As(err error, target any) bool {
+	return stderrors.As(err, target)
+}
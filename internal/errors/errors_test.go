@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"message", New(FileNotFound, "main.go"), "FILE_NOT_FOUND: main.go"},
+		{"formatted", Newf(ResourceLimit, "file too large (%d bytes, max %d)", 20, 10), "RESOURCE_LIMIT: file too large (20 bytes, max 10)"},
+		{"wrapped", Wrap(PermissionDenied, fs.ErrPermission), "PERMISSION_DENIED: permission denied"},
+		{"wrapped with message", Wrapf(PipeError, stderrors.New("no such step"), "step %d", 2), "PIPE_ERROR: step 2: no such step"},
+		// The code of a cause is not repeated in the message
+		{"wrapped coded", Wrap(ExecValidation, New(PathSecurity, "path is not within repository: ..")), "EXEC_VALIDATION: path is not within repository: .."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrap_SameCode(t *testing.T) {
+	inner := New(PathSecurity, "path contains null byte")
+	if got := Wrap(PathSecurity, inner); got != inner {
+		t.Errorf("Wrap() with the same code = %v, want the error unchanged", got)
+	}
+	if got := Wrap(ExecValidation, inner); got == inner || got.Err != inner {
+		t.Errorf("Wrap() with another code = %#v, want inner as cause", got)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, ""},
+		{"plain", stderrors.New("EXEC_FAILED: looks coded"), ""},
+		{"coded", New(ExecFailed, "command exited with code 1"), ExecFailed},
+		{"wrapped by fmt", fmt.Errorf("retrying: %w", New(ExecTimeout, "command timed out")), ExecTimeout},
+		{"outermost wins", Wrap(PluginFailed, New(PathSecurity, "denied")), PluginFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_Unwrap(t *testing.T) {
+	err := Wrap(PermissionDenied, fmt.Errorf("open main.go: %w", fs.ErrPermission))
+	if !Is(err, fs.ErrPermission) {
+		t.Error("Is() did not find the cause")
+	}
+	var coded *Error
+	if !As(fmt.Errorf("context: %w", err), &coded) || coded.Code != PermissionDenied {
+		t.Errorf("As() = %v", coded)
+	}
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	err := Wrapf(PipeError, Wrap(PathSecurity, stderrors.New("path is in excluded list: .env")), "step %d", 1)
+
+	data, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatalf("Marshal() error = %v", jsonErr)
+	}
+	want := `{"code":"PIPE_ERROR","message":"step 1: path is in excluded list: .env",` +
+		`"cause":{"code":"PATH_SECURITY","message":"path is in excluded list: .env",` +
+		`"cause":{"message":"path is in excluded list: .env"}}}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s\nwant %s", data, want)
+	}
+}
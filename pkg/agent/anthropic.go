@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// DefaultAnthropicURL is the base URL of the Anthropic API
+const DefaultAnthropicURL = "https://api.anthropic.com/v1"
+
+// anthropicVersion is the API version sent with each request
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens caps the length of each reply
+const anthropicMaxTokens = 4096
+
+// AnthropicClient is a ChatClient for the Anthropic messages API
+type AnthropicClient struct {
+	URL    string // Base URL, e.g. DefaultAnthropicURL
+	APIKey string
+	Model  string
+	HTTP   *http.Client
+}
+
+type anthropicBlock struct {
+	Type      string                 `json:"type"`
+	Text      string                 `json:"text,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Input     map[string]interface{} `json:"input,omitempty"`
+	ToolUseID string                 `json:"tool_use_id,omitempty"`
+	Content   string                 `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicBlock `json:"content"`
+	Usage   struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Chat sends messages to the messages endpoint and returns the reply
+func (c *AnthropicClient) Chat(ctx context.Context, messages []Message, tools []Tool) (Reply, error) {
+	req := anthropicRequest{Model: c.Model, MaxTokens: anthropicMaxTokens, Messages: anthropicMessages(messages)}
+	for _, m := range messages {
+		if m.Role == "system" {
+			req.System = m.Content
+		}
+	}
+	for _, tool := range tools {
+		req.Tools = append(req.Tools, anthropicTool{Name: tool.Name, Description: tool.Description, InputSchema: tool.schema()})
+	}
+
+	var resp anthropicResponse
+	headers := map[string]string{"x-api-key": c.APIKey, "anthropic-version": anthropicVersion}
+	if err := postJSON(ctx, c.HTTP, "Anthropic", c.URL+"/messages", headers, req, &resp); err != nil {
+		return Reply{}, err
+	}
+
+	reply := Reply{Usage: Usage{InputTokens: resp.Usage.InputTokens, OutputTokens: resp.Usage.OutputTokens}}
+	var text []string
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: stringArguments(block.Input)})
+		}
+	}
+	reply.Content = strings.Join(text, "\n")
+	return reply, nil
+}
+
+// anthropicMessages converts a conversation to API messages. The system
+// prompt is sent separately, and tool results are sent as user turns, with
+// consecutive results merged into one turn.
+func anthropicMessages(messages []Message) []anthropicMessage {
+	var out []anthropicMessage
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			continue
+
+		case "tool":
+			block := anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content}
+			if n := len(out); n > 0 && out[n-1].Role == "user" && out[n-1].Content[0].Type == "tool_result" {
+				out[n-1].Content = append(out[n-1].Content, block)
+			} else {
+				out = append(out, anthropicMessage{Role: "user", Content: []anthropicBlock{block}})
+			}
+
+		default:
+			var blocks []anthropicBlock
+			if m.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				input := make(map[string]interface{}, len(call.Arguments))
+				for key, value := range call.Arguments {
+					input[key] = value
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+			if len(blocks) == 0 {
+				continue
+			}
+			out = append(out, anthropicMessage{Role: m.Role, Content: blocks})
+		}
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnthropicClient_ToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("path = %s, want /messages", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("headers = %v", r.Header)
+		}
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad request body: %v", err)
+		}
+		if req.System != "system" || req.MaxTokens == 0 || len(req.Tools) != len(Tools) {
+			t.Errorf("request = %+v", req)
+		}
+		w.Write([]byte(`{
+			"content": [
+				{"type": "text", "text": "Reading it."},
+				{"type": "tool_use", "id": "toolu_1", "name": "open", "input": {"path": "go.mod"}}
+			],
+			"usage": {"input_tokens": 1500, "output_tokens": 60}
+		}`))
+	}))
+	defer server.Close()
+
+	client := &AnthropicClient{URL: server.URL, APIKey: "key", Model: "claude-3-5-sonnet-latest"}
+	reply, err := client.Chat(context.Background(), []Message{{Role: "system", Content: "system"}, {Role: "user", Content: "task"}}, Tools)
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if reply.Content != "Reading it." || len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID != "toolu_1" || reply.ToolCalls[0].Arguments["path"] != "go.mod" {
+		t.Errorf("reply = %+v", reply)
+	}
+	if reply.Usage != (Usage{InputTokens: 1500, OutputTokens: 60}) {
+		t.Errorf("usage = %+v", reply.Usage)
+	}
+}
+
+func TestAnthropicMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "system"},
+		{Role: "user", Content: "task"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "a", Name: "open", Arguments: map[string]string{"path": "x"}},
+			{ID: "b", Name: "open", Arguments: map[string]string{"path": "y"}},
+		}},
+		{Role: "tool", Content: "x", ToolCallID: "a"},
+		{Role: "tool", Content: "y", ToolCallID: "b"},
+	}
+
+	got := anthropicMessages(messages)
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3: %+v", len(got), got)
+	}
+	if got[1].Role != "assistant" || len(got[1].Content) != 2 || got[1].Content[0].Type != "tool_use" {
+		t.Errorf("assistant turn = %+v", got[1])
+	}
+	results := got[2]
+	if results.Role != "user" || len(results.Content) != 2 || results.Content[1].ToolUseID != "b" {
+		t.Errorf("tool results = %+v", results)
+	}
+}
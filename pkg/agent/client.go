@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Message is one turn of a chat conversation
+type Message struct {
+	Role       string     // system, user, assistant, or tool
+	Content    string     // Text of the turn; the result for a tool turn
+	ToolCalls  []ToolCall // Tools the assistant asked to call
+	ToolCallID string     // The call a tool turn answers
+}
+
+// ToolCall is a model's request to run one of the runtime's tools
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]string
+}
+
+// Usage counts the tokens of a model request
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{InputTokens: u.InputTokens + other.InputTokens, OutputTokens: u.OutputTokens + other.OutputTokens}
+}
+
+// Reply is a model's response to a conversation
+type Reply struct {
+	Content   string
+	ToolCalls []ToolCall
+	Usage     Usage
+}
+
+// ChatClient sends a conversation to a chat model and returns its reply.
+// Tools are offered for native tool calling; nil means text mode.
+type ChatClient interface {
+	Chat(ctx context.Context, messages []Message, tools []Tool) (Reply, error)
+}
+
+// postJSON sends body as JSON to url and decodes the JSON response into
+// out. name identifies the API in errors.
+func postJSON(ctx context.Context, httpClient *http.Client, name, url string, headers map[string]string, body, out interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s API request failed: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s API error (status %d): %s", name, resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", name, err)
+	}
+	return nil
+}
+
+// stringArguments converts decoded tool call arguments to strings
+func stringArguments(args map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(args))
+	for key, value := range args {
+		if s, ok := value.(string); ok {
+			out[key] = s
+		} else {
+			out[key] = fmt.Sprint(value)
+		}
+	}
+	return out
+}
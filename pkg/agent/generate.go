@@ -0,0 +1,262 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
+	"github.com/computerscienceiscool/llm-runtime/pkg/search"
+)
+
+// maxTreeEntries bounds the repository layout included in a generated
+// prompt
+const maxTreeEntries = 40
+
+// GeneratePrompt writes a system prompt describing the runtime exactly as
+// cfg configures it: the commands enabled, their syntax and limits, and the
+// top level of the repository. searchCfg may be nil when search is off.
+func GeneratePrompt(cfg *config.Config, searchCfg *search.SearchConfig) string {
+	var b strings.Builder
+	b.WriteString(`You are working in a repository through a command runtime. Embed commands in
+your reply and they are executed; their results come back in the next message.
+Paths are relative to the repository root.
+
+## Commands
+
+`)
+
+	b.WriteString("<open path>\n  Reads a file. <open path:10-40> reads lines 10 through 40.\n")
+	limit := cfg.MaxFileSize
+	if cfg.MaxChunkedSize > cfg.MaxFileSize {
+		fmt.Fprintf(&b, "  Files larger than %s are shown a chunk at a time, each ending with the\n  <open path cursor=...> that reads the next.",
+			formatSize(cfg.MaxFileSize))
+		limit = cfg.MaxChunkedSize
+	}
+	if cfg.TruncateOversize {
+		keep := cfg.TruncateKeep
+		if keep <= 0 {
+			keep = config.DefaultTruncateKeep
+		}
+		fmt.Fprintf(&b, " Files larger than %s show only their first and last %s.", formatSize(limit), formatSize(keep))
+	} else {
+		fmt.Fprintf(&b, " Files larger than %s cannot be opened.", formatSize(limit))
+	}
+	if !cfg.AllowBinary {
+		b.WriteString(" Binary files are summarized instead of shown.")
+	}
+	if cfg.SkipRepeatOpens {
+		b.WriteString("\n  Opening content you already have, unchanged, returns a short notice instead;\n  <open path force> returns it again.")
+	}
+	b.WriteString("\n\n")
+
+	maxFiles := cfg.OpenManyMaxFiles
+	if maxFiles <= 0 {
+		maxFiles = config.DefaultOpenManyMaxFiles
+	}
+	fmt.Fprintf(&b, "<open-many pattern max_files=N>\n  Opens the files matching a glob, such as internal/parser/*.go, each under a\n  ===== path ===== line. ** matches any number of directories. At most %d\n  files are opened; the rest are listed at the end.\n\n", maxFiles)
+	b.WriteString("<tail path lines=N>\n  Shows the last N lines of a file (10 without lines=), such as a build log.\n\n")
+	b.WriteString("<unzip archive dest> and <archive dest source>\n  Extract or create a .zip, .tar, or .tar.gz archive. Extracted files must have\n  an allowed extension.\n\n")
+	b.WriteString("<overview depth=N>\n  Summarizes the repository: a directory tree N levels deep (2 without depth=),\n  languages, modules and packages, entry points, and the start of the README.\n\n")
+	b.WriteString("<deps package>\n  Shows a Go package's imports and every package that imports it, directly or\n  not. <deps> alone shows the module's import graph and external modules.\n\n")
+	if len(cfg.LanguageServers) > 0 {
+		b.WriteString("<def symbol> and <refs symbol>\n  Find where a symbol such as Session.LogAudit is defined and everywhere it is\n  used, from a language server. A position such as pkg/app/app.go:120:5 works too.\n\n")
+		b.WriteString("<rename-symbol old=NAME new=NAME scope=./...>\n  Renames a symbol everywhere it is used, as one change to every file it is in.\n  Prefer it to editing each use with <write>.\n\n")
+	}
+	b.WriteString("<ast-grep pattern path=DIR>\n  Finds Go code by structure, such as RunContainer($CFG) or if err != nil { $$$ }.\n  $X matches any one expression, $$$ any number; comments and spacing are ignored.\n\n")
+	b.WriteString("<tokens path>\n  Estimates the tokens of a file, a range such as main.go:10-20, or a directory,\n  listing its largest files. Use it before opening something large.\n\n")
+	if cfg.Memory {
+		b.WriteString("<note text> and <memory>\n  <note> records a decision or finding in the session memory, beside the files\n  each session changed; <memory> reads it back. Read it when resuming work.\n\n")
+	}
+	b.WriteString("<coverage profile>\n  Summarizes a Go coverage profile or lcov file by package, with the change\n  since it was last read. <coverage> alone reads coverage.out or lcov.info.\n\n")
+	b.WriteString("<outline path>\n  Lists the imports, types, and function signatures of a Go, Python, or Java\n  file with their line numbers, so you can open just the lines you need.\n\n")
+	b.WriteString("<hash path>\n  Returns the sha256 digest of a file; add algo=md5, sha1, or sha512 for another.\n\n")
+
+	fmt.Fprintf(&b, "<write path>content</write>\n  Creates or replaces a file with the complete content given, up to %s.\n", formatSize(cfg.MaxWriteSize))
+	if len(cfg.AllowedExtensions) > 0 {
+		fmt.Fprintf(&b, "  Allowed extensions: %s\n", strings.Join(cfg.AllowedExtensions, ", "))
+	}
+	if cfg.SyntaxCheck == config.SyntaxCheckReject {
+		b.WriteString("  Go, JSON, and YAML files with syntax errors are rejected.\n")
+	}
+	if cfg.ConflictCheck && !cfg.ForceWrite {
+		b.WriteString("  Writes to files changed on disk since you opened them are rejected; open them again first.\n")
+	}
+	if len(cfg.AllowedModes) > 0 {
+		fmt.Fprintf(&b, "  Files keep their permissions; set one with mode=, such as <write run.sh mode=0755>.\n  Allowed modes: %s\n", strings.Join(cfg.AllowedModes, ", "))
+	}
+	b.WriteString("  If the content contains </write>, put a delimiter after the path and end the\n  content with a line holding only the delimiter: <write notes.md EOF> ... EOF\n\n")
+
+	b.WriteString("<append path>content</append>\n  Adds the lines to the end of a file, creating it if needed.\n")
+	if len(cfg.AppendOnlyPaths) > 0 {
+		fmt.Fprintf(&b, "  These files may only grow; add to them with <append>: %s\n", strings.Join(cfg.AppendOnlyPaths, ", "))
+	}
+	b.WriteString("\n")
+
+	if cfg.Scratch {
+		b.WriteString("/scratch\n  A directory outside the repository for intermediate files that should not be\n  committed. <open>, <write>, and <append> reach it as /scratch/PATH, with any\n  extension.")
+		if cfg.ScratchEncrypt {
+			b.WriteString(" <exec> commands get an empty /scratch of their own each time.")
+		} else {
+			b.WriteString(" <exec> commands see the same files.")
+		}
+		b.WriteString(" It is deleted when the session ends.\n\n")
+	}
+
+	if len(cfg.ExecWhitelist) > 0 {
+		network := "without network access"
+		if cfg.ExecNetworkEnabled {
+			network = "with network access"
+		}
+		fmt.Fprintf(&b, "<exec command args>\n  Runs a command in a sandboxed container %s, timing out after %s.\n", network, cfg.ExecTimeout)
+		fmt.Fprintf(&b, "  Allowed commands: %s\n", strings.Join(cfg.ExecWhitelist, ", "))
+		b.WriteString("  Start with dir=PATH to run in a subdirectory: <exec dir=services/api go test ./...>\n\n")
+		b.WriteString("<test target args>\n  Runs the tests of a package, directory, or test file, passing args to its\n  runner, and returns the pass and fail counts and the first failures. <test>\n  alone runs every test.\n\n")
+		b.WriteString("<lint path>\n  Lints a package, directory, or file and lists each issue as\n  file:line:col severity message. <lint> alone lints everything.\n\n")
+	}
+
+	if len(cfg.FetchAllowedDomains) > 0 {
+		fmt.Fprintf(&b, "<fetch url>\n  Downloads a text, JSON, YAML, or XML document of up to %s. Add dest=PATH\n  to save it to a file.\n", formatSize(cfg.FetchMaxSize))
+		fmt.Fprintf(&b, "  Allowed hosts: %s\n\n", strings.Join(cfg.FetchAllowedDomains, ", "))
+	}
+
+	if len(cfg.SQLDatabases) > 0 {
+		names := make([]string, 0, len(cfg.SQLDatabases))
+		for name := range cfg.SQLDatabases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "<sql name=NAME statement>\n  Runs one statement, SELECT unless a database allows more, returning up to\n  %d rows as a markdown table.\n", cfg.SQLMaxRows)
+		fmt.Fprintf(&b, "  Databases: %s\n", strings.Join(names, ", "))
+		b.WriteString("  The tag ends at the first >, so write 5 < age rather than age > 5.\n\n")
+	}
+
+	if cfg.GitWrite {
+		b.WriteString("<git-branch NAME>\n  Creates a branch at the current commit and switches to it, keeping your\n  uncommitted changes.\n\n")
+		b.WriteString("<git-commit MESSAGE>\n  Commits every change you made to the current branch with MESSAGE, which ends\n  at the first >.\n")
+		if len(cfg.GitProtected) > 0 {
+			fmt.Fprintf(&b, "  Protected branches, never committed to: %s\n", strings.Join(cfg.GitProtected, ", "))
+		}
+		b.WriteString("\n")
+		b.WriteString("<pr TITLE>description</pr>\n  Pushes the current branch and opens a pull request of it titled TITLE. A\n  summary of the session is added to the description. Commit first, since\n  uncommitted changes are not pushed.\n\n")
+	}
+
+	if cfg.Issues {
+		fmt.Fprintf(&b, "<issue NUMBER>\n  Shows the title, description, and up to %d most recent comments of an issue\n  of this repository, so you can work from it.\n\n", cfg.IssueComments)
+	}
+
+	if len(cfg.REPLLanguages) > 0 {
+		fmt.Fprintf(&b, "<repl LANGUAGE>code</repl>\n  Runs a snippet in an interpreter that keeps variables and imports between\n  snippets, timing out after %s. Languages: %s\n", cfg.REPLTimeout, strings.Join(cfg.REPLLanguages, ", "))
+		b.WriteString("  <repl-reset> discards every interpreter's state.\n\n")
+	}
+
+	if searchCfg != nil && searchCfg.Enabled {
+		fmt.Fprintf(&b, "<search query>\n  Finds up to %d files related to a concept by meaning, not just keywords.\n\n", searchCfg.MaxResults)
+	}
+
+	b.WriteString(`<set name=NAME value=VALUE>
+  Defines ${NAME} for later commands. ${REPO_ROOT}, ${SESSION_ID}, and ${DATE}
+  are predefined.
+
+<pipe>...</pipe>
+  Runs the commands inside in order; $1, $2, ... and $last in a step's content
+  are replaced by the output of earlier steps.
+
+<if-success>...</if-success> and <if-failure>...</if-failure>
+  Run the commands inside only if the previous command succeeded or failed.
+
+Commands inside code fences or inline code are not run, so you can quote them
+safely. A malformed command is reported as PARSE_ERROR and nothing runs.
+`)
+
+	b.WriteString("\n## Limits\n\n")
+	if len(cfg.ExcludedPaths) > 0 {
+		fmt.Fprintf(&b, "- These paths cannot be read or written: %s\n", strings.Join(cfg.ExcludedPaths, ", "))
+	}
+	if cfg.RespectIgnoreFiles {
+		b.WriteString("- Files matched by .gitignore or .llmignore cannot be opened\n")
+	}
+	if len(cfg.ExecWhitelist) == 0 {
+		b.WriteString("- Running commands is disabled\n")
+	}
+	if searchCfg == nil || !searchCfg.Enabled {
+		b.WriteString("- Search is disabled\n")
+	}
+	if cfg.OutputBudget > 0 {
+		fmt.Fprintf(&b, "- Results are limited to about %d tokens per reply; long files are cut short with\n  a note giving the line range to open next\n", cfg.OutputBudget)
+	}
+
+	b.WriteString("\n## Repository\n\n")
+	b.WriteString(repoTree(cfg))
+
+	b.WriteString(`
+## Finishing
+
+Work in small steps: read what you need, make a change, check the result, and
+only then move on. When the task is complete, reply with a short summary and
+no commands.
+`)
+	return b.String()
+}
+
+// repoTree lists the top level of the repository, directories first, leaving
+// out excluded and ignored entries
+func repoTree(cfg *config.Config) string {
+	entries, err := os.ReadDir(cfg.RepositoryRoot)
+	if err != nil {
+		return "(the repository could not be listed)\n"
+	}
+
+	excluded := ignore.CompilePatterns(cfg.ExcludedPaths)
+	var matcher *ignore.Matcher
+	if cfg.RespectIgnoreFiles {
+		matcher = ignore.NewMatcher(cfg.RepositoryRoot)
+	}
+
+	var dirs, files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, _, ok := excluded.Match(name, entry.IsDir()); ok {
+			continue
+		}
+		if matcher != nil && matcher.Match(name, entry.IsDir()) {
+			continue
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, name+"/")
+		} else {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+	names := append(dirs, files...)
+	if len(names) == 0 {
+		return "The repository is empty.\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Top level of %s:\n", filepath.Base(cfg.RepositoryRoot))
+	for i, name := range names {
+		if i == maxTreeEntries {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(names)-maxTreeEntries)
+			break
+		}
+		fmt.Fprintf(&b, "  %s\n", name)
+	}
+	return b.String()
+}
+
+// formatSize renders a byte limit
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/search"
+)
+
+func TestGeneratePrompt(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"cmd", "pkg", "node_modules", ".git"} {
+		os.Mkdir(filepath.Join(root, dir), 0755)
+	}
+	for _, file := range []string{"go.mod", "README.md", ".env", ".gitignore"} {
+		os.WriteFile(filepath.Join(root, file), nil, 0644)
+	}
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("node_modules/\n"), 0644)
+
+	cfg := &config.Config{
+		RepositoryRoot:     root,
+		MaxFileSize:        1 << 20,
+		MaxWriteSize:       100 << 10,
+		ExcludedPaths:      []string{".git", ".env"},
+		RespectIgnoreFiles: true,
+		AllowedExtensions:  []string{".go", ".md"},
+		ExecWhitelist:      []string{"go test", "go build"},
+		ExecTimeout:        30 * time.Second,
+		OutputBudget:       8000,
+	}
+	prompt := GeneratePrompt(cfg, &search.SearchConfig{Enabled: true, MaxResults: 5})
+
+	for _, want := range []string{
+		"Files larger than 1 MB cannot be opened",
+		"up to 100 KB",
+		"Allowed extensions: .go, .md",
+		"Allowed commands: go test, go build",
+		"timing out after 30s",
+		"Finds up to 5 files",
+		"cannot be read or written: .git, .env",
+		"about 8000 tokens per reply",
+		"  cmd/\n  pkg/\n  .gitignore\n  README.md\n  go.mod\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	for _, unwanted := range []string{"node_modules", "  .env", "  .git/", "disabled"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("prompt should not contain %q:\n%s", unwanted, prompt)
+		}
+	}
+}
+
+func TestGeneratePrompt_Disabled(t *testing.T) {
+	cfg := &config.Config{RepositoryRoot: t.TempDir(), MaxFileSize: 1000, MaxWriteSize: 1000}
+	prompt := GeneratePrompt(cfg, nil)
+
+	if strings.Contains(prompt, "<exec") || strings.Contains(prompt, "<search") {
+		t.Errorf("disabled commands are described:\n%s", prompt)
+	}
+	for _, want := range []string{"Running commands is disabled", "Search is disabled", "1000 bytes", "The repository is empty."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package agent drives a chat model in a loop against the runtime: the
+// commands in the model's reply, or its tool calls, are executed and the
+// results are sent back as the next message until the model is done or a
+// budget runs out. Ollama, OpenAI, and Anthropic models are supported.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Stop reasons reported in an Outcome
+const (
+	StopCompleted   = "completed"             // The model replied without commands or tool calls
+	StopMaxTurns    = "turn limit reached"    // Limits.MaxTurns replies were received
+	StopMaxCommands = "command limit reached" // Limits.MaxCommands commands were executed
+	StopTimeout     = "time limit reached"    // The context deadline passed
+	StopInterrupted = "interrupted"           // The context was canceled, as on Ctrl+C
+)
+
+// Executor runs the commands in a model reply and returns the result
+// blocks to send back and the number of commands run
+type Executor func(reply string) (results string, commands int)
+
+// CommandExecutor runs a single command, from a tool call, and returns its
+// result block
+type CommandExecutor func(cmd scanner.Command) string
+
+// Limits bound an agent run; zero disables a limit
+type Limits struct {
+	MaxTurns    int
+	MaxCommands int
+}
+
+// Loop is a configured agent run. In text mode the commands in each reply
+// are run by Execute; with UseTools the model calls Tools natively and each
+// call is run by ExecuteCommand.
+type Loop struct {
+	Client         ChatClient
+	Execute        Executor
+	ExecuteCommand CommandExecutor
+	StartTurn      func() // Called before each reply's commands run; may be nil
+	UseTools       bool
+	SystemPrompt   string
+	Limits         Limits
+	Pricing        Pricing   // Used to report the cost of the run
+	Transcript     io.Writer // Receives each reply and its results; may be nil
+}
+
+// Outcome summarizes a finished run
+type Outcome struct {
+	Turns    int
+	Commands int
+	Reason   string
+	Final    string // The model's last reply
+	Usage    Usage  // Tokens used over all turns
+	Cost     float64
+}
+
+// Run sends task to the model and loops until it replies without any
+// commands or a limit is reached. Chat errors end the run with an error.
+func (l *Loop) Run(ctx context.Context, task string) (Outcome, error) {
+	var outcome Outcome
+	messages := []Message{{Role: "system", Content: l.SystemPrompt}, {Role: "user", Content: task}}
+	var tools []Tool
+	if l.UseTools {
+		tools = Tools
+	}
+
+	for {
+		if l.Limits.MaxTurns > 0 && outcome.Turns >= l.Limits.MaxTurns {
+			outcome.Reason = StopMaxTurns
+			return outcome, nil
+		}
+
+		reply, err := l.Client.Chat(ctx, messages, tools)
+		if err != nil {
+			if ctx.Err() != nil {
+				outcome.Reason = stopReason(ctx)
+				return outcome, nil
+			}
+			return outcome, fmt.Errorf("chat failed on turn %d: %w", outcome.Turns+1, err)
+		}
+		outcome.Turns++
+		outcome.Final = reply.Content
+		outcome.Usage = outcome.Usage.Add(reply.Usage)
+		outcome.Cost = l.Pricing.Cost(outcome.Usage)
+		messages = append(messages, Message{Role: "assistant", Content: reply.Content, ToolCalls: reply.ToolCalls})
+		l.log("=== MODEL (turn %d, %d in / %d out tokens) ===\n%s\n", outcome.Turns, reply.Usage.InputTokens, reply.Usage.OutputTokens, reply.Content)
+
+		if l.StartTurn != nil {
+			l.StartTurn()
+		}
+		var commands int
+		if l.UseTools {
+			var results []Message
+			results, commands = l.callTools(reply.ToolCalls)
+			messages = append(messages, results...)
+		} else {
+			var results string
+			results, commands = l.Execute(reply.Content)
+			l.log("%s", results)
+			messages = append(messages, Message{Role: "user", Content: results})
+		}
+		if commands == 0 {
+			outcome.Reason = StopCompleted
+			return outcome, nil
+		}
+		outcome.Commands += commands
+
+		if l.Limits.MaxCommands > 0 && outcome.Commands >= l.Limits.MaxCommands {
+			outcome.Reason = StopMaxCommands
+			return outcome, nil
+		}
+		if ctx.Err() != nil {
+			outcome.Reason = stopReason(ctx)
+			return outcome, nil
+		}
+	}
+}
+
+// stopReason is the reason a run ends once ctx is done: its deadline or
+// its cancellation
+func stopReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return StopTimeout
+	}
+	return StopInterrupted
+}
+
+// callTools runs each tool call and returns the tool turns answering them.
+// Calls that do not map onto a command are answered with the error so the
+// model can correct them.
+func (l *Loop) callTools(calls []ToolCall) ([]Message, int) {
+	var results []Message
+	for _, call := range calls {
+		var result string
+		if cmd, err := call.Command(); err != nil {
+			result = "=== ERROR: TOOL_CALL ===\nMessage: " + err.Error() + "\n"
+		} else {
+			result = l.ExecuteCommand(cmd)
+		}
+		l.log("=== TOOL CALL: %s ===\n%s", call.Name, result)
+		results = append(results, Message{Role: "tool", Content: result, ToolCallID: call.ID})
+	}
+	return results, len(calls)
+}
+
+// log writes to the transcript, if there is one
+func (l *Loop) log(format string, args ...interface{}) {
+	if l.Transcript != nil {
+		fmt.Fprintf(l.Transcript, format, args...)
+	}
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// scriptedClient replies with a fixed sequence of messages and records the
+// conversations it was sent
+type scriptedClient struct {
+	replies []Reply
+	seen    [][]Message
+	tools   []Tool
+}
+
+func (c *scriptedClient) Chat(ctx context.Context, messages []Message, tools []Tool) (Reply, error) {
+	c.seen = append(c.seen, append([]Message(nil), messages...))
+	c.tools = tools
+	if len(c.replies) == 0 {
+		return Reply{}, errors.New("no more replies")
+	}
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	return reply, nil
+}
+
+// textReplies scripts plain text replies using ten input and five output
+// tokens each
+func textReplies(texts ...string) []Reply {
+	replies := make([]Reply, len(texts))
+	for i, text := range texts {
+		replies[i] = Reply{Content: text, Usage: Usage{InputTokens: 10, OutputTokens: 5}}
+	}
+	return replies
+}
+
+// countingExecutor treats each "<" in a reply as one command
+func countingExecutor(reply string) (string, int) {
+	n := strings.Count(reply, "<")
+	return strings.Repeat("=== RESULT ===\n", n), n
+}
+
+func TestLoop_Completes(t *testing.T) {
+	client := &scriptedClient{replies: textReplies("<open main.go>", "<write main.go> <exec go test>", "All tests pass.")}
+	var transcript strings.Builder
+	loop := &Loop{Client: client, Execute: countingExecutor, SystemPrompt: "system", Pricing: Pricing{InputPerMTok: 1, OutputPerMTok: 2}, Transcript: &transcript}
+
+	outcome, err := loop.Run(context.Background(), "fix the tests")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if outcome.Reason != StopCompleted || outcome.Turns != 3 || outcome.Commands != 3 || outcome.Final != "All tests pass." {
+		t.Errorf("outcome = %+v", outcome)
+	}
+	if outcome.Usage != (Usage{InputTokens: 30, OutputTokens: 15}) || outcome.Cost != 60.0/1e6 {
+		t.Errorf("usage = %+v, cost = %v", outcome.Usage, outcome.Cost)
+	}
+	if client.tools != nil {
+		t.Errorf("text mode offered tools: %v", client.tools)
+	}
+
+	// The last request carries the whole conversation, results as user turns
+	last := client.seen[2]
+	roles := make([]string, len(last))
+	for i, m := range last {
+		roles[i] = m.Role
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,user,assistant,user" {
+		t.Errorf("roles = %s", got)
+	}
+	if last[5].Content != "=== RESULT ===\n=== RESULT ===\n" {
+		t.Errorf("results message = %q", last[5].Content)
+	}
+	if !strings.Contains(transcript.String(), "=== MODEL (turn 3, 10 in / 5 out tokens) ===\nAll tests pass.") {
+		t.Errorf("transcript missing final reply:\n%s", transcript.String())
+	}
+}
+
+func TestLoop_Limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		reason string
+		turns  int
+	}{
+		{"turns", Limits{MaxTurns: 2}, StopMaxTurns, 2},
+		{"commands", Limits{MaxCommands: 3}, StopMaxCommands, 2},
+	}
+
+	for _, tt := range tests {
+		client := &scriptedClient{replies: textReplies("<a> <b>", "<c> <d>", "<e>", "<f>")}
+		loop := &Loop{Client: client, Execute: countingExecutor, Limits: tt.limits}
+
+		outcome, err := loop.Run(context.Background(), "task")
+		if err != nil {
+			t.Fatalf("%s: Run() error: %v", tt.name, err)
+		}
+		if outcome.Reason != tt.reason || outcome.Turns != tt.turns {
+			t.Errorf("%s: outcome = %+v, want %s after %d turns", tt.name, outcome, tt.reason, tt.turns)
+		}
+	}
+}
+
+func TestLoop_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &scriptedClient{replies: textReplies("<a>", "<b>")}
+	execute := func(reply string) (string, int) {
+		cancel()
+		return countingExecutor(reply)
+	}
+	loop := &Loop{Client: client, Execute: execute}
+
+	outcome, err := loop.Run(ctx, "task")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if outcome.Reason != StopInterrupted || outcome.Turns != 1 {
+		t.Errorf("outcome = %+v, want %s after 1 turn", outcome, StopInterrupted)
+	}
+}
+
+func TestLoop_ChatError(t *testing.T) {
+	loop := &Loop{Client: &scriptedClient{}, Execute: countingExecutor}
+	if _, err := loop.Run(context.Background(), "task"); err == nil || !strings.Contains(err.Error(), "turn 1") {
+		t.Errorf("Run() error = %v, want chat failure on turn 1", err)
+	}
+}
+
+func TestLoop_Tools(t *testing.T) {
+	client := &scriptedClient{replies: []Reply{
+		{ToolCalls: []ToolCall{
+			{ID: "1", Name: "open", Arguments: map[string]string{"path": "main.go"}},
+			{ID: "2", Name: "delete", Arguments: map[string]string{"path": "main.go"}},
+		}},
+		{Content: "Done."},
+	}}
+	var executed []string
+	loop := &Loop{
+		Client:   client,
+		UseTools: true,
+		ExecuteCommand: func(cmd scanner.Command) string {
+			executed = append(executed, cmd.Type+" "+cmd.Argument)
+			return "=== FILE: " + cmd.Argument + " ===\n"
+		},
+	}
+
+	outcome, err := loop.Run(context.Background(), "task")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if outcome.Reason != StopCompleted || outcome.Turns != 2 || outcome.Commands != 2 {
+		t.Errorf("outcome = %+v", outcome)
+	}
+	if len(client.tools) != len(Tools) {
+		t.Errorf("offered %d tools, want %d", len(client.tools), len(Tools))
+	}
+	if len(executed) != 1 || executed[0] != "open main.go" {
+		t.Errorf("executed = %v", executed)
+	}
+
+	// Each call is answered by a tool turn, errors included
+	last := client.seen[1]
+	results := last[len(last)-2:]
+	if results[0].Role != "tool" || results[0].ToolCallID != "1" || results[0].Content != "=== FILE: main.go ===\n" {
+		t.Errorf("first result = %+v", results[0])
+	}
+	if results[1].ToolCallID != "2" || !strings.Contains(results[1].Content, `unknown tool "delete"`) {
+		t.Errorf("second result = %+v", results[1])
+	}
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// OllamaClient is a ChatClient for a local Ollama server
+type OllamaClient struct {
+	URL   string // e.g. http://localhost:11434
+	Model string // e.g. llama3
+	HTTP  *http.Client
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaChatRequest is the request body of Ollama's /api/chat
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []functionTool  `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+// ollamaChatResponse is the non-streaming response of Ollama's /api/chat
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// Chat sends messages to Ollama's chat endpoint and returns the reply
+func (c *OllamaClient) Chat(ctx context.Context, messages []Message, tools []Tool) (Reply, error) {
+	req := ollamaChatRequest{Model: c.Model, Tools: functionTools(tools)}
+	for _, m := range messages {
+		om := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, call := range m.ToolCalls {
+			var tc ollamaToolCall
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = make(map[string]interface{}, len(call.Arguments))
+			for key, value := range call.Arguments {
+				tc.Function.Arguments[key] = value
+			}
+			om.ToolCalls = append(om.ToolCalls, tc)
+		}
+		req.Messages = append(req.Messages, om)
+	}
+
+	var resp ollamaChatResponse
+	if err := postJSON(ctx, c.HTTP, "Ollama", c.URL+"/api/chat", nil, req, &resp); err != nil {
+		return Reply{}, err
+	}
+	if resp.Error != "" {
+		return Reply{}, fmt.Errorf("Ollama API error: %s", resp.Error)
+	}
+
+	reply := Reply{
+		Content: resp.Message.Content,
+		Usage:   Usage{InputTokens: resp.PromptEvalCount, OutputTokens: resp.EvalCount},
+	}
+	// Ollama does not identify tool calls, so number them
+	for i, tc := range resp.Message.ToolCalls {
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i+1),
+			Name:      tc.Function.Name,
+			Arguments: stringArguments(tc.Function.Arguments),
+		})
+	}
+	return reply, nil
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOllamaClient_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad request body: %v", err)
+		}
+		if req.Model != "llama3" || req.Stream || len(req.Messages) != 2 {
+			t.Errorf("request = %+v", req)
+		}
+		if len(req.Tools) != 0 {
+			t.Errorf("text mode sent %d tools", len(req.Tools))
+		}
+		json.NewEncoder(w).Encode(ollamaChatResponse{
+			Message:         ollamaMessage{Role: "assistant", Content: "<open main.go>"},
+			PromptEvalCount: 120,
+			EvalCount:       8,
+		})
+	}))
+	defer server.Close()
+
+	client := &OllamaClient{URL: server.URL, Model: "llama3"}
+	reply, err := client.Chat(context.Background(), []Message{{Role: "system", Content: "s"}, {Role: "user", Content: "u"}}, nil)
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if reply.Content != "<open main.go>" || reply.Usage != (Usage{InputTokens: 120, OutputTokens: 8}) {
+		t.Errorf("reply = %+v", reply)
+	}
+}
+
+func TestOllamaClient_ToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Tools) != len(Tools) || req.Tools[0].Function.Name != "open" {
+			t.Errorf("tools = %+v", req.Tools)
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"exec","arguments":{"command":"go test"}}}]}}`))
+	}))
+	defer server.Close()
+
+	client := &OllamaClient{URL: server.URL, Model: "llama3"}
+	reply, err := client.Chat(context.Background(), []Message{{Role: "user", Content: "u"}}, Tools)
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID != "call_1" || reply.ToolCalls[0].Arguments["command"] != "go test" {
+		t.Errorf("tool calls = %+v", reply.ToolCalls)
+	}
+}
+
+func TestOllamaClient_ChatError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model 'nope' not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &OllamaClient{URL: server.URL, Model: "nope"}
+	if _, err := client.Chat(context.Background(), nil, nil); err == nil {
+		t.Error("expected error for missing model")
+	}
+}
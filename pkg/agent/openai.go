@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// DefaultOpenAIURL is the base URL of the OpenAI API
+const DefaultOpenAIURL = "https://api.openai.com/v1"
+
+// OpenAIClient is a ChatClient for the OpenAI chat completions API, or a
+// compatible server
+type OpenAIClient struct {
+	URL    string // Base URL, e.g. DefaultOpenAIURL
+	APIKey string
+	Model  string // e.g. gpt-4o-mini
+	HTTP   *http.Client
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content,omitempty"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded object
+	} `json:"function"`
+}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Tools    []functionTool  `json:"tools,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Chat sends messages to the chat completions endpoint and returns the
+// reply
+func (c *OpenAIClient) Chat(ctx context.Context, messages []Message, tools []Tool) (Reply, error) {
+	req := openAIRequest{Model: c.Model, Tools: functionTools(tools)}
+	for _, m := range messages {
+		om := openAIMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+		for _, call := range m.ToolCalls {
+			args, err := json.Marshal(call.Arguments)
+			if err != nil {
+				return Reply{}, fmt.Errorf("failed to marshal tool call: %w", err)
+			}
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = string(args)
+			om.ToolCalls = append(om.ToolCalls, tc)
+		}
+		req.Messages = append(req.Messages, om)
+	}
+
+	var resp openAIResponse
+	headers := map[string]string{"Authorization": "Bearer " + c.APIKey}
+	if err := postJSON(ctx, c.HTTP, "OpenAI", c.URL+"/chat/completions", headers, req, &resp); err != nil {
+		return Reply{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Reply{}, fmt.Errorf("OpenAI API returned no choices")
+	}
+
+	message := resp.Choices[0].Message
+	reply := Reply{
+		Content: message.Content,
+		Usage:   Usage{InputTokens: resp.Usage.PromptTokens, OutputTokens: resp.Usage.CompletionTokens},
+	}
+	for _, tc := range message.ToolCalls {
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+			return Reply{}, fmt.Errorf("invalid arguments for tool %s: %w", tc.Function.Name, err)
+		}
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: stringArguments(args)})
+	}
+	return reply, nil
+}
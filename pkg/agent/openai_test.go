@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIClient_ToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %s, want /chat/completions", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad request body: %v", err)
+		}
+		if req.Model != "gpt-4o-mini" || len(req.Tools) != len(Tools) {
+			t.Errorf("request = %+v", req)
+		}
+		// The earlier call is sent back with JSON-encoded arguments
+		if len(req.Messages) != 3 || req.Messages[1].ToolCalls[0].Function.Arguments != `{"path":"main.go"}` || req.Messages[2].ToolCallID != "call_a" {
+			t.Errorf("messages = %+v", req.Messages)
+		}
+		w.Write([]byte(`{
+			"choices": [{"message": {"role": "assistant", "tool_calls": [
+				{"id": "call_b", "type": "function", "function": {"name": "exec", "arguments": "{\"command\":\"go test ./...\"}"}}
+			]}}],
+			"usage": {"prompt_tokens": 900, "completion_tokens": 40}
+		}`))
+	}))
+	defer server.Close()
+
+	client := &OpenAIClient{URL: server.URL, APIKey: "sk-test", Model: "gpt-4o-mini"}
+	messages := []Message{
+		{Role: "user", Content: "run the tests"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_a", Name: "open", Arguments: map[string]string{"path": "main.go"}}}},
+		{Role: "tool", Content: "=== FILE: main.go ===", ToolCallID: "call_a"},
+	}
+	reply, err := client.Chat(context.Background(), messages, Tools)
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID != "call_b" || reply.ToolCalls[0].Arguments["command"] != "go test ./..." {
+		t.Errorf("tool calls = %+v", reply.ToolCalls)
+	}
+	if reply.Usage != (Usage{InputTokens: 900, OutputTokens: 40}) {
+		t.Errorf("usage = %+v", reply.Usage)
+	}
+}
+
+func TestOpenAIClient_ChatError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"Incorrect API key provided"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &OpenAIClient{URL: server.URL, APIKey: "bad", Model: "gpt-4o-mini"}
+	if _, err := client.Chat(context.Background(), nil, nil); err == nil {
+		t.Error("expected error for rejected key")
+	}
+}
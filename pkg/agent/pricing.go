@@ -0,0 +1,42 @@
+package agent
+
+import "strings"
+
+// Pricing is the price of a model in US dollars per million tokens
+type Pricing struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+// knownPricing lists list prices of common hosted models, matched by model
+// name prefix. Prices change; override them with flags when they matter.
+var knownPricing = map[string]Pricing{
+	"gpt-4o-mini":       {0.15, 0.60},
+	"gpt-4o":            {2.50, 10.00},
+	"gpt-4.1-mini":      {0.40, 1.60},
+	"gpt-4.1":           {2.00, 8.00},
+	"claude-3-5-haiku":  {0.80, 4.00},
+	"claude-3-5-sonnet": {3.00, 15.00},
+	"claude-3-7-sonnet": {3.00, 15.00},
+	"claude-3-opus":     {15.00, 75.00},
+}
+
+// PricingFor returns the known price of model, using the longest matching
+// name prefix
+func PricingFor(model string) (Pricing, bool) {
+	best := ""
+	for prefix := range knownPricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Pricing{}, false
+	}
+	return knownPricing[best], true
+}
+
+// Cost returns the price in US dollars of the given usage
+func (p Pricing) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.InputPerMTok + float64(u.OutputTokens)*p.OutputPerMTok) / 1e6
+}
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// summarizePrompt instructs the model condensing command output
+const summarizePrompt = `You condense the output of a command run by a coding agent, which cannot
+fit all of it in its context. Keep what the agent needs to act: errors and
+failures with their file names and line numbers, counts and totals, and
+anything unusual. Drop repetition and routine lines. Reply with the summary
+only, in plain text, in at most %d words.`
+
+// Summarizer condenses oversized command output with a chat model, usually
+// a small local one served by Ollama
+type Summarizer struct {
+	Client ChatClient
+}
+
+// Summarize returns a summary of the output of command, in about maxTokens
+// tokens
+func (s *Summarizer) Summarize(ctx context.Context, command, output string, maxTokens int) (string, error) {
+	// About three words to four tokens
+	words := max(maxTokens*3/4, 50)
+	messages := []Message{
+		{Role: "system", Content: fmt.Sprintf(summarizePrompt, words)},
+		{Role: "user", Content: fmt.Sprintf("Output of %s:\n\n%s", command, output)},
+	}
+	reply, err := s.Client.Chat(ctx, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(reply.Content)
+	if summary == "" {
+		return "", fmt.Errorf("the model returned an empty summary")
+	}
+	return summary, nil
+}
@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSummarizer(t *testing.T) {
+	client := &scriptedClient{replies: textReplies("  3 tests failed in pkg/app.\n", "")}
+	s := &Summarizer{Client: client}
+
+	summary, err := s.Summarize(context.Background(), "<exec go test ./...>", "--- FAIL: TestA\n", 400)
+	if err != nil || summary != "3 tests failed in pkg/app." {
+		t.Fatalf("Summarize() = %q, %v", summary, err)
+	}
+	sent := client.seen[0]
+	if !strings.Contains(sent[0].Content, "at most 300 words") || !strings.Contains(sent[1].Content, "Output of <exec go test ./...>:\n\n--- FAIL: TestA") {
+		t.Errorf("sent %+v", sent)
+	}
+	if client.tools != nil {
+		t.Errorf("tools = %v, want none", client.tools)
+	}
+
+	if _, err := s.Summarize(context.Background(), "<exec ls>", "x", 400); err == nil {
+		t.Error("Summarize() with an empty reply succeeded")
+	}
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// ToolParam is a string parameter of a tool
+type ToolParam struct {
+	Name        string
+	Description string
+}
+
+// Tool is a runtime command offered to the model for native tool calling
+type Tool struct {
+	Name        string
+	Description string
+	Params      []ToolParam // All required
+}
+
+// Tools are the runtime commands offered in tool-calling mode. Each call
+// maps directly onto the command of the same name.
+var Tools = []Tool{
+	{
+		Name:        "open",
+		Description: "Read a file from the repository",
+		Params:      []ToolParam{{"path", "File path relative to the repository root, optionally with a line range such as main.go:10-40"}},
+	},
+	{
+		Name:        "write",
+		Description: "Create or replace a file in the repository",
+		Params: []ToolParam{
+			{"path", "File path relative to the repository root"},
+			{"content", "Complete new content of the file"},
+		},
+	},
+	{
+		Name:        "exec",
+		Description: "Run a whitelisted command in a sandboxed container at the repository root",
+		Params:      []ToolParam{{"command", "Command line to run, e.g. go test ./..."}},
+	},
+	{
+		Name:        "search",
+		Description: "Find files related to a concept by semantic search",
+		Params:      []ToolParam{{"query", "What to look for"}},
+	},
+}
+
+// schema returns the JSON Schema of the tool's arguments
+func (t Tool) schema() map[string]interface{} {
+	properties := make(map[string]interface{}, len(t.Params))
+	required := make([]string, 0, len(t.Params))
+	for _, p := range t.Params {
+		properties[p.Name] = map[string]interface{}{"type": "string", "description": p.Description}
+		required = append(required, p.Name)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+}
+
+// functionTool is a tool in the function-calling format shared by the
+// OpenAI and Ollama APIs
+type functionTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+func functionTools(tools []Tool) []functionTool {
+	var out []functionTool
+	for _, tool := range tools {
+		ft := functionTool{Type: "function"}
+		ft.Function.Name = tool.Name
+		ft.Function.Description = tool.Description
+		ft.Function.Parameters = tool.schema()
+		out = append(out, ft)
+	}
+	return out
+}
+
+// Command converts a tool call into the runtime command it stands for
+func (c ToolCall) Command() (scanner.Command, error) {
+	var tool *Tool
+	for i := range Tools {
+		if Tools[i].Name == c.Name {
+			tool = &Tools[i]
+		}
+	}
+	if tool == nil {
+		return scanner.Command{}, fmt.Errorf("unknown tool %q", c.Name)
+	}
+	for _, p := range tool.Params {
+		if _, ok := c.Arguments[p.Name]; !ok {
+			return scanner.Command{}, fmt.Errorf("tool %s is missing argument %q", c.Name, p.Name)
+		}
+	}
+
+	cmd := scanner.Command{Type: c.Name}
+	switch c.Name {
+	case "open", "write":
+		cmd.Argument = c.Arguments["path"]
+		cmd.Content = c.Arguments["content"]
+	case "exec":
+		cmd.Argument = c.Arguments["command"]
+	case "search":
+		cmd.Argument = c.Arguments["query"]
+	}
+	return cmd, nil
+}
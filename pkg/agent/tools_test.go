@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToolCall_Command(t *testing.T) {
+	tests := []struct {
+		call     ToolCall
+		wantType string
+		wantArg  string
+		wantErr  bool
+	}{
+		{ToolCall{Name: "open", Arguments: map[string]string{"path": "main.go"}}, "open", "main.go", false},
+		{ToolCall{Name: "write", Arguments: map[string]string{"path": "a.txt", "content": "hi"}}, "write", "a.txt", false},
+		{ToolCall{Name: "exec", Arguments: map[string]string{"command": "go test"}}, "exec", "go test", false},
+		{ToolCall{Name: "search", Arguments: map[string]string{"query": "auth"}}, "search", "auth", false},
+		{ToolCall{Name: "write", Arguments: map[string]string{"path": "a.txt"}}, "", "", true},
+		{ToolCall{Name: "rm", Arguments: map[string]string{"path": "a.txt"}}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		cmd, err := tt.call.Command()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.call.Name, err, tt.wantErr)
+			continue
+		}
+		if cmd.Type != tt.wantType || cmd.Argument != tt.wantArg {
+			t.Errorf("%s: command = %+v", tt.call.Name, cmd)
+		}
+		if tt.call.Name == "write" && !tt.wantErr && cmd.Content != "hi" {
+			t.Errorf("write content = %q", cmd.Content)
+		}
+	}
+}
+
+func TestPricingFor(t *testing.T) {
+	p, ok := PricingFor("gpt-4o-mini-2024-07-18")
+	if !ok || p.InputPerMTok != 0.15 {
+		t.Errorf("PricingFor(gpt-4o-mini-...) = %+v, %v; want the gpt-4o-mini price", p, ok)
+	}
+	if _, ok := PricingFor("llama3"); ok {
+		t.Error("local models should have no known price")
+	}
+
+	cost := Pricing{InputPerMTok: 3, OutputPerMTok: 15}.Cost(Usage{InputTokens: 1_000_000, OutputTokens: 100_000})
+	if math.Abs(cost-4.5) > 1e-9 {
+		t.Errorf("Cost = %v, want 4.5", cost)
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// ExecuteReply runs the commands in a model reply, as pipe mode would if
+// the reply were its input, and returns the result blocks and the number
+// of commands run
+func (a *App) ExecuteReply(reply string) (string, int) {
+	if a.transcript != nil {
+		a.transcript.Input(reply)
+	}
+	sc := scanner.NewScanner(bufio.NewReader(strings.NewReader(reply)), false)
+	sc.SetMaxCommandSize(int(a.config.MaxCommandSize))
+	sc.SetStrict(a.config.StrictParsing)
+	sc.SetParseErrors(a.config.ParseErrors)
+
+	var out strings.Builder
+	commands := 0
+	for {
+		cmd := sc.Scan()
+		if cmd == nil || a.interrupted() {
+			break
+		}
+		commands++
+		out.WriteString(a.ExecuteCommand(*cmd))
+	}
+	return out.String(), commands
+}
+
+// ExecuteCommand runs a single command, such as one from a model's tool
+// call, and returns its result block
+func (a *App) ExecuteCommand(cmd scanner.Command) string {
+	a.transcribe(cmd)
+	a.snapshot(cmd)
+	result := a.executor.Execute(cmd)
+	a.record(result)
+
+	var out strings.Builder
+	writeResult(&out, cmd, result, a.executor.GetCommandsRun(), a.elapsed())
+	return out.String()
+}
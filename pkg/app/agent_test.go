@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestApp_ExecuteReply(t *testing.T) {
+	app, err := Bootstrap(dockerlessConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+
+	// Prose around the commands is skipped, and each command gets its own
+	// result block
+	results, commands := app.ExecuteReply("I'll check the config first.\n<write .env>SECRET=1</write>\nThen <write .git/config>x</write>")
+	if commands != 2 {
+		t.Errorf("commands = %d, want 2", commands)
+	}
+	if strings.Count(results, "=== LLM TOOL START ===") != 2 || !strings.Contains(results, "=== ERROR: PATH_SECURITY ===") {
+		t.Errorf("unexpected results:\n%s", results)
+	}
+
+	if _, commands := app.ExecuteReply("All done, no further changes needed."); commands != 0 {
+		t.Errorf("commands = %d for a reply without commands, want 0", commands)
+	}
+}
+
+func TestApp_ExecuteReplyInterrupted(t *testing.T) {
+	app, err := Bootstrap(dockerlessConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	app.SetContext(ctx)
+
+	if results, commands := app.ExecuteReply("<write .env>SECRET=1</write>"); commands != 0 || results != "" {
+		t.Errorf("ExecuteReply() after interrupt ran %d commands:\n%s", commands, results)
+	}
+}
@@ -2,32 +2,90 @@ package app
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
 	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
 	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/notify"
+	"github.com/computerscienceiscool/llm-runtime/pkg/objrepo"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
 	"github.com/computerscienceiscool/llm-runtime/pkg/search"
 	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+	"github.com/computerscienceiscool/llm-runtime/pkg/transcript"
+	"github.com/computerscienceiscool/llm-runtime/pkg/usage"
+	"github.com/computerscienceiscool/llm-runtime/pkg/worktree"
 )
 
 // App represents the main application
 type App struct {
-	config    *config.Config
-	session   *session.Session
-	executor  *evaluator.Executor
-	searchCfg *search.SearchConfig
-	pool      *sandbox.ContainerPool
+	config     *config.Config
+	session    *session.Session
+	executor   *evaluator.Executor
+	searchCfg  *search.SearchConfig
+	pool       *sandbox.ContainerPool
+	loadConfig ConfigLoader         // Rebuilds the config on reload; nil disables reload
+	watcher    *configWatcher       // Config files checked for changes before each command
+	history    []historyEntry       // Commands run this session, for :history and :stats
+	undo       []undoEntry          // Writes that :undo can revert, oldest first
+	transcript *transcript.Recorder // Records input, commands, and results; may be nil
+	metrics    *http.Server         // Serves Prometheus metrics; may be nil
+	pprof      *http.Server         // Serves profiling endpoints; may be nil
+	originals  map[string]*string   // Content of written files before the session; nil if absent
+	backups    []string             // Backups taken by writes, for the report
+	execTime   time.Duration        // Time spent in exec commands
+	containers int64                // Containers started without a pool
+	cpuSeconds float64              // CPU time containers were allowed, for the usage ledger
+	written    int64                // Bytes written by commands, for the usage ledger
+	model      string               // Model of an agent session, for the usage ledger
+	tokensIn   int                  // Input tokens of the agent's model
+	tokensOut  int                  // Output tokens of the agent's model
+	cost       float64              // What the model's tokens cost, in dollars
+	ctx        context.Context      // Canceled to interrupt the session; nil until SetContext
+	objects    *objrepo.Repo        // Object store prefix the repository root is a copy of; nil for a local root
+	worktree   *worktree.Worktree   // Worktree the session runs in; nil when it runs in the repository itself
+	scratch    *scratch.Space       // The session's /scratch; nil without --scratch
+	notifier   *notify.Notifier     // Posts notable events; nil without notification targets
+	waiting    atomic.Bool          // scanInput is blocked reading input
+}
+
+// ErrInterrupted is returned by RunContext when its context is canceled,
+// as on SIGINT or SIGTERM
+var ErrInterrupted = errors.New("interrupted")
+
+// SetContext sets the context the session runs in. Canceling it stops the
+// command running and any commands after it.
+func (a *App) SetContext(ctx context.Context) {
+	a.ctx = ctx
+	a.executor.SetContext(ctx)
+}
+
+// interrupted reports whether the session's context has been canceled
+func (a *App) interrupted() bool {
+	return a.ctx != nil && a.ctx.Err() != nil
 }
 
 // Run executes the application based on configuration
 func (a *App) Run() error {
-	if a.config.Verbose {
+	return a.RunContext(context.Background())
+}
+
+// RunContext is Run until ctx is canceled. The command running then is
+// stopped and its result written before RunContext returns
+// ErrInterrupted; input not yet read is ignored.
+func (a *App) RunContext(ctx context.Context) error {
+	a.SetContext(ctx)
+	if a.config.Verbose && !a.config.Quiet {
 		a.printVerboseInfo()
 	}
 
@@ -53,93 +111,282 @@ func (a *App) Run() error {
 		output = file
 	}
 
-	a.scanInput(a.executor, a.session.StartTime, a.config.Interactive, input, output)
+	// Input is read on its own goroutine, since a read from a terminal
+	// cannot be interrupted
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.scanInput(a.executor, a.config.Interactive, input, output)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if !a.config.Quiet {
+			fmt.Fprintln(os.Stderr, "Interrupted, stopping...")
+		}
+		// A command in progress is stopped by ctx and its result
+		// written; a pending read is abandoned
+		if !a.waiting.Load() {
+			select {
+			case <-done:
+			case <-time.After(sandbox.TimeoutGracePeriod + 10*time.Second):
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
 	return nil
 }
 
 // scanInput handles continuous input/output using state machine scanner
-func (a *App) scanInput(exec *evaluator.Executor, startTime time.Time, showPrompts bool, input io.Reader, output io.Writer) {
+func (a *App) scanInput(exec *evaluator.Executor, showPrompts bool, input io.Reader, output io.Writer) {
+	if a.transcript != nil {
+		input = a.transcript.Reader(input)
+	}
 	reader := bufio.NewReader(input)
 	sc := scanner.NewScanner(reader, showPrompts)
-
+	sc.SetMaxCommandSize(int(a.config.MaxCommandSize))
+	sc.SetStrict(a.config.StrictParsing)
+	sc.SetParseErrors(a.config.ParseErrors)
+	color := useColor(a.config, output)
 	if showPrompts {
+		// Someone is watching, so <tail follow=...> can stream to them
+		exec.SetFollowOutput(os.Stderr)
+	}
+
+	if showPrompts && !a.config.Quiet {
 		fmt.Fprintln(os.Stderr, "LLM Tool - Interactive Mode")
 		fmt.Fprintln(os.Stderr, "Waiting for input (send EOF with Ctrl+D to process)...")
-		fmt.Fprintln(os.Stderr, "Supports commands: <open filepath>, <write filepath>content</write>, <exec command args>, <search query>")
+		fmt.Fprintln(os.Stderr, "Supports commands: <open filepath>, <open-many pattern max_files=N>, <write filepath>content</write>, <append filepath>content</append>, <exec command args>, <search query>, <tail filepath lines=N follow=DURATION>, <hash filepath algo=sha256>, <outline filepath>, <overview>, <deps package>, <def symbol>, <refs symbol>, <rename-symbol old=NAME new=NAME>, <ast-grep pattern>, <tokens path>, <note text>, <memory>, <test target args>, <lint path>, <coverage profile>, <unzip archive dest>, <archive dest source>, <fetch url>, <sql name=NAME statement>, <git-branch name>, <git-commit message>, <pr title>description</pr>, <issue number>, <repl python>code</repl>, <set name=NAME value=VALUE>")
+		fmt.Fprintln(os.Stderr, "Type :help on its own line for session commands such as :history, :undo, and :quit")
 	}
 
 	for {
+		// Set before the check, so an interrupt either sees the read
+		// pending or the loop sees the interrupt; either way nothing is
+		// written once RunContext may have returned
+		a.waiting.Store(true)
+		if a.interrupted() {
+			return
+		}
 		cmd := sc.Scan()
+		a.waiting.Store(false)
 		if cmd == nil {
 			break
 		}
+		if a.interrupted() {
+			return
+		}
+		a.transcribe(*cmd)
+
+		if cmd.Type == "meta" {
+			if a.runMeta(cmd.Argument, sc, os.Stderr) {
+				break
+			}
+			continue
+		}
+		if a.watcher != nil && a.watcher.changed() {
+			a.reloadConfig(sc)
+		}
+
+		// Each interactive command is answered on its own, so gets the
+		// whole output budget; piped input is one turn
+		if showPrompts {
+			exec.StartTurn()
+		}
 
 		// Execute the command
+		a.snapshot(*cmd)
 		result := exec.Execute(*cmd)
+		a.record(result)
 
-		// Print result directly - no intermediate formatting function
-		fmt.Fprint(output, "=== LLM TOOL START ===\n")
-		fmt.Fprintf(output, "=== COMMAND: <%s %s> ===\n", cmd.Type, cmd.Argument)
+		switch {
+		case a.config.Summary:
+			fmt.Fprintln(output, summaryLine(result))
+		case color:
+			var block bytes.Buffer
+			writeResult(&block, *cmd, result, exec.GetCommandsRun(), a.elapsed())
+			fmt.Fprint(output, colorize(block.String()))
+		default:
+			writeResult(output, *cmd, result, exec.GetCommandsRun(), a.elapsed())
+		}
+		if a.interrupted() {
+			break
+		}
+
+		if showPrompts && !a.config.Quiet {
+			fmt.Fprintln(os.Stderr, "\nWaiting for more input...")
+		}
+	}
+
+	if a.config.Summary {
+		writeSummary(output, a.stats(), a.elapsed())
+	}
+}
+
+// elapsed returns how long the session has run, or 0 in a deterministic
+// session
+func (a *App) elapsed() time.Duration {
+	if a.config.Deterministic || a.session == nil {
+		return 0
+	}
+	return time.Since(a.session.StartTime)
+}
 
-		if result.Success {
-			switch cmd.Type {
-			case "open":
+// writeResult writes the framed output block for a single command
+func writeResult(output io.Writer, cmd scanner.Command, result scanner.ExecutionResult, commandsRun int, elapsed time.Duration) {
+	fmt.Fprint(output, "=== LLM TOOL START ===\n")
+	fmt.Fprintf(output, "=== COMMAND: <%s %s> ===\n", cmd.Type, cmd.Argument)
+
+	// Print with the command as executed, after template expansion
+	printResult(output, result.Command, result)
+
+	fmt.Fprint(output, "=== END COMMAND ===\n")
+	fmt.Fprint(output, "=== LLM TOOL COMPLETE ===\n")
+	fmt.Fprintf(output, "Commands executed: %d\n", commandsRun)
+	fmt.Fprintf(output, "Time elapsed: %.2fs\n", elapsed.Seconds())
+	fmt.Fprint(output, "=== END ===\n")
+}
+
+// printResult writes the outcome of a single command
+func printResult(output io.Writer, cmd scanner.Command, result scanner.ExecutionResult) {
+	if result.Action == "SKIPPED" {
+		fmt.Fprintf(output, "=== SKIPPED: <%s %s> ===\n", cmd.Type, cmd.Argument)
+		fmt.Fprintf(output, "Reason: %s\n", result.Result)
+		return
+	}
+
+	if result.Success {
+		switch cmd.Type {
+		case "open":
+			if result.Action == "UNCHANGED" {
+				fmt.Fprintf(output, "=== FILE UNCHANGED: %s ===\n", cmd.Argument)
+				fmt.Fprint(output, result.Result)
+				break
+			}
+			if result.Action == "BINARY_SUMMARY" {
+				fmt.Fprintf(output, "=== BINARY FILE: %s ===\n", cmd.Argument)
+			} else if result.Tokens > 0 {
+				fmt.Fprintf(output, "=== FILE: %s (≈%d tokens) ===\n", cmd.Argument, result.Tokens)
+			} else {
 				fmt.Fprintf(output, "=== FILE: %s ===\n", cmd.Argument)
+			}
+			fmt.Fprint(output, result.Result)
+			if !strings.HasSuffix(result.Result, "\n") {
+				fmt.Fprint(output, "\n")
+			}
+			fmt.Fprint(output, "=== END FILE ===\n")
+
+		case "write", "append":
+			fmt.Fprintf(output, "=== WRITE SUCCESSFUL: %s ===\n", cmd.Argument)
+			fmt.Fprintf(output, "Action: %s\n", result.Action)
+			fmt.Fprintf(output, "Bytes written: %d\n", result.BytesWritten)
+			if cmd.Encoding != "" {
+				fmt.Fprintf(output, "Decoded from: %s (%s)\n", cmd.Encoding, result.ContentType)
+			}
+			if result.BackupFile != "" {
+				fmt.Fprintf(output, "Backup: %s\n", result.BackupFile)
+			}
+			if result.Convention != "" {
+				fmt.Fprintf(output, "Line endings: %s\n", result.Convention)
+			}
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(output, "Warning: %s\n", warning)
+			}
+			if result.Attempts > 1 {
+				fmt.Fprintf(output, "Attempts: %d\n", result.Attempts)
+			}
+			fmt.Fprint(output, "=== END WRITE ===\n")
+
+		case "exec":
+			if cmd.Dir != "" {
+				fmt.Fprintf(output, "=== EXEC SUCCESSFUL: %s (in %s) ===\n", cmd.Argument, cmd.Dir)
+			} else {
+				fmt.Fprintf(output, "=== EXEC SUCCESSFUL: %s ===\n", cmd.Argument)
+			}
+			fmt.Fprintf(output, "Exit code: %d\n", result.ExitCode)
+			fmt.Fprintf(output, "Duration: %.3fs\n", result.ExecutionTime.Seconds())
+			if result.Attempts > 1 {
+				fmt.Fprintf(output, "Attempts: %d\n", result.Attempts)
+			}
+			if result.Result != "" {
+				fmt.Fprint(output, "Output:\n")
 				fmt.Fprint(output, result.Result)
 				if !strings.HasSuffix(result.Result, "\n") {
 					fmt.Fprint(output, "\n")
 				}
-				fmt.Fprint(output, "=== END FILE ===\n")
-
-			case "write":
-				fmt.Fprintf(output, "=== WRITE SUCCESSFUL: %s ===\n", cmd.Argument)
-				fmt.Fprintf(output, "Action: %s\n", result.Action)
-				fmt.Fprintf(output, "Bytes written: %d\n", result.BytesWritten)
-				if result.BackupFile != "" {
-					fmt.Fprintf(output, "Backup: %s\n", result.BackupFile)
-				}
-				fmt.Fprint(output, "=== END WRITE ===\n")
+			}
+			fmt.Fprint(output, "=== END EXEC ===\n")
 
-			case "exec":
-				fmt.Fprintf(output, "=== EXEC SUCCESSFUL: %s ===\n", cmd.Argument)
-				fmt.Fprintf(output, "Exit code: %d\n", result.ExitCode)
-				fmt.Fprintf(output, "Duration: %.3fs\n", result.ExecutionTime.Seconds())
-				if result.Result != "" {
-					fmt.Fprint(output, "Output:\n")
-					fmt.Fprint(output, result.Result)
-					if !strings.HasSuffix(result.Result, "\n") {
-						fmt.Fprint(output, "\n")
-					}
-				}
-				fmt.Fprint(output, "=== END EXEC ===\n")
+		case "search":
+			fmt.Fprint(output, result.Result)
 
-			case "search":
-				fmt.Fprint(output, result.Result)
+		case "set":
+			fmt.Fprintf(output, "=== SET: %s ===\n", result.Result)
+
+		case "pipe":
+			printSteps(output, result)
+			fmt.Fprintf(output, "=== PIPE SUCCESSFUL: %s ===\n", cmd.Argument)
+
+		case "if-success", "if-failure":
+			if result.Action == "CONDITION_NOT_MET" {
+				fmt.Fprintf(output, "=== CONDITION NOT MET: %s ===\n", result.Result)
 			}
+			printSteps(output, result)
+
+		default:
+			// Plugin commands
+			fmt.Fprintf(output, "=== %s: %s ===\n", strings.ToUpper(cmd.Type), cmd.Argument)
+			fmt.Fprint(output, result.Result)
+			if result.Result != "" && !strings.HasSuffix(result.Result, "\n") {
+				fmt.Fprint(output, "\n")
+			}
+			fmt.Fprintf(output, "=== END %s ===\n", strings.ToUpper(cmd.Type))
+		}
+	} else {
+		printSteps(output, result)
+		errType := errorCode(result.Error)
+		fmt.Fprintf(output, "=== ERROR: %s ===\n", errType)
+		fmt.Fprintf(output, "Message: %s\n", result.Error.Error())
+		if cmd.Dir != "" {
+			fmt.Fprintf(output, "Command: <%s dir=%s %s>\n", cmd.Type, cmd.Dir, cmd.Argument)
 		} else {
-			errParts := strings.Split(result.Error.Error(), ":")
-			errType := errParts[0]
-			fmt.Fprintf(output, "=== ERROR: %s ===\n", errType)
-			fmt.Fprintf(output, "Message: %s\n", result.Error.Error())
 			fmt.Fprintf(output, "Command: <%s %s>\n", cmd.Type, cmd.Argument)
-			if cmd.Type == "exec" && result.ExitCode != 0 {
-				fmt.Fprintf(output, "Exit code: %d\n", result.ExitCode)
-				if result.Stderr != "" {
-					fmt.Fprintf(output, "Stderr: %s\n", result.Stderr)
+		}
+		if result.Attempts > 1 {
+			fmt.Fprintf(output, "Attempts: %d\n", result.Attempts)
+		}
+		if cmd.ParseError != nil {
+			fmt.Fprintf(output, "Position: line %d\n", cmd.ParseError.Line)
+			fmt.Fprintf(output, "Hint: %s\n", cmd.ParseError.Hint)
+		}
+		if (cmd.Type == "write" || cmd.Type == "append") && result.Result != "" {
+			fmt.Fprint(output, "Changes on disk:\n")
+			fmt.Fprint(output, result.Result)
+		}
+		if cmd.Type == "exec" && result.ExitCode != 0 {
+			fmt.Fprintf(output, "Exit code: %d\n", result.ExitCode)
+			if errType == "EXEC_TIMEOUT" && result.Result != "" {
+				fmt.Fprint(output, "Output before timeout:\n")
+				fmt.Fprint(output, result.Result)
+				if !strings.HasSuffix(result.Result, "\n") {
+					fmt.Fprint(output, "\n")
 				}
+			} else if result.Stderr != "" {
+				fmt.Fprintf(output, "Stderr: %s\n", result.Stderr)
 			}
-			fmt.Fprint(output, "=== END ERROR ===\n")
 		}
+		fmt.Fprint(output, "=== END ERROR ===\n")
+	}
+}
 
-		fmt.Fprint(output, "=== END COMMAND ===\n")
-		fmt.Fprint(output, "=== LLM TOOL COMPLETE ===\n")
-		fmt.Fprintf(output, "Commands executed: %d\n", exec.GetCommandsRun())
-		fmt.Fprintf(output, "Time elapsed: %.2fs\n", time.Since(startTime).Seconds())
-		fmt.Fprint(output, "=== END ===\n")
-
-		if showPrompts {
-			fmt.Fprintln(os.Stderr, "\nWaiting for more input...")
-		}
+// printSteps writes the result of each step of a pipe or guard block
+func printSteps(output io.Writer, result scanner.ExecutionResult) {
+	for i, step := range result.Steps {
+		fmt.Fprintf(output, "=== STEP %d: <%s %s> ===\n", i+1, step.Command.Type, step.Command.Argument)
+		printResult(output, step.Command, step)
 	}
 }
 
@@ -185,8 +432,77 @@ func (a *App) GetSearchConfig() *search.SearchConfig {
 	return a.searchCfg
 }
 
-// Close cleans up app resources
+// Close cleans up app resources and prunes old backups
 func (a *App) Close() error {
+	if a.config.ReportFile != "" {
+		if err := a.writeReport(a.config.ReportFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if a.objects != nil && a.config.ObjectWriteBack != "" {
+		if err := a.writeBack(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	removed, err := evaluator.NewBackupManager(a.config).Prune()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: backup pruning failed: %v\n", err)
+	} else if a.config.Verbose && len(removed) > 0 {
+		fmt.Fprintf(os.Stderr, "Pruned %d old backups\n", len(removed))
+	}
+
+	if a.config.UsageLedger != "" {
+		if err := usage.Append(a.config.UsageLedger, a.Usage()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Before the session closes, since failed posts go to its audit log
+	if a.notifier != nil {
+		stats := a.stats()
+		a.notifier.SessionEnd(stats.Run, stats.Failed, a.elapsed())
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultNotifyTimeout)
+		if err := a.notifier.Close(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		cancel()
+	}
+
+	// Last of what works in the root, since the worktree goes with it
+	if a.worktree != nil {
+		if err := a.finishWorktree(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if a.transcript != nil {
+		if err := a.transcript.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if a.session != nil {
+		if err := a.session.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if a.metrics != nil {
+		a.metrics.Close()
+	}
+	if a.pprof != nil {
+		a.pprof.Close()
+	}
+
+	if a.executor != nil {
+		a.executor.Close()
+	}
+	if a.scratch != nil {
+		if err := a.scratch.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot remove scratch space: %v\n", err)
+		}
+	}
 	if a.pool != nil {
 		return a.pool.Close()
 	}
@@ -3,16 +3,36 @@ package app
 import (
 	"time"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
 )
 
+// dockerlessConfig returns a config for tests that run commands on a
+// machine without Docker. .git and .env are excluded, so writes to them
+// fail the path check before any container is started.
+func dockerlessConfig(root string) *config.Config {
+	return &config.Config{
+		RepositoryRoot:    root,
+		MaxFileSize:       1048576,
+		MaxWriteSize:      102400,
+		AllowedExtensions: []string{".txt"},
+		ExcludedPaths:     []string{".git", ".env"},
+		IOTimeout:         60 * time.Second,
+		IOContainerImage:  "llm-runtime-io:latest",
+	}
+}
+
 // captureStderr captures stderr during function execution
 func captureStderr(t *testing.T, f func()) string {
 	t.Helper()
@@ -272,6 +292,43 @@ func TestApp_Run_PipeMode_InputFile(t *testing.T) {
 	}
 }
 
+func TestApp_RunContext_Interrupted(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte("File content here"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	inputFile := filepath.Join(tempDir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("<open test.txt>"), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+
+	cfg := &config.Config{
+		RepositoryRoot:    tempDir,
+		MaxFileSize:       1048576,
+		AllowedExtensions: []string{".txt"},
+		IOTimeout:         60 * time.Second,
+		InputFile:         inputFile,
+		Quiet:             true,
+	}
+	app, err := Bootstrap(cfg)
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stdout := captureStdout(t, func() {
+		err = app.RunContext(ctx)
+	})
+
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("RunContext() error = %v, want ErrInterrupted", err)
+	}
+	if strings.Contains(stdout, "File content here") {
+		t.Errorf("command ran after the interrupt:\n%s", stdout)
+	}
+}
+
 func TestApp_Run_PipeMode_OutputFile(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -1213,3 +1270,162 @@ func TestApp_Run_InteractiveMode_EmptyInput(t *testing.T) {
 		t.Errorf("Expected welcome message in stderr\nGot: %s", stderr)
 	}
 }
+
+// TestPrintResult_ExecDir tests that exec results show their working
+// directory
+func TestPrintResult_ExecDir(t *testing.T) {
+	cmd := scanner.Command{Type: "exec", Argument: "go test ./...", Dir: "services/api"}
+
+	var buf bytes.Buffer
+	printResult(&buf, cmd, scanner.ExecutionResult{Command: cmd, Success: true, Result: "ok\n"})
+	if !strings.Contains(buf.String(), "=== EXEC SUCCESSFUL: go test ./... (in services/api) ===") {
+		t.Errorf("success output missing directory:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	printResult(&buf, cmd, scanner.ExecutionResult{Command: cmd, Error: errors.New(errors.ExecValidation, "working directory: not found")})
+	if !strings.Contains(buf.String(), "Command: <exec dir=services/api go test ./...>") {
+		t.Errorf("error output missing directory:\n%s", buf.String())
+	}
+}
+
+func TestPrintResult_ExecTimeout(t *testing.T) {
+	cmd := scanner.Command{Type: "exec", Argument: "go test ./..."}
+	result := scanner.ExecutionResult{
+		Command:  cmd,
+		Error:    errors.New(errors.ExecTimeout, "command timed out after 30s"),
+		ExitCode: 124,
+		Stdout:   "=== RUN   TestSlow\n",
+		Result:   "=== RUN   TestSlow\n",
+	}
+
+	var buf bytes.Buffer
+	printResult(&buf, cmd, result)
+	out := buf.String()
+	if !strings.Contains(out, "=== ERROR: EXEC_TIMEOUT ===") || !strings.Contains(out, "Exit code: 124") {
+		t.Errorf("timeout output missing error or exit code:\n%s", out)
+	}
+	if !strings.Contains(out, "Output before timeout:\n=== RUN   TestSlow\n") {
+		t.Errorf("timeout output missing partial output:\n%s", out)
+	}
+}
+
+func TestApp_Run_Deterministic(t *testing.T) {
+	input := "<set name=day value=${DATE}>\n" +
+		"<write notes.txt>first\n</write>\n" +
+		"<write notes.txt>rewritten\n</write>\n" +
+		"<write ${day}-${SESSION_ID}.txt>dated\n</write>\n" +
+		"<open notes.txt>\n" +
+		"<exec go test ./...>\n"
+
+	// Two sessions in different directories, at different times, print the
+	// same thing apart from where they ran
+	run := func() string {
+		root := t.TempDir()
+		inputFile := filepath.Join(root, "input.txt")
+		if err := os.WriteFile(inputFile, []byte(input), 0644); err != nil {
+			t.Fatal(err)
+		}
+		outputFile := filepath.Join(t.TempDir(), "output.txt")
+		cfg := &config.Config{
+			RepositoryRoot:    root,
+			MaxFileSize:       1048576,
+			MaxWriteSize:      102400,
+			AllowedExtensions: []string{".txt"},
+			ExcludedPaths:     []string{".git"},
+			BackupBeforeWrite: true,
+			ExecWhitelist:     []string{"go test"},
+			ExecValidation:    config.ExecValidationStrict,
+			ExecTimeout:       30 * time.Second,
+			IOTimeout:         60 * time.Second,
+			IOContainerImage:  "llm-runtime-io:latest",
+			InputFile:         inputFile,
+			OutputFile:        outputFile,
+			Deterministic:     true,
+		}
+		app, err := Bootstrap(cfg)
+		if err != nil {
+			t.Fatalf("Bootstrap() error = %v", err)
+		}
+		defer sandbox.SetFakeBackend(nil)
+		if err := app.Run(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		content, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.ReplaceAll(string(content), root, "$ROOT")
+	}
+
+	first := run()
+	time.Sleep(10 * time.Millisecond)
+	if second := run(); second != first {
+		t.Errorf("output differs between runs\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+	for _, want := range []string{"2000-01-01-deterministic.txt", "Time elapsed: 0.00s", ".bak.946684800000000000", "Duration: 0.000s"} {
+		if !strings.Contains(first, want) {
+			t.Errorf("output missing %q:\n%s", want, first)
+		}
+	}
+}
+
+func TestApp_Worktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("original\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	inputFile := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(inputFile, []byte("<write notes.txt>changed\n</write>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		RepositoryRoot:    repo,
+		MaxFileSize:       1048576,
+		MaxWriteSize:      102400,
+		AllowedExtensions: []string{".txt"},
+		ExcludedPaths:     []string{".git"},
+		BackupBeforeWrite: true,
+		IOTimeout:         60 * time.Second,
+		IOContainerImage:  "llm-runtime-io:latest",
+		InputFile:         inputFile,
+		OutputFile:        filepath.Join(t.TempDir(), "output.txt"),
+		Deterministic:     true,
+		Quiet:             true,
+		Worktree:          true,
+		WorktreeDir:       t.TempDir(),
+	}
+	app, err := Bootstrap(cfg)
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	defer sandbox.SetFakeBackend(nil)
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	branch := app.worktree.Branch
+	if err := app.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(repo, "notes.txt")); string(content) != "original\n" {
+		t.Errorf("checkout changed to %q", content)
+	}
+	out, err := exec.Command("git", "-C", repo, "show", branch+":notes.txt").Output()
+	if err != nil || string(out) != "changed\n" {
+		t.Errorf("notes.txt on %s = %q, %v", branch, out, err)
+	}
+}
@@ -5,15 +5,80 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/computerscienceiscool/llm-runtime/pkg/agent"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/diagnostics"
 	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/memory"
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
+	"github.com/computerscienceiscool/llm-runtime/pkg/notify"
+	"github.com/computerscienceiscool/llm-runtime/pkg/objrepo"
+	"github.com/computerscienceiscool/llm-runtime/pkg/plugin"
 	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
 	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+	"github.com/computerscienceiscool/llm-runtime/pkg/transcript"
+	"github.com/computerscienceiscool/llm-runtime/pkg/worktree"
 )
 
+// loadPlugins returns the plugin commands registered from code and those
+// found in the plugins directory. Files WASM plugins write are written
+// with write, so they get the checks of <write>.
+func loadPlugins(cfg *config.Config, write func(path string, data []byte) error) (*plugin.Registry, error) {
+	registry, err := plugin.NewRegistry()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PluginsDir == "" {
+		return registry, nil
+	}
+
+	capabilities := make(map[string]map[string]bool)
+	for name, block := range cfg.PluginConfig {
+		caps, err := plugin.ParseCapabilities(block)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", name, err)
+		}
+		capabilities[name] = caps
+	}
+
+	found, err := plugin.Discover(cfg.PluginsDir, cfg.PluginTimeout, func(name string) *plugin.Host {
+		return &plugin.Host{
+			Root:              cfg.RepositoryRoot,
+			ExcludedPaths:     cfg.ExcludedPaths,
+			AllowedExtensions: cfg.AllowedExtensions,
+			MaxFileSize:       cfg.MaxFileSize,
+			MemoryLimitPages:  cfg.PluginMemoryPages,
+			Capabilities:      capabilities[name],
+			Log:               os.Stderr,
+			Write:             write,
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range found {
+		if err := registry.Add(p); err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	return registry, nil
+}
+
 // Bootstrap initializes and returns a configured App
 func Bootstrap(cfg *config.Config) (*App, error) {
+	// An object store root is worked on in a local copy
+	var objects *objrepo.Repo
+	if cfg.ObjectSource != "" {
+		var err error
+		if objects, err = materialize(cfg); err != nil {
+			return nil, fmt.Errorf("cannot copy %s: %w", cfg.ObjectSource, err)
+		}
+		cfg.RepositoryRoot = objects.Dir
+	}
+
 	// Resolve repository root to absolute path
 	absRoot, err := filepath.Abs(cfg.RepositoryRoot)
 	if err != nil {
@@ -29,6 +94,36 @@ func Bootstrap(cfg *config.Config) (*App, error) {
 
 	// Create session
 	sess := session.NewSession(cfg)
+	sandbox.SetSessionID(sess.ID)
+
+	// A worktree session works on a checkout of its own
+	var wt *worktree.Worktree
+	if cfg.Worktree {
+		if wt, err = startWorktree(cfg, sess.ID); err != nil {
+			return nil, err
+		}
+		cfg.RepositoryRoot = wt.Root
+	}
+	if cfg.Deterministic {
+		// Containers answer from the fake backend, so none run, need
+		// pooling, or are left behind to sweep
+		sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+		cfg.ContainerPool.Enabled = false
+	} else if cfg.CleanupOnStart {
+		sweepLeftovers(cfg)
+	}
+
+	// Commands share a scratch space outside the repository. Encrypted
+	// files cannot be mounted into containers, which get one in memory.
+	var space *scratch.Space
+	if cfg.Scratch {
+		if space, err = scratch.New(sandbox.Owner(), cfg.ScratchEncrypt); err != nil {
+			return nil, err
+		}
+		if !space.Encrypted() {
+			cfg.ScratchDir = space.Dir
+		}
+	}
 
 	// Load search configuration
 	searchCfg := config.LoadSearchConfig()
@@ -56,11 +151,81 @@ func Bootstrap(cfg *config.Config) (*App, error) {
 
 	// Create executor with audit logging
 	exec := evaluator.NewExecutor(cfg, searchCfg, sess.LogAudit, pool)
+	exec.SetCorrelatedAuditLog(sess.LogCommandAudit)
+	exec.SetSessionID(sess.ID)
 
-	return &App{
+	plugins, err := loadPlugins(cfg, exec.WritePluginFile)
+	if err != nil {
+		return nil, err
+	}
+	exec.SetPlugins(plugins)
+	exec.SetScratch(space)
+	exec.SetSummarizer(&agent.Summarizer{Client: &agent.OllamaClient{URL: cfg.SummarizerURL, Model: cfg.SummarizerModel}})
+	if cfg.Memory {
+		exec.SetMemory(openMemory(cfg, sess.ID))
+	}
+
+	app := &App{
 		config:    cfg,
 		session:   sess,
 		executor:  exec,
 		searchCfg: searchCfg,
-	}, nil
+		objects:   objects,
+		worktree:  wt,
+		scratch:   space,
+	}
+	exec.SetSessionSummary(app.pullRequestSummary)
+
+	if len(cfg.Notifiers) > 0 {
+		notifier, err := notify.New(cfg, sess.ID, sess.LogAudit)
+		if err != nil {
+			return nil, err
+		}
+		exec.SetNotifier(notifier)
+		app.notifier = notifier
+		notifier.SessionStart()
+	}
+
+	if cfg.TranscriptFile != "" {
+		recorder, err := transcript.Create(cfg.TranscriptFile)
+		if err != nil {
+			return nil, err
+		}
+		recorder.Start(cfg.RepositoryRoot, cfg.Interactive)
+		app.transcript = recorder
+	}
+
+	if cfg.MetricsAddr != "" {
+		server, err := metrics.Serve(cfg.MetricsAddr)
+		if err != nil {
+			return nil, err
+		}
+		app.metrics = server
+	}
+
+	if cfg.PprofAddr != "" {
+		server, err := diagnostics.Serve(cfg.PprofAddr, cfg)
+		if err != nil {
+			return nil, err
+		}
+		app.pprof = server
+	}
+	return app, nil
+}
+
+// openMemory returns the session memory document, MemoryFile under the
+// repository root unless it is absolute, timestamped like the session
+func openMemory(cfg *config.Config, sessionID string) *memory.File {
+	path := cfg.MemoryFile
+	if path == "" {
+		path = config.DefaultMemoryFile
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cfg.RepositoryRoot, path)
+	}
+	now := time.Now
+	if cfg.Deterministic {
+		now = func() time.Time { return config.DeterministicTime }
+	}
+	return memory.Open(path, sessionID, now)
 }
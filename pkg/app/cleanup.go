@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/backup"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
+	"github.com/computerscienceiscool/llm-runtime/pkg/worktree"
+)
+
+// CleanupReport lists what a cleanup removed
+type CleanupReport struct {
+	Containers []sandbox.Leftover
+	TempDirs   []sandbox.Leftover
+	Worktrees  []worktree.Abandoned
+	Scratch    []scratch.Abandoned
+	Backups    []backup.Entry
+	Warnings   []string // Steps that could not run, such as with Docker down
+}
+
+// Reclaimed returns the bytes freed by removed directories and backups
+func (r CleanupReport) Reclaimed() int64 {
+	var size int64
+	for _, d := range r.TempDirs {
+		size += d.Size
+	}
+	for _, s := range r.Scratch {
+		size += s.Size
+	}
+	for _, b := range r.Backups {
+		size += b.Size
+	}
+	return size
+}
+
+// Cleanup removes the containers and exec temp directories that sessions
+// left behind more than cfg.CleanupAge ago and the worktrees and scratch
+// spaces of sessions no longer running, and prunes backups beyond the retention settings. Failed steps are reported as warnings so the others
+// still run.
+func Cleanup(ctx context.Context, cfg *config.Config) CleanupReport {
+	var report CleanupReport
+
+	containers, err := sandbox.CleanupContainers(ctx, cfg.CleanupAge)
+	report.Containers = containers
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("containers: %v", err))
+	}
+
+	dirs, err := sandbox.CleanupTempDirs(cfg.CleanupAge)
+	report.TempDirs = dirs
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("temp directories: %v", err))
+	}
+
+	// Only a root in a git repository can have session worktrees
+	worktrees, err := worktree.Prune(cfg.RepositoryRoot, sandbox.OwnerRunning)
+	report.Worktrees = worktrees
+	if err != nil && !errors.Is(err, worktree.ErrNotRepository) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("worktrees: %v", err))
+	}
+
+	spaces, err := scratch.Prune(sandbox.OwnerRunning)
+	report.Scratch = spaces
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("scratch spaces: %v", err))
+	}
+
+	backups, err := evaluator.NewBackupManager(cfg).Prune()
+	report.Backups = backups
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("backups: %v", err))
+	}
+	return report
+}
+
+// sweepLeftovers removes what crashed sessions left behind, before a new
+// session starts. Backups are pruned when the session closes instead.
+func sweepLeftovers(cfg *config.Config) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	containers, err := sandbox.CleanupContainers(ctx, cfg.CleanupAge)
+	if err != nil && cfg.Verbose {
+		fmt.Fprintf(os.Stderr, "Warning: leftover containers not checked: %v\n", err)
+	}
+	dirs, _ := sandbox.CleanupTempDirs(cfg.CleanupAge)
+	spaces, _ := scratch.Prune(sandbox.OwnerRunning)
+
+	if cfg.Verbose && len(containers)+len(dirs)+len(spaces) > 0 {
+		fmt.Fprintf(os.Stderr, "Removed %d leftover containers, %d temp directories, and %d scratch spaces\n", len(containers), len(dirs), len(spaces))
+	}
+}
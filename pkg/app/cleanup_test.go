@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestCleanup(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	leftover := filepath.Join(tmp, "llm-exec-123")
+	if err := os.MkdirAll(leftover, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(leftover, "build.log"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(leftover, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		RepositoryRoot: t.TempDir(),
+		BackupDir:      filepath.Join(t.TempDir(), "backups"),
+		CleanupAge:     time.Hour,
+	}
+	report := Cleanup(context.Background(), cfg)
+
+	if len(report.TempDirs) != 1 || report.TempDirs[0].ID != leftover {
+		t.Errorf("TempDirs = %+v, want %s", report.TempDirs, leftover)
+	}
+	if report.Reclaimed() != 10 {
+		t.Errorf("Reclaimed() = %d, want 10", report.Reclaimed())
+	}
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+// ANSI escape sequences used to color result blocks
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+	ansiGray    = "\x1b[90m"
+)
+
+// useColor reports whether result blocks written to output should be
+// colored. Output files are read by the LLM and are always plain; stdout is
+// colored on a terminal in auto mode, honoring NO_COLOR.
+func useColor(cfg *config.Config, output io.Writer) bool {
+	if cfg.OutputFile != "" || output != io.Writer(os.Stdout) {
+		return false
+	}
+
+	switch cfg.Color {
+	case config.ColorAlways:
+		return true
+	case config.ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize adds ANSI colors to a plain result block: outcomes by severity,
+// framing lines dimmed, and opened files syntax highlighted
+func colorize(block string) string {
+	var b strings.Builder
+	var lang *language
+	inFile := false
+
+	for _, line := range strings.SplitAfter(block, "\n") {
+		text := strings.TrimSuffix(line, "\n")
+		newline := line[len(text):]
+
+		switch {
+		case inFile && text == "=== END FILE ===":
+			inFile = false
+			b.WriteString(paint(text, ansiCyan))
+		case inFile:
+			b.WriteString(highlight(text, lang))
+		case strings.HasPrefix(text, "=== FILE: "):
+			inFile = true
+			name := strings.TrimSuffix(strings.TrimPrefix(text, "=== FILE: "), " ===")
+			if i := strings.Index(name, " (≈"); i >= 0 {
+				// Past the token estimate
+				name = name[:i]
+			}
+			lang = languages[strings.ToLower(filepath.Ext(name))]
+			b.WriteString(paint(text, ansiBold+ansiCyan))
+		case strings.HasPrefix(text, "=== BINARY FILE: "):
+			inFile = true
+			lang = nil
+			b.WriteString(paint(text, ansiBold+ansiCyan))
+		case strings.HasPrefix(text, "=== ERROR"):
+			b.WriteString(paint(text, ansiBold+ansiRed))
+		case strings.HasPrefix(text, "=== WRITE SUCCESSFUL"), strings.HasPrefix(text, "=== EXEC SUCCESSFUL"),
+			strings.HasPrefix(text, "=== PIPE SUCCESSFUL"), strings.HasPrefix(text, "=== SET:"):
+			b.WriteString(paint(text, ansiBold+ansiGreen))
+		case strings.HasPrefix(text, "=== SKIPPED"), strings.HasPrefix(text, "=== CONDITION NOT MET"),
+			strings.HasPrefix(text, "Warning: "):
+			b.WriteString(paint(text, ansiYellow))
+		case strings.HasPrefix(text, "==="):
+			b.WriteString(paint(text, ansiGray))
+		default:
+			b.WriteString(text)
+		}
+		b.WriteString(newline)
+	}
+	return b.String()
+}
+
+// paint wraps non-empty text in an ANSI color
+func paint(text, color string) string {
+	if text == "" {
+		return ""
+	}
+	return color + text + ansiReset
+}
+
+// language describes enough of a language to highlight it a line at a time
+type language struct {
+	keywords    map[string]bool
+	lineComment string
+	quotes      string
+}
+
+func newLanguage(lineComment, quotes, keywords string) *language {
+	l := &language{lineComment: lineComment, quotes: quotes, keywords: make(map[string]bool)}
+	for _, kw := range strings.Fields(keywords) {
+		l.keywords[kw] = true
+	}
+	return l
+}
+
+var (
+	goLanguage = newLanguage("//", "\"'`", `break case chan const continue default defer else fallthrough for
+		func go goto if import interface map package range return select struct switch type var true false nil`)
+	pythonLanguage = newLanguage("#", `"'`, `and as assert async await break class continue def del elif else
+		except finally for from global if import in is lambda nonlocal not or pass raise return try while with
+		yield None True False`)
+	jsLanguage = newLanguage("//", "\"'`", `async await break case catch class const continue debugger default
+		delete do else export extends finally for function if import in instanceof interface let new return super
+		switch this throw try type typeof var void while with yield null true false undefined`)
+	shellLanguage = newLanguage("#", `"'`, `case do done elif else esac export fi for function if in local
+		return then until while`)
+	configLanguage = newLanguage("#", `"'`, `true false null`)
+)
+
+// languages maps file extensions to the language used to highlight them
+var languages = map[string]*language{
+	".go":   goLanguage,
+	".py":   pythonLanguage,
+	".js":   jsLanguage,
+	".jsx":  jsLanguage,
+	".ts":   jsLanguage,
+	".tsx":  jsLanguage,
+	".sh":   shellLanguage,
+	".bash": shellLanguage,
+	".yaml": configLanguage,
+	".yml":  configLanguage,
+	".toml": configLanguage,
+}
+
+// highlight colors comments, strings, numbers, and keywords in one line of
+// source. Constructs spanning lines, such as block comments, are not
+// tracked; lines of unknown languages are returned unchanged.
+func highlight(line string, lang *language) string {
+	if lang == nil {
+		return line
+	}
+
+	var b strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case lang.lineComment != "" && strings.HasPrefix(string(runes[i:]), lang.lineComment):
+			b.WriteString(paint(string(runes[i:]), ansiGray))
+			return b.String()
+
+		case strings.ContainsRune(lang.quotes, r):
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' && r != '`' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(runes))
+			b.WriteString(paint(string(runes[i:end]), ansiGreen))
+			i = end
+
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '.' || runes[end] == '_') {
+				end++
+			}
+			b.WriteString(paint(string(runes[i:end]), ansiMagenta))
+			i = end
+
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			word := string(runes[i:end])
+			if lang.keywords[word] {
+				word = paint(word, ansiBlue)
+			}
+			b.WriteString(word)
+			i = end
+
+		default:
+			b.WriteRune(r)
+			i++
+		}
+	}
+	return b.String()
+}
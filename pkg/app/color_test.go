@@ -0,0 +1,74 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.Config
+		output io.Writer
+		want   bool
+	}{
+		{"always on stdout", config.Config{Color: config.ColorAlways}, os.Stdout, true},
+		{"never", config.Config{Color: config.ColorNever}, os.Stdout, false},
+		{"output file is always plain", config.Config{Color: config.ColorAlways, OutputFile: "results.txt"}, os.Stdout, false},
+		{"other writers are plain", config.Config{Color: config.ColorAlways}, &bytes.Buffer{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := useColor(&tt.cfg, tt.output); got != tt.want {
+			t.Errorf("%s: useColor() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestColorize(t *testing.T) {
+	block := "=== LLM TOOL START ===\n" +
+		"=== FILE: main.go ===\n" +
+		"func main() { // entry\n" +
+		"=== END FILE ===\n" +
+		"=== ERROR: FILE_NOT_FOUND ===\n" +
+		"Message: FILE_NOT_FOUND: missing.go\n"
+
+	got := colorize(block)
+
+	for _, want := range []string{
+		ansiGray + "=== LLM TOOL START ===" + ansiReset + "\n",
+		ansiBold + ansiCyan + "=== FILE: main.go ===" + ansiReset,
+		ansiBlue + "func" + ansiReset + " main() { " + ansiGray + "// entry" + ansiReset,
+		ansiBold + ansiRed + "=== ERROR: FILE_NOT_FOUND ===" + ansiReset,
+		"\nMessage: FILE_NOT_FOUND: missing.go\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("colorize() missing %q\ngot: %q", want, got)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		ext  string
+		want string
+	}{
+		{"go string and number", `x := "a\"b" + 42`, ".go", "x := " + ansiGreen + `"a\"b"` + ansiReset + " + " + ansiMagenta + "42" + ansiReset},
+		{"python keyword and comment", "def f(): # done", ".py", ansiBlue + "def" + ansiReset + " f(): " + ansiGray + "# done" + ansiReset},
+		{"unterminated string", `s = 'open`, ".py", "s = " + ansiGreen + "'open" + ansiReset},
+		{"unknown language", "func main()", ".txt", "func main()"},
+	}
+
+	for _, tt := range tests {
+		if got := highlight(tt.line, languages[tt.ext]); got != tt.want {
+			t.Errorf("%s: highlight() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
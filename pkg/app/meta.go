@@ -0,0 +1,266 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+)
+
+// metaCommands lists the interactive meta-commands, in the order :help
+// shows them
+var metaCommands = []struct {
+	name        string
+	description string
+}{
+	{"help", "List meta-commands"},
+	{"config", "Show the effective configuration"},
+	{"history", "List the commands run this session"},
+	{"session", "Show the session ID, repository, and audit log"},
+	{"undo", "Revert the last write"},
+	{"stats", "Show command counts and timing"},
+	{"reload", "Reload the config files"},
+	{"quit", "End the session"},
+}
+
+// historyEntry records a command run this session
+type historyEntry struct {
+	command  scanner.Command
+	status   string // ok, FAIL, or skip
+	code     string // Error code of a failed command
+	reason   string // Why the command failed
+	duration time.Duration
+}
+
+// undoEntry records a successful write that :undo can revert
+type undoEntry struct {
+	path   string // Absolute path of the written file
+	action string // CREATED or UPDATED
+	backup string // Backup taken before an update, if any
+}
+
+// record adds a command result to the session history and transcript and
+// remembers the writes it made, including those in pipe and guard steps
+func (a *App) record(result scanner.ExecutionResult) {
+	if a.transcript != nil {
+		a.transcript.Result(result)
+	}
+	entry := historyEntry{
+		command:  result.Command,
+		status:   resultStatus(result),
+		duration: result.ExecutionTime,
+	}
+	if !result.Success && result.Error != nil {
+		entry.code = errorCode(result.Error)
+		entry.reason = result.Error.Error()
+	}
+	a.history = append(a.history, entry)
+	if a.session != nil {
+		a.session.RecordCommand(result.Command.Type, resultOutcome(result), result.ExecutionTime)
+	}
+	a.execTime += execTimeOf(result)
+	a.containers += containerCommands(result)
+	a.cpuSeconds += cpuSecondsOf(result, a.config)
+	a.written += bytesWrittenOf(result)
+	a.recordWrites(result)
+}
+
+// resultOutcome maps a result onto the outcomes the session counts
+func resultOutcome(result scanner.ExecutionResult) string {
+	switch resultStatus(result) {
+	case "FAIL":
+		return session.OutcomeFailed
+	case "skip":
+		return session.OutcomeSkipped
+	}
+	return session.OutcomeOK
+}
+
+// stats returns a snapshot of the session's command counts, empty if there
+// is no session
+func (a *App) stats() session.Stats {
+	if a.session == nil {
+		return session.Stats{}
+	}
+	return a.session.Stats()
+}
+
+// transcribe adds a parsed command to the transcript, if one is being kept
+func (a *App) transcribe(cmd scanner.Command) {
+	if a.transcript != nil {
+		a.transcript.Command(cmd)
+	}
+}
+
+func (a *App) recordWrites(result scanner.ExecutionResult) {
+	for _, step := range result.Steps {
+		a.recordWrites(step)
+	}
+	if (result.Command.Type != "write" && result.Command.Type != "append") || !result.Success || result.Action == "UNCHANGED" {
+		return
+	}
+
+	path, err := sandbox.ValidatePath(result.Command.Argument, a.config.RepositoryRoot, a.config.ExcludedPaths)
+	if err != nil {
+		return
+	}
+	a.undo = append(a.undo, undoEntry{path: path, action: result.Action, backup: result.BackupFile})
+	if result.BackupFile != "" {
+		a.backups = append(a.backups, result.BackupFile)
+	}
+
+	// Writes not snapshotted beforehand, such as to templated paths, are
+	// compared with their backup, or with nothing if they created the file
+	switch {
+	case result.Action == "CREATED":
+		a.rememberOriginal(path, "")
+	case result.BackupFile != "":
+		a.rememberOriginal(path, result.BackupFile)
+	}
+}
+
+// runMeta runs an interactive meta-command and reports whether the session
+// should end
+func (a *App) runMeta(name string, sc *scanner.Scanner, w io.Writer) bool {
+	switch name {
+	case "help":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, meta := range metaCommands {
+			fmt.Fprintf(tw, "  :%s\t%s\n", meta.name, meta.description)
+		}
+		tw.Flush()
+	case "config":
+		a.printConfig(w)
+	case "history":
+		a.printHistory(w)
+	case "session":
+		a.printSession(w)
+	case "undo":
+		a.undoWrite(w)
+	case "stats":
+		a.printStats(w)
+	case "reload":
+		a.reloadConfig(sc)
+	case "quit":
+		return true
+	default:
+		fmt.Fprintf(w, "Unknown meta-command :%s (type :help for a list)\n", name)
+	}
+	return false
+}
+
+// printConfig writes every setting of the effective configuration
+func (a *App) printConfig(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	v := reflect.ValueOf(a.config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		fmt.Fprintf(tw, "%s\t%v\n", v.Type().Field(i).Name, v.Field(i).Interface())
+	}
+	tw.Flush()
+}
+
+// printHistory writes the commands run this session, oldest first
+func (a *App) printHistory(w io.Writer) {
+	if len(a.history) == 0 {
+		fmt.Fprintln(w, "No commands run yet")
+		return
+	}
+	for i, entry := range a.history {
+		fmt.Fprintf(w, "%3d  %-4s  <%s %s>  %.2fs\n", i+1, entry.status, entry.command.Type, entry.command.Argument, entry.duration.Seconds())
+	}
+}
+
+// printSession writes the session identity and where it records its audit
+func (a *App) printSession(w io.Writer) {
+	auditLog, err := filepath.Abs("audit.log")
+	if err != nil {
+		auditLog = "audit.log"
+	}
+	fmt.Fprintf(w, "Session ID: %s\n", a.session.ID)
+	fmt.Fprintf(w, "Started: %s (%s ago)\n", a.session.StartTime.Format(time.RFC3339), a.elapsed().Round(time.Second))
+	fmt.Fprintf(w, "Repository: %s\n", a.config.RepositoryRoot)
+	fmt.Fprintf(w, "Audit log: %s\n", auditLog)
+}
+
+// printStats writes command counts by type, failures, and timing
+func (a *App) printStats(w io.Writer) {
+	stats := a.stats()
+	fmt.Fprintf(w, "Commands: %d (%d failed)\n", stats.Run, stats.Failed)
+	types := make([]string, 0, len(stats.ByType))
+	for cmdType := range stats.ByType {
+		types = append(types, cmdType)
+	}
+	sort.Strings(types)
+	for _, cmdType := range types {
+		fmt.Fprintf(w, "  %s: %d\n", cmdType, stats.ByType[cmdType].Run)
+	}
+	fmt.Fprintf(w, "Time in commands: %.2fs\n", stats.Duration.Seconds())
+	if a.session != nil {
+		fmt.Fprintf(w, "Session time: %.2fs\n", a.elapsed().Seconds())
+	}
+
+	if a.pool != nil {
+		poolStats := a.pool.Stats()
+		keys := make([]string, 0, len(poolStats))
+		for key := range poolStats {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintln(w, "Container pool:")
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s: %v\n", key, poolStats[key])
+		}
+	}
+}
+
+// undoWrite reverts the most recent write of the session: a created file
+// is removed and an updated file is restored from its backup
+func (a *App) undoWrite(w io.Writer) {
+	if len(a.undo) == 0 {
+		fmt.Fprintln(w, "Nothing to undo")
+		return
+	}
+	last := a.undo[len(a.undo)-1]
+	a.undo = a.undo[:len(a.undo)-1]
+
+	rel, err := filepath.Rel(a.config.RepositoryRoot, last.path)
+	if err != nil {
+		rel = last.path
+	}
+
+	switch {
+	case last.action == "CREATED":
+		err = os.Remove(last.path)
+	case last.backup != "":
+		_, err = evaluator.NewBackupManager(a.config).RestorePath(last.backup)
+	default:
+		err = fmt.Errorf("no backup was taken (enable --backup to undo updates)")
+	}
+
+	if a.session != nil {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		a.session.LogAudit("undo", rel, err == nil, msg)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Cannot undo write to %s: %v\n", rel, err)
+		return
+	}
+
+	if last.action == "CREATED" {
+		fmt.Fprintf(w, "Undone: removed %s\n", rel)
+	} else {
+		fmt.Fprintf(w, "Undone: restored %s from backup\n", rel)
+	}
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+)
+
+func TestApp_RunMeta(t *testing.T) {
+	a := &App{config: &config.Config{RepositoryRoot: t.TempDir()}}
+
+	var out bytes.Buffer
+	if a.runMeta("help", nil, &out) {
+		t.Error("runMeta(help) ended the session")
+	}
+	for _, meta := range metaCommands {
+		if !strings.Contains(out.String(), ":"+meta.name) {
+			t.Errorf(":help output missing :%s\n%s", meta.name, out.String())
+		}
+	}
+
+	out.Reset()
+	a.runMeta("frobnicate", nil, &out)
+	if !strings.Contains(out.String(), "Unknown meta-command :frobnicate") {
+		t.Errorf("unexpected output for unknown command: %s", out.String())
+	}
+
+	if !a.runMeta("quit", nil, &out) {
+		t.Error("runMeta(quit) did not end the session")
+	}
+}
+
+func TestApp_History(t *testing.T) {
+	a := &App{config: &config.Config{RepositoryRoot: t.TempDir()}, session: &session.Session{StartTime: time.Now()}}
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: "main.go"}, Success: true})
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "exec", Argument: "go test"}, Error: errors.New("EXEC_FAILED")})
+
+	var out bytes.Buffer
+	a.runMeta("history", nil, &out)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "ok    <open main.go>") || !strings.Contains(lines[1], "FAIL  <exec go test>") {
+		t.Errorf("unexpected history:\n%s", out.String())
+	}
+
+	out.Reset()
+	a.runMeta("stats", nil, &out)
+	if !strings.Contains(out.String(), "Commands: 2 (1 failed)") || !strings.Contains(out.String(), "  exec: 1") {
+		t.Errorf("unexpected stats:\n%s", out.String())
+	}
+}
+
+func TestApp_Undo(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{RepositoryRoot: root}
+	a := &App{config: cfg}
+
+	// An update with a backup, inside a pipe, then a newly created file
+	updated := filepath.Join(root, "main.go")
+	if err := os.WriteFile(updated, []byte("v1"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	backupPath, err := evaluator.NewBackupManager(cfg).Create(updated)
+	if err != nil {
+		t.Fatalf("failed to create backup: %v", err)
+	}
+	os.WriteFile(updated, []byte("v2"), 0644)
+	a.record(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "pipe"},
+		Success: true,
+		Steps: []scanner.ExecutionResult{{
+			Command:    scanner.Command{Type: "write", Argument: "main.go"},
+			Success:    true,
+			Action:     "UPDATED",
+			BackupFile: backupPath,
+		}},
+	})
+
+	created := filepath.Join(root, "new.go")
+	os.WriteFile(created, []byte("package main"), 0644)
+	a.record(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "write", Argument: "new.go"},
+		Success: true,
+		Action:  "CREATED",
+	})
+
+	var out bytes.Buffer
+	a.runMeta("undo", nil, &out)
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("created file still exists after :undo: %s", out.String())
+	}
+
+	a.runMeta("undo", nil, &out)
+	if content, _ := os.ReadFile(updated); string(content) != "v1" {
+		t.Errorf("content = %q after :undo, want v1\n%s", content, out.String())
+	}
+
+	out.Reset()
+	a.runMeta("undo", nil, &out)
+	if !strings.Contains(out.String(), "Nothing to undo") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
+
+func TestApp_Undo_SkipsUnchangedWrites(t *testing.T) {
+	root := t.TempDir()
+	a := &App{config: &config.Config{RepositoryRoot: root}}
+	a.record(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "write", Argument: "main.go"},
+		Success: true,
+		Action:  "UNCHANGED",
+	})
+
+	var out bytes.Buffer
+	a.runMeta("undo", nil, &out)
+	if !strings.Contains(out.String(), "Nothing to undo") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
+
+func TestApp_Undo_NoBackup(t *testing.T) {
+	root := t.TempDir()
+	a := &App{config: &config.Config{RepositoryRoot: root}}
+	a.record(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "write", Argument: "main.go"},
+		Success: true,
+		Action:  "UPDATED",
+	})
+
+	var out bytes.Buffer
+	a.runMeta("undo", nil, &out)
+	if !strings.Contains(out.String(), "Cannot undo write to main.go: no backup was taken") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
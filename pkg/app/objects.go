@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/objrepo"
+)
+
+// materialize copies the object store prefix of cfg.ObjectSource into the
+// cache, to serve as the repository root
+func materialize(cfg *config.Config) (*objrepo.Repo, error) {
+	source, err := objrepo.ParseURL(cfg.ObjectSource)
+	if err != nil {
+		return nil, err
+	}
+	store, err := objrepo.NewStore(source, cfg.ObjectEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := objrepo.CacheDir(cfg.ObjectCacheDir, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Quiet {
+		fmt.Fprintf(os.Stderr, "Copying %s to %s\n", source, dir)
+	}
+	return objrepo.Materialize(context.Background(), store, source, dir)
+}
+
+// writeBack writes the files changed in the session to where
+// cfg.ObjectWriteBack says, and records it in the audit log
+func (a *App) writeBack() error {
+	dest := a.objects.Source
+	if a.config.ObjectWriteBack != config.ObjectWriteBackSource {
+		var err error
+		if dest, err = objrepo.ParseURL(a.config.ObjectWriteBack); err != nil {
+			return err
+		}
+	}
+	store, err := objrepo.NewStore(dest, a.config.ObjectEndpoint)
+	if err != nil {
+		return err
+	}
+
+	changes, err := a.objects.WriteBack(context.Background(), store, dest)
+	if a.session != nil {
+		msg := fmt.Sprintf("written:%s,deleted:%s", strings.Join(changes.Written, ";"), strings.Join(changes.Deleted, ";"))
+		if err != nil {
+			msg = err.Error()
+		}
+		a.session.LogAudit("object_writeback", dest.String(), err == nil, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot write changes to %s: %w", dest, err)
+	}
+
+	if !a.config.Quiet {
+		fmt.Fprintf(os.Stderr, "Wrote %d changed files to %s\n", len(changes.Written), dest)
+		if len(changes.Deleted) > 0 && dest != a.objects.Source {
+			fmt.Fprintf(os.Stderr, "Deleted files, not removed from %s: %s\n", a.objects.Source, strings.Join(changes.Deleted, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/watch"
+)
+
+// ConfigLoader builds a fresh configuration from the config files,
+// environment variables, and flags
+type ConfigLoader func() (*config.Config, error)
+
+// configWatcher notices changes to config files by their modification time
+type configWatcher struct {
+	modTimes map[string]time.Time
+}
+
+// newConfigWatcher starts watching paths. Paths that do not exist yet are
+// watched too, so creating one counts as a change.
+func newConfigWatcher(paths ...string) *configWatcher {
+	w := &configWatcher{modTimes: make(map[string]time.Time)}
+	for _, path := range paths {
+		if path != "" {
+			w.modTimes[path] = watch.ModTime(path)
+		}
+	}
+	return w
+}
+
+// changed reports whether any watched file changed since the last call
+func (w *configWatcher) changed() bool {
+	changed := false
+	for path, last := range w.modTimes {
+		if current := watch.ModTime(path); !current.Equal(last) {
+			w.modTimes[path] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
+// EnableReload makes the session pick up config changes. The files in
+// paths are checked before each command, and ":reload" on a line of its own
+// reloads on demand in interactive mode.
+func (a *App) EnableReload(load ConfigLoader, paths ...string) {
+	a.loadConfig = load
+	a.watcher = newConfigWatcher(paths...)
+}
+
+// reloadConfig rebuilds the configuration and applies the settings that can
+// change mid-session. Changes to what the LLM may read, write, or run are
+// audited; settings that need a restart are reported and left alone.
+func (a *App) reloadConfig(sc *scanner.Scanner) {
+	if a.loadConfig == nil {
+		fmt.Fprintln(os.Stderr, "Config reload is not available")
+		return
+	}
+
+	next, err := a.loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config reload failed, keeping current settings: %v\n", err)
+		a.auditReload("", false, err.Error())
+		return
+	}
+
+	// The scratch space was made at bootstrap, so the new config lacks it
+	next.ScratchDir = a.config.ScratchDir
+	applied, restart := config.ApplyReload(a.config, next)
+	sc.SetMaxCommandSize(int(a.config.MaxCommandSize))
+	sc.SetStrict(a.config.StrictParsing)
+	sc.SetParseErrors(a.config.ParseErrors)
+
+	if len(applied) == 0 && len(restart) == 0 {
+		if !a.config.Quiet {
+			fmt.Fprintln(os.Stderr, "Config reloaded: no changes")
+		}
+		return
+	}
+
+	for _, change := range applied {
+		if change.Security {
+			fmt.Fprintf(os.Stderr, "Config reloaded: security setting %s\n", change)
+			a.auditReload(change.Setting, true, fmt.Sprintf("old:%s,new:%s", change.Old, change.New))
+		} else if a.config.Verbose && !a.config.Quiet {
+			fmt.Fprintf(os.Stderr, "Config reloaded: %s\n", change)
+		}
+	}
+	if len(applied) > 0 && !a.config.Quiet {
+		fmt.Fprintf(os.Stderr, "Config reloaded: %d settings changed\n", len(applied))
+	}
+	if len(restart) > 0 {
+		fmt.Fprintf(os.Stderr, "Config reloaded: restart to apply %s\n", strings.Join(restart, ", "))
+	}
+}
+
+// auditReload records a config reload event in the session audit log
+func (a *App) auditReload(setting string, success bool, msg string) {
+	if a.session != nil {
+		a.session.LogAudit("config_reload", setting, success, msg)
+	}
+}
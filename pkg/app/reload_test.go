@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestConfigWatcher_Changed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "llm-runtime.config.yaml")
+	w := newConfigWatcher(path)
+
+	if w.changed() {
+		t.Error("changed() = true before the file exists")
+	}
+
+	if err := os.WriteFile(path, []byte("verbose: true\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if !w.changed() {
+		t.Error("changed() = false after creating the file")
+	}
+	if w.changed() {
+		t.Error("changed() = true with no further change")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Chtimes() error: %v", err)
+	}
+	if !w.changed() {
+		t.Error("changed() = false after modifying the file")
+	}
+}
+
+func TestApp_ReloadConfig(t *testing.T) {
+	cfg := &config.Config{
+		RepositoryRoot: t.TempDir(),
+		ExecWhitelist:  []string{"go test"},
+		MaxCommandSize: 1024,
+	}
+	a := &App{config: cfg}
+	a.EnableReload(func() (*config.Config, error) {
+		next := *cfg
+		next.ExecWhitelist = []string{"go test", "make"}
+		next.RepositoryRoot = "/elsewhere"
+		return &next, nil
+	})
+	sc := scanner.NewScanner(bufio.NewReader(strings.NewReader("")), true)
+
+	stderr := captureStderr(t, func() { a.reloadConfig(sc) })
+
+	if len(cfg.ExecWhitelist) != 2 {
+		t.Errorf("ExecWhitelist = %q, want reloaded whitelist", cfg.ExecWhitelist)
+	}
+	if cfg.RepositoryRoot == "/elsewhere" {
+		t.Error("RepositoryRoot changed without a restart")
+	}
+	if !strings.Contains(stderr, "security setting ExecWhitelist") {
+		t.Errorf("expected security change report, got: %s", stderr)
+	}
+	if !strings.Contains(stderr, "restart to apply RepositoryRoot") {
+		t.Errorf("expected restart notice, got: %s", stderr)
+	}
+}
+
+func TestApp_ReloadConfig_Error(t *testing.T) {
+	cfg := &config.Config{ExecWhitelist: []string{"go test"}}
+	a := &App{config: cfg}
+	a.EnableReload(func() (*config.Config, error) {
+		return nil, errors.New("bad yaml")
+	})
+	sc := scanner.NewScanner(bufio.NewReader(strings.NewReader("")), true)
+
+	stderr := captureStderr(t, func() { a.reloadConfig(sc) })
+
+	if !strings.Contains(stderr, "keeping current settings: bad yaml") {
+		t.Errorf("expected reload failure message, got: %s", stderr)
+	}
+	if len(cfg.ExecWhitelist) != 1 {
+		t.Errorf("ExecWhitelist = %q, want unchanged", cfg.ExecWhitelist)
+	}
+}
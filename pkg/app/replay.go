@@ -0,0 +1,138 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/transcript"
+)
+
+// ReplayReport summarizes a replay compared against its recording
+type ReplayReport struct {
+	Commands int // Commands replayed
+	Differ   int // Commands whose results differ from the recording
+	Missing  int // Recorded results with no replayed command
+}
+
+// timing matches the elapsed times in search output, which differ on
+// every run
+var timing = regexp.MustCompile(`\(\d+\.\d+s\)`)
+
+// Replay re-executes the input of a recorded session. Results are written
+// to output as in pipe mode; with compare, each is instead checked against
+// the recorded result and only differences are written.
+func (a *App) Replay(entries []transcript.Entry, output io.Writer, compare bool) ReplayReport {
+	interactive := false
+	var input strings.Builder
+	var recorded []transcript.Result
+	for _, entry := range entries {
+		switch entry.Type {
+		case transcript.TypeStart:
+			interactive = entry.Interactive
+		case transcript.TypeInput:
+			input.WriteString(entry.Input)
+		case transcript.TypeResult:
+			recorded = append(recorded, *entry.Result)
+		}
+	}
+
+	sc := scanner.NewScanner(bufio.NewReader(strings.NewReader(input.String())), interactive)
+	sc.SetMaxCommandSize(int(a.config.MaxCommandSize))
+	sc.SetStrict(a.config.StrictParsing)
+	sc.SetParseErrors(a.config.ParseErrors)
+
+	var report ReplayReport
+	for {
+		cmd := sc.Scan()
+		if cmd == nil {
+			break
+		}
+		a.transcribe(*cmd)
+
+		// Meta-commands are replayed for their effects, such as :undo
+		if cmd.Type == "meta" {
+			if a.runMeta(cmd.Argument, sc, io.Discard) {
+				break
+			}
+			continue
+		}
+		if interactive {
+			a.executor.StartTurn()
+		}
+
+		start := time.Now()
+		a.snapshot(*cmd)
+		result := a.executor.Execute(*cmd)
+		if result.ExecutionTime == 0 && !a.config.Deterministic {
+			result.ExecutionTime = time.Since(start)
+		}
+		a.record(result)
+		report.Commands++
+
+		if !compare {
+			writeResult(output, *cmd, result, a.executor.GetCommandsRun(), a.elapsed())
+			continue
+		}
+
+		replayed := transcript.NewResult(result)
+		if report.Commands > len(recorded) {
+			report.Differ++
+			fmt.Fprintf(output, "=== DIFF: command %d <%s %s> ===\nNot in the recording\n=== END DIFF ===\n", report.Commands, cmd.Type, cmd.Argument)
+			continue
+		}
+		if diffs := compareResults(recorded[report.Commands-1], replayed); len(diffs) > 0 {
+			report.Differ++
+			fmt.Fprintf(output, "=== DIFF: command %d <%s %s> ===\n", report.Commands, cmd.Type, cmd.Argument)
+			for _, diff := range diffs {
+				fmt.Fprintln(output, diff)
+			}
+			fmt.Fprint(output, "=== END DIFF ===\n")
+		}
+	}
+
+	if compare {
+		report.Missing = max(len(recorded)-report.Commands, 0)
+		for i := report.Commands; i < len(recorded); i++ {
+			cmd := recorded[i].Command
+			fmt.Fprintf(output, "=== DIFF: command %d <%s %s> ===\nNot replayed\n=== END DIFF ===\n", i+1, cmd.Type, cmd.Argument)
+		}
+	}
+	return report
+}
+
+// compareResults describes how a replayed result differs from the recorded
+// one. Durations and elapsed times in output are ignored.
+func compareResults(recorded, replayed transcript.Result) []string {
+	var diffs []string
+	field := func(name string, old, new interface{}) {
+		if old != new {
+			diffs = append(diffs, fmt.Sprintf("%s: %q -> %q", name, fmt.Sprint(old), fmt.Sprint(new)))
+		}
+	}
+
+	field("command", recorded.Command.Type+" "+recorded.Command.Argument, replayed.Command.Type+" "+replayed.Command.Argument)
+	field("success", recorded.Success, replayed.Success)
+	field("action", recorded.Action, replayed.Action)
+	field("exit code", recorded.ExitCode, replayed.ExitCode)
+	field("error", recorded.Error, replayed.Error)
+
+	oldOutput := timing.ReplaceAllString(recorded.Output, "")
+	newOutput := timing.ReplaceAllString(replayed.Output, "")
+	if oldOutput != newOutput {
+		diffs = append(diffs, "output:\n"+strings.TrimSuffix(evaluator.UnifiedDiff("recorded", "replayed", oldOutput, newOutput), "\n"))
+	}
+
+	field("steps", len(recorded.Steps), len(replayed.Steps))
+	for i := 0; i < min(len(recorded.Steps), len(replayed.Steps)); i++ {
+		for _, diff := range compareResults(recorded.Steps[i], replayed.Steps[i]) {
+			diffs = append(diffs, fmt.Sprintf("step %d %s", i+1, diff))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/transcript"
+)
+
+func TestApp_RecordAndReplay(t *testing.T) {
+	root := t.TempDir()
+	cfg := dockerlessConfig(root)
+	cfg.TranscriptFile = filepath.Join(t.TempDir(), "session.jsonl")
+
+	recording, err := Bootstrap(cfg)
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	// The writes are refused the same way on every run, so a replay under
+	// the same config reproduces each result
+	recording.ExecuteReply("<set name=dir value=.git>\n<write ${dir}/config>x</write>\n<write .env>SECRET=1</write>")
+	recording.Close()
+
+	entries, err := transcript.ReadFile(cfg.TranscriptFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Type]++
+	}
+	if counts["start"] != 1 || counts["input"] != 1 || counts["command"] != 3 || counts["result"] != 3 {
+		t.Fatalf("entry counts = %v", counts)
+	}
+
+	replaying, err := Bootstrap(dockerlessConfig(root))
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	var out bytes.Buffer
+	report := replaying.Replay(entries, &out, true)
+	if report != (ReplayReport{Commands: 3}) || out.Len() != 0 {
+		t.Errorf("report = %+v, output:\n%s", report, out.String())
+	}
+
+	// With .env no longer excluded the write gets further and fails differently
+	changed := dockerlessConfig(root)
+	changed.ExcludedPaths = []string{".git"}
+	changed.AllowedExtensions = nil
+	replaying, err = Bootstrap(changed)
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	out.Reset()
+	report = replaying.Replay(entries, &out, true)
+	if report.Differ != 1 || !strings.Contains(out.String(), "=== DIFF: command 3 <write .env> ===") {
+		t.Errorf("report = %+v, output:\n%s", report, out.String())
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	recorded := transcript.Result{Success: true, Output: "=== SEARCH RESULTS (0.12s) ===\nmain.go\n", DurationMS: 120}
+	replayed := transcript.Result{Success: true, Output: "=== SEARCH RESULTS (0.31s) ===\nmain.go\n", DurationMS: 310}
+	if diffs := compareResults(recorded, replayed); len(diffs) != 0 {
+		t.Errorf("timing alone reported as differences: %v", diffs)
+	}
+
+	replayed.Output = "=== SEARCH RESULTS (0.31s) ===\nutil.go\n"
+	replayed.Success = false
+	diffs := compareResults(recorded, replayed)
+	if len(diffs) != 2 || diffs[0] != `success: "true" -> "false"` || !strings.Contains(diffs[1], "-main.go\n+util.go") {
+		t.Errorf("diffs = %q", diffs)
+	}
+
+	recorded.Steps = []transcript.Result{{ExitCode: 0}}
+	replayed = recorded
+	replayed.Steps = []transcript.Result{{ExitCode: 1}}
+	if diffs := compareResults(recorded, replayed); len(diffs) != 1 || diffs[0] != `step 1 exit code: "0" -> "1"` {
+		t.Errorf("step diffs = %q", diffs)
+	}
+}
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Report summarizes what a session changed and ran, as written by --report
+type Report struct {
+	SessionID  string         `json:"session_id"`
+	Repository string         `json:"repository"`
+	Started    time.Time      `json:"started"`
+	Duration   float64        `json:"duration_seconds"`
+	Files      []FileChange   `json:"files"`
+	Commands   map[string]int `json:"commands"`
+	Failures   []Failure      `json:"failures"`
+	ExecTime   float64        `json:"exec_seconds"`
+	Containers int64          `json:"containers"`
+	Backups    []string       `json:"backups"`
+}
+
+// FileChange is a file created, modified, or deleted during the session,
+// compared with its state before the session first wrote it
+type FileChange struct {
+	Path    string `json:"path"`
+	Change  string `json:"change"` // created, modified, or deleted
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// Failure is a command that failed, with its error code and the reason
+// given
+type Failure struct {
+	Command string `json:"command"`
+	Code    string `json:"code"`
+	Reason  string `json:"reason"`
+}
+
+// snapshot remembers the content of each file cmd writes, before the
+// session first writes it, so the report can show what changed
+func (a *App) snapshot(cmd scanner.Command) {
+	for _, step := range cmd.Steps {
+		a.snapshot(step)
+	}
+	if cmd.Type != "write" && cmd.Type != "append" {
+		return
+	}
+	path, err := sandbox.ValidatePath(cmd.Argument, a.config.RepositoryRoot, a.config.ExcludedPaths)
+	if err != nil {
+		return
+	}
+	a.rememberOriginal(path, path)
+}
+
+// rememberOriginal records the content of source as the original content
+// of path, unless path already has one. A missing source means the file did
+// not exist.
+func (a *App) rememberOriginal(path, source string) {
+	if _, ok := a.originals[path]; ok {
+		return
+	}
+	if a.originals == nil {
+		a.originals = make(map[string]*string)
+	}
+	var original *string
+	if data, err := os.ReadFile(source); err == nil {
+		content := string(data)
+		original = &content
+	}
+	a.originals[path] = original
+}
+
+// Report builds the end-of-session report
+func (a *App) Report() Report {
+	report := Report{
+		Repository: a.config.RepositoryRoot,
+		Files:      []FileChange{},
+		Commands:   make(map[string]int),
+		Failures:   []Failure{},
+		ExecTime:   a.execTime.Seconds(),
+		Containers: a.containers,
+		Backups:    append([]string{}, a.backups...),
+	}
+	if a.session != nil {
+		report.SessionID = a.session.ID
+		report.Started = a.session.StartTime
+		report.Duration = a.elapsed().Seconds()
+	}
+	if a.pool != nil {
+		report.Containers, _ = a.pool.Stats()["containers_created"].(int64)
+	}
+
+	for cmdType, t := range a.stats().ByType {
+		report.Commands[cmdType] = t.Run
+	}
+	for _, entry := range a.history {
+		if entry.status == "FAIL" {
+			report.Failures = append(report.Failures, Failure{
+				Command: fmt.Sprintf("<%s %s>", entry.command.Type, entry.command.Argument),
+				Code:    entry.code,
+				Reason:  entry.reason,
+			})
+		}
+	}
+
+	for path, original := range a.originals {
+		change := FileChange{Path: path}
+		if rel, err := filepath.Rel(a.config.RepositoryRoot, path); err == nil {
+			change.Path = rel
+		}
+
+		data, err := os.ReadFile(path)
+		exists := err == nil
+		switch {
+		case original == nil && !exists:
+			continue
+		case original == nil:
+			change.Change = "created"
+			change.Added, _ = evaluator.DiffStat("", string(data))
+		case !exists:
+			change.Change = "deleted"
+			_, change.Removed = evaluator.DiffStat(*original, "")
+		case *original == string(data):
+			continue
+		default:
+			change.Change = "modified"
+			change.Added, change.Removed = evaluator.DiffStat(*original, string(data))
+		}
+		report.Files = append(report.Files, change)
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+	return report
+}
+
+// WriteText writes the report for people, in Markdown so it can start a
+// pull request description
+func (r Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "# Session %s\n\n", r.SessionID)
+	fmt.Fprintf(w, "Repository: %s\n", r.Repository)
+	fmt.Fprintf(w, "Duration: %s\n\n", (time.Duration(r.Duration * float64(time.Second))).Round(time.Second))
+
+	fmt.Fprintf(w, "## Files (%d)\n\n", len(r.Files))
+	if len(r.Files) == 0 {
+		fmt.Fprintln(w, "No files changed.")
+	}
+	for _, f := range r.Files {
+		fmt.Fprintf(w, "- %s `%s` (+%d -%d)\n", f.Change, f.Path, f.Added, f.Removed)
+	}
+
+	types := make([]string, 0, len(r.Commands))
+	total := 0
+	for cmdType, n := range r.Commands {
+		types = append(types, cmdType)
+		total += n
+	}
+	sort.Strings(types)
+	fmt.Fprintf(w, "\n## Commands (%d)\n\n", total)
+	for _, cmdType := range types {
+		fmt.Fprintf(w, "- %s: %d\n", cmdType, r.Commands[cmdType])
+	}
+
+	fmt.Fprintf(w, "\n## Failures (%d)\n\n", len(r.Failures))
+	for _, f := range r.Failures {
+		fmt.Fprintf(w, "- `%s`: %s\n", f.Command, strings.Join(strings.Fields(f.Reason), " "))
+	}
+	if len(r.Failures) == 0 {
+		fmt.Fprintln(w, "None.")
+	}
+
+	fmt.Fprintf(w, "\n## Resources\n\n")
+	fmt.Fprintf(w, "- Exec time: %.2fs\n", r.ExecTime)
+	fmt.Fprintf(w, "- Containers launched: %d\n", r.Containers)
+	fmt.Fprintf(w, "- Backups created: %d\n", len(r.Backups))
+	for _, b := range r.Backups {
+		fmt.Fprintf(w, "  - %s\n", b)
+	}
+}
+
+// pullRequestSummary is the report of the session so far in Markdown, as
+// <pr> adds it to pull request descriptions. Paths on this host are left
+// out, since the description is published.
+func (a *App) pullRequestSummary() string {
+	report := a.Report()
+	report.Repository = filepath.Base(report.Repository)
+	for i, backup := range report.Backups {
+		report.Backups[i] = filepath.Base(backup)
+	}
+	var b strings.Builder
+	report.WriteText(&b)
+	return b.String()
+}
+
+// writeReport writes the report to path: JSON for a .json file, Markdown
+// otherwise, and Markdown to stderr for "-"
+func (a *App) writeReport(path string) error {
+	report := a.Report()
+	if path == "-" {
+		report.WriteText(os.Stderr)
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot write report: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		enc := json.NewEncoder(file)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	report.WriteText(file)
+	return nil
+}
+
+// execTimeOf totals the time spent in exec commands of a result and its
+// steps
+func execTimeOf(result scanner.ExecutionResult) time.Duration {
+	var total time.Duration
+	if result.Command.Type == "exec" && result.Action != "SKIPPED" {
+		total += result.ExecutionTime
+	}
+	for _, step := range result.Steps {
+		total += execTimeOf(step)
+	}
+	return total
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+)
+
+func TestApp_Report(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "same.txt"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a := &App{config: &config.Config{RepositoryRoot: root}, session: &session.Session{StartTime: time.Now()}}
+
+	writeMain := scanner.Command{Type: "write", Argument: "main.go"}
+	writeSame := scanner.Command{Type: "write", Argument: "same.txt"}
+	writeNew := scanner.Command{Type: "write", Argument: "docs/new.md"}
+	for _, cmd := range []scanner.Command{writeMain, writeSame, {Type: "pipe", Steps: []scanner.Command{writeNew}}} {
+		a.snapshot(cmd)
+	}
+
+	// Stand in for the writes the commands would make
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("a\nx\nc\nd\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "docs"), 0755)
+	os.WriteFile(filepath.Join(root, "docs/new.md"), []byte("one\ntwo\n"), 0644)
+	os.WriteFile(filepath.Join(root, "late.txt"), []byte("late\n"), 0644)
+
+	a.record(scanner.ExecutionResult{Command: writeMain, Success: true, Action: "UPDATED", BackupFile: "/backups/main.go.1"})
+	a.record(scanner.ExecutionResult{Command: writeSame, Success: true, Action: "UPDATED"})
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "pipe"}, Success: true, Steps: []scanner.ExecutionResult{
+		{Command: writeNew, Success: true, Action: "CREATED"},
+	}})
+	// Not snapshotted, as for a templated path: a created file still counts
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "write", Argument: "late.txt"}, Success: true, Action: "CREATED"})
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "exec", Argument: "go test"}, Error: errors.New(errors.ExecFailed, "command exited with code 1"), ExecutionTime: 2 * time.Second})
+
+	report := a.Report()
+
+	want := []FileChange{
+		{Path: "docs/new.md", Change: "created", Added: 2},
+		{Path: "late.txt", Change: "created", Added: 1},
+		{Path: "main.go", Change: "modified", Added: 2, Removed: 1},
+	}
+	if len(report.Files) != len(want) {
+		t.Fatalf("Files = %+v, want %+v", report.Files, want)
+	}
+	for i := range want {
+		if report.Files[i] != want[i] {
+			t.Errorf("Files[%d] = %+v, want %+v", i, report.Files[i], want[i])
+		}
+	}
+	if report.Commands["write"] != 3 || report.Commands["exec"] != 1 || report.Commands["pipe"] != 1 {
+		t.Errorf("Commands = %v", report.Commands)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].Command != "<exec go test>" || report.Failures[0].Code != "EXEC_FAILED" || !strings.Contains(report.Failures[0].Reason, "code 1") {
+		t.Errorf("Failures = %+v", report.Failures)
+	}
+	if report.ExecTime != 2 {
+		t.Errorf("ExecTime = %v, want 2", report.ExecTime)
+	}
+	if report.Containers != 5 {
+		t.Errorf("Containers = %d, want 5", report.Containers)
+	}
+	if len(report.Backups) != 1 || report.Backups[0] != "/backups/main.go.1" {
+		t.Errorf("Backups = %v", report.Backups)
+	}
+}
+
+func TestApp_WriteReport(t *testing.T) {
+	root := t.TempDir()
+	a := &App{config: &config.Config{RepositoryRoot: root}, session: &session.Session{StartTime: time.Now()}}
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: ".env"}, Error: errors.New(errors.PathSecurity, "access denied")})
+
+	jsonPath := filepath.Join(root, "report.json")
+	if err := a.writeReport(jsonPath); err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, data)
+	}
+	if report.Commands["open"] != 1 || len(report.Failures) != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	textPath := filepath.Join(root, "report.md")
+	if err := a.writeReport(textPath); err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	data, err = os.ReadFile(textPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Files (0)", "No files changed.", "## Failures (1)", "- `<open .env>`: PATH_SECURITY: access denied"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("text report missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestApp_PullRequestSummary(t *testing.T) {
+	root := t.TempDir()
+	a := &App{config: &config.Config{RepositoryRoot: root}, session: &session.Session{ID: "s1", StartTime: time.Now()}}
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "write", Argument: "main.go"}, Success: true, Action: "UPDATED", BackupFile: filepath.Join(root, ".llm-tools", "backups", "main.go.1")})
+
+	summary := a.pullRequestSummary()
+	if !strings.Contains(summary, "# Session s1") || !strings.Contains(summary, "main.go.1") {
+		t.Errorf("summary = %q", summary)
+	}
+	if strings.Contains(summary, root) {
+		t.Errorf("summary has host paths: %q", summary)
+	}
+}
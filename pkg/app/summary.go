@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+)
+
+// summaryLine renders a command result as a single line for --summary
+func summaryLine(result scanner.ExecutionResult) string {
+	line := fmt.Sprintf("%-4s  <%s %s>  %.2fs", resultStatus(result), result.Command.Type, result.Command.Argument, result.ExecutionTime.Seconds())
+	if !result.Success && result.Error != nil {
+		// Keep multi-line errors, such as syntax errors, on the one line
+		line += "  " + strings.Join(strings.Fields(result.Error.Error()), " ")
+	}
+	return line
+}
+
+// resultStatus classifies a result as ok, FAIL, or skip
+func resultStatus(result scanner.ExecutionResult) string {
+	switch {
+	case result.Action == "SKIPPED":
+		return "skip"
+	case !result.Success:
+		return "FAIL"
+	}
+	return "ok"
+}
+
+// errorCode returns the code of a result's error, such as "EXEC_TIMEOUT",
+// or "ERROR" if it has none
+func errorCode(err error) string {
+	if code := errors.CodeOf(err); code != "" {
+		return string(code)
+	}
+	return "ERROR"
+}
+
+// writeSummary writes the final --summary table: results and time spent
+// per command type, then totals
+func writeSummary(output io.Writer, stats session.Stats, elapsed time.Duration) {
+	types := make([]string, 0, len(stats.ByType))
+	for cmdType := range stats.ByType {
+		types = append(types, cmdType)
+	}
+	sort.Strings(types)
+
+	fmt.Fprint(output, "=== SUMMARY ===\n")
+	tw := tabwriter.NewWriter(output, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "TYPE\tOK\tFAILED\tSKIPPED\tTIME\t\n")
+	for _, cmdType := range types {
+		writeSummaryRow(tw, cmdType, stats.ByType[cmdType])
+	}
+	writeSummaryRow(tw, "total", stats.Tally)
+	tw.Flush()
+	fmt.Fprintf(output, "Time elapsed: %.2fs\n", elapsed.Seconds())
+	fmt.Fprint(output, "=== END SUMMARY ===\n")
+}
+
+func writeSummaryRow(w io.Writer, name string, t session.Tally) {
+	ok := t.Run - t.Failed - t.Skipped
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2fs\t\n", name, ok, t.Failed, t.Skipped, t.Duration.Seconds())
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+)
+
+func TestSummaryLine(t *testing.T) {
+	tests := []struct {
+		result scanner.ExecutionResult
+		want   string
+	}{
+		{
+			scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: "main.go"}, Success: true, ExecutionTime: 20 * time.Millisecond},
+			"ok    <open main.go>  0.02s",
+		},
+		{
+			scanner.ExecutionResult{Command: scanner.Command{Type: "write", Argument: "main.go"}, Error: errors.New(errors.SyntaxError, "main.go:3:1:\n  expected '}'")},
+			"FAIL  <write main.go>  0.00s  SYNTAX_ERROR: main.go:3:1: expected '}'",
+		},
+		{
+			scanner.ExecutionResult{Command: scanner.Command{Type: "exec", Argument: "make"}, Action: "SKIPPED"},
+			"skip  <exec make>  0.00s",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := summaryLine(tt.result); got != tt.want {
+			t.Errorf("summaryLine() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	stats := session.Stats{
+		Tally: session.Tally{Run: 3, Failed: 1, Duration: 4 * time.Second},
+		ByType: map[string]session.Tally{
+			"open": {Run: 2, Duration: 2 * time.Second},
+			"exec": {Run: 1, Failed: 1, Duration: 2 * time.Second},
+		},
+	}
+
+	var out bytes.Buffer
+	writeSummary(&out, stats, 5*time.Second)
+
+	rows := make(map[string][]string)
+	for _, line := range strings.Split(out.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) == 5 {
+			rows[fields[0]] = fields[1:]
+		}
+	}
+	want := map[string]string{
+		"open":  "2 0 0 2.00s",
+		"exec":  "0 1 0 2.00s",
+		"total": "2 1 0 4.00s",
+	}
+	for cmdType, cols := range want {
+		if got := strings.Join(rows[cmdType], " "); got != cols {
+			t.Errorf("%s row = %q, want %q\n%s", cmdType, got, cols, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "Time elapsed: 5.00s") {
+		t.Errorf("missing elapsed time:\n%s", out.String())
+	}
+}
+
+// TestApp_Run_Summary tests that summary mode replaces result blocks with
+// one line per command and a final table
+func TestApp_Run_Summary(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.txt")
+	outputFile := filepath.Join(tempDir, "output.txt")
+	// Both writes fail, so each gets a FAIL line
+	if err := os.WriteFile(inputFile, []byte("<write .env>SECRET=1</write>\n<write .git/config>x</write>\n"), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+
+	cfg := dockerlessConfig(tempDir)
+	cfg.InputFile = inputFile
+	cfg.OutputFile = outputFile
+	cfg.Summary = true
+
+	app, err := Bootstrap(cfg)
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	output, _ := os.ReadFile(outputFile)
+	if strings.Contains(string(output), "=== LLM TOOL START ===") {
+		t.Errorf("summary mode printed result blocks:\n%s", output)
+	}
+	if !strings.HasPrefix(string(output), "FAIL  <write .env>") {
+		t.Errorf("expected a summary line per command:\n%s", output)
+	}
+	if !strings.Contains(string(output), "=== SUMMARY ===") {
+		t.Errorf("expected a final summary table:\n%s", output)
+	}
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/tui"
+)
+
+// RunTUI runs the session under the full-screen dashboard drawn on tty.
+// Commands are read from the input as in pipe mode, and results go to the
+// output file, or to stdout when it is not the terminal. With
+// require-confirmation set, writes wait in the approval queue.
+func (a *App) RunTUI(tty *os.File) error {
+	input := os.Stdin
+	if a.config.InputFile != "" {
+		file, err := os.Open(a.config.InputFile)
+		if err != nil {
+			return fmt.Errorf("cannot read input file: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var output io.Writer = io.Discard
+	if a.config.OutputFile != "" {
+		file, err := os.Create(a.config.OutputFile)
+		if err != nil {
+			return fmt.Errorf("cannot write output file: %w", err)
+		}
+		defer file.Close()
+		output = file
+	} else if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		output = os.Stdout
+	}
+
+	program := tui.NewProgram(tty)
+	go a.superviseInput(program, input, output)
+
+	model, err := program.Run()
+	if err != nil {
+		return err
+	}
+
+	stats := model.Stats()
+	fmt.Fprintf(os.Stderr, "Session ended: %d commands (%d failed), %d bytes written, %d containers\n",
+		stats.CommandsRun, stats.Failed, stats.BytesWritten, stats.ContainersLaunched)
+	return nil
+}
+
+// superviseInput executes the commands in input, reporting each to the
+// dashboard and holding writes for approval when confirmation is required
+func (a *App) superviseInput(program *tui.Program, input io.Reader, output io.Writer) {
+	if a.transcript != nil {
+		input = a.transcript.Reader(input)
+	}
+	sc := scanner.NewScanner(bufio.NewReader(input), false)
+	sc.SetMaxCommandSize(int(a.config.MaxCommandSize))
+	sc.SetStrict(a.config.StrictParsing)
+	sc.SetParseErrors(a.config.ParseErrors)
+
+	var containers int64
+	for {
+		cmd := sc.Scan()
+		if cmd == nil {
+			break
+		}
+		a.transcribe(*cmd)
+
+		var result scanner.ExecutionResult
+		if a.config.RequireConfirmation && writes(*cmd) && !program.RequestApproval(*cmd) {
+			result = scanner.ExecutionResult{Command: *cmd, Action: "SKIPPED", Result: "denied by operator"}
+			if a.session != nil {
+				a.session.LogAudit(cmd.Type, cmd.Argument, false, "denied by operator")
+			}
+		} else {
+			program.Send(tui.CommandStarted{Command: *cmd})
+			start := time.Now()
+			a.snapshot(*cmd)
+			result = a.executor.Execute(*cmd)
+			if result.ExecutionTime == 0 && !a.config.Deterministic {
+				result.ExecutionTime = time.Since(start)
+			}
+			a.record(result)
+			containers += containerCommands(result)
+		}
+
+		writeResult(output, *cmd, result, a.executor.GetCommandsRun(), a.elapsed())
+		program.Send(tui.CommandFinished{Result: result})
+
+		if a.pool != nil {
+			containers, _ = a.pool.Stats()["containers_created"].(int64)
+		}
+		program.Send(tui.ContainersLaunched{Count: containers})
+	}
+	program.Send(tui.InputClosed{})
+}
+
+// writes reports whether cmd writes files, directly or in one of its steps
+func writes(cmd scanner.Command) bool {
+	if cmd.Type == "write" || cmd.Type == "append" {
+		return true
+	}
+	for _, step := range cmd.Steps {
+		if writes(step) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerCommands counts the containers a result started when no pool is
+// in use: each open, write, exec, and tail runs in a fresh container
+func containerCommands(result scanner.ExecutionResult) int64 {
+	var n int64
+	switch result.Command.Type {
+	case "open", "write", "append", "exec", "tail":
+		if result.Action != "SKIPPED" {
+			n++
+		}
+	}
+	for _, step := range result.Steps {
+		n += containerCommands(step)
+	}
+	return n
+}
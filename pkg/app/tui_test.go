@@ -0,0 +1,45 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestWrites(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  scanner.Command
+		want bool
+	}{
+		{"write", scanner.Command{Type: "write", Argument: "main.go"}, true},
+		{"open", scanner.Command{Type: "open", Argument: "main.go"}, false},
+		{"pipe with write", scanner.Command{Type: "pipe", Steps: []scanner.Command{
+			{Type: "exec", Argument: "go fmt"},
+			{Type: "write", Argument: "out.txt"},
+		}}, true},
+		{"pipe without write", scanner.Command{Type: "pipe", Steps: []scanner.Command{
+			{Type: "exec", Argument: "go test"},
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := writes(tt.cmd); got != tt.want {
+			t.Errorf("%s: writes() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContainerCommands(t *testing.T) {
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "if-success"},
+		Steps: []scanner.ExecutionResult{
+			{Command: scanner.Command{Type: "exec"}},
+			{Command: scanner.Command{Type: "write"}, Action: "SKIPPED"},
+			{Command: scanner.Command{Type: "search"}},
+		},
+	}
+	if got := containerCommands(result); got != 1 {
+		t.Errorf("containerCommands() = %d, want 1", got)
+	}
+}
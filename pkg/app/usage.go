@@ -0,0 +1,95 @@
+package app
+
+import (
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/usage"
+)
+
+// RecordModelUsage adds the tokens an agent's model used, and what they
+// cost, to the session's usage
+func (a *App) RecordModelUsage(model string, inputTokens, outputTokens int, cost float64) {
+	a.model = model
+	a.tokensIn += inputTokens
+	a.tokensOut += outputTokens
+	a.cost += cost
+}
+
+// Usage returns the resources the session has used so far
+func (a *App) Usage() usage.Entry {
+	entry := usage.Entry{
+		Repository:   a.config.RepositoryRoot,
+		Label:        a.config.UsageLabel,
+		User:         usage.CurrentUser(),
+		Commands:     a.stats().Run,
+		Containers:   a.containers,
+		CPUSeconds:   a.cpuSeconds,
+		BytesWritten: a.written,
+		Model:        a.model,
+		InputTokens:  a.tokensIn,
+		OutputTokens: a.tokensOut,
+		Cost:         a.cost,
+	}
+	// Attributed to the repository the session was given, not the copy
+	// it worked in
+	switch {
+	case a.objects != nil:
+		entry.Repository = a.config.ObjectSource
+	case a.worktree != nil:
+		entry.Repository = a.worktree.Origin()
+	}
+	if a.pool != nil {
+		entry.Containers, _ = a.pool.Stats()["containers_created"].(int64)
+	}
+	if a.session != nil {
+		entry.Session = a.session.ID
+		entry.Start = a.session.StartTime
+		entry.End = a.session.StartTime.Add(a.elapsed())
+	}
+	if entry.End.IsZero() {
+		entry.End = time.Now()
+	}
+	return entry
+}
+
+// cpuSecondsOf estimates the CPU time the containers of a result and its
+// steps were allowed: the time of each command run in a container times
+// the CPUs it could use, counting an unlimited container as one CPU
+func cpuSecondsOf(result scanner.ExecutionResult, cfg *config.Config) float64 {
+	var total float64
+	if result.Action != "SKIPPED" {
+		cpus := 0
+		switch result.Command.Type {
+		case "exec":
+			cpus = cfg.ExecCPULimit
+		case "open", "write", "append", "tail":
+			cpus = cfg.IOCPULimit
+		default:
+			cpus = -1
+		}
+		if cpus == 0 {
+			cpus = 1
+		}
+		if cpus > 0 {
+			total += result.ExecutionTime.Seconds() * float64(cpus)
+		}
+	}
+	for _, step := range result.Steps {
+		total += cpuSecondsOf(step, cfg)
+	}
+	return total
+}
+
+// bytesWrittenOf totals the bytes a result and its steps wrote
+func bytesWrittenOf(result scanner.ExecutionResult) int64 {
+	if len(result.Steps) == 0 {
+		return result.BytesWritten
+	}
+	var total int64
+	for _, step := range result.Steps {
+		total += bytesWrittenOf(step)
+	}
+	return total
+}
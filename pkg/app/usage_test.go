@@ -0,0 +1,44 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+	"github.com/computerscienceiscool/llm-runtime/pkg/usage"
+)
+
+func TestApp_Usage(t *testing.T) {
+	ledger := filepath.Join(t.TempDir(), "usage.jsonl")
+	cfg := &config.Config{RepositoryRoot: "/repo", ExecCPULimit: 2, UsageLedger: ledger, UsageLabel: "platform"}
+	a := &App{config: cfg, session: &session.Session{ID: "s1", StartTime: time.Now()}}
+
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "exec", Argument: "go test"}, Success: true, ExecutionTime: 3 * time.Second})
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "pipe"}, Success: true, Steps: []scanner.ExecutionResult{
+		// IOCPULimit 0 is unlimited, counted as one CPU
+		{Command: scanner.Command{Type: "write", Argument: "a.go"}, Success: true, Action: "CREATED", BytesWritten: 100, ExecutionTime: time.Second},
+		{Command: scanner.Command{Type: "append", Argument: "b.md"}, Success: true, Action: "APPENDED", BytesWritten: 20, ExecutionTime: time.Second},
+	}})
+	a.record(scanner.ExecutionResult{Command: scanner.Command{Type: "search", Argument: "x"}, Success: true, ExecutionTime: time.Second})
+	a.RecordModelUsage("claude-sonnet-4-5", 1000, 200, 0.25)
+
+	got := a.Usage()
+	if got.Session != "s1" || got.Repository != "/repo" || got.Label != "platform" || got.User == "" {
+		t.Errorf("Usage() = %+v", got)
+	}
+	if got.Containers != 3 || got.CPUSeconds != 8 || got.BytesWritten != 120 {
+		t.Errorf("Usage() containers = %d, CPU = %v, bytes = %d; want 3, 8, 120", got.Containers, got.CPUSeconds, got.BytesWritten)
+	}
+	if got.Model != "claude-sonnet-4-5" || got.InputTokens != 1000 || got.OutputTokens != 200 || got.Cost != 0.25 {
+		t.Errorf("Usage() model usage = %+v", got)
+	}
+
+	a.Close()
+	entries, err := usage.Read(ledger, time.Time{})
+	if err != nil || len(entries) != 1 || entries[0].Session != "s1" {
+		t.Errorf("ledger = %+v, %v; want the session", entries, err)
+	}
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"io"
+	"path/filepath"
+)
+
+// RunScript executes the commands of script as pipe mode executes its
+// input, as one turn of its own, writing the results to output. It returns
+// the files the script wrote, relative to the repository root, so a
+// watcher can tell them from the changes it is waiting for.
+func (a *App) RunScript(script io.Reader, output io.Writer) []string {
+	first := len(a.undo)
+	a.executor.StartTurn()
+	a.scanInput(a.executor, false, script, output)
+
+	var written []string
+	if first > len(a.undo) {
+		// The script ran :undo
+		first = len(a.undo)
+	}
+	for _, entry := range a.undo[first:] {
+		if rel, err := filepath.Rel(a.config.RepositoryRoot, entry.path); err == nil {
+			written = append(written, rel)
+		}
+	}
+	return written
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestApp_RunScript(t *testing.T) {
+	a, err := Bootstrap(dockerlessConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	defer a.Close()
+
+	// Each run executes the whole script again
+	script := "<set name=x value=1>\n<write .env>SECRET=1</write>\n"
+	for run := 1; run <= 2; run++ {
+		var out bytes.Buffer
+		written := a.RunScript(strings.NewReader(script), &out)
+		if len(written) != 0 {
+			t.Errorf("run %d wrote %v; want nothing, the write is refused", run, written)
+		}
+		if got := strings.Count(out.String(), "=== COMMAND: <write .env> ==="); got != 1 {
+			t.Errorf("run %d output has %d write results, want 1:\n%s", run, got, out.String())
+		}
+	}
+	if got := a.stats().Run; got != 4 {
+		t.Errorf("commands run = %d, want 4", got)
+	}
+}
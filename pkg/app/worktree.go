@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/worktree"
+)
+
+// startWorktree checks out the repository holding cfg.RepositoryRoot into
+// a worktree on a branch for the session, first removing worktrees that
+// earlier sessions abandoned
+func startWorktree(cfg *config.Config, sessionID string) (*worktree.Worktree, error) {
+	abandoned, err := worktree.Prune(cfg.RepositoryRoot, sandbox.OwnerRunning)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot prune abandoned worktrees: %v\n", err)
+	}
+	for _, a := range abandoned {
+		if cfg.Verbose {
+			fmt.Fprintf(os.Stderr, "Removed abandoned worktree of branch %s\n", a.Branch)
+		}
+	}
+
+	wt, err := worktree.Create(cfg.RepositoryRoot, cfg.WorktreeDir, "session-"+sessionID, sandbox.Owner())
+	if err != nil {
+		return nil, fmt.Errorf("--worktree: %w", err)
+	}
+	if !cfg.Quiet {
+		fmt.Fprintf(os.Stderr, "Working in %s on branch %s\n", wt.Dir, wt.Branch)
+	}
+	return wt, nil
+}
+
+// finishWorktree commits the session's changes to its branch, removes the
+// worktree, and says where the changes are, recording it in the audit log
+func (a *App) finishWorktree() error {
+	message := "llm-runtime session"
+	if a.session != nil {
+		message += " " + a.session.ID
+	}
+	summary, err := a.worktree.Finish(message)
+	if a.session != nil {
+		msg := "commit:" + summary.Commit
+		if err != nil {
+			msg = err.Error()
+		}
+		a.session.LogAudit("worktree_finish", summary.Branch, err == nil, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot finish worktree %s: %w", a.worktree.Dir, err)
+	}
+
+	if summary.Commit == "" {
+		if !a.config.Quiet {
+			fmt.Fprintf(os.Stderr, "No changes; deleted branch %s\n", summary.Branch)
+		}
+		return nil
+	}
+	// The branch is the session's result, so it is reported even when quiet
+	fmt.Fprintf(os.Stderr, "Changes are on branch %s:\n%s\n", summary.Branch, summary.DiffStat)
+	return nil
+}
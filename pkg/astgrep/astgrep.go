@@ -0,0 +1,400 @@
+// Package astgrep finds Go code by its structure rather than its text. A
+// pattern is Go source, an expression, a declaration, or statements, in
+// which metavariables stand for code:
+//
+//	$NAME   any one expression, statement, or name, which must have the
+//	        same text wherever NAME appears again
+//	$_      any one, not captured
+//	$$$     any number of arguments, statements, fields, or elements;
+//	        $$$NAME captures them
+//
+// RunContainer($CFG) finds the calls of RunContainer with one argument,
+// whatever the argument and however the call is spaced or commented. A
+// function named without its package matches calls through any package,
+// so it also finds sandbox.RunContainer(cfg).
+package astgrep
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// metaPrefix and multiPrefix begin the identifiers metavariables are
+// rewritten to, so that a pattern parses as Go
+const (
+	metaPrefix  = "__astgrep_"
+	multiPrefix = "__astgrep_multi_"
+)
+
+// metavariable matches $NAME, $_, $$$, and $$$NAME
+var metavariable = regexp.MustCompile(`\$(\$\$)?([A-Za-z_][A-Za-z0-9_]*)?`)
+
+// Pattern is a compiled pattern
+type Pattern struct {
+	source string
+	nodes  []ast.Node // One expression or declaration, or a run of statements
+}
+
+// Compile parses pattern
+func Compile(pattern string) (*Pattern, error) {
+	source := strings.TrimSpace(pattern)
+	if source == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	var bad error
+	rewritten := metavariable.ReplaceAllStringFunc(source, func(m string) string {
+		sub := metavariable.FindStringSubmatch(m)
+		if sub[1] != "" {
+			return multiPrefix + sub[2]
+		}
+		if sub[2] == "" {
+			bad = fmt.Errorf("$ must be followed by a name, as in $X, or be $$$")
+		}
+		return metaPrefix + sub[2]
+	})
+	if bad != nil {
+		return nil, bad
+	}
+
+	p := &Pattern{source: source}
+	if expr, err := parser.ParseExpr(rewritten); err == nil {
+		p.nodes = []ast.Node{expr}
+	} else if file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+rewritten, 0); err == nil && len(file.Decls) == 1 {
+		p.nodes = []ast.Node{file.Decls[0]}
+	} else {
+		file, err := parser.ParseFile(token.NewFileSet(), "", "package p\nfunc _() {\n"+rewritten+"\n}", 0)
+		if err != nil {
+			return nil, fmt.Errorf("not a Go expression, declaration, or statement: %s", pattern)
+		}
+		for _, stmt := range file.Decls[0].(*ast.FuncDecl).Body.List {
+			p.nodes = append(p.nodes, stmt)
+		}
+		if len(p.nodes) == 0 {
+			return nil, fmt.Errorf("empty pattern")
+		}
+	}
+	if len(p.nodes) == 1 {
+		if _, ok := metaName(p.nodes[0]); ok {
+			return nil, fmt.Errorf("a pattern of only a metavariable matches everything")
+		}
+	}
+	return p, nil
+}
+
+// String returns the pattern as it was written
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// Match is code a pattern matched
+type Match struct {
+	Start, End token.Position
+	Captures   []Capture // In the order the pattern names them
+}
+
+// Capture is the code a metavariable matched
+type Capture struct {
+	Name string // Such as $CFG, or $$$ARGS for a run
+	Text string
+}
+
+// Find returns the matches of p in the Go file src, named filename, in
+// the order they appear. Matches may nest, as a call matching inside the
+// argument of another.
+func (p *Pattern) Find(filename string, src []byte) ([]Match, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	m := &matcher{fset: fset, src: src}
+	var matches []Match
+	record := func(start, end ast.Node) {
+		matches = append(matches, Match{
+			Start:    fset.Position(start.Pos()),
+			End:      fset.Position(end.End()),
+			Captures: m.captures(),
+		})
+	}
+
+	root := reflect.TypeOf(p.nodes[0])
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if len(p.nodes) == 1 {
+			if reflect.TypeOf(n) == root {
+				m.reset()
+				if m.match(reflect.ValueOf(p.nodes[0]), reflect.ValueOf(n)) {
+					record(n, n)
+				}
+			}
+			return true
+		}
+		// A run of statements matches within a block
+		var list []ast.Stmt
+		switch b := n.(type) {
+		case *ast.BlockStmt:
+			list = b.List
+		case *ast.CaseClause:
+			list = b.Body
+		case *ast.CommClause:
+			list = b.Body
+		}
+		for i := range list {
+			for j := i + 1; j <= len(list); j++ {
+				m.reset()
+				if m.matchSlice(reflect.ValueOf(p.stmts()), reflect.ValueOf(list[i:j])) {
+					record(list[i], list[j-1])
+					break
+				}
+			}
+		}
+		return true
+	})
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Start.Offset < matches[j].Start.Offset })
+	return matches, nil
+}
+
+// stmts returns the nodes of a pattern of statements
+func (p *Pattern) stmts() []ast.Stmt {
+	stmts := make([]ast.Stmt, len(p.nodes))
+	for i, n := range p.nodes {
+		stmts[i] = n.(ast.Stmt)
+	}
+	return stmts
+}
+
+// matcher compares a pattern with code, collecting what its
+// metavariables match
+type matcher struct {
+	fset     *token.FileSet
+	src      []byte
+	bindings map[string]string
+	order    []string
+}
+
+func (m *matcher) reset() {
+	m.bindings = make(map[string]string)
+	m.order = nil
+}
+
+func (m *matcher) captures() []Capture {
+	captures := make([]Capture, len(m.order))
+	for i, name := range m.order {
+		captures[i] = Capture{Name: name, Text: m.bindings[name]}
+	}
+	return captures
+}
+
+// bind records that name matched text, failing if it matched other text
+// before. $_ and an unnamed $$$ are never recorded.
+func (m *matcher) bind(name, text string) bool {
+	if name == "$_" || name == "$$$" {
+		return true
+	}
+	if prev, ok := m.bindings[name]; ok {
+		return prev == text
+	}
+	m.bindings[name] = text
+	m.order = append(m.order, name)
+	return true
+}
+
+// text returns the source of the nodes from first to last
+func (m *matcher) text(first, last ast.Node) string {
+	start, end := m.fset.Position(first.Pos()).Offset, m.fset.Position(last.End()).Offset
+	if start < 0 || end > len(m.src) || start > end {
+		return ""
+	}
+	return string(m.src[start:end])
+}
+
+// metaName returns the name of the metavariable n is, if it is one, such
+// as CFG for $CFG: an identifier, or a statement of nothing else
+func metaName(n any) (string, bool) {
+	switch n := n.(type) {
+	case *ast.Ident:
+		if n != nil && strings.HasPrefix(n.Name, metaPrefix) && !strings.HasPrefix(n.Name, multiPrefix) {
+			return strings.TrimPrefix(n.Name, metaPrefix), true
+		}
+	case *ast.ExprStmt:
+		if n != nil {
+			return metaName(n.X)
+		}
+	}
+	return "", false
+}
+
+// multiName returns the name of the $$$ metavariable n is, if it is one,
+// as $$$NAME or $$$
+func multiName(n any) (string, bool) {
+	switch n := n.(type) {
+	case *ast.Ident:
+		if n != nil && strings.HasPrefix(n.Name, multiPrefix) {
+			return "$$$" + strings.TrimPrefix(n.Name, multiPrefix), true
+		}
+	case *ast.ExprStmt:
+		if n != nil {
+			return multiName(n.X)
+		}
+	case *ast.Field:
+		if n != nil && len(n.Names) == 0 {
+			return multiName(n.Type)
+		}
+	}
+	return "", false
+}
+
+var (
+	posType     = reflect.TypeOf(token.NoPos)
+	objectType  = reflect.TypeOf((*ast.Object)(nil))
+	scopeType   = reflect.TypeOf((*ast.Scope)(nil))
+	commentType = reflect.TypeOf((*ast.CommentGroup)(nil))
+)
+
+// match reports whether the code t matches the pattern p
+func (m *matcher) match(p, t reflect.Value) bool {
+	if p.Kind() == reflect.Interface {
+		if p.IsNil() || t.IsNil() {
+			return p.IsNil() && t.IsNil()
+		}
+		p, t = p.Elem(), t.Elem()
+	}
+	if p.Kind() == reflect.Ptr && !p.IsNil() {
+		if name, ok := metaName(p.Interface()); ok {
+			node, isNode := t.Interface().(ast.Node)
+			if !isNode || t.IsNil() {
+				return false
+			}
+			return m.bind("$"+name, m.text(node, node))
+		}
+	}
+	if p.Type() != t.Type() {
+		return false
+	}
+
+	switch p.Kind() {
+	case reflect.Ptr:
+		if p.IsNil() || t.IsNil() {
+			return p.IsNil() == t.IsNil()
+		}
+		switch pn := p.Interface().(type) {
+		case *ast.CallExpr:
+			return m.matchCall(pn, t.Interface().(*ast.CallExpr))
+		case *ast.FuncDecl:
+			return m.matchFunc(pn, t.Interface().(*ast.FuncDecl))
+		}
+		return m.match(p.Elem(), t.Elem())
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			switch p.Type().Field(i).Type {
+			case posType, objectType, scopeType, commentType:
+				continue
+			}
+			if !m.match(p.Field(i), t.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		return m.matchSlice(p, t)
+	case reflect.String:
+		return p.String() == t.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return p.Int() == t.Int()
+	case reflect.Bool:
+		return p.Bool() == t.Bool()
+	}
+	return false
+}
+
+// matchCall matches a call, letting a function named without its package
+// match one called through any
+func (m *matcher) matchCall(p, t *ast.CallExpr) bool {
+	fun := reflect.ValueOf(t.Fun)
+	if id, ok := p.Fun.(*ast.Ident); ok {
+		if _, meta := metaName(id); !meta {
+			if sel, ok := t.Fun.(*ast.SelectorExpr); ok {
+				fun = reflect.ValueOf(sel.Sel)
+			}
+		}
+	}
+	return m.match(reflect.ValueOf(p.Fun), fun) &&
+		p.Ellipsis.IsValid() == t.Ellipsis.IsValid() &&
+		m.matchSlice(reflect.ValueOf(p.Args), reflect.ValueOf(t.Args))
+}
+
+// matchFunc matches a function declaration; one in the pattern without a
+// body matches any body
+func (m *matcher) matchFunc(p, t *ast.FuncDecl) bool {
+	if !m.match(reflect.ValueOf(p.Recv), reflect.ValueOf(t.Recv)) ||
+		!m.match(reflect.ValueOf(p.Name), reflect.ValueOf(t.Name)) ||
+		!m.match(reflect.ValueOf(p.Type), reflect.ValueOf(t.Type)) {
+		return false
+	}
+	return p.Body == nil || m.match(reflect.ValueOf(p.Body), reflect.ValueOf(t.Body))
+}
+
+// matchSlice matches lists, where $$$ matches a run of any length
+func (m *matcher) matchSlice(p, t reflect.Value) bool {
+	if p.Len() == 0 {
+		return t.Len() == 0
+	}
+	first := p.Index(0)
+	name, multi := multiName(first.Interface())
+	if !multi {
+		if t.Len() == 0 {
+			return false
+		}
+		saved := m.save()
+		if m.match(first, t.Index(0)) && m.matchSlice(p.Slice(1, p.Len()), t.Slice(1, t.Len())) {
+			return true
+		}
+		m.restore(saved)
+		return false
+	}
+
+	for n := 0; n <= t.Len(); n++ {
+		saved := m.save()
+		text := ""
+		if n > 0 {
+			first, _ := t.Index(0).Interface().(ast.Node)
+			last, _ := t.Index(n - 1).Interface().(ast.Node)
+			if first != nil && last != nil {
+				text = m.text(first, last)
+			}
+		}
+		if m.bind(name, text) && m.matchSlice(p.Slice(1, p.Len()), t.Slice(n, t.Len())) {
+			return true
+		}
+		m.restore(saved)
+	}
+	return false
+}
+
+// binding is the state of a matcher's bindings, to go back to when a
+// match fails part way
+type binding struct {
+	bindings map[string]string
+	order    int
+}
+
+func (m *matcher) save() binding {
+	saved := make(map[string]string, len(m.bindings))
+	for k, v := range m.bindings {
+		saved[k] = v
+	}
+	return binding{saved, len(m.order)}
+}
+
+func (m *matcher) restore(b binding) {
+	m.bindings = b.bindings
+	m.order = m.order[:b.order]
+}
@@ -0,0 +1,80 @@
+package astgrep
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const source = `package p
+
+func run(ctx context.Context, cfg Config) error {
+	res, err := sandbox.RunContainer(cfg)
+	if err != nil {
+		return err
+	}
+	RunContainer(   // a comment
+		other,
+	)
+	RunContainer(ctx, cfg)
+	log.Printf("%s: %d", name, res.Code)
+	x := compare(a, a)
+	y := compare(a, b)
+	if err != nil {
+		return fmt.Errorf("wrap: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) Close() error { return nil }
+`
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string // Line and captures of each match
+	}{
+		{"RunContainer($CFG)", []string{"4 $CFG=cfg", "8 $CFG=other"}},
+		{"sandbox.RunContainer($_)", []string{"4"}},
+		{"RunContainer($$$ARGS)", []string{"4 $$$ARGS=cfg", "8 $$$ARGS=other", "11 $$$ARGS=ctx, cfg"}},
+		{`log.Printf($FMT, $$$)`, []string{`12 $FMT="%s: %d"`}},
+		{"compare($X, $X)", []string{"13 $X=a"}},
+		{"if err != nil { return $E }", []string{"5 $E=err", `15 $E=fmt.Errorf("wrap: %w", err)`}},
+		{"if err != nil { $$$ }", []string{"5", "15"}},
+		{"res, err := $CALL\nif err != nil { $$$ }", []string{"4 $CALL=sandbox.RunContainer(cfg)"}},
+		{"func ($R *Server) $M() error", []string{"21 $R=s $M=Close"}},
+		// A function is not a method
+		{"func $F($$$) error", []string{"3 $F=run"}},
+		{"NoSuchCall($X)", nil},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		matches, err := p.Find("p.go", []byte(source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range matches {
+			s := fmt.Sprint(m.Start.Line)
+			for _, c := range m.Captures {
+				s += " " + c.Name + "=" + c.Text
+			}
+			got = append(got, s)
+		}
+		if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+			t.Errorf("Find(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, pattern := range []string{"", "$X", "f(", "$ + 1"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) succeeded", pattern)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package backup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDir is the backup location relative to the repository root
+const DefaultDir = ".llm-tools/backups"
+
+// backupName matches "<file>.bak.<unix nanoseconds>"
+var backupName = regexp.MustCompile(`^(.+)\.bak\.(\d+)$`)
+
+// Entry describes a single backup
+type Entry struct {
+	File string    // Original file, slash-separated and relative to the repository root
+	ID   string    // Timestamp identifier used to select the backup for restore
+	Path string    // Absolute path of the backup file
+	Time time.Time // When the backup was taken
+	Size int64     // Backup size in bytes
+}
+
+// Manager stores backups under a central directory that mirrors the
+// repository structure and enforces retention limits
+type Manager struct {
+	repoRoot string
+	dir      string
+	maxCount int
+	maxAge   time.Duration
+	now      func() time.Time
+}
+
+// NewManager creates a backup manager. dir is resolved against repoRoot when
+// relative (empty means DefaultDir). maxCount limits backups kept per file
+// and maxAge removes older backups; zero disables either limit.
+func NewManager(repoRoot, dir string, maxCount int, maxAge time.Duration) *Manager {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoRoot, dir)
+	}
+	return &Manager{
+		repoRoot: repoRoot,
+		dir:      dir,
+		maxCount: maxCount,
+		maxAge:   maxAge,
+		now:      time.Now,
+	}
+}
+
+// SetClock sets where the manager reads the time that names backups and
+// ages them, in place of time.Now
+func (m *Manager) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// Dir returns the absolute backup directory
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Create copies the file at absPath into the backup directory and returns
+// the backup path
+func (m *Manager) Create(absPath string) (string, error) {
+	rel, err := m.relPath(absPath)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read original file: %w", err)
+	}
+
+	// A stopped or coarse clock can repeat a reading; the next free
+	// nanosecond keeps the earlier backup and the order of both
+	base := filepath.Join(m.dir, filepath.FromSlash(rel)) + ".bak."
+	stamp := m.now().UnixNano()
+	backupPath := base + strconv.FormatInt(stamp, 10)
+	for {
+		if _, err := os.Stat(backupPath); err != nil {
+			break
+		}
+		stamp++
+		backupPath = base + strconv.FormatInt(stamp, 10)
+	}
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(backupPath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	return backupPath, nil
+}
+
+// List returns backups grouped by file, newest first. If file is non-empty
+// only backups of that file (relative to the repository root) are returned.
+func (m *Manager) List(file string) ([]Entry, error) {
+	var entries []Entry
+	if file != "" {
+		file = filepath.ToSlash(filepath.Clean(file))
+	}
+
+	err := filepath.WalkDir(m.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == m.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		entry, ok := m.parseEntry(path)
+		if !ok || (file != "" && entry.File != file) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			entry.Size = info.Size()
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].File != entries[j].File {
+			return entries[i].File < entries[j].File
+		}
+		return entries[i].Time.After(entries[j].Time)
+	})
+
+	return entries, nil
+}
+
+// Restore copies a backup of file back into the repository. An empty id
+// restores the newest backup. The current file, if any, is backed up first
+// so a restore can itself be undone.
+func (m *Manager) Restore(file, id string) (Entry, error) {
+	entries, err := m.List(file)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("no backups found for %s", file)
+	}
+
+	entry := entries[0]
+	if id != "" {
+		found := false
+		for _, e := range entries {
+			if e.ID == id {
+				entry, found = e, true
+				break
+			}
+		}
+		if !found {
+			return Entry{}, fmt.Errorf("backup %s not found for %s", id, file)
+		}
+	}
+
+	content, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	target := filepath.Join(m.repoRoot, filepath.FromSlash(entry.File))
+	if _, err := os.Stat(target); err == nil {
+		if _, err := m.Create(target); err != nil {
+			return Entry{}, fmt.Errorf("failed to back up current file: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return Entry{}, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return Entry{}, fmt.Errorf("failed to restore file: %w", err)
+	}
+
+	return entry, nil
+}
+
+// RestorePath restores the backup at backupPath, as returned by Create, to
+// the file it was taken from
+func (m *Manager) RestorePath(backupPath string) (Entry, error) {
+	entry, ok := m.parseEntry(backupPath)
+	if !ok {
+		return Entry{}, fmt.Errorf("not a backup in %s: %s", m.dir, backupPath)
+	}
+	return m.Restore(entry.File, entry.ID)
+}
+
+// Prune removes backups beyond the retention limits and returns the
+// removed entries. The count limit keeps the newest backups of each file;
+// the age limit applies to every backup, so all of a file's backups can
+// expire.
+func (m *Manager) Prune() ([]Entry, error) {
+	entries, err := m.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Entry
+	cutoff := m.now().Add(-m.maxAge)
+	perFile := make(map[string]int)
+
+	for _, e := range entries {
+		perFile[e.File]++
+		expired := m.maxAge > 0 && e.Time.Before(cutoff)
+		excess := m.maxCount > 0 && perFile[e.File] > m.maxCount
+		if !expired && !excess {
+			continue
+		}
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", e.Path, err)
+		}
+		removed = append(removed, e)
+	}
+
+	m.removeEmptyDirs()
+	return removed, nil
+}
+
+// relPath returns the slash-separated path of absPath relative to the
+// repository root
+func (m *Manager) relPath(absPath string) (string, error) {
+	rel, err := filepath.Rel(m.repoRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file is outside repository: %s", absPath)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// parseEntry builds an Entry from a file in the backup directory
+func (m *Manager) parseEntry(path string) (Entry, bool) {
+	rel, err := filepath.Rel(m.dir, path)
+	if err != nil {
+		return Entry{}, false
+	}
+	match := backupName.FindStringSubmatch(filepath.ToSlash(rel))
+	if match == nil {
+		return Entry{}, false
+	}
+	nanos, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	return Entry{
+		File: match[1],
+		ID:   match[2],
+		Path: path,
+		Time: time.Unix(0, nanos),
+	}, true
+}
+
+// removeEmptyDirs deletes directories left empty by pruning, deepest first
+func (m *Manager) removeEmptyDirs() {
+	var dirs []string
+	filepath.WalkDir(m.dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != m.dir {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i]) // Fails harmlessly if not empty
+	}
+}
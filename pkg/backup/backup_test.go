@@ -0,0 +1,349 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// newTestManager returns a manager whose clock advances one minute per call
+func newTestManager(root string, maxCount int, maxAge time.Duration) (*Manager, *time.Time) {
+	m := NewManager(root, "", maxCount, maxAge)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return m, &clock
+}
+
+func TestNewManager_Dir(t *testing.T) {
+	root := t.TempDir()
+
+	if got := NewManager(root, "", 0, 0).Dir(); got != filepath.Join(root, DefaultDir) {
+		t.Errorf("default Dir() = %q", got)
+	}
+	if got := NewManager(root, "custom", 0, 0).Dir(); got != filepath.Join(root, "custom") {
+		t.Errorf("relative Dir() = %q", got)
+	}
+	abs := t.TempDir()
+	if got := NewManager(root, abs, 0, 0).Dir(); got != abs {
+		t.Errorf("absolute Dir() = %q", got)
+	}
+}
+
+func TestCreate_MirrorsRepoStructure(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "pkg", "app", "main.go")
+	writeFile(t, file, "package main\n")
+
+	m, _ := newTestManager(root, 0, 0)
+	backupPath, err := m.Create(file)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	wantPrefix := filepath.Join(root, DefaultDir, "pkg", "app", "main.go.bak.")
+	if !strings.HasPrefix(backupPath, wantPrefix) {
+		t.Errorf("backup path %q should start with %q", backupPath, wantPrefix)
+	}
+
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	if string(content) != "package main\n" {
+		t.Errorf("backup content = %q", content)
+	}
+
+	// Nothing is written next to the original file
+	matches, _ := filepath.Glob(file + ".bak.*")
+	if len(matches) != 0 {
+		t.Errorf("unexpected backups next to file: %v", matches)
+	}
+}
+
+func TestCreate_StoppedClock(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "main.go")
+	m := NewManager(root, "", 0, 0)
+	stopped := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.SetClock(func() time.Time { return stopped })
+
+	var paths []string
+	for _, content := range []string{"v1\n", "v2\n"} {
+		writeFile(t, file, content)
+		backupPath, err := m.Create(file)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		paths = append(paths, backupPath)
+	}
+
+	if paths[0] == paths[1] {
+		t.Fatalf("both backups written to %s", paths[0])
+	}
+	entries, err := m.List("main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Path != paths[1] {
+		t.Errorf("List() = %+v, want the second backup first", entries)
+	}
+	if data, _ := os.ReadFile(paths[0]); string(data) != "v1\n" {
+		t.Errorf("first backup = %q, want v1", data)
+	}
+}
+
+func TestCreate_Errors(t *testing.T) {
+	root := t.TempDir()
+	m, _ := newTestManager(root, 0, 0)
+
+	if _, err := m.Create(filepath.Join(root, "missing.txt")); err == nil {
+		t.Error("expected error for missing file")
+	}
+
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	writeFile(t, outside, "x")
+	if _, err := m.Create(outside); err == nil || !strings.Contains(err.Error(), "outside repository") {
+		t.Errorf("expected outside repository error, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.txt")
+	b := filepath.Join(root, "dir", "b.txt")
+	writeFile(t, a, "a")
+	writeFile(t, b, "bb")
+
+	m, _ := newTestManager(root, 0, 0)
+	for _, f := range []string{a, b, a} {
+		if _, err := m.Create(f); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	// Unrelated files in the backup directory are ignored
+	writeFile(t, filepath.Join(m.Dir(), "README"), "x")
+
+	all, err := m.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 backups, got %d", len(all))
+	}
+	if all[0].File != "a.txt" || all[1].File != "a.txt" || all[2].File != "dir/b.txt" {
+		t.Errorf("unexpected order: %s, %s, %s", all[0].File, all[1].File, all[2].File)
+	}
+	if !all[0].Time.After(all[1].Time) {
+		t.Error("backups of a file should be newest first")
+	}
+	if all[2].Size != 2 {
+		t.Errorf("Size = %d, want 2", all[2].Size)
+	}
+
+	only, err := m.List("dir/b.txt")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(only) != 1 || only[0].File != "dir/b.txt" {
+		t.Errorf("List(dir/b.txt) = %+v", only)
+	}
+}
+
+func TestList_NoBackupDir(t *testing.T) {
+	m, _ := newTestManager(t.TempDir(), 0, 0)
+	entries, err := m.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no backups, got %d", len(entries))
+	}
+}
+
+func TestRestore(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "config.yaml")
+	writeFile(t, file, "v1")
+
+	m, _ := newTestManager(root, 0, 0)
+	first, _ := m.Create(file)
+	writeFile(t, file, "v2")
+	m.Create(file)
+	writeFile(t, file, "v3")
+
+	// Newest backup by default
+	entry, err := m.Restore("config.yaml", "")
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "v2" {
+		t.Errorf("restored content = %q, want v2", content)
+	}
+	if entry.File != "config.yaml" {
+		t.Errorf("entry.File = %q", entry.File)
+	}
+
+	// Specific backup by id
+	firstEntry, _ := m.parseEntry(first)
+	if _, err := m.Restore("config.yaml", firstEntry.ID); err != nil {
+		t.Fatalf("Restore by id failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "v1" {
+		t.Errorf("restored content = %q, want v1", content)
+	}
+
+	// Each restore backed up the current file first
+	entries, _ := m.List("config.yaml")
+	if len(entries) != 4 {
+		t.Errorf("expected 4 backups after two restores, got %d", len(entries))
+	}
+}
+
+func TestRestore_DeletedFile(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "sub", "gone.txt")
+	writeFile(t, file, "content")
+
+	m, _ := newTestManager(root, 0, 0)
+	m.Create(file)
+	os.RemoveAll(filepath.Join(root, "sub"))
+
+	if _, err := m.Restore("sub/gone.txt", ""); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "content" {
+		t.Errorf("restored content = %q", content)
+	}
+}
+
+func TestRestorePath(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "main.go")
+	writeFile(t, file, "v1")
+
+	m, _ := newTestManager(root, 0, 0)
+	backupPath, _ := m.Create(file)
+	writeFile(t, file, "v2")
+
+	if _, err := m.RestorePath(backupPath); err != nil {
+		t.Fatalf("RestorePath failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "v1" {
+		t.Errorf("restored content = %q, want v1", content)
+	}
+
+	if _, err := m.RestorePath(file); err == nil {
+		t.Error("expected error for a path outside the backup directory")
+	}
+}
+
+func TestRestore_Errors(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.txt")
+	writeFile(t, file, "a")
+	m, _ := newTestManager(root, 0, 0)
+
+	if _, err := m.Restore("a.txt", ""); err == nil {
+		t.Error("expected error with no backups")
+	}
+
+	m.Create(file)
+	if _, err := m.Restore("a.txt", "12345"); err == nil {
+		t.Error("expected error for unknown id")
+	}
+}
+
+func TestPrune_Count(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.txt")
+	b := filepath.Join(root, "nested", "b.txt")
+	writeFile(t, a, "a")
+	writeFile(t, b, "b")
+
+	m, _ := newTestManager(root, 2, 0)
+	for i := 0; i < 4; i++ {
+		m.Create(a)
+	}
+	m.Create(b)
+
+	newest, _ := m.List("a.txt")
+
+	removed, err := m.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("expected 2 removed, got %d", len(removed))
+	}
+
+	kept, _ := m.List("a.txt")
+	if len(kept) != 2 || kept[0].ID != newest[0].ID || kept[1].ID != newest[1].ID {
+		t.Errorf("expected the two newest backups to be kept, got %+v", kept)
+	}
+	if others, _ := m.List("nested/b.txt"); len(others) != 1 {
+		t.Errorf("other files should keep their backups, got %d", len(others))
+	}
+}
+
+func TestPrune_Age(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "old", "a.txt")
+	writeFile(t, file, "a")
+
+	m, clock := newTestManager(root, 0, time.Hour)
+	m.Create(file)
+	m.Create(file)
+
+	// Jump past the retention window
+	*clock = clock.Add(2 * time.Hour)
+
+	removed, err := m.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("expected 2 removed, got %d", len(removed))
+	}
+
+	// Empty mirrored directories are cleaned up
+	if _, err := os.Stat(filepath.Join(m.Dir(), "old")); !os.IsNotExist(err) {
+		t.Error("expected empty backup subdirectory to be removed")
+	}
+	if _, err := os.Stat(m.Dir()); err != nil {
+		t.Error("backup root should remain")
+	}
+}
+
+func TestPrune_Unlimited(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.txt")
+	writeFile(t, file, "a")
+
+	m, _ := newTestManager(root, 0, 0)
+	for i := 0; i < 10; i++ {
+		m.Create(file)
+	}
+
+	removed, err := m.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(removed) != 0 {
+		t.Errorf("expected nothing pruned without limits, got %d", len(removed))
+	}
+}
@@ -0,0 +1,332 @@
+// Package batch runs a file of jobs, each a command script or an agent
+// prompt, against one or more repositories, for automations such as a
+// nightly "update dependencies and run the tests". Every run is a session
+// of its own, started as a separate llm-runtime process, which leaves a log
+// and a report behind; the batch collects their outcomes into a summary.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/app"
+	"gopkg.in/yaml.v3"
+)
+
+// File is a jobs file
+type File struct {
+	Parallel int      `yaml:"parallel"` // Runs at once; 1 when unset
+	Args     []string `yaml:"args"`     // llm-runtime flags of every job, such as --exec-whitelist
+	Jobs     []Job    `yaml:"jobs"`
+
+	dir string // Directory of the file, which relative paths in it are in
+}
+
+// Job is a command script or agent prompt run against repositories
+type Job struct {
+	Name    string        `yaml:"name"`
+	Repo    string        `yaml:"repo"`    // Repository root, as --root takes it
+	Repos   []string      `yaml:"repos"`   // Or several, each run on its own
+	Script  string        `yaml:"script"`  // File of commands, run as pipe mode runs its input
+	Prompt  string        `yaml:"prompt"`  // Or a file with the task of an agent session
+	Args    []string      `yaml:"args"`    // Flags of this job, after those of the file
+	Timeout time.Duration `yaml:"timeout"` // Longest the job may run on one repository; unlimited when unset
+}
+
+// Run is a job on one repository
+type Run struct {
+	Name string // The job's name, with the repository's when it has several
+	Job  Job
+	Repo string
+}
+
+// Result is the outcome of a run
+type Result struct {
+	Name     string  `json:"name"`
+	Repo     string  `json:"repo"`
+	Status   string  `json:"status"` // ok, failed, or error
+	ExitCode int     `json:"exit_code"`
+	Failures int     `json:"failures"` // Commands that failed, from the run's report
+	Changed  int     `json:"files_changed"`
+	Duration float64 `json:"duration_seconds"`
+	Log      string  `json:"log"`
+	Report   string  `json:"report"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Status of a run
+const (
+	StatusOK     = "ok"     // Exited zero with no command failing
+	StatusFailed = "failed" // Exited zero, but commands failed, such as the tests
+	StatusError  = "error"  // Could not start, exited non-zero, or timed out
+)
+
+// validName matches the job names usable in file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Load reads and checks the jobs file at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if f.dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if len(f.Jobs) == 0 {
+		return nil, fmt.Errorf("%s has no jobs", path)
+	}
+	if f.Parallel < 0 {
+		return nil, fmt.Errorf("%s: parallel cannot be negative", path)
+	}
+
+	names := map[string]bool{}
+	for i, job := range f.Jobs {
+		switch {
+		case !validName.MatchString(job.Name):
+			return nil, fmt.Errorf("%s: job %d needs a name of letters, digits, ., _, and -", path, i+1)
+		case names[job.Name]:
+			return nil, fmt.Errorf("%s: two jobs are named %s", path, job.Name)
+		case (job.Script == "") == (job.Prompt == ""):
+			return nil, fmt.Errorf("%s: job %s needs a script or a prompt, not both", path, job.Name)
+		case job.Repo != "" && len(job.Repos) > 0:
+			return nil, fmt.Errorf("%s: job %s has both repo and repos", path, job.Name)
+		case job.Timeout < 0:
+			return nil, fmt.Errorf("%s: job %s: timeout cannot be negative", path, job.Name)
+		}
+		names[job.Name] = true
+	}
+	return &f, nil
+}
+
+// Runs returns the runs of the file's jobs, in order: each job on each of
+// its repositories, or on the current directory without any
+func (f *File) Runs() []Run {
+	var runs []Run
+	used := map[string]bool{}
+	for _, job := range f.Jobs {
+		repos := job.Repos
+		if job.Repo != "" {
+			repos = []string{job.Repo}
+		}
+		if len(repos) == 0 {
+			repos = []string{"."}
+		}
+		for _, repo := range repos {
+			name := job.Name
+			if len(repos) > 1 {
+				name += "-" + sanitize(filepath.Base(strings.TrimSuffix(repo, "/")))
+			}
+			// Names name files, so repositories of the same base name
+			// are numbered
+			for n := 2; used[name]; n++ {
+				name = fmt.Sprintf("%s-%d", strings.TrimSuffix(name, fmt.Sprintf("-%d", n-1)), n)
+			}
+			used[name] = true
+			runs = append(runs, Run{Name: name, Job: job, Repo: f.path(repo)})
+		}
+	}
+	return runs
+}
+
+// path resolves p, relative to the file's directory. Object store prefixes
+// such as s3://bucket/repo are left as they are.
+func (f *File) path(p string) string {
+	if strings.Contains(p, "://") {
+		return p
+	}
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(f.dir, p)
+}
+
+// sanitize makes s usable in a file name
+func sanitize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// Runner runs the runs of a jobs file
+type Runner struct {
+	Executable string // The llm-runtime binary each run starts
+	OutDir     string // Where the runs' logs and reports, and the summary, are written
+	Parallel   int    // Runs at once; the file's when 0
+	Progress   func(Result)
+}
+
+// Run runs the runs of f and returns their results, in the order of the
+// runs. Canceling ctx interrupts the runs in progress and skips the rest.
+func (r *Runner) Run(ctx context.Context, f *File) ([]Result, error) {
+	if err := os.MkdirAll(r.OutDir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create output directory: %w", err)
+	}
+	parallel := r.Parallel
+	if parallel == 0 {
+		parallel = f.Parallel
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	runs := f.Runs()
+	results := make([]Result, len(runs))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, run := range runs {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, run Run) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result := r.run(ctx, f, run)
+			results[i] = result
+			if r.Progress != nil {
+				mu.Lock()
+				r.Progress(result)
+				mu.Unlock()
+			}
+		}(i, run)
+	}
+	wg.Wait()
+
+	summary, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return results, err
+	}
+	if err := os.WriteFile(filepath.Join(r.OutDir, "summary.json"), append(summary, '\n'), 0644); err != nil {
+		return results, fmt.Errorf("cannot write summary: %w", err)
+	}
+	return results, nil
+}
+
+// run starts run's session and waits for it
+func (r *Runner) run(ctx context.Context, f *File, run Run) Result {
+	result := Result{
+		Name:   run.Name,
+		Repo:   run.Repo,
+		Log:    filepath.Join(r.OutDir, run.Name+".log"),
+		Report: filepath.Join(r.OutDir, run.Name+".json"),
+	}
+	start := time.Now()
+	fail := func(err error) Result {
+		result.Status = StatusError
+		result.Error = err.Error()
+		result.Duration = time.Since(start).Seconds()
+		return result
+	}
+	if ctx.Err() != nil {
+		return fail(fmt.Errorf("not run: %w", ctx.Err()))
+	}
+
+	args := r.args(f, run, result.Report)
+	var input *os.File
+	if run.Job.Script != "" {
+		var err error
+		if input, err = os.Open(f.path(run.Job.Script)); err != nil {
+			return fail(fmt.Errorf("cannot read script: %w", err))
+		}
+		defer input.Close()
+	} else {
+		task, err := os.ReadFile(f.path(run.Job.Prompt))
+		if err != nil {
+			return fail(fmt.Errorf("cannot read prompt: %w", err))
+		}
+		args = append(args, "--prompt", string(task))
+	}
+
+	log, err := os.Create(result.Log)
+	if err != nil {
+		return fail(fmt.Errorf("cannot create log: %w", err))
+	}
+	defer log.Close()
+
+	runCtx := ctx
+	if run.Job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, run.Job.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(runCtx, r.Executable, args...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	if input != nil {
+		cmd.Stdin = input
+	}
+	// Interrupted as on Ctrl+C, so the session cleans up and writes its
+	// report, and killed if it has not stopped a while later
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 30 * time.Second
+
+	err = cmd.Run()
+	result.Duration = time.Since(start).Seconds()
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	if report, rerr := readReport(result.Report); rerr == nil {
+		result.Failures = len(report.Failures)
+		result.Changed = len(report.Files)
+	}
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		result.Status = StatusError
+		result.Error = fmt.Sprintf("timed out after %s", run.Job.Timeout)
+	case err != nil:
+		result.Status = StatusError
+		result.Error = err.Error()
+	case result.Failures > 0:
+		result.Status = StatusFailed
+	default:
+		result.Status = StatusOK
+	}
+	return result
+}
+
+// args returns the flags that start run's session, writing its report to
+// report
+func (r *Runner) args(f *File, run Run, report string) []string {
+	var args []string
+	if run.Job.Prompt != "" {
+		args = append(args, "agent")
+	}
+	args = append(args, "--root", run.Repo, "--report", report)
+	args = append(args, f.Args...)
+	return append(args, run.Job.Args...)
+}
+
+// readReport reads the report a run wrote
+func readReport(path string) (*app.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report app.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,174 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no jobs":        "parallel: 2\n",
+		"no name":        "jobs:\n  - script: a.txt\n",
+		"bad name":       "jobs:\n  - name: a/b\n    script: a.txt\n",
+		"duplicate":      "jobs:\n  - name: a\n    script: a.txt\n  - name: a\n    script: b.txt\n",
+		"neither":        "jobs:\n  - name: a\n",
+		"both":           "jobs:\n  - name: a\n    script: a.txt\n    prompt: p.md\n",
+		"repo and repos": "jobs:\n  - name: a\n    script: a.txt\n    repo: x\n    repos: [y]\n",
+		"negative":       "parallel: -1\njobs:\n  - name: a\n    script: a.txt\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jobs.yaml")
+			writeFile(t, path, content)
+			if _, err := Load(path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRuns(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jobs.yaml")
+	writeFile(t, path, `
+jobs:
+  - name: deps
+    script: deps.txt
+    repos: [one/app, two/app/, /abs/lib]
+  - name: tests
+    script: tests.txt
+    repo: svc
+  - name: here
+    prompt: task.md
+`)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, run := range f.Runs() {
+		got = append(got, run.Name+"="+run.Repo)
+	}
+	want := []string{
+		"deps-app=" + filepath.Join(dir, "one/app"),
+		"deps-app-2=" + filepath.Join(dir, "two/app"),
+		"deps-lib=/abs/lib",
+		"tests=" + filepath.Join(dir, "svc"),
+		"here=" + dir,
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("runs = %v, want %v", got, want)
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	// Stands in for llm-runtime: writes a report with a failure when its
+	// input says so, and exits with the status its input names
+	exe := filepath.Join(dir, "fake")
+	writeFile(t, exe, `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in --report) report=$2; shift;; esac
+	shift
+done
+input=$(cat)
+echo "ran: $input"
+case "$input" in
+	*fail*) echo '{"failures":[{}],"files":[{},{}]}' > "$report";;
+	*) echo '{"failures":[],"files":[{}]}' > "$report";;
+esac
+case "$input" in *crash*) exit 3;; esac
+exit 0
+`)
+	writeFile(t, filepath.Join(dir, "ok.txt"), "all good")
+	writeFile(t, filepath.Join(dir, "fail.txt"), "tests fail")
+	writeFile(t, filepath.Join(dir, "crash.txt"), "crash")
+	path := filepath.Join(dir, "jobs.yaml")
+	writeFile(t, path, `
+parallel: 2
+jobs:
+  - name: good
+    script: ok.txt
+  - name: bad
+    script: fail.txt
+  - name: broken
+    script: crash.txt
+  - name: missing
+    script: nope.txt
+`)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+	var seen int
+	runner := &Runner{Executable: exe, OutDir: out, Progress: func(Result) { seen++ }}
+	results, err := runner.Run(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != 4 {
+		t.Errorf("progress called %d times, want 4", seen)
+	}
+
+	want := []struct {
+		name, status     string
+		exit, fails, chg int
+	}{
+		{"good", StatusOK, 0, 0, 1},
+		{"bad", StatusFailed, 0, 1, 2},
+		{"broken", StatusError, 3, 0, 1},
+		{"missing", StatusError, 0, 0, 0},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Name != w.name || r.Status != w.status || r.ExitCode != w.exit || r.Failures != w.fails || r.Changed != w.chg {
+			t.Errorf("result %d = %+v, want %+v", i, r, w)
+		}
+	}
+	if log, _ := os.ReadFile(filepath.Join(out, "good.log")); !strings.Contains(string(log), "ran: all good") {
+		t.Errorf("good.log = %q", log)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "summary.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary []Result
+	if err := json.Unmarshal(data, &summary); err != nil || len(summary) != 4 {
+		t.Errorf("summary = %s (%v)", data, err)
+	}
+}
+
+func TestRunner_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jobs.yaml")
+	writeFile(t, path, "jobs:\n  - name: a\n    script: a.txt\n")
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := (&Runner{Executable: "/bin/false", OutDir: filepath.Join(dir, "out")}).Run(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != StatusError || !strings.Contains(results[0].Error, "not run") {
+		t.Errorf("result = %+v", results[0])
+	}
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/agent"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run a chat model as a coding agent",
+	Long: `Sends the task to a chat model, executes the commands in each reply, and sends
+the results back, until the model replies without commands or a turn, command,
+or time limit is reached. The conversation is printed to stdout, and token use
+and cost to stderr.
+
+Models are served by a local Ollama (--provider ollama), OpenAI
+(--provider openai, key in OPENAI_API_KEY or agent.openai_api_key), or
+Anthropic (--provider anthropic, key in ANTHROPIC_API_KEY or
+agent.anthropic_api_key). With --tools the model calls open, write, exec, and
+search as native tools instead of writing commands in its replies.`,
+	Example: `  llm-runtime agent --model llama3 --prompt "fix the failing tests" --root . --exec-whitelist "go test"
+  llm-runtime agent --provider anthropic --tools --prompt "add a --version flag" --root .`,
+	Args: cobra.NoArgs,
+	RunE: runAgent,
+}
+
+// defaultModels is the model used for each provider when --model is not set
+var defaultModels = map[string]string{
+	"ollama":    "llama3",
+	"openai":    "gpt-4o-mini",
+	"anthropic": "claude-3-5-sonnet-latest",
+}
+
+func init() {
+	agentCmd.Flags().String("provider", "ollama", "Model provider: ollama, openai, or anthropic")
+	agentCmd.Flags().String("model", "", "Model name (default: llama3, gpt-4o-mini, or claude-3-5-sonnet-latest by provider)")
+	agentCmd.Flags().String("prompt", "", "Task for the agent")
+	agentCmd.Flags().Bool("tools", false, "Use native tool calling instead of commands in the reply text")
+	agentCmd.Flags().String("system-prompt", "", "File with a system prompt to use instead of the built-in one")
+	agentCmd.Flags().String("api-url", "", "API base URL (default: the provider's; commands.search.ollama_url for Ollama)")
+	agentCmd.Flags().String("ollama-url", "", "Ollama server URL")
+	agentCmd.Flags().MarkDeprecated("ollama-url", "use --api-url instead")
+	agentCmd.Flags().Int("max-turns", 20, "Model replies before stopping (0 for unlimited)")
+	agentCmd.Flags().Int("max-commands", 100, "Commands executed before stopping (0 for unlimited)")
+	agentCmd.Flags().Duration("time-limit", 30*time.Minute, "Total run time before stopping (0 for unlimited)")
+	agentCmd.Flags().Float64("input-price", -1, "Price in USD per million input tokens (default: known list price, or 0)")
+	agentCmd.Flags().Float64("output-price", -1, "Price in USD per million output tokens (default: known list price, or 0)")
+	agentCmd.MarkFlagRequired("prompt")
+	rootCmd.AddCommand(agentCmd)
+}
+
+func runAgent(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	provider, _ := flags.GetString("provider")
+	model, _ := flags.GetString("model")
+	task, _ := flags.GetString("prompt")
+	useTools, _ := flags.GetBool("tools")
+	systemPromptFile, _ := flags.GetString("system-prompt")
+	apiURL, _ := flags.GetString("api-url")
+	if ollamaURL, _ := flags.GetString("ollama-url"); apiURL == "" {
+		apiURL = ollamaURL
+	}
+	maxTurns, _ := flags.GetInt("max-turns")
+	maxCommands, _ := flags.GetInt("max-commands")
+	timeLimit, _ := flags.GetDuration("time-limit")
+	inputPrice, _ := flags.GetFloat64("input-price")
+	outputPrice, _ := flags.GetFloat64("output-price")
+
+	if model == "" {
+		model = defaultModels[provider]
+	}
+	client, err := newChatClient(provider, model, apiURL)
+	if err != nil {
+		return err
+	}
+
+	systemPrompt := agent.DefaultSystemPrompt
+	if useTools {
+		systemPrompt = agent.ToolsSystemPrompt
+	}
+	if systemPromptFile != "" {
+		content, err := os.ReadFile(systemPromptFile)
+		if err != nil {
+			return fmt.Errorf("cannot read system prompt: %w", err)
+		}
+		systemPrompt = string(content)
+	}
+
+	pricing, _ := agent.PricingFor(model)
+	if inputPrice >= 0 {
+		pricing.InputPerMTok = inputPrice
+	}
+	if outputPrice >= 0 {
+		pricing.OutputPerMTok = outputPrice
+	}
+
+	cfg, err := buildConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+	app, err := bootstrapApp(cfg)
+	if err != nil {
+		return fmt.Errorf("bootstrap failed: %w", err)
+	}
+	defer app.Close()
+
+	ctx, stop := interruptContext()
+	defer stop()
+	app.SetContext(ctx)
+	if timeLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeLimit)
+		defer cancel()
+	}
+
+	loop := &agent.Loop{
+		Client:         client,
+		Execute:        app.ExecuteReply,
+		ExecuteCommand: app.ExecuteCommand,
+		StartTurn:      app.GetExecutor().StartTurn,
+		UseTools:       useTools,
+		SystemPrompt:   systemPrompt,
+		Limits:         agent.Limits{MaxTurns: maxTurns, MaxCommands: maxCommands},
+		Pricing:        pricing,
+		Transcript:     cmd.OutOrStdout(),
+	}
+	outcome, err := loop.Run(ctx, task)
+	app.RecordModelUsage(model, outcome.Usage.InputTokens, outcome.Usage.OutputTokens, outcome.Cost)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Agent stopped: %s after %d turns and %d commands\n", outcome.Reason, outcome.Turns, outcome.Commands)
+	fmt.Fprintf(cmd.ErrOrStderr(), "Tokens: %d input, %d output; cost: $%.4f\n", outcome.Usage.InputTokens, outcome.Usage.OutputTokens, outcome.Cost)
+	if outcome.Reason == agent.StopInterrupted {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return ErrInterrupted
+	}
+	return nil
+}
+
+// newChatClient creates the client for provider, reading API keys from the
+// environment or the config file
+func newChatClient(provider, model, apiURL string) (agent.ChatClient, error) {
+	switch provider {
+	case "ollama":
+		if apiURL == "" {
+			apiURL = config.LoadSearchConfig().OllamaURL
+		}
+		return &agent.OllamaClient{URL: apiURL, Model: model}, nil
+
+	case "openai":
+		key, err := apiKey("openai", "OPENAI_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		if apiURL == "" {
+			apiURL = agent.DefaultOpenAIURL
+		}
+		return &agent.OpenAIClient{URL: apiURL, APIKey: key, Model: model}, nil
+
+	case "anthropic":
+		key, err := apiKey("anthropic", "ANTHROPIC_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		if apiURL == "" {
+			apiURL = agent.DefaultAnthropicURL
+		}
+		return &agent.AnthropicClient{URL: apiURL, APIKey: key, Model: model}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q (want ollama, openai, or anthropic)", provider)
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/spf13/cobra"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "Manage write backups",
+	Long:  "Lists, restores, and prunes the backups created before files are overwritten.",
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:   "list [file]",
+	Short: "List backups",
+	Long:  "Lists all backups, or only the backups of one file, newest first.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBackupsList,
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore <file> [id]",
+	Short: "Restore a file from backup",
+	Long:  "Restores a file from its newest backup, or from the backup with the given id. The current file is backed up first.",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runBackupsRestore,
+}
+
+var backupsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old backups",
+	Long:  "Removes backups beyond the configured retention count and age.",
+	Args:  cobra.NoArgs,
+	RunE:  runBackupsPrune,
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsListCmd)
+	backupsCmd.AddCommand(backupsRestoreCmd)
+	backupsCmd.AddCommand(backupsPruneCmd)
+	rootCmd.AddCommand(backupsCmd)
+}
+
+func runBackupsList(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+
+	file := ""
+	if len(args) == 1 {
+		file = args[0]
+	}
+
+	entries, err := evaluator.NewBackupManager(cfg).List(file)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No backups found")
+		return nil
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\t%d bytes\n", e.File, e.ID, e.Time.Format(time.RFC3339), e.Size)
+	}
+	return nil
+}
+
+func runBackupsRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+
+	id := ""
+	if len(args) == 2 {
+		id = args[1]
+	}
+
+	entry, err := evaluator.NewBackupManager(cfg).Restore(args[0], id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Restored %s from backup %s (%s)\n", entry.File, entry.ID, entry.Time.Format(time.RFC3339))
+	return nil
+}
+
+func runBackupsPrune(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+
+	removed, err := evaluator.NewBackupManager(cfg).Prune()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Verbose {
+		for _, e := range removed {
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %s (%s)\n", e.File, e.ID)
+		}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Pruned %d backups\n", len(removed))
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/batch"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Run files of jobs against repositories",
+	Long: `Runs the jobs of a jobs file, each a command script or an agent prompt,
+against one or more repositories, for automations such as a nightly
+"update dependencies and run the tests" from cron or CI.`,
+}
+
+var batchRunCmd = &cobra.Command{
+	Use:   "run <jobs.yaml>",
+	Short: "Run the jobs of a jobs file",
+	Long: `Runs each job of the jobs file on each of its repositories, as a session
+of its own, one at a time or --parallel at once. The log and report of every
+run, and a summary.json of their outcomes, are written to --out. Exits
+non-zero when any run failed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBatchRun,
+}
+
+func init() {
+	batchRunCmd.Flags().Int("parallel", 0, "Runs at once (default the jobs file's parallel, or 1)")
+	batchRunCmd.Flags().String("out", "", "Directory for the runs' logs and reports (default batch-<time>)")
+	batchCmd.AddCommand(batchRunCmd)
+	rootCmd.AddCommand(batchCmd)
+}
+
+func runBatchRun(cmd *cobra.Command, args []string) error {
+	parallel, _ := cmd.Flags().GetInt("parallel")
+	out, _ := cmd.Flags().GetString("out")
+	if parallel < 0 {
+		return fmt.Errorf("--parallel cannot be negative")
+	}
+	if out == "" {
+		out = "batch-" + time.Now().Format("20060102-150405")
+	}
+
+	file, err := batch.Load(args[0])
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find llm-runtime binary: %w", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	runner := &batch.Runner{
+		Executable: executable,
+		OutDir:     out,
+		Parallel:   parallel,
+		Progress: func(r batch.Result) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s (%.1fs)\n", r.Name, r.Status, r.Duration)
+		},
+	}
+	results, err := runner.Run(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Status != batch.StatusOK {
+			failed++
+		}
+	}
+	if viper.GetBool("json") {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "%-30s %-7s %5s %8s %7s %9s  %s\n", "RUN", "STATUS", "EXIT", "FAILURES", "CHANGED", "SECONDS", "REPOSITORY")
+		for _, r := range results {
+			fmt.Fprintf(w, "%-30s %-7s %5d %8d %7d %9.1f  %s\n", r.Name, r.Status, r.ExitCode, r.Failures, r.Changed, r.Duration, r.Repo)
+		}
+		fmt.Fprintf(w, "Logs and reports in %s\n", out)
+	}
+
+	if ctx.Err() != nil {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return ErrInterrupted
+	}
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d runs did not succeed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/spf13/cobra"
+)
+
+var sandboxCmd = &cobra.Command{
+	Use:   "sandbox",
+	Short: "Manage exec container resources",
+	Long:  "Manages resources kept between exec containers, such as dependency caches.",
+}
+
+var sandboxCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage dependency caches",
+	Long:  "Manages the Docker volumes that keep Go, npm, and pip caches between exec commands.",
+}
+
+var sandboxCacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cache volumes",
+	Long:  "Lists the cache volumes created by exec commands.",
+	Args:  cobra.NoArgs,
+	RunE:  runSandboxCacheList,
+}
+
+var sandboxCachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cache volumes",
+	Long:  "Removes the cache volumes created by exec commands, so the next commands download dependencies afresh. Host directories configured as caches are not touched.",
+	Args:  cobra.NoArgs,
+	RunE:  runSandboxCachePrune,
+}
+
+func init() {
+	sandboxCacheCmd.AddCommand(sandboxCacheListCmd)
+	sandboxCacheCmd.AddCommand(sandboxCachePruneCmd)
+	sandboxCmd.AddCommand(sandboxCacheCmd)
+	rootCmd.AddCommand(sandboxCmd)
+}
+
+func runSandboxCacheList(cmd *cobra.Command, args []string) error {
+	volumes, err := sandbox.ListCacheVolumes(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(volumes) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No cache volumes found")
+		return nil
+	}
+
+	for _, v := range volumes {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", v.Name, v.CreatedAt)
+	}
+	return nil
+}
+
+func runSandboxCachePrune(cmd *cobra.Command, args []string) error {
+	removed, err := sandbox.PruneCacheVolumes(context.Background())
+	for _, name := range removed {
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Pruned %d cache volumes\n", len(removed))
+	return nil
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove leftover containers, temp files, worktrees, and old backups",
+	Long: `Removes the containers and exec temp directories that sessions left behind,
+such as after a crash, and prunes backups beyond the retention settings.
+Containers and directories are removed once older than --cleanup-age, except
+containers of sessions still running on this host. --worktree worktrees of
+sessions no longer running are removed too, after committing any changes
+left in them to their branches.`,
+	Args: cobra.NoArgs,
+	RunE: runCleanup,
+}
+
+func init() {
+	rootCmd.AddCommand(cleanupCmd)
+}
+
+func runCleanup(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+
+	report := app.Cleanup(context.Background(), cfg)
+	out := cmd.OutOrStdout()
+	for _, c := range report.Containers {
+		fmt.Fprintf(out, "Removed container %s (%s, session %s, repo %s, %s old)\n", c.ID, c.Kind, c.Session, c.Repo, c.Age.Round(time.Minute))
+	}
+	for _, d := range report.TempDirs {
+		fmt.Fprintf(out, "Removed %s (%d bytes)\n", d.ID, d.Size)
+	}
+	for _, w := range report.Worktrees {
+		saved := ""
+		if w.Committed {
+			saved = ", changes committed"
+		}
+		fmt.Fprintf(out, "Removed worktree %s (branch %s%s)\n", w.Dir, w.Branch, saved)
+	}
+	for _, s := range report.Scratch {
+		fmt.Fprintf(out, "Removed scratch space %s (%d bytes)\n", s.Dir, s.Size)
+	}
+	for _, b := range report.Backups {
+		fmt.Fprintf(out, "Removed backup %s (%s, %d bytes)\n", b.File, b.ID, b.Size)
+	}
+	for _, w := range report.Warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", w)
+	}
+
+	fmt.Fprintf(out, "Removed %d containers, %d temp directories, %d worktrees, %d scratch spaces, and %d backups; reclaimed %d bytes\n",
+		len(report.Containers), len(report.TempDirs), len(report.Worktrees), len(report.Scratch), len(report.Backups), report.Reclaimed())
+	return nil
+}
@@ -1,12 +1,24 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/computerscienceiscool/llm-runtime/pkg/app"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
 	"github.com/computerscienceiscool/llm-runtime/pkg/dynrepo"
+	"github.com/computerscienceiscool/llm-runtime/pkg/forge"
+	"github.com/computerscienceiscool/llm-runtime/pkg/hooks"
+	"github.com/computerscienceiscool/llm-runtime/pkg/notify"
+	"github.com/computerscienceiscool/llm-runtime/pkg/objrepo"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
 	"github.com/spf13/viper"
 )
 
@@ -21,6 +33,191 @@ func initConfig() {
 	}
 }
 
+// loadConfigLayers merges the repo-local config file over the user-level
+// one, then applies the selected profile over both. Environment variables
+// and flags still take precedence over everything merged here.
+func loadConfigLayers() error {
+	if viper.GetBool("repo-config") {
+		if err := mergeRepoConfig(repoConfigDir()); err != nil {
+			return err
+		}
+	}
+	return applyProfile(viper.GetString("profile"))
+}
+
+// dynamicRoot is the dynamic repository created when no root was given
+var dynamicRoot string
+
+// repoConfigDir returns the directory searched for the repo-local config
+// file: the repository root, or the working directory when none is given
+func repoConfigDir() string {
+	root := viper.GetString("root")
+	if root == "" || root == "." || root == dynamicRoot {
+		if wd, err := os.Getwd(); err == nil {
+			return wd
+		}
+	}
+	return root
+}
+
+// mergeRepoConfig merges dir's repo-local config file, if it has one, into
+// the config file layer
+func mergeRepoConfig(dir string) error {
+	path := filepath.Join(dir, config.RepoConfigFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	repo := viper.New()
+	repo.SetConfigFile(path)
+	if err := repo.ReadInConfig(); err != nil {
+		return fmt.Errorf("invalid %s: %w", config.RepoConfigFile, err)
+	}
+	if err := checkRepoSecrets(repo); err != nil {
+		return err
+	}
+	// Hooks, plugin executables, and language servers run on the host, and
+	// notifications post the session's commands wherever they are told to,
+	// so a file anyone able to commit to the repository can change may set
+	// none of them
+	for _, key := range []string{"hooks", "notifications", "plugins", "plugins-dir", "commands.lsp"} {
+		if profile, ok := repoSets(repo, key); ok {
+			return fmt.Errorf("%s cannot set %s%s; set them in the user config file", config.RepoConfigFile, key, profile)
+		}
+	}
+	if !viper.GetBool("trust-repo-config") {
+		if key, profile, ok := untrustedRepoKey(repo); ok {
+			return fmt.Errorf("%s cannot set %s%s unless --trust-repo-config is given, since it is not among the settings a repository may choose; set it in the user config file or pass --repo-config=false to ignore the file", config.RepoConfigFile, key, profile)
+		}
+	}
+	return viper.MergeConfigMap(repo.AllSettings())
+}
+
+// repoConfigKeys are the settings a repo-local config file may set without
+// --trust-repo-config: limits, timeouts, and how writes and results are
+// presented. None of them can widen what commands may do, pass the user's
+// credentials or environment on, choose what runs or which image it runs
+// in, or write outside the repository. Each key also covers the keys
+// nested under it. Anything else, including settings added later, is
+// refused unless the file is trusted.
+var repoConfigKeys = []string{
+	// Sizes, limits, and timeouts
+	"max-size", "max-chunked-size", "open-chunk-size", "max-write-size", "max-command-size",
+	"max-archive-size", "max-archive-entries", "truncate-oversize", "truncate-keep",
+	"open-many-max-files", "open-many-max-bytes", "tail-max-follow", "binary-hex-bytes",
+	"exec-timeout", "exec-memory", "exec-cpu", "io-timeout", "io-memory", "io-cpu",
+	"repl-timeout", "repl-memory", "repl-cpu", "fetch-max-size", "fetch-timeout",
+	"issue-max-size", "issue-comments", "test-max-failures", "lint-max-issues",
+	"retries", "retry-backoff", "retry-on", "retry",
+	"commands.open.max_file_size", "commands.open.binary_hex_bytes", "commands.open.max_chunked_size",
+	"commands.open.chunk_size", "commands.open.truncate_oversize", "commands.open.truncate_keep",
+	"commands.open_many", "commands.write.max_file_size",
+	"commands.exec.timeout_seconds", "commands.exec.memory_limit", "commands.exec.cpu_limit",
+	"commands.fetch.max_size", "commands.fetch.timeout",
+	"commands.issue.max_bytes", "commands.issue.comments",
+	"commands.repl.timeout", "commands.repl.memory_limit", "commands.repl.cpu_limit",
+	"commands.test.max_failures", "commands.lint.max_issues",
+	"commands.search.max_results", "commands.search.min_similarity_score",
+	"commands.search.max_preview_length", "commands.search.chunk_size", "commands.search.max_file_size",
+	// How writes are checked and laid out
+	"syntax-check", "line-endings", "trailing-newline", "bom", "backup-max-count", "backup-max-age",
+	"commands.write.syntax_check", "commands.write.line_endings", "commands.write.trailing_newline",
+	"commands.write.bom", "commands.write.backup_max_count", "commands.write.backup_max_age",
+	"git-author", "git-commit-template", "commands.git.author", "commands.git.commit_template",
+	"pr-base", "pr-draft", "commands.pr.base", "commands.pr.draft",
+	// How results are presented
+	"verbose", "quiet", "json", "summary", "color", "deterministic", "output-budget",
+	"token-estimator", "token-annotations", "summarize-oversize", "summarizer-model",
+	"skip-repeat-opens", "strict-parsing", "parse-errors", "usage-label", "usage.label",
+	"output.show_summaries", "output.show_execution_time", "output.truncate_large_outputs",
+	"output.max_output_lines", "output.summarize_oversize", "output.summarizer_model",
+	"logging.level", "logging.format",
+}
+
+// untrustedRepoKey returns the first setting repo sets, at the top level
+// or in one of its profiles, that repoConfigKeys does not cover, and if in
+// a profile, names it for an error message
+func untrustedRepoKey(repo *viper.Viper) (key, profile string, ok bool) {
+	keys := repo.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		setting, profile := key, ""
+		if rest, found := strings.CutPrefix(key, "profiles."); found {
+			name, nested, _ := strings.Cut(rest, ".")
+			if nested == "" {
+				continue
+			}
+			setting, profile = nested, fmt.Sprintf(", as profile %s does", name)
+		}
+		if !repoConfigKey(setting) {
+			return setting, profile, true
+		}
+	}
+	return "", "", false
+}
+
+// repoConfigKey reports whether key is in repoConfigKeys or nested under
+// one of them
+func repoConfigKey(key string) bool {
+	for _, allowed := range repoConfigKeys {
+		if key == allowed || strings.HasPrefix(key, allowed+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// repoSets reports whether repo sets key, at the top level or in one of
+// its profiles, and if in a profile, names it for an error message
+func repoSets(repo *viper.Viper, key string) (string, bool) {
+	if repo.IsSet(key) {
+		return "", true
+	}
+	for name := range repo.GetStringMap("profiles") {
+		if repo.IsSet("profiles." + name + "." + key) {
+			return fmt.Sprintf(", as profile %s does", name), true
+		}
+	}
+	return "", false
+}
+
+// applyProfile merges the settings of the named profile into the config
+// file layer. An empty name applies no profile.
+func applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	key := "profiles." + name
+	if !viper.IsSet(key) {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	return viper.MergeConfigMap(viper.GetStringMap(key))
+}
+
+// reloadConfig re-reads the config files and rebuilds the configuration,
+// for sessions that pick up config changes while running
+func reloadConfig() (*config.Config, error) {
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return nil, err
+		}
+	}
+	if err := loadConfigLayers(); err != nil {
+		return nil, err
+	}
+	return buildConfig()
+}
+
+// watchedConfigFiles returns the config files a running session watches
+func watchedConfigFiles() []string {
+	files := []string{viper.ConfigFileUsed()}
+	if viper.GetBool("repo-config") {
+		files = append(files, filepath.Join(repoConfigDir(), config.RepoConfigFile))
+	}
+	return files
+}
+
 // buildConfig constructs a config.Config from Viper values
 func buildConfig() (*config.Config, error) {
 	// Determine repository root
@@ -31,33 +228,132 @@ func buildConfig() (*config.Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to create dynamic repo: %w", err)
 		}
+		dynamicRoot = dir
 		viper.Set("root", dir)
 	}
 
 	cfg := &config.Config{
 		RepositoryRoot:      viper.GetString("root"),
 		MaxFileSize:         viper.GetInt64("max-size"),
+		MaxChunkedSize:      viper.GetInt64("max-chunked-size"),
+		OpenChunkSize:       viper.GetInt64("open-chunk-size"),
+		TruncateOversize:    viper.GetBool("truncate-oversize"),
+		TruncateKeep:        viper.GetInt64("truncate-keep"),
+		OpenManyMaxFiles:    viper.GetInt("open-many-max-files"),
+		OpenManyMaxBytes:    viper.GetInt64("open-many-max-bytes"),
 		MaxWriteSize:        viper.GetInt64("max-write-size"),
-		ExcludedPaths:       viper.GetStringSlice("exclude"),
+		MaxArchiveSize:      viper.GetInt64("max-archive-size"),
+		MaxArchiveEntries:   viper.GetInt("max-archive-entries"),
+		MaxCommandSize:      viper.GetInt64("max-command-size"),
+		StrictParsing:       viper.GetBool("strict-parsing"),
+		ParseErrors:         viper.GetBool("parse-errors"),
+		ExcludedPaths:       stringSlice("exclude"),
+		AppendOnlyPaths:     stringSlice("append-only"),
+		RespectIgnoreFiles:  viper.GetBool("respect-ignore"),
 		Interactive:         viper.GetBool("interactive"),
 		InputFile:           viper.GetString("input"),
 		OutputFile:          viper.GetString("output"),
+		TranscriptFile:      viper.GetString("transcript"),
+		MetricsAddr:         viper.GetString("metrics"),
+		ReportFile:          viper.GetString("report"),
+		PprofAddr:           viper.GetString("pprof"),
 		JSONOutput:          viper.GetBool("json"),
-		Verbose:             viper.GetBool("verbose"),
+		Verbose:             viper.GetBool("verbose") && !viper.GetBool("quiet"),
+		Quiet:               viper.GetBool("quiet"),
+		Summary:             viper.GetBool("summary"),
+		Deterministic:       viper.GetBool("deterministic"),
+		Color:               viper.GetString("color"),
+		OutputBudget:        viper.GetInt("output-budget"),
+		TokenEstimator:      viper.GetString("token-estimator"),
+		TokenAnnotations:    viper.GetBool("token-annotations"),
+		SkipRepeatOpens:     viper.GetBool("skip-repeat-opens"),
+		SummarizeOversize:   viper.GetBool("summarize-oversize"),
+		SummarizerModel:     viper.GetString("summarizer-model"),
 		RequireConfirmation: viper.GetBool("require-confirmation"),
 		BackupBeforeWrite:   viper.GetBool("backup"),
-		AllowedExtensions:   viper.GetStringSlice("allowed-extensions"),
+		BackupDir:           viper.GetString("backup-dir"),
+		BackupMaxCount:      viper.GetInt("backup-max-count"),
+		CleanupOnStart:      viper.GetBool("cleanup-on-start"),
+		AllowedExtensions:   stringSlice("allowed-extensions"),
+		AllowedModes:        stringSlice("allowed-modes"),
+		AllowBinary:         viper.GetBool("allow-binary"),
+		BinaryHexBytes:      viper.GetInt("binary-hex-bytes"),
 		ForceWrite:          viper.GetBool("force"),
-		ExecWhitelist:       viper.GetStringSlice("exec-whitelist"),
+		ConflictCheck:       viper.GetBool("conflict-check"),
+		SyntaxCheck:         viper.GetString("syntax-check"),
+		LineEndings:         viper.GetString("line-endings"),
+		TrailingNewline:     viper.GetString("trailing-newline"),
+		BOM:                 viper.GetString("bom"),
+		ExecWhitelist:       stringSlice("exec-whitelist"),
+		ExecValidation:      viper.GetString("exec-validation"),
 		ExecMemoryLimit:     viper.GetString("exec-memory"),
 		ExecCPULimit:        viper.GetInt("exec-cpu"),
 		ExecContainerImage:  viper.GetString("exec-image"),
 		ExecNetworkEnabled:  viper.GetBool("exec-network"),
+		FetchAllowedDomains: stringSlice("fetch-allow-domains"),
+		FetchMaxSize:        viper.GetInt64("fetch-max-size"),
+		FetchContentTypes:   stringSlice("fetch-content-types"),
+		REPLLanguages:       stringSlice("repl-languages"),
+		REPLMemoryLimit:     viper.GetString("repl-memory"),
+		REPLCPULimit:        viper.GetInt("repl-cpu"),
+		TestMaxFailures:     viper.GetInt("test-max-failures"),
+		LintMaxIssues:       viper.GetInt("lint-max-issues"),
 		IOContainerImage:    viper.GetString("io-image"),
 		IOMemoryLimit:       viper.GetString("io-memory"),
 		IOCPULimit:          viper.GetInt("io-cpu"),
 	}
 
+	switch cfg.SyntaxCheck {
+	case "", config.SyntaxCheckOff, config.SyntaxCheckWarn, config.SyntaxCheckReject:
+	default:
+		return nil, fmt.Errorf("invalid syntax-check mode %q (want off, warn, or reject)", cfg.SyntaxCheck)
+	}
+
+	switch cfg.LineEndings {
+	case "", config.ConventionPreserve, config.LineEndingLF, config.LineEndingCRLF:
+	default:
+		return nil, fmt.Errorf("invalid line-endings %q (want preserve, lf, or crlf)", cfg.LineEndings)
+	}
+
+	for name, value := range map[string]string{"trailing-newline": cfg.TrailingNewline, "bom": cfg.BOM} {
+		switch value {
+		case "", config.ConventionPreserve, config.ConventionAlways, config.ConventionNever:
+		default:
+			return nil, fmt.Errorf("invalid %s %q (want preserve, always, or never)", name, value)
+		}
+	}
+
+	switch cfg.Color {
+	case "", config.ColorAuto, config.ColorAlways, config.ColorNever:
+	default:
+		return nil, fmt.Errorf("invalid color mode %q (want auto, always, or never)", cfg.Color)
+	}
+
+	if !viper.IsSet("respect-ignore") && viper.IsSet("repository.respect_ignore_files") {
+		cfg.RespectIgnoreFiles = viper.GetBool("repository.respect_ignore_files")
+	}
+
+	if !viper.IsSet("exec-validation") && viper.IsSet("commands.exec.validation") {
+		cfg.ExecValidation = viper.GetString("commands.exec.validation")
+	}
+	switch cfg.ExecValidation {
+	case "":
+		cfg.ExecValidation = config.ExecValidationStrict
+	case config.ExecValidationStrict, config.ExecValidationFirstToken:
+	default:
+		return nil, fmt.Errorf("invalid exec-validation %q (want strict or first-token)", cfg.ExecValidation)
+	}
+
+	switch cfg.TokenEstimator {
+	case "", config.TokenEstimatorBytes, config.TokenEstimatorBPE:
+	default:
+		return nil, fmt.Errorf("invalid token-estimator %q (want bytes or bpe)", cfg.TokenEstimator)
+	}
+
+	if cfg.OutputBudget < 0 {
+		return nil, fmt.Errorf("invalid output-budget %d (want 0 or more tokens)", cfg.OutputBudget)
+	}
+
 	// Parse timeout durations
 	execTimeoutStr := viper.GetString("exec-timeout")
 	execTimeout, err := time.ParseDuration(execTimeoutStr)
@@ -73,6 +369,75 @@ func buildConfig() (*config.Config, error) {
 	}
 	cfg.IOTimeout = ioTimeout
 
+	cfg.FetchTimeout = config.DefaultFetchTimeout
+	fetchTimeoutStr := viper.GetString("fetch-timeout")
+	if !viper.IsSet("fetch-timeout") && viper.IsSet("commands.fetch.timeout") {
+		fetchTimeoutStr = viper.GetString("commands.fetch.timeout")
+	}
+	if fetchTimeoutStr != "" {
+		fetchTimeout, err := time.ParseDuration(fetchTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fetch-timeout: %w", err)
+		}
+		cfg.FetchTimeout = fetchTimeout
+	}
+
+	cfg.REPLTimeout = config.DefaultREPLTimeout
+	if replTimeoutStr := viper.GetString("repl-timeout"); replTimeoutStr != "" {
+		replTimeout, err := time.ParseDuration(replTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repl-timeout: %w", err)
+		}
+		cfg.REPLTimeout = replTimeout
+	}
+
+	cfg.TailMaxFollow = config.DefaultTailFollow
+	if tailMaxFollowStr := viper.GetString("tail-max-follow"); tailMaxFollowStr != "" {
+		tailMaxFollow, err := time.ParseDuration(tailMaxFollowStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tail-max-follow: %w", err)
+		}
+		cfg.TailMaxFollow = tailMaxFollow
+	}
+
+	if backupMaxAgeStr := viper.GetString("backup-max-age"); backupMaxAgeStr != "" {
+		backupMaxAge, err := time.ParseDuration(backupMaxAgeStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup-max-age: %w", err)
+		}
+		cfg.BackupMaxAge = backupMaxAge
+	}
+
+	if cleanupAgeStr := viper.GetString("cleanup-age"); cleanupAgeStr != "" {
+		cleanupAge, err := time.ParseDuration(cleanupAgeStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cleanup-age: %w", err)
+		}
+		cfg.CleanupAge = cleanupAge
+	}
+
+	cfg.Retry = config.RetryPolicy{
+		MaxRetries: viper.GetInt("retries"),
+		RetryOn:    stringSlice("retry-on"),
+	}
+	if cfg.Retry.MaxRetries < 0 {
+		return nil, fmt.Errorf("invalid retries %d (must be 0 or more)", cfg.Retry.MaxRetries)
+	}
+	if retryBackoffStr := viper.GetString("retry-backoff"); retryBackoffStr != "" {
+		retryBackoff, err := time.ParseDuration(retryBackoffStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry-backoff: %w", err)
+		}
+		cfg.Retry.Backoff = retryBackoff
+	}
+
+	// Per-command retry policies are only configurable from the config file
+	if viper.IsSet("retry.commands") {
+		if err := viper.UnmarshalKey("retry.commands", &cfg.RetryPolicies); err != nil {
+			return nil, fmt.Errorf("invalid retry.commands: %w", err)
+		}
+	}
+
 	// Load container pool configuration
 	cfg.ContainerPool = config.PoolConfig{
 		Enabled:             viper.GetBool("container_pool.enabled"),
@@ -83,13 +448,404 @@ func buildConfig() (*config.Config, error) {
 		StartupContainers:   viper.GetInt("container_pool.startup_containers"),
 	}
 
+	// Plugins come from --plugins-dir or plugins.dir; their config blocks,
+	// timeout, and memory limit only from the config file
+	cfg.PluginsDir = viper.GetString("plugins-dir")
+	if cfg.PluginsDir == "" {
+		cfg.PluginsDir = viper.GetString("plugins.dir")
+	}
+	cfg.PluginTimeout = viper.GetDuration("plugins.timeout")
+	cfg.PluginMemoryPages = viper.GetUint32("plugins.memory_limit_pages")
+	if viper.IsSet("plugins.commands") {
+		if err := viper.UnmarshalKey("plugins.commands", &cfg.PluginConfig); err != nil {
+			return nil, fmt.Errorf("invalid plugins.commands: %w", err)
+		}
+	}
+
+	// Hooks are only configurable from the config file
+	if viper.IsSet("hooks") {
+		if err := viper.UnmarshalKey("hooks", &cfg.Hooks); err != nil {
+			return nil, fmt.Errorf("invalid hooks: %w", err)
+		}
+		if err := hooks.Validate(cfg.Hooks); err != nil {
+			return nil, err
+		}
+	}
+
+	// Notifications are only configurable from the config file. Their URLs
+	// are credentials, so may be secret references.
+	cfg.SensitivePaths = stringSlice("notifications.sensitive_paths")
+	if viper.IsSet("notifications.targets") {
+		if err := viper.UnmarshalKey("notifications.targets", &cfg.Notifiers); err != nil {
+			return nil, fmt.Errorf("invalid notifications.targets: %w", err)
+		}
+		for i := range cfg.Notifiers {
+			url, err := config.ResolveSecret(context.Background(), cfg.Notifiers[i].URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid notifications.targets[%d].url: %w", i, err)
+			}
+			cfg.Notifiers[i].URL = url
+		}
+		if err := notify.Validate(cfg.Notifiers); err != nil {
+			return nil, err
+		}
+	}
+
+	// External formatters are only configurable from the config file
+	if viper.IsSet("commands.write.formatters") {
+		if err := viper.UnmarshalKey("commands.write.formatters", &cfg.Formatters); err != nil {
+			return nil, fmt.Errorf("invalid commands.write.formatters: %w", err)
+		}
+		for ext, fc := range cfg.Formatters {
+			switch fc.Builtin {
+			case "", config.FormatterGo, config.FormatterJSON, config.FormatterYAML, config.FormatterMarkdown, config.FormatterSQL:
+			default:
+				return nil, fmt.Errorf("invalid commands.write.formatters.%s.builtin %q (want go, json, yaml, markdown, or sql)", ext, fc.Builtin)
+			}
+			if fc.Builtin != "" && fc.Command != "" {
+				return nil, fmt.Errorf("invalid commands.write.formatters.%s: builtin and command are exclusive", ext)
+			}
+		}
+	}
+
+	// Write quotas are only configurable from the config file
+	if viper.IsSet("commands.write.quotas") {
+		if err := viper.UnmarshalKey("commands.write.quotas", &cfg.WriteQuotas); err != nil {
+			return nil, fmt.Errorf("invalid commands.write.quotas: %w", err)
+		}
+	}
+
+	// Databases for <sql> hold credentials, so they and their limits are
+	// only configurable from the config file
+	if viper.IsSet("commands.sql.databases") {
+		if err := viper.UnmarshalKey("commands.sql.databases", &cfg.SQLDatabases); err != nil {
+			return nil, fmt.Errorf("invalid commands.sql.databases: %w", err)
+		}
+	}
+	for name, db := range cfg.SQLDatabases {
+		dsn, err := resolveDSN(db.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commands.sql.databases.%s.dsn: %w", name, err)
+		}
+		db.DSN = dsn
+		cfg.SQLDatabases[name] = db
+	}
+	cfg.SQLMaxRows = config.DefaultSQLMaxRows
+	if viper.IsSet("commands.sql.max_rows") {
+		cfg.SQLMaxRows = viper.GetInt("commands.sql.max_rows")
+	}
+	cfg.SQLMaxBytes = config.DefaultSQLMaxBytes
+	if viper.IsSet("commands.sql.max_bytes") {
+		cfg.SQLMaxBytes = viper.GetInt64("commands.sql.max_bytes")
+	}
+	cfg.SQLTimeout = config.DefaultSQLTimeout
+	if viper.IsSet("commands.sql.timeout") {
+		sqlTimeout, err := time.ParseDuration(viper.GetString("commands.sql.timeout"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid commands.sql.timeout: %w", err)
+		}
+		cfg.SQLTimeout = sqlTimeout
+	}
+
+	// Interpreter images default to the built-in ones; the config file can
+	// replace any of them
+	cfg.REPLImages = make(map[string]string)
+	for lang, image := range config.DefaultREPLImages {
+		cfg.REPLImages[lang] = image
+	}
+	if viper.IsSet("commands.repl.images") {
+		var configured map[string]string
+		if err := viper.UnmarshalKey("commands.repl.images", &configured); err != nil {
+			return nil, fmt.Errorf("invalid commands.repl.images: %w", err)
+		}
+		for lang, image := range configured {
+			cfg.REPLImages[lang] = image
+		}
+	}
+	if len(cfg.REPLLanguages) == 0 && viper.IsSet("commands.repl.languages") {
+		cfg.REPLLanguages = stringSlice("commands.repl.languages")
+	}
+	if len(cfg.AppendOnlyPaths) == 0 && viper.IsSet("security.append_only_paths") {
+		cfg.AppendOnlyPaths = stringSlice("security.append_only_paths")
+	}
+
+	// Test runners default to the built-in ones; the config file can replace
+	// any of them or add a language
+	cfg.TestRunners = make(map[string]string)
+	for lang, runner := range config.DefaultTestRunners {
+		cfg.TestRunners[lang] = runner
+	}
+	if viper.IsSet("commands.test.runners") {
+		var configured map[string]string
+		if err := viper.UnmarshalKey("commands.test.runners", &configured); err != nil {
+			return nil, fmt.Errorf("invalid commands.test.runners: %w", err)
+		}
+		for lang, runner := range configured {
+			cfg.TestRunners[lang] = runner
+		}
+	}
+	if !viper.IsSet("test-max-failures") && viper.IsSet("commands.test.max_failures") {
+		cfg.TestMaxFailures = viper.GetInt("commands.test.max_failures")
+	}
+
+	// Linters likewise
+	cfg.Linters = make(map[string]string)
+	for lang, linter := range config.DefaultLinters {
+		cfg.Linters[lang] = linter
+	}
+	if viper.IsSet("commands.lint.linters") {
+		var configured map[string]string
+		if err := viper.UnmarshalKey("commands.lint.linters", &configured); err != nil {
+			return nil, fmt.Errorf("invalid commands.lint.linters: %w", err)
+		}
+		for lang, linter := range configured {
+			cfg.Linters[lang] = linter
+		}
+	}
+	if !viper.IsSet("lint-max-issues") && viper.IsSet("commands.lint.max_issues") {
+		cfg.LintMaxIssues = viper.GetInt("commands.lint.max_issues")
+	}
+
+	// Language servers likewise
+	cfg.LanguageServers = make(map[string]string)
+	for lang, server := range config.DefaultLanguageServers {
+		cfg.LanguageServers[lang] = server
+	}
+	if viper.IsSet("commands.lsp.servers") {
+		var configured map[string]string
+		if err := viper.UnmarshalKey("commands.lsp.servers", &configured); err != nil {
+			return nil, fmt.Errorf("invalid commands.lsp.servers: %w", err)
+		}
+		for lang, server := range configured {
+			cfg.LanguageServers[lang] = server
+		}
+	}
+	cfg.LSPTimeout = config.DefaultLSPTimeout
+	if lspTimeoutStr := viper.GetString("commands.lsp.timeout"); lspTimeoutStr != "" {
+		lspTimeout, err := time.ParseDuration(lspTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commands.lsp.timeout: %w", err)
+		}
+		cfg.LSPTimeout = lspTimeout
+	}
+
+	// Dependency caches default to volumes; the config file can replace any
+	// of them, and --exec-hermetic drops them all
+	if !viper.GetBool("exec-hermetic") {
+		sources := make(map[string]string)
+		for name, source := range config.DefaultExecCacheMounts {
+			sources[name] = source
+		}
+		if viper.IsSet("commands.exec.cache_mounts") {
+			var configured map[string]string
+			if err := viper.UnmarshalKey("commands.exec.cache_mounts", &configured); err != nil {
+				return nil, fmt.Errorf("invalid commands.exec.cache_mounts: %w", err)
+			}
+			for name, source := range configured {
+				sources[name] = source
+			}
+		}
+		mounts, err := sandbox.ParseCacheMounts(sources)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commands.exec.cache_mounts: %w", err)
+		}
+		cfg.ExecCacheMounts = make(map[string]string)
+		for _, m := range mounts {
+			cfg.ExecCacheMounts[m.Name] = m.Source
+		}
+	}
+
+	// Exec commands see no host environment except the variables allowed
+	// here; static values only come from the config file
+	cfg.ExecEnvPassthrough = stringSlice("exec-env-passthrough")
+	if len(cfg.ExecEnvPassthrough) == 0 {
+		cfg.ExecEnvPassthrough = stringSlice("commands.exec.env_passthrough")
+	}
+	if viper.IsSet("commands.exec.env") {
+		if err := viper.UnmarshalKey("commands.exec.env", &cfg.ExecEnv); err != nil {
+			return nil, fmt.Errorf("invalid commands.exec.env: %w", err)
+		}
+	}
+	for name := range cfg.ExecEnv {
+		if err := sandbox.ValidateEnvName(name); err != nil {
+			return nil, fmt.Errorf("invalid commands.exec.env: %w", err)
+		}
+	}
+	for _, name := range cfg.ExecEnvPassthrough {
+		if err := sandbox.ValidateEnvName(name); err != nil {
+			return nil, fmt.Errorf("invalid exec-env-passthrough: %w", err)
+		}
+	}
+
 	// If exec-whitelist is empty from flags, try loading from config file
 	if len(cfg.ExecWhitelist) == 0 {
 		// Viper can read from nested config like commands.exec.whitelist
 		if viper.IsSet("commands.exec.whitelist") {
-			cfg.ExecWhitelist = viper.GetStringSlice("commands.exec.whitelist")
+			cfg.ExecWhitelist = stringSlice("commands.exec.whitelist")
 		}
 	}
+
+	if !viper.IsSet("truncate-oversize") && viper.IsSet("commands.open.truncate_oversize") {
+		cfg.TruncateOversize = viper.GetBool("commands.open.truncate_oversize")
+	}
+	if !viper.IsSet("truncate-keep") && viper.IsSet("commands.open.truncate_keep") {
+		cfg.TruncateKeep = viper.GetInt64("commands.open.truncate_keep")
+	}
+	// Summaries of oversize output, from flags or the output block
+	if !viper.IsSet("summarize-oversize") && viper.IsSet("output.summarize_oversize") {
+		cfg.SummarizeOversize = viper.GetBool("output.summarize_oversize")
+	}
+	if !viper.IsSet("summarizer-model") && viper.IsSet("output.summarizer_model") {
+		cfg.SummarizerModel = viper.GetString("output.summarizer_model")
+	}
+	cfg.SummarizerURL = viper.GetString("output.summarizer_url")
+	if cfg.SummarizerURL == "" {
+		cfg.SummarizerURL = viper.GetString("commands.search.ollama_url")
+	}
+	cfg.OutputArtifactDir = viper.GetString("output.artifact_dir")
+
+	if !viper.IsSet("open-many-max-files") && viper.IsSet("commands.open_many.max_files") {
+		cfg.OpenManyMaxFiles = viper.GetInt("commands.open_many.max_files")
+	}
+	if !viper.IsSet("open-many-max-bytes") && viper.IsSet("commands.open_many.max_bytes") {
+		cfg.OpenManyMaxBytes = viper.GetInt64("commands.open_many.max_bytes")
+	}
+	if !viper.IsSet("allowed-modes") && viper.IsSet("commands.write.allowed_modes") {
+		cfg.AllowedModes = stringSlice("commands.write.allowed_modes")
+	}
+	if len(cfg.AllowedModes) == 0 {
+		cfg.AllowedModes = config.DefaultAllowedModes
+	}
+
+	// Likewise for the fetch settings
+	if len(cfg.FetchAllowedDomains) == 0 && viper.IsSet("commands.fetch.allowed_domains") {
+		cfg.FetchAllowedDomains = stringSlice("commands.fetch.allowed_domains")
+	}
+	if !viper.IsSet("fetch-max-size") && viper.IsSet("commands.fetch.max_size") {
+		cfg.FetchMaxSize = viper.GetInt64("commands.fetch.max_size")
+	}
+	if !viper.IsSet("fetch-content-types") && viper.IsSet("commands.fetch.content_types") {
+		cfg.FetchContentTypes = stringSlice("commands.fetch.content_types")
+	}
+	if len(cfg.FetchContentTypes) == 0 {
+		cfg.FetchContentTypes = config.DefaultFetchContentTypes
+	}
+
+	// Git write commands, from flags or the commands.git block
+	cfg.GitWrite = viper.GetBool("git-write")
+	if !viper.IsSet("git-write") && viper.IsSet("commands.git.enabled") {
+		cfg.GitWrite = viper.GetBool("commands.git.enabled")
+	}
+	cfg.GitProtected = stringSlice("git-protected-branches")
+	if !viper.IsSet("git-protected-branches") && viper.IsSet("commands.git.protected_branches") {
+		cfg.GitProtected = stringSlice("commands.git.protected_branches")
+	}
+	cfg.GitAuthor = viper.GetString("git-author")
+	if !viper.IsSet("git-author") && viper.IsSet("commands.git.author") {
+		cfg.GitAuthor = viper.GetString("commands.git.author")
+	}
+	if cfg.GitAuthor == "" {
+		cfg.GitAuthor = config.DefaultGitAuthor
+	}
+	if _, err := mail.ParseAddress(cfg.GitAuthor); err != nil {
+		return nil, fmt.Errorf("invalid git-author %q (want \"Name <email>\"): %w", cfg.GitAuthor, err)
+	}
+	cfg.GitCommitTemplate = viper.GetString("git-commit-template")
+	if !viper.IsSet("git-commit-template") && viper.IsSet("commands.git.commit_template") {
+		cfg.GitCommitTemplate = viper.GetString("commands.git.commit_template")
+	}
+	if cfg.GitCommitTemplate == "" {
+		cfg.GitCommitTemplate = config.DefaultGitCommitTemplate
+	}
+	if _, err := template.New("commit").Parse(cfg.GitCommitTemplate); err != nil {
+		return nil, fmt.Errorf("invalid git-commit-template: %w", err)
+	}
+
+	// Pull requests, from flags or the commands.pr block
+	cfg.PRRemote = viper.GetString("pr-remote")
+	if !viper.IsSet("pr-remote") && viper.IsSet("commands.pr.remote") {
+		cfg.PRRemote = viper.GetString("commands.pr.remote")
+	}
+	if cfg.PRRemote == "" {
+		cfg.PRRemote = "origin"
+	}
+	cfg.PRBase = viper.GetString("pr-base")
+	if !viper.IsSet("pr-base") && viper.IsSet("commands.pr.base") {
+		cfg.PRBase = viper.GetString("commands.pr.base")
+	}
+	cfg.PRForge = viper.GetString("pr-forge")
+	if !viper.IsSet("pr-forge") && viper.IsSet("commands.pr.forge") {
+		cfg.PRForge = viper.GetString("commands.pr.forge")
+	}
+	if cfg.PRForge != "" && cfg.PRForge != forge.GitHub && cfg.PRForge != forge.GitLab {
+		return nil, fmt.Errorf("invalid pr-forge %q (want %s or %s)", cfg.PRForge, forge.GitHub, forge.GitLab)
+	}
+	cfg.PRDraft = viper.GetBool("pr-draft")
+	if !viper.IsSet("pr-draft") && viper.IsSet("commands.pr.draft") {
+		cfg.PRDraft = viper.GetBool("commands.pr.draft")
+	}
+
+	// Issues, from flags or the commands.issue block
+	cfg.Issues = viper.GetBool("issues")
+	if !viper.IsSet("issues") && viper.IsSet("commands.issue.enabled") {
+		cfg.Issues = viper.GetBool("commands.issue.enabled")
+	}
+	cfg.IssueMaxBytes = viper.GetInt64("issue-max-size")
+	if !viper.IsSet("issue-max-size") && viper.IsSet("commands.issue.max_bytes") {
+		cfg.IssueMaxBytes = viper.GetInt64("commands.issue.max_bytes")
+	}
+	cfg.IssueComments = viper.GetInt("issue-comments")
+	if !viper.IsSet("issue-comments") && viper.IsSet("commands.issue.comments") {
+		cfg.IssueComments = viper.GetInt("commands.issue.comments")
+	}
+	// An object store root is copied into the cache at bootstrap
+	if objrepo.IsURL(cfg.RepositoryRoot) {
+		if _, err := objrepo.ParseURL(cfg.RepositoryRoot); err != nil {
+			return nil, fmt.Errorf("invalid root: %w", err)
+		}
+		cfg.ObjectSource = cfg.RepositoryRoot
+	}
+	cfg.ObjectWriteBack = viper.GetString("object-writeback")
+	cfg.ObjectCacheDir = viper.GetString("object-cache-dir")
+	cfg.ObjectEndpoint = viper.GetString("object-endpoint")
+	if cfg.ObjectWriteBack != "" {
+		if cfg.ObjectSource == "" {
+			return nil, fmt.Errorf("--object-writeback needs an s3:// or gs:// root")
+		}
+		if cfg.ObjectWriteBack != config.ObjectWriteBackSource {
+			if _, err := objrepo.ParseURL(cfg.ObjectWriteBack); err != nil {
+				return nil, fmt.Errorf("invalid --object-writeback: %w", err)
+			}
+		}
+	}
+	cfg.Worktree = viper.GetBool("worktree")
+	cfg.WorktreeDir = viper.GetString("worktree-dir")
+	if cfg.Worktree && cfg.ObjectSource != "" {
+		return nil, fmt.Errorf("--worktree needs a git repository root, not an object store prefix")
+	}
+	cfg.ScratchEncrypt = viper.GetBool("scratch-encrypt")
+	cfg.Scratch = viper.GetBool("scratch") || cfg.ScratchEncrypt
+
+	// The memory document comes from --memory or memory.enabled
+	cfg.Memory = viper.GetBool("memory") || viper.GetBool("memory.enabled")
+	cfg.MemoryFile = viper.GetString("memory-file")
+	if !viper.IsSet("memory-file") && viper.GetString("memory.file") != "" {
+		cfg.MemoryFile = viper.GetString("memory.file")
+	}
+
+	// Usage comes from --record-usage or usage.enabled, and is kept in
+	// usage.ledger or else the default ledger
+	if viper.GetBool("record-usage") || viper.GetBool("usage.enabled") {
+		ledger, err := usageLedger()
+		if err != nil {
+			return nil, err
+		}
+		cfg.UsageLedger = ledger
+	}
+	cfg.UsageLabel = viper.GetString("usage-label")
+	if cfg.UsageLabel == "" {
+		cfg.UsageLabel = viper.GetString("usage.label")
+	}
 	//fmt.Printf("DEBUG buildConfig: RepositoryRoot = %s\n", cfg.RepositoryRoot)
 
 	return cfg, nil
@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect configuration",
+	Long:  "Shows how flags, LLM_TOOLS_* environment variables, the config file, and defaults combine.",
+}
+
+var configResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Show effective configuration",
+	Long: `Lists every config key with its effective value, where that value comes from
+(flag, env, file, or default), and the environment variable that overrides it.
+Flags take precedence over environment variables, which take precedence over
+the config file, which takes precedence over defaults.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigResolve,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check configuration for problems",
+	Long: `Checks the user-level and repo-local config files for unknown keys and values
+of the wrong type, then checks the effective configuration for conflicting
+options and missing Docker images. Exits with an error if any problem other
+than a warning is found.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runConfigValidate,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema for the config file",
+	Long:  "Prints a JSON Schema describing the config file, for validation and autocompletion in editors.",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigSchema,
+}
+
+// durationFlags are string flags that hold a Go duration
+var durationFlags = map[string]bool{
+	"exec-timeout":   true,
+	"io-timeout":     true,
+	"backup-max-age": true,
+	"retry-backoff":  true,
+}
+
+func init() {
+	configCmd.AddCommand(configResolveCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigResolve(cmd *cobra.Command, args []string) error {
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	// Secrets given as they are, not as references, are not shown
+	secrets := secretSettings(viper.GetViper())
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tSOURCE\tENV\tVALUE")
+	for _, key := range keys {
+		value := viper.Get(key)
+		if secret, ok := secrets[key]; ok && secret != "" && config.SecretSource(secret) == "" {
+			value = "[REDACTED]"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%v\n", key, configSource(key), envVar(key), value)
+	}
+	return w.Flush()
+}
+
+// configSchema returns the schema of the config file: its sections, a
+// top-level key for every flag, and named profiles of the same keys
+func configSchema() *config.SchemaNode {
+	schema := config.Schema()
+
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		// Config file sections win over flags of the same name, such as
+		// output
+		if _, ok := schema.Properties[f.Name]; ok || f.Name == "profile" {
+			return
+		}
+		node := &config.SchemaNode{Description: f.Usage}
+		switch f.Value.Type() {
+		case "bool":
+			node.Type = "boolean"
+		case "int", "int64":
+			node.Type = "integer"
+		case "stringSlice":
+			node.Type = "array"
+			node.Items = &config.SchemaNode{Type: "string"}
+		default:
+			node.Type = "string"
+		}
+		if durationFlags[f.Name] {
+			node.Type = "duration"
+		}
+		node.Enum = config.EnumValues(f.Name)
+		schema.Properties[f.Name] = node
+	})
+
+	profile := *schema
+	profile.Ref = "#"
+	schema.Properties["profiles"] = &config.SchemaNode{
+		Type:        "object",
+		Description: "Named sets of overrides applied with --profile",
+		Additional:  &profile,
+	}
+	return schema
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	doc := configSchema().JSONSchema()
+	doc["$schema"] = "http://json-schema.org/draft-07/schema#"
+	doc["title"] = "llm-runtime configuration"
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	schema := configSchema()
+	errors := 0
+
+	report := func(source string, problems []config.Problem) {
+		for _, p := range problems {
+			if !p.Warning {
+				errors++
+			}
+			fmt.Fprintf(out, "%s: %s\n", source, p)
+		}
+	}
+
+	files := []string{viper.ConfigFileUsed()}
+	if viper.GetBool("repo-config") {
+		files = append(files, filepath.Join(repoConfigDir(), config.RepoConfigFile))
+	}
+	for _, path := range files {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		file := viper.New()
+		file.SetConfigFile(path)
+		if err := file.ReadInConfig(); err != nil {
+			report(path, []config.Problem{{Key: "(file)", Message: err.Error()}})
+			continue
+		}
+		report(path, schema.Validate(file.AllSettings()))
+	}
+
+	report("effective config", configConflicts())
+	report("docker", missingImages())
+
+	if errors > 0 {
+		return fmt.Errorf("config has %d errors", errors)
+	}
+	fmt.Fprintln(out, "Config is valid")
+	return nil
+}
+
+// configConflicts checks the effective configuration for options that
+// cannot work together
+func configConflicts() []config.Problem {
+	var problems []config.Problem
+
+	if viper.GetBool("commands.exec.enabled") && len(stringSlice("exec-whitelist")) == 0 && len(stringSlice("commands.exec.whitelist")) == 0 {
+		problems = append(problems, config.Problem{Key: "commands.exec.enabled",
+			Message: "exec is enabled but the whitelist is empty, so every exec command is rejected"})
+	}
+
+	if size := viper.GetInt64("max-command-size"); size > 0 && viper.GetInt64("max-write-size") > size {
+		problems = append(problems, config.Problem{Key: "max-write-size",
+			Message: fmt.Sprintf("larger than max-command-size (%d), so writes that large can never be parsed", size),
+			Warning: true})
+	}
+
+	if viper.GetInt("container_pool.startup_containers") > viper.GetInt("container_pool.size") {
+		problems = append(problems, config.Problem{Key: "container_pool.startup_containers",
+			Message: fmt.Sprintf("exceeds container_pool.size (%d)", viper.GetInt("container_pool.size"))})
+	}
+
+	return problems
+}
+
+// missingImages checks that the configured container images exist locally.
+// Without Docker the check is skipped with a warning.
+func missingImages() []config.Problem {
+	if err := sandbox.CheckDockerAvailability(); err != nil {
+		return []config.Problem{{Key: "images", Message: "not checked: Docker is not available", Warning: true}}
+	}
+
+	var problems []config.Problem
+	for _, key := range []string{"exec-image", "io-image"} {
+		image := viper.GetString(key)
+		exists, err := sandbox.ImageExists(image)
+		if err != nil {
+			problems = append(problems, config.Problem{Key: key, Message: fmt.Sprintf("cannot inspect image %s: %v", image, err)})
+		} else if !exists {
+			problems = append(problems, config.Problem{Key: key, Message: fmt.Sprintf("image %s not found locally", image)})
+		}
+	}
+	return problems
+}
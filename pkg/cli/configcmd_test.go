@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfigConflicts(t *testing.T) {
+	viper.Reset()
+	viper.Set("commands.exec.enabled", true)
+	viper.Set("max-command-size", 1024)
+	viper.Set("max-write-size", 2048)
+	viper.Set("container_pool.size", 2)
+	viper.Set("container_pool.startup_containers", 3)
+
+	problems := configConflicts()
+
+	keys := make(map[string]bool)
+	for _, p := range problems {
+		keys[p.Key] = p.Warning
+	}
+	if warning, ok := keys["commands.exec.enabled"]; !ok || warning {
+		t.Errorf("expected an error for exec enabled with an empty whitelist, got %v", problems)
+	}
+	if warning, ok := keys["max-write-size"]; !ok || !warning {
+		t.Errorf("expected a warning for max-write-size over max-command-size, got %v", problems)
+	}
+	if _, ok := keys["container_pool.startup_containers"]; !ok {
+		t.Errorf("expected an error for startup containers over pool size, got %v", problems)
+	}
+
+	viper.Set("exec-whitelist", []string{"go test"})
+	viper.Set("max-write-size", 512)
+	viper.Set("container_pool.startup_containers", 1)
+	if problems := configConflicts(); len(problems) != 0 {
+		t.Errorf("expected no conflicts, got %v", problems)
+	}
+}
+
+func TestRunConfigValidate_ReportsFileProblems(t *testing.T) {
+	viper.Reset()
+
+	repoDir := t.TempDir()
+	content := "commands:\n  exec:\n    timeout_seconds: soon\n    colour: blue\n"
+	if err := os.WriteFile(filepath.Join(repoDir, ".llm-tools.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write repo config: %v", err)
+	}
+	viper.Set("root", repoDir)
+	viper.Set("repo-config", true)
+
+	var out bytes.Buffer
+	configValidateCmd.SetOut(&out)
+	defer configValidateCmd.SetOut(nil)
+
+	err := runConfigValidate(configValidateCmd, nil)
+	if err == nil {
+		t.Fatal("expected an error for a value of the wrong type")
+	}
+	if !strings.Contains(out.String(), "error: commands.exec.timeout_seconds: expected integer") {
+		t.Errorf("output missing type error:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "warning: commands.exec.colour: unknown key") {
+		t.Errorf("output missing unknown key warning:\n%s", out.String())
+	}
+}
+
+func TestRunConfigSchema(t *testing.T) {
+	var out bytes.Buffer
+	configSchemaCmd.SetOut(&out)
+	defer configSchemaCmd.SetOut(nil)
+
+	if err := runConfigSchema(configSchemaCmd, nil); err != nil {
+		t.Fatalf("runConfigSchema() error: %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	props := doc["properties"].(map[string]interface{})
+	for _, key := range []string{"exec-timeout", "repository", "commands", "profiles"} {
+		if _, ok := props[key]; !ok {
+			t.Errorf("schema missing property %q", key)
+		}
+	}
+	if _, ok := props["output"].(map[string]interface{})["properties"]; !ok {
+		t.Error("output should be the config section, not the flag")
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/diagnostics"
+	"github.com/spf13/cobra"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Runtime diagnostics",
+	Long:  "Collects diagnostics for investigating problems such as memory leaks in long-running sessions.",
+}
+
+var debugDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Write a diagnostics bundle",
+	Long: `Writes a gzipped tar bundle of goroutine stacks, a heap profile, and the
+effective configuration. With --addr the bundle is fetched from a running
+llm-runtime started with --pprof; otherwise it describes this process.`,
+	Args: cobra.NoArgs,
+	RunE: runDebugDump,
+}
+
+func init() {
+	debugDumpCmd.Flags().String("addr", "", "Address of a running llm-runtime's --pprof server, e.g. localhost:6060")
+	debugDumpCmd.Flags().StringP("out", "o", "", "Bundle file (default llm-runtime-diagnostics-TIMESTAMP.tar.gz)")
+	debugCmd.AddCommand(debugDumpCmd)
+	rootCmd.AddCommand(debugCmd)
+}
+
+func runDebugDump(cmd *cobra.Command, args []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	out, _ := cmd.Flags().GetString("out")
+	if out == "" {
+		out = fmt.Sprintf("llm-runtime-diagnostics-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	var bundle *diagnostics.Bundle
+	var err error
+	if addr != "" {
+		bundle, err = diagnostics.Fetch(&http.Client{Timeout: 30 * time.Second}, addr)
+	} else {
+		cfg, cfgErr := buildConfig()
+		if cfgErr != nil {
+			return cfgErr
+		}
+		bundle, err = diagnostics.Collect(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("cannot write bundle: %w", err)
+	}
+	if err := bundle.Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("cannot write bundle: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("cannot write bundle: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote diagnostics bundle to %s\n", out)
+	return nil
+}
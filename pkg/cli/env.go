@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// envPrefix prefixes every environment variable override
+const envPrefix = "LLM_TOOLS"
+
+// legacyEnvPrefix is the prefix overrides had before envPrefix. A legacy
+// variable still applies to a key that has no LLM_TOOLS_ variable set, with
+// a warning.
+const legacyEnvPrefix = "LLM"
+
+// envKeyReplacer maps a config key to its environment variable suffix:
+// exec-timeout becomes EXEC_TIMEOUT and commands.exec.enabled becomes
+// COMMANDS_EXEC_ENABLED
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// bindEnv lets an LLM_TOOLS_* environment variable override any config
+// key. Viper applies flags over environment variables, environment
+// variables over the config file, and the config file over defaults.
+func bindEnv() {
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(envKeyReplacer)
+	viper.AutomaticEnv()
+	bindLegacyEnv(os.Stderr)
+}
+
+// bindLegacyEnv binds each known key whose legacy LLM_ variable is set, and
+// whose LLM_TOOLS_ variable is not, to the legacy variable, warning on w
+func bindLegacyEnv(w io.Writer) {
+	for _, key := range viper.AllKeys() {
+		legacy := legacyEnvVar(key)
+		if _, ok := os.LookupEnv(legacy); !ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(envVar(key)); ok {
+			continue
+		}
+		viper.BindEnv(key, legacy)
+		fmt.Fprintf(w, "Warning: %s is deprecated; set %s instead\n", legacy, envVar(key))
+	}
+}
+
+// envVar returns the environment variable that overrides key
+func envVar(key string) string {
+	return envPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// legacyEnvVar returns the variable that overrode key before envPrefix
+func legacyEnvVar(key string) string {
+	return legacyEnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// stringSlice returns a list setting. A value from an environment variable
+// arrives as one string and is split on commas, like the flag form.
+func stringSlice(key string) []string {
+	value, ok := viper.Get(key).(string)
+	if !ok {
+		return viper.GetStringSlice(key)
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// configSource reports where the effective value of key comes from:
+// "flag", "env", "file", or "default"
+func configSource(key string) string {
+	if flag := rootCmd.PersistentFlags().Lookup(key); flag != nil && flag.Changed {
+		return "flag"
+	}
+	if _, ok := os.LookupEnv(envVar(key)); ok {
+		return "env"
+	}
+	if _, ok := os.LookupEnv(legacyEnvVar(key)); ok {
+		return "env"
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
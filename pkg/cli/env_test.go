@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestEnvVar(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"root", "LLM_TOOLS_ROOT"},
+		{"exec-timeout", "LLM_TOOLS_EXEC_TIMEOUT"},
+		{"commands.exec.enabled", "LLM_TOOLS_COMMANDS_EXEC_ENABLED"},
+	}
+
+	for _, tt := range tests {
+		if got := envVar(tt.key); got != tt.want {
+			t.Errorf("envVar(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+// TestBuildConfig_EnvOverridesFile tests that environment variables take
+// precedence over the config file
+func TestBuildConfig_EnvOverridesFile(t *testing.T) {
+	viper.Reset()
+	bindEnv()
+
+	configFile := filepath.Join(t.TempDir(), "llm-runtime.config.yaml")
+	content := "exec-timeout: 45s\nmax-write-size: 2048\n"
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	viper.SetConfigFile(configFile)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig() error: %v", err)
+	}
+
+	t.Setenv("LLM_TOOLS_EXEC_TIMEOUT", "5s")
+	t.Setenv("LLM_TOOLS_EXEC_WHITELIST", "go test, make")
+	viper.Set("root", "/tmp/test")
+	viper.Set("io-timeout", "10s")
+
+	cfg, err := buildConfig()
+	if err != nil {
+		t.Fatalf("buildConfig() unexpected error: %v", err)
+	}
+
+	if cfg.ExecTimeout != 5*time.Second {
+		t.Errorf("ExecTimeout = %v, want 5s from environment", cfg.ExecTimeout)
+	}
+	if cfg.MaxWriteSize != 2048 {
+		t.Errorf("MaxWriteSize = %d, want 2048 from config file", cfg.MaxWriteSize)
+	}
+	if want := []string{"go test", "make"}; !reflect.DeepEqual(cfg.ExecWhitelist, want) {
+		t.Errorf("ExecWhitelist = %q, want %q", cfg.ExecWhitelist, want)
+	}
+
+	if got := configSource("exec-timeout"); got != "env" {
+		t.Errorf("configSource(exec-timeout) = %q, want env", got)
+	}
+	if got := configSource("max-write-size"); got != "file" {
+		t.Errorf("configSource(max-write-size) = %q, want file", got)
+	}
+	if got := configSource("json"); got != "default" {
+		t.Errorf("configSource(json) = %q, want default", got)
+	}
+}
+
+// TestBindLegacyEnv tests that the LLM_ variables from before the LLM_TOOLS_
+// prefix still apply, with a warning, unless the new variable is also set
+func TestBindLegacyEnv(t *testing.T) {
+	viper.Reset()
+	viper.SetDefault("exec-timeout", "30s")
+	viper.SetDefault("verbose", false)
+	viper.SetDefault("max-write-size", 1024)
+	bindEnv()
+
+	t.Setenv("LLM_EXEC_TIMEOUT", "5s")
+	t.Setenv("LLM_VERBOSE", "true")
+	t.Setenv("LLM_MAX_WRITE_SIZE", "1")
+	t.Setenv("LLM_TOOLS_MAX_WRITE_SIZE", "2048")
+	var warnings bytes.Buffer
+	bindLegacyEnv(&warnings)
+
+	if got := viper.GetString("exec-timeout"); got != "5s" {
+		t.Errorf("exec-timeout = %q, want 5s from LLM_EXEC_TIMEOUT", got)
+	}
+	if !viper.GetBool("verbose") {
+		t.Error("verbose not set from LLM_VERBOSE")
+	}
+	if got := viper.GetInt64("max-write-size"); got != 2048 {
+		t.Errorf("max-write-size = %d, want 2048 from LLM_TOOLS_MAX_WRITE_SIZE", got)
+	}
+	if !strings.Contains(warnings.String(), "LLM_EXEC_TIMEOUT is deprecated; set LLM_TOOLS_EXEC_TIMEOUT instead") || strings.Contains(warnings.String(), "LLM_MAX_WRITE_SIZE") {
+		t.Errorf("warnings = %q", warnings.String())
+	}
+	if got := configSource("verbose"); got != "env" {
+		t.Errorf("configSource(verbose) = %q, want env", got)
+	}
+}
+
+// TestBuildConfig_EnvNestedKey tests that a nested config file key read
+// by buildConfig can be set from the environment
+func TestBuildConfig_EnvNestedKey(t *testing.T) {
+	viper.Reset()
+	bindEnv()
+	t.Setenv("LLM_TOOLS_COMMANDS_LINT_MAX_ISSUES", "7")
+	viper.Set("root", "/tmp/test")
+	viper.Set("exec-timeout", "30s")
+	viper.Set("io-timeout", "10s")
+
+	cfg, err := buildConfig()
+	if err != nil {
+		t.Fatalf("buildConfig() unexpected error: %v", err)
+	}
+	if cfg.LintMaxIssues != 7 {
+		t.Errorf("LintMaxIssues = %d, want 7 from LLM_TOOLS_COMMANDS_LINT_MAX_ISSUES", cfg.LintMaxIssues)
+	}
+}
+
+func TestRunConfigResolve(t *testing.T) {
+	viper.Reset()
+	bindEnv()
+	viper.SetDefault("commands.exec.enabled", false)
+	t.Setenv("LLM_TOOLS_COMMANDS_EXEC_ENABLED", "true")
+
+	var out bytes.Buffer
+	configResolveCmd.SetOut(&out)
+	defer configResolveCmd.SetOut(nil)
+
+	if err := runConfigResolve(configResolveCmd, nil); err != nil {
+		t.Fatalf("runConfigResolve() error: %v", err)
+	}
+
+	var line string
+	for _, l := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(l, "commands.exec.enabled ") {
+			line = l
+		}
+	}
+	if fields := strings.Fields(line); len(fields) != 4 || fields[1] != "env" || fields[2] != "LLM_TOOLS_COMMANDS_EXEC_ENABLED" || fields[3] != "true" {
+		t.Errorf("resolve line = %q, want env override", line)
+	}
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/forge"
+	"github.com/spf13/cobra"
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Open pull requests of session branches",
+	Long:  "Pushes branches, such as those --worktree sessions leave behind, and opens pull requests of them on GitHub or merge requests on GitLab.",
+}
+
+var prCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Push a branch and open a pull request",
+	Long: `Pushes a branch of the repository at --root, or in the working directory,
+by default the branch checked out, to --pr-remote and opens a pull request of
+it on GitHub, or a merge request on GitLab, into --pr-base. The API token is
+read from GITHUB_TOKEN or GH_TOKEN, or from GITLAB_TOKEN; over HTTPS it also
+authenticates the push.
+
+The description is --body followed by a summary of the session: the report
+given with --summary, as --report wrote it in Markdown, or else the branch's
+commits and the files they change. The title defaults to the subject of the
+branch's last commit.`,
+	Args: cobra.NoArgs,
+	RunE: runPRCreate,
+}
+
+func init() {
+	prCreateCmd.Flags().StringP("title", "t", "", "Title of the pull request (default the subject of the branch's last commit)")
+	prCreateCmd.Flags().StringP("body", "b", "", "Text starting the description")
+	prCreateCmd.Flags().String("branch", "", "Branch to push (default the one checked out)")
+	prCreateCmd.Flags().String("summary", "", "Session report in Markdown, as written by --report, to add to the description")
+	prCmd.AddCommand(prCreateCmd)
+	rootCmd.AddCommand(prCmd)
+}
+
+func runPRCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+	title, _ := cmd.Flags().GetString("title")
+	body, _ := cmd.Flags().GetString("body")
+	branch, _ := cmd.Flags().GetString("branch")
+	summaryFile, _ := cmd.Flags().GetString("summary")
+	ctx := context.Background()
+	// Without --root the branch is in the repository being worked in, not
+	// a dynamic one
+	dir := cfg.RepositoryRoot
+	if dir == dynamicRoot {
+		if dir, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+
+	if branch == "" {
+		if branch, err = forge.CurrentBranch(ctx, dir); err != nil {
+			return fmt.Errorf("%w; name one with --branch", err)
+		}
+	}
+	if title == "" {
+		if title, err = forge.Subject(ctx, dir, branch); err != nil {
+			return err
+		}
+	}
+	base := cfg.PRBase
+	if base == "" {
+		if base, err = forge.DefaultBranch(ctx, dir, cfg.PRRemote); err != nil {
+			return err
+		}
+	}
+
+	var summary string
+	if summaryFile != "" {
+		data, err := os.ReadFile(summaryFile)
+		if err != nil {
+			return fmt.Errorf("cannot read summary: %w", err)
+		}
+		summary = string(data)
+	} else if summary, err = forge.BranchSummary(ctx, dir, cfg.PRRemote, base, branch); err != nil && cfg.Verbose {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: cannot summarize %s: %v\n", branch, err)
+	}
+	description := strings.TrimSpace(body)
+	if summary = strings.TrimSpace(summary); summary != "" {
+		description = strings.TrimSpace(description + "\n\n---\n\n" + summary)
+	}
+
+	pr, err := forge.Create(ctx, forge.Options{
+		Dir:    dir,
+		Remote: cfg.PRRemote,
+		Forge:  cfg.PRForge,
+		Head:   branch,
+		Base:   base,
+		Title:  title,
+		Body:   description,
+		Draft:  cfg.PRDraft,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Opened pull request %s (%s into %s)\n", pr.URL, pr.Head, pr.Base)
+	return nil
+}
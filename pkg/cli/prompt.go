@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/agent"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print a system prompt for the active configuration",
+	Long: `Prints a ready-to-paste system prompt describing the commands enabled by the
+active configuration, their syntax and limits (whitelisted commands, allowed
+extensions, size limits, excluded paths), and the top level of the repository.
+Regenerate it whenever the configuration changes instead of editing a copy.`,
+	Example: `  llm-runtime prompt --root . --exec-whitelist "go test,go build" > system-prompt.md`,
+	Args:    cobra.NoArgs,
+	RunE:    runPrompt,
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+}
+
+func runPrompt(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), agent.GeneratePrompt(cfg, config.LoadSearchConfig()))
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay TRANSCRIPT",
+	Short: "Re-execute a recorded session",
+	Long: `Re-executes the input recorded in a transcript (see --transcript) against the
+repository it was recorded in, or --root, and prints the results as pipe mode
+would. With --diff, each result is compared with the recorded one instead, and
+only the differences are printed; the command fails if any result differs.
+
+Replay from the same starting state as the recording, for example a clean
+checkout, or writes and tests will differ for that reason alone.`,
+	Example: `  llm-runtime --transcript session.jsonl --exec-whitelist "go test" < llm_output.txt
+  llm-runtime replay --diff --exec-whitelist "go test" session.jsonl`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runReplay,
+}
+
+func init() {
+	replayCmd.Flags().Bool("diff", false, "Compare results with the recording and print only differences")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	compare, _ := cmd.Flags().GetBool("diff")
+
+	entries, err := transcript.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Default to the repository the session was recorded in
+	if !cmd.Flags().Changed("root") {
+		for _, entry := range entries {
+			if entry.Type != transcript.TypeStart || entry.Repository == "" {
+				continue
+			}
+			if _, err := os.Stat(entry.Repository); err != nil {
+				return fmt.Errorf("recorded repository %s is not available; use --root: %w", entry.Repository, err)
+			}
+			viper.Set("root", entry.Repository)
+		}
+	}
+
+	cfg, err := buildConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+	app, err := bootstrapApp(cfg)
+	if err != nil {
+		return fmt.Errorf("bootstrap failed: %w", err)
+	}
+	defer app.Close()
+
+	report := app.Replay(entries, cmd.OutOrStdout(), compare)
+	if !compare {
+		return nil
+	}
+
+	differ := report.Differ + report.Missing
+	fmt.Fprintf(cmd.ErrOrStderr(), "Replayed %d commands: %d differ from the recording\n", report.Commands, differ)
+	if differ > 0 {
+		return fmt.Errorf("%d results differ from the recording", differ)
+	}
+	return nil
+}
@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -13,32 +18,92 @@ var rootCmd = &cobra.Command{
 	Short: "LLM File Access Tool - Command interpreter for LLMs",
 	Long: `llm-runtime enables Large Language Models to interact with local filesystems
 and execute sandboxed commands. It processes commands like <open>, <write>, <exec>, and <search>.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadConfigLayers()
+	},
 	RunE: runRoot,
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	// Config flags
+	rootCmd.PersistentFlags().String("profile", "", "Config profile to apply from the profiles section of the config file")
+	rootCmd.PersistentFlags().Bool("repo-config", true, "Merge the repository's .llm-tools.yaml over the user-level config file")
+	rootCmd.PersistentFlags().Bool("trust-repo-config", false, "Let the repository's .llm-tools.yaml set security settings such as the exec whitelist and excluded paths")
+
 	// Repository flags
-	rootCmd.PersistentFlags().String("root", ".", "Repository root directory")
-	rootCmd.PersistentFlags().StringSlice("exclude", []string{".git", ".env", "*.key", "*.pem"}, "Comma-separated list of excluded paths")
+	rootCmd.PersistentFlags().String("root", ".", "Repository root directory, or an s3:// or gs:// prefix to copy into a local cache")
+	rootCmd.PersistentFlags().String("object-writeback", "", "With an s3:// or gs:// root, where changed files go on exit: source, or an s3:// or gs:// prefix (default: kept in the cache)")
+	rootCmd.PersistentFlags().String("object-cache-dir", "", "Where s3:// and gs:// roots are copied (default: the user cache directory)")
+	rootCmd.PersistentFlags().String("object-endpoint", "", "S3-compatible endpoint for s3:// roots, such as http://localhost:9000 for MinIO")
+	rootCmd.PersistentFlags().Bool("worktree", false, "Work in a git worktree of the root on a new branch, leaving the checkout untouched")
+	rootCmd.PersistentFlags().String("worktree-dir", "", "Where --worktree creates worktrees (default: the temporary directory)")
+	rootCmd.PersistentFlags().Bool("scratch", false, "Give commands a /scratch directory outside the repository, removed when the session ends")
+	rootCmd.PersistentFlags().Bool("scratch-encrypt", false, "Encrypt /scratch files on the host with a key kept in memory (implies --scratch)")
+	rootCmd.PersistentFlags().Bool("memory", false, "Keep a session memory document of the files changed and the notes recorded with <note>, read back with <memory>")
+	rootCmd.PersistentFlags().String("memory-file", config.DefaultMemoryFile, "Where the session memory document is kept, relative to the repository root")
+	rootCmd.PersistentFlags().StringSlice("exclude", config.DefaultExcludedPaths, "Comma-separated list of excluded paths")
+	rootCmd.PersistentFlags().StringSlice("append-only", nil, "Comma-separated list of paths writes may only add lines to, such as CHANGELOG.md,migrations/**")
+	rootCmd.PersistentFlags().Bool("respect-ignore", true, "Honor .gitignore and .llmignore files when opening files")
 
 	// I/O flags
 	rootCmd.PersistentFlags().String("input", "", "Input file (default: stdin)")
 	rootCmd.PersistentFlags().String("output", "", "Output file (default: stdout)")
 	rootCmd.PersistentFlags().Bool("interactive", false, "Run in interactive mode")
+	rootCmd.PersistentFlags().String("transcript", "", "Record input, commands, and results to this JSONL file for replay")
+	rootCmd.PersistentFlags().String("report", "", "On exit, write a session report to this file (JSON for .json, Markdown otherwise, - for stderr)")
+	rootCmd.PersistentFlags().Bool("record-usage", false, "On exit, add the session's resource usage to the usage ledger (see the usage command)")
+	rootCmd.PersistentFlags().String("usage-label", "", "Label, such as a team or project, recorded with the session's usage")
+	rootCmd.PersistentFlags().String("metrics", "", "Serve Prometheus metrics at /metrics on this address, e.g. :9090")
+	rootCmd.PersistentFlags().String("pprof", "", "Serve net/http/pprof and the effective config under /debug/ on this address, e.g. :6060")
 
 	// Output flags
 	rootCmd.PersistentFlags().Bool("json", false, "Output in JSON format")
 	rootCmd.PersistentFlags().Bool("verbose", false, "Verbose output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress banners and informational messages (overrides --verbose)")
+	rootCmd.PersistentFlags().Bool("summary", false, "Print one line per command and a final table of results instead of full result blocks")
+	rootCmd.PersistentFlags().Bool("deterministic", false, "Pin timestamps, IDs, and durations and answer container work without Docker, for golden tests of output")
+	rootCmd.PersistentFlags().String("color", "auto", "Color result blocks and highlight opened code: auto (on a terminal), always, or never")
+	rootCmd.PersistentFlags().Int("output-budget", 0, "Estimated tokens of command output per turn; larger opens are truncated and searches return fewer results (0 for unlimited)")
+	rootCmd.PersistentFlags().String("token-estimator", config.TokenEstimatorBytes, "How tokens are estimated for budgets, <tokens>, and annotations: bytes (four to a token) or bpe (closer on code)")
+	rootCmd.PersistentFlags().Bool("token-annotations", true, "Annotate opened files and search results with their estimated tokens")
+	rootCmd.PersistentFlags().Bool("summarize-oversize", false, "Summarize command output over the remaining --output-budget with a local Ollama model, keeping the full output in a file")
+	rootCmd.PersistentFlags().String("summarizer-model", config.DefaultSummarizerModel, "Ollama model that summarizes output over the budget")
+	rootCmd.PersistentFlags().Bool("skip-repeat-opens", true, "Answer an open of content already returned this session, and unchanged since, with a short notice; <open path force> returns it anyway")
+	rootCmd.PersistentFlags().Int64("max-command-size", 10485760, "Maximum size in bytes of a command body in the input (default 10MB)")
+	rootCmd.PersistentFlags().Bool("strict-parsing", true, "Ignore commands inside markdown code fences and inline code")
+	rootCmd.PersistentFlags().Bool("parse-errors", true, "Report malformed or unclosed commands as PARSE_ERROR instead of dropping them; a tag must close on the line it opens")
 
 	// File operation flags
 	rootCmd.PersistentFlags().Int64("max-size", 1048576, "Maximum file size in bytes (default 1MB)")
+	rootCmd.PersistentFlags().Int64("max-chunked-size", 104857600, "Files over --max-size up to this size are opened a chunk at a time (default 100MB, 0 to refuse them)")
+	rootCmd.PersistentFlags().Int64("open-chunk-size", 65536, "Bytes per chunk when opening a file over --max-size (default 64KB)")
+	rootCmd.PersistentFlags().Bool("truncate-oversize", false, "Open files too large to open whole as their first and last --truncate-keep bytes instead of failing")
+	rootCmd.PersistentFlags().Int64("truncate-keep", config.DefaultTruncateKeep, "Bytes shown from each end of a file opened with --truncate-oversize (default 16KB)")
+	rootCmd.PersistentFlags().Int("open-many-max-files", config.DefaultOpenManyMaxFiles, "Most files one <open-many> opens")
+	rootCmd.PersistentFlags().Int64("open-many-max-bytes", config.DefaultOpenManyMaxBytes, "Most bytes one <open-many> opens in all (default 256KB)")
+	rootCmd.PersistentFlags().String("tail-max-follow", "60s", "Longest a <tail follow=DURATION> may watch a file for appended lines")
 	rootCmd.PersistentFlags().Int64("max-write-size", 102400, "Maximum file size in bytes for writing (default 100KB)")
-	rootCmd.PersistentFlags().StringSlice("allowed-extensions", []string{".go", ".py", ".js", ".md", ".txt", ".json", ".yaml", ".yml", ".toml"}, "Comma-separated list of allowed file extensions for writing")
+	rootCmd.PersistentFlags().Int64("max-archive-size", 104857600, "Most bytes <unzip> may extract or <archive> may pack (default 100MB)")
+	rootCmd.PersistentFlags().Int("max-archive-entries", 1000, "Most files <unzip> may extract or <archive> may pack")
+	rootCmd.PersistentFlags().StringSlice("allowed-extensions", config.DefaultAllowedExtensions, "Comma-separated list of allowed file extensions for writing")
+	rootCmd.PersistentFlags().StringSlice("allowed-modes", config.DefaultAllowedModes, "Comma-separated octal file modes <write path mode=...> may set")
+	rootCmd.PersistentFlags().Bool("allow-binary", false, "Return raw content when opening binary files instead of a summary")
+	rootCmd.PersistentFlags().Int("binary-hex-bytes", 64, "Bytes of hex dump included in binary file summaries (0 to disable)")
 	rootCmd.PersistentFlags().Bool("backup", true, "Create backup before overwriting files")
+	rootCmd.PersistentFlags().String("backup-dir", ".llm-tools/backups", "Backup directory, relative to the repository root")
+	rootCmd.PersistentFlags().Int("backup-max-count", 5, "Backups kept per file (0 for unlimited)")
+	rootCmd.PersistentFlags().String("backup-max-age", "720h", "Backups older than this are pruned (0 to keep forever)")
+	rootCmd.PersistentFlags().Bool("cleanup-on-start", true, "Remove containers and temp files left behind by crashed sessions at startup")
+	rootCmd.PersistentFlags().String("cleanup-age", "1h", "Leftover containers and temp files older than this are removed by cleanup")
 	rootCmd.PersistentFlags().Bool("require-confirmation", false, "Require confirmation for write operations")
 	rootCmd.PersistentFlags().Bool("force", false, "Force write even if conflicts exist")
+	rootCmd.PersistentFlags().Bool("conflict-check", true, "Reject writes to files changed on disk since they were last opened")
+	rootCmd.PersistentFlags().String("syntax-check", "reject", "Syntax validation for Go/JSON/YAML writes: off, warn, or reject")
+	rootCmd.PersistentFlags().String("line-endings", "preserve", "Line endings for written files: preserve, lf, or crlf")
+	rootCmd.PersistentFlags().String("trailing-newline", "preserve", "Final newline for written files: preserve, always, or never")
+	rootCmd.PersistentFlags().String("bom", "preserve", "UTF-8 byte order mark for written files: preserve, always, or never")
 
 	// Exec flags
 	rootCmd.PersistentFlags().String("exec-timeout", "30s", "Timeout for exec commands")
@@ -46,7 +111,37 @@ func init() {
 	rootCmd.PersistentFlags().Int("exec-cpu", 1, "CPU limit for containers")
 	rootCmd.PersistentFlags().String("exec-image", "python-go", "Docker image for exec commands")
 	rootCmd.PersistentFlags().Bool("exec-network", false, "Enable network access in containers")
+	rootCmd.PersistentFlags().Bool("exec-hermetic", false, "Run exec commands without the persistent Go, npm, and pip caches")
+	rootCmd.PersistentFlags().StringSlice("exec-env-passthrough", []string{}, "Comma-separated host environment variables to pass to exec commands, e.g. CI,GOFLAGS")
+	rootCmd.PersistentFlags().String("exec-validation", config.ExecValidationStrict, "How exec commands are checked against the whitelist: strict (every command in chains and pipelines) or first-token")
 	rootCmd.PersistentFlags().StringSlice("exec-whitelist", []string{}, "Comma-separated list of allowed exec commands")
+	rootCmd.PersistentFlags().StringSlice("fetch-allow-domains", []string{}, "Comma-separated hosts <fetch> may request, e.g. api.example.com,*.example.org (empty disables <fetch>)")
+	rootCmd.PersistentFlags().Int64("fetch-max-size", 1048576, "Largest response body in bytes <fetch> accepts (default 1MB)")
+	rootCmd.PersistentFlags().StringSlice("fetch-content-types", config.DefaultFetchContentTypes, "Comma-separated response media types <fetch> accepts; a subtype of * matches any")
+	rootCmd.PersistentFlags().String("fetch-timeout", "30s", "Timeout for <fetch> requests")
+	rootCmd.PersistentFlags().Bool("git-write", false, "Allow <git-branch> and <git-commit> to create branches and commits in the repository")
+	rootCmd.PersistentFlags().StringSlice("git-protected-branches", config.DefaultGitProtected, "Comma-separated branches, or patterns such as release/*, <git-commit> never commits to and <git-branch> never creates")
+	rootCmd.PersistentFlags().String("git-author", config.DefaultGitAuthor, "Author and committer of <git-commit> commits, as \"Name <email>\"")
+	rootCmd.PersistentFlags().String("git-commit-template", config.DefaultGitCommitTemplate, "Go template of <git-commit> messages, given {{.Message}}, {{.Branch}}, and {{.SessionID}}")
+	rootCmd.PersistentFlags().String("pr-remote", "origin", "Git remote <pr> and pr create push branches to and open pull requests on")
+	rootCmd.PersistentFlags().String("pr-base", "", "Branch pull requests merge into (default the remote's default branch)")
+	rootCmd.PersistentFlags().String("pr-forge", "", "Forge of the remote: github or gitlab (default told from its host)")
+	rootCmd.PersistentFlags().Bool("pr-draft", false, "Open pull requests as drafts")
+	rootCmd.PersistentFlags().Bool("issues", false, "Allow <issue> to read issues of the --pr-remote repository on GitHub or GitLab")
+	rootCmd.PersistentFlags().Int64("issue-max-size", config.DefaultIssueMaxBytes, "Most bytes an <issue> result may take (default 32KB)")
+	rootCmd.PersistentFlags().Int("issue-comments", config.DefaultIssueComments, "Most recent comments an <issue> result shows")
+	rootCmd.PersistentFlags().StringSlice("repl-languages", []string{}, "Comma-separated languages <repl> may run: python, go (empty disables <repl>)")
+	rootCmd.PersistentFlags().String("repl-timeout", "30s", "Longest a <repl> snippet may run; a snippet that times out resets its session")
+	rootCmd.PersistentFlags().String("repl-memory", "512m", "Memory limit for <repl> interpreter containers")
+	rootCmd.PersistentFlags().Int("repl-cpu", 1, "CPU limit for <repl> interpreter containers")
+	rootCmd.PersistentFlags().Int("test-max-failures", config.DefaultTestMaxFailures, "Failures a <test> result shows messages for")
+	rootCmd.PersistentFlags().Int("lint-max-issues", config.DefaultLintMaxIssues, "Issues a <lint> result lists")
+	rootCmd.PersistentFlags().String("plugins-dir", "", "Directory of executables providing plugin commands, such as <jira ISSUE-123>")
+
+	// Retry flags
+	rootCmd.PersistentFlags().Int("retries", 0, "Times to retry a command that fails with a retryable error")
+	rootCmd.PersistentFlags().String("retry-backoff", "1s", "Delay before the first retry; doubles on each later retry")
+	rootCmd.PersistentFlags().StringSlice("retry-on", config.DefaultRetryOn, "Comma-separated list of error codes to retry")
 
 	// I/O Containerization flags
 	rootCmd.PersistentFlags().String("io-image", "llm-runtime-io:latest", "Docker image for I/O operations")
@@ -72,11 +167,35 @@ func runRoot(cmd *cobra.Command, args []string) error {
 	}
 	defer app.Close()
 
-	return app.Run()
+	if cfg.Interactive {
+		app.EnableReload(reloadConfig, watchedConfigFiles()...)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	err = app.RunContext(ctx)
+	if errors.Is(err, ErrInterrupted) {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+	}
+	return err
 }
 
 // Execute runs the root command
 func Execute() error {
+	shutdown, err := telemetry.Setup(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: tracing disabled: %v\n", err)
+		return rootCmd.Execute()
+	}
+	defer func() {
+		// Flush spans still buffered, without holding up exit for long
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot export traces: %v\n", err)
+		}
+	}()
 	return rootCmd.Execute()
 }
 
@@ -90,7 +209,6 @@ func init() {
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("$HOME")
 
-	// Enable environment variables with LLM prefix
-	viper.SetEnvPrefix("LLM")
-	viper.AutomaticEnv()
+	// Enable LLM_TOOLS_* environment variable overrides
+	bindEnv()
 }
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/spf13/viper"
+)
+
+// resolveDSN returns dsn with its secret resolved: the whole of it when it
+// is a reference such as env:NAME, or else each ${VAR} in it
+func resolveDSN(dsn string) (string, error) {
+	if config.SecretSource(dsn) != "" {
+		return config.ResolveSecret(context.Background(), dsn)
+	}
+	// Unset variables expand to nothing, as they always have
+	return os.Expand(dsn, func(name string) string {
+		value, _ := config.ResolveSecret(context.Background(), "env:"+name)
+		return value
+	}), nil
+}
+
+// apiKey returns the API key of provider: the environment variable env,
+// or else agent.PROVIDER_api_key in the config file, resolved
+func apiKey(provider, env string) (string, error) {
+	if key := os.Getenv(env); key != "" {
+		return key, nil
+	}
+	setting := "agent." + provider + "_api_key"
+	value := viper.GetString(setting)
+	if value == "" {
+		return "", fmt.Errorf("%s is not set, nor is %s in the config file", env, setting)
+	}
+	key, err := config.ResolveSecret(context.Background(), value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", setting, err)
+	}
+	return key, nil
+}
+
+// secretSettings returns the settings in v that hold secrets, by key
+func secretSettings(v *viper.Viper) map[string]string {
+	settings := map[string]string{}
+	for _, key := range []string{"agent.openai_api_key", "agent.anthropic_api_key"} {
+		if v.IsSet(key) {
+			settings[key] = v.GetString(key)
+		}
+	}
+	for name := range v.GetStringMap("commands.sql.databases") {
+		key := "commands.sql.databases." + name + ".dsn"
+		settings[key] = v.GetString(key)
+	}
+	return settings
+}
+
+// checkRepoSecrets refuses secrets the repo-local config file reads from
+// files or commands: the file comes with the repository, so anyone able to
+// commit to it could otherwise read the user's files or run commands on
+// the host when the config is loaded
+func checkRepoSecrets(repo *viper.Viper) error {
+	var refused []string
+	for key, value := range secretSettings(repo) {
+		if source := config.SecretSource(value); source == "file" || source == "command" {
+			refused = append(refused, key)
+		}
+	}
+	if len(refused) == 0 {
+		return nil
+	}
+	sort.Strings(refused)
+	return fmt.Errorf("%s cannot read secrets from files or commands (%s); use env:NAME, or set them in the user config file", config.RepoConfigFile, strings.Join(refused, ", "))
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/app"
+)
+
+// ErrInterrupted is returned by Execute when SIGINT or SIGTERM stopped the
+// session
+var ErrInterrupted = app.ErrInterrupted
+
+// interruptContext returns a context canceled on SIGINT or SIGTERM, so a
+// session can stop its containers and close its logs before exiting. Once
+// the first signal arrives the default handling is restored, and a second
+// one ends the process at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Manage working tree snapshots",
+	Long:  "Creates, lists, and restores snapshots of the whole working tree, a coarse safety net to take before an agent run.",
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Snapshot the working tree",
+	Long:  "Archives the working tree under .llm-tools/snapshots: in a git repository the tracked files and untracked ones git does not ignore, elsewhere every file.",
+	Args:  cobra.NoArgs,
+	RunE:  runSnapshotCreate,
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore [id]",
+	Short: "Restore the working tree from a snapshot",
+	Long:  "Restores the working tree from the newest snapshot, or from the snapshot with the given id. Files not in the snapshot are deleted. The current tree is snapshotted first.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runSnapshotRestore,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots",
+	Long:  "Lists all snapshots, newest first.",
+	Args:  cobra.NoArgs,
+	RunE:  runSnapshotList,
+}
+
+func init() {
+	snapshotCreateCmd.Flags().StringP("message", "m", "", "Note stored with the snapshot")
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+	message, _ := cmd.Flags().GetString("message")
+
+	snap, err := snapshot.NewManager(cfg.RepositoryRoot, "").Create(message)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Created snapshot %s: %d files, %d bytes (sha256 %s)\n", snap.ID, snap.Files, snap.Bytes, snap.SHA256)
+	return nil
+}
+
+func runSnapshotRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+
+	id := ""
+	if len(args) == 1 {
+		id = args[0]
+	}
+
+	result, err := snapshot.NewManager(cfg.RepositoryRoot, "").Restore(id)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Verbose {
+		for _, file := range result.Removed {
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", file)
+		}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Restored snapshot %s (%s): %d files restored, %d removed\n",
+		result.Snapshot.ID, result.Snapshot.Time.Format(time.RFC3339), result.Restored, len(result.Removed))
+	fmt.Fprintf(cmd.OutOrStdout(), "The previous tree is in snapshot %s\n", result.Safety.ID)
+	return nil
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return err
+	}
+
+	snaps, err := snapshot.NewManager(cfg.RepositoryRoot, "").List()
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No snapshots found")
+		return nil
+	}
+
+	for _, s := range snaps {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%d files\t%d bytes\t%s\n", s.ID, s.Time.Format(time.RFC3339), s.Files, s.Bytes, s.Message)
+	}
+	return nil
+}
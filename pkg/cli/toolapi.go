@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/toolapi"
+	"github.com/spf13/cobra"
+)
+
+var toolapiCmd = &cobra.Command{
+	Use:   "toolapi",
+	Short: "Run commands sent as JSON lines on stdin",
+	Long: `Reads one command per line from stdin as JSON, runs each in turn in a single
+session, and writes one JSON response line per command to stdout. Agent
+frameworks outside Go use it to call the runtime as a tool; Go programs can
+import pkg/toolapi instead. See docs/toolapi.md for the protocol.`,
+	Example: `  echo '{"id": "1", "type": "open", "argument": "go.mod"}' | llm-runtime toolapi
+  llm-runtime toolapi --exec-whitelist "go test" < requests.jsonl`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runToolAPI,
+}
+
+func init() {
+	rootCmd.AddCommand(toolapiCmd)
+}
+
+func runToolAPI(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+
+	runtime, err := toolapi.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer runtime.Close()
+
+	ctx, stop := interruptContext()
+	defer stop()
+	if err := runtime.Serve(ctx, os.Stdin, cmd.OutOrStdout()); err != nil {
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Supervise a session in a full-screen dashboard",
+	Long: `Runs commands from stdin or --input like pipe mode while showing a full-screen
+dashboard on the terminal: a scrolling command log, the output of the latest
+exec, the queue of writes awaiting approval (with --require-confirmation), and
+session totals. Results go to --output, or to stdout when it is redirected.`,
+	Args: cobra.NoArgs,
+	RunE: runTUI,
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+
+	// Draw on the controlling terminal, since stdin carries the agent's
+	// commands and stdout may carry the results
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("the dashboard needs a terminal: %w", err)
+	}
+	defer tty.Close()
+
+	app, err := bootstrapApp(cfg)
+	if err != nil {
+		return fmt.Errorf("bootstrap failed: %w", err)
+	}
+	defer app.Close()
+
+	return app.RunTUI(tty)
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/usage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report the resources sessions used",
+	Long: `Reports the usage ledger that sessions run with --record-usage, or with
+usage.enabled in the config file, add to when they end: the containers they
+ran and the CPU time those were allowed, the bytes they wrote, and the model
+tokens and cost of agent sessions.`,
+}
+
+var usageReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Total usage by user, repository, label, model, or day",
+	Long:  "Totals the usage of the sessions that ended within --since, grouped by --by.",
+	Args:  cobra.NoArgs,
+	RunE:  runUsageReport,
+}
+
+func init() {
+	usageReportCmd.Flags().String("since", "30d", "How far back to report: a duration such as 12h or 7d, or a date such as 2024-05-01")
+	usageReportCmd.Flags().String("by", "user", "Group sessions by "+strings.Join(usage.Groupings, ", "))
+	usageCmd.AddCommand(usageReportCmd)
+	rootCmd.AddCommand(usageCmd)
+}
+
+func runUsageReport(cmd *cobra.Command, args []string) error {
+	since, _ := cmd.Flags().GetString("since")
+	by, _ := cmd.Flags().GetString("by")
+
+	from, err := usage.ParseSince(since, time.Now())
+	if err != nil {
+		return err
+	}
+	ledger, err := usageLedger()
+	if err != nil {
+		return err
+	}
+	entries, err := usage.Read(ledger, from)
+	if err != nil {
+		return fmt.Errorf("cannot read usage ledger: %w", err)
+	}
+	totals, all, err := usage.Report(entries, by)
+	if err != nil {
+		return err
+	}
+
+	if viper.GetBool("json") {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]any{"since": from, "by": by, "groups": totals, "total": all})
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No sessions recorded in %s since %s\n", ledger, from.Format(time.RFC3339))
+		return nil
+	}
+	usage.WriteText(cmd.OutOrStdout(), by, totals, all)
+	return nil
+}
+
+// usageLedger returns the usage ledger file: usage.ledger in the config
+// file, or else the default ledger
+func usageLedger() (string, error) {
+	ledger := viper.GetString("usage.ledger")
+	if ledger == "" {
+		return usage.DefaultLedger()
+	}
+	if ledger == "~" || strings.HasPrefix(ledger, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot find home directory: %w", err)
+		}
+		ledger = filepath.Join(home, strings.TrimPrefix(ledger, "~"))
+	}
+	return ledger, nil
+}
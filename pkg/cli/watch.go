@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Re-run a command script when files change",
+	Long: `Runs the command script given by --on-change, then watches the repository
+and runs it again whenever files change, as a local CI loop in the same
+sandbox the LLM uses. Changes are gathered until none has followed for
+--debounce, so saving several files runs the script once. Files the script
+writes itself, such as a summary, do not trigger another run.
+
+Paths excluded by excluded_paths, .gitignore, or .llmignore, the .git
+directory, and paths matching --ignore are not watched.`,
+	Example: `  llm-runtime watch --on-change checks.txt --exec-whitelist "go test"
+
+  # checks.txt
+  <exec go test ./...>
+  <write .llm-tools/last-run.md>Tests ran on ${DATE}.</write>`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runWatch,
+}
+
+func init() {
+	watchCmd.Flags().String("on-change", "", "Command script to run when files change")
+	watchCmd.Flags().Duration("debounce", 500*time.Millisecond, "How long files must be quiet before the script runs")
+	watchCmd.Flags().StringSlice("ignore", nil, "More paths not to watch, in .gitignore syntax")
+	watchCmd.MarkFlagRequired("on-change")
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	script, _ := cmd.Flags().GetString("on-change")
+	debounce, _ := cmd.Flags().GetDuration("debounce")
+	ignored, _ := cmd.Flags().GetStringSlice("ignore")
+	if debounce < 0 {
+		return fmt.Errorf("--debounce cannot be negative")
+	}
+	if _, err := os.ReadFile(script); err != nil {
+		return fmt.Errorf("cannot read script: %w", err)
+	}
+
+	cfg, err := buildConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+	// The files edited are those of the repository, not of a copy
+	if cfg.Worktree || cfg.ObjectSource != "" {
+		return fmt.Errorf("watch runs in the repository itself, not with --worktree or an object store root")
+	}
+	app, err := bootstrapApp(cfg)
+	if err != nil {
+		return fmt.Errorf("bootstrap failed: %w", err)
+	}
+	defer app.Close()
+
+	ctx, stop := interruptContext()
+	defer stop()
+	app.SetContext(ctx)
+
+	watcher, err := watch.New(cfg.RepositoryRoot, debounce, append(cfg.ExcludedPaths, ignored...))
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	changed := []string(nil)
+	for run := 1; ; run++ {
+		if !cfg.Quiet {
+			if changed == nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "--- Run %d ---\n", run)
+			} else {
+				fmt.Fprintf(cmd.ErrOrStderr(), "--- Run %d, changed: %s ---\n", run, describeChanges(changed))
+			}
+		}
+		// Read for every run, so edits to the script take effect
+		file, err := os.Open(script)
+		if err != nil {
+			return fmt.Errorf("cannot read script: %w", err)
+		}
+		written := app.RunScript(file, cmd.OutOrStdout())
+		file.Close()
+		watcher.Settle(written)
+		if ctx.Err() != nil {
+			break
+		}
+
+		if !cfg.Quiet {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Watching for changes (Ctrl+C to stop)...")
+		}
+		if changed, err = watcher.Next(ctx); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+	}
+
+	cmd.SilenceErrors = true
+	return ErrInterrupted
+}
+
+// describeChanges lists the first few of the changed paths
+func describeChanges(paths []string) string {
+	const shown = 5
+	if len(paths) <= shown {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s, and %d more", strings.Join(paths[:shown], ", "), len(paths)-shown)
+}
@@ -5,13 +5,37 @@ import "time"
 // Default values and limits for the LLM runtime
 const (
 	// File size limits
-	DefaultMaxFileSize    = 1 * 1024 * 1024  // 1MB - maximum file size for read operations
-	DefaultMaxWriteSize   = 100 * 1024       // 100KB - maximum write content size
-	DefaultScanBufferSize = 10 * 1024 * 1024 // 10MB - maximum scanner buffer size
+	DefaultMaxFileSize       = 1 * 1024 * 1024   // 1MB - maximum file size for read operations
+	DefaultMaxWriteSize      = 100 * 1024        // 100KB - maximum write content size
+	DefaultScanBufferSize    = 10 * 1024 * 1024  // 10MB - maximum scanner buffer size
+	DefaultBinaryHexBytes    = 64                // Bytes of hex dump shown for binary files
+	DefaultMaxChunkedSize    = 100 * 1024 * 1024 // 100MB - files up to this size are opened a chunk at a time
+	DefaultOpenChunkSize     = 64 * 1024         // 64KB - bytes per chunk of a chunked open
+	DefaultTruncateKeep      = 16 * 1024         // 16KB - bytes shown from each end of a file too large to open
+	DefaultOpenManyMaxFiles  = 20                // Most files one <open-many> opens
+	DefaultOpenManyMaxBytes  = 256 * 1024        // 256KB - most bytes one <open-many> opens in all
+	DefaultMaxArchiveSize    = 100 * 1024 * 1024 // 100MB - most bytes an archive may expand to or hold
+	DefaultMaxArchiveEntries = 1000              // Most files an archive may expand to or hold
+	DefaultFetchMaxSize      = 1024 * 1024       // 1MB - largest response body <fetch> accepts
+	DefaultSQLMaxRows        = 100               // Most rows an <sql> result shows
+	DefaultSQLMaxBytes       = 64 * 1024         // 64KB - most bytes an <sql> result table may take
+	DefaultIssueMaxBytes     = 32 * 1024         // 32KB - most bytes an <issue> result may take
+	DefaultIssueComments     = 10                // Most recent comments an <issue> result shows
+	DefaultTestMaxFailures   = 5                 // Failures a <test> result shows messages for
+	DefaultLintMaxIssues     = 50                // Issues a <lint> result lists
 
 	// Timeout values
-	DefaultIOTimeout   = 30 * time.Second // Timeout for I/O container operations
-	DefaultExecTimeout = 30 * time.Second // Timeout for exec container operations
+	DefaultIOTimeout    = 30 * time.Second // Timeout for I/O container operations
+	DefaultExecTimeout  = 30 * time.Second // Timeout for exec container operations
+	DefaultTailFollow   = 60 * time.Second // Longest a <tail follow=...> may watch a file
+	DefaultFetchTimeout = 30 * time.Second // Timeout for <fetch> requests
+	DefaultSQLTimeout   = 30 * time.Second // Timeout for <sql> queries
+	DefaultREPLTimeout  = 30 * time.Second // Longest a <repl> snippet may run
+	DefaultLSPTimeout   = 60 * time.Second // Longest a <def> or <refs> may take, loading the workspace included
+
+	// Summaries of oversize output
+	DefaultSummarizerModel  = "llama3"        // Ollama model that summarizes output over the budget
+	DefaultSummarizeTimeout = 2 * time.Minute // Longest a summary may take before the full output is kept
 
 	// Container resource limits
 	DefaultContainerMemory = "512m" // Memory limit per container
@@ -25,10 +49,31 @@ const (
 	// Validation limits
 	MaxCommandLength = 1000 // Maximum length for exec commands
 	MaxPathLength    = 4096 // Maximum path length
+	MaxPipeSteps     = 10   // Maximum number of commands in a <pipe> block
+
+	// Session memory
+	DefaultMemoryFile = ".llm-tools/memory.md" // Memory document location relative to the repository root
+	MemoryMaxRead     = 32 * 1024              // 32KB - most of the memory document a <memory> returns, from its end
 
 	// Backup configuration
-	BackupExtension = ".bak" // Extension for backup files
-	MaxBackups      = 5      // Maximum number of backups to keep per file
+	BackupExtension     = ".bak"               // Extension for backup files
+	MaxBackups          = 5                    // Maximum number of backups to keep per file
+	DefaultBackupDir    = ".llm-tools/backups" // Backup location relative to the repository root
+	DefaultBackupMaxAge = 30 * 24 * time.Hour  // Backups older than this are pruned
+
+	// Retry configuration
+	DefaultRetryBackoff = 1 * time.Second // Delay before the first retry; doubles on each later retry
+
+	// Plugin configuration
+	DefaultPluginTimeout          = 30 * time.Second // Longest a plugin command may run
+	DefaultPluginMemoryLimitPages = 1024             // 64 KiB pages of memory a WASM plugin may use (64 MiB)
+
+	// Hook configuration
+	DefaultHookTimeout = 10 * time.Second // Longest a hook may run before it counts as failed
+
+	// Notification configuration
+	DefaultNotifyRateLimit = 10               // Messages a notifier posts a minute; the rest are counted and dropped
+	DefaultNotifyTimeout   = 10 * time.Second // Longest a notification may take to post
 
 	// Audit log configuration
 	DefaultAuditLogPath = "audit.log"
@@ -47,3 +92,122 @@ const (
 	DefaultHealthCheckInterval = 30 * time.Second
 	DefaultStartupContainers   = 3
 )
+
+// RepoConfigFile is the repo-local config file merged over the user-level
+// config file
+const RepoConfigFile = ".llm-tools.yaml"
+
+// Syntax check modes for writes
+const (
+	SyntaxCheckOff    = "off"    // Never validate syntax
+	SyntaxCheckWarn   = "warn"   // Write anyway and report a warning
+	SyntaxCheckReject = "reject" // Refuse the write with SYNTAX_ERROR
+)
+
+// Line ending, trailing newline, and BOM handling for writes
+const (
+	ConventionPreserve = "preserve" // Match the existing file
+	ConventionAlways   = "always"   // Always add (trailing newline, BOM)
+	ConventionNever    = "never"    // Always remove (trailing newline, BOM)
+	LineEndingLF       = "lf"       // Convert line endings to \n
+	LineEndingCRLF     = "crlf"     // Convert line endings to \r\n
+)
+
+// Built-in formatters commands.write.formatters can enable by name. Go
+// and JSON are on for .go and .json without being named.
+const (
+	FormatterGo       = "go"
+	FormatterJSON     = "json"
+	FormatterYAML     = "yaml"
+	FormatterMarkdown = "markdown"
+	FormatterSQL      = "sql"
+)
+
+// Color modes for result blocks
+const (
+	ColorAuto   = "auto"   // Color when writing to a terminal
+	ColorAlways = "always" // Color stdout even when redirected
+	ColorNever  = "never"  // Never color
+)
+
+// Token estimators
+const (
+	TokenEstimatorBytes = "bytes" // Four bytes to a token
+	TokenEstimatorBPE   = "bpe"   // Words, numbers, and punctuation split as BPE tokenizers split them
+)
+
+// Exec validation modes
+const (
+	ExecValidationStrict     = "strict"      // Every command in chains, pipelines, and substitutions is checked
+	ExecValidationFirstToken = "first-token" // Only the start of the command line is checked
+)
+
+// ObjectWriteBackSource writes the changed files of an object store root
+// back to the prefix they came from
+const ObjectWriteBackSource = "source"
+
+// DefaultExcludedPaths are the paths no command may read or write unless
+// configured otherwise
+var DefaultExcludedPaths = []string{".git", ".env", "*.key", "*.pem", ".llm-tools", ".llm-tools.yaml"}
+
+// DefaultAllowedExtensions are the file extensions <write> may create
+// unless configured otherwise
+var DefaultAllowedExtensions = []string{".go", ".py", ".js", ".md", ".txt", ".json", ".yaml", ".yml", ".toml"}
+
+// DefaultExecCacheMounts keeps the Go, npm, and pip caches of exec
+// commands in Docker volumes that outlive each container
+var DefaultExecCacheMounts = map[string]string{"go": "volume", "npm": "volume", "pip": "volume"}
+
+// DefaultRetryOn lists the error codes retried when a policy names none:
+// timeouts and container or Docker failures that are usually transient
+var DefaultRetryOn = []string{"EXEC_TIMEOUT", "EXEC_ERROR", "DOCKER_IMAGE", "READ_CONTAINER", "WRITE_CONTAINER"}
+
+// DefaultAllowedModes are the file modes <write path mode=...> may set
+// unless configured otherwise
+var DefaultAllowedModes = []string{"0644", "0755"}
+
+// DefaultFetchContentTypes are the response types <fetch> accepts unless
+// configured otherwise: text, such as docs and specs, and structured data
+var DefaultFetchContentTypes = []string{"text/*", "application/json", "application/*+json", "application/yaml", "application/x-yaml", "application/xml", "application/*+xml"}
+
+// DefaultGitProtected are the branches <git-commit> never commits to and
+// <git-branch> never creates unless configured otherwise
+var DefaultGitProtected = []string{"main", "master"}
+
+// Defaults of the identity and message template of <git-commit> commits.
+// The trailer ties each commit to the session's audit log.
+const (
+	DefaultGitAuthor         = "LLM Runtime <llm-runtime@localhost>"
+	DefaultGitCommitTemplate = "{{.Message}}\n\nLLM-Runtime-Session: {{.SessionID}}\n"
+)
+
+// DefaultREPLImages are the interpreter images <repl> uses unless
+// configured otherwise. Go snippets are interpreted by yaegi.
+var DefaultREPLImages = map[string]string{"python": "python:3.12-slim", "go": "traefik/yaegi:latest"}
+
+// DefaultTestRunners are the commands <test> runs for each language unless
+// configured otherwise. Each runs in the exec container and must be allowed
+// by the exec whitelist.
+var DefaultTestRunners = map[string]string{"go": "go test -v", "python": "python -m pytest -rfE", "javascript": "npx jest"}
+
+// DefaultLinters are the commands <lint> runs for each language unless
+// configured otherwise. Like test runners, they run in the exec container
+// and must be allowed by the exec whitelist.
+var DefaultLinters = map[string]string{
+	"go":         "golangci-lint run",
+	"python":     "ruff check --output-format=concise",
+	"javascript": "npx eslint --format unix",
+}
+
+// DefaultLanguageServers are the language servers <def> and <refs> start
+// for each language unless configured otherwise. Unlike test runners, they
+// run on the host, in the repository, like the go list behind <deps>.
+var DefaultLanguageServers = map[string]string{"go": "gopls"}
+
+// DeterministicTime is the clock reading of every timestamp a
+// deterministic session would otherwise take from the clock, such as
+// ${DATE} and backup names
+var DeterministicTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DeterministicSessionID is the session ID of every deterministic session
+const DeterministicSessionID = "deterministic"
@@ -19,6 +19,7 @@ func getDefaultSearchConfig() *search.SearchConfig {
 		OllamaURL:           "http://localhost:11434",
 		IndexExtensions:     []string{".go", ".py", ".js", ".md", ".txt", ".yaml", ".json"},
 		MaxFileSize:         int64(DefaultMaxFileSize),
+		RespectIgnoreFiles:  true,
 	}
 }
 
@@ -26,17 +27,32 @@ func getDefaultSearchConfig() *search.SearchConfig {
 func SetViperDefaults() {
 	// Repository defaults
 	viper.SetDefault("repository.root", ".")
-	viper.SetDefault("repository.excluded_paths", []string{".git", ".env", "*.key", "*.pem"})
+	viper.SetDefault("repository.excluded_paths", DefaultExcludedPaths)
+	viper.SetDefault("repository.respect_ignore_files", true)
 
 	// Command defaults - Open
 	viper.SetDefault("commands.open.enabled", true)
 	viper.SetDefault("commands.open.max_file_size", DefaultMaxFileSize)
 	viper.SetDefault("commands.open.allowed_extensions", []string{".go", ".py", ".js", ".md", ".txt", ".json", ".yaml"})
+	viper.SetDefault("commands.open.allow_binary", false)
+	viper.SetDefault("commands.open.binary_hex_bytes", DefaultBinaryHexBytes)
+	viper.SetDefault("commands.open.max_chunked_size", DefaultMaxChunkedSize)
+	viper.SetDefault("commands.open.chunk_size", DefaultOpenChunkSize)
+	viper.SetDefault("commands.open.truncate_oversize", false)
+	viper.SetDefault("commands.open.truncate_keep", DefaultTruncateKeep)
 
 	// Command defaults - Write
 	viper.SetDefault("commands.write.enabled", true)
 	viper.SetDefault("commands.write.max_file_size", DefaultMaxWriteSize)
 	viper.SetDefault("commands.write.backup_before_write", true)
+	viper.SetDefault("commands.write.backup_dir", DefaultBackupDir)
+	viper.SetDefault("commands.write.backup_max_count", MaxBackups)
+	viper.SetDefault("commands.write.backup_max_age", DefaultBackupMaxAge)
+	viper.SetDefault("commands.write.syntax_check", SyntaxCheckReject)
+	viper.SetDefault("commands.write.conflict_check", true)
+	viper.SetDefault("commands.write.line_endings", ConventionPreserve)
+	viper.SetDefault("commands.write.trailing_newline", ConventionPreserve)
+	viper.SetDefault("commands.write.bom", ConventionPreserve)
 
 	// Command defaults - Exec
 	viper.SetDefault("commands.exec.enabled", false)
@@ -58,6 +74,17 @@ func SetViperDefaults() {
 	viper.SetDefault("commands.search.ollama_url", "http://localhost:11434")
 	viper.SetDefault("commands.search.index_extensions", []string{".go", ".py", ".js", ".md", ".txt", ".yaml", ".json"})
 	viper.SetDefault("commands.search.max_file_size", DefaultMaxFileSize)
+	viper.SetDefault("commands.search.respect_ignore_files", true)
+
+	// Retry defaults
+	viper.SetDefault("retry.max_retries", 0)
+	viper.SetDefault("retry.backoff", DefaultRetryBackoff)
+	viper.SetDefault("retry.retry_on", DefaultRetryOn)
+
+	// Plugin defaults
+	viper.SetDefault("plugins.dir", "")
+	viper.SetDefault("plugins.timeout", DefaultPluginTimeout)
+	viper.SetDefault("plugins.memory_limit_pages", DefaultPluginMemoryLimitPages)
 
 	// Security defaults
 	viper.SetDefault("security.rate_limit_per_minute", 100)
@@ -69,6 +96,8 @@ func SetViperDefaults() {
 	viper.SetDefault("output.show_execution_time", true)
 	viper.SetDefault("output.truncate_large_outputs", true)
 	viper.SetDefault("output.max_output_lines", 1000)
+	viper.SetDefault("output.summarize_oversize", false)
+	viper.SetDefault("output.summarizer_model", DefaultSummarizerModel)
 
 	// Logging defaults
 	viper.SetDefault("logging.level", "info")
@@ -88,16 +117,30 @@ func SetViperDefaults() {
 func setFullConfigDefaults(config *fullConfig) {
 	// Default repository settings
 	config.Repository.Root = "."
-	config.Repository.ExcludedPaths = []string{".git", ".env", "*.key", "*.pem"}
+	config.Repository.ExcludedPaths = []string{".git", ".env", "*.key", "*.pem", ".llm-tools", ".llm-tools.yaml"}
+	config.Repository.RespectIgnoreFiles = true
 
 	// Default command settings
 	config.Commands.Open.Enabled = true
 	config.Commands.Open.MaxFileSize = DefaultMaxFileSize
 	config.Commands.Open.AllowedExtensions = []string{".go", ".py", ".js", ".md", ".txt", ".json", ".yaml"}
+	config.Commands.Open.AllowBinary = false
+	config.Commands.Open.BinaryHexBytes = DefaultBinaryHexBytes
+	config.Commands.Open.MaxChunkedSize = DefaultMaxChunkedSize
+	config.Commands.Open.ChunkSize = DefaultOpenChunkSize
+	config.Commands.Open.TruncateKeep = DefaultTruncateKeep
 
 	config.Commands.Write.Enabled = true
 	config.Commands.Write.MaxFileSize = DefaultMaxWriteSize
 	config.Commands.Write.BackupBeforeWrite = true
+	config.Commands.Write.BackupDir = DefaultBackupDir
+	config.Commands.Write.BackupMaxCount = MaxBackups
+	config.Commands.Write.BackupMaxAge = DefaultBackupMaxAge
+	config.Commands.Write.SyntaxCheck = SyntaxCheckReject
+	config.Commands.Write.ConflictCheck = true
+	config.Commands.Write.LineEndings = ConventionPreserve
+	config.Commands.Write.TrailingNewline = ConventionPreserve
+	config.Commands.Write.BOM = ConventionPreserve
 
 	config.Commands.Exec.Enabled = false
 	config.Commands.Exec.ContainerImage = "ubuntu:22.04"
@@ -117,6 +160,12 @@ func setFullConfigDefaults(config *fullConfig) {
 	config.Commands.Search.OllamaURL = "http://localhost:11434"
 	config.Commands.Search.IndexExtensions = []string{".go", ".py", ".js", ".md", ".txt", ".yaml", ".json"}
 	config.Commands.Search.MaxFileSize = int64(DefaultMaxFileSize)
+	config.Commands.Search.RespectIgnoreFiles = true
+
+	// Default retry settings
+	config.Retry.MaxRetries = 0
+	config.Retry.Backoff = DefaultRetryBackoff
+	config.Retry.RetryOn = DefaultRetryOn
 
 	// Default security settings
 	config.Security.RateLimitPerMinute = 100
@@ -128,6 +177,7 @@ func setFullConfigDefaults(config *fullConfig) {
 	config.Output.ShowExecutionTime = true
 	config.Output.TruncateLargeOutputs = true
 	config.Output.MaxOutputLines = 1000
+	config.Output.SummarizerModel = DefaultSummarizerModel
 
 	// Default logging settings
 	config.Logging.Level = "info"
@@ -173,6 +223,9 @@ func LoadSearchConfig() *search.SearchConfig {
 	if viper.IsSet("commands.search.max_file_size") {
 		cfg.MaxFileSize = viper.GetInt64("commands.search.max_file_size")
 	}
+	if viper.IsSet("commands.search.respect_ignore_files") {
+		cfg.RespectIgnoreFiles = viper.GetBool("commands.search.respect_ignore_files")
+	}
 
 	return cfg
 }
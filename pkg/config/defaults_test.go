@@ -85,7 +85,7 @@ func TestsetFullConfigDefaults(t *testing.T) {
 		if len(cfg.Repository.ExcludedPaths) != 4 {
 			t.Errorf("expected 4 excluded paths, got %d", len(cfg.Repository.ExcludedPaths))
 		}
-		expectedExcluded := []string{".git", ".env", "*.key", "*.pem"}
+		expectedExcluded := []string{".git", ".env", "*.key", "*.pem", ".llm-tools", ".llm-tools.yaml"}
 		for i, path := range expectedExcluded {
 			if cfg.Repository.ExcludedPaths[i] != path {
 				t.Errorf("excluded path %d: expected %q, got %q", i, path, cfg.Repository.ExcludedPaths[i])
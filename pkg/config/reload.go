@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Change describes a setting changed by a config reload
+type Change struct {
+	Setting  string // Config field name, e.g. "ExecWhitelist"
+	Old      string
+	New      string
+	Security bool // Changes what the LLM may read, write, or run
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Setting, c.Old, c.New)
+}
+
+// reloadable lists the settings a running session picks up on reload, and
+// whether each affects its security posture. Everything else, such as the
+// repository root or container images, only changes on restart.
+var reloadable = map[string]bool{
+	"ExecWhitelist":       true,
+	"ExcludedPaths":       true,
+	"AppendOnlyPaths":     true,
+	"AllowedExtensions":   true,
+	"AllowedModes":        true,
+	"RespectIgnoreFiles":  true,
+	"ExecNetworkEnabled":  true,
+	"ExecEnv":             true,
+	"ExecValidation":      true,
+	"ExecEnvPassthrough":  true,
+	"FetchAllowedDomains": true,
+	"FetchContentTypes":   true,
+	"SQLDatabases":        true,
+	"GitWrite":            true,
+	"GitProtected":        true,
+	"PRRemote":            true,
+	"Issues":              true,
+	"REPLLanguages":       true,
+	"TestRunners":         true,
+	"Linters":             true,
+	"WriteQuotas":         true,
+	"Hooks":               true,
+	"AllowBinary":         false,
+	"MaxFileSize":         false,
+	"MaxChunkedSize":      false,
+	"OpenChunkSize":       false,
+	"TruncateOversize":    false,
+	"TruncateKeep":        false,
+	"OpenManyMaxFiles":    false,
+	"OpenManyMaxBytes":    false,
+	"TailMaxFollow":       false,
+	"MaxWriteSize":        false,
+	"MaxArchiveSize":      false,
+	"MaxArchiveEntries":   false,
+	"FetchMaxSize":        false,
+	"FetchTimeout":        false,
+	"SQLMaxRows":          false,
+	"SQLMaxBytes":         false,
+	"SQLTimeout":          false,
+	"GitAuthor":           false,
+	"GitCommitTemplate":   false,
+	"PRBase":              false,
+	"PRForge":             false,
+	"PRDraft":             false,
+	"IssueMaxBytes":       false,
+	"IssueComments":       false,
+	"REPLTimeout":         false,
+	"TestMaxFailures":     false,
+	"LintMaxIssues":       false,
+	"MaxCommandSize":      false,
+	"StrictParsing":       false,
+	"ParseErrors":         false,
+	"Verbose":             false,
+	"Quiet":               false,
+	"Color":               false,
+	"OutputBudget":        false,
+	"TokenEstimator":      false,
+	"TokenAnnotations":    false,
+	"SkipRepeatOpens":     false,
+	"SummarizeOversize":   false,
+	"BackupBeforeWrite":   false,
+	"BackupMaxCount":      false,
+	"BackupMaxAge":        false,
+	"BinaryHexBytes":      false,
+	"ForceWrite":          false,
+	"ConflictCheck":       false,
+	"SyntaxCheck":         false,
+	"LineEndings":         false,
+	"TrailingNewline":     false,
+	"BOM":                 false,
+	"Formatters":          false,
+	"ExecTimeout":         false,
+	"ExecMemoryLimit":     false,
+	"ExecCPULimit":        false,
+	"IOTimeout":           false,
+	"IOMemoryLimit":       false,
+	"IOCPULimit":          false,
+	"Retry":               false,
+	"RetryPolicies":       false,
+}
+
+// ApplyReload copies the reloadable settings that differ in next into cfg,
+// in place so everything holding cfg sees them. It returns the changes
+// applied and the names of changed settings that need a restart.
+func ApplyReload(cfg, next *Config) ([]Change, []string) {
+	var applied []Change
+	var restart []string
+
+	dst := reflect.ValueOf(cfg).Elem()
+	src := reflect.ValueOf(next).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		name := dst.Type().Field(i).Name
+		oldValue, newValue := dst.Field(i), src.Field(i)
+		if reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+			continue
+		}
+
+		security, ok := reloadable[name]
+		if !ok {
+			restart = append(restart, name)
+			continue
+		}
+
+		applied = append(applied, Change{
+			Setting:  name,
+			Old:      RedactSecrets(fmt.Sprint(oldValue.Interface())),
+			New:      RedactSecrets(fmt.Sprint(newValue.Interface())),
+			Security: security,
+		})
+		oldValue.Set(newValue)
+	}
+
+	return applied, restart
+}
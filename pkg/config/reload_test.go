@@ -0,0 +1,54 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyReload(t *testing.T) {
+	cfg := &Config{
+		RepositoryRoot:     "/repo",
+		ExecWhitelist:      []string{"go test"},
+		MaxWriteSize:       1024,
+		ExecContainerImage: "python:3.11-alpine",
+	}
+	next := &Config{
+		RepositoryRoot:     "/other",
+		ExecWhitelist:      []string{"go test", "make"},
+		MaxWriteSize:       2048,
+		ExecContainerImage: "golang:1.22",
+	}
+
+	applied, restart := ApplyReload(cfg, next)
+
+	if want := []string{"go test", "make"}; !reflect.DeepEqual(cfg.ExecWhitelist, want) {
+		t.Errorf("ExecWhitelist = %q, want %q", cfg.ExecWhitelist, want)
+	}
+	if cfg.MaxWriteSize != 2048 {
+		t.Errorf("MaxWriteSize = %d, want 2048", cfg.MaxWriteSize)
+	}
+	if cfg.RepositoryRoot != "/repo" || cfg.ExecContainerImage != "python:3.11-alpine" {
+		t.Errorf("restart-only settings changed: root=%q image=%q", cfg.RepositoryRoot, cfg.ExecContainerImage)
+	}
+
+	security := make(map[string]bool)
+	for _, change := range applied {
+		security[change.Setting] = change.Security
+	}
+	if want := map[string]bool{"ExecWhitelist": true, "MaxWriteSize": false}; !reflect.DeepEqual(security, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+	if want := []string{"RepositoryRoot", "ExecContainerImage"}; !reflect.DeepEqual(restart, want) {
+		t.Errorf("restart = %q, want %q", restart, want)
+	}
+}
+
+func TestApplyReload_NoChanges(t *testing.T) {
+	cfg := &Config{ExecWhitelist: []string{"go test"}}
+	next := &Config{ExecWhitelist: []string{"go test"}}
+
+	applied, restart := ApplyReload(cfg, next)
+	if len(applied) != 0 || len(restart) != 0 {
+		t.Errorf("ApplyReload() = %v, %v, want no changes", applied, restart)
+	}
+}
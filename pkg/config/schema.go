@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaNode describes the value allowed at one config key. Type is a
+// JSON Schema type, or "duration" for a Go duration such as "30s".
+type SchemaNode struct {
+	Type        string
+	Description string
+	Enum        []string
+	Items       *SchemaNode            // Element schema of an array
+	Properties  map[string]*SchemaNode // Known keys of an object
+	Additional  *SchemaNode            // Schema of every value of a map
+	Ref         string                 // Emitted as a JSON Schema $ref instead of inline, for recursive schemas
+}
+
+// Problem is an issue found while validating config settings
+type Problem struct {
+	Key     string
+	Message string
+	Warning bool // Warnings are reported but do not fail validation
+}
+
+func (p Problem) String() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", level, p.Key, p.Message)
+}
+
+// enumValues lists the allowed values of settings with a fixed set
+var enumValues = map[string][]string{
+	"commands.write.syntax_check":     {SyntaxCheckOff, SyntaxCheckWarn, SyntaxCheckReject},
+	"commands.write.line_endings":     {ConventionPreserve, LineEndingLF, LineEndingCRLF},
+	"commands.write.trailing_newline": {ConventionPreserve, ConventionAlways, ConventionNever},
+	"commands.write.bom":              {ConventionPreserve, ConventionAlways, ConventionNever},
+	"syntax-check":                    {SyntaxCheckOff, SyntaxCheckWarn, SyntaxCheckReject},
+	"line-endings":                    {ConventionPreserve, LineEndingLF, LineEndingCRLF},
+	"trailing-newline":                {ConventionPreserve, ConventionAlways, ConventionNever},
+	"bom":                             {ConventionPreserve, ConventionAlways, ConventionNever},
+	"color":                           {ColorAuto, ColorAlways, ColorNever},
+	"logging.level":                   {"debug", "info", "warn", "error"},
+	"logging.format":                  {"json", "text"},
+}
+
+// EnumValues returns the allowed values of key, or nil if any value of its
+// type is allowed
+func EnumValues(key string) []string {
+	return enumValues[key]
+}
+
+// Schema returns the schema of the config file sections, derived from
+// their Go types. Flag-named keys are added by the caller, which knows the
+// flags.
+func Schema() *SchemaNode {
+	root := schemaFor(reflect.TypeOf(fullConfig{}), "")
+	root.Description = "llm-runtime configuration"
+	return root
+}
+
+// schemaFor builds the schema of a Go type found at key
+func schemaFor(t reflect.Type, key string) *SchemaNode {
+	node := &SchemaNode{Enum: enumValues[key]}
+
+	if t == reflect.TypeOf(time.Duration(0)) {
+		node.Type = "duration"
+		return node
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		node.Type = "boolean"
+	case reflect.Int, reflect.Int64:
+		node.Type = "integer"
+	case reflect.Float64:
+		node.Type = "number"
+	case reflect.String:
+		node.Type = "string"
+	case reflect.Slice:
+		node.Type = "array"
+		node.Items = schemaFor(t.Elem(), "")
+	case reflect.Map:
+		node.Type = "object"
+		node.Additional = schemaFor(t.Elem(), "")
+	case reflect.Struct:
+		node.Type = "object"
+		node.Properties = make(map[string]*SchemaNode)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			node.Properties[name] = schemaFor(field.Type, strings.TrimPrefix(key+"."+name, "."))
+		}
+	}
+	return node
+}
+
+// Validate checks settings, as decoded from a YAML config file, against
+// the schema. Unknown keys are warnings since they are ignored; values of
+// the wrong type or outside their allowed set are errors.
+func (n *SchemaNode) Validate(settings map[string]interface{}) []Problem {
+	return n.validate("", settings)
+}
+
+func (n *SchemaNode) validate(key string, value interface{}) []Problem {
+	if msg := n.checkType(value); msg != "" {
+		return []Problem{{Key: key, Message: msg}}
+	}
+
+	var problems []Problem
+	switch v := value.(type) {
+	case []interface{}:
+		if n.Items != nil {
+			for i, item := range v {
+				problems = append(problems, n.Items.validate(fmt.Sprintf("%s[%d]", key, i), item)...)
+			}
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			childKey := strings.TrimPrefix(key+"."+name, ".")
+			child := n.Properties[name]
+			if child == nil {
+				child = n.Additional
+			}
+			if child == nil {
+				problems = append(problems, Problem{Key: childKey, Message: "unknown key (ignored)", Warning: true})
+				continue
+			}
+			problems = append(problems, child.validate(childKey, v[name])...)
+		}
+	}
+	return problems
+}
+
+// checkType returns why value does not fit the node, or "" if it does
+func (n *SchemaNode) checkType(value interface{}) string {
+	ok := true
+	switch n.Type {
+	case "boolean":
+		_, ok = value.(bool)
+	case "integer":
+		switch value.(type) {
+		case int, int64, uint64:
+		default:
+			ok = false
+		}
+	case "number":
+		switch value.(type) {
+		case int, int64, uint64, float64:
+		default:
+			ok = false
+		}
+	case "string":
+		_, ok = value.(string)
+	case "duration":
+		switch v := value.(type) {
+		case int, int64:
+		case string:
+			if _, err := time.ParseDuration(v); err != nil {
+				return fmt.Sprintf("invalid duration %q (use a value such as 30s or 5m)", v)
+			}
+		default:
+			ok = false
+		}
+	case "array":
+		_, ok = value.([]interface{})
+	case "object":
+		_, ok = value.(map[string]interface{})
+	}
+	if !ok {
+		return fmt.Sprintf("expected %s, got %v", n.Type, value)
+	}
+
+	if len(n.Enum) > 0 {
+		s := fmt.Sprint(value)
+		for _, allowed := range n.Enum {
+			if s == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("invalid value %q (want %s)", s, strings.Join(n.Enum, ", "))
+	}
+	return ""
+}
+
+// JSONSchema returns the node as a JSON Schema document fragment
+func (n *SchemaNode) JSONSchema() map[string]interface{} {
+	if n.Ref != "" {
+		return map[string]interface{}{"$ref": n.Ref}
+	}
+
+	out := make(map[string]interface{})
+	switch n.Type {
+	case "duration":
+		out["type"] = []string{"string", "integer"}
+		out["pattern"] = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+	case "":
+	default:
+		out["type"] = n.Type
+	}
+	if n.Description != "" {
+		out["description"] = n.Description
+	}
+	if len(n.Enum) > 0 {
+		out["enum"] = n.Enum
+	}
+	if n.Items != nil {
+		out["items"] = n.Items.JSONSchema()
+	}
+	if n.Properties != nil {
+		props := make(map[string]interface{}, len(n.Properties))
+		for name, child := range n.Properties {
+			props[name] = child.JSONSchema()
+		}
+		out["properties"] = props
+	}
+	if n.Additional != nil {
+		out["additionalProperties"] = n.Additional.JSONSchema()
+	}
+	return out
+}
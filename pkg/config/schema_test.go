@@ -0,0 +1,96 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	settings := map[string]interface{}{
+		"commands": map[string]interface{}{
+			"exec": map[string]interface{}{
+				"enabled":         "yes",
+				"timeout_seconds": 30,
+				"whitelist":       []interface{}{"go test", 5},
+				"network_enabled": true,
+			},
+			"write": map[string]interface{}{
+				"syntax_check":   "strict",
+				"backup_max_age": "1 week",
+				"formatters": map[string]interface{}{
+					".py": map[string]interface{}{"command": "black -", "flags": "-q"},
+				},
+			},
+		},
+		"retry": map[string]interface{}{
+			"commands": map[string]interface{}{
+				"exec": map[string]interface{}{"max_retries": 2, "backoff": "2s"},
+			},
+		},
+		"container_pool": map[string]interface{}{"size": 5},
+	}
+
+	problems := Schema().Validate(settings)
+
+	want := map[string]bool{
+		"commands.exec.enabled":               false,
+		"commands.exec.whitelist[1]":          false,
+		"commands.exec.network_enabled":       true,
+		"commands.write.syntax_check":         false,
+		"commands.write.backup_max_age":       false,
+		"commands.write.formatters..py.flags": true,
+	}
+	got := make(map[string]bool)
+	for _, p := range problems {
+		got[p.Key] = p.Warning
+	}
+
+	for key, warning := range want {
+		w, ok := got[key]
+		if !ok {
+			t.Errorf("expected a problem for %s, got %v", key, problems)
+			continue
+		}
+		if w != warning {
+			t.Errorf("%s: Warning = %v, want %v", key, w, warning)
+		}
+	}
+	if len(problems) != len(want) {
+		t.Errorf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+}
+
+func TestSchema_EnumMessage(t *testing.T) {
+	problems := Schema().Validate(map[string]interface{}{
+		"commands": map[string]interface{}{
+			"write": map[string]interface{}{"line_endings": "cr"},
+		},
+	})
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, "want preserve, lf, crlf") {
+		t.Errorf("problems = %v, want enum error listing allowed values", problems)
+	}
+	if got := problems[0].String(); !strings.HasPrefix(got, "error: commands.write.line_endings:") {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestSchemaNode_JSONSchema(t *testing.T) {
+	root := Schema()
+	profile := *root
+	profile.Ref = "#"
+	root.Properties["profiles"] = &SchemaNode{Type: "object", Additional: &profile}
+
+	doc := root.JSONSchema()
+	props := doc["properties"].(map[string]interface{})
+
+	profiles := props["profiles"].(map[string]interface{})
+	if ref := profiles["additionalProperties"].(map[string]interface{})["$ref"]; ref != "#" {
+		t.Errorf("profiles additionalProperties = %v, want $ref #", profiles["additionalProperties"])
+	}
+
+	write := props["commands"].(map[string]interface{})["properties"].(map[string]interface{})["write"].(map[string]interface{})
+	syntax := write["properties"].(map[string]interface{})["syntax_check"].(map[string]interface{})
+	if syntax["type"] != "string" || len(syntax["enum"].([]string)) != 3 {
+		t.Errorf("syntax_check schema = %v, want string enum", syntax)
+	}
+}
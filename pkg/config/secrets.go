@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretTimeout is how long a secret command may run
+const SecretTimeout = 30 * time.Second
+
+// redactedSecret replaces resolved secrets in logged text
+const redactedSecret = "[REDACTED]"
+
+// secrets holds every value resolved by ResolveSecret, so RedactSecrets can
+// keep them out of logs
+var secrets = struct {
+	sync.Mutex
+	values map[string]bool
+}{values: map[string]bool{}}
+
+// SecretSource reports where a config value is read from: "env" for
+// env:NAME, "file" for @PATH, "command" for !COMMAND, or "" for a value
+// used as it is
+func SecretSource(value string) string {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		return "env"
+	case strings.HasPrefix(value, "@"):
+		return "file"
+	case strings.HasPrefix(value, "!"):
+		return "command"
+	}
+	return ""
+}
+
+// ResolveSecret returns the value a config value refers to: the
+// environment variable NAME for env:NAME, the contents of PATH for @PATH,
+// or the output of COMMAND, run by the shell, for !COMMAND. A final newline
+// is dropped from files and output. Other values are returned as they are.
+// Resolved values are remembered so RedactSecrets can hide them.
+func ResolveSecret(ctx context.Context, value string) (string, error) {
+	var secret string
+	switch SecretSource(value) {
+	case "env":
+		name := strings.TrimPrefix(value, "env:")
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		secret = v
+
+	case "file":
+		path := strings.TrimPrefix(value, "@")
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = home + path[1:]
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read secret file: %w", err)
+		}
+		secret = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+
+	case "command":
+		command := strings.TrimSpace(strings.TrimPrefix(value, "!"))
+		ctx, cancel := context.WithTimeout(ctx, SecretTimeout)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			// The command is named by its first word only, since the rest
+			// may carry credentials of its own
+			name, _, _ := strings.Cut(command, " ")
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("secret command %s failed: %w: %s", name, err, firstLine(msg))
+			}
+			return "", fmt.Errorf("secret command %s failed: %w", name, err)
+		}
+		secret = strings.TrimSuffix(strings.TrimSuffix(stdout.String(), "\n"), "\r")
+
+	default:
+		return value, nil
+	}
+
+	if secret == "" {
+		return "", fmt.Errorf("secret %s is empty", value)
+	}
+	secrets.Lock()
+	secrets.values[secret] = true
+	secrets.Unlock()
+	return secret, nil
+}
+
+// RedactSecrets replaces every value ResolveSecret has returned in s
+func RedactSecrets(s string) string {
+	secrets.Lock()
+	values := make([]string, 0, len(secrets.values))
+	for v := range secrets.values {
+		values = append(values, v)
+	}
+	secrets.Unlock()
+
+	// Longest first, so a secret containing another is hidden whole
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, redactedSecret)
+	}
+	return s
+}
+
+// firstLine returns s up to its first newline
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("LLM_TEST_SECRET", "from-env")
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value, want string
+		wantError   bool
+	}{
+		{"postgres://localhost/app", "postgres://localhost/app", false},
+		{"env:LLM_TEST_SECRET", "from-env", false},
+		{"env:LLM_TEST_UNSET_SECRET", "", true},
+		{"@" + file, "from-file", false},
+		{"@" + file + ".missing", "", true},
+		{"!echo from-command", "from-command", false},
+		{"!exit 3", "", true},
+		{"!true", "", true}, // No output
+	}
+	for _, tt := range tests {
+		got, err := ResolveSecret(context.Background(), tt.value)
+		if (err != nil) != tt.wantError || got != tt.want {
+			t.Errorf("ResolveSecret(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantError)
+		}
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	t.Setenv("LLM_TEST_DSN", "postgres://app:hunter2-redact@db/app")
+	dsn, err := ResolveSecret(context.Background(), "env:LLM_TEST_DSN")
+	if err != nil {
+		t.Fatalf("ResolveSecret() error = %v", err)
+	}
+
+	got := RedactSecrets("cannot connect to " + dsn + ": timeout")
+	if strings.Contains(got, "hunter2-redact") || got != "cannot connect to [REDACTED]: timeout" {
+		t.Errorf("RedactSecrets() = %q", got)
+	}
+	if got := RedactSecrets("nothing secret"); got != "nothing secret" {
+		t.Errorf("RedactSecrets() = %q, want unchanged", got)
+	}
+}
@@ -7,36 +7,138 @@ import (
 // Config holds the tool configuration
 type Config struct {
 	RepositoryRoot      string
+	ObjectSource        string // s3:// or gs:// prefix materialized as RepositoryRoot at bootstrap; empty for a local root
+	ObjectWriteBack     string // Where changed files go when the session ends: "source", an s3:// or gs:// prefix, or empty to keep them local
+	ObjectCacheDir      string // Where object store prefixes are materialized; empty for the user cache directory
+	ObjectEndpoint      string // S3-compatible endpoint, such as a MinIO server; empty for AWS S3 or Google Cloud Storage
+	Worktree            bool   // Run the session in a git worktree of RepositoryRoot, on a branch of its own
+	WorktreeDir         string // Where session worktrees are created; empty for the temporary directory
+	Scratch             bool   // Commands get a /scratch directory of the session's, outside the repository
+	ScratchEncrypt      bool   // Scratch files are encrypted on the host, and exec containers get an in-memory /scratch
+	ScratchDir          string // Host directory of /scratch, created at bootstrap; empty without Scratch or when encrypted
+	Memory              bool   // Keep a session memory document of files changed and notes, read with <memory>
+	MemoryFile          string // Where the memory document is kept, relative to the repository root
 	MaxFileSize         int64
+	MaxChunkedSize      int64         // Files over MaxFileSize up to this size are opened a chunk at a time; 0 to refuse them
+	OpenChunkSize       int64         // Bytes per chunk of a chunked open
+	TruncateOversize    bool          // Files too large to open show their first and last TruncateKeep bytes instead of failing
+	TruncateKeep        int64         // Bytes shown from each end of a file too large to open
+	OpenManyMaxFiles    int           // Most files one <open-many> opens
+	OpenManyMaxBytes    int64         // Most bytes one <open-many> opens in all
+	TailMaxFollow       time.Duration // Longest a <tail follow=...> may watch a file
 	MaxWriteSize        int64
+	MaxArchiveSize      int64 // Most bytes <unzip> may extract or <archive> may pack
+	MaxArchiveEntries   int   // Most entries <unzip> may extract or files <archive> may pack
+	MaxCommandSize      int64
+	StrictParsing       bool
+	ParseErrors         bool // Report malformed commands as PARSE_ERROR instead of dropping them
 	ExcludedPaths       []string
+	AppendOnlyPaths     []string // Files that writes may add lines to but not change or remove them from
+	RespectIgnoreFiles  bool
 	Interactive         bool
 	InputFile           string
 	OutputFile          string
+	TranscriptFile      string // Records the session as JSON lines for replay; empty to disable
+	MetricsAddr         string // Serves Prometheus metrics on this address, e.g. ":9090"; empty to disable
+	PprofAddr           string // Serves net/http/pprof and /debug/config on this address, e.g. ":6060"; empty to disable
+	ReportFile          string // Writes an end-of-session report here: JSON for .json, Markdown otherwise, "-" for stderr
+	UsageLedger         string // Appends the session's resource usage to this file when it ends; empty to keep no ledger
+	UsageLabel          string // Recorded with the session's usage to attribute it, such as a team or project
 	JSONOutput          bool
 	Verbose             bool
+	Quiet               bool   // Suppress banners and informational messages; overrides Verbose
+	Summary             bool   // One line per command and a final table instead of result blocks
+	Deterministic       bool   // Pin timestamps, IDs, and durations and fake containers, so output is the same on every run
+	Color               string // Result block coloring: auto, always, or never
+	OutputBudget        int    // Estimated tokens of command output per turn; 0 for unlimited
+	TokenEstimator      string // How tokens are estimated: bytes, or bpe; empty for bytes
+	TokenAnnotations    bool   // Annotate opened files and search results with their estimated tokens
+	SkipRepeatOpens     bool   // Answer a repeated open of unchanged content with a short notice instead of the content
+	SummarizeOversize   bool   // Summarize output over the remaining budget with a local model, keeping the full output in a file
+	SummarizerModel     string // Ollama model that writes the summaries
+	SummarizerURL       string // Ollama server that writes the summaries
+	OutputArtifactDir   string // Where summarized output is kept in full without a /scratch; empty for the temporary directory
 	RequireConfirmation bool
 	BackupBeforeWrite   bool
+	BackupDir           string
+	BackupMaxCount      int
+	BackupMaxAge        time.Duration
+	CleanupOnStart      bool          // Removes what crashed sessions left behind before starting
+	CleanupAge          time.Duration // Leftover containers and temp directories older than this are removed
 	AllowedExtensions   []string
+	AllowedModes        []string // Modes <write path mode=...> may set, in octal, such as 0644 or 0755
+	AllowBinary         bool
+	BinaryHexBytes      int
 	ForceWrite          bool
+	ConflictCheck       bool
+	SyntaxCheck         string
+	LineEndings         string
+	TrailingNewline     string
+	BOM                 string
+	Formatters          map[string]FormatterConfig
+	WriteQuotas         map[string]WriteQuota // Per-directory limits on what a session writes, by repository-relative directory
 	ExecWhitelist       []string
+	ExecValidation      string // How exec commands are checked against the whitelist: strict or first-token
 	ExecTimeout         time.Duration
 	ExecMemoryLimit     string
 	ExecCPULimit        int
 	ExecContainerImage  string
 	ExecNetworkEnabled  bool
+	ExecCacheMounts     map[string]string      // Cache name (go, npm, pip) to "volume" or a host directory; empty for hermetic runs
+	ExecEnv             map[string]string      // Variables set in exec containers
+	ExecEnvPassthrough  []string               // Host variables copied into exec containers when set
+	FetchAllowedDomains []string               // Hosts <fetch> may request, such as api.example.com or *.example.com; empty disables it
+	FetchMaxSize        int64                  // Largest response body <fetch> accepts
+	FetchContentTypes   []string               // Response media types <fetch> accepts, such as application/json or text/*
+	FetchTimeout        time.Duration          // Timeout for <fetch> requests
+	SQLDatabases        map[string]SQLDatabase // Databases <sql> may query, by name; empty disables it
+	SQLMaxRows          int                    // Most rows an <sql> result shows
+	SQLMaxBytes         int64                  // Most bytes an <sql> result table may take
+	SQLTimeout          time.Duration          // Timeout for <sql> queries
+	GitWrite            bool                   // <git-branch> and <git-commit> may create branches and commits
+	GitProtected        []string               // Branches, or patterns such as release/*, never committed to or created
+	GitAuthor           string                 // Author and committer of <git-commit> commits, as "Name <email>"
+	GitCommitTemplate   string                 // text/template of commit messages, given .Message, .Branch, and .SessionID
+	PRRemote            string                 // Git remote <pr> and pr create push to and open requests on
+	PRBase              string                 // Branch requests merge into; empty for the remote's default branch
+	PRForge             string                 // github or gitlab; empty to tell from the remote's host
+	PRDraft             bool                   // Open requests as drafts
+	Issues              bool                   // <issue> may read issues of the PRRemote repository
+	IssueMaxBytes       int64                  // Most bytes an <issue> result may take
+	IssueComments       int                    // Most recent comments an <issue> result shows
+	REPLLanguages       []string               // Languages <repl> may run, such as python or go; empty disables it
+	REPLImages          map[string]string      // Interpreter image of each language
+	REPLTimeout         time.Duration          // Longest a <repl> snippet may run
+	REPLMemoryLimit     string
+	REPLCPULimit        int
+	TestRunners         map[string]string // Command <test> runs for each language: go, python, javascript
+	TestMaxFailures     int               // Failures a <test> result shows messages for
+	Linters             map[string]string // Command <lint> runs for each language: go, python, javascript
+	LintMaxIssues       int               // Issues a <lint> result lists
+	LanguageServers     map[string]string // Command starting the language server of each language <def> and <refs> ask, such as gopls for go
+	LSPTimeout          time.Duration     // Longest a <def> or <refs> may take, including the server loading the workspace
 	IOContainerImage    string
 	IOTimeout           time.Duration
 	IOMemoryLimit       string
 	IOCPULimit          int
-	ContainerPool PoolConfig
+	Retry               RetryPolicy
+	RetryPolicies       map[string]RetryPolicy // Per command type, overriding Retry
+	PluginsDir          string                 // Executables providing plugin commands; empty for none
+	PluginTimeout       time.Duration
+	PluginMemoryPages   uint32                            // Most 64 KiB pages of memory a WASM plugin may use
+	PluginConfig        map[string]map[string]interface{} // Config block of each plugin command
+	Hooks               map[string][]HookConfig           // Scripts and webhooks run around commands, by event such as pre_write or post_exec
+	Notifiers           []NotifierConfig                  // Slack, Discord, and webhook targets notable events are posted to
+	SensitivePaths      []string                          // Patterns of files whose writes are notified as sensitive_write
+	ContainerPool       PoolConfig
 }
 
 // FullConfig represents the complete configuration structure including search
 type fullConfig struct {
 	Repository struct {
-		Root          string   `yaml:"root"`
-		ExcludedPaths []string `yaml:"excluded_paths"`
+		Root               string   `yaml:"root"`
+		ExcludedPaths      []string `yaml:"excluded_paths"`
+		RespectIgnoreFiles bool     `yaml:"respect_ignore_files"`
 	} `yaml:"repository"`
 
 	Commands struct {
@@ -44,12 +146,34 @@ type fullConfig struct {
 			Enabled           bool     `yaml:"enabled"`
 			MaxFileSize       int64    `yaml:"max_file_size"`
 			AllowedExtensions []string `yaml:"allowed_extensions"`
+			AllowBinary       bool     `yaml:"allow_binary"`
+			BinaryHexBytes    int      `yaml:"binary_hex_bytes"`
+			MaxChunkedSize    int64    `yaml:"max_chunked_size"`
+			ChunkSize         int64    `yaml:"chunk_size"`
+			TruncateOversize  bool     `yaml:"truncate_oversize"`
+			TruncateKeep      int64    `yaml:"truncate_keep"`
 		} `yaml:"open"`
 
+		OpenMany struct {
+			MaxFiles int   `yaml:"max_files"`
+			MaxBytes int64 `yaml:"max_bytes"`
+		} `yaml:"open_many"`
+
 		Write struct {
-			Enabled           bool  `yaml:"enabled"`
-			MaxFileSize       int64 `yaml:"max_file_size"`
-			BackupBeforeWrite bool  `yaml:"backup_before_write"`
+			Enabled           bool                       `yaml:"enabled"`
+			MaxFileSize       int64                      `yaml:"max_file_size"`
+			BackupBeforeWrite bool                       `yaml:"backup_before_write"`
+			BackupDir         string                     `yaml:"backup_dir"`
+			BackupMaxCount    int                        `yaml:"backup_max_count"`
+			BackupMaxAge      time.Duration              `yaml:"backup_max_age"`
+			SyntaxCheck       string                     `yaml:"syntax_check"`
+			ConflictCheck     bool                       `yaml:"conflict_check"`
+			LineEndings       string                     `yaml:"line_endings"`
+			TrailingNewline   string                     `yaml:"trailing_newline"`
+			BOM               string                     `yaml:"bom"`
+			Formatters        map[string]FormatterConfig `yaml:"formatters"`
+			AllowedModes      []string                   `yaml:"allowed_modes"`
+			Quotas            map[string]WriteQuota      `yaml:"quotas"`
 		} `yaml:"write"`
 
 		Exec struct {
@@ -61,6 +185,63 @@ type fullConfig struct {
 			Whitelist      []string `yaml:"whitelist"`
 		} `yaml:"exec"`
 
+		Fetch struct {
+			AllowedDomains []string `yaml:"allowed_domains"`
+			MaxSize        int64    `yaml:"max_size"`
+			ContentTypes   []string `yaml:"content_types"`
+			Timeout        string   `yaml:"timeout"`
+		} `yaml:"fetch"`
+
+		SQL struct {
+			Databases map[string]SQLDatabase `yaml:"databases"`
+			MaxRows   int                    `yaml:"max_rows"`
+			MaxBytes  int64                  `yaml:"max_bytes"`
+			Timeout   string                 `yaml:"timeout"`
+		} `yaml:"sql"`
+
+		Git struct {
+			Enabled           bool     `yaml:"enabled"`
+			ProtectedBranches []string `yaml:"protected_branches"`
+			Author            string   `yaml:"author"`
+			CommitTemplate    string   `yaml:"commit_template"`
+		} `yaml:"git"`
+
+		PR struct {
+			Remote string `yaml:"remote"`
+			Base   string `yaml:"base"`
+			Forge  string `yaml:"forge"`
+			Draft  bool   `yaml:"draft"`
+		} `yaml:"pr"`
+
+		Issue struct {
+			Enabled  bool  `yaml:"enabled"`
+			MaxBytes int64 `yaml:"max_bytes"`
+			Comments int   `yaml:"comments"`
+		} `yaml:"issue"`
+
+		REPL struct {
+			Languages   []string          `yaml:"languages"`
+			Images      map[string]string `yaml:"images"`
+			Timeout     string            `yaml:"timeout"`
+			MemoryLimit string            `yaml:"memory_limit"`
+			CPULimit    int               `yaml:"cpu_limit"`
+		} `yaml:"repl"`
+
+		Test struct {
+			Runners     map[string]string `yaml:"runners"`
+			MaxFailures int               `yaml:"max_failures"`
+		} `yaml:"test"`
+
+		Lint struct {
+			Linters   map[string]string `yaml:"linters"`
+			MaxIssues int               `yaml:"max_issues"`
+		} `yaml:"lint"`
+
+		LSP struct {
+			Servers map[string]string `yaml:"servers"`
+			Timeout string            `yaml:"timeout"`
+		} `yaml:"lsp"`
+
 		Search struct {
 			Enabled            bool     `yaml:"enabled"`
 			VectorDBPath       string   `yaml:"vector_db_path"`
@@ -72,20 +253,33 @@ type fullConfig struct {
 			OllamaURL          string   `yaml:"ollama_url"`
 			IndexExtensions    []string `yaml:"index_extensions"`
 			MaxFileSize        int64    `yaml:"max_file_size"`
+			RespectIgnoreFiles bool     `yaml:"respect_ignore_files"`
 		} `yaml:"search"`
 	} `yaml:"commands"`
 
+	Retry struct {
+		MaxRetries int                    `yaml:"max_retries"`
+		Backoff    time.Duration          `yaml:"backoff"`
+		RetryOn    []string               `yaml:"retry_on"`
+		Commands   map[string]RetryPolicy `yaml:"commands"`
+	} `yaml:"retry"`
+
 	Security struct {
-		RateLimitPerMinute int    `yaml:"rate_limit_per_minute"`
-		LogAllOperations   bool   `yaml:"log_all_operations"`
-		AuditLogPath       string `yaml:"audit_log_path"`
+		RateLimitPerMinute int      `yaml:"rate_limit_per_minute"`
+		LogAllOperations   bool     `yaml:"log_all_operations"`
+		AuditLogPath       string   `yaml:"audit_log_path"`
+		AppendOnlyPaths    []string `yaml:"append_only_paths"`
 	} `yaml:"security"`
 
 	Output struct {
-		ShowSummaries        bool `yaml:"show_summaries"`
-		ShowExecutionTime    bool `yaml:"show_execution_time"`
-		TruncateLargeOutputs bool `yaml:"truncate_large_outputs"`
-		MaxOutputLines       int  `yaml:"max_output_lines"`
+		ShowSummaries        bool   `yaml:"show_summaries"`
+		ShowExecutionTime    bool   `yaml:"show_execution_time"`
+		TruncateLargeOutputs bool   `yaml:"truncate_large_outputs"`
+		MaxOutputLines       int    `yaml:"max_output_lines"`
+		SummarizeOversize    bool   `yaml:"summarize_oversize"`
+		SummarizerModel      string `yaml:"summarizer_model"`
+		SummarizerURL        string `yaml:"summarizer_url"`
+		ArtifactDir          string `yaml:"artifact_dir"`
 	} `yaml:"output"`
 
 	Logging struct {
@@ -93,6 +287,85 @@ type fullConfig struct {
 		File   string `yaml:"file"`
 		Format string `yaml:"format"`
 	} `yaml:"logging"`
+
+	// API keys of the agent command's providers, each a secret reference
+	// such as env:NAME, @PATH, or !COMMAND, or the key itself
+	Agent struct {
+		OpenAIAPIKey    string `yaml:"openai_api_key"`
+		AnthropicAPIKey string `yaml:"anthropic_api_key"`
+	} `yaml:"agent"`
+
+	Hooks map[string][]HookConfig `yaml:"hooks"`
+
+	Notifications struct {
+		SensitivePaths []string         `yaml:"sensitive_paths"`
+		Targets        []NotifierConfig `yaml:"targets"`
+	} `yaml:"notifications"`
+
+	// Usage ledger
+	Usage struct {
+		Enabled bool   `yaml:"enabled"`
+		Ledger  string `yaml:"ledger"`
+		Label   string `yaml:"label"`
+	} `yaml:"usage"`
+
+	ContainerPool PoolConfig `yaml:"container_pool"`
+}
+
+// HookConfig is a script or webhook run before or after commands, given
+// the command as JSON. A pre hook that fails refuses the command.
+type HookConfig struct {
+	Command string        `yaml:"command" mapstructure:"command"` // Run by the host's shell in the repository root, the JSON on stdin
+	URL     string        `yaml:"url" mapstructure:"url"`         // Sent the JSON in a POST instead
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"` // Defaults to DefaultHookTimeout
+}
+
+// NotifierConfig is a Slack or Discord channel or a webhook that notable
+// events, such as a denied command or the end of the session, are posted to
+type NotifierConfig struct {
+	Type      string   `yaml:"type" mapstructure:"type"`             // slack, discord, or webhook
+	URL       string   `yaml:"url" mapstructure:"url"`               // Incoming webhook URL, or a secret reference such as env:NAME
+	Events    []string `yaml:"events" mapstructure:"events"`         // Events posted; every event when empty
+	Template  string   `yaml:"template" mapstructure:"template"`     // text/template of the message; a default for each event when empty
+	RateLimit int      `yaml:"rate_limit" mapstructure:"rate_limit"` // Most messages a minute; DefaultNotifyRateLimit when 0
+}
+
+// FormatterConfig describes the formatter for an extension: a built-in
+// one by name, or an external command run inside the exec container.
+// Content is passed on stdin and the formatted result is read from stdout;
+// {file} in Command expands to the file's container path.
+type FormatterConfig struct {
+	Builtin string `yaml:"builtin" mapstructure:"builtin"` // One of the Formatter* names; excludes Command
+	Command string `yaml:"command" mapstructure:"command"`
+	Image   string `yaml:"image" mapstructure:"image"` // Defaults to the exec container image
+}
+
+// WriteQuota caps what a session may write under a directory, counting
+// <write> and <append> in it and its subdirectories. Zero leaves a limit
+// off.
+type WriteQuota struct {
+	MaxFiles int   `yaml:"max_files" mapstructure:"max_files"` // Files the session may create
+	MaxBytes int64 `yaml:"max_bytes" mapstructure:"max_bytes"` // Total size of the files the session writes
+}
+
+// SQLDatabase is a database <sql name=NAME ...> can query. DSN should name
+// a read-only account: queries also run in a read-only transaction that is
+// always rolled back, but not every driver enforces that. ${VAR} in DSN is
+// expanded from the environment, or the whole DSN may be a secret
+// reference such as !COMMAND, so passwords can stay out of the file.
+type SQLDatabase struct {
+	Driver            string   `yaml:"driver" mapstructure:"driver"` // postgres, mysql, or sqlite3
+	DSN               string   `yaml:"dsn" mapstructure:"dsn"`
+	AllowedStatements []string `yaml:"allowed_statements" mapstructure:"allowed_statements"` // Statement types such as select or explain; defaults to select
+}
+
+// RetryPolicy controls how a failed command is retried. A command is
+// retried only if its error code is listed in RetryOn; the delay starts at
+// Backoff and doubles on each retry.
+type RetryPolicy struct {
+	MaxRetries int           `yaml:"max_retries" mapstructure:"max_retries"`
+	Backoff    time.Duration `yaml:"backoff" mapstructure:"backoff"`
+	RetryOn    []string      `yaml:"retry_on" mapstructure:"retry_on"` // Error codes such as EXEC_TIMEOUT
 }
 
 // PoolConfig holds container pool configuration
@@ -0,0 +1,167 @@
+// Package diagnostics serves runtime profiling endpoints and collects
+// diagnostics bundles for investigating long-running sessions, such as
+// memory leaks in agent mode.
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+// Handler serves the net/http/pprof endpoints under /debug/pprof/ and the
+// effective configuration at /debug/config
+func Handler(cfg *config.Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		writeConfig(w, cfg)
+	})
+	return mux
+}
+
+// Serve listens on addr, such as "localhost:6060", and answers the pprof
+// and config endpoints from a goroutine until the returned server is
+// closed. Errors after it starts are printed as warnings.
+func Serve(addr string, cfg *config.Config) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serve pprof: %w", err)
+	}
+
+	server := &http.Server{Handler: Handler(cfg), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Warning: pprof server stopped: %v\n", err)
+		}
+	}()
+	return server, nil
+}
+
+// Bundle is the content of a diagnostics bundle
+type Bundle struct {
+	Goroutines []byte // Stacks of all goroutines, as text
+	Heap       []byte // Heap profile, in pprof format
+	Config     []byte // Effective configuration, as JSON
+	Info       []byte // Where and when the bundle was collected
+}
+
+// Collect captures a bundle from the current process
+func Collect(cfg *config.Config) (*Bundle, error) {
+	var goroutines, heap, conf bytes.Buffer
+	if err := runtimepprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return nil, fmt.Errorf("cannot capture goroutines: %w", err)
+	}
+	// Collect garbage first so the profile shows live memory only
+	runtime.GC()
+	if err := runtimepprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return nil, fmt.Errorf("cannot capture heap profile: %w", err)
+	}
+	writeConfig(&conf, cfg)
+
+	return &Bundle{
+		Goroutines: goroutines.Bytes(),
+		Heap:       heap.Bytes(),
+		Config:     conf.Bytes(),
+		Info:       info(fmt.Sprintf("process %d", os.Getpid())),
+	}, nil
+}
+
+// Fetch captures a bundle from a running llm-runtime serving --pprof on
+// addr, such as "localhost:6060"
+func Fetch(client *http.Client, addr string) (*Bundle, error) {
+	base := addr
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	get := func(path string) ([]byte, error) {
+		resp, err := client.Get(base + path)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s", path, resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	}
+
+	bundle := &Bundle{Info: info(base)}
+	var err error
+	if bundle.Goroutines, err = get("/debug/pprof/goroutine?debug=2"); err != nil {
+		return nil, fmt.Errorf("cannot fetch goroutines: %w", err)
+	}
+	if bundle.Heap, err = get("/debug/pprof/heap?gc=1"); err != nil {
+		return nil, fmt.Errorf("cannot fetch heap profile: %w", err)
+	}
+	if bundle.Config, err = get("/debug/config"); err != nil {
+		return nil, fmt.Errorf("cannot fetch config: %w", err)
+	}
+	return bundle, nil
+}
+
+// Write writes the bundle as a gzipped tar archive
+func (b *Bundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"info.txt", b.Info},
+		{"goroutines.txt", b.Goroutines},
+		{"heap.pprof", b.Heap},
+		{"config.json", b.Config},
+	}
+	for _, f := range files {
+		header := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeConfig writes cfg as indented JSON
+func writeConfig(w io.Writer, cfg *config.Config) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(cfg)
+}
+
+// info describes where and when a bundle was collected
+func info(source string) []byte {
+	host, _ := os.Hostname()
+	return []byte(fmt.Sprintf("source: %s\nhost: %s\ncollected: %s\ncollector: %s %s/%s\n",
+		source, host, time.Now().Format(time.RFC3339), runtime.Version(), runtime.GOOS, runtime.GOARCH))
+}
@@ -0,0 +1,92 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+// readBundle returns the files of a written bundle by name
+func readBundle(t *testing.T, bundle *Bundle) map[string]string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("bundle is not gzipped: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("bundle is not a tar archive: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[header.Name] = string(data)
+	}
+	return files
+}
+
+func TestCollect(t *testing.T) {
+	bundle, err := Collect(&config.Config{RepositoryRoot: "/repo", ExecTimeout: 30})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	files := readBundle(t, bundle)
+	if !strings.Contains(files["goroutines.txt"], "goroutine ") {
+		t.Errorf("goroutines.txt has no stacks:\n%s", files["goroutines.txt"])
+	}
+	if len(files["heap.pprof"]) == 0 {
+		t.Error("heap.pprof is empty")
+	}
+	if !strings.Contains(files["config.json"], `"RepositoryRoot": "/repo"`) {
+		t.Errorf("config.json missing the repository:\n%s", files["config.json"])
+	}
+	if !strings.Contains(files["info.txt"], "source: process ") {
+		t.Errorf("unexpected info.txt:\n%s", files["info.txt"])
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(Handler(&config.Config{RepositoryRoot: "/served"}))
+	defer server.Close()
+
+	bundle, err := Fetch(server.Client(), strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	files := readBundle(t, bundle)
+	if !strings.Contains(files["goroutines.txt"], "goroutine ") {
+		t.Errorf("goroutines.txt has no stacks:\n%s", files["goroutines.txt"])
+	}
+	if len(files["heap.pprof"]) == 0 {
+		t.Error("heap.pprof is empty")
+	}
+	if !strings.Contains(files["config.json"], `"RepositoryRoot": "/served"`) {
+		t.Errorf("config.json is not the served config:\n%s", files["config.json"])
+	}
+}
+
+func TestFetch_NotServing(t *testing.T) {
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	if _, err := Fetch(server.Client(), server.URL); err == nil {
+		t.Error("expected an error from a server without pprof endpoints")
+	}
+}
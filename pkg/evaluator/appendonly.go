@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// ExecuteAppend handles the "append" command
+func ExecuteAppend(filePath, content string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeAppend(context.Background(), filePath, content, cfg, auditLog, pool)
+}
+
+// executeAppend is ExecuteAppend as part of the trace in ctx. The content
+// is added as new lines at the end of the file, which is created if it does
+// not exist; the result is written as a write would be, with its checks,
+// formatting, and backup, so an append-only file accepts it.
+func executeAppend(ctx context.Context, filePath, content string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	combined := content
+	existed := false
+	if safePath, err := sandbox.ValidateHostPath(filePath, cfg.RepositoryRoot, cfg.ExcludedPaths); err == nil {
+		if data, err := os.ReadFile(safePath); err == nil {
+			existed = true
+			combined = appendLines(string(data), content)
+		}
+	}
+
+	var appendAudit func(cmd, arg string, success bool, errMsg string)
+	if auditLog != nil {
+		appendAudit = func(_, arg string, success bool, errMsg string) {
+			auditLog("append", arg, success, errMsg)
+		}
+	}
+	result := executeWrite(ctx, filePath, combined, "", cfg, appendAudit, pool)
+	result.Command = scanner.Command{Type: "append", Argument: filePath, Content: content}
+	if result.Success && existed && result.Action == "UPDATED" {
+		result.Action = "APPENDED"
+	}
+	return result
+}
+
+// appendLines adds addition to existing as lines of their own
+func appendLines(existing, addition string) string {
+	if addition == "" {
+		return existing
+	}
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+	return existing + addition + "\n"
+}
+
+// appendOnlyPattern returns the pattern of cfg.AppendOnlyPaths that
+// safePath matches, if any
+func appendOnlyPattern(safePath string, cfg *config.Config) (string, bool) {
+	if len(cfg.AppendOnlyPaths) == 0 {
+		return "", false
+	}
+	rel, err := filepath.Rel(cfg.RepositoryRoot, safePath)
+	if err != nil {
+		return "", false
+	}
+	pattern, _, matched := ignore.CompilePatterns(cfg.AppendOnlyPaths).Match(rel, false)
+	return pattern, matched
+}
+
+// checkAppendOnly rejects replacing current, the content of the file at
+// safePath, with content if the file is append-only and content does not
+// keep every line it has, in order. Lines may be added anywhere, so a new
+// entry can go at the top of a changelog.
+func checkAppendOnly(safePath string, current []byte, content string, cfg *config.Config) error {
+	pattern, ok := appendOnlyPattern(safePath, cfg)
+	if !ok {
+		return nil
+	}
+	if line := firstRemovedLine(string(current), content); line > 0 {
+		rel, _ := filepath.Rel(cfg.RepositoryRoot, safePath)
+		return errors.Newf(errors.AppendOnly, "%s is append-only (%s), and line %d would be removed or changed; add lines with <append> or a write that keeps every existing line",
+			filepath.ToSlash(rel), pattern, line)
+	}
+	return nil
+}
+
+// firstRemovedLine returns the number of the first line of before that
+// after does not keep, with the lines kept in order, or 0 if after keeps
+// them all
+func firstRemovedLine(before, after string) int {
+	old := contentLines(before)
+	i := 0
+	for _, line := range contentLines(after) {
+		if i < len(old) && line == old[i] {
+			i++
+		}
+	}
+	if i < len(old) {
+		return i + 1
+	}
+	return 0
+}
+
+// contentLines splits content into lines without their endings
+func contentLines(content string) []string {
+	content = strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+func TestFirstRemovedLine(t *testing.T) {
+	before := "# Changelog\n\n## 1.0.0\n- First release\n"
+	tests := []struct {
+		name  string
+		after string
+		want  int
+	}{
+		{"appended", before + "## 1.1.0\n- More\n", 0},
+		{"prepended", "## 1.1.0\n- More\n" + before, 0},
+		{"inserted", "# Changelog\n\n## 1.1.0\n- More\n\n## 1.0.0\n- First release\n", 0},
+		{"line endings", strings.ReplaceAll(before, "\n", "\r\n"), 0},
+		{"changed", "# Changelog\n\n## 1.0.0\n- First release, fixed\n", 4},
+		{"removed", "# Changelog\n\n- First release\n", 3},
+		{"emptied", "", 1},
+	}
+	for _, tt := range tests {
+		if got := firstRemovedLine(before, tt.after); got != tt.want {
+			t.Errorf("%s: firstRemovedLine = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAppendLines(t *testing.T) {
+	for _, tt := range []struct{ existing, addition, want string }{
+		{"a\n", "b", "a\nb\n"},
+		{"a", "b", "a\nb\n"},
+		{"", "b", "b\n"},
+		{"a\n", "", "a\n"},
+	} {
+		if got := appendLines(tt.existing, tt.addition); got != tt.want {
+			t.Errorf("appendLines(%q, %q) = %q, want %q", tt.existing, tt.addition, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteWrite_AppendOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.AllowedExtensions = append(cfg.AllowedExtensions, ".sql")
+	cfg.AppendOnlyPaths = []string{"CHANGELOG.md", "migrations/**"}
+
+	os.MkdirAll(filepath.Join(tmpDir, "migrations"), 0755)
+	files := map[string]string{
+		"CHANGELOG.md":       "# Changelog\n\n- First release\n",
+		"migrations/001.sql": "CREATE TABLE users (id INT);\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, name)), 0755)
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit := &testAuditLog{}
+	for path, content := range map[string]string{
+		"CHANGELOG.md":       "# Changelog\n",
+		"migrations/001.sql": "CREATE TABLE users (id BIGINT);\n",
+	} {
+		result := ExecuteWrite(path, content, cfg, audit.log, nil)
+		if result.Success || result.Error == nil || !strings.HasPrefix(result.Error.Error(), "APPEND_ONLY") {
+			t.Errorf("overwrite of %s: success=%v err=%v, want APPEND_ONLY", path, result.Success, result.Error)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md")); string(content) != files["CHANGELOG.md"] {
+		t.Errorf("CHANGELOG.md changed to %q", content)
+	}
+	entries := audit.entries
+	if len(entries) != 2 || entries[0].success || !strings.Contains(entries[0].errMsg, "append-only") {
+		t.Errorf("audit entries = %+v", entries)
+	}
+
+	// Binary writes are held to the same rule
+	result := ExecuteEncodedWrite("CHANGELOG.md", "base64", "eA==", cfg, nil, nil)
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "APPEND_ONLY") {
+		t.Errorf("encoded overwrite: success=%v err=%v, want APPEND_ONLY", result.Success, result.Error)
+	}
+}
+
+func TestExecuteAppend(t *testing.T) {
+	if sandbox.CheckDockerAvailability() != nil {
+		t.Skip("Docker not available")
+	}
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.BackupBeforeWrite = false
+	cfg.AppendOnlyPaths = []string{"CHANGELOG.md"}
+	changelog := filepath.Join(tmpDir, "CHANGELOG.md")
+	if err := os.WriteFile(changelog, []byte("# Changelog\n- First release"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := &testAuditLog{}
+	result := ExecuteAppend("CHANGELOG.md", "- Second release", cfg, audit.log, nil)
+	if !result.Success || result.Action != "APPENDED" || result.Command.Type != "append" {
+		t.Fatalf("append: success=%v action=%q type=%q err=%v", result.Success, result.Action, result.Command.Type, result.Error)
+	}
+	if content, _ := os.ReadFile(changelog); string(content) != "# Changelog\n- First release\n- Second release\n" {
+		t.Errorf("content = %q", content)
+	}
+	if entries := audit.entries; len(entries) != 1 || entries[0].cmdType != "append" {
+		t.Errorf("audit entries = %+v", entries)
+	}
+
+	result = ExecuteAppend("NOTES.md", "first note", cfg, nil, nil)
+	if !result.Success || result.Action != "CREATED" {
+		t.Errorf("append to new file: success=%v action=%q err=%v", result.Success, result.Action, result.Error)
+	}
+}
@@ -0,0 +1,605 @@
+package evaluator
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Archives are read and written by llm-runtime itself rather than in a
+// container, so every entry can be checked before anything is written.
+// Since that happens on the host, paths are also checked after resolving
+// symlinks, which the container would otherwise contain.
+
+// Archive formats, by file name
+const (
+	formatZip   = "zip"
+	formatTar   = "tar"
+	formatTarGz = "tar.gz"
+)
+
+// archiveFormat returns the format of an archive from its name
+func archiveFormat(name string) (string, bool) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip, true
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz, true
+	case strings.HasSuffix(lower, ".tar"):
+		return formatTar, true
+	}
+	return "", false
+}
+
+// archiveEntry is a file or directory in an archive
+type archiveEntry struct {
+	Name string // Slash-separated path inside the archive
+	Dir  bool
+	Size int64
+	Mode fs.FileMode
+}
+
+// walkArchive calls fn for each entry of the archive at path, with a
+// reader for its content. Entries other than files and directories, such
+// as symlinks and devices, are rejected, since extracting them could
+// point outside the destination.
+func walkArchive(path, format string, fn func(entry archiveEntry, r io.Reader) error) error {
+	if format == formatZip {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return errors.Wrap(errors.ArchiveError, err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			mode := f.Mode()
+			if !mode.IsRegular() && !mode.IsDir() {
+				return errors.Newf(errors.PathSecurity, "archive entry %s is not a regular file or directory", f.Name)
+			}
+			entry := archiveEntry{Name: f.Name, Dir: mode.IsDir(), Size: int64(f.UncompressedSize64), Mode: mode}
+			if err := walkZipEntry(f, entry, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(errors.ArchiveError, err)
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if format == formatTarGz {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return errors.Wrap(errors.ArchiveError, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(errors.ArchiveError, err)
+		}
+		entry := archiveEntry{Name: hdr.Name, Size: hdr.Size, Mode: hdr.FileInfo().Mode()}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+		case tar.TypeDir:
+			entry.Dir = true
+		default:
+			return errors.Newf(errors.PathSecurity, "archive entry %s is not a regular file or directory", hdr.Name)
+		}
+		if err := fn(entry, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func walkZipEntry(f *zip.File, entry archiveEntry, fn func(entry archiveEntry, r io.Reader) error) error {
+	if entry.Dir {
+		return fn(entry, strings.NewReader(""))
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return errors.Wrap(errors.ArchiveError, err)
+	}
+	defer rc.Close()
+	return fn(entry, rc)
+}
+
+// validatedPath is sandbox.ValidateHostPath with the configured root and
+// exclusions
+func validatedPath(path string, cfg *config.Config) (string, error) {
+	safePath, err := sandbox.ValidateHostPath(path, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return "", errors.Wrap(errors.PathSecurity, err)
+	}
+	return safePath, nil
+}
+
+// archiveLimits returns the configured entry and size limits, or their
+// defaults if unset
+func archiveLimits(cfg *config.Config) (int, int64) {
+	entries, size := cfg.MaxArchiveEntries, cfg.MaxArchiveSize
+	if entries <= 0 {
+		entries = config.DefaultMaxArchiveEntries
+	}
+	if size <= 0 {
+		size = config.DefaultMaxArchiveSize
+	}
+	return entries, size
+}
+
+// ExecuteUnzip handles the "unzip" command
+func ExecuteUnzip(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeUnzip(context.Background(), arg, cfg, auditLog, nil)
+}
+
+// executeUnzip extracts an archive into a directory, by default the one
+// holding the archive. Every entry is checked first, and nothing is written
+// if any would land outside the destination, is not a regular file or
+// directory, has an extension writes do not allow, would replace an
+// append-only file, or takes the archive over its entry or size limit.
+// With guards, nothing is written either if a file it would replace
+// changed on disk since it was last opened or the files would go over the
+// write quotas as a batch, and the files are tracked and counted once
+// written.
+func executeUnzip(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), guards *writeGuards) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "unzip", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("unzip", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	fields := strings.Fields(arg)
+	if len(fields) < 1 || len(fields) > 2 {
+		return fail(errors.New(errors.ParseError, "want <unzip ARCHIVE> or <unzip ARCHIVE DEST>"))
+	}
+	archivePath := fields[0]
+	dest := filepath.Dir(archivePath)
+	if len(fields) == 2 {
+		dest = fields[1]
+	}
+	format, ok := archiveFormat(archivePath)
+	if !ok {
+		return fail(errors.Newf(errors.ArchiveError, "unsupported archive format: %s (want .zip, .tar, .tar.gz, or .tgz)", archivePath))
+	}
+
+	safeArchive, err := validatedPath(archivePath, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	if cfg.RespectIgnoreFiles {
+		if err := sandbox.CheckIgnored(safeArchive, cfg.RepositoryRoot); err != nil {
+			return fail(errors.Wrap(errors.PathSecurity, err))
+		}
+	}
+	if _, err := os.Stat(safeArchive); err != nil {
+		if os.IsNotExist(err) {
+			return fail(errors.New(errors.FileNotFound, archivePath))
+		}
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+	safeDest, err := validatedPath(dest, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	relDest, _ := filepath.Rel(cfg.RepositoryRoot, safeDest)
+
+	// Check every entry before writing anything
+	maxEntries, maxSize := archiveLimits(cfg)
+	count, total := 0, int64(0)
+	targets := make(map[string]string)
+	var planned []QuotaWrite
+	err = walkArchive(safeArchive, format, func(entry archiveEntry, _ io.Reader) error {
+		count++
+		if count > maxEntries {
+			return errors.Newf(errors.ResourceLimit, "archive has more than %d entries", maxEntries)
+		}
+		name := filepath.FromSlash(entry.Name)
+		if !filepath.IsLocal(name) {
+			return errors.Newf(errors.PathSecurity, "archive entry escapes the destination: %s", entry.Name)
+		}
+		target, err := validatedPath(filepath.Join(relDest, name), cfg)
+		if err != nil {
+			return err
+		}
+		if entry.Dir {
+			return nil
+		}
+		if err := sandbox.ValidateWriteExtension(name, cfg.AllowedExtensions); err != nil {
+			return errors.Wrapf(errors.ExtensionDenied, err, "archive entry %s", entry.Name)
+		}
+		total += entry.Size
+		if total > maxSize {
+			return errors.Newf(errors.ResourceLimit, "archive expands to more than %d bytes", maxSize)
+		}
+		targets[entry.Name] = target
+		_, statErr := os.Lstat(target)
+		if pattern, ok := appendOnlyPattern(target, cfg); ok && statErr == nil {
+			return errors.Newf(errors.AppendOnly, "archive entry %s would replace %s, which is append-only (%s)", entry.Name, repoRelativePath(target, cfg.RepositoryRoot), pattern)
+		}
+		if diff, err := guards.checkConflict(target, cfg); err != nil {
+			result.Result = diff
+			return err
+		}
+		planned = append(planned, QuotaWrite{Path: repoRelativePath(target, cfg.RepositoryRoot), Size: entry.Size, Exists: statErr == nil})
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+	if err := guards.checkQuotas(planned, cfg); err != nil {
+		return fail(err)
+	}
+
+	var written []string
+	var bytesWritten int64
+	err = walkArchive(safeArchive, format, func(entry archiveEntry, r io.Reader) error {
+		if entry.Dir {
+			return nil
+		}
+		target := targets[entry.Name]
+		_, statErr := os.Lstat(target)
+		n, err := extractFile(target, entry, io.LimitReader(r, maxSize-bytesWritten+1), cfg)
+		bytesWritten += n
+		if err != nil {
+			return err
+		}
+		guards.wrote(target, statErr != nil, cfg)
+		if bytesWritten > maxSize {
+			return errors.Newf(errors.ResourceLimit, "archive expands to more than %d bytes", maxSize)
+		}
+		rel, _ := filepath.Rel(cfg.RepositoryRoot, target)
+		written = append(written, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return fail(errors.Wrapf(errors.ArchiveError, err, "after extracting %d files", len(written)))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Extracted %d files (%d bytes) from %s to %s\n", len(written), bytesWritten, archivePath, dest)
+	for _, path := range written {
+		fmt.Fprintf(&b, "  %s\n", path)
+	}
+	result.Success = true
+	result.Result = b.String()
+	result.BytesWritten = bytesWritten
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("unzip", arg, true, fmt.Sprintf("files:%d,bytes:%d", len(written), bytesWritten))
+	}
+	return result
+}
+
+// extractFile writes one archive entry to target through a temporary file,
+// backing up a file it replaces as writes do
+func extractFile(target string, entry archiveEntry, r io.Reader, cfg *config.Config) (int64, error) {
+	if info, err := os.Lstat(target); err == nil {
+		if !info.Mode().IsRegular() {
+			return 0, errors.Newf(errors.PathSecurity, "%s exists and is not a regular file", entry.Name)
+		}
+		if cfg.BackupBeforeWrite {
+			if _, err := NewBackupManager(cfg).Create(target); err != nil {
+				return 0, errors.Wrap(errors.BackupFailed, err)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".unzip-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+	perm := fs.FileMode(0644)
+	if entry.Mode&0111 != 0 {
+		perm = 0755
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), target)
+}
+
+// ExecuteArchive handles the "archive" command
+func ExecuteArchive(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeArchive(context.Background(), arg, cfg, auditLog, nil)
+}
+
+// executeArchive packs a file or directory into an archive whose format
+// follows its name. Excluded and ignored files and symlinks are left out,
+// as they cannot be opened either. The archive is written as <write>
+// writes a file: its extension must be allowed, it may not replace an
+// append-only file, and with guards it is refused if it changed on disk
+// since it was last opened or would go over a write quota.
+func executeArchive(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), guards *writeGuards) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "archive", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("archive", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	fields := strings.Fields(arg)
+	if len(fields) != 2 {
+		return fail(errors.New(errors.ParseError, "want <archive DEST SOURCE>"))
+	}
+	dest, src := fields[0], fields[1]
+	format, ok := archiveFormat(dest)
+	if !ok {
+		return fail(errors.Newf(errors.ArchiveError, "unsupported archive format: %s (want .zip, .tar, .tar.gz, or .tgz)", dest))
+	}
+
+	safeDest, err := validatedPath(dest, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	if err := sandbox.ValidateWriteExtension(safeDest, cfg.AllowedExtensions); err != nil {
+		return fail(errors.Wrap(errors.ExtensionDenied, err))
+	}
+	_, statErr := os.Lstat(safeDest)
+	destExists := statErr == nil
+	if pattern, ok := appendOnlyPattern(safeDest, cfg); ok && destExists {
+		return fail(errors.Newf(errors.AppendOnly, "%s is append-only (%s), and an archive would replace it", dest, pattern))
+	}
+	if diff, err := guards.checkConflict(safeDest, cfg); err != nil {
+		result.Result = diff
+		return fail(err)
+	}
+	safeSrc, err := validatedPath(src, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	if cfg.RespectIgnoreFiles {
+		if err := sandbox.CheckIgnored(safeSrc, cfg.RepositoryRoot); err != nil {
+			return fail(errors.Wrap(errors.PathSecurity, err))
+		}
+	}
+	srcInfo, err := os.Stat(safeSrc)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fail(errors.New(errors.FileNotFound, src))
+		}
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+
+	// Collect the files first, so limits are checked before writing
+	maxEntries, maxSize := archiveLimits(cfg)
+	base := filepath.Dir(safeSrc)
+	var files []string
+	var total int64
+	err = filepath.WalkDir(safeSrc, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == safeDest || !d.Type().IsRegular() && !d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(cfg.RepositoryRoot, path)
+		if path != safeSrc {
+			if _, err := sandbox.ValidatePath(rel, cfg.RepositoryRoot, cfg.ExcludedPaths); err != nil {
+				return skipEntry(d)
+			}
+			if cfg.RespectIgnoreFiles && sandbox.CheckIgnored(path, cfg.RepositoryRoot) != nil {
+				return skipEntry(d)
+			}
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, path)
+		total += info.Size()
+		if len(files) > maxEntries {
+			return errors.Newf(errors.ResourceLimit, "%s has more than %d files", src, maxEntries)
+		}
+		if total > maxSize {
+			return errors.Newf(errors.ResourceLimit, "%s holds more than %d bytes", src, maxSize)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.CodeOf(err) == "" {
+			err = errors.Wrap(errors.PermissionDenied, err)
+		}
+		return fail(err)
+	}
+	if !srcInfo.IsDir() && len(files) == 0 {
+		return fail(errors.Newf(errors.PathSecurity, "%s is not a regular file", src))
+	}
+
+	if destExists && cfg.BackupBeforeWrite {
+		if _, err := NewBackupManager(cfg).Create(safeDest); err != nil {
+			return fail(errors.Wrap(errors.BackupFailed, err))
+		}
+	}
+	// The archive's size is only known once it is packed
+	checkQuota := func(size int64) error {
+		return guards.checkQuotas([]QuotaWrite{{Path: repoRelativePath(safeDest, cfg.RepositoryRoot), Size: size, Exists: destExists}}, cfg)
+	}
+	size, err := writeArchive(safeDest, format, base, files, checkQuota)
+	if err != nil {
+		if errors.CodeOf(err) == "" {
+			err = errors.Wrap(errors.ArchiveError, err)
+		}
+		return fail(err)
+	}
+	guards.wrote(safeDest, !destExists, cfg)
+
+	result.Success = true
+	result.Result = fmt.Sprintf("Archived %d files (%d bytes) from %s into %s (%d bytes)\n", len(files), total, src, dest, size)
+	result.BytesWritten = size
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("archive", arg, true, fmt.Sprintf("files:%d,bytes:%d", len(files), size))
+	}
+	return result
+}
+
+// skipEntry leaves a file, or a whole directory, out of a walk
+func skipEntry(d fs.DirEntry) error {
+	if d.IsDir() {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// writeArchive writes files, named relative to base, to an archive at dest
+// through a temporary file and returns its size. The temporary file only
+// replaces dest if check accepts its size.
+func writeArchive(dest, format, base string, files []string, check func(size int64) error) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".archive-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = packFiles(tmp, format, base, files)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	if err := check(info.Size()); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp.Name(), dest)
+}
+
+// packFiles writes files, named relative to base, to w in format
+func packFiles(w io.Writer, format, base string, files []string) error {
+	if format == formatZip {
+		zw := zip.NewWriter(w)
+		for _, path := range files {
+			if err := addZipFile(zw, base, path); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	}
+
+	var gz *gzip.Writer
+	if format == formatTarGz {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	for _, path := range files {
+		if err := addTarFile(tw, base, path); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+func addZipFile(zw *zip.Writer, base, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	rel, _ := filepath.Rel(base, path)
+	hdr.Name = filepath.ToSlash(rel)
+	hdr.Method = zip.Deflate
+	fw, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	return copyFile(fw, path)
+}
+
+func addTarFile(tw *tar.Writer, base, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	rel, _ := filepath.Rel(base, path)
+	hdr.Name = filepath.ToSlash(rel)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	return copyFile(tw, path)
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,298 @@
+package evaluator
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// writeZip creates a zip archive at path holding files, by name
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newArchiveTestConfig(t *testing.T) *config.Config {
+	cfg := newTestConfig(t.TempDir())
+	cfg.BackupBeforeWrite = false
+	// <archive> writes its destination as <write> would
+	cfg.AllowedExtensions = append(cfg.AllowedExtensions, ".zip", ".tar", ".gz", ".tgz")
+	return cfg
+}
+
+func TestArchiveFormat(t *testing.T) {
+	tests := map[string]string{
+		"a.zip": formatZip, "a.TGZ": formatTarGz, "a.tar.gz": formatTarGz, "a.tar": formatTar, "a.rar": "",
+	}
+	for name, want := range tests {
+		if got, _ := archiveFormat(name); got != want {
+			t.Errorf("archiveFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExecuteUnzip(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	writeZip(t, filepath.Join(cfg.RepositoryRoot, "fixtures.zip"), map[string]string{
+		"data/a.json": `{"a": 1}`,
+		"b.txt":       "hello",
+	})
+
+	result := ExecuteUnzip("fixtures.zip testdata", cfg, nil)
+	if !result.Success {
+		t.Fatalf("ExecuteUnzip() failed: %v", result.Error)
+	}
+	got, err := os.ReadFile(filepath.Join(cfg.RepositoryRoot, "testdata", "data", "a.json"))
+	if err != nil || string(got) != `{"a": 1}` {
+		t.Errorf("testdata/data/a.json = %q, %v", got, err)
+	}
+	if !strings.Contains(result.Result, "Extracted 2 files (13 bytes)") || !strings.Contains(result.Result, "  testdata/b.txt\n") {
+		t.Errorf("Result = %q", result.Result)
+	}
+	if result.BytesWritten != 13 {
+		t.Errorf("BytesWritten = %d, want 13", result.BytesWritten)
+	}
+}
+
+func TestExecuteUnzip_Rejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		setup func(cfg *config.Config)
+		code  string
+	}{
+		{"zip slip", map[string]string{"ok.txt": "x", "../../evil.txt": "x"}, nil, "PATH_SECURITY"},
+		{"absolute", map[string]string{"/etc/evil.txt": "x"}, nil, "PATH_SECURITY"},
+		{"excluded", map[string]string{".env": "SECRET=1"}, nil, "PATH_SECURITY"},
+		{"extension", map[string]string{"ok.txt": "x", "run.sh": "rm -rf /"}, nil, "EXTENSION_DENIED"},
+		{"entries", map[string]string{"a.txt": "x", "b.txt": "x", "c.txt": "x"}, func(cfg *config.Config) { cfg.MaxArchiveEntries = 2 }, "RESOURCE_LIMIT"},
+		{"size", map[string]string{"a.txt": strings.Repeat("x", 100)}, func(cfg *config.Config) { cfg.MaxArchiveSize = 50 }, "RESOURCE_LIMIT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newArchiveTestConfig(t)
+			if tt.setup != nil {
+				tt.setup(cfg)
+			}
+			writeZip(t, filepath.Join(cfg.RepositoryRoot, "bad.zip"), tt.files)
+
+			result := ExecuteUnzip("bad.zip out", cfg, nil)
+			if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), tt.code) {
+				t.Fatalf("result = %+v, want %s", result, tt.code)
+			}
+			if _, err := os.Stat(filepath.Join(cfg.RepositoryRoot, "out")); !os.IsNotExist(err) {
+				t.Error("files were written for a rejected archive")
+			}
+		})
+	}
+}
+
+func TestExecuteUnzip_AppendOnly(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	cfg.AppendOnlyPaths = []string{"CHANGELOG.md"}
+	changelog := filepath.Join(cfg.RepositoryRoot, "CHANGELOG.md")
+	os.WriteFile(changelog, []byte("## v1\n"), 0644)
+	writeZip(t, filepath.Join(cfg.RepositoryRoot, "release.zip"), map[string]string{"notes.txt": "x", "CHANGELOG.md": "## v2\n"})
+
+	result := ExecuteUnzip("release.zip", cfg, nil)
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "APPEND_ONLY") {
+		t.Fatalf("result = %+v, want APPEND_ONLY", result)
+	}
+	if data, _ := os.ReadFile(changelog); string(data) != "## v1\n" {
+		t.Errorf("CHANGELOG.md = %q, want it untouched", data)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.RepositoryRoot, "notes.txt")); err == nil {
+		t.Error("files were written for a rejected archive")
+	}
+
+	// A new file matching the pattern may be created
+	os.Remove(changelog)
+	if result := ExecuteUnzip("release.zip", cfg, nil); !result.Success {
+		t.Errorf("unzip creating CHANGELOG.md = %v", result.Error)
+	}
+}
+
+func TestExecuteUnzip_TarSymlink(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	f, err := os.Create(filepath.Join(cfg.RepositoryRoot, "links.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(f)
+	tw.WriteHeader(&tar.Header{Name: "passwd.txt", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"})
+	tw.Close()
+	f.Close()
+
+	result := ExecuteUnzip("links.tar", cfg, nil)
+	if result.Success || !strings.Contains(result.Error.Error(), "not a regular file or directory") {
+		t.Errorf("result = %+v, want symlink rejected", result)
+	}
+}
+
+func TestExecuteUnzip_ThroughSymlink(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(cfg.RepositoryRoot, "link")); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	writeZip(t, filepath.Join(cfg.RepositoryRoot, "a.zip"), map[string]string{"a.txt": "x"})
+
+	result := ExecuteUnzip("a.zip link", cfg, nil)
+	if result.Success || !strings.Contains(result.Error.Error(), "PATH_SECURITY") {
+		t.Errorf("result = %+v, want PATH_SECURITY", result)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt")); err == nil {
+		t.Error("file was written outside the repository")
+	}
+}
+
+func TestExecuteArchive_RoundTrip(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	root := cfg.RepositoryRoot
+	os.MkdirAll(filepath.Join(root, "src", "pkg"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(root, "src", "pkg", "util.go"), []byte("package pkg\n"), 0644)
+	os.WriteFile(filepath.Join(root, "src", ".env"), []byte("SECRET=1\n"), 0644)
+
+	for _, name := range []string{"out.tar.gz", "out.zip"} {
+		t.Run(name, func(t *testing.T) {
+			result := ExecuteArchive(name+" src", cfg, nil)
+			if !result.Success {
+				t.Fatalf("ExecuteArchive() failed: %v", result.Error)
+			}
+			if !strings.Contains(result.Result, "Archived 2 files") {
+				t.Errorf("Result = %q, want the excluded file left out", result.Result)
+			}
+
+			result = ExecuteUnzip(name+" copy-"+name, cfg, nil)
+			if !result.Success {
+				t.Fatalf("ExecuteUnzip() failed: %v", result.Error)
+			}
+			got, err := os.ReadFile(filepath.Join(root, "copy-"+name, "src", "pkg", "util.go"))
+			if err != nil || string(got) != "package pkg\n" {
+				t.Errorf("extracted util.go = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestExecuteArchive_DestGuards(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	root := cfg.RepositoryRoot
+	os.MkdirAll(filepath.Join(root, "src"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0644)
+
+	// The default extensions do not include archives
+	result := ExecuteArchive("notes.zip src", cfg, nil)
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "EXTENSION_DENIED") {
+		t.Errorf("result = %+v, want EXTENSION_DENIED", result)
+	}
+	if _, err := os.Stat(filepath.Join(root, "notes.zip")); err == nil {
+		t.Error("archive with a denied extension was written")
+	}
+
+	cfg = newArchiveTestConfig(t)
+	root = cfg.RepositoryRoot
+	cfg.AppendOnlyPaths = []string{"logs/**"}
+	cfg.RespectIgnoreFiles = true
+	os.MkdirAll(filepath.Join(root, "src"), 0755)
+	os.MkdirAll(filepath.Join(root, "logs"), 0755)
+	os.MkdirAll(filepath.Join(root, "build"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(root, "build", "secret.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("build/\n"), 0644)
+	os.WriteFile(filepath.Join(root, "logs", "app.tar"), []byte("log"), 0644)
+
+	result = ExecuteArchive("logs/app.tar src", cfg, nil)
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "APPEND_ONLY") {
+		t.Errorf("result = %+v, want APPEND_ONLY", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "logs", "app.tar")); string(data) != "log" {
+		t.Errorf("logs/app.tar = %q, want it untouched", data)
+	}
+	if result := ExecuteArchive("logs/new.tar src", cfg, nil); !result.Success {
+		t.Errorf("archive creating logs/new.tar = %v", result.Error)
+	}
+
+	// The source itself is held to the ignore files, not only what is in it
+	result = ExecuteArchive("build.zip build", cfg, nil)
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "PATH_SECURITY") {
+		t.Errorf("result = %+v, want PATH_SECURITY for an ignored source", result)
+	}
+}
+
+func TestExecutor_ArchiveQuotaAndConflict(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	root := cfg.RepositoryRoot
+	cfg.ConflictCheck = true
+	cfg.WriteQuotas = map[string]config.WriteQuota{"dist": {MaxBytes: 64}}
+	os.MkdirAll(filepath.Join(root, "src"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0644)
+	e := NewExecutor(cfg, nil, nil, nil)
+
+	result := e.Execute(scanner.Command{Type: "archive", Argument: "dist/src.zip src"})
+	if result.Success || !strings.Contains(result.Error.Error(), "write quota for dist/") {
+		t.Fatalf("archive over the quota: success=%v err=%v", result.Success, result.Error)
+	}
+	if _, err := os.Stat(filepath.Join(root, "dist", "src.zip")); err == nil {
+		t.Error("archive over the quota was written")
+	}
+
+	// An archive changed on disk since it was last written is not replaced
+	if result := e.Execute(scanner.Command{Type: "archive", Argument: "src.zip src"}); !result.Success {
+		t.Fatalf("archive = %v", result.Error)
+	}
+	if result := e.Execute(scanner.Command{Type: "archive", Argument: "src.zip src"}); !result.Success {
+		t.Fatalf("archive over its own earlier output = %v", result.Error)
+	}
+	os.WriteFile(filepath.Join(root, "src.zip"), []byte("edited"), 0644)
+	result = e.Execute(scanner.Command{Type: "archive", Argument: "src.zip src"})
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "CONFLICT") {
+		t.Fatalf("expected CONFLICT, got success=%v err=%v", result.Success, result.Error)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "src.zip")); string(data) != "edited" {
+		t.Errorf("conflicting archive replaced the file: %q", data)
+	}
+}
+
+func TestExecuteArchive_Errors(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	os.WriteFile(filepath.Join(cfg.RepositoryRoot, "a.txt"), []byte("x"), 0644)
+
+	tests := []struct {
+		arg  string
+		code string
+	}{
+		{"out.zip", "PARSE_ERROR"},
+		{"out.rar a.txt", "ARCHIVE_ERROR"},
+		{"out.zip missing", "FILE_NOT_FOUND"},
+		{"../out.zip a.txt", "PATH_SECURITY"},
+	}
+	for _, tt := range tests {
+		result := ExecuteArchive(tt.arg, cfg, nil)
+		if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), tt.code) {
+			t.Errorf("ExecuteArchive(%q) = %+v, want %s", tt.arg, result, tt.code)
+		}
+	}
+}
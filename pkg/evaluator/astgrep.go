@@ -0,0 +1,180 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/astgrep"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// astGrepMaxMatches bounds the matches an <ast-grep> result lists
+const astGrepMaxMatches = 200
+
+// ExecuteASTGrep handles the "ast-grep" command
+func ExecuteASTGrep(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeASTGrep(context.Background(), arg, cfg, auditLog)
+}
+
+// executeASTGrep is ExecuteASTGrep as part of the trace in ctx. It finds
+// the Go code matching a structural pattern, such as RunContainer($CFG),
+// in the files under path= or the whole repository, leaving out what
+// <open> could not read.
+func executeASTGrep(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "ast-grep", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("ast-grep", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	source, dir := parseASTGrepArg(arg)
+	pattern, err := astgrep.Compile(source)
+	if err != nil {
+		return fail(errors.Wrap(errors.ParseError, err))
+	}
+	safeDir, err := sandbox.ValidateHostPath(dir, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return fail(errors.Wrap(errors.PathSecurity, err))
+	}
+	if cfg.RespectIgnoreFiles && dir != "." {
+		if err := sandbox.CheckIgnored(safeDir, cfg.RepositoryRoot); err != nil {
+			return fail(errors.Wrap(errors.PathSecurity, err))
+		}
+	}
+	if _, err := os.Stat(safeDir); err != nil {
+		return fail(errors.Newf(errors.FileNotFound, "path not found: %s", dir))
+	}
+	var ignored *ignore.Matcher
+	if cfg.RespectIgnoreFiles {
+		ignored = ignore.NewMatcher(cfg.RepositoryRoot)
+	}
+
+	var b strings.Builder
+	searched, unparsed, found, listed := 0, 0, 0, 0
+	files := 0
+	err = filepath.WalkDir(safeDir, func(absPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return skipEntry(d)
+		}
+		if ctx.Err() != nil {
+			return errors.New(errors.ExecInterrupted, "ast-grep stopped by interrupt")
+		}
+		rel, _ := filepath.Rel(cfg.RepositoryRoot, absPath)
+		if absPath != safeDir {
+			if d.IsDir() && (d.Name() == ".git" || d.Name() == "vendor" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			if _, err := sandbox.ValidatePath(rel, cfg.RepositoryRoot, cfg.ExcludedPaths); err != nil {
+				return skipEntry(d)
+			}
+			if ignored != nil && ignored.Match(rel, d.IsDir()) {
+				return skipEntry(d)
+			}
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(absPath, ".go") {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || (cfg.MaxFileSize > 0 && info.Size() > cfg.MaxFileSize) {
+			return nil
+		}
+		src, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil
+		}
+		searched++
+		matches, err := pattern.Find(rel, src)
+		if err != nil {
+			unparsed++
+			return nil
+		}
+		if len(matches) > 0 {
+			files++
+		}
+		for _, m := range matches {
+			found++
+			if listed == astGrepMaxMatches {
+				continue
+			}
+			listed++
+			writeASTGrepMatch(&b, filepath.ToSlash(rel), src, m)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.CodeOf(err) == "" {
+			err = errors.Wrap(errors.PermissionDenied, err)
+		}
+		return fail(err)
+	}
+
+	var header strings.Builder
+	fmt.Fprintf(&header, "Matches of %s: %d in %s (%s searched)\n", pattern, found, pluralize(files, "file"), pluralize(searched, "Go file"))
+	if found > listed {
+		fmt.Fprintf(&b, "... and %d more\n", found-listed)
+	}
+	if unparsed > 0 {
+		fmt.Fprintf(&b, "(%s could not be parsed)\n", pluralize(unparsed, "file"))
+	}
+
+	result.Success = true
+	result.Result = header.String() + b.String()
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("ast-grep", arg, true, fmt.Sprintf("files:%d,matches:%d", searched, found))
+	}
+	return result
+}
+
+// parseASTGrepArg splits the argument of <ast-grep> into the pattern and
+// the directory or file to search, from a trailing path=
+func parseASTGrepArg(arg string) (pattern, dir string) {
+	arg = strings.TrimSpace(arg)
+	if i := strings.LastIndex(arg, " path="); i >= 0 && !strings.ContainsAny(arg[i+6:], " \t\n") {
+		return arg[:i], arg[i+6:]
+	}
+	return arg, "."
+}
+
+// writeASTGrepMatch lists m, found in the file rel with source src, as the
+// first line of the match and what its metavariables captured
+func writeASTGrepMatch(b *strings.Builder, rel string, src []byte, m astgrep.Match) {
+	line := strings.TrimSpace(lineAt(string(src), m.Start.Line-1))
+	if m.End.Line > m.Start.Line {
+		line += " ..."
+	}
+	fmt.Fprintf(b, "%s:%d:%d: %s", rel, m.Start.Line, m.Start.Column, clip(line, 160))
+	if len(m.Captures) > 0 {
+		captures := make([]string, len(m.Captures))
+		for i, c := range m.Captures {
+			captures[i] = c.Name + "=" + clip(strings.Join(strings.Fields(c.Text), " "), 80)
+		}
+		fmt.Fprintf(b, "  [%s]", strings.Join(captures, ", "))
+	}
+	b.WriteString("\n")
+}
+
+// clip shortens s to at most n runes
+func clip(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteASTGrep(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.go":           "package main\n\nfunc main() {\n\tsandbox.RunContainer(cfg)\n\tRunContainer(\n\t\tother,\n\t)\n\t// RunContainer(cfg) in a comment\n}\n",
+		"pkg/a/a.go":        "package a\n\nvar x = RunContainer(a.Config{Image: \"go\"})\n",
+		"pkg/a/broken.go":   "package a\n\nfunc {\n",
+		"secrets/keys.go":   "package secrets\n\nvar k = RunContainer(key)\n",
+		"build/gen.go":      "package build\n\nvar g = RunContainer(gen)\n",
+		".gitignore":        "build/\n",
+		"pkg/a/readme.txt":  "RunContainer(text)\n",
+		"vendor/dep/dep.go": "package dep\n\nvar d = RunContainer(dep)\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := newTestConfig(tmpDir)
+	cfg.ExcludedPaths = []string{"secrets/"}
+	cfg.RespectIgnoreFiles = true
+
+	audit := &testAuditLog{}
+	result := ExecuteASTGrep("RunContainer($CFG)", cfg, audit.log)
+	want := "Matches of RunContainer($CFG): 3 in 2 files (3 Go files searched)\n" +
+		"main.go:4:2: sandbox.RunContainer(cfg)  [$CFG=cfg]\n" +
+		"main.go:5:2: RunContainer( ...  [$CFG=other]\n" +
+		"pkg/a/a.go:3:9: var x = RunContainer(a.Config{Image: \"go\"})  [$CFG=a.Config{Image: \"go\"}]\n" +
+		"(1 file could not be parsed)\n"
+	if !result.Success || result.Result != want {
+		t.Errorf("ast-grep = %v:\n%s\nwant:\n%s", result.Error, result.Result, want)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].errMsg != "files:3,matches:3" {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+
+	result = ExecuteASTGrep("RunContainer($CFG) path=pkg/a", cfg, nil)
+	if !result.Success || !strings.HasPrefix(result.Result, "Matches of RunContainer($CFG): 1 in 1 file (2 Go files searched)\n") {
+		t.Errorf("ast-grep path=pkg/a = %v:\n%s", result.Error, result.Result)
+	}
+
+	errorTests := map[string]string{
+		"RunContainer(":                      "PARSE_ERROR",
+		"RunContainer($CFG) path=secrets":    "PATH_SECURITY",
+		"RunContainer($CFG) path=no/such":    "FILE_NOT_FOUND",
+		"RunContainer($CFG) path=../outside": "PATH_SECURITY",
+	}
+	for arg, code := range errorTests {
+		if result := ExecuteASTGrep(arg, cfg, nil); result.Success || !strings.Contains(result.Error.Error(), code) {
+			t.Errorf("ast-grep %s = %+v, want %s", arg, result, code)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package evaluator
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffSize is how much of a file is inspected to detect its content type
+const sniffSize = 8192
+
+// ContentInfo describes the detected content of a file
+type ContentInfo struct {
+	ContentType string // MIME type as reported by http.DetectContentType
+	Binary      bool   // True if the file should not be shown as text
+	Head        []byte // Leading bytes of the file (up to sniffSize)
+}
+
+// DetectContent inspects the start of a file and reports whether it is
+// binary. A file is binary if it contains NUL bytes or is not valid UTF-8
+// within the inspected prefix.
+func DetectContent(filePath string) (ContentInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ContentInfo{}, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ContentInfo{}, err
+	}
+	head := buf[:n]
+
+	info := ContentInfo{
+		ContentType: http.DetectContentType(head),
+		Head:        head,
+		Binary:      isBinaryContent(head, n == sniffSize),
+	}
+
+	return info, nil
+}
+
+// isBinaryContent reports whether data looks binary. truncated indicates
+// data was cut at the sniff limit, so a trailing partial rune is ignored.
+func isBinaryContent(data []byte, truncated bool) bool {
+	for _, b := range data {
+		if b == 0 {
+			return true
+		}
+	}
+
+	if truncated {
+		// Drop an incomplete multi-byte sequence at the cut point
+		for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+			if utf8.Valid(data) {
+				return false
+			}
+			data = data[:len(data)-1]
+		}
+	}
+
+	return !utf8.Valid(data)
+}
+
+// formatBinarySummary builds the summary returned instead of raw content
+// when a binary file is opened
+func formatBinarySummary(path string, size int64, info ContentInfo, hexBytes int) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Binary file not shown: %s\n", path))
+	sb.WriteString(fmt.Sprintf("Size: %d bytes\n", size))
+	sb.WriteString(fmt.Sprintf("Detected type: %s\n", info.ContentType))
+
+	if hexBytes > 0 && len(info.Head) > 0 {
+		head := info.Head
+		if len(head) > hexBytes {
+			head = head[:hexBytes]
+		}
+		sb.WriteString(fmt.Sprintf("Hex head (first %d bytes):\n", len(head)))
+		sb.WriteString(hex.Dump(head))
+	}
+
+	// The LLM cannot pass flags, so say who can turn raw content on
+	sb.WriteString("Raw content is only returned if the operator sets --allow-binary (commands.open.allow_binary).\n")
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package evaluator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectContent(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		content    []byte
+		wantBinary bool
+		wantType   string
+	}{
+		{name: "plain text", content: []byte("hello world\n"), wantBinary: false, wantType: "text/plain"},
+		{name: "empty file", content: []byte{}, wantBinary: false, wantType: "text/plain"},
+		{name: "utf8 text", content: []byte("héllo wörld ✓\n"), wantBinary: false, wantType: "text/plain"},
+		{name: "null bytes", content: []byte("abc\x00def"), wantBinary: true},
+		{name: "invalid utf8", content: []byte{0xff, 0xfe, 0xfd, 'a'}, wantBinary: true},
+		{name: "png header", content: []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}, wantBinary: true, wantType: "image/png"},
+		{name: "json", content: []byte(`{"key": "value"}`), wantBinary: false, wantType: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, strings.ReplaceAll(tt.name, " ", "_"))
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+
+			info, err := DetectContent(path)
+			if err != nil {
+				t.Fatalf("DetectContent failed: %v", err)
+			}
+			if info.Binary != tt.wantBinary {
+				t.Errorf("Binary = %v, want %v", info.Binary, tt.wantBinary)
+			}
+			if tt.wantType != "" && !strings.HasPrefix(info.ContentType, tt.wantType) {
+				t.Errorf("ContentType = %q, want prefix %q", info.ContentType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestDetectContent_MultibyteRuneAtSniffBoundary(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Place a 3-byte rune so it straddles the sniff limit
+	content := append(bytes.Repeat([]byte("a"), sniffSize-1), []byte("✓ trailing text")...)
+	path := filepath.Join(tmpDir, "boundary.txt")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	info, err := DetectContent(path)
+	if err != nil {
+		t.Fatalf("DetectContent failed: %v", err)
+	}
+	if info.Binary {
+		t.Error("text with a rune split at the sniff boundary should not be binary")
+	}
+	if len(info.Head) != sniffSize {
+		t.Errorf("expected head of %d bytes, got %d", sniffSize, len(info.Head))
+	}
+}
+
+func TestDetectContent_NonexistentFile(t *testing.T) {
+	if _, err := DetectContent("/nonexistent/file.bin"); err == nil {
+		t.Error("expected error for nonexistent file")
+	}
+}
+
+func TestFormatBinarySummary_TruncatesHexHead(t *testing.T) {
+	info := ContentInfo{
+		ContentType: "application/octet-stream",
+		Head:        bytes.Repeat([]byte{0xAB}, 100),
+	}
+
+	summary := formatBinarySummary("data.bin", 100, info, 8)
+
+	if !strings.Contains(summary, "Hex head (first 8 bytes)") {
+		t.Errorf("expected hex head to be limited to 8 bytes, got:\n%s", summary)
+	}
+	if strings.Count(summary, "ab") != 8 {
+		t.Errorf("expected 8 hex bytes in dump, got:\n%s", summary)
+	}
+	if !strings.Contains(summary, "operator sets --allow-binary") {
+		t.Errorf("expected summary to say the operator turns on raw content, got:\n%s", summary)
+	}
+}
@@ -0,0 +1,108 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/search"
+)
+
+// bytesPerToken approximates common BPE tokenizers on source code and
+// English text, which average about four bytes per token
+const bytesPerToken = 4
+
+// truncationReserve is the token budget held back for the notice that
+// replaces truncated lines
+const truncationReserve = 32
+
+// searchResultOverhead estimates the bytes of one search result besides its
+// preview: the rank, path, score, and metadata line
+const searchResultOverhead = 120
+
+// EstimateTokens estimates the tokens text takes up in a model's context,
+// by its length in bytes
+func EstimateTokens(text string) int {
+	return (len(text) + bytesPerToken - 1) / bytesPerToken
+}
+
+// StartTurn resets the output budget, at the start of each batch of
+// commands whose results are returned to the model together
+func (e *Executor) StartTurn() {
+	e.mu.Lock()
+	e.budgetUsed = 0
+	e.mu.Unlock()
+}
+
+// remainingBudget returns the tokens of output left this turn, and false
+// when no budget is set
+func (e *Executor) remainingBudget() (int, bool) {
+	if e.config.OutputBudget <= 0 {
+		return 0, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return max(e.config.OutputBudget-e.budgetUsed, 0), true
+}
+
+// spend charges the output of a result to the turn's budget
+func (e *Executor) spend(result scanner.ExecutionResult) {
+	e.mu.Lock()
+	e.budgetUsed += e.estimate(result.Result)
+	e.mu.Unlock()
+}
+
+// fitOpen truncates the content of a successful open to the remaining
+// budget, ending it with a notice naming the line range left out. Opens
+// inside a pipe are not truncated, since later steps consume them.
+func (e *Executor) fitOpen(result *scanner.ExecutionResult) {
+	if !result.Success || result.Action == "BINARY_SUMMARY" || result.Action == "CHUNK" || result.Action == "TRUNCATED" || e.piping > 0 {
+		return
+	}
+	remaining, limited := e.remainingBudget()
+	if !limited || e.estimate(result.Result) <= remaining {
+		return
+	}
+
+	lines := strings.SplitAfter(result.Result, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	kept, used := 0, 0
+	for kept < len(lines) {
+		tokens := e.estimate(lines[kept])
+		if used+tokens > remaining-truncationReserve {
+			break
+		}
+		used += tokens
+		kept++
+	}
+
+	path, first, _, ranged := splitLineRange(result.Command.Argument)
+	if !ranged {
+		first = 1
+	}
+	omitted := len(lines) - kept
+	e.forgetOpen(result.Command.Argument)
+	result.Result = strings.Join(lines[:kept], "") + fmt.Sprintf("[%d lines omitted, use <open %s:%d-%d> to read them]\n",
+		omitted, path, first+kept, first+len(lines)-1)
+}
+
+// searchWithinBudget returns the search configuration with the result count
+// shrunk to fit the remaining budget, keeping at least one result
+func (e *Executor) searchWithinBudget() *search.SearchConfig {
+	remaining, limited := e.remainingBudget()
+	if !limited || e.searchCfg == nil {
+		return e.searchCfg
+	}
+
+	perResult := (searchResultOverhead + e.searchCfg.MaxPreviewLength + bytesPerToken - 1) / bytesPerToken
+	fits := max(remaining/perResult, 1)
+	if e.searchCfg.MaxResults > 0 && fits >= e.searchCfg.MaxResults {
+		return e.searchCfg
+	}
+
+	shrunk := *e.searchCfg
+	shrunk.MaxResults = fits
+	return &shrunk
+}
@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/search"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := map[string]int{"": 0, "a": 1, "abcd": 1, "abcde": 2, strings.Repeat("x", 400): 100}
+	for text, want := range tests {
+		if got := EstimateTokens(text); got != want {
+			t.Errorf("EstimateTokens(%d bytes) = %d, want %d", len(text), got, want)
+		}
+	}
+}
+
+// numberedLines returns n lines of 40 bytes each, 10 tokens apiece
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %03d %s\n", i, strings.Repeat("x", 30))
+	}
+	return b.String()
+}
+
+func TestFitOpen(t *testing.T) {
+	e := NewExecutor(&config.Config{OutputBudget: 132}, nil, nil, nil)
+
+	result := scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: "main.go"}, Success: true, Result: numberedLines(50)}
+	e.fitOpen(&result)
+
+	// 132 tokens less the notice reserve leaves room for 10 lines
+	if !strings.HasSuffix(result.Result, "line 010 "+strings.Repeat("x", 30)+"\n[40 lines omitted, use <open main.go:11-50> to read them]\n") {
+		t.Errorf("truncated result ends:\n%s", result.Result[max(len(result.Result)-200, 0):])
+	}
+
+	// Line numbers continue from the start of an opened range
+	result = scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: "main.go:101-150"}, Success: true, Result: numberedLines(50)}
+	e.fitOpen(&result)
+	if !strings.Contains(result.Result, "use <open main.go:111-150>") {
+		t.Errorf("ranged notice missing:\n%s", result.Result)
+	}
+}
+
+func TestFitOpen_WithinBudget(t *testing.T) {
+	content := numberedLines(5)
+	for _, cfg := range []*config.Config{{OutputBudget: 1000}, {}} {
+		e := NewExecutor(cfg, nil, nil, nil)
+		result := scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: "a.go"}, Success: true, Result: content}
+		e.fitOpen(&result)
+		if result.Result != content {
+			t.Errorf("budget %d: result changed to %q", cfg.OutputBudget, result.Result)
+		}
+	}
+}
+
+func TestBudget_SpentPerTurn(t *testing.T) {
+	e := NewExecutor(&config.Config{OutputBudget: 100}, nil, nil, nil)
+	e.spend(scanner.ExecutionResult{Result: strings.Repeat("x", 360)})
+	if remaining, _ := e.remainingBudget(); remaining != 10 {
+		t.Errorf("remaining = %d, want 10", remaining)
+	}
+
+	e.spend(scanner.ExecutionResult{Result: strings.Repeat("x", 360)})
+	if remaining, _ := e.remainingBudget(); remaining != 0 {
+		t.Errorf("remaining = %d, want 0 once overspent", remaining)
+	}
+
+	e.StartTurn()
+	if remaining, _ := e.remainingBudget(); remaining != 100 {
+		t.Errorf("remaining = %d after StartTurn, want 100", remaining)
+	}
+}
+
+func TestSearchWithinBudget(t *testing.T) {
+	searchCfg := &search.SearchConfig{Enabled: true, MaxResults: 10, MaxPreviewLength: 80}
+	// 200 bytes, 50 tokens, per result
+	tests := []struct {
+		budget int
+		want   int
+	}{
+		{0, 10},
+		{10000, 10},
+		{200, 4},
+		{10, 1},
+	}
+
+	for _, tt := range tests {
+		e := NewExecutor(&config.Config{OutputBudget: tt.budget}, searchCfg, nil, nil)
+		if got := e.searchWithinBudget().MaxResults; got != tt.want {
+			t.Errorf("budget %d: MaxResults = %d, want %d", tt.budget, got, tt.want)
+		}
+	}
+	if searchCfg.MaxResults != 10 {
+		t.Error("shared search config was modified")
+	}
+}
@@ -0,0 +1,138 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+// cursorSuffix matches an open argument ending in cursor=TOKEN
+var cursorSuffix = regexp.MustCompile(`^(.+?)\s+cursor=(\S+)$`)
+
+// splitCursor splits an open argument such as app.log cursor=abc into the
+// path and the cursor
+func splitCursor(arg string) (path, cursor string, ok bool) {
+	m := cursorSuffix.FindStringSubmatch(arg)
+	if m == nil {
+		return arg, "", false
+	}
+	return m[1], m[2], true
+}
+
+// chunkCursor is where the next chunk of a file starts. It carries the
+// size and modification time of the file it was issued for, so a cursor
+// for a file that has since changed is refused rather than paging into
+// different content.
+type chunkCursor struct {
+	Offset  int64
+	Size    int64
+	ModTime int64 // Unix nanoseconds
+}
+
+// encode returns the cursor as an opaque token
+func (c chunkCursor) encode() string {
+	raw := fmt.Sprintf("%d.%d.%d", c.Offset, c.Size, c.ModTime)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a token from encode and checks it against the file
+// as it is now
+func decodeCursor(token string, info os.FileInfo) (chunkCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return chunkCursor{}, errors.Newf(errors.InvalidCursor, "malformed cursor %q", token)
+	}
+	parts := strings.Split(string(raw), ".")
+	if len(parts) != 3 {
+		return chunkCursor{}, errors.Newf(errors.InvalidCursor, "malformed cursor %q", token)
+	}
+	var c chunkCursor
+	for i, field := range []*int64{&c.Offset, &c.Size, &c.ModTime} {
+		if *field, err = strconv.ParseInt(parts[i], 10, 64); err != nil {
+			return chunkCursor{}, errors.Newf(errors.InvalidCursor, "malformed cursor %q", token)
+		}
+	}
+
+	if c.Size != info.Size() || c.ModTime != info.ModTime().UnixNano() {
+		return chunkCursor{}, errors.New(errors.InvalidCursor, "file changed since the cursor was issued, open it again")
+	}
+	if c.Offset < 0 || c.Offset >= c.Size {
+		return chunkCursor{}, errors.Newf(errors.InvalidCursor, "offset %d is outside the file (%d bytes)", c.Offset, c.Size)
+	}
+	return c, nil
+}
+
+// cutChunk trims a chunk that does not reach the end of the file back to
+// its last complete line, or to its last complete character if it holds
+// no line break, so the next chunk starts cleanly
+func cutChunk(chunk string, atEnd bool) string {
+	if atEnd {
+		return chunk
+	}
+	if i := strings.LastIndexByte(chunk, '\n'); i >= 0 {
+		return chunk[:i+1]
+	}
+	end := len(chunk)
+	// Back up over at most one incomplete character
+	for i := 1; i < utf8.UTFMax && i <= len(chunk); i++ {
+		if utf8.RuneStart(chunk[len(chunk)-i]) {
+			if !utf8.FullRuneInString(chunk[len(chunk)-i:]) {
+				end = len(chunk) - i
+			}
+			break
+		}
+	}
+	if end == 0 {
+		// Too short to hold a whole character; keep it so the cursor moves on
+		return chunk
+	}
+	return chunk[:end]
+}
+
+// openChunk returns the chunk of a large file starting at cursor, or at
+// the start of the file if cursor is empty. The content ends with a line
+// giving the bytes shown and, unless it is the last chunk, the cursor of
+// the next one.
+func openChunk(ctx context.Context, path, cursor, safePath string, info os.FileInfo, cfg *config.Config, pool *sandbox.ContainerPool) (string, string, error) {
+	next := chunkCursor{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	if cursor != "" {
+		c, err := decodeCursor(cursor, info)
+		if err != nil {
+			return "", "", err
+		}
+		next = c
+	}
+
+	chunkSize := cfg.OpenChunkSize
+	if chunkSize <= 0 {
+		chunkSize = config.DefaultOpenChunkSize
+	}
+	start := next.Offset
+	content, err := sandbox.ReadFileRangeInContainerPooled(ctx, pool, safePath, cfg.RepositoryRoot, start, chunkSize)
+	if err != nil {
+		return "", "", errors.Wrap(errors.ReadContainer, err)
+	}
+	atEnd := start+int64(len(content)) >= next.Size
+	content = cutChunk(content, atEnd)
+	next.Offset = start + int64(len(content))
+
+	if !strings.HasSuffix(content, "\n") && content != "" {
+		content += "\n"
+	}
+	if next.Offset >= next.Size {
+		content += fmt.Sprintf("[bytes %d-%d of %d, end of file]\n", start+1, next.Offset, next.Size)
+	} else {
+		content += fmt.Sprintf("[bytes %d-%d of %d, use <open %s cursor=%s> to read more]\n",
+			start+1, next.Offset, next.Size, path, next.encode())
+	}
+	return content, fmt.Sprintf("chunk:%d-%d,bytes:%d", start+1, next.Offset, next.Size), nil
+}
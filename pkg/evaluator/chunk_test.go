@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitCursor(t *testing.T) {
+	tests := []struct {
+		arg    string
+		path   string
+		cursor string
+		ok     bool
+	}{
+		{"app.log cursor=MTAuMjAuMzA", "app.log", "MTAuMjAuMzA", true},
+		{"logs/my app.log cursor=abc", "logs/my app.log", "abc", true},
+		{"app.log", "app.log", "", false},
+		{"app.log cursor=", "app.log cursor=", "", false},
+	}
+	for _, tt := range tests {
+		path, cursor, ok := splitCursor(tt.arg)
+		if path != tt.path || cursor != tt.cursor || ok != tt.ok {
+			t.Errorf("splitCursor(%q) = %q, %q, %v", tt.arg, path, cursor, ok)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("line\n", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := info.ModTime().UnixNano()
+
+	token := chunkCursor{Offset: 250, Size: 500, ModTime: modTime}.encode()
+	c, err := decodeCursor(token, info)
+	if err != nil || c.Offset != 250 {
+		t.Errorf("decodeCursor() = %+v, %v", c, err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"malformed", "not a cursor", "malformed cursor"},
+		{"grown", chunkCursor{Offset: 250, Size: 400, ModTime: modTime}.encode(), "file changed"},
+		{"modified", chunkCursor{Offset: 250, Size: 500, ModTime: modTime - int64(time.Second)}.encode(), "file changed"},
+		{"past end", chunkCursor{Offset: 500, Size: 500, ModTime: modTime}.encode(), "outside the file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.token, info)
+			if err == nil || !strings.Contains(err.Error(), "INVALID_CURSOR") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decodeCursor() error = %v, want INVALID_CURSOR %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutChunk(t *testing.T) {
+	tests := []struct {
+		name  string
+		chunk string
+		atEnd bool
+		want  string
+	}{
+		{"last chunk", "one\ntwo", true, "one\ntwo"},
+		{"partial line", "one\ntwo\nthr", false, "one\ntwo\n"},
+		{"single line", "abcdef", false, "abcdef"},
+		{"split character", "caf\xc3", false, "caf"},
+		{"split wide character", "a\xe2\x82", false, "a"},
+		{"complete character", "café", false, "café"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cutChunk(tt.chunk, tt.atEnd); got != tt.want {
+				t.Errorf("cutChunk(%q) = %q, want %q", tt.chunk, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteOpen_CursorWithRange(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	result := ExecuteOpen("app.log:1-10 cursor=abc", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "INVALID_CURSOR") {
+		t.Errorf("result = %+v, want INVALID_CURSOR", result)
+	}
+}
+
+func TestExecuteOpen_StaleCursor(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxFileSize = 100
+	cfg.MaxChunkedSize = 1000
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.txt"), []byte(strings.Repeat("x\n", 200)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	token := chunkCursor{Offset: 100, Size: 300, ModTime: 1}.encode()
+	result := ExecuteOpen("app.txt cursor="+token, cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "file changed") {
+		t.Errorf("result = %+v, want a stale cursor error", result)
+	}
+}
+
+func TestExecuteOpen_OverChunkedSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxFileSize = 100
+	cfg.MaxChunkedSize = 200
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.txt"), []byte(strings.Repeat("x", 300)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := ExecuteOpen("app.txt", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "max 200") {
+		t.Errorf("result = %+v, want RESOURCE_LIMIT naming the chunked limit", result)
+	}
+}
@@ -0,0 +1,109 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// trackedFile is the content of a file as the LLM last saw it
+type trackedFile struct {
+	hash    string
+	content string
+}
+
+// FileTracker remembers the content of files as the LLM last opened or
+// wrote them, so a later write can detect edits made on disk in between
+type FileTracker struct {
+	mu    sync.Mutex
+	files map[string]trackedFile
+}
+
+// NewFileTracker creates an empty tracker
+func NewFileTracker() *FileTracker {
+	return &FileTracker{files: make(map[string]trackedFile)}
+}
+
+// Record stores the content seen for the file at safePath
+func (t *FileTracker) Record(safePath, content string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.files[safePath] = trackedFile{hash: CalculateContentHash(content), content: content}
+}
+
+// Forget drops any record for safePath
+func (t *FileTracker) Forget(safePath string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.files, safePath)
+}
+
+// Check compares the file on disk with the recorded content. If the file
+// changed since the LLM last saw it, Check returns a unified diff of the
+// changes and true; unrecorded or unchanged files return false.
+func (t *FileTracker) Check(safePath, displayPath string) (string, bool) {
+	t.mu.Lock()
+	seen, ok := t.files[safePath]
+	t.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+
+	current := ""
+	data, err := os.ReadFile(safePath)
+	if err == nil {
+		current = string(data)
+	} else if !os.IsNotExist(err) {
+		return "", false
+	}
+
+	if err == nil && CalculateContentHash(current) == seen.hash {
+		return "", false
+	}
+
+	displayPath = filepath.ToSlash(displayPath)
+	diff := UnifiedDiff("a/"+displayPath+" (last seen)", "b/"+displayPath+" (on disk)", seen.content, current)
+	if os.IsNotExist(err) {
+		diff = fmt.Sprintf("File was deleted since it was last opened\n%s", diff)
+	}
+	return diff, true
+}
+
+// checkWriteConflict rejects a write to a file that changed on disk since
+// the LLM last opened or wrote it. Returns nil if the write may proceed.
+func checkWriteConflict(filePath string, cfg *config.Config, tracker *FileTracker, auditLog func(cmd, arg string, success bool, errMsg string)) *scanner.ExecutionResult {
+	if tracker == nil || !cfg.ConflictCheck || cfg.ForceWrite {
+		return nil
+	}
+
+	// The tracker reads the file on the host, so a symlink out of the
+	// repository is not followed
+	safePath, err := sandbox.ValidateHostPath(filePath, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		// ExecuteWrite reports the path error
+		return nil
+	}
+
+	diff, conflict := tracker.Check(safePath, filePath)
+	if !conflict {
+		return nil
+	}
+
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "write", Argument: filePath},
+		Success: false,
+		Result:  diff,
+	}
+	fullError := errors.New(errors.Conflict, "file changed on disk since it was last opened; open it again before writing")
+	result.Error = SanitizeError(fullError) // Sanitized for LLM
+	if auditLog != nil {
+		auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+	}
+	return &result
+}
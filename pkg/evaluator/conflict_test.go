@@ -0,0 +1,259 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestFileTracker_Check(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	tracker := NewFileTracker()
+
+	// Unrecorded files never conflict
+	if _, conflict := tracker.Check(path, "main.go"); conflict {
+		t.Error("unrecorded file should not conflict")
+	}
+
+	tracker.Record(path, "package main\n")
+	if _, conflict := tracker.Check(path, "main.go"); conflict {
+		t.Error("unchanged file should not conflict")
+	}
+
+	// Human edit on disk
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("failed to edit file: %v", err)
+	}
+	diff, conflict := tracker.Check(path, "main.go")
+	if !conflict {
+		t.Fatal("expected conflict after edit on disk")
+	}
+	if !strings.Contains(diff, "+func main() {}") {
+		t.Errorf("diff should show the edit, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "a/main.go (last seen)") {
+		t.Errorf("diff should name the file, got:\n%s", diff)
+	}
+
+	// Deleted file
+	os.Remove(path)
+	diff, conflict = tracker.Check(path, "main.go")
+	if !conflict || !strings.Contains(diff, "deleted") {
+		t.Errorf("expected deletion conflict, got %v:\n%s", conflict, diff)
+	}
+
+	tracker.Forget(path)
+	if _, conflict := tracker.Check(path, "main.go"); conflict {
+		t.Error("forgotten file should not conflict")
+	}
+}
+
+func TestCheckWriteConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.ConflictCheck = true
+
+	path := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(path, []byte("edited by human\n"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	safePath, err := sandbox.ValidatePath("notes.txt", cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		t.Fatalf("failed to validate path: %v", err)
+	}
+	tracker := NewFileTracker()
+	tracker.Record(safePath, "original\n")
+
+	audit := &testAuditLog{}
+	result := checkWriteConflict("notes.txt", cfg, tracker, audit.log)
+	if result == nil {
+		t.Fatal("expected conflict result")
+	}
+	if result.Success {
+		t.Error("conflict should not succeed")
+	}
+	if !strings.HasPrefix(result.Error.Error(), "CONFLICT") {
+		t.Errorf("expected CONFLICT error, got %v", result.Error)
+	}
+	if !strings.Contains(result.Result, "-original") || !strings.Contains(result.Result, "+edited by human") {
+		t.Errorf("expected diff in result, got:\n%s", result.Result)
+	}
+	entries := audit.getEntries()
+	if len(entries) != 1 || entries[0].success || !strings.Contains(entries[0].errMsg, "CONFLICT") {
+		t.Errorf("expected CONFLICT audit entry, got %+v", entries)
+	}
+
+	// --force bypasses the check
+	cfg.ForceWrite = true
+	if checkWriteConflict("notes.txt", cfg, tracker, nil) != nil {
+		t.Error("force should skip conflict detection")
+	}
+
+	// Disabled check
+	cfg.ForceWrite = false
+	cfg.ConflictCheck = false
+	if checkWriteConflict("notes.txt", cfg, tracker, nil) != nil {
+		t.Error("disabled conflict check should not reject")
+	}
+}
+
+func TestExecutor_WriteConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.ConflictCheck = true
+
+	path := filepath.Join(tmpDir, "data.txt")
+	if err := os.WriteFile(path, []byte("v1\n"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	// Simulate a successful open
+	executor.trackFile(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open", Argument: "data.txt"},
+		Success: true,
+	})
+
+	if err := os.WriteFile(path, []byte("v2\n"), 0644); err != nil {
+		t.Fatalf("failed to edit file: %v", err)
+	}
+
+	result := executor.Execute(scanner.Command{Type: "write", Argument: "data.txt", Content: "llm\n"})
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "CONFLICT") {
+		t.Fatalf("expected CONFLICT, got success=%v err=%v", result.Success, result.Error)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "v2\n" {
+		t.Errorf("conflicting write modified the file: %q", content)
+	}
+
+	// Re-opening picks up the new content and clears the conflict
+	executor.trackFile(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open", Argument: "data.txt"},
+		Success: true,
+	})
+	if checkWriteConflict("data.txt", cfg, executor.tracker, nil) != nil {
+		t.Error("expected no conflict after re-opening")
+	}
+}
+
+func TestExecutor_FetchDestConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newFetchConfig(tmpDir)
+	cfg.ConflictCheck = true
+	server := newFetchServer(t)
+
+	path := filepath.Join(tmpDir, "spec.json")
+	os.WriteFile(path, []byte("{}\n"), 0644)
+	executor := NewExecutor(cfg, nil, nil, nil)
+	executor.trackFile(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open", Argument: "spec.json"},
+		Success: true,
+	})
+	os.WriteFile(path, []byte(`{"edited": true}`), 0644)
+
+	result := executor.Execute(scanner.Command{Type: "fetch", Argument: server.URL + "/spec.json dest=spec.json"})
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "CONFLICT") {
+		t.Fatalf("expected CONFLICT, got success=%v err=%v", result.Success, result.Error)
+	}
+	if content, _ := os.ReadFile(path); string(content) != `{"edited": true}` {
+		t.Errorf("conflicting fetch modified the file: %q", content)
+	}
+}
+
+func TestExecutor_UnzipConflict(t *testing.T) {
+	cfg := newArchiveTestConfig(t)
+	cfg.ConflictCheck = true
+	root := cfg.RepositoryRoot
+	writeZip(t, filepath.Join(root, "fixtures.zip"), map[string]string{"a.txt": "new a\n", "b.txt": "new b\n"})
+	os.WriteFile(filepath.Join(root, "b.txt"), []byte("b\n"), 0644)
+
+	executor := NewExecutor(cfg, nil, nil, nil)
+	executor.trackFile(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open", Argument: "b.txt"},
+		Success: true,
+	})
+	os.WriteFile(filepath.Join(root, "b.txt"), []byte("edited b\n"), 0644)
+
+	result := executor.Execute(scanner.Command{Type: "unzip", Argument: "fixtures.zip"})
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "CONFLICT") || !strings.Contains(result.Result, "+edited b") {
+		t.Fatalf("expected CONFLICT with a diff, got success=%v err=%v result=%q", result.Success, result.Error, result.Result)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); err == nil {
+		t.Error("conflicting unzip extracted a.txt")
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "b.txt")); string(content) != "edited b\n" {
+		t.Errorf("conflicting unzip modified b.txt: %q", content)
+	}
+
+	// Extracted files are tracked as written
+	os.WriteFile(filepath.Join(root, "b.txt"), []byte("b\n"), 0644)
+	if result := executor.Execute(scanner.Command{Type: "unzip", Argument: "fixtures.zip"}); !result.Success {
+		t.Fatalf("unzip = %v", result.Error)
+	}
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("edited a\n"), 0644)
+	result = executor.Execute(scanner.Command{Type: "unzip", Argument: "fixtures.zip"})
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "CONFLICT") {
+		t.Errorf("expected CONFLICT for an extracted file edited on disk, got success=%v err=%v", result.Success, result.Error)
+	}
+}
+
+func TestExecutor_TrackFileSkipsBinarySummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.ConflictCheck = true
+
+	path := filepath.Join(tmpDir, "image.txt")
+	os.WriteFile(path, []byte{0, 1, 2}, 0644)
+
+	executor := NewExecutor(cfg, nil, nil, nil)
+	executor.trackFile(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open", Argument: "image.txt"},
+		Success: true,
+		Action:  "BINARY_SUMMARY",
+	})
+
+	os.WriteFile(path, []byte{3, 4, 5}, 0644)
+	if checkWriteConflict("image.txt", cfg, executor.tracker, nil) != nil {
+		t.Error("binary summaries should not be tracked")
+	}
+}
+
+func TestExecutor_TrackFileSymlinkOutOfRepo(t *testing.T) {
+	tmpDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(outside, []byte("hunter2\n"), 0644)
+	if err := os.Symlink(outside, filepath.Join(tmpDir, "link.txt")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	cfg := newTestConfig(tmpDir)
+	cfg.ConflictCheck = true
+
+	executor := NewExecutor(cfg, nil, nil, nil)
+	executor.trackFile(scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open", Argument: "link.txt"},
+		Success: true,
+	})
+	if len(executor.tracker.files) != 0 {
+		t.Errorf("tracker read a file through a symlink out of the repository: %v", executor.tracker.files)
+	}
+
+	// A record made under the same name is not compared with the file
+	// outside the repository either
+	executor.tracker.Record(filepath.Join(tmpDir, "link.txt"), "old\n")
+	if conflict := checkWriteConflict("link.txt", cfg, executor.tracker, nil); conflict != nil {
+		t.Errorf("conflict check read through a symlink: %q", conflict.Result)
+	}
+}
@@ -0,0 +1,167 @@
+package evaluator
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+// utf8BOM is the UTF-8 byte order mark
+const utf8BOM = "\xef\xbb\xbf"
+
+// TextConvention describes the line endings and encoding markers of a file
+type TextConvention struct {
+	CRLF          bool // Lines end with \r\n rather than \n
+	FinalNewline  bool // Content ends with a line ending
+	BOM           bool // Content starts with a UTF-8 byte order mark
+	HasLineBreaks bool // False if there were no line endings to detect from
+	Empty         bool // No content apart from an optional BOM
+}
+
+// String describes the convention, e.g. "CRLF, BOM, no final newline"
+func (c TextConvention) String() string {
+	parts := []string{"LF"}
+	if c.CRLF {
+		parts[0] = "CRLF"
+	}
+	if c.BOM {
+		parts = append(parts, "BOM")
+	}
+	if !c.FinalNewline {
+		parts = append(parts, "no final newline")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DetectConvention inspects content and reports its convention. Files with
+// mixed line endings are treated as CRLF when most lines use CRLF.
+func DetectConvention(data []byte) TextConvention {
+	var c TextConvention
+
+	if bytes.HasPrefix(data, []byte(utf8BOM)) {
+		c.BOM = true
+		data = data[len(utf8BOM):]
+	}
+	if len(data) == 0 {
+		c.Empty = true
+		return c
+	}
+
+	lines := bytes.Count(data, []byte("\n"))
+	crlf := bytes.Count(data, []byte("\r\n"))
+	c.HasLineBreaks = lines > 0
+	c.CRLF = crlf > 0 && crlf*2 >= lines
+	c.FinalNewline = data[len(data)-1] == '\n'
+
+	return c
+}
+
+// ConventionOptions holds the configured overrides for each part of the
+// convention. Each field is config.ConventionPreserve or an explicit value.
+type ConventionOptions struct {
+	LineEndings     string // preserve, lf, or crlf
+	TrailingNewline string // preserve, always, or never
+	BOM             string // preserve, always, or never
+}
+
+// conventionOptions extracts the convention overrides from cfg
+func conventionOptions(cfg *config.Config) ConventionOptions {
+	return ConventionOptions{
+		LineEndings:     cfg.LineEndings,
+		TrailingNewline: cfg.TrailingNewline,
+		BOM:             cfg.BOM,
+	}
+}
+
+// ApplyConvention rewrites content to match the existing file's convention
+// (nil for a new file) and the configured overrides. In preserve mode,
+// anything that cannot be detected from the existing file is left as the
+// content has it. Returns the new content and its resulting convention.
+func ApplyConvention(content string, existing *TextConvention, opts ConventionOptions) (string, TextConvention) {
+	hadBOM := strings.HasPrefix(content, utf8BOM)
+	body := strings.TrimPrefix(content, utf8BOM)
+
+	// Line endings
+	crlf := false
+	switch opts.LineEndings {
+	case config.LineEndingLF:
+		body = toLF(body)
+	case config.LineEndingCRLF:
+		body = toCRLF(body)
+		crlf = true
+	default:
+		if existing != nil && existing.HasLineBreaks {
+			if existing.CRLF {
+				body = toCRLF(body)
+				crlf = true
+			} else {
+				body = toLF(body)
+			}
+		} else {
+			crlf = DetectConvention([]byte(body)).CRLF
+		}
+	}
+
+	// Trailing newline
+	eol := "\n"
+	if crlf {
+		eol = "\r\n"
+	}
+	switch opts.TrailingNewline {
+	case config.ConventionAlways:
+		body = ensureFinalNewline(body, eol)
+	case config.ConventionNever:
+		body = trimFinalNewline(body)
+	default:
+		if existing != nil && !existing.Empty {
+			if existing.FinalNewline {
+				body = ensureFinalNewline(body, eol)
+			} else {
+				body = trimFinalNewline(body)
+			}
+		}
+	}
+
+	// Byte order mark
+	bom := hadBOM
+	switch opts.BOM {
+	case config.ConventionAlways:
+		bom = true
+	case config.ConventionNever:
+		bom = false
+	default:
+		if existing != nil {
+			bom = existing.BOM
+		}
+	}
+	if bom {
+		body = utf8BOM + body
+	}
+
+	return body, DetectConvention([]byte(body))
+}
+
+// toLF converts CRLF line endings to LF
+func toLF(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// toCRLF converts all line endings to CRLF
+func toCRLF(s string) string {
+	return strings.ReplaceAll(toLF(s), "\n", "\r\n")
+}
+
+// ensureFinalNewline appends eol unless s is empty or already ends with a
+// newline
+func ensureFinalNewline(s, eol string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + eol
+}
+
+// trimFinalNewline removes all trailing line endings
+func trimFinalNewline(s string) string {
+	return strings.TrimRight(s, "\r\n")
+}
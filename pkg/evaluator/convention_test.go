@@ -0,0 +1,235 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestDetectConvention(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want TextConvention
+	}{
+		{
+			name: "lf with final newline",
+			data: "a\nb\n",
+			want: TextConvention{FinalNewline: true, HasLineBreaks: true},
+		},
+		{
+			name: "crlf without final newline",
+			data: "a\r\nb",
+			want: TextConvention{CRLF: true, HasLineBreaks: true},
+		},
+		{
+			name: "bom crlf",
+			data: utf8BOM + "a\r\nb\r\n",
+			want: TextConvention{CRLF: true, FinalNewline: true, BOM: true, HasLineBreaks: true},
+		},
+		{
+			name: "mostly lf",
+			data: "a\nb\nc\r\n",
+			want: TextConvention{FinalNewline: true, HasLineBreaks: true},
+		},
+		{
+			name: "mostly crlf",
+			data: "a\r\nb\r\nc\n",
+			want: TextConvention{CRLF: true, FinalNewline: true, HasLineBreaks: true},
+		},
+		{
+			name: "single line",
+			data: "abc",
+			want: TextConvention{},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: TextConvention{Empty: true},
+		},
+		{
+			name: "bom only",
+			data: utf8BOM,
+			want: TextConvention{BOM: true, Empty: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectConvention([]byte(tt.data)); got != tt.want {
+				t.Errorf("DetectConvention(%q) = %+v, want %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextConvention_String(t *testing.T) {
+	tests := []struct {
+		c    TextConvention
+		want string
+	}{
+		{TextConvention{FinalNewline: true}, "LF"},
+		{TextConvention{CRLF: true, FinalNewline: true, BOM: true}, "CRLF, BOM"},
+		{TextConvention{CRLF: true}, "CRLF, no final newline"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestApplyConvention_Preserve(t *testing.T) {
+	preserve := ConventionOptions{
+		LineEndings:     config.ConventionPreserve,
+		TrailingNewline: config.ConventionPreserve,
+		BOM:             config.ConventionPreserve,
+	}
+
+	tests := []struct {
+		name     string
+		existing string
+		isNew    bool
+		content  string
+		want     string
+	}{
+		{
+			name:     "crlf file keeps crlf",
+			existing: "old\r\nfile\r\n",
+			content:  "new\ncontent\n",
+			want:     "new\r\ncontent\r\n",
+		},
+		{
+			name:     "lf file normalizes crlf content",
+			existing: "old\nfile\n",
+			content:  "new\r\ncontent\r\n",
+			want:     "new\ncontent\n",
+		},
+		{
+			name:     "missing final newline kept missing",
+			existing: "old\nfile",
+			content:  "new\ncontent\n\n",
+			want:     "new\ncontent",
+		},
+		{
+			name:     "final newline added",
+			existing: "old\r\nfile\r\n",
+			content:  "new\ncontent",
+			want:     "new\r\ncontent\r\n",
+		},
+		{
+			name:     "bom kept",
+			existing: utf8BOM + "old\n",
+			content:  "new\n",
+			want:     utf8BOM + "new\n",
+		},
+		{
+			name:     "bom not added",
+			existing: "old\n",
+			content:  utf8BOM + "new\n",
+			want:     "new\n",
+		},
+		{
+			name:     "single-line file leaves line endings alone",
+			existing: "old",
+			content:  "a\r\nb",
+			want:     "a\r\nb",
+		},
+		{
+			name:     "empty file leaves final newline alone",
+			existing: "",
+			content:  "a\n",
+			want:     "a\n",
+		},
+		{
+			name:    "new file unchanged",
+			isNew:   true,
+			content: utf8BOM + "a\r\nb",
+			want:    utf8BOM + "a\r\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var existing *TextConvention
+			if !tt.isNew {
+				detected := DetectConvention([]byte(tt.existing))
+				existing = &detected
+			}
+			got, conv := ApplyConvention(tt.content, existing, preserve)
+			if got != tt.want {
+				t.Errorf("ApplyConvention = %q, want %q", got, tt.want)
+			}
+			if conv != DetectConvention([]byte(got)) {
+				t.Errorf("reported convention %+v does not match content", conv)
+			}
+		})
+	}
+}
+
+func TestApplyConvention_Overrides(t *testing.T) {
+	crlfFile := DetectConvention([]byte(utf8BOM + "old\r\nfile\r\n"))
+
+	tests := []struct {
+		name     string
+		existing *TextConvention
+		opts     ConventionOptions
+		content  string
+		want     string
+	}{
+		{
+			name:     "force lf",
+			existing: &crlfFile,
+			opts:     ConventionOptions{LineEndings: config.LineEndingLF},
+			content:  "a\r\nb\r\n",
+			want:     utf8BOM + "a\nb\n",
+		},
+		{
+			name:    "force crlf on new file",
+			opts:    ConventionOptions{LineEndings: config.LineEndingCRLF},
+			content: "a\nb\n",
+			want:    "a\r\nb\r\n",
+		},
+		{
+			name:    "always final newline uses crlf",
+			opts:    ConventionOptions{LineEndings: config.LineEndingCRLF, TrailingNewline: config.ConventionAlways},
+			content: "a\nb",
+			want:    "a\r\nb\r\n",
+		},
+		{
+			name:     "never final newline",
+			existing: &crlfFile,
+			opts:     ConventionOptions{TrailingNewline: config.ConventionNever},
+			content:  "a\nb\n",
+			want:     utf8BOM + "a\r\nb",
+		},
+		{
+			name:     "never bom",
+			existing: &crlfFile,
+			opts:     ConventionOptions{BOM: config.ConventionNever},
+			content:  "a\n",
+			want:     "a\r\n",
+		},
+		{
+			name:    "always bom",
+			opts:    ConventionOptions{BOM: config.ConventionAlways},
+			content: "a\n",
+			want:    utf8BOM + "a\n",
+		},
+		{
+			name:    "always final newline on empty content",
+			opts:    ConventionOptions{TrailingNewline: config.ConventionAlways},
+			content: "",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := ApplyConvention(tt.content, tt.existing, tt.opts); got != tt.want {
+				t.Errorf("ApplyConvention = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
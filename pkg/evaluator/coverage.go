@@ -0,0 +1,285 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// coverageProfiles are the profiles <coverage> reads without a path, in
+// the order it looks for them
+var coverageProfiles = []string{"coverage.out", "cover.out", "coverage.txt", "lcov.info", "coverage/lcov.info"}
+
+// coverageCount is how much of some code the tests ran: statements for Go
+// profiles, lines for lcov
+type coverageCount struct {
+	Covered, Total int
+}
+
+// percent is the share of the code covered, 0 for none
+func (c coverageCount) percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return 100 * float64(c.Covered) / float64(c.Total)
+}
+
+// coverageRun is the coverage of one profile, in total and by package
+type coverageRun struct {
+	Format   string
+	Unit     string // What Total counts: "statements" or "lines"
+	Total    coverageCount
+	Packages map[string]coverageCount
+}
+
+// ExecuteCoverage handles the "coverage" command outside a session, so
+// with no earlier run to compare against
+func ExecuteCoverage(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeCoverage(context.Background(), arg, cfg, auditLog, nil)
+}
+
+// executeCoverage is ExecuteCoverage as part of the trace in ctx. It reads
+// a Go coverage profile or an lcov file written by a test run and reports
+// the coverage of each package, or directory for lcov, with the change
+// since the same profile was last read this session. history holds the
+// last run of each profile and is updated with this one; it may be nil.
+func executeCoverage(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), history map[string]coverageRun) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "coverage", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("coverage", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	profile := strings.TrimSpace(arg)
+	if profile == "" {
+		for _, name := range coverageProfiles {
+			if _, err := os.Stat(filepath.Join(cfg.RepositoryRoot, name)); err == nil {
+				profile = name
+				break
+			}
+		}
+		if profile == "" {
+			return fail(errors.Newf(errors.CoverageFailed, "no coverage profile found (looked for %s); write one with go test -coverprofile=coverage.out or name it", strings.Join(coverageProfiles, ", ")))
+		}
+	}
+
+	// Validate the path as open does, except for ignore files: profiles are
+	// generated and usually ignored, and only their totals are shown. The
+	// profile is read on the host, so symlinks out of the root are refused.
+	safePath, err := sandbox.ValidateHostPath(profile, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return fail(errors.Wrap(errors.PathSecurity, err))
+	}
+	data, err := os.ReadFile(safePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fail(errors.New(errors.FileNotFound, profile))
+		}
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+	if int64(len(data)) > max(cfg.MaxFileSize, cfg.MaxChunkedSize) {
+		return fail(errors.Newf(errors.ResourceLimit, "coverage profile too large: %d bytes", len(data)))
+	}
+
+	var run coverageRun
+	switch {
+	case strings.HasPrefix(string(data), "mode:"):
+		run = parseGoCoverage(string(data), manifestModuleName(filepath.Join(cfg.RepositoryRoot, "go.mod")))
+	case strings.Contains(string(data), "SF:") && strings.Contains(string(data), "end_of_record"):
+		run = parseLcov(string(data), cfg.RepositoryRoot)
+	default:
+		return fail(errors.Newf(errors.CoverageFailed, "%s is not a Go coverage profile or an lcov file", profile))
+	}
+
+	key := filepath.ToSlash(filepath.Clean(profile))
+	previous, seen := history[key]
+	if history != nil {
+		history[key] = run
+	}
+
+	result.Success = true
+	result.Result = formatCoverage(key, run, previous, seen)
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("coverage", key, true, fmt.Sprintf("packages:%d,coverage:%.1f", len(run.Packages), run.Total.percent()))
+	}
+	return result
+}
+
+// parseGoCoverage reads a profile written by go test -coverprofile, naming
+// packages by their directory within module. A block listed more than
+// once, as in profiles merged from several runs, is counted once.
+func parseGoCoverage(data, module string) coverageRun {
+	type block struct {
+		statements int
+		covered    bool
+	}
+	blocks := make(map[string]block)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// name.go:line.column,line.column statements count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		statements, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		b := blocks[fields[0]]
+		b.statements = statements
+		b.covered = b.covered || count > 0
+		blocks[fields[0]] = b
+	}
+
+	run := coverageRun{Format: "Go profile", Unit: "statements", Packages: make(map[string]coverageCount)}
+	for pos, b := range blocks {
+		file, _, _ := strings.Cut(pos, ":")
+		pkg := path.Dir(file)
+		if module != "" {
+			switch {
+			case pkg == module:
+				pkg = "."
+			case strings.HasPrefix(pkg, module+"/"):
+				pkg = strings.TrimPrefix(pkg, module+"/")
+			}
+		}
+		c := run.Packages[pkg]
+		c.Total += b.statements
+		run.Total.Total += b.statements
+		if b.covered {
+			c.Covered += b.statements
+			run.Total.Covered += b.statements
+		}
+		run.Packages[pkg] = c
+	}
+	return run
+}
+
+// parseLcov reads an lcov tracefile, naming packages by the directory of
+// each source file within root or the exec container's /workspace
+func parseLcov(data, root string) coverageRun {
+	run := coverageRun{Format: "lcov", Unit: "lines", Packages: make(map[string]coverageCount)}
+	var file string
+	var c, found coverageCount
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = strings.TrimPrefix(line, "SF:")
+			c, found = coverageCount{}, coverageCount{}
+		case strings.HasPrefix(line, "DA:"):
+			// DA:line,hits
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(fields) >= 2 {
+				c.Total++
+				if hits, err := strconv.Atoi(fields[1]); err == nil && hits > 0 {
+					c.Covered++
+				}
+			}
+		case strings.HasPrefix(line, "LF:"):
+			found.Total, _ = strconv.Atoi(strings.TrimPrefix(line, "LF:"))
+		case strings.HasPrefix(line, "LH:"):
+			found.Covered, _ = strconv.Atoi(strings.TrimPrefix(line, "LH:"))
+		case line == "end_of_record":
+			// The summary lines are what the tool reported, so they win
+			if found.Total > 0 {
+				c = found
+			}
+			pkg := path.Dir(repoRelativePath(file, root))
+			p := run.Packages[pkg]
+			p.Covered += c.Covered
+			p.Total += c.Total
+			run.Packages[pkg] = p
+			run.Total.Covered += c.Covered
+			run.Total.Total += c.Total
+		}
+	}
+	return run
+}
+
+// repoRelativePath makes a path a tool printed relative to the repository,
+// whether the tool ran on the host or in a container
+func repoRelativePath(file, root string) string {
+	file = filepath.ToSlash(file)
+	for _, prefix := range []string{filepath.ToSlash(filepath.Clean(root)) + "/", "/workspace/"} {
+		if strings.HasPrefix(file, prefix) {
+			return strings.TrimPrefix(file, prefix)
+		}
+	}
+	return strings.TrimPrefix(file, "./")
+}
+
+// formatCoverage renders run, with the change of each package since
+// previous if the profile was read before
+func formatCoverage(profile string, run, previous coverageRun, seen bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s): %.1f%% of %d %s", profile, run.Format, run.Total.percent(), run.Total.Total, run.Unit)
+	if seen {
+		fmt.Fprintf(&b, ", %s since the last run", coverageDelta(run.Total, previous.Total))
+	}
+	b.WriteString("\n\n")
+
+	names := make([]string, 0, len(run.Packages))
+	for name := range run.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := run.Packages[name]
+		fmt.Fprintf(&b, "- %s: %.1f%% (%d/%d)", name, c.percent(), c.Covered, c.Total)
+		if seen {
+			if before, ok := previous.Packages[name]; !ok {
+				b.WriteString(" new")
+			} else if d := coverageDelta(c, before); d != "unchanged" {
+				fmt.Fprintf(&b, " %s", d)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	var gone []string
+	for name := range previous.Packages {
+		if _, ok := run.Packages[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+	if len(gone) > 0 {
+		sort.Strings(gone)
+		fmt.Fprintf(&b, "\nNo longer in the profile: %s\n", strings.Join(gone, ", "))
+	}
+	return b.String()
+}
+
+// coverageDelta describes the change in percentage points from before to
+// now, such as "+2.5"
+func coverageDelta(now, before coverageCount) string {
+	d := fmt.Sprintf("%+.1f", now.percent()-before.percent())
+	if d == "+0.0" || d == "-0.0" {
+		return "unchanged"
+	}
+	return d
+}
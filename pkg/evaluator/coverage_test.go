@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteCoverage_GoProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example.com/app\n"), 0644)
+	profile := filepath.Join(tmpDir, "coverage.out")
+	write := func(content string) {
+		if err := os.WriteFile(profile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`mode: set
+example.com/app/main.go:3.13,5.2 2 1
+example.com/app/store/store.go:3.20,6.2 3 1
+example.com/app/store/store.go:8.20,10.2 1 0
+example.com/app/store/store.go:8.20,10.2 1 0
+example.com/app/old/old.go:1.1,2.2 4 0
+`)
+	cfg := newTestConfig(tmpDir)
+	history := make(map[string]coverageRun)
+
+	audit := &testAuditLog{}
+	result := executeCoverage(context.Background(), "", cfg, audit.log, history)
+	if !result.Success {
+		t.Fatalf("coverage failed: %v", result.Error)
+	}
+	want := "coverage.out (Go profile): 50.0% of 10 statements\n\n" +
+		"- .: 100.0% (2/2)\n" +
+		"- old: 0.0% (0/4)\n" +
+		"- store: 75.0% (3/4)\n"
+	if result.Result != want {
+		t.Errorf("first run =\n%s\nwant\n%s", result.Result, want)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].errMsg != "packages:3,coverage:50.0" {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+
+	write(`mode: count
+example.com/app/main.go:3.13,5.2 2 4
+example.com/app/store/store.go:3.20,6.2 3 2
+example.com/app/store/store.go:8.20,10.2 1 1
+example.com/app/api/api.go:1.1,2.2 2 0
+`)
+	result = executeCoverage(context.Background(), "coverage.out", cfg, nil, history)
+	want = "coverage.out (Go profile): 75.0% of 8 statements, +25.0 since the last run\n\n" +
+		"- .: 100.0% (2/2)\n" +
+		"- api: 0.0% (0/2) new\n" +
+		"- store: 100.0% (4/4) +25.0\n" +
+		"\nNo longer in the profile: old\n"
+	if result.Result != want {
+		t.Errorf("second run =\n%s\nwant\n%s", result.Result, want)
+	}
+}
+
+func TestExecuteCoverage_Lcov(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "coverage"), 0755)
+	lcov := "TN:\nSF:/workspace/src/sum.js\nDA:1,1\nDA:2,0\nLF:2\nLH:1\nend_of_record\n" +
+		"SF:src/util/fmt.js\nDA:1,3\nDA:2,1\nDA:3,0\nend_of_record\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "coverage", "lcov.info"), []byte(lcov), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := ExecuteCoverage("", newTestConfig(tmpDir), nil)
+	if !result.Success {
+		t.Fatalf("coverage failed: %v", result.Error)
+	}
+	want := "coverage/lcov.info (lcov): 60.0% of 5 lines\n\n" +
+		"- src: 50.0% (1/2)\n" +
+		"- src/util: 66.7% (2/3)\n"
+	if result.Result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result.Result, want)
+	}
+}
+
+func TestExecuteCoverage_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("hello\n"), 0644)
+	cfg := newTestConfig(tmpDir)
+
+	for arg, code := range map[string]string{
+		"":            "COVERAGE_FAILED",
+		"notes.txt":   "COVERAGE_FAILED",
+		"missing.out": "FILE_NOT_FOUND",
+		"../x.out":    "PATH_SECURITY",
+	} {
+		result := ExecuteCoverage(arg, cfg, nil)
+		if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), code) {
+			t.Errorf("coverage %q = %+v, want %s", arg, result, code)
+		}
+	}
+}
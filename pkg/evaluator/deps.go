@@ -0,0 +1,324 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// depsTimeout bounds the go list run behind <deps>
+const depsTimeout = 60 * time.Second
+
+// depsGraph is the import graph of the packages of the repository's Go
+// module
+type depsGraph struct {
+	module    *packages.Module
+	pkgs      map[string]*packages.Package // Main module packages by import path
+	importers map[string][]string          // Main module importers by import path
+	errors    int                          // Packages that failed to load
+}
+
+// ExecuteDeps handles the "deps" command
+func ExecuteDeps(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeDeps(context.Background(), arg, cfg, auditLog)
+}
+
+// executeDeps is ExecuteDeps as part of the trace in ctx. Without a package
+// it reports how the module's packages import each other and the external
+// modules it needs; with one, what that package imports, the packages that
+// import it directly or through others, and the external modules under it.
+// Packages are loaded by llm-runtime itself with go list, which only reads
+// the module: nothing is downloaded, built, or run.
+func executeDeps(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	target := strings.TrimSpace(arg)
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "deps", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("deps", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.RepositoryRoot, "go.mod")); err != nil {
+		return fail(errors.New(errors.DepsFailed, "deps needs a Go module (no go.mod at the repository root)"))
+	}
+	ctx, cancel := context.WithTimeout(ctx, depsTimeout)
+	defer cancel()
+	graph, err := loadDepsGraph(ctx, cfg.RepositoryRoot)
+	if err != nil {
+		return fail(err)
+	}
+
+	var output string
+	if target == "" {
+		output = graph.moduleReport()
+	} else {
+		path, ok := graph.resolve(target)
+		if !ok {
+			return fail(errors.Newf(errors.DepsFailed, "no package %s in module %s", target, graph.module.Path))
+		}
+		output = graph.packageReport(path)
+	}
+	if graph.errors > 0 {
+		output += fmt.Sprintf("\n(%d packages had errors and may be missing imports)\n", graph.errors)
+	}
+
+	result.Success = true
+	result.Result = output
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("deps", arg, true, fmt.Sprintf("packages:%d,errors:%d", len(graph.pkgs), graph.errors))
+	}
+	return result
+}
+
+// loadDepsGraph loads the packages of the module at root. The go command
+// runs with the local toolchain and no module proxy, so a module that is
+// not already in the module cache is reported rather than downloaded.
+func loadDepsGraph(ctx context.Context, root string) (*depsGraph, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+		Dir:     root,
+		Env:     append(os.Environ(), "GOTOOLCHAIN=local", "GOPROXY=off", "GOWORK=off", "GOFLAGS=-mod=mod"),
+	}, "./...")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Newf(errors.ExecTimeout, "loading packages took more than %v", depsTimeout)
+		}
+		return nil, errors.Wrap(errors.DepsFailed, err)
+	}
+
+	g := &depsGraph{
+		pkgs:      make(map[string]*packages.Package),
+		importers: make(map[string][]string),
+	}
+	for _, pkg := range pkgs {
+		if pkg.Module != nil && pkg.Module.Main {
+			g.module = pkg.Module
+			break
+		}
+	}
+	if g.module == nil {
+		return nil, errors.New(errors.DepsFailed, "no packages found in the module")
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if !g.inModule(pkg.PkgPath) {
+			return
+		}
+		g.pkgs[pkg.PkgPath] = pkg
+		if len(pkg.Errors) > 0 {
+			g.errors++
+		}
+		for imp := range pkg.Imports {
+			if g.inModule(imp) {
+				g.importers[imp] = append(g.importers[imp], pkg.PkgPath)
+			}
+		}
+	})
+	return g, nil
+}
+
+// inModule reports whether path is a package of the main module
+func (g *depsGraph) inModule(path string) bool {
+	return path == g.module.Path || strings.HasPrefix(path, g.module.Path+"/")
+}
+
+// short names a main module package by its directory
+func (g *depsGraph) short(path string) string {
+	if path == g.module.Path {
+		return "."
+	}
+	return strings.TrimPrefix(path, g.module.Path+"/")
+}
+
+// resolve finds the package target names, by directory or import path
+func (g *depsGraph) resolve(target string) (string, bool) {
+	target = strings.TrimSuffix(filepath.ToSlash(target), "/")
+	candidates := []string{target, g.module.Path + "/" + strings.TrimPrefix(target, "./")}
+	if target == "." || target == "./" {
+		candidates = append(candidates, g.module.Path)
+	}
+	for _, c := range candidates {
+		if _, ok := g.pkgs[c]; ok {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// imports splits the imports of the package at path into main module
+// packages, external packages, and standard library packages
+func (g *depsGraph) imports(path string) (internal, external, std []string) {
+	for imp, dep := range g.pkgs[path].Imports {
+		switch {
+		case g.inModule(imp):
+			internal = append(internal, g.short(imp))
+		case dep.Module == nil:
+			std = append(std, imp)
+		default:
+			external = append(external, imp)
+		}
+	}
+	sort.Strings(internal)
+	sort.Strings(external)
+	sort.Strings(std)
+	return internal, external, std
+}
+
+// externalModules returns the external modules the packages at paths need,
+// directly or through other packages, as "path version" lines
+func (g *depsGraph) externalModules(paths []string) []string {
+	modules := make(map[string]string)
+	seen := make(map[string]bool)
+	var walk func(pkg *packages.Package)
+	walk = func(pkg *packages.Package) {
+		if seen[pkg.PkgPath] {
+			return
+		}
+		seen[pkg.PkgPath] = true
+		if m := pkg.Module; m != nil && !m.Main {
+			version := m.Version
+			if m.Replace != nil {
+				version += " => " + strings.TrimSpace(m.Replace.Path+" "+m.Replace.Version)
+			}
+			modules[m.Path] = version
+		}
+		for _, dep := range pkg.Imports {
+			walk(dep)
+		}
+	}
+	for _, path := range paths {
+		walk(g.pkgs[path])
+	}
+	lines := make([]string, 0, len(modules))
+	for path, version := range modules {
+		lines = append(lines, strings.TrimSpace(path+" "+version))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// moduleReport lists the main module's packages with the module packages
+// each imports, then the external modules the module needs
+func (g *depsGraph) moduleReport() string {
+	paths := make([]string, 0, len(g.pkgs))
+	for path := range g.pkgs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "module %s", g.module.Path)
+	if g.module.GoVersion != "" {
+		fmt.Fprintf(&b, " (go %s)", g.module.GoVersion)
+	}
+	fmt.Fprintf(&b, "\n\n## Packages (%d)\n", len(paths))
+	for _, path := range paths {
+		internal, external, _ := g.imports(path)
+		fmt.Fprintf(&b, "- %s", g.short(path))
+		if len(internal) > 0 {
+			fmt.Fprintf(&b, " -> %s", strings.Join(internal, ", "))
+		}
+		if n := len(g.importers[path]); n > 0 {
+			fmt.Fprintf(&b, " [imported by %d]", n)
+		}
+		if len(external) > 0 {
+			fmt.Fprintf(&b, " [%d external]", len(external))
+		}
+		b.WriteString("\n")
+	}
+	writeDepsList(&b, "External modules", g.externalModules(paths))
+	return b.String()
+}
+
+// packageReport describes the package at path: its imports, the packages
+// that depend on it, and the external modules under it
+func (g *depsGraph) packageReport(path string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s (%s)\n", path, g.short(path))
+
+	internal, external, std := g.imports(path)
+	b.WriteString("\n## Imports\n")
+	if len(internal)+len(external)+len(std) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for _, group := range []struct {
+		name string
+		list []string
+	}{{"module", internal}, {"external", external}, {"stdlib", std}} {
+		if len(group.list) > 0 {
+			fmt.Fprintf(&b, "%s: %s\n", group.name, strings.Join(group.list, ", "))
+		}
+	}
+
+	// Everything that would need rebuilding, and retesting, after a change
+	direct := g.importers[path]
+	seen := map[string]bool{path: true}
+	queue := append([]string(nil), direct...)
+	var all []string
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		all = append(all, p)
+		queue = append(queue, g.importers[p]...)
+	}
+	isDirect := make(map[string]bool, len(direct))
+	for _, p := range direct {
+		isDirect[p] = true
+	}
+	var directNames, indirectNames []string
+	for _, p := range all {
+		if isDirect[p] {
+			directNames = append(directNames, g.short(p))
+		} else {
+			indirectNames = append(indirectNames, g.short(p))
+		}
+	}
+	sort.Strings(directNames)
+	sort.Strings(indirectNames)
+	fmt.Fprintf(&b, "\n## Imported by (%d direct, %d total)\n", len(directNames), len(all))
+	if len(all) == 0 {
+		b.WriteString("(nothing in the module)\n")
+	}
+	if len(directNames) > 0 {
+		fmt.Fprintf(&b, "direct: %s\n", strings.Join(directNames, ", "))
+	}
+	if len(indirectNames) > 0 {
+		fmt.Fprintf(&b, "indirect: %s\n", strings.Join(indirectNames, ", "))
+	}
+
+	writeDepsList(&b, "External modules", g.externalModules([]string{path}))
+	return b.String()
+}
+
+// writeDepsList writes a titled list, if it has entries
+func writeDepsList(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s (%d)\n", title, len(lines))
+	for _, line := range lines {
+		fmt.Fprintf(b, "- %s\n", line)
+	}
+}
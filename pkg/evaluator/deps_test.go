@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newDepsRepo(t *testing.T) string {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                 "module example.com/app\n\ngo 1.21\n\nrequire example.com/lib v0.1.0\n\nreplace example.com/lib => ./third_party/lib\n",
+		"main.go":                "package main\n\nimport _ \"example.com/app/api\"\n\nfunc main() {}\n",
+		"api/api.go":             "package api\n\nimport (\n\t_ \"fmt\"\n\t_ \"example.com/app/store\"\n)\n",
+		"store/store.go":         "package store\n\nimport _ \"example.com/lib\"\n",
+		"third_party/lib/go.mod": "module example.com/lib\n\ngo 1.21\n",
+		"third_party/lib/lib.go": "package lib\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+func TestExecuteDeps(t *testing.T) {
+	cfg := newTestConfig(newDepsRepo(t))
+
+	audit := &testAuditLog{}
+	result := ExecuteDeps("", cfg, audit.log)
+	if !result.Success {
+		t.Fatalf("deps failed: %v", result.Error)
+	}
+	for _, want := range []string{
+		"module example.com/app (go 1.21)\n",
+		"- . -> api\n",
+		"- api -> store [imported by 1]\n",
+		"- store [imported by 1] [1 external]\n",
+		"## External modules (1)\n- example.com/lib v0.1.0 => ./third_party/lib\n",
+	} {
+		if !strings.Contains(result.Result, want) {
+			t.Errorf("module report missing %q:\n%s", want, result.Result)
+		}
+	}
+	if len(audit.entries) != 1 || !strings.Contains(audit.entries[0].errMsg, "packages:3") {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+
+	result = ExecuteDeps("store", cfg, nil)
+	if !result.Success {
+		t.Fatalf("deps store failed: %v", result.Error)
+	}
+	for _, want := range []string{
+		"package example.com/app/store (store)\n",
+		"external: example.com/lib\n",
+		"## Imported by (1 direct, 2 total)\ndirect: api\nindirect: .\n",
+	} {
+		if !strings.Contains(result.Result, want) {
+			t.Errorf("package report missing %q:\n%s", want, result.Result)
+		}
+	}
+
+	if result := ExecuteDeps("missing", cfg, nil); result.Success || !strings.Contains(result.Error.Error(), "DEPS_FAILED") {
+		t.Errorf("unknown package: %+v", result)
+	}
+}
+
+func TestExecuteDeps_NoModule(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	result := ExecuteDeps("", cfg, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "DEPS_FAILED") {
+		t.Errorf("result = %+v, want DEPS_FAILED", result)
+	}
+}
@@ -0,0 +1,38 @@
+package evaluator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+)
+
+// clock returns the time, or config.DeterministicTime when cfg asks for
+// deterministic output
+func clock(cfg *config.Config) time.Time {
+	if cfg.Deterministic {
+		return config.DeterministicTime
+	}
+	return time.Now()
+}
+
+// newCorrelationID returns a random correlation ID, or the next in
+// sequence in a deterministic session
+func (e *Executor) newCorrelationID() string {
+	if !e.config.Deterministic {
+		return telemetry.NewCorrelationID()
+	}
+	e.idsIssued++
+	return fmt.Sprintf("%016x", e.idsIssued)
+}
+
+// pinDurations zeroes how long a command and its steps took, which
+// differs from run to run
+func pinDurations(result *scanner.ExecutionResult) {
+	result.ExecutionTime = 0
+	for i := range result.Steps {
+		pinDurations(&result.Steps[i])
+	}
+}
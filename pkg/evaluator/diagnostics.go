@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// buildDiagnosticsLimit is the most diagnostics the summary of an exec
+// result lists
+const buildDiagnosticsLimit = 20
+
+// diagnostic is one problem a compiler or linter reported
+type diagnostic struct {
+	File     string
+	Line     int
+	Column   int // 0 if the tool gave none
+	Severity string
+	Message  string
+}
+
+// String formats the diagnostic as file:line:col severity message
+func (d diagnostic) String() string {
+	pos := fmt.Sprintf("%s:%d", d.File, d.Line)
+	if d.Column > 0 {
+		pos += fmt.Sprintf(":%d", d.Column)
+	}
+	return fmt.Sprintf("%s %s %s", pos, d.Severity, d.Message)
+}
+
+// Diagnostic lines of each build tool
+var (
+	// ./store/store.go:12:2: undefined: x
+	goDiagnostic = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+	// src/app.ts(3,7): error TS2322: ...
+	tscDiagnostic = regexp.MustCompile(`^(\S+?)\((\d+),(\d+)\): (error|warning) (TS\d+: .+)$`)
+	// src/app.ts:3:7 - error TS2322: ... (with --pretty)
+	tscPrettyDiagnostic = regexp.MustCompile(`^(\S+?):(\d+):(\d+) - (error|warning) (TS\d+: .+)$`)
+	// error[E0425]: cannot find value `x` in this scope
+	cargoHeader = regexp.MustCompile(`^(error|warning)(?:\[(\w+)\])?: (.+)$`)
+	//  --> src/main.rs:2:5
+	cargoLocation = regexp.MustCompile(`^\s*--> (\S+?):(\d+):(\d+)$`)
+)
+
+// buildTool names the build tool a command runs, if it is one whose
+// diagnostics can be read: "go", "tsc", or "cargo"
+func buildTool(command string) string {
+	fields := strings.Fields(command)
+	for i, f := range fields {
+		next := ""
+		if i+1 < len(fields) {
+			next = fields[i+1]
+		}
+		switch f {
+		case "go":
+			switch next {
+			case "build", "vet", "test", "run", "install":
+				return "go"
+			}
+		case "tsc":
+			return "tsc"
+		case "cargo":
+			switch next {
+			case "build", "check", "test", "run", "clippy":
+				return "cargo"
+			}
+		}
+	}
+	return ""
+}
+
+// parseBuildDiagnostics reads the diagnostics tool printed in output. Paths
+// are made relative to the repository at root, those the tool printed
+// relative to its working directory dir (relative to root) included.
+func parseBuildDiagnostics(tool, output, root, dir string) []diagnostic {
+	var diags []diagnostic
+	seen := make(map[string]bool)
+	add := func(file, line, column, severity, message string) {
+		d := diagnostic{File: repoRelativePath(file, root), Severity: severity, Message: strings.TrimSpace(message)}
+		if dir != "" && !filepath.IsAbs(file) {
+			d.File = path.Join(filepath.ToSlash(dir), d.File)
+		}
+		d.File = path.Clean(d.File)
+		d.Line, _ = strconv.Atoi(line)
+		d.Column, _ = strconv.Atoi(column)
+		if key := d.String(); !seen[key] {
+			seen[key] = true
+			diags = append(diags, d)
+		}
+	}
+
+	var pending []string // Severity, code, and message of a cargo header awaiting its location
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch tool {
+		case "go":
+			if m := goDiagnostic.FindStringSubmatch(line); m != nil {
+				add(m[1], m[2], m[3], "error", m[4])
+			}
+		case "tsc":
+			if m := tscDiagnostic.FindStringSubmatch(line); m != nil {
+				add(m[1], m[2], m[3], m[4], m[5])
+			} else if m := tscPrettyDiagnostic.FindStringSubmatch(line); m != nil {
+				add(m[1], m[2], m[3], m[4], m[5])
+			}
+		case "cargo":
+			if m := cargoHeader.FindStringSubmatch(line); m != nil {
+				pending = m[1:]
+			} else if m := cargoLocation.FindStringSubmatch(line); m != nil && pending != nil {
+				message := pending[2]
+				if pending[1] != "" {
+					message = pending[1] + ": " + message
+				}
+				add(m[1], m[2], m[3], pending[0], message)
+				pending = nil
+			}
+		}
+	}
+	return diags
+}
+
+// formatBuildDiagnostics renders diagnostics as the section that leads an
+// exec result: a count by severity, then one diagnostic per line, errors
+// first, up to buildDiagnosticsLimit
+func formatBuildDiagnostics(diags []diagnostic) string {
+	var errs, warnings []diagnostic
+	for _, d := range diags {
+		if d.Severity == "error" {
+			errs = append(errs, d)
+		} else {
+			warnings = append(warnings, d)
+		}
+	}
+	var counts []string
+	if n := len(errs); n > 0 {
+		counts = append(counts, pluralize(n, "error"))
+	}
+	if n := len(warnings); n > 0 {
+		counts = append(counts, pluralize(n, "warning"))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "DIAGNOSTICS (%s):\n", strings.Join(counts, ", "))
+	for i, d := range append(errs, warnings...) {
+		if i == buildDiagnosticsLimit {
+			fmt.Fprintf(&b, "(%d more not shown)\n", len(diags)-i)
+			break
+		}
+		b.WriteString(d.String() + "\n")
+	}
+	return b.String()
+}
+
+// pluralize returns n and noun, with an s unless n is 1
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
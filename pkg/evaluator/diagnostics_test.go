@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildTool(t *testing.T) {
+	for command, want := range map[string]string{
+		"go build ./...":               "go",
+		"go test -run TestX ./pkg/...": "go",
+		"go mod tidy":                  "",
+		"npx tsc --noEmit":             "tsc",
+		"cd web && tsc -p .":           "tsc",
+		"cargo check":                  "cargo",
+		"cargo fmt":                    "",
+		"ls -la":                       "",
+	} {
+		if got := buildTool(command); got != want {
+			t.Errorf("buildTool(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+func TestParseBuildDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		tool   string
+		dir    string
+		output string
+		want   []string
+	}{
+		{
+			name: "go build",
+			tool: "go",
+			output: `# example.com/app/store
+store/store.go:12:2: declared and not used: x
+store/store.go:15:9: undefined: fetch
+note: module requires Go 1.23
+`,
+			want: []string{
+				"store/store.go:12:2 error declared and not used: x",
+				"store/store.go:15:9 error undefined: fetch",
+			},
+		},
+		{
+			name:   "go vet in a subdirectory",
+			tool:   "go",
+			dir:    "services/api",
+			output: "# example.com/api\nvet: ./main.go:9:2: fmt.Printf format %d has arg s of wrong type string\n",
+			want:   []string{"services/api/main.go:9:2 error fmt.Printf format %d has arg s of wrong type string"},
+		},
+		{
+			name: "tsc",
+			tool: "tsc",
+			output: `src/app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.
+src/util.ts:10:1 - warning TS6133: 'x' is declared but its value is never read.
+
+10 const x = 1;
+   ~~~~~~~
+
+Found 2 errors in 2 files.
+`,
+			want: []string{
+				"src/app.ts:3:7 error TS2322: Type 'string' is not assignable to type 'number'.",
+				"src/util.ts:10:1 warning TS6133: 'x' is declared but its value is never read.",
+			},
+		},
+		{
+			name: "cargo",
+			tool: "cargo",
+			output: `   Compiling app v0.1.0 (/workspace)
+warning: unused variable: ` + "`y`" + `
+ --> src/main.rs:3:9
+  |
+3 |     let y = 2;
+  |         ^ help: if this is intentional, prefix it with an underscore: ` + "`_y`" + `
+
+error[E0425]: cannot find value ` + "`x`" + ` in this scope
+ --> /workspace/src/main.rs:2:5
+  |
+2 |     x
+  |     ^ not found in this scope
+
+error: could not compile ` + "`app`" + ` (bin "app") due to 1 previous error
+`,
+			want: []string{
+				"src/main.rs:3:9 warning unused variable: `y`",
+				"src/main.rs:2:5 error E0425: cannot find value `x` in this scope",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range parseBuildDiagnostics(tt.tool, tt.output, "/repo", tt.dir) {
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diagnostics =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestFormatBuildDiagnostics(t *testing.T) {
+	diags := []diagnostic{
+		{File: "a.rs", Line: 3, Column: 9, Severity: "warning", Message: "unused"},
+		{File: "a.rs", Line: 2, Column: 5, Severity: "error", Message: "not found"},
+	}
+	want := "DIAGNOSTICS (1 error, 1 warning):\na.rs:2:5 error not found\na.rs:3:9 warning unused\n"
+	if got := formatBuildDiagnostics(diags); got != want {
+		t.Errorf("section =\n%s\nwant\n%s", got, want)
+	}
+
+	var many []diagnostic
+	for i := 0; i < buildDiagnosticsLimit+3; i++ {
+		many = append(many, diagnostic{File: "a.go", Line: i + 1, Severity: "error", Message: "bad"})
+	}
+	if got := formatBuildDiagnostics(many); !strings.HasSuffix(got, "(3 more not shown)\n") {
+		t.Errorf("long section ends:\n%s", got[len(got)-60:])
+	}
+}
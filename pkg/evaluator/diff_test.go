@@ -0,0 +1,153 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "identical",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "single change",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "addition at end",
+			old:  "a\n",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -1,1 +1,2 @@\n a\n+b\n",
+		},
+		{
+			name: "from empty",
+			old:  "",
+			new:  "a\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name: "to empty",
+			old:  "a\nb\n",
+			new:  "",
+			want: "--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "missing final newline",
+			old:  "a\n",
+			new:  "a",
+			want: "--- old\n+++ new\n@@ -1,1 +1,1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("old", "new", tt.old, tt.new); got != tt.want {
+				t.Errorf("UnifiedDiff:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d", i))
+		newLines = append(newLines, fmt.Sprintf("line %d", i))
+	}
+	newLines[1] = "changed 2"
+	newLines[17] = "changed 18"
+
+	got := UnifiedDiff("old", "new", strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n")
+
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Fatalf("expected 2 hunks, got %d:\n%s", n, got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@") {
+		t.Errorf("unexpected first hunk header:\n%s", got)
+	}
+	if !strings.Contains(got, "@@ -15,6 +15,6 @@") {
+		t.Errorf("unexpected second hunk header:\n%s", got)
+	}
+}
+
+func TestUnifiedDiff_MergesNearbyChanges(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	new := "1\nX\n3\n4\n5\n6\nY\n8\n9\n10\n"
+
+	got := UnifiedDiff("old", "new", old, new)
+	if n := strings.Count(got, "@@ -"); n != 1 {
+		t.Errorf("expected nearby changes in one hunk, got %d:\n%s", n, got)
+	}
+}
+
+func TestDiffLines_RoundTrip(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"a\nb\nc\nd\n", "b\nc\nx\nd\ne\n"},
+		{"x\ny\nz\n", "a\nb\nc\n"},
+		{"same\n", "same\n"},
+		{"", "new\nlines\n"},
+		{"a\nb\na\nb\na\n", "b\na\nb\na\nb\n"},
+	}
+
+	for _, tt := range tests {
+		ops := diffLines(splitLines(tt.a), splitLines(tt.b))
+		var gotA, gotB strings.Builder
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA.WriteString(op.line)
+			}
+			if op.kind != '-' {
+				gotB.WriteString(op.line)
+			}
+		}
+		if gotA.String() != tt.a || gotB.String() != tt.b {
+			t.Errorf("diff of %q -> %q does not reproduce inputs: %q, %q", tt.a, tt.b, gotA.String(), gotB.String())
+		}
+	}
+}
+
+func TestDiffLines_Minimal(t *testing.T) {
+	ops := diffLines(splitLines("a\nb\nc\nd\ne\n"), splitLines("a\nc\nd\nx\ne\n"))
+
+	changes := 0
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changes++
+		}
+	}
+	if changes != 2 {
+		t.Errorf("expected 2 changed lines (delete b, add x), got %d: %+v", changes, ops)
+	}
+}
+
+func TestDiffStat(t *testing.T) {
+	tests := []struct {
+		old, new       string
+		added, removed int
+	}{
+		{"a\nb\n", "a\nb\n", 0, 0},
+		{"", "a\nb\n", 2, 0},
+		{"a\nb\nc\n", "a\nx\nc\nd\n", 2, 1},
+		{"a\nb\n", "", 0, 2},
+	}
+	for _, tt := range tests {
+		added, removed := DiffStat(tt.old, tt.new)
+		if added != tt.added || removed != tt.removed {
+			t.Errorf("DiffStat(%q, %q) = +%d -%d, want +%d -%d", tt.old, tt.new, added, removed, tt.added, tt.removed)
+		}
+	}
+}
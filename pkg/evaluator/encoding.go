@@ -0,0 +1,203 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// DecodeContent decodes the content of an encoded write. Whitespace is
+// ignored so long payloads can be wrapped across lines.
+func DecodeContent(encoding, content string) ([]byte, error) {
+	switch encoding {
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 content: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q (supported: base64)", encoding)
+	}
+}
+
+// ExecuteEncodedWrite handles a "write" command whose content is encoded,
+// such as <write logo.png encoding=base64>. The content is decoded and the
+// bytes written as is: size limits apply to the decoded bytes, and no
+// formatting, syntax checking, or line ending conversion takes place.
+func ExecuteEncodedWrite(filePath, encoding, content string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeEncodedWrite(context.Background(), filePath, encoding, content, "", cfg, auditLog, pool)
+}
+
+// executeEncodedWrite is ExecuteEncodedWrite as part of the trace in ctx,
+// giving the file mode as executeWrite does
+func executeEncodedWrite(ctx context.Context, filePath, encoding, content, mode string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "write", Argument: filePath, Content: content, Encoding: encoding, Mode: mode},
+	}
+
+	// Validate the path
+	safePath, err := sandbox.ValidateHostPath(filePath, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		result.Success = false
+		fullError := errors.Wrap(errors.PathSecurity, err)
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	// Validate file extension
+	if err := sandbox.ValidateWriteExtension(filePath, cfg.AllowedExtensions); err != nil {
+		result.Success = false
+		fullError := errors.Wrap(errors.ExtensionDenied, err)
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	data, err := DecodeContent(encoding, content)
+	if err != nil {
+		result.Success = false
+		fullError := errors.Wrap(errors.EncodingError, err)
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	// Check decoded size
+	if int64(len(data)) > cfg.MaxWriteSize {
+		result.Success = false
+		fullError := errors.Newf(errors.ResourceLimit, "decoded content too large (%d bytes, max %d)",
+			len(data), cfg.MaxWriteSize)
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	// Check if file exists
+	var backupPath string
+	fileExists := false
+	info, err := os.Stat(safePath)
+	if err != nil {
+		info = nil
+	}
+	fileMode, err := writeFileMode(mode, info, cfg)
+	if err != nil {
+		result.Success = false
+		result.Error = SanitizeError(err) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, false, err.Error()) // Full error to audit
+		}
+		return result
+	}
+	if info != nil {
+		fileExists = true
+		result.Action = "UPDATED"
+
+		// As for text writes, identical content is left alone, and an
+		// append-only file must keep its lines
+		if current, err := os.ReadFile(safePath); err == nil {
+			if bytes.Equal(current, data) && info.Mode().Perm() == fileMode {
+				result.Success = true
+				result.Action = "UNCHANGED"
+				result.ContentType = http.DetectContentType(data)
+				result.ExecutionTime = time.Since(startTime)
+				if auditLog != nil {
+					auditLog("write", filePath, true, fmt.Sprintf("hash:%s,bytes:0,encoding:%s,action:unchanged", CalculateContentHash(string(data)), encoding))
+				}
+				return result
+			}
+			if err := checkAppendOnly(safePath, current, string(data), cfg); err != nil {
+				result.Success = false
+				result.Error = SanitizeError(err) // Sanitized for LLM
+				result.ExecutionTime = time.Since(startTime)
+				if auditLog != nil {
+					auditLog("write", filePath, false, err.Error()) // Full error to audit
+				}
+				return result
+			}
+		}
+
+		if cfg.BackupBeforeWrite {
+			backupPath, err = NewBackupManager(cfg).Create(safePath)
+			if err != nil {
+				result.Success = false
+				fullError := errors.Wrap(errors.BackupFailed, err)
+				result.Error = SanitizeError(fullError) // Sanitized for LLM
+				result.ExecutionTime = time.Since(startTime)
+				if auditLog != nil {
+					auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+				}
+				return result
+			}
+			result.BackupFile = backupPath
+		}
+	} else {
+		result.Action = "CREATED"
+	}
+
+	err = sandbox.WriteBytesInContainerPooled(ctx, pool, safePath, data, cfg.RepositoryRoot, fileMode)
+	if err != nil {
+		result.Success = false
+		fullError := errors.Wrap(errors.WriteContainer, err)
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if fileExists {
+		sandbox.RestoreOwner(safePath, info)
+	}
+
+	result.Success = true
+	result.BytesWritten = int64(len(data))
+	result.ContentType = http.DetectContentType(data)
+	result.ExecutionTime = time.Since(startTime)
+
+	auditMsg := fmt.Sprintf("hash:%s,bytes:%d,encoding:%s", CalculateContentHash(string(data)), result.BytesWritten, encoding)
+	if fileExists {
+		auditMsg += ",action:updated"
+	} else {
+		auditMsg += ",action:created"
+	}
+	if backupPath != "" {
+		auditMsg += fmt.Sprintf(",backup:%s", filepath.Base(backupPath))
+	}
+	if mode != "" {
+		auditMsg += fmt.Sprintf(",mode:%04o", fileMode)
+	}
+
+	if auditLog != nil {
+		auditLog("write", filePath, true, auditMsg)
+	}
+
+	return result
+}
@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestDecodeContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		content  string
+		want     []byte
+		wantErr  bool
+	}{
+		{"base64", "base64", "aGVsbG8=", []byte("hello"), false},
+		{"wrapped lines", "base64", "\n  aGVs\n  bG8=\n", []byte("hello"), false},
+		{"binary", "base64", "AAH/", []byte{0x00, 0x01, 0xff}, false},
+		{"invalid", "base64", "not base64!", nil, true},
+		{"unknown encoding", "hex", "00ff", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeContent(tt.encoding, tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("DecodeContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteEncodedWrite_InvalidContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	audit := &testAuditLog{}
+
+	result := ExecuteEncodedWrite("data.txt", "base64", "%%%", cfg, audit.log, nil)
+
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if !strings.HasPrefix(result.Error.Error(), "ENCODING_ERROR") {
+		t.Errorf("expected ENCODING_ERROR, got %v", result.Error)
+	}
+	entries := audit.getEntries()
+	if len(entries) != 1 || entries[0].success {
+		t.Errorf("audit entries = %+v, want one failed write", entries)
+	}
+}
+
+func TestExecuteEncodedWrite_SizeLimitAppliesToDecodedBytes(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxWriteSize = 4
+
+	// 8 base64 characters decode to 6 bytes
+	result := ExecuteEncodedWrite("data.txt", "base64", "aGVsbG8h", cfg, nil, nil)
+	if result.Success || !strings.Contains(result.Error.Error(), "RESOURCE_LIMIT") {
+		t.Fatalf("expected RESOURCE_LIMIT, got success=%v err=%v", result.Success, result.Error)
+	}
+
+	// 4 base64 characters decode to 3 bytes, under the limit even though
+	// the encoded form is not
+	cfg.MaxWriteSize = 3
+	result = ExecuteEncodedWrite("data.txt", "base64", "aGVs", cfg, nil, nil)
+	if result.Error != nil && strings.Contains(result.Error.Error(), "RESOURCE_LIMIT") {
+		t.Errorf("decoded content within limit was rejected: %v", result.Error)
+	}
+}
+
+func TestExecuteEncodedWrite_PathSecurity(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+
+	result := ExecuteEncodedWrite(".env", "base64", "aGVsbG8=", cfg, nil, nil)
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "PATH_SECURITY") {
+		t.Errorf("expected PATH_SECURITY, got success=%v err=%v", result.Success, result.Error)
+	}
+}
+
+func TestExecuteEncodedWrite_UnchangedContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "data.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := ExecuteEncodedWrite("data.txt", "base64", "aGVsbG8=", cfg, nil, nil)
+	if !result.Success || result.Action != "UNCHANGED" || result.BackupFile != "" {
+		t.Errorf("success=%v action=%q backup=%q err=%v, want UNCHANGED without backup", result.Success, result.Action, result.BackupFile, result.Error)
+	}
+}
+
+func TestExecute_EncodedWrite(t *testing.T) {
+	if sandbox.CheckDockerAvailability() != nil {
+		t.Skip("Docker not available")
+	}
+
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	result := executor.Execute(scanner.Command{Type: "write", Argument: "fixture.txt", Encoding: "base64", Content: "AAEC/w=="})
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if result.BytesWritten != 4 {
+		t.Errorf("BytesWritten = %d, want 4", result.BytesWritten)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "fixture.txt"))
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0x00, 0x01, 0x02, 0xff}) {
+		t.Errorf("fixture.txt = %v, want decoded bytes", data)
+	}
+}
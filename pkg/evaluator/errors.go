@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 )
 
 // SanitizeError removes sensitive information from error messages
@@ -30,6 +32,11 @@ func SanitizeError(err error) error {
 	// After:  "permission denied"
 	msg = sanitizeUserInfo(msg)
 
+	// Keep the code, so callers can still tell what failed. The cause is
+	// dropped, as its message is what was just sanitized.
+	if code := errors.CodeOf(err); code != "" {
+		return errors.New(code, strings.TrimPrefix(msg, string(code)+": "))
+	}
 	return fmt.Errorf("%s", msg)
 }
 
@@ -1,9 +1,14 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
 	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
@@ -11,15 +16,20 @@ import (
 
 // ExecuteExec handles the "exec" command
 func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeExec(context.Background(), cmd, cfg, auditLog, pool)
+}
+
+// executeExec is ExecuteExec as part of the trace in ctx
+func executeExec(ctx context.Context, cmd scanner.Command, cfg *config.Config, auditLog func(cmdType, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
 	startTime := time.Now()
 	result := scanner.ExecutionResult{
 		Command: cmd,
 	}
 
 	// Validate command
-	if err := sandbox.ValidateExecCommand(cmd.Argument, cfg.ExecWhitelist); err != nil {
+	if err := sandbox.ValidateExecCommandMode(cmd.Argument, cfg.ExecWhitelist, cfg.ExecValidation); err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("EXEC_VALIDATION: %w", err)
+		fullError := errors.Wrap(errors.ExecValidation, err)
 		result.Error = SanitizeError(fullError) // ← Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -28,10 +38,27 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 		return result
 	}
 
+	// Resolve the working directory within the repository
+	workDir := ""
+	if cmd.Dir != "" {
+		dir, err := execWorkDir(cmd.Dir, cfg)
+		if err != nil {
+			result.Success = false
+			fullError := errors.Wrap(errors.ExecValidation, err)
+			result.Error = SanitizeError(fullError)
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("exec", cmd.Argument, false, fullError.Error())
+			}
+			return result
+		}
+		workDir = dir
+	}
+
 	// Check Docker availability
 	if err := sandbox.CheckDockerAvailability(); err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("DOCKER_UNAVAILABLE: %w", err)
+		fullError := errors.Wrap(errors.DockerUnavailable, err)
 		result.Error = SanitizeError(fullError) // ← Sanitized
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -43,7 +70,7 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 	// Pull Docker image if needed
 	if err := sandbox.PullDockerImage(cfg.ExecContainerImage, cfg.Verbose); err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("DOCKER_IMAGE: %w", err)
+		fullError := errors.Wrap(errors.DockerImage, err)
 		result.Error = SanitizeError(fullError) // ← Sanitized
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -52,6 +79,20 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 		return result
 	}
 
+	caches, err := sandbox.ParseCacheMounts(cfg.ExecCacheMounts)
+	if err != nil {
+		result.Success = false
+		fullError := errors.Wrap(errors.ExecError, err)
+		result.Error = SanitizeError(fullError)
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("exec", cmd.Argument, false, fullError.Error())
+		}
+		return result
+	}
+
+	env, envNames := sandbox.ExecEnv(cfg.ExecEnv, cfg.ExecEnvPassthrough, os.LookupEnv)
+
 	// Configure and run container
 	containerCfg := sandbox.ContainerConfig{
 		Image:       cfg.ExecContainerImage,
@@ -61,9 +102,14 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 		CPULimit:    cfg.ExecCPULimit,
 		Timeout:     cfg.ExecTimeout,
 		Stdin:       cmd.Content, // NEW: Pass stdin content if present
+		Caches:      caches,
+		Env:         env,
+		WorkDir:     workDir,
+		Scratch:     cfg.ScratchDir,
+		ScratchMem:  cfg.Scratch && cfg.ScratchEncrypt,
 	}
 
-	containerResult, err := sandbox.RunContainer(containerCfg)
+	containerResult, err := sandbox.RunContainerContext(ctx, containerCfg)
 
 	result.Stdout = containerResult.Stdout
 	result.Stderr = containerResult.Stderr
@@ -72,12 +118,17 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 
 	if err != nil {
 		result.Success = false
-		if containerResult.ExitCode == 124 {
-			result.Error = fmt.Errorf("EXEC_TIMEOUT: command timed out after %v", cfg.ExecTimeout)
+		if errors.Is(err, sandbox.ErrTimeout) {
+			// Output written before the timeout is kept in the result
+			result.ExitCode = sandbox.TimeoutExitCode
+			result.Error = errors.Newf(errors.ExecTimeout, "command timed out after %v", cfg.ExecTimeout)
+		} else if errors.Is(err, sandbox.ErrInterrupted) {
+			result.ExitCode = sandbox.InterruptExitCode
+			result.Error = errors.New(errors.ExecInterrupted, "command stopped by interrupt")
 		} else if containerResult.ExitCode != 0 {
-			result.Error = fmt.Errorf("EXEC_FAILED: command exited with code %d", containerResult.ExitCode)
+			result.Error = errors.Newf(errors.ExecFailed, "command exited with code %d", containerResult.ExitCode)
 		} else {
-			result.Error = fmt.Errorf("EXEC_ERROR: %w", err)
+			result.Error = errors.Wrap(errors.ExecError, err)
 		}
 	} else {
 		result.Success = true
@@ -92,6 +143,16 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 		result.Result = result.Stderr
 	}
 
+	// Lead with the diagnostics of a known build tool, so the first error
+	// need not be dug out of the log
+	diagnostics := 0
+	if tool := buildTool(cmd.Argument); tool != "" {
+		if diags := parseBuildDiagnostics(tool, result.Stdout+"\n"+result.Stderr, cfg.RepositoryRoot, cmd.Dir); len(diags) > 0 {
+			result.Result = formatBuildDiagnostics(diags) + "\n" + result.Result
+			diagnostics = len(diags)
+		}
+	}
+
 	// Enhanced audit logging for exec commands
 	auditMsg := fmt.Sprintf("exit_code:%d,duration:%.3fs", result.ExitCode, result.ExecutionTime.Seconds())
 	if result.Success {
@@ -99,9 +160,19 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 	} else {
 		auditMsg += ",status:failed"
 	}
+	auditMsg += ",hash:" + sandbox.CommandHash(cmd.Argument)
 	if cmd.Content != "" {
 		auditMsg += ",stdin:provided"
 	}
+	if cmd.Dir != "" {
+		auditMsg += ",dir:" + cmd.Dir
+	}
+	if len(envNames) > 0 {
+		auditMsg += ",env:" + strings.Join(envNames, "+")
+	}
+	if diagnostics > 0 {
+		auditMsg += fmt.Sprintf(",diagnostics:%d", diagnostics)
+	}
 
 	if auditLog != nil {
 		auditLog("exec", cmd.Argument, result.Success, auditMsg)
@@ -109,3 +180,28 @@ func ExecuteExec(cmd scanner.Command, cfg *config.Config, auditLog func(cmdType,
 
 	return result
 }
+
+// execWorkDir returns the container path of an exec working directory,
+// which must be an existing directory the LLM may access
+func execWorkDir(dir string, cfg *config.Config) (string, error) {
+	fullPath, err := sandbox.ValidatePath(dir, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return "", fmt.Errorf("working directory: %w", err)
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("working directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("working directory %s is not a directory", dir)
+	}
+
+	rel, err := filepath.Rel(cfg.RepositoryRoot, fullPath)
+	if err != nil {
+		return "", fmt.Errorf("working directory: %w", err)
+	}
+	if rel == "." {
+		return "/workspace", nil
+	}
+	return sandbox.ContainerPath(rel), nil
+}
@@ -1,12 +1,17 @@
 package evaluator
 
 import (
+	"fmt"
 	"time"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
 )
 
@@ -286,6 +291,9 @@ func TestExecuteExec_Integration_Echo(t *testing.T) {
 	if !strings.Contains(entries[0].errMsg, "status:completed") {
 		t.Errorf("audit message should contain status:completed, got %q", entries[0].errMsg)
 	}
+	if hash := "hash:" + sandbox.CommandHash("echo hello world"); !strings.Contains(entries[0].errMsg, hash) {
+		t.Errorf("audit message should contain %s, got %q", hash, entries[0].errMsg)
+	}
 }
 
 func TestExecuteExec_Integration_FailingCommand(t *testing.T) {
@@ -610,3 +618,123 @@ func TestExecuteExec_AuditLogFormat(t *testing.T) {
 		t.Errorf("expected arg 'test', got %q", entries[0].arg)
 	}
 }
+
+func TestExecWorkDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "services", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "secrets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{RepositoryRoot: root, ExcludedPaths: []string{"secrets"}}
+
+	valid := map[string]string{
+		"services/api":  "/workspace/services/api",
+		"services/api/": "/workspace/services/api",
+		".":             "/workspace",
+	}
+	for dir, want := range valid {
+		got, err := execWorkDir(dir, cfg)
+		if err != nil || got != want {
+			t.Errorf("execWorkDir(%q) = %q, %v, want %q", dir, got, err, want)
+		}
+	}
+
+	for _, dir := range []string{"missing", "main.go", "../outside", "/etc", "secrets"} {
+		if got, err := execWorkDir(dir, cfg); err == nil {
+			t.Errorf("execWorkDir(%q) = %q, want error", dir, got)
+		}
+	}
+}
+
+func TestExecuteExec_InvalidWorkDir(t *testing.T) {
+	cfg := &config.Config{
+		RepositoryRoot: t.TempDir(),
+		ExecWhitelist:  []string{"go test"},
+	}
+
+	audit := &testAuditLog{}
+	cmd := scanner.Command{Type: "exec", Argument: "go test ./...", Dir: "../elsewhere"}
+	result := ExecuteExec(cmd, cfg, audit.log, nil)
+
+	if result.Success {
+		t.Fatal("expected failure for a directory outside the repository")
+	}
+	if !strings.Contains(result.Error.Error(), "EXEC_VALIDATION") || !strings.Contains(result.Error.Error(), "working directory") {
+		t.Errorf("expected EXEC_VALIDATION working directory error, got: %v", result.Error)
+	}
+	if entries := audit.getEntries(); len(entries) != 1 || entries[0].success {
+		t.Errorf("expected one failed audit entry, got %+v", entries)
+	}
+}
+
+// useFakeRunner runs exec commands on r instead of Docker until the test ends
+func useFakeRunner(t *testing.T, r *sandbox.FakeRunner) {
+	t.Helper()
+	sandbox.SetFakeBackend(&sandbox.FakeBackend{Runner: r})
+	t.Cleanup(func() { sandbox.SetFakeBackend(nil) })
+}
+
+func TestExecuteExec_FakeRunner(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	runner := &sandbox.FakeRunner{Responses: map[string]sandbox.FakeResponse{
+		"go test ./...":  {Stdout: "ok  \texample.com/app\t0.01s\n"},
+		"go vet ./...":   {Stderr: "main.go:3:2: unreachable code\n", ExitCode: 1},
+		"go build ./...": {Latency: time.Hour},
+		"go run .":       {Err: fmt.Errorf("no such image")},
+	}}
+	useFakeRunner(t, runner)
+
+	cfg := &config.Config{
+		RepositoryRoot:     root,
+		ExecWhitelist:      []string{"go test", "go vet", "go build", "go run"},
+		ExecContainerImage: "golang:1.23",
+		ExecTimeout:        20 * time.Millisecond,
+		ExecMemoryLimit:    "512m",
+		ExecCPULimit:       1,
+	}
+
+	tests := []struct {
+		name     string
+		cmd      scanner.Command
+		success  bool
+		code     errors.Code
+		exitCode int
+		output   string
+	}{
+		{"success", scanner.Command{Type: "exec", Argument: "go test ./...", Dir: "pkg"}, true, "", 0, "example.com/app"},
+		{"nonzero exit", scanner.Command{Type: "exec", Argument: "go vet ./..."}, false, errors.ExecFailed, 1, "unreachable code"},
+		{"timeout", scanner.Command{Type: "exec", Argument: "go build ./..."}, false, errors.ExecTimeout, sandbox.TimeoutExitCode, ""},
+		{"cannot run", scanner.Command{Type: "exec", Argument: "go run ."}, false, errors.ExecError, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &testAuditLog{}
+			result := ExecuteExec(tt.cmd, cfg, audit.log, nil)
+			if result.Success != tt.success || errors.CodeOf(result.Error) != tt.code || result.ExitCode != tt.exitCode {
+				t.Errorf("result = success %v, error %v, exit code %d", result.Success, result.Error, result.ExitCode)
+			}
+			if !strings.Contains(result.Result, tt.output) {
+				t.Errorf("output = %q, want it to contain %q", result.Result, tt.output)
+			}
+			if entries := audit.getEntries(); len(entries) != 1 || entries[0].success != tt.success {
+				t.Errorf("audit entries = %+v", entries)
+			}
+		})
+	}
+
+	calls := runner.Calls()
+	if len(calls) != len(tests) {
+		t.Fatalf("ran %d containers, want %d", len(calls), len(tests))
+	}
+	if calls[0].Image != "golang:1.23" || calls[0].WorkDir != "/workspace/pkg" || calls[0].RepoRoot != root || calls[0].Timeout != cfg.ExecTimeout {
+		t.Errorf("container config = %+v", calls[0])
+	}
+}
@@ -1,13 +1,24 @@
 package evaluator
 
 import (
-	"fmt"
+	"context"
+	"io"
+	"os"
 	"sync"
+	"time"
 
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/memory"
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
+	"github.com/computerscienceiscool/llm-runtime/pkg/notify"
+	"github.com/computerscienceiscool/llm-runtime/pkg/plugin"
 	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
 	"github.com/computerscienceiscool/llm-runtime/pkg/search"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Executor handles command execution
@@ -22,9 +33,31 @@ type Executor struct {
 	config      *config.Config
 	searchCfg   *search.SearchConfig
 	auditLog    func(cmd, arg string, success bool, errMsg string)
+	correlated  func(correlationID, cmd, arg string, success bool, errMsg string) // Audit log taking each command's correlation ID; nil to use auditLog
 	commandsRun int
 	mu          sync.Mutex
 	pool        *sandbox.ContainerPool
+	tracker     *FileTracker
+	quotas      *QuotaTracker
+	lastResult  *bool // Outcome of the last command outside a guard; nil before any
+	sleep       func(time.Duration)
+	builtins    map[string]string               // ${REPO_ROOT}, ${SESSION_ID} and ${DATE}
+	variables   map[string]string               // Defined with <set>
+	budgetUsed  int                             // Estimated tokens of output this turn
+	piping      int                             // Pipes running; their steps are not truncated
+	traceCtx    context.Context                 // Span of the command running, parent of its steps
+	plugins     *plugin.Registry                // Custom commands; nil for none
+	follow      io.Writer                       // Where <tail follow=...> streams appended lines; nil to refuse follow
+	repls       map[string]*sandbox.REPLSession // Interpreters started by <repl>, by language
+	coverage    map[string]coverageRun          // Last <coverage> report of each profile
+	idsIssued   int                             // Correlation IDs handed out, in a deterministic session
+	summary     func() string                   // Summary of the session that <pr> adds to descriptions; nil for none
+	scratch     *scratch.Space                  // The session's /scratch; nil for none
+	notifier    *notify.Notifier                // Posts denied commands, failed execs, and sensitive writes; nil for none
+	servers     map[string]*languageServer      // Language servers started by <def> and <refs>, by language
+	opened      map[string]string               // Hash of the content each open returned, by file and range
+	summarizer  Summarizer                      // Condenses output over the budget; nil to return it as is
+	memory      *memory.File                    // The session memory document; nil for none
 }
 
 // NewExecutor creates a new executor instance
@@ -34,39 +67,391 @@ func NewExecutor(cfg *config.Config, searchCfg *search.SearchConfig, auditLog fu
 		searchCfg: searchCfg,
 		auditLog:  auditLog,
 		pool:      pool,
+		tracker:   NewFileTracker(),
+		quotas:    NewQuotaTracker(),
+		sleep:     time.Sleep,
+		builtins:  builtinVariables(cfg.RepositoryRoot, "", clock(cfg).Format("2006-01-02")),
+		variables: make(map[string]string),
+		coverage:  make(map[string]coverageRun),
+		opened:    make(map[string]string),
 	}
 }
 
-// Execute dispatches command execution based on type
+// SetFollowOutput sets where <tail follow=...> writes lines appended to a
+// file as they arrive. Follow is refused while it is unset, since no one
+// would see the lines until the watch ended.
+func (e *Executor) SetFollowOutput(w io.Writer) {
+	e.follow = w
+}
+
+// SetCorrelatedAuditLog sets an audit log that records the correlation ID
+// of the command each entry belongs to, used instead of the one given to
+// NewExecutor
+func (e *Executor) SetCorrelatedAuditLog(log func(correlationID, cmd, arg string, success bool, errMsg string)) {
+	e.correlated = log
+}
+
+// SetContext sets the context commands run in. Canceling it, as on
+// Ctrl+C, stops the exec command or plugin running.
+func (e *Executor) SetContext(ctx context.Context) {
+	e.traceCtx = ctx
+}
+
+// SetNotifier sets where notable command results, such as commands
+// refused by policy, are posted. Without one, nothing is posted.
+func (e *Executor) SetNotifier(n *notify.Notifier) {
+	e.notifier = n
+}
+
+// Execute dispatches command execution based on type. Each command is
+// traced as a span, the steps of pipes and guards as its children, and
+// counted in the metrics. It is given a correlation ID, shared by its
+// steps, that its spans, containers, audit entries, and result carry.
 func (e *Executor) Execute(cmd scanner.Command) scanner.ExecutionResult {
+	parent := e.traceCtx
+	ctx := parent
+	correlationID := telemetry.CorrelationID(parent)
+	if correlationID == "" {
+		correlationID = e.newCorrelationID()
+		ctx = telemetry.WithCorrelationID(parent, correlationID)
+		if e.correlated != nil {
+			auditLog := e.auditLog
+			e.auditLog = func(cmdType, arg string, success bool, errMsg string) {
+				e.correlated(correlationID, cmdType, arg, success, errMsg)
+			}
+			defer func() { e.auditLog = auditLog }()
+		}
+	}
+	ctx, span := telemetry.Start(ctx, "command "+cmd.Type,
+		attribute.String("command.type", cmd.Type),
+		attribute.String("command.argument", cmd.Argument),
+	)
+	e.traceCtx = ctx
+	result := e.execute(cmd)
+	e.traceCtx = parent
+	result.CorrelationID = correlationID
+	if e.config.Deterministic {
+		pinDurations(&result)
+	}
+	e.runPostHooks(ctx, result)
+	if e.notifier != nil {
+		e.notifier.Command(result)
+	}
+
+	span.SetAttributes(resultAttributes(result)...)
+	telemetry.End(span, result.Error)
+	metrics.ObserveCommand(result)
+	return result
+}
+
+func (e *Executor) execute(cmd scanner.Command) scanner.ExecutionResult {
 	var result scanner.ExecutionResult
 
+	if cmd.ParseError != nil {
+		return e.rejectMalformed(cmd)
+	}
+	if cmd.Oversized {
+		return e.rejectOversized(cmd)
+	}
+
+	// Expand template variables before anything validates the argument
+	cmd.Argument = e.expandTemplate(cmd.Argument)
+	cmd.Dir = e.expandTemplate(cmd.Dir)
+
+	if refused := e.runPreHooks(cmd); refused != nil {
+		return *refused
+	}
+
 	switch cmd.Type {
 	case "open":
-		result = ExecuteOpen(cmd.Argument, e.config, e.auditLog, e.pool)
+		if scratch.IsPath(cmd.Argument) {
+			result = executeScratch(cmd, e.scratch, e.config, e.auditLog)
+			break
+		}
+		arg, force := splitForce(cmd.Argument)
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeOpen(e.traceCtx, arg, e.config, e.auditLog, e.pool)
+		})
+		e.trackFile(result)
+		if !e.skipRepeatOpen(&result, force) {
+			e.fitOpen(&result)
+			e.annotateTokens(&result)
+		}
+	case "open-many":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeOpenMany(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.pool)
+		})
 	case "write":
-		result = ExecuteWrite(cmd.Argument, cmd.Content, e.config, e.auditLog, e.pool)
+		if scratch.IsPath(cmd.Argument) {
+			result = executeScratch(cmd, e.scratch, e.config, e.auditLog)
+			break
+		}
+		if conflict := checkWriteConflict(cmd.Argument, e.config, e.tracker, e.auditLog); conflict != nil {
+			result = *conflict
+			break
+		}
+		if exceeded := e.checkWriteQuota(cmd); exceeded != nil {
+			result = *exceeded
+			break
+		}
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			if cmd.Encoding != "" {
+				return executeEncodedWrite(e.traceCtx, cmd.Argument, cmd.Encoding, cmd.Content, cmd.Mode, e.config, e.auditLog, e.pool)
+			}
+			return executeWrite(e.traceCtx, cmd.Argument, cmd.Content, cmd.Mode, e.config, e.auditLog, e.pool)
+		})
+		e.trackFile(result)
+		e.recordWriteQuota(result)
+	case "append":
+		// Appending keeps whatever the file holds now, so there is no
+		// conflict to check
+		if scratch.IsPath(cmd.Argument) {
+			result = executeScratch(cmd, e.scratch, e.config, e.auditLog)
+			break
+		}
+		if exceeded := e.checkWriteQuota(cmd); exceeded != nil {
+			result = *exceeded
+			break
+		}
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeAppend(e.traceCtx, cmd.Argument, cmd.Content, e.config, e.auditLog, e.pool)
+		})
+		e.trackFile(result)
+		e.recordWriteQuota(result)
 	case "exec":
-		result = ExecuteExec(cmd, e.config, e.auditLog, e.pool)
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeExec(e.traceCtx, cmd, e.config, e.auditLog, e.pool)
+		})
+	case "test":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeTest(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.pool)
+		})
+	case "lint":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeLint(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.pool)
+		})
+	case "coverage":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeCoverage(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.coverage)
+		})
+	case "unzip":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeUnzip(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.guards())
+		})
+	case "archive":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeArchive(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.guards())
+		})
+	case "fetch":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeFetch(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.guardedWrite)
+		})
+	case "sql":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeSQL(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "git-branch":
+		result = executeGitBranch(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+	case "git-commit":
+		// Not retried, since a commit that failed late may have landed
+		sessionID, _ := e.lookupVariable("SESSION_ID")
+		result = executeGitCommit(e.traceCtx, cmd.Argument, sessionID, e.config, e.auditLog)
+	case "pr":
+		// Not retried, since the push may have landed
+		summary := ""
+		if e.summary != nil {
+			summary = e.summary()
+		}
+		result = executePR(e.traceCtx, cmd.Argument, cmd.Content, summary, e.config, e.auditLog)
+	case "issue":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeIssue(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "repl":
+		result = e.executeREPL(cmd)
+	case "repl-reset":
+		result = e.executeREPLReset(cmd)
+	case "hash":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeHash(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "outline":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeOutline(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "overview":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeOverview(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "deps":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeDeps(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "def", "refs":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return e.executeLSP(cmd)
+		})
+	case "rename-symbol":
+		// Not retried, since some of the files may have been written
+		result = e.executeRenameSymbol(cmd)
+	case "ast-grep":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeASTGrep(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "note":
+		result = executeNote(cmd.Argument, e.memory, e.auditLog)
+	case "memory":
+		result = executeMemory(e.memory, e.auditLog)
+	case "tokens":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeTokens(e.traceCtx, cmd.Argument, e.config, e.auditLog)
+		})
+	case "tail":
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeTail(e.traceCtx, cmd.Argument, e.config, e.auditLog, e.pool, e.follow)
+		})
 	case "search":
-		result = ExecuteSearch(cmd.Argument, e.config, e.searchCfg, e.auditLog, e.pool)
+		result = e.withRetry(cmd, func() scanner.ExecutionResult {
+			return executeSearch(e.traceCtx, cmd.Argument, e.config, e.searchWithinBudget(), e.auditLog, e.pool)
+		})
+	case "pipe":
+		result = e.executePipe(cmd)
+	case "if-success", "if-failure":
+		// Guards neither count as commands nor change the outcome that
+		// later guards check
+		return e.executeGuard(cmd)
+	case "set":
+		// Neither is a variable definition
+		return e.executeSet(cmd)
 	default:
+		if p, ok := e.plugins.Lookup(cmd.Type); ok {
+			result = e.withRetry(cmd, func() scanner.ExecutionResult {
+				return e.executePlugin(cmd, p)
+			})
+			break
+		}
 		result = scanner.ExecutionResult{
 			Command: cmd,
 			Success: false,
-			Error:   fmt.Errorf("UNKNOWN_COMMAND: %s", cmd.Type),
+			Error:   errors.New(errors.UnknownCommand, cmd.Type),
 		}
 	}
 
-	if result.Success {
-		e.mu.Lock()
+	// Pipe steps are counted and charged to the budget individually as
+	// they run
+	e.recordMemory(result)
+	if cmd.Type != "pipe" {
+		e.summarizeOversize(&result)
+		e.spend(result)
+	}
+
+	e.mu.Lock()
+	if result.Success && cmd.Type != "pipe" {
 		e.commandsRun++
-		e.mu.Unlock()
 	}
+	success := result.Success
+	e.lastResult = &success
+	e.mu.Unlock()
 
 	return result
 }
 
+// resultAttributes describes the outcome of a command for its span
+func resultAttributes(result scanner.ExecutionResult) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Bool("command.success", result.Success),
+		attribute.Int("command.output_bytes", len(result.Result)),
+		attribute.Float64("command.duration_ms", float64(result.ExecutionTime.Microseconds())/1000),
+	}
+	if result.Action != "" {
+		attrs = append(attrs, attribute.String("command.action", result.Action))
+	}
+	if result.BytesWritten > 0 {
+		attrs = append(attrs, attribute.Int64("command.bytes_written", result.BytesWritten))
+	}
+	if result.Command.Type == "exec" {
+		attrs = append(attrs, attribute.Int("exec.exit_code", result.ExitCode))
+	}
+	if result.Attempts > 1 {
+		attrs = append(attrs, attribute.Int("command.attempts", result.Attempts))
+	}
+	if result.Error != nil {
+		attrs = append(attrs, attribute.String("command.error_code", errorCode(result.Error)))
+	}
+	return attrs
+}
+
+// rejectMalformed reports a command the scanner could not parse
+func (e *Executor) rejectMalformed(cmd scanner.Command) scanner.ExecutionResult {
+	fullError := errors.Newf(errors.ParseError, "line %d: %s", cmd.ParseError.Line, cmd.ParseError.Message)
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error())
+	}
+
+	success := false
+	e.mu.Lock()
+	e.lastResult = &success
+	e.mu.Unlock()
+
+	// Not sanitized: the message only echoes the LLM's own input, and tags
+	// like </write> would be mangled as paths
+	return scanner.ExecutionResult{
+		Command: cmd,
+		Success: false,
+		Error:   fullError,
+	}
+}
+
+// rejectOversized reports a command whose body the scanner discarded for
+// exceeding the maximum command size
+func (e *Executor) rejectOversized(cmd scanner.Command) scanner.ExecutionResult {
+	limit := e.config.MaxCommandSize
+	if limit <= 0 {
+		limit = config.DefaultScanBufferSize
+	}
+
+	fullError := errors.Newf(errors.CommandTooLarge, "%s body exceeds %d bytes", cmd.Type, limit)
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error())
+	}
+
+	success := false
+	e.mu.Lock()
+	e.lastResult = &success
+	e.mu.Unlock()
+
+	return scanner.ExecutionResult{
+		Command: cmd,
+		Success: false,
+		Error:   SanitizeError(fullError),
+	}
+}
+
+// trackFile records what the LLM saw after a successful open or write so
+// later writes can detect changes made on disk in between
+func (e *Executor) trackFile(result scanner.ExecutionResult) {
+	if !result.Success || result.Action == "BINARY_SUMMARY" || result.Action == "CHUNK" || result.Action == "TRUNCATED" {
+		return
+	}
+
+	path, _, _, _ := splitLineRange(result.Command.Argument)
+	safePath, err := sandbox.ValidateHostPath(path, e.config.RepositoryRoot, e.config.ExcludedPaths)
+	if err != nil {
+		// Not read back on the host if a symlink leads out of the
+		// repository
+		return
+	}
+
+	// Read back from disk so formatting and line ending changes made by the
+	// write are included
+	data, err := os.ReadFile(safePath)
+	if err != nil {
+		e.tracker.Forget(safePath)
+		return
+	}
+	e.tracker.Record(safePath, string(data))
+}
+
 // GetCommandsRun returns the number of successfully executed commands
 func (e *Executor) GetCommandsRun() int {
 	e.mu.Lock()
@@ -10,6 +10,12 @@ import (
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
 	"github.com/computerscienceiscool/llm-runtime/pkg/search"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestNewExecutor(t *testing.T) {
@@ -767,3 +773,159 @@ func BenchmarkExecutor_GetCommandsRun(b *testing.B) {
 		executor.GetCommandsRun()
 	}
 }
+
+func TestExecutor_RejectsOversizedCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxCommandSize = 1024
+	audit := &testAuditLog{}
+	executor := NewExecutor(cfg, nil, audit.log, nil)
+
+	result := executor.Execute(scanner.Command{Type: "write", Argument: "big.txt", Oversized: true})
+
+	if result.Success {
+		t.Fatal("expected oversized command to fail")
+	}
+	if !strings.HasPrefix(result.Error.Error(), "COMMAND_TOO_LARGE") {
+		t.Errorf("expected COMMAND_TOO_LARGE, got %v", result.Error)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "big.txt")); !os.IsNotExist(err) {
+		t.Error("oversized write should not create the file")
+	}
+
+	entries := audit.getEntries()
+	if len(entries) != 1 || entries[0].success {
+		t.Errorf("audit entries = %+v, want one failure", entries)
+	}
+}
+
+func TestExecutor_RejectsMalformedCommand(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	result := executor.Execute(scanner.Command{
+		Type:     "write",
+		Argument: "notes/todo.md",
+		ParseError: &scanner.ParseError{
+			Tag:     "write",
+			Line:    4,
+			Message: "<write notes/todo.md> is never closed with </write>",
+			Hint:    "end the content with </write>",
+		},
+	})
+
+	if result.Success {
+		t.Fatal("expected malformed command to fail")
+	}
+	want := "PARSE_ERROR: line 4: <write notes/todo.md> is never closed with </write>"
+	if result.Error.Error() != want {
+		t.Errorf("Error = %q, want %q", result.Error, want)
+	}
+	if executor.GetCommandsRun() != 0 {
+		t.Error("malformed command should not count as run")
+	}
+}
+
+func TestExecutor_TracesCommands(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	cfg := &config.Config{
+		RepositoryRoot: t.TempDir(),
+		ExcludedPaths:  []string{".git", ".env"},
+	}
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	result := executor.Execute(scanner.Command{
+		Type:  "pipe",
+		Steps: []scanner.Command{{Type: "open", Argument: ".env"}},
+	})
+	if result.Success {
+		t.Fatal("expected the pipe to fail")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	open, pipe := spans[0], spans[1]
+	if open.Name() != "command open" || pipe.Name() != "command pipe" {
+		t.Fatalf("unexpected span names %q, %q", open.Name(), pipe.Name())
+	}
+	if open.Parent().SpanID() != pipe.SpanContext().SpanID() {
+		t.Error("expected the step span to be a child of the pipe span")
+	}
+	if open.Status().Code != codes.Error {
+		t.Errorf("expected error status, got %v", open.Status().Code)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range open.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["command.argument"].AsString(); got != ".env" {
+		t.Errorf("expected argument .env, got %q", got)
+	}
+	if got := attrs["command.error_code"].AsString(); got != "PATH_SECURITY" {
+		t.Errorf("expected error code PATH_SECURITY, got %q", got)
+	}
+	if attrs["command.success"].AsBool() {
+		t.Error("expected command.success to be false")
+	}
+}
+
+func TestExecutor_CorrelationID(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	cfg := &config.Config{
+		RepositoryRoot: t.TempDir(),
+		ExcludedPaths:  []string{".git", ".env"},
+	}
+	var audited []string
+	executor := NewExecutor(cfg, nil, func(cmd, arg string, success bool, errMsg string) {
+		t.Errorf("uncorrelated audit entry for %s %s", cmd, arg)
+	}, nil)
+	executor.SetCorrelatedAuditLog(func(correlationID, cmd, arg string, success bool, errMsg string) {
+		audited = append(audited, correlationID)
+	})
+
+	result := executor.Execute(scanner.Command{
+		Type:  "pipe",
+		Steps: []scanner.Command{{Type: "open", Argument: ".env"}, {Type: "open", Argument: ".git/config"}},
+	})
+	id := result.CorrelationID
+	if len(id) != 16 {
+		t.Fatalf("CorrelationID = %q, want 16 hex digits", id)
+	}
+	if len(result.Steps) == 0 || result.Steps[0].CorrelationID != id {
+		t.Errorf("steps should share the pipe's correlation ID %s: %+v", id, result.Steps)
+	}
+	if len(audited) == 0 {
+		t.Fatal("expected audit entries")
+	}
+	for _, got := range audited {
+		if got != id {
+			t.Errorf("audit entry correlation ID = %q, want %q", got, id)
+		}
+	}
+	for _, span := range recorder.Ended() {
+		found := false
+		for _, kv := range span.Attributes() {
+			if kv.Key == telemetry.CorrelationAttribute && kv.Value.AsString() == id {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("span %q does not carry correlation ID %s", span.Name(), id)
+		}
+	}
+
+	if next := executor.Execute(scanner.Command{Type: "open", Argument: ".env"}); next.CorrelationID == id || next.CorrelationID == "" {
+		t.Errorf("next command's correlation ID = %q, want a new one", next.CorrelationID)
+	}
+}
@@ -0,0 +1,213 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// fetchDest matches a fetch argument ending in dest=PATH
+var fetchDest = regexp.MustCompile(`^(\S+)\s+dest=(\S+)$`)
+
+// splitFetchDest splits a fetch argument such as
+// "https://api.example.com/spec.json dest=docs/spec.json" into the URL and
+// the file to save the body to, which is empty if none is given
+func splitFetchDest(arg string) (rawURL, dest string) {
+	arg = strings.TrimSpace(arg)
+	if m := fetchDest.FindStringSubmatch(arg); m != nil {
+		return m[1], m[2]
+	}
+	return arg, ""
+}
+
+// domainAllowed reports whether host is in allowed. An entry matches its
+// host exactly; an entry such as *.example.com matches any subdomain of
+// example.com, but not example.com itself.
+func domainAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// contentTypeAllowed reports whether the media type mediaType matches one
+// of patterns. A pattern such as text/* matches any subtype, and one such
+// as application/*+json any subtype with that suffix.
+func contentTypeAllowed(mediaType string, patterns []string) bool {
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok {
+		return false
+	}
+	for _, pattern := range patterns {
+		pTyp, pSubtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(pattern)), "/")
+		if !ok || pTyp != typ {
+			continue
+		}
+		if pSubtype == "*" || pSubtype == subtype {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pSubtype, "*"); ok && strings.HasSuffix(subtype, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFetchURL parses rawURL and checks it may be fetched: http or https,
+// with no credentials, to an allowed host
+func checkFetchURL(rawURL string, allowed []string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Newf(errors.ParseError, "invalid URL: %s", rawURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Newf(errors.FetchDenied, "scheme %q not allowed (want http or https)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.Newf(errors.ParseError, "invalid URL: %s", rawURL)
+	}
+	if u.User != nil {
+		return nil, errors.New(errors.FetchDenied, "URLs with credentials are not allowed")
+	}
+	if !domainAllowed(u.Hostname(), allowed) {
+		return nil, errors.Newf(errors.FetchDenied, "domain not allowed: %s", u.Hostname())
+	}
+	return u, nil
+}
+
+// ExecuteFetch handles the "fetch" command
+func ExecuteFetch(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	write := func(ctx context.Context, dest, content string) scanner.ExecutionResult {
+		return executeWrite(ctx, dest, content, "", cfg, auditLog, pool)
+	}
+	return executeFetch(context.Background(), arg, cfg, auditLog, write)
+}
+
+// executeFetch is ExecuteFetch as part of the trace in ctx. The request is
+// made from the host, not a container, so containers keep their network
+// disabled; only hosts in FetchAllowedDomains can be reached, including
+// through redirects. The body is the result, or with dest= is written to
+// that file with write.
+func executeFetch(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), write func(ctx context.Context, dest, content string) scanner.ExecutionResult) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "fetch", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("fetch", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if len(cfg.FetchAllowedDomains) == 0 {
+		return fail(errors.New(errors.FetchDisabled, "fetch is disabled (no allowed domains configured)"))
+	}
+	rawURL, dest := splitFetchDest(arg)
+	u, err := checkFetchURL(rawURL, cfg.FetchAllowedDomains)
+	if err != nil {
+		return fail(err)
+	}
+
+	if cfg.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.FetchTimeout)
+		defer cancel()
+	}
+	var redirectErr error
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				redirectErr = errors.New(errors.FetchFailed, "too many redirects")
+			} else if _, err := checkFetchURL(req.URL.String(), cfg.FetchAllowedDomains); err != nil {
+				redirectErr = errors.Wrapf(errors.CodeOf(err), err, "redirect to %s", req.URL.Host)
+			}
+			return redirectErr
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fail(errors.Wrap(errors.FetchFailed, err))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if redirectErr != nil {
+			return fail(redirectErr)
+		}
+		return fail(errors.Wrap(errors.FetchFailed, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fail(errors.Newf(errors.FetchFailed, "%s returned %s", u.Host, resp.Status))
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return fail(errors.Newf(errors.FetchDenied, "missing or invalid content type %q", resp.Header.Get("Content-Type")))
+	}
+	if !contentTypeAllowed(mediaType, cfg.FetchContentTypes) {
+		return fail(errors.Newf(errors.FetchDenied, "content type not allowed: %s", mediaType))
+	}
+	if cfg.FetchMaxSize > 0 && resp.ContentLength > cfg.FetchMaxSize {
+		return fail(errors.Newf(errors.ResourceLimit, "response too large: %d bytes (max %d)", resp.ContentLength, cfg.FetchMaxSize))
+	}
+
+	// The length header can be missing or wrong, so the limit is also
+	// applied to what is read
+	body := io.Reader(resp.Body)
+	if cfg.FetchMaxSize > 0 {
+		body = io.LimitReader(resp.Body, cfg.FetchMaxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fail(errors.Wrap(errors.FetchFailed, err))
+	}
+	if cfg.FetchMaxSize > 0 && int64(len(data)) > cfg.FetchMaxSize {
+		return fail(errors.Newf(errors.ResourceLimit, "response too large: over %d bytes", cfg.FetchMaxSize))
+	}
+	result.ContentType = mediaType
+	detail := fmt.Sprintf("status:%d,bytes:%d,type:%s", resp.StatusCode, len(data), mediaType)
+
+	if dest != "" {
+		written := write(ctx, dest, string(data))
+		if !written.Success {
+			return fail(written.Error)
+		}
+		result.Action = written.Action
+		result.BytesWritten = written.BytesWritten
+		result.BackupFile = written.BackupFile
+		result.Result = fmt.Sprintf("Saved %d bytes (%s) to %s\n", len(data), mediaType, dest)
+		detail += ",dest:" + dest
+	} else {
+		result.Result = string(data)
+	}
+
+	result.Success = true
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("fetch", arg, true, detail)
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestDomainAllowed(t *testing.T) {
+	allowed := []string{"api.example.com", "*.docs.example.org"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.example.com", true},
+		{"API.example.com", true},
+		{"example.com", false},
+		{"evil-api.example.com", false},
+		{"v1.docs.example.org", true},
+		{"docs.example.org", false},
+		{"docs.example.org.evil.com", false},
+	}
+	for _, tt := range tests {
+		if got := domainAllowed(tt.host, allowed); got != tt.want {
+			t.Errorf("domainAllowed(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestContentTypeAllowed(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		want      bool
+	}{
+		{"application/json", true},
+		{"text/markdown", true},
+		{"application/vnd.api+json", true},
+		{"application/octet-stream", false},
+		{"image/png", false},
+	}
+	for _, tt := range tests {
+		if got := contentTypeAllowed(tt.mediaType, config.DefaultFetchContentTypes); got != tt.want {
+			t.Errorf("contentTypeAllowed(%q) = %v, want %v", tt.mediaType, got, tt.want)
+		}
+	}
+}
+
+func newFetchServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/spec.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"openapi": "3.0.0"}`))
+	})
+	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	mux.HandleFunc("/big.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://example.com/spec.json", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newFetchConfig(tmpDir string) *config.Config {
+	cfg := newTestConfig(tmpDir)
+	cfg.FetchAllowedDomains = []string{"127.0.0.1"}
+	cfg.FetchMaxSize = 1024
+	cfg.FetchContentTypes = config.DefaultFetchContentTypes
+	cfg.FetchTimeout = 5 * time.Second
+	return cfg
+}
+
+func TestExecuteFetch(t *testing.T) {
+	server := newFetchServer(t)
+	cfg := newFetchConfig(t.TempDir())
+
+	audit := &testAuditLog{}
+	result := ExecuteFetch(server.URL+"/spec.json", cfg, audit.log, nil)
+	if !result.Success {
+		t.Fatalf("fetch failed: %v", result.Error)
+	}
+	if result.Result != `{"openapi": "3.0.0"}` {
+		t.Errorf("Result = %q", result.Result)
+	}
+	if result.ContentType != "application/json" {
+		t.Errorf("ContentType = %q, want application/json", result.ContentType)
+	}
+	if len(audit.entries) != 1 || !strings.Contains(audit.entries[0].errMsg, "status:200") {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+}
+
+func TestExecuteFetch_Errors(t *testing.T) {
+	server := newFetchServer(t)
+	tests := []struct {
+		name  string
+		arg   string
+		setup func(cfg *config.Config)
+		want  string
+	}{
+		{"disabled", server.URL + "/spec.json", func(cfg *config.Config) { cfg.FetchAllowedDomains = nil }, "FETCH_DISABLED"},
+		{"domain", "https://example.com/spec.json", nil, "FETCH_DENIED"},
+		{"scheme", "file:///etc/passwd", nil, "FETCH_DENIED"},
+		{"content type", server.URL + "/logo.png", nil, "FETCH_DENIED"},
+		{"redirect", server.URL + "/away", nil, "FETCH_DENIED"},
+		{"status", server.URL + "/missing", nil, "FETCH_FAILED"},
+		{"size", server.URL + "/big.txt", nil, "RESOURCE_LIMIT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newFetchConfig(t.TempDir())
+			if tt.setup != nil {
+				tt.setup(cfg)
+			}
+			result := ExecuteFetch(tt.arg, cfg, nil, nil)
+			if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), tt.want) {
+				t.Errorf("result = %+v, want %s", result, tt.want)
+			}
+		})
+	}
+}
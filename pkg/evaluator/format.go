@@ -0,0 +1,419 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+// Formatter formats the content of a file before it is written
+type Formatter interface {
+	Format(filePath, content string) (string, error)
+}
+
+// FormatterFunc adapts a plain function to the Formatter interface
+type FormatterFunc func(filePath, content string) (string, error)
+
+// Format calls f(filePath, content)
+func (f FormatterFunc) Format(filePath, content string) (string, error) {
+	return f(filePath, content)
+}
+
+// FormatterRegistry maps file extensions to formatters
+type FormatterRegistry struct {
+	formatters map[string]Formatter
+}
+
+// NewFormatterRegistry creates an empty registry
+func NewFormatterRegistry() *FormatterRegistry {
+	return &FormatterRegistry{formatters: make(map[string]Formatter)}
+}
+
+// builtinFormatters are the built-in formatters by the name
+// commands.write.formatters uses to enable them for an extension
+var builtinFormatters = map[string]Formatter{
+	config.FormatterGo:       FormatterFunc(formatGo),
+	config.FormatterJSON:     FormatterFunc(formatJSON),
+	config.FormatterYAML:     FormatterFunc(formatYAML),
+	config.FormatterMarkdown: FormatterFunc(formatMarkdown),
+	config.FormatterSQL:      FormatterFunc(formatSQL),
+}
+
+// DefaultFormatterRegistry returns a registry with the built-in formatters
+// that are always on, for Go and JSON. The YAML, Markdown, and SQL
+// formatters rewrite more than whitespace, so they only run for the
+// extensions the config enables them for.
+func DefaultFormatterRegistry() *FormatterRegistry {
+	r := NewFormatterRegistry()
+	r.Register(".go", builtinFormatters[config.FormatterGo])
+	r.Register(".json", builtinFormatters[config.FormatterJSON])
+	return r
+}
+
+// NewFormatterRegistryFromConfig returns the default formatters with the
+// formatters from cfg.Formatters registered on top: a built-in one by
+// name, or an external command. Either replaces the default for the same
+// extension.
+func NewFormatterRegistryFromConfig(cfg *config.Config) *FormatterRegistry {
+	r := DefaultFormatterRegistry()
+	for ext, fc := range cfg.Formatters {
+		if f, ok := builtinFormatters[fc.Builtin]; ok {
+			r.Register(ext, f)
+			continue
+		}
+		if fc.Command == "" {
+			continue
+		}
+		image := fc.Image
+		if image == "" {
+			image = cfg.ExecContainerImage
+		}
+		r.Register(ext, &ExternalFormatter{
+			Command:     fc.Command,
+			Image:       image,
+			RepoRoot:    cfg.RepositoryRoot,
+			Timeout:     cfg.ExecTimeout,
+			MemoryLimit: cfg.ExecMemoryLimit,
+			CPULimit:    cfg.ExecCPULimit,
+		})
+	}
+	return r
+}
+
+// Register sets the formatter for an extension (with or without the leading
+// dot, case-insensitive)
+func (r *FormatterRegistry) Register(ext string, f Formatter) {
+	r.formatters[normalizeExtension(ext)] = f
+}
+
+// Lookup returns the formatter registered for the file's extension
+func (r *FormatterRegistry) Lookup(filePath string) (Formatter, bool) {
+	lastDot := strings.LastIndex(filePath, ".")
+	if lastDot == -1 || strings.ContainsAny(filePath[lastDot:], `/\`) {
+		return nil, false
+	}
+	f, ok := r.formatters[normalizeExtension(filePath[lastDot:])]
+	return f, ok
+}
+
+// Format formats content with the formatter for the file's extension.
+// Files without a registered formatter are returned unchanged.
+func (r *FormatterRegistry) Format(filePath, content string) (string, error) {
+	f, ok := r.Lookup(filePath)
+	if !ok {
+		return content, nil
+	}
+	return f.Format(filePath, content)
+}
+
+// normalizeExtension lowercases ext and ensures it starts with a dot
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// ExternalFormatter runs a formatter command inside a sandbox container.
+// Content is passed on stdin and the formatted result is read from stdout.
+// The placeholder {file} in Command is replaced with the quoted container
+// path of the file, for tools such as prettier that infer the parser from
+// the file name.
+type ExternalFormatter struct {
+	Command     string
+	Image       string
+	RepoRoot    string
+	Timeout     time.Duration
+	MemoryLimit string
+	CPULimit    int
+}
+
+// Format runs the formatter command in a container
+func (f *ExternalFormatter) Format(filePath, content string) (string, error) {
+	if content == "" {
+		return content, nil
+	}
+
+	relPath := filePath
+	if filepath.IsAbs(filePath) {
+		if rel, err := filepath.Rel(f.RepoRoot, filePath); err == nil {
+			relPath = rel
+		} else {
+			relPath = filepath.Base(filePath)
+		}
+	}
+	command := strings.ReplaceAll(f.Command, "{file}", shellQuote(sandbox.ContainerPath(relPath)))
+
+	result, err := sandbox.RunContainer(sandbox.ContainerConfig{
+		Image:       f.Image,
+		Command:     command,
+		RepoRoot:    f.RepoRoot,
+		MemoryLimit: f.MemoryLimit,
+		CPULimit:    f.CPULimit,
+		Timeout:     f.Timeout,
+		Stdin:       content,
+	})
+	if err != nil {
+		stderr := strings.TrimSpace(result.Stderr)
+		if stderr != "" {
+			return content, fmt.Errorf("formatter %q failed: %w: %s", f.Command, err, stderr)
+		}
+		return content, fmt.Errorf("formatter %q failed: %w", f.Command, err)
+	}
+
+	return result.Stdout, nil
+}
+
+// shellQuote wraps s in single quotes for sh -c
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// formatGo formats Go source with gofmt rules; invalid source is returned as-is
+func formatGo(_, content string) (string, error) {
+	formatted, err := format.Source([]byte(content))
+	if err != nil {
+		return content, nil
+	}
+	return string(formatted), nil
+}
+
+// formatJSON indents JSON with two spaces; invalid JSON is returned as-is
+func formatJSON(_, content string) (string, error) {
+	var jsonData interface{}
+	if err := json.Unmarshal([]byte(content), &jsonData); err != nil {
+		return content, nil
+	}
+	formatted, err := json.MarshalIndent(jsonData, "", "  ")
+	if err != nil {
+		return content, nil
+	}
+	return string(formatted), nil
+}
+
+// formatYAML re-encodes every document with two-space indentation, keeping
+// comments; invalid YAML is returned as-is
+func formatYAML(_, content string) (string, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return content, nil
+		}
+		docs = append(docs, &doc)
+	}
+	if len(docs) == 0 {
+		return content, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return content, nil
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return content, nil
+	}
+
+	return buf.String(), nil
+}
+
+// markdownFence matches the opening or closing line of a fenced code block
+var markdownFence = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+
+// formatMarkdown trims trailing whitespace and collapses runs of blank
+// lines. Two or more trailing spaces (a hard line break) are kept as two,
+// and fenced code blocks are left untouched.
+func formatMarkdown(_, content string) (string, error) {
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	inFence := false
+	fence := ""
+	blank := false
+
+	for _, line := range lines {
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			if !inFence {
+				inFence, fence = true, m[1]
+			} else if m[1] == fence {
+				inFence = false
+			}
+			out = append(out, line)
+			blank = false
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed == "" {
+			if blank {
+				continue
+			}
+			blank = true
+			out = append(out, "")
+			continue
+		}
+		blank = false
+
+		if strings.HasSuffix(strings.TrimRight(line, "\t"), "  ") {
+			trimmed += "  "
+		}
+		out = append(out, trimmed)
+	}
+
+	// Keep at most one trailing newline
+	result := strings.Join(out, "\n")
+	if strings.HasSuffix(content, "\n") {
+		result = strings.TrimRight(result, "\n") + "\n"
+	}
+	return result, nil
+}
+
+// sqlKeywords are uppercased by formatSQL
+var sqlKeywords = map[string]bool{
+	"add": true, "all": true, "alter": true, "and": true, "as": true,
+	"asc": true, "begin": true, "between": true, "by": true, "case": true,
+	"check": true, "column": true, "commit": true, "constraint": true,
+	"create": true, "cross": true, "default": true, "delete": true,
+	"desc": true, "distinct": true, "drop": true, "else": true, "end": true,
+	"exists": true, "foreign": true, "from": true, "full": true,
+	"group": true, "having": true, "if": true, "in": true, "index": true,
+	"inner": true, "insert": true, "into": true, "is": true, "join": true,
+	"key": true, "left": true, "like": true, "limit": true, "not": true,
+	"null": true, "offset": true, "on": true, "or": true, "order": true,
+	"outer": true, "primary": true, "references": true, "returning": true,
+	"right": true, "rollback": true, "select": true, "set": true,
+	"table": true, "then": true, "transaction": true, "union": true,
+	"unique": true, "update": true, "values": true, "view": true,
+	"when": true, "where": true, "with": true,
+}
+
+// formatSQL uppercases SQL keywords and trims trailing whitespace. Quoted
+// strings, quoted identifiers, dollar-quoted bodies, and comments are left
+// untouched.
+func formatSQL(_, content string) (string, error) {
+	out := make([]byte, 0, len(content))
+
+	for i := 0; i < len(content); {
+		ch := content[i]
+		switch {
+		case ch == '$' && sqlDollarTag(content[i:]) != "":
+			// PostgreSQL $tag$...$tag$ strings, such as function bodies
+			tag := sqlDollarTag(content[i:])
+			end := strings.Index(content[i+len(tag):], tag)
+			if end == -1 {
+				end = len(content) - i
+			} else {
+				end += 2 * len(tag)
+			}
+			out = append(out, content[i:i+end]...)
+			i += end
+		case ch == '\'' || ch == '"' || ch == '`':
+			end := i + 1
+			for end < len(content) {
+				if content[end] == ch {
+					// Doubled quote is an escaped quote
+					if end+1 < len(content) && content[end+1] == ch {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end < len(content) {
+				end++
+			}
+			out = append(out, content[i:end]...)
+			i = end
+		case strings.HasPrefix(content[i:], "--"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end == -1 {
+				end = len(content) - i
+			}
+			out = append(out, content[i:i+end]...)
+			i += end
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end == -1 {
+				end = len(content) - i
+			} else {
+				end += 4
+			}
+			out = append(out, content[i:i+end]...)
+			i += end
+		case isSQLIdentByte(ch):
+			end := i
+			for end < len(content) && isSQLIdentByte(content[end]) {
+				end++
+			}
+			word := content[i:end]
+			if sqlKeywords[strings.ToLower(word)] {
+				word = strings.ToUpper(word)
+			}
+			out = append(out, word...)
+			i = end
+		case ch == '\n':
+			out = append(trimTrailingBlanks(out), ch)
+			i++
+		default:
+			out = append(out, ch)
+			i++
+		}
+	}
+
+	return string(trimTrailingBlanks(out)), nil
+}
+
+// trimTrailingBlanks removes trailing spaces and tabs
+func trimTrailingBlanks(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == ' ' || b[len(b)-1] == '\t') {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// sqlDollarTag returns the dollar-quote tag s starts with, such as "$$" or
+// "$body$", or "" if it doesn't start one. Positional parameters such as
+// $1 are not tags.
+func sqlDollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		b := s[i]
+		switch {
+		case b == '$':
+			return s[:i+1]
+		case b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9' && i > 1):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// isSQLIdentByte reports whether b can be part of an unquoted SQL word
+func isSQLIdentByte(b byte) bool {
+	return b == '_' || b == '$' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
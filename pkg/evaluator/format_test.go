@@ -0,0 +1,320 @@
+package evaluator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestFormatterRegistry_Lookup(t *testing.T) {
+	r := DefaultFormatterRegistry()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", true},
+		{"data.JSON", true},
+		{"config.yml", false},
+		{"config.yaml", false},
+		{"README.md", false},
+		{"schema.sql", false},
+		{"script.py", false},
+		{"Makefile", false},
+		{"dir.d/Makefile", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := r.Lookup(tt.path); ok != tt.want {
+			t.Errorf("Lookup(%q) = %v, want %v", tt.path, ok, tt.want)
+		}
+	}
+}
+
+func TestFormatterRegistry_Register(t *testing.T) {
+	r := NewFormatterRegistry()
+	upper := FormatterFunc(func(_, content string) (string, error) {
+		return strings.ToUpper(content), nil
+	})
+
+	// Extension is normalized: no dot and mixed case still match
+	r.Register("TXT", upper)
+
+	got, err := r.Format("notes.txt", "hello")
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if got != "HELLO" {
+		t.Errorf("Format = %q, want %q", got, "HELLO")
+	}
+
+	got, _ = r.Format("notes.log", "hello")
+	if got != "hello" {
+		t.Errorf("unregistered extension should be unchanged, got %q", got)
+	}
+}
+
+func TestFormatterRegistry_PropagatesErrors(t *testing.T) {
+	r := NewFormatterRegistry()
+	r.Register(".txt", FormatterFunc(func(_, content string) (string, error) {
+		return content, errors.New("boom")
+	}))
+
+	if _, err := r.Format("a.txt", "x"); err == nil {
+		t.Error("expected formatter error to be returned")
+	}
+}
+
+func TestNewFormatterRegistryFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		RepositoryRoot:     "/repo",
+		ExecContainerImage: "python-go",
+		Formatters: map[string]config.FormatterConfig{
+			".py":   {Command: "black -q -"},
+			"RS":    {Command: "rustfmt --emit stdout", Image: "rust:1.75"},
+			".md":   {Command: "prettier --stdin-filepath {file}", Image: "node:18-alpine"},
+			".txt":  {},
+			"YML":   {Builtin: config.FormatterYAML},
+			".json": {Builtin: config.FormatterSQL},
+		},
+	}
+
+	r := NewFormatterRegistryFromConfig(cfg)
+
+	f, ok := r.Lookup("script.py")
+	if !ok {
+		t.Fatal("expected formatter for .py")
+	}
+	ext, ok := f.(*ExternalFormatter)
+	if !ok {
+		t.Fatalf("expected *ExternalFormatter, got %T", f)
+	}
+	if ext.Image != "python-go" {
+		t.Errorf("Image = %q, want exec image default", ext.Image)
+	}
+	if ext.RepoRoot != "/repo" {
+		t.Errorf("RepoRoot = %q, want /repo", ext.RepoRoot)
+	}
+
+	f, _ = r.Lookup("main.rs")
+	if ext, ok := f.(*ExternalFormatter); !ok || ext.Image != "rust:1.75" {
+		t.Errorf("expected rustfmt formatter with its own image, got %#v", f)
+	}
+
+	f, _ = r.Lookup("README.md")
+	if _, ok := f.(*ExternalFormatter); !ok {
+		t.Errorf("expected external formatter for .md, got %T", f)
+	}
+
+	// Built-ins are enabled by name, and replace the default for an
+	// extension
+	got, err := r.Format("config.yml", "a:\n    b: 1\n")
+	if err != nil || got != "a:\n  b: 1\n" {
+		t.Errorf("Format(config.yml) = %q, %v; want the YAML built-in", got, err)
+	}
+	got, err = r.Format("data.json", "select 1")
+	if err != nil || got != "SELECT 1" {
+		t.Errorf("Format(data.json) = %q, %v; want the SQL built-in", got, err)
+	}
+
+	// Entries without a command are ignored
+	if _, ok := r.Lookup("notes.txt"); ok {
+		t.Error("formatter without command should not be registered")
+	}
+
+	// Built-ins remain for other extensions
+	if _, ok := r.Lookup("main.go"); !ok {
+		t.Error("expected built-in Go formatter")
+	}
+}
+
+func TestExternalFormatter_EmptyContent(t *testing.T) {
+	// Empty content is returned without starting a container
+	f := &ExternalFormatter{Command: "false", Image: "does-not-exist"}
+	got, err := f.Format("/repo/a.py", "")
+	if err != nil || got != "" {
+		t.Errorf("Format(empty) = %q, %v", got, err)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/workspace/a.ts", "'/workspace/a.ts'"},
+		{"/workspace/it's.ts", `'/workspace/it'\''s.ts'`},
+		{"/workspace/$(rm -rf).ts", "'/workspace/$(rm -rf).ts'"},
+	}
+
+	for _, tt := range tests {
+		if got := shellQuote(tt.in); got != tt.want {
+			t.Errorf("shellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "reindents nested maps",
+			input:    "outer:\n    inner: value\n    list:\n        - a\n        - b\n",
+			expected: "outer:\n  inner: value\n  list:\n    - a\n    - b\n",
+		},
+		{
+			name:     "keeps comments",
+			input:    "# settings\nkey: value # inline\n",
+			expected: "# settings\nkey: value # inline\n",
+		},
+		{
+			name:     "multiple documents",
+			input:    "a: 1\n---\nb: 2\n",
+			expected: "a: 1\n---\nb: 2\n",
+		},
+		{
+			name:     "invalid yaml unchanged",
+			input:    "key: [unclosed",
+			expected: "key: [unclosed",
+		},
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := formatYAML("config.yaml", tt.input)
+			if err != nil {
+				t.Fatalf("formatYAML failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "trailing whitespace",
+			input:    "# Title \t\n\nText \n",
+			expected: "# Title\n\nText\n",
+		},
+		{
+			name:     "hard line break kept",
+			input:    "line one    \nline two\n",
+			expected: "line one  \nline two\n",
+		},
+		{
+			name:     "collapse blank lines",
+			input:    "a\n\n\n\nb\n\n\n",
+			expected: "a\n\nb\n",
+		},
+		{
+			name:     "fenced code untouched",
+			input:    "```go\nx := 1   \n\n\n\ny := 2\n```\n",
+			expected: "```go\nx := 1   \n\n\n\ny := 2\n```\n",
+		},
+		{
+			name:     "no trailing newline added",
+			input:    "# Markdown\n\nContent",
+			expected: "# Markdown\n\nContent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := formatMarkdown("README.md", tt.input)
+			if err != nil {
+				t.Fatalf("formatMarkdown failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "uppercase keywords",
+			input:    "select id, name from users where id in (1, 2) order by name desc;",
+			expected: "SELECT id, name FROM users WHERE id IN (1, 2) ORDER BY name DESC;",
+		},
+		{
+			name:     "string literals untouched",
+			input:    "select 'select from where' from t where a = 'it''s';",
+			expected: "SELECT 'select from where' FROM t WHERE a = 'it''s';",
+		},
+		{
+			name:     "quoted identifiers untouched",
+			input:    `select "from", ` + "`order`" + ` from t;`,
+			expected: `SELECT "from", ` + "`order`" + ` FROM t;`,
+		},
+		{
+			name:     "comments untouched",
+			input:    "-- select all\nselect * from t; /* where clause */",
+			expected: "-- select all\nSELECT * FROM t; /* where clause */",
+		},
+		{
+			name:     "trailing whitespace trimmed",
+			input:    "select *   \nfrom t\t\n",
+			expected: "SELECT *\nFROM t\n",
+		},
+		{
+			name:     "multi-line string kept",
+			input:    "insert into t values ('a   \nb');",
+			expected: "INSERT INTO t VALUES ('a   \nb');",
+		},
+		{
+			name:     "identifiers containing keywords",
+			input:    "select order_id, fromage from orders;",
+			expected: "SELECT order_id, fromage FROM orders;",
+		},
+		{
+			name:     "dollar-quoted body untouched",
+			input:    "create function f() returns int as $$ select 1 from t $$ language sql;",
+			expected: "CREATE function f() returns int AS $$ select 1 from t $$ language sql;",
+		},
+		{
+			name:     "tagged dollar quote",
+			input:    "do $body$ begin select $$x$$ where 1; end $body$;",
+			expected: "do $body$ begin select $$x$$ where 1; end $body$;",
+		},
+		{
+			name:     "positional parameters",
+			input:    "select * from t where id = $1 and name = $2",
+			expected: "SELECT * FROM t WHERE id = $1 AND name = $2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := formatSQL("query.sql", tt.input)
+			if err != nil {
+				t.Fatalf("formatSQL failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/mail"
+	"os/exec"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// metaDir is the runtime's own directory, whose backups and snapshots are
+// never committed
+const metaDir = ".llm-tools"
+
+// gitArgs come before every git command the runtime runs. Hooks are
+// disabled, so a commit cannot run code from the repository on the host,
+// and paths are taken literally rather than as patterns.
+var gitArgs = []string{"-c", "core.hooksPath=/dev/null", "--literal-pathspecs"}
+
+// commitMessage holds what a commit message template is given
+type commitMessage struct {
+	Message   string
+	Branch    string
+	SessionID string
+}
+
+// runGit runs git in dir and returns its output without the final
+// newline, or its error output as the error
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append(append([]string{"-C", dir}, gitArgs...), args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(errors.GitFailed, msg)
+		}
+		return "", errors.Wrapf(errors.GitFailed, err, "git failed")
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// branchProtected reports whether branch matches one of patterns, which
+// are branch names or path.Match patterns such as release/*
+func branchProtected(branch string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if matched, _ := path.Match(pattern, branch); matched || pattern == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// currentBranch returns the branch checked out in the repository at root
+func currentBranch(ctx context.Context, root string) (string, error) {
+	branch, err := runGit(ctx, root, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil || branch == "" {
+		return "", errors.New(errors.GitDenied, "HEAD is not on a branch; create one with <git-branch NAME> first")
+	}
+	return branch, nil
+}
+
+// ExecuteGitBranch handles the "git-branch" command
+func ExecuteGitBranch(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeGitBranch(context.Background(), arg, cfg, auditLog)
+}
+
+// executeGitBranch is ExecuteGitBranch as part of the trace in ctx. It
+// creates the branch named by arg at HEAD and checks it out, keeping
+// uncommitted changes, so later commits land on it.
+func executeGitBranch(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "git-branch", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("git-branch", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if !cfg.GitWrite {
+		return fail(errors.New(errors.GitDisabled, "git write commands are disabled"))
+	}
+	branch := strings.TrimSpace(arg)
+	if branch == "" || strings.ContainsAny(branch, " \t") {
+		return fail(errors.New(errors.ParseError, "git-branch needs one branch name"))
+	}
+	if _, err := runGit(ctx, cfg.RepositoryRoot, "check-ref-format", "--branch", branch); err != nil || strings.HasPrefix(branch, "-") {
+		return fail(errors.Newf(errors.GitDenied, "invalid branch name: %s", branch))
+	}
+	if branchProtected(branch, cfg.GitProtected) {
+		return fail(errors.Newf(errors.GitDenied, "branch %s is protected", branch))
+	}
+
+	base, err := runGit(ctx, cfg.RepositoryRoot, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return fail(errors.New(errors.GitFailed, "no commit to branch from"))
+	}
+	if _, err := runGit(ctx, cfg.RepositoryRoot, "switch", "-q", "-c", branch); err != nil {
+		return fail(err)
+	}
+
+	result.Success = true
+	result.Result = fmt.Sprintf("Created and switched to branch %s at %s\n", branch, shortSHA(base))
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("git-branch", arg, true, fmt.Sprintf("branch:%s,from:%s", branch, base))
+	}
+	return result
+}
+
+// ExecuteGitCommit handles the "git-commit" command
+func ExecuteGitCommit(arg, sessionID string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeGitCommit(context.Background(), arg, sessionID, cfg, auditLog)
+}
+
+// executeGitCommit is ExecuteGitCommit as part of the trace in ctx. It
+// commits every change under the repository root to the current branch,
+// with arg as the message through cfg.GitCommitTemplate and cfg.GitAuthor
+// as author and committer. Files the LLM cannot access, such as excluded
+// paths, and the runtime's own directory are never included.
+func executeGitCommit(ctx context.Context, arg, sessionID string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "git-commit", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("git-commit", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if !cfg.GitWrite {
+		return fail(errors.New(errors.GitDisabled, "git write commands are disabled"))
+	}
+	message := strings.TrimSpace(arg)
+	if message == "" {
+		return fail(errors.New(errors.ParseError, "git-commit needs a commit message"))
+	}
+	authorSetting, templateSetting := cfg.GitAuthor, cfg.GitCommitTemplate
+	if authorSetting == "" {
+		authorSetting = config.DefaultGitAuthor
+	}
+	if templateSetting == "" {
+		templateSetting = config.DefaultGitCommitTemplate
+	}
+	author, err := mail.ParseAddress(authorSetting)
+	if err != nil {
+		return fail(errors.Wrapf(errors.GitFailed, err, "invalid git author"))
+	}
+	branch, err := currentBranch(ctx, cfg.RepositoryRoot)
+	if err != nil {
+		return fail(err)
+	}
+	if branchProtected(branch, cfg.GitProtected) {
+		return fail(errors.Newf(errors.GitDenied, "branch %s is protected; create another with <git-branch NAME>", branch))
+	}
+
+	files, err := changedFiles(ctx, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	if len(files) == 0 {
+		return fail(errors.New(errors.GitFailed, "nothing to commit"))
+	}
+
+	tmpl, err := template.New("commit").Parse(templateSetting)
+	if err != nil {
+		return fail(errors.Wrapf(errors.GitFailed, err, "invalid commit template"))
+	}
+	var full strings.Builder
+	if err := tmpl.Execute(&full, commitMessage{Message: message, Branch: branch, SessionID: sessionID}); err != nil {
+		return fail(errors.Wrapf(errors.GitFailed, err, "invalid commit template"))
+	}
+
+	pathspec := append([]string{"--"}, files...)
+	if _, err := runGit(ctx, cfg.RepositoryRoot, append([]string{"add", "-A"}, pathspec...)...); err != nil {
+		return fail(err)
+	}
+	commit := append([]string{
+		"-c", "user.name=" + author.Name,
+		"-c", "user.email=" + author.Address,
+		"commit", "-q", "--no-verify", "--cleanup=strip", "-m", full.String(),
+	}, pathspec...)
+	if _, err := runGit(ctx, cfg.RepositoryRoot, commit...); err != nil {
+		return fail(err)
+	}
+	sha, err := runGit(ctx, cfg.RepositoryRoot, "rev-parse", "HEAD")
+	if err != nil {
+		return fail(err)
+	}
+	stat, _ := runGit(ctx, cfg.RepositoryRoot, "show", "--stat", "--format=", "HEAD")
+
+	result.Success = true
+	result.Result = fmt.Sprintf("Committed %s to %s\n%s\n", shortSHA(sha), branch, stat)
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("git-commit", arg, true, fmt.Sprintf("commit:%s,branch:%s,files:%d", sha, branch, len(files)))
+	}
+	return result
+}
+
+// changedFiles lists the created, modified, and deleted files under the
+// repository root that a commit may include, relative to the root
+func changedFiles(ctx context.Context, cfg *config.Config) ([]string, error) {
+	out, err := runGit(ctx, cfg.RepositoryRoot, "ls-files", "-z", "--modified", "--deleted", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, rel := range strings.Split(out, "\x00") {
+		// A deleted file is listed as both modified and deleted
+		if rel == "" || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		if rel == metaDir || strings.HasPrefix(rel, metaDir+"/") {
+			continue
+		}
+		if _, err := sandbox.ValidatePath(rel, cfg.RepositoryRoot, cfg.ExcludedPaths); err != nil {
+			continue
+		}
+		files = append(files, rel)
+	}
+	return files, nil
+}
+
+// shortSHA abbreviates a commit hash for display
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,108 @@
+package evaluator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+// newGitConfig returns a config for a repository on main with one commit,
+// with git write commands enabled
+func newGitConfig(t *testing.T) *config.Config {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		if _, err := runGit(context.Background(), dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	cfg := newTestConfig(dir)
+	cfg.GitWrite = true
+	cfg.GitProtected = []string{"main", "release/*"}
+	cfg.GitAuthor = "Review Bot <bot@example.com>"
+	cfg.GitCommitTemplate = config.DefaultGitCommitTemplate
+	return cfg
+}
+
+func TestBranchProtected(t *testing.T) {
+	patterns := []string{"main", "release/*"}
+	for branch, want := range map[string]bool{"main": true, "release/1.2": true, "feature/x": false, "mainline": false} {
+		if got := branchProtected(branch, patterns); got != want {
+			t.Errorf("branchProtected(%q) = %v, want %v", branch, got, want)
+		}
+	}
+}
+
+func TestExecuteGitCommit(t *testing.T) {
+	cfg := newGitConfig(t)
+	root := cfg.RepositoryRoot
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	if result := ExecuteGitCommit("Add main", "s1", cfg, nil); result.Success || !strings.Contains(result.Error.Error(), "GIT_DENIED") {
+		t.Fatalf("commit on main = %+v, want GIT_DENIED", result)
+	}
+	if result := ExecuteGitBranch("release/2.0", cfg, nil); result.Success || !strings.Contains(result.Error.Error(), "GIT_DENIED") {
+		t.Errorf("protected branch = %+v, want GIT_DENIED", result)
+	}
+	if result := ExecuteGitBranch("feature/main-func", cfg, nil); !result.Success {
+		t.Fatalf("git-branch failed: %v", result.Error)
+	}
+
+	os.WriteFile(filepath.Join(root, ".env"), []byte("TOKEN=secret\n"), 0644)
+	os.MkdirAll(filepath.Join(root, ".llm-tools", "backups"), 0755)
+	os.WriteFile(filepath.Join(root, ".llm-tools", "backups", "main.go.bak"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(root, "notes.md"), []byte("# Notes\n"), 0644)
+
+	audit := &testAuditLog{}
+	result := ExecuteGitCommit("Add main", "s1", cfg, audit.log)
+	if !result.Success {
+		t.Fatalf("git-commit failed: %v", result.Error)
+	}
+	if !strings.Contains(result.Result, "to feature/main-func") || !strings.Contains(result.Result, "2 files changed") {
+		t.Errorf("Result = %q", result.Result)
+	}
+
+	sha, _ := runGit(ctx, root, "rev-parse", "HEAD")
+	if len(audit.entries) != 1 || !strings.Contains(audit.entries[0].errMsg, "commit:"+sha) {
+		t.Errorf("audit = %+v, want commit:%s", audit.entries, sha)
+	}
+	files, _ := runGit(ctx, root, "show", "--name-only", "--format=", "HEAD")
+	if files != "main.go\nnotes.md" {
+		t.Errorf("committed %q, want main.go and notes.md only", files)
+	}
+	if who, _ := runGit(ctx, root, "show", "-s", "--format=%an <%ae>|%cn <%ce>", "HEAD"); who != "Review Bot <bot@example.com>|Review Bot <bot@example.com>" {
+		t.Errorf("author|committer = %q", who)
+	}
+	if msg, _ := runGit(ctx, root, "show", "-s", "--format=%B", "HEAD"); msg != "Add main\n\nLLM-Runtime-Session: s1" {
+		t.Errorf("message = %q", msg)
+	}
+
+	if result := ExecuteGitCommit("Again", "s1", cfg, nil); result.Success || !strings.Contains(result.Error.Error(), "nothing to commit") {
+		t.Errorf("empty commit = %+v, want nothing to commit", result)
+	}
+}
+
+func TestExecuteGit_Disabled(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	if result := ExecuteGitBranch("feature", cfg, nil); result.Error == nil || !strings.Contains(result.Error.Error(), "GIT_DISABLED") {
+		t.Errorf("git-branch = %+v, want GIT_DISABLED", result)
+	}
+	if result := ExecuteGitCommit("message", "s1", cfg, nil); result.Error == nil || !strings.Contains(result.Error.Error(), "GIT_DISABLED") {
+		t.Errorf("git-commit = %+v, want GIT_DISABLED", result)
+	}
+}
@@ -0,0 +1,98 @@
+package evaluator
+
+import (
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// executeGuard runs the commands of an <if-success> or <if-failure> block
+// when the last command outside a guard succeeded or failed respectively.
+// Otherwise every command in the block is reported as skipped. Commands
+// inside the block do not change the outcome later guards check.
+func (e *Executor) executeGuard(cmd scanner.Command) scanner.ExecutionResult {
+	result := scanner.ExecutionResult{
+		Command: cmd,
+		Success: false,
+	}
+
+	for i, step := range cmd.Steps {
+		if step.Type == "if-success" || step.Type == "if-failure" {
+			fullError := errors.Newf(errors.GuardError, "command %d: guards cannot be nested", i+1)
+			result.Error = SanitizeError(fullError) // Sanitized for LLM
+			if e.auditLog != nil {
+				e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error()) // Full error to audit
+			}
+			return result
+		}
+	}
+
+	e.mu.Lock()
+	last := e.lastResult
+	e.mu.Unlock()
+
+	met, reason := guardCondition(cmd.Type, last)
+	if !met {
+		for _, step := range cmd.Steps {
+			result.Steps = append(result.Steps, scanner.ExecutionResult{
+				Command: step,
+				Action:  "SKIPPED",
+				Result:  reason,
+			})
+		}
+		result.Success = true
+		result.Action = "CONDITION_NOT_MET"
+		result.Result = reason
+		if e.auditLog != nil {
+			e.auditLog(cmd.Type, cmd.Argument, true, "skipped: "+reason)
+		}
+		return result
+	}
+
+	failed := 0
+	for _, step := range cmd.Steps {
+		stepResult := e.Execute(step)
+		result.Steps = append(result.Steps, stepResult)
+		if !stepResult.Success {
+			failed++
+		}
+	}
+
+	// Restore the outcome the guard was evaluated against
+	e.mu.Lock()
+	e.lastResult = last
+	e.mu.Unlock()
+
+	result.Action = "CONDITION_MET"
+	if failed > 0 {
+		fullError := errors.Newf(errors.GuardFailed, "%d of %d commands failed", failed, len(cmd.Steps))
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		if e.auditLog != nil {
+			e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	result.Success = true
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, true, "")
+	}
+	return result
+}
+
+// guardCondition reports whether a guard of the given type should run
+// given the outcome of the last command, and if not, why
+func guardCondition(guardType string, last *bool) (bool, string) {
+	if last == nil {
+		return false, "no previous command"
+	}
+	if guardType == "if-success" {
+		if *last {
+			return true, ""
+		}
+		return false, "previous command failed"
+	}
+	if !*last {
+		return true, ""
+	}
+	return false, "previous command succeeded"
+}
@@ -0,0 +1,148 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestGuardCondition(t *testing.T) {
+	success, failure := true, false
+
+	tests := []struct {
+		name      string
+		guardType string
+		last      *bool
+		want      bool
+	}{
+		{"success after success", "if-success", &success, true},
+		{"success after failure", "if-success", &failure, false},
+		{"failure after failure", "if-failure", &failure, true},
+		{"failure after success", "if-failure", &success, false},
+		{"success with no previous command", "if-success", nil, false},
+		{"failure with no previous command", "if-failure", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := guardCondition(tt.guardType, tt.last)
+			if got != tt.want {
+				t.Errorf("guardCondition() = %v, want %v", got, tt.want)
+			}
+			if !got && reason == "" {
+				t.Error("expected a reason when the condition is not met")
+			}
+		})
+	}
+}
+
+func TestExecuteGuard_SkipsAfterFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	audit := &testAuditLog{}
+	executor := NewExecutor(cfg, nil, audit.log, nil)
+
+	// Writing to an excluded path fails without touching Docker
+	if result := executor.Execute(scanner.Command{Type: "write", Argument: ".git/config", Content: "x"}); result.Success {
+		t.Fatal("expected write to excluded path to fail")
+	}
+
+	result := executor.Execute(scanner.Command{
+		Type:  "if-success",
+		Steps: []scanner.Command{{Type: "write", Argument: "DONE.md", Content: "done\n"}},
+	})
+
+	if !result.Success {
+		t.Fatalf("skipped guard should succeed, got error: %v", result.Error)
+	}
+	if result.Action != "CONDITION_NOT_MET" {
+		t.Errorf("Action = %q, want CONDITION_NOT_MET", result.Action)
+	}
+	if len(result.Steps) != 1 || result.Steps[0].Action != "SKIPPED" {
+		t.Fatalf("Steps = %+v, want one SKIPPED step", result.Steps)
+	}
+	if result.Steps[0].Result != "previous command failed" {
+		t.Errorf("skip reason = %q", result.Steps[0].Result)
+	}
+
+	entries := audit.getEntries()
+	last := entries[len(entries)-1]
+	if last.cmdType != "if-success" || !strings.HasPrefix(last.errMsg, "skipped") {
+		t.Errorf("last audit entry = %+v, want skipped if-success", last)
+	}
+}
+
+func TestExecuteGuard_RunsAfterFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	executor.Execute(scanner.Command{Type: "write", Argument: ".git/config", Content: "x"})
+
+	result := executor.Execute(scanner.Command{
+		Type:  "if-failure",
+		Steps: []scanner.Command{{Type: "write", Argument: ".env", Content: "x"}},
+	})
+
+	if result.Action != "CONDITION_MET" {
+		t.Fatalf("Action = %q, want CONDITION_MET", result.Action)
+	}
+	if len(result.Steps) != 1 || result.Steps[0].Action == "SKIPPED" {
+		t.Fatalf("Steps = %+v, want one executed step", result.Steps)
+	}
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "GUARD_FAILED") {
+		t.Errorf("expected GUARD_FAILED from the failing step, got success=%v err=%v", result.Success, result.Error)
+	}
+}
+
+func TestExecuteGuard_KeepsOutcomeForLaterGuards(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	success := true
+	executor.lastResult = &success
+
+	// The failing write inside the guard must not flip the outcome
+	executor.Execute(scanner.Command{
+		Type:  "if-success",
+		Steps: []scanner.Command{{Type: "write", Argument: ".env", Content: "x"}},
+	})
+
+	result := executor.Execute(scanner.Command{
+		Type:  "if-failure",
+		Steps: []scanner.Command{{Type: "write", Argument: "FAILED.md", Content: "x"}},
+	})
+	if result.Action != "CONDITION_NOT_MET" {
+		t.Errorf("Action = %q, want CONDITION_NOT_MET", result.Action)
+	}
+}
+
+func TestExecuteGuard_NoPreviousCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	result := executor.Execute(scanner.Command{
+		Type:  "if-failure",
+		Steps: []scanner.Command{{Type: "open", Argument: "a.txt"}},
+	})
+	if result.Action != "CONDITION_NOT_MET" || result.Result != "no previous command" {
+		t.Errorf("result = %+v, want skipped with no previous command", result)
+	}
+}
+
+func TestExecuteGuard_RejectsNesting(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	result := executor.Execute(scanner.Command{
+		Type:  "if-success",
+		Steps: []scanner.Command{{Type: "if-failure"}},
+	})
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "GUARD_ERROR") {
+		t.Errorf("expected GUARD_ERROR, got success=%v err=%v", result.Success, result.Error)
+	}
+}
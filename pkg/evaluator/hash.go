@@ -0,0 +1,145 @@
+package evaluator
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// DefaultHashAlgorithm is the digest <hash> returns without algo=
+const DefaultHashAlgorithm = "sha256"
+
+// hashAlgorithms are the digests <hash> supports, by algo= name
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// hashAlgo matches a hash argument ending in algo=NAME
+var hashAlgo = regexp.MustCompile(`^(.+?)\s+algo=(\S+)$`)
+
+// CalculateContentHash calculates SHA256 hash of content
+func CalculateContentHash(content string) string {
+	digest, _ := CalculateReaderHash(strings.NewReader(content), DefaultHashAlgorithm)
+	return digest
+}
+
+// CalculateReaderHash returns the hex digest of everything read from r
+// under algo, one of md5, sha1, sha256, or sha512. The input is hashed as
+// it is read, so large files are not held in memory.
+func CalculateReaderHash(r io.Reader, algo string) (string, error) {
+	newHash, ok := hashAlgorithms[algo]
+	if !ok {
+		return "", errors.Newf(errors.ParseError, "unsupported algo=%s (want md5, sha1, sha256, or sha512)", algo)
+	}
+	h := newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// splitHashAlgo splits a hash argument such as main.go algo=sha1 into the
+// path and the algorithm, which is DefaultHashAlgorithm if none is given
+func splitHashAlgo(arg string) (path, algo string) {
+	m := hashAlgo.FindStringSubmatch(arg)
+	if m == nil {
+		return arg, DefaultHashAlgorithm
+	}
+	return m[1], strings.ToLower(m[2])
+}
+
+// ExecuteHash handles the "hash" command
+func ExecuteHash(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeHash(context.Background(), arg, cfg, auditLog)
+}
+
+// executeHash is ExecuteHash as part of the trace in ctx. The result is
+// the digest and path in the format of sha256sum(1), so a harness can
+// check it with the usual tools.
+func executeHash(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "hash", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("hash", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	path, algo := splitHashAlgo(arg)
+	if _, ok := hashAlgorithms[algo]; !ok {
+		return fail(errors.Newf(errors.ParseError, "unsupported algo=%s (want md5, sha1, sha256, or sha512)", algo))
+	}
+
+	// Validate the path as open does; the file is hashed on the host, so a
+	// symlink out of the repository is refused too
+	safePath, err := sandbox.ValidateHostPath(path, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return fail(errors.Wrap(errors.PathSecurity, err))
+	}
+	if cfg.RespectIgnoreFiles {
+		if err := sandbox.CheckIgnored(safePath, cfg.RepositoryRoot); err != nil {
+			return fail(errors.Wrap(errors.PathSecurity, err))
+		}
+	}
+
+	file, err := os.Open(safePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fail(errors.New(errors.FileNotFound, path))
+		}
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		return fail(errors.Newf(errors.FileNotFound, "%s is a directory", path))
+	}
+
+	counter := &countingReader{r: file}
+	digest, err := CalculateReaderHash(counter, algo)
+	if err != nil {
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+
+	result.Success = true
+	result.Result = fmt.Sprintf("%s  %s\n", digest, path)
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("hash", arg, true, fmt.Sprintf("%s:%s,bytes:%d", algo, digest, counter.n))
+	}
+	return result
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestCalculateReaderHash(t *testing.T) {
+	tests := []struct {
+		algo string
+		want string
+	}{
+		{"md5", "5d41402abc4b2a76b9719d911017c592"},
+		{"sha1", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{"sha256", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+	}
+	for _, tt := range tests {
+		got, err := CalculateReaderHash(strings.NewReader("hello"), tt.algo)
+		if err != nil || got != tt.want {
+			t.Errorf("CalculateReaderHash(%s) = %q, %v, want %q", tt.algo, got, err, tt.want)
+		}
+	}
+
+	if _, err := CalculateReaderHash(strings.NewReader("hello"), "crc32"); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
+
+func TestSplitHashAlgo(t *testing.T) {
+	tests := []struct {
+		arg, path, algo string
+	}{
+		{"main.go", "main.go", "sha256"},
+		{"main.go algo=SHA1", "main.go", "sha1"},
+		{"my file.txt algo=md5", "my file.txt", "md5"},
+	}
+	for _, tt := range tests {
+		path, algo := splitHashAlgo(tt.arg)
+		if path != tt.path || algo != tt.algo {
+			t.Errorf("splitHashAlgo(%q) = %q, %q", tt.arg, path, algo)
+		}
+	}
+}
+
+func TestExecuteHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "hello.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := &testAuditLog{}
+	result := ExecuteHash("hello.txt", cfg, audit.log)
+	if !result.Success {
+		t.Fatalf("ExecuteHash() failed: %v", result.Error)
+	}
+	want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  hello.txt\n"
+	if result.Result != want {
+		t.Errorf("Result = %q, want %q", result.Result, want)
+	}
+	if result.Result != CalculateContentHash("hello")+"  hello.txt\n" {
+		t.Error("digest differs from CalculateContentHash")
+	}
+	entries := audit.getEntries()
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].errMsg, ",bytes:5") {
+		t.Errorf("audit entries = %+v", entries)
+	}
+}
+
+func TestExecuteHash_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	os.Mkdir(filepath.Join(tmpDir, "dir"), 0755)
+
+	tests := []struct {
+		arg  string
+		code string
+	}{
+		{"missing.txt", "FILE_NOT_FOUND"},
+		{"dir", "FILE_NOT_FOUND"},
+		{"../outside.txt", "PATH_SECURITY"},
+		{".env", "PATH_SECURITY"},
+		{"hello.txt algo=crc32", "PARSE_ERROR"},
+	}
+	for _, tt := range tests {
+		result := ExecuteHash(tt.arg, cfg, nil)
+		if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), tt.code) {
+			t.Errorf("ExecuteHash(%q) = %+v, want %s", tt.arg, result, tt.code)
+		}
+	}
+}
+
+// TestHostReads_Symlinks tests that the commands reading files on the host,
+// whether for the whole command or for checks before a container runs,
+// refuse a symlink out of the repository
+func TestHostReads_Symlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.go"), []byte("package secret\n\nfunc Key() string { return \"hunter2\" }\n"), 0644)
+	os.WriteFile(filepath.Join(outside, "coverage.out"), []byte("mode: set\nsecret/key.go:1.1,2.2 1 1\n"), 0644)
+	if err := os.Symlink(outside, filepath.Join(tmpDir, "vendor-link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+	defer executor.Close()
+
+	for _, cmd := range []scanner.Command{
+		{Type: "hash", Argument: "vendor-link/secret.go"},
+		{Type: "outline", Argument: "vendor-link/secret.go"},
+		{Type: "coverage", Argument: "vendor-link/coverage.out"},
+		{Type: "tokens", Argument: "vendor-link/secret.go"},
+		{Type: "tokens", Argument: "vendor-link"},
+		{Type: "ast-grep", Argument: "Key() path=vendor-link"},
+		{Type: "open", Argument: "vendor-link/secret.go"},
+		{Type: "tail", Argument: "vendor-link/secret.go"},
+		{Type: "write", Argument: "vendor-link/secret.go", Content: "package secret\n"},
+		{Type: "write", Argument: "vendor-link/secret.go", Content: "cGFja2FnZSBzZWNyZXQK", Encoding: "base64"},
+		{Type: "append", Argument: "vendor-link/secret.go", Content: "// more\n"},
+		{Type: "test", Argument: "vendor-link"},
+	} {
+		result := executor.Execute(cmd)
+		if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "PATH_SECURITY") {
+			t.Errorf("<%s %s> = success %v, err %v, output %q, want PATH_SECURITY", cmd.Type, cmd.Argument, result.Success, result.Error, result.Result)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(outside, "secret.go")); !strings.Contains(string(data), "hunter2") {
+		t.Errorf("file outside the repository was changed: %q", data)
+	}
+}
@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"context"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/hooks"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+)
+
+// hookEvent describes cmd to the hooks of phase
+func (e *Executor) hookEvent(ctx context.Context, phase string, cmd scanner.Command) hooks.Event {
+	sessionID, _ := e.lookupVariable("SESSION_ID")
+	return hooks.Event{
+		Event:         phase + "_" + cmd.Type,
+		Session:       sessionID,
+		CorrelationID: telemetry.CorrelationID(ctx),
+		Repository:    e.config.RepositoryRoot,
+		Command:       cmd.Type,
+		Argument:      cmd.Argument,
+		Content:       cmd.Content,
+	}
+}
+
+// runPreHooks runs the pre hooks of cmd. When one fails, cmd is refused
+// and the result saying so is returned; otherwise nil.
+func (e *Executor) runPreHooks(cmd scanner.Command) *scanner.ExecutionResult {
+	list := hooks.For(e.config.Hooks, "pre", cmd.Type)
+	if len(list) == 0 {
+		return nil
+	}
+	startTime := time.Now()
+	err := hooks.Run(e.traceCtx, list, e.hookEvent(e.traceCtx, "pre", cmd))
+	if err == nil {
+		return nil
+	}
+
+	fullError := errors.Wrap(errors.HookDenied, err)
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error())
+	}
+	success := false
+	e.mu.Lock()
+	e.lastResult = &success
+	e.mu.Unlock()
+
+	return &scanner.ExecutionResult{
+		Command:       cmd,
+		Success:       false,
+		Error:         SanitizeError(fullError),
+		ExecutionTime: time.Since(startTime),
+	}
+}
+
+// runPostHooks runs the post hooks of the command result is of. They
+// cannot change the result, so a failure is only audited.
+func (e *Executor) runPostHooks(ctx context.Context, result scanner.ExecutionResult) {
+	list := hooks.For(e.config.Hooks, "post", result.Command.Type)
+	if len(list) == 0 || errorCode(result.Error) == string(errors.HookDenied) {
+		return
+	}
+	event := e.hookEvent(ctx, "post", result.Command)
+	success := result.Success
+	event.Success = &success
+	if result.Error != nil {
+		event.Error = result.Error.Error()
+	}
+	event.ExitCode = result.ExitCode
+	event.Action = result.Action
+	event.DurationMS = float64(result.ExecutionTime.Microseconds()) / 1000
+
+	if err := hooks.Run(ctx, list, event); err != nil && e.auditLog != nil {
+		e.auditLog("hook", event.Event, false, err.Error())
+	}
+}
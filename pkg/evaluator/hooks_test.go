@@ -0,0 +1,55 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/hooks"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestExecute_Hooks(t *testing.T) {
+	root := t.TempDir()
+	events := filepath.Join(t.TempDir(), "events")
+	cfg := &config.Config{RepositoryRoot: root, MaxFileSize: 1024, MaxWriteSize: 1024}
+	cfg.Hooks = map[string][]config.HookConfig{
+		// Refuses writes to vendor/
+		"pre_write": {{Command: `grep -q '"argument":"vendor/' && { echo "vendor is read-only"; exit 1; }; exit 0`}},
+		"post_all":  {{Command: "cat >> " + events + "; echo >> " + events}},
+	}
+	e := NewExecutor(cfg, nil, nil, nil)
+
+	result := e.Execute(scanner.Command{Type: "write", Argument: "vendor/lib.go", Content: "package lib"})
+	if result.Success || !strings.Contains(result.Error.Error(), "HOOK_DENIED") || !strings.Contains(result.Error.Error(), "vendor is read-only") {
+		t.Fatalf("vetoed write = %+v, want HOOK_DENIED", result)
+	}
+	if _, err := os.Stat(filepath.Join(root, "vendor", "lib.go")); err == nil {
+		t.Errorf("vetoed write created the file")
+	}
+
+	// An <open> of a missing file runs, and fails, without Docker
+	result = e.Execute(scanner.Command{Type: "open", Argument: "missing.go"})
+	if result.Success {
+		t.Fatalf("open of missing file succeeded")
+	}
+
+	data, err := os.ReadFile(events)
+	if err != nil {
+		t.Fatalf("post hook did not run: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("post hooks ran %d times, want once (not for the vetoed write): %q", len(lines), data)
+	}
+	var event hooks.Event
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatalf("post hook event is not JSON: %v", err)
+	}
+	if event.Event != "post_open" || event.Argument != "missing.go" || event.Success == nil || *event.Success || event.CorrelationID == "" {
+		t.Errorf("post hook event = %+v", event)
+	}
+}
@@ -0,0 +1,120 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/forge"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// ExecuteIssue handles the "issue" command
+func ExecuteIssue(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeIssue(context.Background(), arg, cfg, auditLog)
+}
+
+// executeIssue is ExecuteIssue as part of the trace in ctx. It fetches the
+// issue numbered arg from the repository of cfg.PRRemote, with its most
+// recent comments, in at most cfg.IssueMaxBytes.
+func executeIssue(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "issue", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("issue", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if !cfg.Issues {
+		return fail(errors.New(errors.IssueDisabled, "reading issues is disabled"))
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(arg), "#"))
+	if err != nil || number <= 0 {
+		return fail(errors.Newf(errors.ParseError, "issue needs an issue number, such as <issue 123>, not %q", arg))
+	}
+
+	remote, err := forge.RemoteOf(ctx, cfg.RepositoryRoot, cfg.PRRemote, cfg.PRForge)
+	if err != nil {
+		return fail(errors.Wrap(errors.IssueFailed, err))
+	}
+	issue, err := remote.Issue(ctx, forge.Token(remote.Forge), number, cfg.IssueComments)
+	if err != nil {
+		return fail(errors.Wrapf(errors.IssueFailed, err, "cannot read issue %d", number))
+	}
+
+	maxBytes := cfg.IssueMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultIssueMaxBytes
+	}
+	var shown int
+	result.Result, shown = formatIssue(issue, int(maxBytes))
+	result.Success = true
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("issue", arg, true, fmt.Sprintf("issue:%d,url:%s,comments:%d,bytes:%d", issue.Number, issue.URL, shown, len(result.Result)))
+	}
+	return result
+}
+
+// formatIssue renders issue in about maxBytes: its description, cut short
+// to leave room for comments if need be, then as many of the most recent
+// comments as fit. It returns the text and the number of comments in it.
+func formatIssue(issue *forge.Issue, maxBytes int) (string, int) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Issue #%d: %s\n", issue.Number, issue.Title)
+	fmt.Fprintf(&b, "State: %s | Author: %s", issue.State, issue.Author)
+	if len(issue.Labels) > 0 {
+		fmt.Fprintf(&b, " | Labels: %s", strings.Join(issue.Labels, ", "))
+	}
+	fmt.Fprintf(&b, "\nURL: %s\n\n", issue.URL)
+
+	budget := maxBytes - b.Len()
+	body := strings.TrimSpace(issue.Body)
+	if body == "" {
+		body = "(no description)"
+	}
+	// The description may take half of what is left when there are
+	// comments to show
+	bodyLimit := budget
+	if len(issue.Comments) > 0 {
+		bodyLimit = budget / 2
+	}
+	if len(body) > bodyLimit {
+		cut := cutChunk(body[:max(bodyLimit, 0)], false)
+		body = fmt.Sprintf("%s\n[description truncated: showing %d of %d bytes]", strings.TrimRight(cut, "\n"), len(cut), len(body))
+	}
+	b.WriteString(body)
+	b.WriteString("\n")
+	budget -= len(body) + 1
+
+	// The most recent comments that fit, newest first
+	var blocks []string
+	for i := len(issue.Comments) - 1; i >= 0; i-- {
+		c := issue.Comments[i]
+		number := issue.Total - (len(issue.Comments) - 1 - i)
+		block := fmt.Sprintf("\n--- Comment %d of %d by %s, %s ---\n%s\n", number, issue.Total, c.Author, c.Created.UTC().Format("2006-01-02 15:04"), strings.TrimSpace(c.Body))
+		if len(block) > budget {
+			break
+		}
+		budget -= len(block)
+		blocks = append(blocks, block)
+	}
+	if earlier := issue.Total - len(blocks); earlier > 0 {
+		fmt.Fprintf(&b, "\n[%d earlier comments not shown]\n", earlier)
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		b.WriteString(blocks[i])
+	}
+	return b.String(), len(blocks)
+}
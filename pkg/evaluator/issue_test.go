@@ -0,0 +1,57 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/forge"
+)
+
+func TestFormatIssue(t *testing.T) {
+	issue := &forge.Issue{Number: 12, Title: "Scanner hangs", State: "open", Author: "alice", Labels: []string{"bug"}, URL: "https://github.com/acme/tools/issues/12", Body: "Steps to reproduce", Total: 30}
+	for i := 21; i <= 30; i++ {
+		issue.Comments = append(issue.Comments, forge.Comment{Author: "bob", Body: fmt.Sprintf("comment %d %s", i, strings.Repeat("x", 100)), Created: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)})
+	}
+
+	text, shown := formatIssue(issue, 32*1024)
+	if shown != 10 || !strings.Contains(text, "Issue #12: Scanner hangs") || !strings.Contains(text, "Labels: bug") || !strings.Contains(text, "[20 earlier comments not shown]") {
+		t.Errorf("formatIssue() shown %d:\n%s", shown, text)
+	}
+	if !strings.Contains(text, "--- Comment 30 of 30 by bob, 2026-10-01 12:00 ---") || strings.Index(text, "comment 21") > strings.Index(text, "comment 30") {
+		t.Errorf("comments missing or out of order:\n%s", text)
+	}
+
+	// A small limit keeps the newest comments that fit
+	text, shown = formatIssue(issue, 600)
+	if shown == 0 || shown == 10 || !strings.Contains(text, "comment 30") || strings.Contains(text, "comment 21") {
+		t.Errorf("formatIssue(600) shown %d:\n%s", shown, text)
+	}
+	if len(text) > 600 {
+		t.Errorf("formatIssue(600) is %d bytes", len(text))
+	}
+
+	// A long description is cut to leave room for comments
+	issue.Body = strings.Repeat("line of description\n", 100)
+	text, _ = formatIssue(issue, 1000)
+	if !strings.Contains(text, "[description truncated: showing") || !strings.Contains(text, "comment 30") {
+		t.Errorf("formatIssue() with long description:\n%s", text)
+	}
+}
+
+func TestExecuteIssue_Errors(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	if result := ExecuteIssue("12", cfg, nil); result.Error == nil || !strings.Contains(result.Error.Error(), "ISSUE_DISABLED") {
+		t.Errorf("issue = %+v, want ISSUE_DISABLED", result)
+	}
+
+	cfg.Issues = true
+	cfg.PRRemote = "origin"
+	if result := ExecuteIssue("twelve", cfg, nil); result.Error == nil || !strings.Contains(result.Error.Error(), "PARSE_ERROR") {
+		t.Errorf("issue twelve = %+v, want PARSE_ERROR", result)
+	}
+	if result := ExecuteIssue("#12", cfg, nil); result.Error == nil || !strings.Contains(result.Error.Error(), "ISSUE_FAILED") {
+		t.Errorf("issue without a remote = %+v, want ISSUE_FAILED", result)
+	}
+}
@@ -0,0 +1,142 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// lintLine matches the file:line[:col]: message lines that golangci-lint,
+// ruff's concise format, and eslint's unix format all print
+var lintLine = regexp.MustCompile(`^(\S.*?):(\d+):(?:(\d+):)?\s+(.+)$`)
+
+// eslintSeverity matches the [Error/rule] eslint's unix format ends with
+var eslintSeverity = regexp.MustCompile(`\s*\[(Error|Warning)(?:/(\S+))?\]$`)
+
+// ExecuteLint handles the "lint" command
+func ExecuteLint(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeLint(context.Background(), arg, cfg, auditLog, pool)
+}
+
+// executeLint is ExecuteLint as part of the trace in ctx. The target's
+// language picks the linter configured for it, which runs as an exec
+// command like <test> runners do; the issues it reports are listed one per
+// line as file:line:col severity message, up to cfg.LintMaxIssues.
+func executeLint(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "lint", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("lint", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	target := strings.TrimSpace(arg)
+	lang, err := targetLanguage(target, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	linter := strings.TrimSpace(cfg.Linters[lang])
+	if linter == "" {
+		return fail(errors.Newf(errors.ExecValidation, "no linter configured for %s", lang))
+	}
+	switch {
+	case lang == "go":
+		target = goPackageTarget(target)
+	case target == "":
+		target = "."
+	}
+	command := linter + " " + target
+
+	var execAudit string
+	execResult := executeExec(ctx, scanner.Command{Type: "exec", Argument: command}, cfg, func(_, _ string, _ bool, msg string) {
+		execAudit = msg
+	}, pool)
+	result.Stdout = execResult.Stdout
+	result.Stderr = execResult.Stderr
+	result.ExitCode = execResult.ExitCode
+	result.Success = execResult.Success
+	result.Error = execResult.Error
+	result.ExecutionTime = time.Since(startTime)
+
+	issues := parseLintOutput(execResult.Stdout+"\n"+execResult.Stderr, cfg.RepositoryRoot)
+	switch {
+	case len(issues) > 0:
+		result.Result = formatLintReport(command, issues, execResult.ExitCode, cfg.LintMaxIssues)
+		if result.Error != nil && errors.CodeOf(result.Error) == errors.ExecFailed {
+			result.Error = errors.Newf(errors.ExecFailed, "%d lint issues", len(issues))
+		}
+	case execResult.Success:
+		result.Result = command + ": no issues\n"
+	default:
+		// The linter failed without reporting issues, as when it is missing
+		// or its configuration is broken, so its own words explain it best
+		result.Result = execResult.Result
+	}
+
+	if auditLog != nil {
+		auditLog("lint", command, result.Success, strings.TrimPrefix(fmt.Sprintf("%s,issues:%d", execAudit, len(issues)), ","))
+	}
+	return result
+}
+
+// parseLintOutput reads the issues in a linter's output, skipping the
+// source excerpts and summaries around them and any issue repeated
+func parseLintOutput(output, root string) []diagnostic {
+	var issues []diagnostic
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		m := lintLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		issue := diagnostic{File: repoRelativePath(m[1], root), Severity: "error", Message: m[4]}
+		issue.Line, _ = strconv.Atoi(m[2])
+		issue.Column, _ = strconv.Atoi(m[3])
+		if s := eslintSeverity.FindStringSubmatch(issue.Message); s != nil {
+			issue.Severity = strings.ToLower(s[1])
+			issue.Message = strings.TrimSpace(eslintSeverity.ReplaceAllString(issue.Message, ""))
+			if s[2] != "" {
+				issue.Message += " (" + s[2] + ")"
+			}
+		}
+		if key := issue.String(); !seen[key] {
+			seen[key] = true
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// formatLintReport renders the issues a linter found, the first maxIssues
+// of them if maxIssues is positive
+func formatLintReport(command string, issues []diagnostic, exitCode, maxIssues int) string {
+	var b strings.Builder
+	noun := "issues"
+	if len(issues) == 1 {
+		noun = "issue"
+	}
+	fmt.Fprintf(&b, "%s: %d %s (exit code %d)\n", command, len(issues), noun, exitCode)
+	for i, issue := range issues {
+		if maxIssues > 0 && i == maxIssues {
+			fmt.Fprintf(&b, "(%d more issues not shown)\n", len(issues)-i)
+			break
+		}
+		b.WriteString(issue.String() + "\n")
+	}
+	return b.String()
+}
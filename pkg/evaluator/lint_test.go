@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLintOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name: "golangci-lint",
+			output: `pkg/store/store.go:12:2: declared and not used: x (typecheck)
+	x := 1
+	^
+pkg/store/store.go:30: line is 140 characters (lll)
+2 issues:
+* lll: 1
+* typecheck: 1
+`,
+			want: []string{
+				"pkg/store/store.go:12:2 error declared and not used: x (typecheck)",
+				"pkg/store/store.go:30 error line is 140 characters (lll)",
+			},
+		},
+		{
+			name: "ruff",
+			output: `app/main.py:1:8: F401 [*] ` + "`os`" + ` imported but unused
+Found 1 error.
+[*] 1 fixable with the ` + "`--fix`" + ` option.
+`,
+			want: []string{"app/main.py:1:8 error F401 [*] `os` imported but unused"},
+		},
+		{
+			name: "eslint",
+			output: `/workspace/src/sum.js:3:7: 'x' is assigned a value but never used. [Error/no-unused-vars]
+/workspace/src/sum.js:9:1: Unexpected console statement. [Warning/no-console]
+/workspace/src/sum.js:9:1: Unexpected console statement. [Warning/no-console]
+
+2 problems
+`,
+			want: []string{
+				"src/sum.js:3:7 error 'x' is assigned a value but never used. (no-unused-vars)",
+				"src/sum.js:9:1 warning Unexpected console statement. (no-console)",
+			},
+		},
+		{
+			name:   "no issues",
+			output: "level=warning msg=\"[runner] no go files to analyze\"\n0 issues.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range parseLintOutput(tt.output, "/repo") {
+				got = append(got, issue.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestFormatLintReport(t *testing.T) {
+	issues := []diagnostic{
+		{File: "a.go", Line: 1, Column: 2, Severity: "error", Message: "first"},
+		{File: "a.go", Line: 5, Severity: "warning", Message: "second"},
+		{File: "b.go", Line: 9, Column: 1, Severity: "error", Message: "third"},
+	}
+	got := formatLintReport("golangci-lint run ./...", issues, 1, 2)
+	want := "golangci-lint run ./...: 3 issues (exit code 1)\n" +
+		"a.go:1:2 error first\n" +
+		"a.go:5 warning second\n" +
+		"(1 more issues not shown)\n"
+	if got != want {
+		t.Errorf("report =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExecuteLint_NoLinter(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	result := ExecuteLint("", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "EXEC_VALIDATION") {
+		t.Errorf("result = %+v, want EXEC_VALIDATION", result)
+	}
+}
@@ -0,0 +1,491 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/lsp"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// lspMaxLocations bounds the locations a <def> or <refs> result lists
+const lspMaxLocations = 200
+
+// lspMaxSymbols bounds the symbols matching a name whose references <refs>
+// looks up
+const lspMaxSymbols = 5
+
+// languageServer is a language server started by <def> or <refs>, kept
+// running for the rest of the session so its index is built only once
+type languageServer struct {
+	client   *lsp.Client
+	language string
+	synced   time.Time // Files modified since have not been reported to the server
+}
+
+// lspQuery is the argument of <def> or <refs>: a symbol, or a position in
+// a file
+type lspQuery struct {
+	symbol   string // Such as internal/core.NewCommandExecutor or Session.LogAudit
+	path     string // Or a file, with the position in it
+	pos      lsp.Position
+	column   bool   // Whether the position has a column; otherwise the line's first word is used
+	language string // From lang=, or the file's extension
+}
+
+// lspPosition matches file:line and file:line:col
+var lspPosition = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?$`)
+
+// parseLSPQuery parses the argument of <def> or <refs>
+func parseLSPQuery(arg string) (lspQuery, error) {
+	var q lspQuery
+	var target []string
+	for _, field := range strings.Fields(arg) {
+		if lang, ok := strings.CutPrefix(field, "lang="); ok {
+			q.language = lang
+			continue
+		}
+		target = append(target, field)
+	}
+	if len(target) != 1 {
+		return q, errors.New(errors.ParseError, "give one symbol, such as pkg.Func or Type.Method, or a position, such as file.go:12:5")
+	}
+
+	m := lspPosition.FindStringSubmatch(target[0])
+	if m == nil {
+		q.symbol = strings.TrimPrefix(target[0], "./")
+		return q, nil
+	}
+	q.path = m[1]
+	line, _ := strconv.Atoi(m[2])
+	if line < 1 {
+		return q, errors.New(errors.ParseError, "lines are numbered from 1")
+	}
+	q.pos.Line = line - 1
+	if m[3] != "" {
+		col, _ := strconv.Atoi(m[3])
+		if col < 1 {
+			return q, errors.New(errors.ParseError, "columns are numbered from 1")
+		}
+		q.pos.Character = col - 1
+		q.column = true
+	}
+	return q, nil
+}
+
+// matchSymbols returns the symbols whose qualified names end with name,
+// such as core.NewCommandExecutor for example.com/m/internal/core.NewCommandExecutor
+func matchSymbols(symbols []lsp.Symbol, name string) []lsp.Symbol {
+	var matches []lsp.Symbol
+	seen := make(map[lsp.Location]bool)
+	for _, s := range symbols {
+		qualified := s.Name
+		if s.ContainerName != "" && !strings.HasPrefix(s.Name, s.ContainerName) {
+			qualified = s.ContainerName + "." + s.Name
+		}
+		if (qualified == name || s.Name == name || strings.HasSuffix(qualified, "/"+name) || strings.HasSuffix(qualified, "."+name)) && !seen[s.Location] {
+			seen[s.Location] = true
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
+
+// symbolQuery is what a workspace symbol search for name asks for: its
+// last identifier, which servers match names against
+func symbolQuery(name string) string {
+	if i := strings.LastIndexAny(name, "./"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// executeLSP handles the "def" and "refs" commands: where a symbol, or the
+// symbol at a position, is defined, or where it is used. They are answered
+// by the language server of its language, started on the host the first
+// time it is needed and kept for the rest of the session.
+func (e *Executor) executeLSP(cmd scanner.Command) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{Command: cmd}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if e.auditLog != nil {
+			e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	q, err := parseLSPQuery(cmd.Argument)
+	if err != nil {
+		return fail(err)
+	}
+	if err := e.lspFile(&q); err != nil {
+		return fail(err)
+	}
+	language, err := e.lspLanguage(q.language)
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(e.traceCtx, e.config.LSPTimeout)
+	defer cancel()
+	server, err := e.languageServer(ctx, language)
+	if err == nil {
+		server.sync(e.config.RepositoryRoot)
+	}
+
+	var header string
+	var found []lspLocation
+	if err == nil && q.path != "" {
+		header, found, err = e.lspAtPosition(ctx, server, cmd.Type, q)
+	} else if err == nil {
+		header, found, err = e.lspForSymbol(ctx, server, cmd.Type, q.symbol)
+	}
+	if err != nil {
+		return fail(e.lspError(ctx, language, err))
+	}
+
+	result.Success = true
+	result.Result = formatLSPResult(header, found, lspShown(found))
+	result.ExecutionTime = time.Since(startTime)
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, true, fmt.Sprintf("language:%s,locations:%d", language, len(found)))
+	}
+	return result
+}
+
+// lspError gives err, from a request to the server of language in ctx, its
+// code: EXEC_TIMEOUT if the request ran out of time, or else LSP_FAILED
+// unless it has one
+func (e *Executor) lspError(ctx context.Context, language string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Newf(errors.ExecTimeout, "the %s language server took more than %v", language, e.config.LSPTimeout)
+	}
+	if errors.CodeOf(err) != "" {
+		return err
+	}
+	return errors.Wrap(errors.LSPFailed, err)
+}
+
+// lspFile checks the file of a query about a position, if it has one, and
+// makes its path absolute. The file's extension gives the language unless
+// lang= did.
+func (e *Executor) lspFile(q *lspQuery) error {
+	if q.path == "" {
+		return nil
+	}
+	safePath, err := sandbox.ValidateHostPath(q.path, e.config.RepositoryRoot, e.config.ExcludedPaths)
+	if err != nil {
+		return errors.Wrap(errors.PathSecurity, err)
+	}
+	if e.config.RespectIgnoreFiles {
+		if err := sandbox.CheckIgnored(safePath, e.config.RepositoryRoot); err != nil {
+			return errors.Wrap(errors.PathSecurity, err)
+		}
+	}
+	if _, err := os.Stat(safePath); err != nil {
+		return errors.Newf(errors.FileNotFound, "file not found: %s", q.path)
+	}
+	q.path = safePath
+	if q.language == "" {
+		q.language = lsp.Languages[filepath.Ext(safePath)]
+	}
+	return nil
+}
+
+// lspLanguage returns the language whose server answers a query: the one
+// given, or else Go in a Go module, or else the only one configured
+func (e *Executor) lspLanguage(language string) (string, error) {
+	servers := e.config.LanguageServers
+	if language != "" {
+		if servers[language] == "" {
+			return "", errors.Newf(errors.LSPUnavailable, "no language server is configured for %s", language)
+		}
+		return language, nil
+	}
+	if servers["go"] != "" {
+		if _, err := os.Stat(filepath.Join(e.config.RepositoryRoot, "go.mod")); err == nil {
+			return "go", nil
+		}
+	}
+	var configured []string
+	for lang, command := range servers {
+		if command != "" {
+			configured = append(configured, lang)
+		}
+	}
+	switch len(configured) {
+	case 0:
+		return "", errors.New(errors.LSPUnavailable, "no language servers are configured")
+	case 1:
+		return configured[0], nil
+	}
+	sort.Strings(configured)
+	return "", errors.Newf(errors.LSPUnavailable, "say which language to ask with lang=NAME (configured: %s)", strings.Join(configured, ", "))
+}
+
+// languageServer returns the running server of language, starting it if
+// need be
+func (e *Executor) languageServer(ctx context.Context, language string) (*languageServer, error) {
+	if server := e.servers[language]; server != nil {
+		return server, nil
+	}
+	command := strings.Fields(e.config.LanguageServers[language])
+	if len(command) == 0 {
+		return nil, errors.Newf(errors.LSPUnavailable, "no language server is configured for %s", language)
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, errors.Newf(errors.LSPUnavailable, "%s, the language server for %s, is not installed", command[0], language)
+	}
+
+	var env []string
+	var options any
+	if language == "go" {
+		// As for <deps>, nothing is downloaded; names are qualified by
+		// package path, so pkg.Func can be told from other.Func
+		env = []string{"GOTOOLCHAIN=local", "GOPROXY=off"}
+		options = map[string]any{"symbolStyle": "Full"}
+	}
+	started := time.Now()
+	client, err := lsp.Start(ctx, command, e.config.RepositoryRoot, env, options)
+	if err != nil {
+		return nil, err
+	}
+	if e.servers == nil {
+		e.servers = make(map[string]*languageServer)
+	}
+	server := &languageServer{client: client, language: language, synced: started}
+	e.servers[language] = server
+	return server, nil
+}
+
+// sync tells the server about the files of its language changed since it
+// last heard, by writes, exec commands, or anyone else, since servers
+// otherwise only notice edits made through the client
+func (s *languageServer) sync(root string) {
+	since := s.synced
+	s.synced = time.Now()
+	var changed []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if name := d.Name(); name == ".git" || name == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if lsp.Languages[filepath.Ext(path)] != s.language {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && !info.ModTime().Before(since) {
+			changed = append(changed, path)
+		}
+		return nil
+	})
+	s.client.Changed(changed)
+}
+
+// lspAtPosition answers a query about the symbol at a position in a file
+func (e *Executor) lspAtPosition(ctx context.Context, server *languageServer, kind string, q lspQuery) (string, []lspLocation, error) {
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		return "", nil, err
+	}
+	if !q.column {
+		q.pos.Character = firstWord(lineAt(string(data), q.pos.Line))
+	}
+	rel, _ := filepath.Rel(e.config.RepositoryRoot, q.path)
+	at := fmt.Sprintf("%s:%d:%d", filepath.ToSlash(rel), q.pos.Line+1, q.pos.Character+1)
+
+	// Some servers only answer about files they were given
+	server.client.Open(q.path, server.language, string(data))
+	defer server.client.CloseFile(q.path)
+
+	var locations []lsp.Location
+	if kind == "def" {
+		locations, err = server.client.Definition(ctx, q.path, q.pos)
+	} else {
+		locations, err = server.client.References(ctx, q.path, q.pos, false)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	found := e.lspLocations(locations, "")
+	if kind == "def" {
+		return "Definition of the symbol at " + at, found, nil
+	}
+	return "References to the symbol at " + at, found, nil
+}
+
+// lspForSymbol answers a query about the symbols matching name
+func (e *Executor) lspForSymbol(ctx context.Context, server *languageServer, kind, name string) (string, []lspLocation, error) {
+	symbols, err := server.client.Symbols(ctx, symbolQuery(name))
+	if err != nil {
+		return "", nil, err
+	}
+	matches := matchSymbols(symbols, name)
+	if len(matches) == 0 {
+		return "", nil, fmt.Errorf("no %s symbol matches %s", server.language, name)
+	}
+
+	var found []lspLocation
+	if kind == "def" {
+		for _, s := range matches {
+			found = append(found, e.lspLocations([]lsp.Location{s.Location}, s.KindName())...)
+		}
+		return "Definition of " + name, found, nil
+	}
+	if len(matches) > lspMaxSymbols {
+		matches = matches[:lspMaxSymbols]
+	}
+	for _, s := range matches {
+		locations, err := server.client.References(ctx, lsp.Path(s.Location.URI), s.Location.Range.Start, false)
+		if err != nil {
+			return "", nil, err
+		}
+		found = append(found, e.lspLocations(locations, "")...)
+	}
+	return "References to " + name, found, nil
+}
+
+// lspLocation is a location in the repository a query found
+type lspLocation struct {
+	path      string // Relative to the repository root; empty when outside it, excluded, or ignored
+	line, col int    // One-based
+	text      string // The line, trimmed
+	kind      string // Kind of symbol defined there, when known
+}
+
+// lspLocations converts the locations a server answered with, leaving out
+// the text of those the session may not open
+func (e *Executor) lspLocations(locations []lsp.Location, kind string) []lspLocation {
+	found := make([]lspLocation, 0, len(locations))
+	for _, loc := range locations {
+		l := lspLocation{line: loc.Range.Start.Line + 1, col: loc.Range.Start.Character + 1, kind: kind}
+		if path := lsp.Path(loc.URI); path != "" && e.lspVisible(path) {
+			rel, _ := filepath.Rel(e.config.RepositoryRoot, path)
+			l.path = filepath.ToSlash(rel)
+			if data, err := os.ReadFile(path); err == nil {
+				l.text = strings.TrimSpace(lineAt(string(data), loc.Range.Start.Line))
+			}
+		}
+		found = append(found, l)
+	}
+	return found
+}
+
+// lspVisible reports whether path is a file the session may open
+func (e *Executor) lspVisible(path string) bool {
+	safePath, err := sandbox.ValidateHostPath(path, e.config.RepositoryRoot, e.config.ExcludedPaths)
+	if err != nil {
+		return false
+	}
+	return !e.config.RespectIgnoreFiles || sandbox.CheckIgnored(safePath, e.config.RepositoryRoot) == nil
+}
+
+// lspShown sorts found and removes repeats, returning the locations that
+// are in the repository and how many others there were
+func lspShown(found []lspLocation) []lspLocation {
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].path != found[j].path {
+			return found[i].path < found[j].path
+		}
+		if found[i].line != found[j].line {
+			return found[i].line < found[j].line
+		}
+		return found[i].col < found[j].col
+	})
+	var shown []lspLocation
+	for i, l := range found {
+		if l.path == "" || (i > 0 && l.path == found[i-1].path && l.line == found[i-1].line && l.col == found[i-1].col) {
+			continue
+		}
+		shown = append(shown, l)
+	}
+	return shown
+}
+
+// formatLSPResult lists the locations shown under header
+func formatLSPResult(header string, found, shown []lspLocation) string {
+	var b strings.Builder
+	files := make(map[string]bool)
+	for _, l := range shown {
+		files[l.path] = true
+	}
+	hidden := 0
+	for _, l := range found {
+		if l.path == "" {
+			hidden++
+		}
+	}
+	fmt.Fprintf(&b, "%s: %s in %s\n", header, pluralize(len(shown), "location"), pluralize(len(files), "file"))
+	for i, l := range shown {
+		if i == lspMaxLocations {
+			fmt.Fprintf(&b, "... and %d more\n", len(shown)-lspMaxLocations)
+			break
+		}
+		text := l.text
+		if utf8.RuneCountInString(text) > 160 {
+			text = string([]rune(text)[:160]) + "..."
+		}
+		fmt.Fprintf(&b, "%s:%d:%d: %s", l.path, l.line, l.col, text)
+		if l.kind != "" {
+			fmt.Fprintf(&b, " (%s)", l.kind)
+		}
+		b.WriteString("\n")
+	}
+	if hidden > 0 {
+		fmt.Fprintf(&b, "(%d more outside the repository or in excluded files)\n", hidden)
+	}
+	return b.String()
+}
+
+// lineAt returns the zero-based line n of text, or "" past its end
+func lineAt(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[n], "\r")
+}
+
+// identifier matches a word of source code
+var identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// receiver matches the receiver of a Go method declaration
+var receiver = regexp.MustCompile(`^\s*func\s*\([^)]*\)`)
+
+// firstWord returns the zero-based column of the first identifier on line,
+// skipping keywords such as func and type and a method's receiver, for
+// positions given without one
+func firstWord(line string) int {
+	start := 0
+	if m := receiver.FindStringIndex(line); m != nil {
+		start = m[1]
+	}
+	for _, w := range identifier.FindAllStringIndex(line[start:], -1) {
+		w[0], w[1] = w[0]+start, w[1]+start
+		switch line[w[0]:w[1]] {
+		case "func", "type", "var", "const", "def", "class", "fn", "pub", "let", "public", "private", "static", "return", "async":
+			continue
+		}
+		return utf8.RuneCountInString(line[:w[0]])
+	}
+	return 0
+}
@@ -0,0 +1,217 @@
+package evaluator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/lsp"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// TestMain lets the test binary stand in for a language server
+func TestMain(m *testing.M) {
+	if os.Getenv("EVALUATOR_TEST_LSP") == "1" {
+		fakeLanguageServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeLanguageServer answers as gopls would in the repository lspRepo
+// creates, where main.go calls core.NewExecutor twice. Renaming it to
+// Secret changes an excluded file too.
+func fakeLanguageServer() {
+	root, _ := os.Getwd()
+	in := bufio.NewReader(os.Stdin)
+	loc := func(path string, line, char int) map[string]any {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		pos := map[string]int{"line": line, "character": char}
+		return map[string]any{"uri": lsp.URI(path), "range": map[string]any{"start": pos, "end": pos}}
+	}
+	for {
+		var length int
+		for {
+			line, err := in.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if line == "\r\n" {
+				break
+			}
+			fmt.Sscanf(line, "Content-Length: %d", &length)
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(in, body); err != nil {
+			return
+		}
+		var msg struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Query    string       `json:"query"`
+				Position lsp.Position `json:"position"`
+				NewName  string       `json:"newName"`
+			} `json:"params"`
+		}
+		json.Unmarshal(body, &msg)
+		var result any
+		switch msg.Method {
+		case "initialize":
+			result = map[string]any{"capabilities": map[string]any{}}
+		case "workspace/symbol":
+			if msg.Params.Query == "NewExecutor" {
+				result = []any{
+					map[string]any{"name": "example.com/m/core.NewExecutor", "kind": 12, "location": loc("core/core.go", 2, 5)},
+					map[string]any{"name": "example.com/m/other.NewExecutorPool", "kind": 12, "location": loc("other/other.go", 2, 5)},
+				}
+			}
+		case "textDocument/definition":
+			result = loc("core/core.go", 2, 5)
+		case "textDocument/references":
+			result = []any{loc("main.go", 4, 6), loc("main.go", 5, 6), loc("main.go", 4, 6), loc("secrets/keys.go", 3, 1), loc("/usr/lib/go/x.go", 1, 1)}
+		case "textDocument/rename":
+			edit := func(path string, line, start, end int) map[string]any {
+				r := map[string]any{"start": map[string]int{"line": line, "character": start}, "end": map[string]int{"line": line, "character": end}}
+				return map[string]any{"range": r, "newText": msg.Params.NewName}
+			}
+			changes := map[string]any{
+				lsp.URI(filepath.Join(root, "core/core.go")): []any{edit("core/core.go", 2, 5, 16)},
+				lsp.URI(filepath.Join(root, "main.go")):      []any{edit("main.go", 5, 13, 24), edit("main.go", 4, 13, 24)},
+			}
+			if msg.Params.NewName == "Secret" {
+				changes[lsp.URI(filepath.Join(root, "secrets/keys.go"))] = []any{edit("secrets/keys.go", 2, 4, 7)}
+			}
+			result = map[string]any{"changes": changes}
+		case "shutdown":
+		case "exit":
+			return
+		}
+		if msg.ID != nil {
+			reply, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *msg.ID, "result": result})
+			fmt.Printf("Content-Length: %d\r\n\r\n%s", len(reply), reply)
+		}
+	}
+}
+
+// lspRepo creates a repository with the files fakeLanguageServer knows
+func lspRepo(t *testing.T) *config.Config {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":          "module example.com/m\n",
+		"core/core.go":    "package core\n\nfunc NewExecutor() int { return 1 }\n",
+		"main.go":         "package main\n\nimport \"example.com/m/core\"\n\nvar a = core.NewExecutor()\nvar b = core.NewExecutor()\n",
+		"secrets/keys.go": "package secrets\n\nvar key = 1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("EVALUATOR_TEST_LSP", "1")
+	return &config.Config{
+		RepositoryRoot:  root,
+		ExcludedPaths:   []string{"secrets/"},
+		LanguageServers: map[string]string{"go": os.Args[0]},
+		LSPTimeout:      10 * time.Second,
+	}
+}
+
+func TestExecuteLSP(t *testing.T) {
+	cfg := lspRepo(t)
+	executor := NewExecutor(cfg, nil, nil, nil)
+	defer executor.Close()
+
+	result := executor.Execute(scanner.Command{Type: "def", Argument: "core.NewExecutor"})
+	if !result.Success || !strings.Contains(result.Result, "core/core.go:3:6: func NewExecutor() int { return 1 } (function)") || strings.Contains(result.Result, "other") {
+		t.Errorf("<def core.NewExecutor> = %v:\n%s", result.Error, result.Result)
+	}
+
+	// Repeats are listed once; excluded files and those outside the
+	// repository are only counted
+	result = executor.Execute(scanner.Command{Type: "refs", Argument: "NewExecutor"})
+	want := "References to NewExecutor: 2 locations in 1 file\nmain.go:5:7: var a = core.NewExecutor()\nmain.go:6:7: var b = core.NewExecutor()\n(2 more outside the repository or in excluded files)\n"
+	if !result.Success || result.Result != want {
+		t.Errorf("<refs NewExecutor> = %v:\n%s\nwant:\n%s", result.Error, result.Result, want)
+	}
+
+	// Without a column, the position is the line's first name
+	result = executor.Execute(scanner.Command{Type: "def", Argument: "main.go:5"})
+	if !result.Success || !strings.HasPrefix(result.Result, "Definition of the symbol at main.go:5:5: 1 location in 1 file\ncore/core.go:3:6:") {
+		t.Errorf("<def main.go:5> = %v:\n%s", result.Error, result.Result)
+	}
+
+	result = executor.Execute(scanner.Command{Type: "def", Argument: "Missing"})
+	if result.Success || !strings.Contains(result.Error.Error(), "LSP_FAILED") {
+		t.Errorf("<def Missing> = %+v, want LSP_FAILED", result)
+	}
+	result = executor.Execute(scanner.Command{Type: "refs", Argument: "secrets/keys.go:3:5"})
+	if result.Success || !strings.Contains(result.Error.Error(), "PATH_SECURITY") {
+		t.Errorf("<refs> of an excluded file = %+v, want PATH_SECURITY", result)
+	}
+}
+
+func TestExecuteLSP_Unavailable(t *testing.T) {
+	cfg := lspRepo(t)
+	tests := map[string]map[string]string{
+		"not installed": {"go": "no-such-language-server"},
+		"none":          {},
+	}
+	for name, servers := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg.LanguageServers = servers
+			result := NewExecutor(cfg, nil, nil, nil).Execute(scanner.Command{Type: "def", Argument: "core.NewExecutor"})
+			if result.Success || !strings.Contains(result.Error.Error(), "LSP_UNAVAILABLE") {
+				t.Errorf("result = %+v, want LSP_UNAVAILABLE", result)
+			}
+		})
+	}
+}
+
+func TestParseLSPQuery(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want lspQuery
+		ok   bool
+	}{
+		{"internal/core.NewCommandExecutor", lspQuery{symbol: "internal/core.NewCommandExecutor"}, true},
+		{"Session.LogAudit lang=go", lspQuery{symbol: "Session.LogAudit", language: "go"}, true},
+		{"pkg/app/app.go:120:5", lspQuery{path: "pkg/app/app.go", pos: lsp.Position{Line: 119, Character: 4}, column: true}, true},
+		{"app.py:3", lspQuery{path: "app.py", pos: lsp.Position{Line: 2}}, true},
+		{"a.go:0:1", lspQuery{}, false},
+		{"", lspQuery{}, false},
+		{"one two", lspQuery{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseLSPQuery(tt.arg)
+		if (err == nil) != tt.ok || (tt.ok && got != tt.want) {
+			t.Errorf("parseLSPQuery(%q) = %+v, %v", tt.arg, got, err)
+		}
+	}
+}
+
+func TestFirstWord(t *testing.T) {
+	tests := map[string]int{
+		"func (s *Session) LogAudit(cmd string) {": 18,
+		"	result := run()":                         1,
+		"type Config struct {":                     5,
+		"":                                         0,
+	}
+	for line, want := range tests {
+		if got := firstWord(line); got != want {
+			t.Errorf("firstWord(%q) = %d, want %d", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/memory"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
+)
+
+// SetMemory sets the session's memory document, which <note> appends to,
+// <memory> reads, and the files each successful command changes are
+// recorded in. Without one, <note> and <memory> are refused.
+func (e *Executor) SetMemory(m *memory.File) {
+	e.memory = m
+}
+
+// executeNote records a decision or finding of the LLM's in the memory
+// document
+func executeNote(arg string, m *memory.File, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "note", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("note", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if m == nil {
+		return fail(errors.New(errors.MemoryDisabled, "the session keeps no memory document; start it with --memory"))
+	}
+	text := strings.TrimSpace(arg)
+	if text == "" {
+		return fail(errors.New(errors.ParseError, "note needs text, as in <note chose sqlite for the cache>"))
+	}
+	if err := m.Append("note: " + text); err != nil {
+		return fail(errors.Wrap(errors.MemoryFailed, err))
+	}
+
+	result.Success = true
+	result.Result = "Noted in the session memory\n"
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("note", arg, true, "")
+	}
+	return result
+}
+
+// executeMemory returns the memory document, its last MemoryMaxRead bytes
+// when it is longer
+func executeMemory(m *memory.File, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "memory"},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("memory", "", false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if m == nil {
+		return fail(errors.New(errors.MemoryDisabled, "the session keeps no memory document; start it with --memory"))
+	}
+	text, err := m.Read(config.MemoryMaxRead)
+	if err != nil {
+		return fail(errors.Wrap(errors.MemoryFailed, err))
+	}
+	if text == "" {
+		text = "The session memory is empty: nothing has been changed or noted yet.\n"
+	}
+
+	result.Success = true
+	result.Result = text
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("memory", "", true, fmt.Sprintf("bytes:%d", len(text)))
+	}
+	return result
+}
+
+// recordMemory appends what a successful command changed to the memory
+// document. A failure to write it is audited, not reported to the LLM.
+func (e *Executor) recordMemory(result scanner.ExecutionResult) {
+	if e.memory == nil || !result.Success || scratch.IsPath(result.Command.Argument) {
+		return
+	}
+	var entry string
+	switch result.Command.Type {
+	case "write":
+		if result.Action != "CREATED" && result.Action != "UPDATED" {
+			return
+		}
+		entry = fmt.Sprintf("wrote %s (%s)", result.Command.Argument, strings.ToLower(result.Action))
+	case "append":
+		entry = "appended to " + result.Command.Argument
+	case "rename-symbol":
+		entry, _, _ = strings.Cut(result.Result, "\n")
+	case "git-commit":
+		first, _, _ := strings.Cut(result.Result, "\n")
+		entry = fmt.Sprintf("%s: %s", first, result.Command.Argument)
+	default:
+		return
+	}
+	if err := e.memory.Append(entry); err != nil && e.auditLog != nil {
+		e.auditLog("memory", entry, false, errors.Wrap(errors.MemoryFailed, err).Error())
+	}
+}
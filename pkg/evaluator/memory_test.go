@@ -0,0 +1,59 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/memory"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestExecute_Memory(t *testing.T) {
+	tmpDir := t.TempDir()
+	sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+	t.Cleanup(func() { sandbox.SetFakeBackend(nil) })
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxWriteSize = 1 << 20
+	executor := NewExecutor(cfg, nil, nil, nil)
+	defer executor.Close()
+
+	for _, cmd := range []scanner.Command{{Type: "note", Argument: "x"}, {Type: "memory"}} {
+		if result := executor.Execute(cmd); result.Success || !strings.Contains(result.Error.Error(), "MEMORY_DISABLED") {
+			t.Errorf("%s without memory = %+v, want MEMORY_DISABLED", cmd.Type, result)
+		}
+	}
+
+	path := filepath.Join(tmpDir, config.DefaultMemoryFile)
+	executor.SetMemory(memory.Open(path, "s1", func() time.Time { return config.DeterministicTime }))
+	if result := executor.Execute(scanner.Command{Type: "memory"}); !result.Success || !strings.Contains(result.Result, "memory is empty") {
+		t.Errorf("empty memory = %+v", result)
+	}
+	steps := []scanner.Command{
+		{Type: "write", Argument: "main.go", Content: "package main\n"},
+		{Type: "write", Argument: "main.go", Content: "package main\n"},
+		{Type: "note", Argument: "chose sqlite for the cache"},
+		{Type: "note", Argument: "  "},
+	}
+	for _, cmd := range steps {
+		executor.Execute(cmd)
+	}
+
+	result := executor.Execute(scanner.Command{Type: "memory"})
+	if !result.Success {
+		t.Fatalf("memory = %v", result.Error)
+	}
+	data, _ := os.ReadFile(path)
+	if result.Result != string(data) {
+		t.Errorf("memory = %q, want the document %q", result.Result, data)
+	}
+	// The unchanged second write and the empty note are not recorded
+	entries := strings.Count(result.Result, "\n- ")
+	if entries != 2 || !strings.Contains(result.Result, "wrote main.go (created)\n") || !strings.Contains(result.Result, "note: chose sqlite for the cache\n") {
+		t.Errorf("memory has %d entries:\n%s", entries, result.Result)
+	}
+}
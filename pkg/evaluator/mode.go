@@ -0,0 +1,44 @@
+package evaluator
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+// writeFileMode returns the permission bits a write gives its file: those
+// requested with mode=, which must be in cfg.AllowedModes, or else those of
+// the file being replaced, given by existing. A new file written without
+// mode= gets 0, leaving the container's default.
+func writeFileMode(requested string, existing os.FileInfo, cfg *config.Config) (os.FileMode, error) {
+	if requested == "" {
+		if existing == nil {
+			return 0, nil
+		}
+		return existing.Mode().Perm(), nil
+	}
+
+	mode, err := parseFileMode(requested)
+	if err != nil {
+		return 0, err
+	}
+	for _, allowed := range cfg.AllowedModes {
+		if allowedMode, err := parseFileMode(allowed); err == nil && allowedMode == mode {
+			return mode, nil
+		}
+	}
+	return 0, errors.Newf(errors.ModeDenied, "mode %04o is not allowed (allowed: %s)", mode, strings.Join(cfg.AllowedModes, ", "))
+}
+
+// parseFileMode parses an octal mode such as 0755 or 644. Only permission
+// bits may be set: setuid, setgid, and sticky bits are refused.
+func parseFileMode(s string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+	if err != nil || value > 0777 {
+		return 0, errors.Newf(errors.ModeDenied, "invalid mode %q; give permission bits in octal, such as 0644 or 0755", s)
+	}
+	return os.FileMode(value), nil
+}
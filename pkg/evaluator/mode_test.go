@@ -0,0 +1,109 @@
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+func TestWriteFileMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.AllowedModes = []string{"0644", "755"}
+
+	script := filepath.Join(tmpDir, "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(script, 0750); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		requested string
+		existing  os.FileInfo
+		want      os.FileMode
+		wantErr   string
+	}{
+		{"new file", "", nil, 0, ""},
+		{"existing file keeps its mode", "", info, 0750, ""},
+		{"allowed", "0755", nil, 0755, ""},
+		{"allowed over existing", "0644", info, 0644, ""},
+		{"0o prefix", "0o755", nil, 0755, ""},
+		{"not allowed", "0777", nil, 0, "MODE_DENIED: mode 0777 is not allowed"},
+		{"setuid", "4755", nil, 0, "MODE_DENIED: invalid mode"},
+		{"not octal", "rwxr-xr-x", nil, 0, "MODE_DENIED: invalid mode"},
+	}
+	for _, tt := range tests {
+		got, err := writeFileMode(tt.requested, tt.existing, cfg)
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: writeFileMode = %04o, %v, want %04o", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestExecuteWrite_ModeDenied(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.AllowedModes = []string{"0644", "0755"}
+
+	audit := &testAuditLog{}
+	result := executeWrite(context.Background(), "tool.py", "print(1)\n", "0777", cfg, audit.log, nil)
+	if result.Success || result.Error == nil || !strings.HasPrefix(result.Error.Error(), "MODE_DENIED") {
+		t.Fatalf("success=%v err=%v, want MODE_DENIED", result.Success, result.Error)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "tool.py")); !os.IsNotExist(err) {
+		t.Error("file was written despite the denied mode")
+	}
+	if len(audit.entries) != 1 || audit.entries[0].success {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+}
+
+func TestExecuteWrite_PreservesMode(t *testing.T) {
+	if sandbox.CheckDockerAvailability() != nil {
+		t.Skip("Docker not available")
+	}
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.BackupBeforeWrite = false
+	cfg.AllowedModes = []string{"0644", "0755"}
+
+	script := filepath.Join(tmpDir, "build.py")
+	if err := os.WriteFile(script, []byte("print(1)\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if result := ExecuteWrite("build.py", "print(2)\n", cfg, nil, nil); !result.Success {
+		t.Fatalf("write failed: %v", result.Error)
+	}
+	if info, err := os.Stat(script); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0755 {
+		t.Errorf("mode after update = %04o, want 0755", info.Mode().Perm())
+	}
+
+	result := executeWrite(context.Background(), "deploy.py", "print(3)\n", "0755", cfg, nil, nil)
+	if !result.Success {
+		t.Fatalf("write with mode failed: %v", result.Error)
+	}
+	if info, err := os.Stat(filepath.Join(tmpDir, "deploy.py")); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0755 {
+		t.Errorf("mode of new file = %04o, want 0755", info.Mode().Perm())
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"context"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
 	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
@@ -13,16 +17,44 @@ import (
 
 // ExecuteOpen handles the "open" command
 func ExecuteOpen(filepath string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeOpen(context.Background(), filepath, cfg, auditLog, pool)
+}
+
+// executeOpen is ExecuteOpen as part of the trace in ctx
+func executeOpen(ctx context.Context, filepath string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
 	startTime := time.Now()
 	result := scanner.ExecutionResult{
 		Command: scanner.Command{Type: "open", Argument: filepath},
 	}
 
+	// A cursor=TOKEN suffix continues a chunked open, and a
+	// path:first-last suffix opens a range of lines
+	path, cursor, chunked := splitCursor(filepath)
+	path, first, last, ranged := splitLineRange(path)
+	if chunked && ranged {
+		result.Success = false
+		result.Error = errors.New(errors.InvalidCursor, "a cursor cannot be combined with a line range")
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("open", filepath, false, result.Error.Error())
+		}
+		return result
+	}
+	if ranged && (first < 1 || last < first) {
+		result.Success = false
+		result.Error = errors.Newf(errors.InvalidRange, "lines %d-%d", first, last)
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("open", filepath, false, result.Error.Error())
+		}
+		return result
+	}
+
 	// Validate the path
-	safePath, err := sandbox.ValidatePath(filepath, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	safePath, err := sandbox.ValidateHostPath(path, cfg.RepositoryRoot, cfg.ExcludedPaths)
 	if err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("PATH_SECURITY: %w", err)
+		fullError := errors.Wrap(errors.PathSecurity, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -31,15 +63,29 @@ func ExecuteOpen(filepath string, cfg *config.Config, auditLog func(cmd, arg str
 		return result
 	}
 
+	// Honor .gitignore/.llmignore files
+	if cfg.RespectIgnoreFiles {
+		if err := sandbox.CheckIgnored(safePath, cfg.RepositoryRoot); err != nil {
+			result.Success = false
+			fullError := errors.Wrap(errors.PathSecurity, err)
+			result.Error = SanitizeError(fullError) // Sanitized for LLM
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("open", filepath, false, fullError.Error()) // Full error to audit
+			}
+			return result
+		}
+	}
+
 	// Check if file exists
 	fileInfo, err := os.Stat(safePath)
 	if err != nil {
 		result.Success = false
 		if os.IsNotExist(err) {
-			fullError := fmt.Errorf("FILE_NOT_FOUND: %s", filepath)
+			fullError := errors.New(errors.FileNotFound, filepath)
 			result.Error = SanitizeError(fullError)
 		} else {
-			fullError := fmt.Errorf("PERMISSION_DENIED: %w", err)
+			fullError := errors.Wrap(errors.PermissionDenied, err)
 			result.Error = SanitizeError(fullError)
 		}
 		result.ExecutionTime = time.Since(startTime)
@@ -49,11 +95,20 @@ func ExecuteOpen(filepath string, cfg *config.Config, auditLog func(cmd, arg str
 		return result
 	}
 
-	// Check file size
-	if fileInfo.Size() > cfg.MaxFileSize {
+	// Check file size. Whole files over the limit but within
+	// MaxChunkedSize are opened a chunk at a time.
+	maxSize := cfg.MaxFileSize
+	if !ranged && cfg.MaxChunkedSize > maxSize {
+		maxSize = cfg.MaxChunkedSize
+		chunked = chunked || fileInfo.Size() > cfg.MaxFileSize
+	}
+	// With TruncateOversize, whole files over every limit show their
+	// start and end instead
+	truncated := fileInfo.Size() > maxSize && cfg.TruncateOversize && !ranged && cursor == ""
+	if fileInfo.Size() > maxSize && !truncated {
 		result.Success = false
-		fullError := fmt.Errorf("RESOURCE_LIMIT: file too large (%d bytes, max %d)",
-			fileInfo.Size(), cfg.MaxFileSize)
+		fullError := errors.Newf(errors.ResourceLimit, "file too large (%d bytes, max %d)",
+			fileInfo.Size(), maxSize)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -61,18 +116,76 @@ func ExecuteOpen(filepath string, cfg *config.Config, auditLog func(cmd, arg str
 		}
 		return result
 	}
+
+	// Guard against dumping binary content into the LLM context
+	if contentInfo, err := DetectContent(safePath); err == nil {
+		result.ContentType = contentInfo.ContentType
+		if contentInfo.Binary && !cfg.AllowBinary {
+			result.Success = true
+			result.Action = "BINARY_SUMMARY"
+			result.Result = formatBinarySummary(filepath, fileInfo.Size(), contentInfo, cfg.BinaryHexBytes)
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("open", filepath, true, fmt.Sprintf("binary:%s,bytes:%d", contentInfo.ContentType, fileInfo.Size()))
+			}
+			return result
+		}
+	}
+
+	if truncated {
+		content, detail, err := openTruncated(ctx, safePath, fileInfo, maxSize, cfg, pool)
+		if err != nil {
+			result.Success = false
+			result.Error = SanitizeError(err) // Sanitized for LLM
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("open", filepath, false, err.Error()) // Full error to audit
+			}
+			return result
+		}
+		result.Success = true
+		result.Action = "TRUNCATED"
+		result.Result = content
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("open", filepath, true, detail)
+		}
+		return result
+	}
+
+	if chunked {
+		content, detail, err := openChunk(ctx, path, cursor, safePath, fileInfo, cfg, pool)
+		if err != nil {
+			result.Success = false
+			result.Error = SanitizeError(err) // Sanitized for LLM
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("open", filepath, false, err.Error()) // Full error to audit
+			}
+			return result
+		}
+		result.Success = true
+		result.Action = "CHUNK"
+		result.Result = content
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("open", filepath, true, detail)
+		}
+		return result
+	}
+
 	// Read the file using container
 	var content []byte
 	// Use containerized I/O
 	contentStr, err := sandbox.ReadFileInContainerPooled(
-		context.Background(),
+		ctx,
 		pool,
 		safePath,
 		cfg.RepositoryRoot,
 	)
 	if err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("READ_CONTAINER: %w", err)
+		fullError := errors.Wrap(errors.ReadContainer, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -80,6 +193,18 @@ func ExecuteOpen(filepath string, cfg *config.Config, auditLog func(cmd, arg str
 		}
 		return result
 	}
+	if ranged {
+		contentStr, err = selectLines(contentStr, first, last)
+		if err != nil {
+			result.Success = false
+			result.Error = err
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("open", filepath, false, err.Error())
+			}
+			return result
+		}
+	}
 	content = []byte(contentStr)
 
 	result.Success = true
@@ -91,3 +216,34 @@ func ExecuteOpen(filepath string, cfg *config.Config, auditLog func(cmd, arg str
 
 	return result
 }
+
+// lineRange matches an open argument ending in :first-last
+var lineRange = regexp.MustCompile(`^(.+):(\d+)-(\d+)$`)
+
+// splitLineRange splits an open argument such as main.go:10-20 into the
+// path and the range of lines, which are numbered from 1
+func splitLineRange(arg string) (path string, first, last int, ok bool) {
+	m := lineRange.FindStringSubmatch(arg)
+	if m == nil {
+		return arg, 0, 0, false
+	}
+	first, err1 := strconv.Atoi(m[2])
+	last, err2 := strconv.Atoi(m[3])
+	if err1 != nil || err2 != nil {
+		return arg, 0, 0, false
+	}
+	return m[1], first, last, true
+}
+
+// selectLines returns lines first through last of content. A range running
+// past the end of the file stops at its last line.
+func selectLines(content string, first, last int) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if first > len(lines) {
+		return "", errors.Newf(errors.InvalidRange, "line %d is past the end of the file (%d lines)", first, len(lines))
+	}
+	return strings.Join(lines[first-1:min(last, len(lines))], ""), nil
+}
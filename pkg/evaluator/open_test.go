@@ -283,9 +283,9 @@ func TestExecuteOpen_EmptyFile(t *testing.T) {
 }
 
 func TestExecuteOpen_BinaryContent(t *testing.T) {
-	t.Skip("TODO: Fix binary content handling")
 	tmpDir := t.TempDir()
 	cfg := newTestConfig(tmpDir)
+	cfg.BinaryHexBytes = 16
 
 	// Create file with binary content
 	binaryContent := []byte{0x00, 0x01, 0x02, 0xFF, 0xFE, 0xFD}
@@ -294,14 +294,58 @@ func TestExecuteOpen_BinaryContent(t *testing.T) {
 		t.Fatalf("failed to create test file: %v", err)
 	}
 
-	result := ExecuteOpen("binary.txt", cfg, nil, nil)
+	auditLog := &testAuditLog{}
+	result := ExecuteOpen("binary.txt", cfg, auditLog.log, nil)
 
 	if !result.Success {
-		t.Errorf("expected success, got error: %v", result.Error)
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+
+	if result.Action != "BINARY_SUMMARY" {
+		t.Errorf("expected BINARY_SUMMARY action, got %q", result.Action)
+	}
+
+	if strings.Contains(result.Result, string(binaryContent)) {
+		t.Error("raw binary content should not be returned")
 	}
 
-	if result.Result != string(binaryContent) {
-		t.Error("binary content mismatch")
+	for _, want := range []string{"Binary file not shown: binary.txt", "Size: 6 bytes", "Detected type: application/octet-stream", "00 01 02 ff fe fd"} {
+		if !strings.Contains(result.Result, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, result.Result)
+		}
+	}
+
+	if result.ContentType != "application/octet-stream" {
+		t.Errorf("expected content type application/octet-stream, got %q", result.ContentType)
+	}
+
+	entries := auditLog.getEntries()
+	if len(entries) != 1 || !entries[0].success || !strings.Contains(entries[0].errMsg, "binary:") {
+		t.Errorf("expected successful binary audit entry, got %+v", entries)
+	}
+}
+
+func TestExecuteOpen_BinaryContentNoHexHead(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.BinaryHexBytes = 0
+
+	testFile := filepath.Join(tmpDir, "image.png")
+	pngHeader := []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00, 0x00}
+	if err := os.WriteFile(testFile, pngHeader, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	result := ExecuteOpen("image.png", cfg, nil, nil)
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if !strings.Contains(result.Result, "Detected type: image/png") {
+		t.Errorf("expected PNG type detection, got:\n%s", result.Result)
+	}
+	if strings.Contains(result.Result, "Hex head") {
+		t.Error("expected no hex head when BinaryHexBytes is 0")
 	}
 }
 
@@ -362,7 +406,6 @@ func TestExecuteOpen_NilAuditLogOnError(t *testing.T) {
 }
 
 func TestExecuteOpen_SpecialCharactersInFilename(t *testing.T) {
-	t.Skip("TODO: Fix shell quoting for filenames with spaces")
 	tmpDir := t.TempDir()
 	cfg := newTestConfig(tmpDir)
 
@@ -681,3 +724,44 @@ func TestExecuteOpen_ReadErrorOnDirectory(t *testing.T) {
 		t.Error("audit should show failure")
 	}
 }
+
+func TestSplitLineRange(t *testing.T) {
+	tests := []struct {
+		arg         string
+		path        string
+		first, last int
+		ok          bool
+	}{
+		{"main.go:10-20", "main.go", 10, 20, true},
+		{"dir/a:b.txt:1-1", "dir/a:b.txt", 1, 1, true},
+		{"main.go", "main.go", 0, 0, false},
+		{"main.go:10", "main.go:10", 0, 0, false},
+	}
+	for _, tt := range tests {
+		path, first, last, ok := splitLineRange(tt.arg)
+		if path != tt.path || first != tt.first || last != tt.last || ok != tt.ok {
+			t.Errorf("splitLineRange(%q) = %q, %d, %d, %v", tt.arg, path, first, last, ok)
+		}
+	}
+}
+
+func TestSelectLines(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\n"
+	if got, _ := selectLines(content, 2, 3); got != "two\nthree\n" {
+		t.Errorf("lines 2-3 = %q", got)
+	}
+	if got, _ := selectLines(content, 3, 99); got != "three\nfour\n" {
+		t.Errorf("lines 3-99 = %q", got)
+	}
+	if _, err := selectLines(content, 5, 6); err == nil || !strings.Contains(err.Error(), "INVALID_RANGE") {
+		t.Errorf("range past end: err = %v", err)
+	}
+}
+
+func TestExecuteOpen_InvalidRange(t *testing.T) {
+	cfg := &config.Config{RepositoryRoot: t.TempDir(), MaxFileSize: 1024}
+	result := ExecuteOpen("main.go:20-10", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "INVALID_RANGE") {
+		t.Errorf("result = %+v, want INVALID_RANGE", result)
+	}
+}
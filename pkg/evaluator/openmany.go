@@ -0,0 +1,254 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// openManyMaxFiles matches an open-many argument ending in max_files=N
+var openManyMaxFiles = regexp.MustCompile(`^(.+?)\s+max_files=(\d+)$`)
+
+// openManyFile is a file an <open-many> pattern matched
+type openManyFile struct {
+	rel      string // Repository-relative, with forward slashes
+	safePath string
+	size     int64
+}
+
+// ExecuteOpenMany handles the "open-many" command
+func ExecuteOpenMany(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeOpenMany(context.Background(), arg, cfg, auditLog, pool)
+}
+
+// executeOpenMany is ExecuteOpenMany as part of the trace in ctx. It opens
+// the files matching a glob, or every file under a directory, in path
+// order, each under a separator line naming it. Files over MaxFileSize
+// and binary files are listed as skipped; the rest are opened until
+// max_files or OpenManyMaxBytes is reached, and what was left out is
+// listed at the end so the LLM can open it on its own.
+func executeOpenMany(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open-many", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("open-many", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	pattern, maxFiles, err := parseOpenManyArg(arg, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	files, err := findOpenManyFiles(pattern, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	if len(files) == 0 {
+		return fail(errors.Newf(errors.FileNotFound, "no files match %s", pattern))
+	}
+
+	maxBytes := cfg.OpenManyMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultOpenManyMaxBytes
+	}
+	var b strings.Builder
+	var skipped, notOpened []string
+	opened, total := 0, int64(0)
+	for _, f := range files {
+		switch {
+		case f.size > cfg.MaxFileSize:
+			skipped = append(skipped, fmt.Sprintf("%s (%d bytes, over the %d byte limit)", f.rel, f.size, cfg.MaxFileSize))
+			continue
+		case opened >= maxFiles || total+f.size > maxBytes:
+			notOpened = append(notOpened, f.rel)
+			continue
+		}
+		if info, err := DetectContent(f.safePath); err == nil && info.Binary && !cfg.AllowBinary {
+			skipped = append(skipped, fmt.Sprintf("%s (binary, %s)", f.rel, info.ContentType))
+			continue
+		}
+
+		content, err := sandbox.ReadFileInContainerPooled(ctx, pool, f.safePath, cfg.RepositoryRoot)
+		if err != nil {
+			return fail(errors.Wrap(errors.ReadContainer, err))
+		}
+		if cfg.TokenAnnotations {
+			tokens := estimateTokens(cfg.TokenEstimator, content)
+			fmt.Fprintf(&b, "===== %s (≈%d tokens) =====\n%s", f.rel, tokens, content)
+		} else {
+			fmt.Fprintf(&b, "===== %s =====\n%s", f.rel, content)
+		}
+		if !strings.HasSuffix(content, "\n") {
+			b.WriteString("\n")
+		}
+		opened++
+		total += f.size
+	}
+
+	fmt.Fprintf(&b, "===== opened %d of %d matching files, %d bytes =====\n", opened, len(files), total)
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "Skipped: %s\n", strings.Join(skipped, ", "))
+	}
+	if len(notOpened) > 0 {
+		fmt.Fprintf(&b, "Not opened, past max_files=%d or the %d byte budget: %s\n", maxFiles, maxBytes, strings.Join(notOpened, ", "))
+	}
+
+	result.Success = true
+	result.Result = b.String()
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("open-many", arg, true, fmt.Sprintf("matched:%d,opened:%d,bytes:%d", len(files), opened, total))
+	}
+	return result
+}
+
+// parseOpenManyArg splits an open-many argument into its pattern and the
+// most files to open, which is cfg.OpenManyMaxFiles unless max_files=
+// asks for fewer
+func parseOpenManyArg(arg string, cfg *config.Config) (string, int, error) {
+	maxFiles := cfg.OpenManyMaxFiles
+	if maxFiles <= 0 {
+		maxFiles = config.DefaultOpenManyMaxFiles
+	}
+	pattern := strings.TrimSpace(arg)
+	if match := openManyMaxFiles.FindStringSubmatch(pattern); match != nil {
+		n, err := strconv.Atoi(match[2])
+		if err != nil || n < 1 {
+			return "", 0, errors.Newf(errors.ParseError, "max_files=%s must be at least 1", match[2])
+		}
+		pattern = match[1]
+		if n < maxFiles {
+			maxFiles = n
+		}
+	}
+	if pattern == "" {
+		return "", 0, errors.New(errors.ParseError, "open-many needs a glob pattern or directory, such as internal/parser/*.go")
+	}
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	if path.IsAbs(pattern) || pattern == ".." || strings.HasPrefix(pattern, "../") {
+		return "", 0, errors.Newf(errors.PathSecurity, "pattern is not within repository: %s", pattern)
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return "", 0, errors.Newf(errors.ParseError, "malformed pattern %s: %v", pattern, err)
+		}
+	}
+	return pattern, maxFiles, nil
+}
+
+// findOpenManyFiles returns the files under the repository matching
+// pattern, in path order. A pattern without wildcards names a file or a
+// directory, whose files are all matched at any depth. Excluded and, if
+// configured, ignored paths are left out.
+func findOpenManyFiles(pattern string, cfg *config.Config) ([]openManyFile, error) {
+	base := globBase(pattern)
+	safeBase, err := sandbox.ValidatePath(base, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return nil, errors.Wrap(errors.PathSecurity, err)
+	}
+	if cfg.RespectIgnoreFiles && base != "." {
+		if err := sandbox.CheckIgnored(safeBase, cfg.RepositoryRoot); err != nil {
+			return nil, errors.Wrap(errors.PathSecurity, err)
+		}
+	}
+	info, err := os.Stat(safeBase)
+	if err != nil {
+		return nil, errors.Newf(errors.FileNotFound, "no files match %s", pattern)
+	}
+	if base == pattern && info.IsDir() {
+		pattern = path.Join(pattern, "**")
+	}
+
+	var files []openManyFile
+	err = filepath.WalkDir(safeBase, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel := repoRelativePath(p, cfg.RepositoryRoot)
+		if p != safeBase {
+			if _, err := sandbox.ValidatePath(rel, cfg.RepositoryRoot, cfg.ExcludedPaths); err != nil {
+				return skipEntry(d)
+			}
+			if cfg.RespectIgnoreFiles && sandbox.CheckIgnored(p, cfg.RepositoryRoot) != nil {
+				return skipEntry(d)
+			}
+		}
+		if !d.Type().IsRegular() || !matchGlob(pattern, rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, openManyFile{rel: rel, safePath: p, size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, nil
+}
+
+// globBase returns the leading directories of pattern that hold no
+// wildcards, where matching files are searched for
+func globBase(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			if i == 0 {
+				return "."
+			}
+			return strings.Join(segments[:i], "/")
+		}
+	}
+	return pattern
+}
+
+// matchGlob reports whether the slash-separated name matches pattern,
+// whose segments are path.Match patterns or "**" for any number of
+// directories
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,173 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"internal/parser/*.go", "internal/parser/lexer.go", true},
+		{"internal/parser/*.go", "internal/parser/sub/lexer.go", false},
+		{"internal/parser/*.go", "internal/parser/README.md", false},
+		{"internal/**/*.go", "internal/parser/sub/lexer.go", true},
+		{"internal/**/*.go", "internal/main.go", true},
+		{"internal/**", "internal/a/b/c.txt", true},
+		{"*.md", "docs/README.md", false},
+		{"cmd/?ain.go", "cmd/main.go", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := map[string]string{
+		"internal/parser/*.go": "internal/parser",
+		"internal/**/*.go":     "internal",
+		"*.go":                 ".",
+		"docs":                 "docs",
+	}
+	for pattern, want := range tests {
+		if got := globBase(pattern); got != want {
+			t.Errorf("globBase(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestParseOpenManyArg(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	cfg.OpenManyMaxFiles = 10
+
+	tests := []struct {
+		arg       string
+		pattern   string
+		maxFiles  int
+		errPrefix string
+	}{
+		{arg: "internal/parser/*.go max_files=5", pattern: "internal/parser/*.go", maxFiles: 5},
+		{arg: "internal/parser/*.go max_files=50", pattern: "internal/parser/*.go", maxFiles: 10},
+		{arg: "./docs/", pattern: "docs", maxFiles: 10},
+		{arg: "src/*.go max_files=0", errPrefix: "PARSE_ERROR"},
+		{arg: "src/[a-.go", errPrefix: "PARSE_ERROR"},
+		{arg: "../other/*.go", errPrefix: "PATH_SECURITY"},
+		{arg: "/etc/*", errPrefix: "PATH_SECURITY"},
+	}
+	for _, tt := range tests {
+		pattern, maxFiles, err := parseOpenManyArg(tt.arg, cfg)
+		if tt.errPrefix != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.errPrefix) {
+				t.Errorf("parseOpenManyArg(%q) error = %v, want %s", tt.arg, err, tt.errPrefix)
+			}
+			continue
+		}
+		if err != nil || pattern != tt.pattern || maxFiles != tt.maxFiles {
+			t.Errorf("parseOpenManyArg(%q) = %q, %d, %v; want %q, %d", tt.arg, pattern, maxFiles, err, tt.pattern, tt.maxFiles)
+		}
+	}
+}
+
+func TestFindOpenManyFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	for _, name := range []string{"internal/parser/lexer.go", "internal/parser/ast.go", "internal/parser/sub/deep.go", "internal/parser/notes.md", "internal/parser/secret.key"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package parser\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := findOpenManyFiles("internal/parser/*.go", cfg)
+	if err != nil {
+		t.Fatalf("findOpenManyFiles() error = %v", err)
+	}
+	if got := openManyNames(files); got != "internal/parser/ast.go,internal/parser/lexer.go" {
+		t.Errorf("glob matched %s", got)
+	}
+
+	// A directory matches every file under it, except excluded ones
+	files, err = findOpenManyFiles("internal/parser", cfg)
+	if err != nil {
+		t.Fatalf("findOpenManyFiles() error = %v", err)
+	}
+	if got := openManyNames(files); got != "internal/parser/ast.go,internal/parser/lexer.go,internal/parser/notes.md,internal/parser/sub/deep.go" {
+		t.Errorf("directory matched %s", got)
+	}
+}
+
+func TestExecuteOpenMany_NoMatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := &testAuditLog{}
+	for _, arg := range []string{"*.py", "missing/*.go"} {
+		result := ExecuteOpenMany(arg, cfg, audit.log, nil)
+		if result.Success || result.Error == nil || !strings.HasPrefix(result.Error.Error(), "FILE_NOT_FOUND") {
+			t.Errorf("ExecuteOpenMany(%q) = %+v, want FILE_NOT_FOUND", arg, result)
+		}
+	}
+	if len(audit.entries) != 2 || audit.entries[0].cmdType != "open-many" || audit.entries[0].success {
+		t.Errorf("audit entries = %+v, want two failed open-many entries", audit.entries)
+	}
+}
+
+func TestExecuteOpenMany(t *testing.T) {
+	if sandbox.CheckDockerAvailability() != nil {
+		t.Skip("Docker not available")
+	}
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxFileSize = 100
+	files := map[string]string{
+		"src/a.go":   "package a\n",
+		"src/b.go":   "package b",
+		"src/big.go": strings.Repeat("// filler\n", 20),
+		"src/c.go":   "package c\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := ExecuteOpenMany("src/*.go max_files=2", cfg, nil, nil)
+	if !result.Success {
+		t.Fatalf("ExecuteOpenMany() failed: %v", result.Error)
+	}
+	want := "===== src/a.go =====\npackage a\n===== src/b.go =====\npackage b\n" +
+		"===== opened 2 of 4 matching files, 19 bytes =====\n" +
+		"Skipped: src/big.go (200 bytes, over the 100 byte limit)\n" +
+		"Not opened, past max_files=2 or the 262144 byte budget: src/c.go\n"
+	if result.Result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result.Result, want)
+	}
+}
+
+// openManyNames joins the paths of files for comparison
+func openManyNames(files []openManyFile) string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.rel
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,258 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// outlineEntry is one declaration in an outline
+type outlineEntry struct {
+	Line      int
+	Signature string
+	Members   []outlineEntry // Methods and fields of a type
+}
+
+// outline is the structure of a source file
+type outline struct {
+	Language   string
+	Package    string
+	Imports    []string
+	Entries    []outlineEntry
+	Incomplete bool // The file has syntax errors
+}
+
+// outlineLanguages maps file extensions to the language outlined for them
+var outlineLanguages = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".pyi":  "python",
+	".java": "java",
+}
+
+// ExecuteOutline handles the "outline" command
+func ExecuteOutline(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeOutline(context.Background(), arg, cfg, auditLog)
+}
+
+// executeOutline is ExecuteOutline as part of the trace in ctx. The
+// result lists a source file's imports, types, and function signatures
+// with their line numbers, a fraction of the tokens of the whole file.
+func executeOutline(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	path := strings.TrimSpace(arg)
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "outline", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("outline", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if path == "" {
+		return fail(errors.New(errors.ParseError, "outline needs a path: <outline main.go>"))
+	}
+	lang, ok := outlineLanguages[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return fail(errors.Newf(errors.OutlineUnsupported, "no outline for %s files (supported: .go, .py, .java)", filepath.Ext(path)))
+	}
+
+	// Validate the path as open does, and since the source is parsed on the
+	// host rather than read in a container, refuse symlinks leaving the root
+	safePath, err := sandbox.ValidateHostPath(path, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return fail(errors.Wrap(errors.PathSecurity, err))
+	}
+	if cfg.RespectIgnoreFiles {
+		if err := sandbox.CheckIgnored(safePath, cfg.RepositoryRoot); err != nil {
+			return fail(errors.Wrap(errors.PathSecurity, err))
+		}
+	}
+
+	info, err := os.Stat(safePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fail(errors.New(errors.FileNotFound, path))
+		}
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+	if info.IsDir() {
+		return fail(errors.Newf(errors.FileNotFound, "%s is a directory", path))
+	}
+	// An outline is how a file too large to open whole is looked at, so it
+	// takes files up to the chunked open limit
+	maxSize := cfg.MaxFileSize
+	if cfg.MaxChunkedSize > maxSize {
+		maxSize = cfg.MaxChunkedSize
+	}
+	if info.Size() > maxSize {
+		return fail(errors.Newf(errors.ResourceLimit, "file too large (%d bytes, max %d)", info.Size(), maxSize))
+	}
+	src, err := os.ReadFile(safePath)
+	if err != nil {
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+
+	var o *outline
+	if lang == "go" {
+		o = outlineGo(path, src)
+	} else if o, err = outlineTreeSitter(ctx, lang, src); err != nil {
+		return fail(err)
+	}
+
+	result.Success = true
+	lines := bytes.Count(src, []byte("\n"))
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		lines++
+	}
+	result.Result = formatOutline(path, o, lines)
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("outline", arg, true, fmt.Sprintf("language:%s,entries:%d,bytes:%d", lang, countOutlineEntries(o.Entries), len(src)))
+	}
+	return result
+}
+
+// formatOutline renders o for the LLM, one declaration per line after its
+// line number, with the members of a type indented below it
+func formatOutline(path string, o *outline, lines int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s, %d lines)\n", path, o.Language, lines)
+	if o.Incomplete {
+		b.WriteString("(the file has syntax errors; the outline may be incomplete)\n")
+	}
+	if o.Package != "" {
+		fmt.Fprintf(&b, "package %s\n", o.Package)
+	}
+	if len(o.Imports) > 0 {
+		fmt.Fprintf(&b, "imports: %s\n", strings.Join(o.Imports, ", "))
+	}
+	width := len(strconv.Itoa(lines))
+	var write func(entries []outlineEntry, indent string)
+	write = func(entries []outlineEntry, indent string) {
+		for _, e := range entries {
+			fmt.Fprintf(&b, "%*d  %s%s\n", width, e.Line, indent, e.Signature)
+			write(e.Members, indent+"  ")
+		}
+	}
+	if len(o.Entries) > 0 {
+		b.WriteString("\n")
+		write(o.Entries, "")
+	}
+	return b.String()
+}
+
+// countOutlineEntries counts entries and their members
+func countOutlineEntries(entries []outlineEntry) int {
+	n := len(entries)
+	for _, e := range entries {
+		n += countOutlineEntries(e.Members)
+	}
+	return n
+}
+
+// outlineGo outlines a Go file with go/ast. A file with syntax errors is
+// outlined as far as the parser got.
+func outlineGo(path string, src []byte) *outline {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	o := &outline{Language: "go", Incomplete: err != nil}
+	if file == nil {
+		return o
+	}
+	o.Package = file.Name.Name
+	for _, imp := range file.Imports {
+		name, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil {
+			name = imp.Name.Name + " " + name
+		}
+		o.Imports = append(o.Imports, name)
+	}
+
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			fn := *d
+			fn.Doc, fn.Body = nil, nil
+			o.Entries = append(o.Entries, outlineEntry{Line: line(d.Pos()), Signature: goNode(fset, &fn)})
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				o.Entries = append(o.Entries, outlineGoType(fset, ts))
+			}
+		}
+	}
+	return o
+}
+
+// outlineGoType outlines a type declaration, listing the methods of an
+// interface and the fields of a struct as its members
+func outlineGoType(fset *token.FileSet, ts *ast.TypeSpec) outlineEntry {
+	spec := *ts
+	spec.Doc, spec.Comment = nil, nil
+	entry := outlineEntry{Line: fset.Position(ts.Pos()).Line}
+	var fields *ast.FieldList
+	_, isInterface := ts.Type.(*ast.InterfaceType)
+	switch t := ts.Type.(type) {
+	case *ast.StructType:
+		spec.Type = ast.NewIdent("struct")
+		fields = t.Fields
+	case *ast.InterfaceType:
+		spec.Type = ast.NewIdent("interface")
+		fields = t.Methods
+	}
+	entry.Signature = "type " + goNode(fset, &spec)
+	if fields == nil {
+		return entry
+	}
+	for _, f := range fields.List {
+		member := outlineEntry{Line: fset.Position(f.Pos()).Line}
+		var names []string
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		typ := goNode(fset, f.Type)
+		switch {
+		case len(names) == 0:
+			member.Signature = typ // Embedded type
+		case isInterface:
+			member.Signature = names[0] + strings.TrimPrefix(typ, "func")
+		default:
+			member.Signature = strings.Join(names, ", ") + " " + typ
+		}
+		entry.Members = append(entry.Members, member)
+	}
+	return entry
+}
+
+// goNode renders node as Go source on one line
+func goNode(fset *token.FileSet, node any) string {
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package evaluator
+
+import (
+	"context"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+)
+
+func outlineTreeSitter(ctx context.Context, lang string, src []byte) (*outline, error) {
+	return nil, errors.Newf(errors.OutlineUnsupported, "no outline for %s in this build (tree-sitter needs cgo)", lang)
+}
@@ -0,0 +1,158 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const outlineGoSource = `package server
+
+import (
+	"fmt"
+	nethttp "net/http"
+)
+
+// Handler serves requests
+type Handler interface {
+	ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request)
+}
+
+type Server struct {
+	Addr    string
+	handler Handler
+}
+
+type ID = string
+
+func New(addr string) *Server {
+	return &Server{Addr: addr}
+}
+
+func (s *Server) Start() error {
+	return fmt.Errorf("not implemented")
+}
+`
+
+func TestExecuteOutline_Go(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "server.go"), []byte(outlineGoSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := newTestConfig(tmpDir)
+
+	audit := &testAuditLog{}
+	result := ExecuteOutline("server.go", cfg, audit.log)
+	if !result.Success {
+		t.Fatalf("outline failed: %v", result.Error)
+	}
+	want := `server.go (go, 26 lines)
+package server
+imports: fmt, nethttp net/http
+
+ 9  type Handler interface
+10    ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request)
+13  type Server struct
+14    Addr string
+15    handler Handler
+18  type ID = string
+20  func New(addr string) *Server
+24  func (s *Server) Start() error
+`
+	if result.Result != want {
+		t.Errorf("Result =\n%s\nwant\n%s", result.Result, want)
+	}
+	if len(audit.entries) != 1 || !strings.Contains(audit.entries[0].errMsg, "entries:8") {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+}
+
+func TestExecuteOutline_TreeSitter(t *testing.T) {
+	tmpDir := t.TempDir()
+	python := `import os
+from typing import List
+
+@dataclass
+class Job:
+    def run(self, args: List[str]) -> int:
+        return 0
+
+def main():
+    pass
+`
+	java := `package com.example;
+
+import java.util.List;
+
+public class Jobs {
+    private final List<String> names;
+
+    public Jobs(List<String> names) {
+        this.names = names;
+    }
+
+    public int count() {
+        return names.size();
+    }
+}
+`
+	os.WriteFile(filepath.Join(tmpDir, "jobs.py"), []byte(python), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "Jobs.java"), []byte(java), 0644)
+	cfg := newTestConfig(tmpDir)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"jobs.py", []string{
+			"imports: import os, from typing import List",
+			" 4  @dataclass class Job",
+			" 6    def run(self, args: List[str]) -> int",
+			" 9  def main()",
+		}},
+		{"Jobs.java", []string{
+			"package com.example",
+			"imports: import java.util.List",
+			" 5  public class Jobs",
+			" 6    private final List<String> names",
+			" 8    public Jobs(List<String> names)",
+			"12    public int count()",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := ExecuteOutline(tt.path, cfg, nil)
+			if !result.Success {
+				t.Fatalf("outline failed: %v", result.Error)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(result.Result, line+"\n") {
+					t.Errorf("outline missing %q:\n%s", line, result.Result)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteOutline_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("notes"), 0644)
+	cfg := newTestConfig(tmpDir)
+
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"", "PARSE_ERROR"},
+		{"notes.txt", "OUTLINE_UNSUPPORTED"},
+		{"missing.go", "FILE_NOT_FOUND"},
+		{"../outside.go", "PATH_SECURITY"},
+	}
+	for _, tt := range tests {
+		result := ExecuteOutline(tt.arg, cfg, nil)
+		if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), tt.want) {
+			t.Errorf("ExecuteOutline(%q) = %+v, want %s", tt.arg, result, tt.want)
+		}
+	}
+}
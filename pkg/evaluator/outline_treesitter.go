@@ -0,0 +1,128 @@
+//go:build cgo
+
+package evaluator
+
+import (
+	"context"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+	tree_sitter_java "github.com/tree-sitter/tree-sitter-java/bindings/go"
+	tree_sitter_python "github.com/tree-sitter/tree-sitter-python/bindings/go"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+)
+
+// treeSitterGrammar says which syntax nodes of a tree-sitter grammar make
+// up an outline
+type treeSitterGrammar struct {
+	Language func() *tree_sitter.Language
+	Package  map[string]bool // Package declarations
+	Imports  map[string]bool // Import statements, listed by their text
+	Types    map[string]bool // Declarations whose body holds members
+	Funcs    map[string]bool // Declarations listed by their signature
+	Wrappers map[string]bool // Nodes wrapping a declaration, such as decorators
+}
+
+// treeSitterGrammars are the languages outlined with tree-sitter
+var treeSitterGrammars = map[string]treeSitterGrammar{
+	"python": {
+		Language: func() *tree_sitter.Language { return tree_sitter.NewLanguage(tree_sitter_python.Language()) },
+		Imports:  kinds("import_statement", "import_from_statement", "future_import_statement"),
+		Types:    kinds("class_definition"),
+		Funcs:    kinds("function_definition"),
+		Wrappers: kinds("decorated_definition"),
+	},
+	"java": {
+		Language: func() *tree_sitter.Language { return tree_sitter.NewLanguage(tree_sitter_java.Language()) },
+		Package:  kinds("package_declaration"),
+		Imports:  kinds("import_declaration"),
+		Types:    kinds("class_declaration", "interface_declaration", "enum_declaration", "record_declaration", "annotation_type_declaration"),
+		Funcs:    kinds("method_declaration", "constructor_declaration", "compact_constructor_declaration", "field_declaration", "constant_declaration"),
+	},
+}
+
+func kinds(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// outlineTreeSitter outlines src in lang with its tree-sitter grammar
+func outlineTreeSitter(ctx context.Context, lang string, src []byte) (*outline, error) {
+	grammar, ok := treeSitterGrammars[lang]
+	if !ok {
+		return nil, errors.Newf(errors.OutlineUnsupported, "no outline for %s", lang)
+	}
+	parser := tree_sitter.NewParser()
+	defer parser.Close()
+	if err := parser.SetLanguage(grammar.Language()); err != nil {
+		return nil, errors.Wrap(errors.OutlineUnsupported, err)
+	}
+	tree := parser.ParseCtx(ctx, src, nil)
+	if tree == nil {
+		return nil, errors.New(errors.ExecInterrupted, "outline stopped before the file was parsed")
+	}
+	defer tree.Close()
+
+	root := tree.RootNode()
+	o := &outline{Language: lang, Incomplete: root.HasError()}
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		node := root.NamedChild(i)
+		switch kind := node.Kind(); {
+		case grammar.Package[kind]:
+			o.Package = strings.TrimSuffix(strings.TrimPrefix(oneLine(node.Utf8Text(src)), "package "), ";")
+		case grammar.Imports[kind]:
+			o.Imports = append(o.Imports, strings.TrimSuffix(oneLine(node.Utf8Text(src)), ";"))
+		default:
+			if entry, ok := treeSitterEntry(grammar, node, src); ok {
+				o.Entries = append(o.Entries, entry)
+			}
+		}
+	}
+	return o, nil
+}
+
+// treeSitterEntry outlines a declaration node, reporting false for nodes
+// that are not declarations
+func treeSitterEntry(grammar treeSitterGrammar, node *tree_sitter.Node, src []byte) (outlineEntry, bool) {
+	decl := node
+	if grammar.Wrappers[node.Kind()] {
+		decl = node.ChildByFieldName("definition")
+		if decl == nil {
+			return outlineEntry{}, false
+		}
+	}
+	kind := decl.Kind()
+	if !grammar.Types[kind] && !grammar.Funcs[kind] {
+		return outlineEntry{}, false
+	}
+
+	// The signature is everything before the body, so decorators,
+	// annotations, and modifiers are kept
+	body := decl.ChildByFieldName("body")
+	end := decl.EndByte()
+	if body != nil {
+		end = body.StartByte()
+	}
+	signature := oneLine(string(src[node.StartByte():end]))
+	signature = strings.TrimRight(signature, ":;{ ")
+	entry := outlineEntry{Line: int(node.StartPosition().Row) + 1, Signature: signature}
+
+	if grammar.Types[kind] && body != nil {
+		for i := uint(0); i < body.NamedChildCount(); i++ {
+			if member, ok := treeSitterEntry(grammar, body.NamedChild(i), src); ok {
+				entry.Members = append(entry.Members, member)
+			}
+		}
+	}
+	return entry, true
+}
+
+// oneLine collapses the whitespace in s, including newlines, to single
+// spaces
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,459 @@
+package evaluator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+const (
+	// DefaultOverviewDepth is how many directory levels <overview> shows
+	// without depth=
+	DefaultOverviewDepth = 2
+
+	overviewMaxFiles      = 20000 // Files walked before the overview stops counting
+	overviewMaxDirEntries = 25    // Entries shown per directory in the tree
+	overviewMaxPackages   = 40    // Packages listed under each module
+	overviewReadmeLines   = 40    // Lines of the README shown
+)
+
+// overviewDepth matches the depth=N argument of <overview>
+var overviewDepth = regexp.MustCompile(`^depth=(\d+)$`)
+
+// overviewLanguages names the languages counted in the overview, by
+// extension
+var overviewLanguages = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".jsx": "JavaScript",
+	".mjs": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript",
+	".java": "Java", ".kt": "Kotlin", ".rs": "Rust", ".rb": "Ruby",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++",
+	".cs": "C#", ".php": "PHP", ".swift": "Swift", ".scala": "Scala",
+	".sh": "Shell", ".bash": "Shell", ".sql": "SQL", ".proto": "Protobuf",
+	".html": "HTML", ".css": "CSS", ".scss": "CSS", ".vue": "Vue",
+	".md": "Markdown", ".rst": "reStructuredText", ".yaml": "YAML",
+	".yml": "YAML", ".json": "JSON", ".toml": "TOML", ".xml": "XML",
+}
+
+// overviewManifests are the files that mark the root of a module
+var overviewManifests = map[string]bool{
+	"go.mod": true, "package.json": true, "pyproject.toml": true,
+	"setup.py": true, "Cargo.toml": true, "pom.xml": true,
+	"build.gradle": true, "build.gradle.kts": true, "Gemfile": true,
+	"composer.json": true,
+}
+
+// overviewEntryFiles are files that show how a project is built or run
+var overviewEntryFiles = map[string]bool{
+	"Makefile": true, "Dockerfile": true, "docker-compose.yml": true,
+	"docker-compose.yaml": true, "compose.yaml": true, "Procfile": true,
+	"__main__.py": true, "manage.py": true, "main.py": true,
+}
+
+// overviewDir is a directory found by the overview walk
+type overviewDir struct {
+	entries []string // Names of files, and of directories with a trailing /
+	files   int      // Files anywhere below
+}
+
+// repoOverview is what the overview walk collects
+type repoOverview struct {
+	dirs      map[string]*overviewDir // By slash-separated path, "." for the root
+	languages map[string][2]int64     // Files and bytes by language
+	manifests []string
+	goPkgs    map[string]string // Package name by directory
+	entries   []string
+	files     int
+	truncated bool // The walk stopped at overviewMaxFiles
+}
+
+// ExecuteOverview handles the "overview" command
+func ExecuteOverview(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeOverview(context.Background(), arg, cfg, auditLog)
+}
+
+// executeOverview is ExecuteOverview as part of the trace in ctx. It walks
+// the repository once, skipping excluded and ignored paths, and returns a
+// digest an agent can start from instead of opening files one by one: a
+// directory tree, language statistics, modules and their packages, entry
+// points, and the start of the README.
+func executeOverview(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "overview", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("overview", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	depth := DefaultOverviewDepth
+	if arg = strings.TrimSpace(arg); arg != "" {
+		m := overviewDepth.FindStringSubmatch(arg)
+		if m == nil {
+			return fail(errors.Newf(errors.ParseError, "unexpected overview argument %q (want depth=N)", arg))
+		}
+		depth, _ = strconv.Atoi(m[1])
+	}
+
+	o, err := walkOverview(ctx, cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Repository overview\n\n%s", overviewFiles(o.files))
+	if o.truncated {
+		fmt.Fprintf(&b, " (stopped counting at %d)", overviewMaxFiles)
+	}
+	b.WriteString("\n\n")
+	writeOverviewTree(&b, o, depth)
+	writeOverviewLanguages(&b, o)
+	writeOverviewModules(&b, o, cfg.RepositoryRoot)
+	if len(o.entries) > 0 {
+		b.WriteString("## Entry points\n")
+		for _, e := range o.entries {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+		b.WriteString("\n")
+	}
+	writeOverviewReadme(&b, o, cfg)
+
+	result.Success = true
+	result.Result = b.String()
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("overview", arg, true, fmt.Sprintf("files:%d,depth:%d,bytes:%d", o.files, depth, b.Len()))
+	}
+	return result
+}
+
+// walkOverview walks the repository, leaving out what <open> could not
+// read
+func walkOverview(ctx context.Context, cfg *config.Config) (*repoOverview, error) {
+	o := &repoOverview{
+		dirs:      map[string]*overviewDir{".": {}},
+		languages: make(map[string][2]int64),
+		goPkgs:    make(map[string]string),
+	}
+	var ignored *ignore.Matcher
+	if cfg.RespectIgnoreFiles {
+		ignored = ignore.NewMatcher(cfg.RepositoryRoot)
+	}
+
+	err := filepath.WalkDir(cfg.RepositoryRoot, func(absPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if absPath == cfg.RepositoryRoot {
+				return err
+			}
+			return skipEntry(d)
+		}
+		if ctx.Err() != nil {
+			return errors.New(errors.ExecInterrupted, "overview stopped by interrupt")
+		}
+		if absPath == cfg.RepositoryRoot {
+			return nil
+		}
+		rel, _ := filepath.Rel(cfg.RepositoryRoot, absPath)
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		if _, err := sandbox.ValidatePath(rel, cfg.RepositoryRoot, cfg.ExcludedPaths); err != nil {
+			return skipEntry(d)
+		}
+		if ignored != nil && ignored.Match(rel, d.IsDir()) {
+			return skipEntry(d)
+		}
+
+		rel = filepath.ToSlash(rel)
+		parent := o.dirs[path.Dir(rel)]
+		if d.IsDir() {
+			o.dirs[rel] = &overviewDir{}
+			if parent != nil {
+				parent.entries = append(parent.entries, d.Name()+"/")
+			}
+			return nil
+		}
+
+		if o.files == overviewMaxFiles {
+			o.truncated = true
+			return filepath.SkipAll
+		}
+		o.files++
+		if parent != nil {
+			parent.entries = append(parent.entries, d.Name())
+		}
+		for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+			if od := o.dirs[dir]; od != nil {
+				od.files++
+			}
+			if dir == "." {
+				break
+			}
+		}
+
+		if lang, ok := overviewLanguages[strings.ToLower(filepath.Ext(rel))]; ok {
+			stats := o.languages[lang]
+			stats[0]++
+			if info, err := d.Info(); err == nil {
+				stats[1] += info.Size()
+			}
+			o.languages[lang] = stats
+		}
+		name := d.Name()
+		if overviewManifests[name] {
+			o.manifests = append(o.manifests, rel)
+		}
+		if overviewEntryFiles[name] {
+			o.entries = append(o.entries, rel)
+		}
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			dir := path.Dir(rel)
+			if _, seen := o.goPkgs[dir]; !seen {
+				if pkg := goPackageName(absPath); pkg != "" {
+					o.goPkgs[dir] = pkg
+					if pkg == "main" {
+						o.entries = append(o.entries, dir+" (Go package main)")
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.CodeOf(err) == "" {
+			err = errors.Wrap(errors.PermissionDenied, err)
+		}
+		return nil, err
+	}
+	sort.Strings(o.entries)
+	return o, nil
+}
+
+// goPackageName returns the package clause of a Go file, or "" if none is
+// found near its start
+func goPackageName(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for i := 0; i < 200 && s.Scan(); i++ {
+		if fields := strings.Fields(s.Text()); len(fields) >= 2 && fields[0] == "package" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// writeOverviewTree writes the directory tree to depth levels, with the
+// number of files below each directory
+func writeOverviewTree(b *strings.Builder, o *repoOverview, depth int) {
+	fmt.Fprintf(b, "## Tree (depth %d)\n", depth)
+	var write func(dir string, level int)
+	write = func(dir string, level int) {
+		entries := o.dirs[dir].entries
+		sort.Slice(entries, func(i, j int) bool {
+			// Directories first
+			di, dj := strings.HasSuffix(entries[i], "/"), strings.HasSuffix(entries[j], "/")
+			if di != dj {
+				return di
+			}
+			return entries[i] < entries[j]
+		})
+		indent := strings.Repeat("  ", level)
+		for i, name := range entries {
+			if i == overviewMaxDirEntries {
+				fmt.Fprintf(b, "%s... and %d more\n", indent, len(entries)-i)
+				break
+			}
+			if !strings.HasSuffix(name, "/") {
+				fmt.Fprintf(b, "%s%s\n", indent, name)
+				continue
+			}
+			child := strings.TrimSuffix(name, "/")
+			if dir != "." {
+				child = dir + "/" + child
+			}
+			fmt.Fprintf(b, "%s%s (%s)\n", indent, name, overviewFiles(o.dirs[child].files))
+			if level+1 < depth {
+				write(child, level+1)
+			}
+		}
+	}
+	if depth > 0 {
+		write(".", 0)
+	}
+	b.WriteString("\n")
+}
+
+// writeOverviewLanguages writes the languages by size, largest first
+func writeOverviewLanguages(b *strings.Builder, o *repoOverview) {
+	if len(o.languages) == 0 {
+		return
+	}
+	langs := make([]string, 0, len(o.languages))
+	var total int64
+	for lang, stats := range o.languages {
+		langs = append(langs, lang)
+		total += stats[1]
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		si, sj := o.languages[langs[i]], o.languages[langs[j]]
+		if si[1] != sj[1] {
+			return si[1] > sj[1]
+		}
+		return langs[i] < langs[j]
+	})
+	b.WriteString("## Languages\n")
+	for _, lang := range langs {
+		stats := o.languages[lang]
+		percent := 0.0
+		if total > 0 {
+			percent = float64(stats[1]) * 100 / float64(total)
+		}
+		fmt.Fprintf(b, "- %s: %s, %s (%.0f%%)\n", lang, overviewFiles(int(stats[0])), overviewSize(stats[1]), percent)
+	}
+	b.WriteString("\n")
+}
+
+// writeOverviewModules writes each manifest with the module it names, and
+// the Go packages of each Go module
+func writeOverviewModules(b *strings.Builder, o *repoOverview, root string) {
+	if len(o.manifests) == 0 && len(o.goPkgs) == 0 {
+		return
+	}
+	sort.Strings(o.manifests)
+	b.WriteString("## Modules\n")
+	for _, manifest := range o.manifests {
+		name := manifestModuleName(filepath.Join(root, filepath.FromSlash(manifest)))
+		if name == "" {
+			fmt.Fprintf(b, "- %s\n", manifest)
+		} else {
+			fmt.Fprintf(b, "- %s: %s\n", manifest, name)
+		}
+		if filepath.Base(manifest) != "go.mod" {
+			continue
+		}
+		moduleDir := path.Dir(manifest)
+		var pkgs []string
+		for dir, pkg := range o.goPkgs {
+			if moduleDir == "." || dir == moduleDir || strings.HasPrefix(dir, moduleDir+"/") {
+				pkgs = append(pkgs, fmt.Sprintf("%s (%s)", dir, pkg))
+			}
+		}
+		sort.Strings(pkgs)
+		for i, pkg := range pkgs {
+			if i == overviewMaxPackages {
+				fmt.Fprintf(b, "  - ... and %d more packages\n", len(pkgs)-i)
+				break
+			}
+			fmt.Fprintf(b, "  - %s\n", pkg)
+		}
+	}
+	b.WriteString("\n")
+}
+
+// manifestModuleName returns the module or package name a manifest
+// declares, where it is easy to find
+func manifestModuleName(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	switch filepath.Base(path) {
+	case "go.mod":
+		for _, line := range strings.Split(string(data), "\n") {
+			if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+				return strings.Trim(fields[1], `"`)
+			}
+		}
+	case "package.json", "composer.json":
+		var manifest struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &manifest) == nil {
+			return manifest.Name
+		}
+	case "pyproject.toml", "Cargo.toml":
+		for _, line := range strings.Split(string(data), "\n") {
+			if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == "name" {
+				return strings.Trim(strings.TrimSpace(v), `"'`)
+			}
+		}
+	}
+	return ""
+}
+
+// writeOverviewReadme writes the first lines of the README at the root
+func writeOverviewReadme(b *strings.Builder, o *repoOverview, cfg *config.Config) {
+	var readme string
+	for _, name := range o.dirs["."].entries {
+		if strings.HasPrefix(strings.ToLower(name), "readme") {
+			readme = name
+			break
+		}
+	}
+	if readme == "" {
+		return
+	}
+	f, err := os.Open(filepath.Join(cfg.RepositoryRoot, readme))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var lines []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	fmt.Fprintf(b, "## %s\n", readme)
+	if len(lines) > overviewReadmeLines {
+		fmt.Fprintf(b, "%s\n[first %d of %d lines, use <open %s> to read more]\n", strings.Join(lines[:overviewReadmeLines], "\n"), overviewReadmeLines, len(lines), readme)
+	} else {
+		fmt.Fprintf(b, "%s\n", strings.Join(lines, "\n"))
+	}
+}
+
+// overviewSize renders a file size
+func overviewSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// overviewFiles renders a number of files
+func overviewFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
@@ -0,0 +1,69 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteOverview(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                   "module example.com/app\n\ngo 1.23\n",
+		"README.md":                "# App\n\nDoes things.\n",
+		"Makefile":                 "test:\n\tgo test ./...\n",
+		"cmd/app/main.go":          "package main\n\nfunc main() {}\n",
+		"internal/store/store.go":  "// Package store keeps things\npackage store\n",
+		"internal/store/deep/x.go": "package deep\n",
+		"web/package.json":         `{"name": "app-web"}`,
+		"web/index.js":             "console.log(1)\n",
+		".env":                     "SECRET=1\n",
+		"build/out.bin":            "binary",
+		".gitignore":               "build/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := newTestConfig(tmpDir)
+	cfg.ExcludedPaths = []string{".env"}
+	cfg.RespectIgnoreFiles = true
+
+	audit := &testAuditLog{}
+	result := ExecuteOverview("", cfg, audit.log)
+	if !result.Success {
+		t.Fatalf("overview failed: %v", result.Error)
+	}
+	for _, want := range []string{
+		"## Tree (depth 2)\ncmd/ (1 file)\n  app/ (1 file)\ninternal/ (2 files)\n  store/ (2 files)\nweb/ (2 files)\n",
+		"- Go: 3 files",
+		"- go.mod: example.com/app\n  - cmd/app (main)\n  - internal/store (store)\n  - internal/store/deep (deep)\n",
+		"- web/package.json: app-web\n",
+		"## Entry points\n- Makefile\n- cmd/app (Go package main)\n",
+		"## README.md\n# App\n\nDoes things.\n",
+	} {
+		if !strings.Contains(result.Result, want) {
+			t.Errorf("overview missing %q:\n%s", want, result.Result)
+		}
+	}
+	for _, hidden := range []string{".env", "build", "deep/"} {
+		if strings.Contains(result.Result, hidden+"\n") || strings.Contains(result.Result, hidden+"/ (") {
+			t.Errorf("overview shows %s:\n%s", hidden, result.Result)
+		}
+	}
+	if len(audit.entries) != 1 || !strings.Contains(audit.entries[0].errMsg, "depth:2") {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+
+	result = ExecuteOverview("depth=3", cfg, nil)
+	if !result.Success || !strings.Contains(result.Result, "    deep/ (1 file)\n") {
+		t.Errorf("depth=3 overview:\n%s", result.Result)
+	}
+	if result := ExecuteOverview("everything", cfg, nil); result.Success || !strings.Contains(result.Error.Error(), "PARSE_ERROR") {
+		t.Errorf("bad argument: %+v", result)
+	}
+}
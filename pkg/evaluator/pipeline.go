@@ -0,0 +1,122 @@
+package evaluator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// pipeVariable matches $1..$N (output of step N), $last (output of the
+// previous step) and $$ (a literal dollar sign)
+var pipeVariable = regexp.MustCompile(`\$(\$|last\b|\d+)`)
+
+// executePipe runs the steps of a <pipe> block in order, expanding result
+// variables in each step's content from the outputs of earlier steps. An
+// exec step that runs but exits non-zero does not stop the pipe, so its
+// output can still be captured; any other failure stops the pipe.
+func (e *Executor) executePipe(cmd scanner.Command) scanner.ExecutionResult {
+	result := scanner.ExecutionResult{
+		Command: cmd,
+		Success: false,
+	}
+
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		if e.auditLog != nil {
+			e.auditLog("pipe", cmd.Argument, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if len(cmd.Steps) == 0 {
+		return fail(errors.New(errors.PipeError, "pipe contains no commands"))
+	}
+	if len(cmd.Steps) > config.MaxPipeSteps {
+		return fail(errors.Newf(errors.PipeError, "pipe has %d steps (max %d)", len(cmd.Steps), config.MaxPipeSteps))
+	}
+	for i, step := range cmd.Steps {
+		if step.Type == "pipe" || step.Type == "if-success" || step.Type == "if-failure" {
+			return fail(errors.Newf(errors.PipeError, "step %d: pipes and guards cannot be nested in a pipe", i+1))
+		}
+	}
+
+	e.piping++
+	defer func() { e.piping-- }()
+
+	var outputs []string
+	for i, step := range cmd.Steps {
+		content, err := expandPipeVariables(step.Content, outputs)
+		if err != nil {
+			return fail(errors.Wrapf(errors.PipeError, err, "step %d", i+1))
+		}
+		step.Content = content
+
+		stepResult := e.Execute(step)
+		result.Steps = append(result.Steps, stepResult)
+
+		if !stepResult.Success && !exitedNonZero(stepResult) {
+			return fail(errors.Newf(errors.PipeFailed, "step %d (%s) failed", i+1, step.Type))
+		}
+
+		outputs = append(outputs, stepOutput(stepResult))
+	}
+
+	result.Success = true
+	result.Result = outputs[len(outputs)-1]
+	if e.auditLog != nil {
+		e.auditLog("pipe", cmd.Argument, true, "")
+	}
+	return result
+}
+
+// expandPipeVariables substitutes result variables in content. outputs
+// holds the outputs of the steps that have run so far.
+func expandPipeVariables(content string, outputs []string) (string, error) {
+	var expandErr error
+
+	expanded := pipeVariable.ReplaceAllStringFunc(content, func(ref string) string {
+		name := ref[1:]
+		switch name {
+		case "$":
+			return "$"
+		case "last":
+			if len(outputs) == 0 {
+				if expandErr == nil {
+					expandErr = fmt.Errorf("$last used before any command ran")
+				}
+				return ref
+			}
+			return outputs[len(outputs)-1]
+		}
+
+		n, err := strconv.Atoi(name)
+		if err != nil || n < 1 || n > len(outputs) {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("$%s does not refer to an earlier step", name)
+			}
+			return ref
+		}
+		return outputs[n-1]
+	})
+
+	return expanded, expandErr
+}
+
+// stepOutput returns the output of a step as seen by later steps: the
+// combined stdout and stderr of an exec, the result text of anything else
+func stepOutput(result scanner.ExecutionResult) string {
+	if result.Command.Type == "exec" {
+		return result.Stdout + result.Stderr
+	}
+	return result.Result
+}
+
+// exitedNonZero reports whether an exec step ran to completion with a
+// non-zero exit code, as opposed to timing out or failing to start
+func exitedNonZero(result scanner.ExecutionResult) bool {
+	return result.Command.Type == "exec" && errors.CodeOf(result.Error) == errors.ExecFailed
+}
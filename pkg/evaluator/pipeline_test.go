@@ -0,0 +1,197 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestExpandPipeVariables(t *testing.T) {
+	outputs := []string{"first", "second"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"no variables", "plain text", "plain text", false},
+		{"last", "got $last", "got second", false},
+		{"numbered", "$1 then $2", "first then second", false},
+		{"escaped dollar", "costs $$5", "costs $5", false},
+		{"shell variable untouched", "echo $HOME", "echo $HOME", false},
+		{"lastname is not last", "$lastname", "$lastname", false},
+		{"out of range", "$3", "", true},
+		{"zero", "$0", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandPipeVariables(tt.content, outputs)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expandPipeVariables(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPipeVariables_LastBeforeAnyStep(t *testing.T) {
+	if _, err := expandPipeVariables("$last", nil); err == nil {
+		t.Error("expected error for $last in the first step")
+	}
+}
+
+func TestExecutePipe_OpenIntoWrite(t *testing.T) {
+	if sandbox.CheckDockerAvailability() != nil {
+		t.Skip("Docker not available")
+	}
+
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("hello pipe\n"), 0644); err != nil {
+		t.Fatalf("failed to create source file: %v", err)
+	}
+
+	audit := &testAuditLog{}
+	executor := NewExecutor(cfg, nil, audit.log, nil)
+
+	result := executor.Execute(scanner.Command{
+		Type: "pipe",
+		Steps: []scanner.Command{
+			{Type: "open", Argument: "source.txt"},
+			{Type: "write", Argument: "copy.txt", Content: "$last"},
+		},
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if len(result.Steps) != 2 {
+		t.Fatalf("len(Steps) = %d, want 2", len(result.Steps))
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "copy.txt"))
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if !strings.Contains(string(data), "hello pipe") {
+		t.Errorf("copy.txt = %q, want content of source.txt", data)
+	}
+
+	if got := executor.GetCommandsRun(); got != 2 {
+		t.Errorf("GetCommandsRun() = %d, want 2 (steps only)", got)
+	}
+
+	entries := audit.getEntries()
+	last := entries[len(entries)-1]
+	if last.cmdType != "pipe" || !last.success {
+		t.Errorf("last audit entry = %+v, want successful pipe", last)
+	}
+}
+
+func TestExecutePipe_WritesInOrder(t *testing.T) {
+	if sandbox.CheckDockerAvailability() != nil {
+		t.Skip("Docker not available")
+	}
+
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	result := executor.Execute(scanner.Command{
+		Type: "pipe",
+		Steps: []scanner.Command{
+			{Type: "write", Argument: "a.txt", Content: "first\n"},
+			{Type: "write", Argument: "b.txt", Content: "price: $$5\n"},
+		},
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if got := executor.GetCommandsRun(); got != 2 {
+		t.Errorf("GetCommandsRun() = %d, want 2 (steps only)", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "b.txt"))
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if string(data) != "price: $5\n" {
+		t.Errorf("b.txt = %q, want escaped dollar expanded", data)
+	}
+}
+
+func TestExecutePipe_FailedStepStopsPipe(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	result := executor.Execute(scanner.Command{
+		Type: "pipe",
+		Steps: []scanner.Command{
+			{Type: "write", Argument: ".git/config", Content: "blocked\n"},
+			{Type: "write", Argument: "out.txt", Content: "$last"},
+		},
+	})
+
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if !strings.Contains(result.Error.Error(), "PIPE_FAILED") {
+		t.Errorf("expected PIPE_FAILED, got %v", result.Error)
+	}
+	if len(result.Steps) != 1 {
+		t.Errorf("len(Steps) = %d, want 1", len(result.Steps))
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "out.txt")); !os.IsNotExist(err) {
+		t.Error("later step should not have run")
+	}
+}
+
+func TestExecutePipe_Validation(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	tooMany := make([]scanner.Command, config.MaxPipeSteps+1)
+	for i := range tooMany {
+		tooMany[i] = scanner.Command{Type: "open", Argument: "a.txt"}
+	}
+
+	tests := []struct {
+		name  string
+		steps []scanner.Command
+	}{
+		{"empty", nil},
+		{"too many steps", tooMany},
+		{"nested", []scanner.Command{{Type: "pipe"}}},
+		{"unknown variable", []scanner.Command{{Type: "write", Argument: "a.txt", Content: "$2"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := executor.Execute(scanner.Command{Type: "pipe", Steps: tt.steps})
+			if result.Success {
+				t.Fatal("expected failure")
+			}
+			if !strings.Contains(result.Error.Error(), "PIPE_ERROR") {
+				t.Errorf("expected PIPE_ERROR, got %v", result.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package evaluator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/plugin"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// SetPlugins sets the plugin commands the executor runs. Commands of any
+// type not built in are looked up here.
+func (e *Executor) SetPlugins(plugins *plugin.Registry) {
+	e.plugins = plugins
+}
+
+// executePlugin runs a plugin command: its validation hook first, then the
+// command itself, both audited under the command's name
+func (e *Executor) executePlugin(cmd scanner.Command, p plugin.Command) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{Command: cmd}
+	req := plugin.Request{
+		Command:    cmd.Type,
+		Argument:   cmd.Argument,
+		Repository: e.config.RepositoryRoot,
+		Config:     e.config.PluginConfig[cmd.Type],
+	}
+
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if e.auditLog != nil {
+			e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if err := p.Validate(req); err != nil {
+		return fail(errors.Wrap(errors.PluginValidation, err))
+	}
+
+	output, err := p.Execute(e.traceCtx, req)
+	if err != nil {
+		return fail(errors.Wrap(errors.PluginFailed, err))
+	}
+
+	result.Success = true
+	result.Result = output
+	result.ExecutionTime = time.Since(startTime)
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, true, fmt.Sprintf("plugin,bytes:%d,duration:%.3fs", len(output), result.ExecutionTime.Seconds()))
+	}
+	return result
+}
+
+// WritePluginFile writes data to path for a WASM plugin as <write
+// encoding=base64> writes it, with the same checks of extensions,
+// append-only files, write quotas, and conflicts, the same backup, and an
+// audit entry under plugin-write. It is meant for plugin.Host's Write.
+func (e *Executor) WritePluginFile(path string, data []byte) error {
+	cmd := scanner.Command{Type: "plugin-write", Argument: path, Content: base64.StdEncoding.EncodeToString(data), Encoding: "base64"}
+	var auditLog func(cmd, arg string, success bool, errMsg string)
+	if e.auditLog != nil {
+		auditLog = func(_, arg string, success bool, errMsg string) {
+			e.auditLog(cmd.Type, arg, success, errMsg)
+		}
+	}
+	if conflict := checkWriteConflict(path, e.config, e.tracker, auditLog); conflict != nil {
+		return conflict.Error
+	}
+	if exceeded := e.checkWriteQuota(cmd); exceeded != nil {
+		return exceeded.Error
+	}
+	result := executeEncodedWrite(e.traceCtx, path, cmd.Encoding, cmd.Content, "", e.config, auditLog, e.pool)
+	e.trackFile(result)
+	e.recordWriteQuota(result)
+	if !result.Success {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/plugin"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestExecutor_Plugin(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.PluginConfig = map[string]map[string]interface{}{
+		"jira": {"project": "ABC"},
+	}
+
+	var audits []string
+	audit := func(cmd, arg string, success bool, errMsg string) {
+		audits = append(audits, cmd+" "+arg+" "+errMsg)
+	}
+	executor := NewExecutor(cfg, nil, audit, nil)
+
+	registry, err := plugin.NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { scanner.UnregisterCommand("jira") })
+	err = registry.Add(plugin.Func{
+		CommandName: "jira",
+		Check: func(req plugin.Request) error {
+			if !strings.HasPrefix(req.Argument, req.Config["project"].(string)+"-") {
+				return errors.New("issue is not in project " + req.Config["project"].(string))
+			}
+			return nil
+		},
+		Run: func(ctx context.Context, req plugin.Request) (string, error) {
+			if req.Argument == "ABC-0" {
+				return "", errors.New("issue was deleted")
+			}
+			return "Issue " + req.Argument + " in " + req.Repository, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor.SetPlugins(registry)
+
+	result := executor.Execute(scanner.Command{Type: "jira", Argument: "ABC-1"})
+	if !result.Success {
+		t.Fatalf("jira failed: %v", result.Error)
+	}
+	if result.Result != "Issue ABC-1 in "+tmpDir {
+		t.Errorf("Result = %q", result.Result)
+	}
+
+	tests := []struct {
+		argument string
+		wantErr  string
+	}{
+		{"XYZ-1", "PLUGIN_VALIDATION: issue is not in project ABC"},
+		{"ABC-0", "PLUGIN_FAILED: issue was deleted"},
+	}
+	for _, tt := range tests {
+		result := executor.Execute(scanner.Command{Type: "jira", Argument: tt.argument})
+		if result.Success || result.Error == nil || result.Error.Error() != tt.wantErr {
+			t.Errorf("jira %s: error = %v, want %q", tt.argument, result.Error, tt.wantErr)
+		}
+	}
+
+	if len(audits) != 3 || !strings.HasPrefix(audits[0], "jira ABC-1 plugin,bytes:") {
+		t.Errorf("audit entries = %q", audits)
+	}
+}
+
+// TestExecutor_PluginWrites tests that files a WASM plugin writes through
+// its host go through the same checks as <write>
+func TestExecutor_PluginWrites(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.ConflictCheck = true
+	cfg.AppendOnlyPaths = []string{"logs/**"}
+	cfg.WriteQuotas = map[string]config.WriteQuota{"gen": {MaxBytes: 8}}
+	os.MkdirAll(filepath.Join(tmpDir, "logs"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "logs", "app.txt"), []byte("started\n"), 0644)
+	sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+	t.Cleanup(func() { sandbox.SetFakeBackend(nil) })
+
+	audit := &testAuditLog{}
+	executor := NewExecutor(cfg, nil, audit.log, nil)
+	host := &plugin.Host{
+		Root:         tmpDir,
+		Capabilities: map[string]bool{plugin.CapabilityWriteFile: true},
+		Write:        executor.WritePluginFile,
+	}
+
+	// A plugin command writing each file it is given, as a WASM plugin's
+	// write_file would
+	registry, err := plugin.NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { scanner.UnregisterCommand("gen") })
+	err = registry.Add(plugin.Func{
+		CommandName: "gen",
+		Run: func(ctx context.Context, req plugin.Request) (string, error) {
+			path, content, _ := strings.Cut(req.Argument, " ")
+			return "wrote " + path, host.WriteFile(path, []byte(content))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor.SetPlugins(registry)
+
+	if result := executor.Execute(scanner.Command{Type: "gen", Argument: "gen/out.txt hello"}); !result.Success {
+		t.Fatalf("plugin write = %v", result.Error)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "gen", "out.txt")); string(data) != "hello" {
+		t.Errorf("gen/out.txt = %q", data)
+	}
+	found := false
+	for _, entry := range audit.entries {
+		found = found || (entry.cmdType == "plugin-write" && entry.arg == "gen/out.txt" && entry.success)
+	}
+	if !found {
+		t.Errorf("audit = %+v, want a plugin-write entry", audit.entries)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "gen", "out.txt"), []byte("edited"), 0644)
+	tests := map[string]string{
+		"gen/out.txt hi":     "changed on disk since it was last opened",
+		"gen/more.txt 12345": "write quota for gen/",
+		"logs/app.txt reset": "is append-only",
+		"run.sh rm -rf /":    "extension not allowed",
+	}
+	for arg, want := range tests {
+		result := executor.Execute(scanner.Command{Type: "gen", Argument: arg})
+		if result.Success || !strings.Contains(result.Error.Error(), want) {
+			t.Errorf("gen %s: success=%v err=%v, want an error containing %q", arg, result.Success, result.Error, want)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "logs", "app.txt")); string(data) != "started\n" {
+		t.Errorf("logs/app.txt = %q, want it untouched", data)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "gen", "more.txt")); err == nil {
+		t.Error("write over the quota was made")
+	}
+}
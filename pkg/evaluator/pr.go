@@ -0,0 +1,89 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/forge"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// SetSessionSummary sets what gives the summary of the session so far,
+// in Markdown, that <pr> adds to the description of the requests it opens
+func (e *Executor) SetSessionSummary(summary func() string) {
+	e.summary = summary
+}
+
+// ExecutePR handles the "pr" command
+func ExecutePR(title, body, summary string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executePR(context.Background(), title, body, summary, cfg, auditLog)
+}
+
+// executePR is ExecutePR as part of the trace in ctx. It pushes the
+// current branch to cfg.PRRemote and opens a pull or merge request of it
+// with title, described by body followed by summary.
+func executePR(ctx context.Context, title, body, summary string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "pr", Argument: title, Content: body},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("pr", title, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	// Pushing is a git write like any other
+	if !cfg.GitWrite {
+		return fail(errors.New(errors.GitDisabled, "git write commands are disabled"))
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return fail(errors.New(errors.ParseError, "pr needs a title"))
+	}
+	branch, err := currentBranch(ctx, cfg.RepositoryRoot)
+	if err != nil {
+		return fail(err)
+	}
+	if branchProtected(branch, cfg.GitProtected) {
+		return fail(errors.Newf(errors.GitDenied, "branch %s is protected; create another with <git-branch NAME>", branch))
+	}
+
+	description := strings.TrimSpace(body)
+	if summary = strings.TrimSpace(summary); summary != "" {
+		description = strings.TrimSpace(description + "\n\n---\n\n" + summary)
+	}
+	pr, err := forge.Create(ctx, forge.Options{
+		Dir:    cfg.RepositoryRoot,
+		Remote: cfg.PRRemote,
+		Forge:  cfg.PRForge,
+		Head:   branch,
+		Base:   cfg.PRBase,
+		Title:  title,
+		Body:   description,
+		Draft:  cfg.PRDraft,
+	})
+	if err != nil {
+		return fail(errors.Wrapf(errors.PRFailed, err, "cannot open pull request"))
+	}
+
+	result.Success = true
+	result.Result = fmt.Sprintf("Opened pull request %s\nMerging %s into %s\n", pr.URL, branch, pr.Base)
+	// Changes not committed were not pushed, which is easy to miss
+	if files, err := changedFiles(ctx, cfg); err == nil && len(files) > 0 {
+		result.Result += fmt.Sprintf("%d changed files are not committed, so were not pushed; commit them with <git-commit MESSAGE> first\n", len(files))
+	}
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("pr", title, true, fmt.Sprintf("url:%s,branch:%s,base:%s,commit:%s", pr.URL, branch, pr.Base, pr.Commit))
+	}
+	return result
+}
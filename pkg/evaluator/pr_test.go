@@ -0,0 +1,42 @@
+package evaluator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecutePR_Policy(t *testing.T) {
+	cfg := newGitConfig(t)
+	cfg.PRRemote = "origin"
+
+	if result := ExecutePR("Add main", "", "", cfg, nil); result.Success || !strings.Contains(result.Error.Error(), "GIT_DENIED") {
+		t.Errorf("pr from main = %+v, want GIT_DENIED", result)
+	}
+	if result := ExecuteGitBranch("feature/main-func", cfg, nil); !result.Success {
+		t.Fatalf("git-branch failed: %v", result.Error)
+	}
+
+	audit := &testAuditLog{}
+	result := ExecutePR("Add main", "Details", "# Session s1", cfg, audit.log)
+	if result.Success || !strings.Contains(result.Error.Error(), "PR_FAILED") {
+		t.Errorf("pr without a remote = %+v, want PR_FAILED", result)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].success {
+		t.Errorf("audit = %+v, want one failure", audit.entries)
+	}
+
+	runGit(context.Background(), cfg.RepositoryRoot, "remote", "add", "origin", "https://github.com/acme/tools.git")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	if result := ExecutePR("Add main", "", "", cfg, nil); result.Success || !strings.Contains(result.Error.Error(), "GITHUB_TOKEN") {
+		t.Errorf("pr without a token = %+v, want to be told to set GITHUB_TOKEN", result)
+	}
+}
+
+func TestExecutePR_Disabled(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	if result := ExecutePR("title", "body", "", cfg, nil); result.Error == nil || !strings.Contains(result.Error.Error(), "GIT_DISABLED") {
+		t.Errorf("pr = %+v, want GIT_DISABLED", result)
+	}
+}
@@ -0,0 +1,277 @@
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// QuotaTracker remembers the files a session has written, so writes can be
+// held to the per-directory quotas in cfg.WriteQuotas
+type QuotaTracker struct {
+	mu      sync.Mutex
+	sizes   map[string]int64 // Size of each file written, by repository-relative path
+	created map[string]bool  // Files the session created, by repository-relative path
+}
+
+// NewQuotaTracker creates an empty tracker
+func NewQuotaTracker() *QuotaTracker {
+	return &QuotaTracker{sizes: make(map[string]int64), created: make(map[string]bool)}
+}
+
+// Record stores the size of the file at relPath after a write, and whether
+// the write created it
+func (t *QuotaTracker) Record(relPath string, size int64, created bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sizes[relPath] = size
+	if created {
+		t.created[relPath] = true
+	}
+}
+
+// Check returns a RESOURCE_LIMIT error if writing size bytes to relPath
+// would take a directory over its quota: more files created by the session
+// than MaxFiles, or more bytes in the files it wrote than MaxBytes. A file
+// written again counts once, at its new size. exists tells whether the file
+// is on disk already, so only new files count against MaxFiles.
+func (t *QuotaTracker) Check(relPath string, size int64, exists bool, quotas map[string]config.WriteQuota) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, dir := range quotaDirs(relPath, quotas) {
+		quota := quotas[dir]
+		files, bytes := 0, size
+		for path, written := range t.sizes {
+			if !underDir(path, dir) || path == relPath {
+				continue
+			}
+			bytes += written
+			if t.created[path] {
+				files++
+			}
+		}
+		if !exists || t.created[relPath] {
+			files++
+		}
+		if quota.MaxFiles > 0 && files > quota.MaxFiles {
+			return errors.Newf(errors.ResourceLimit, "write quota for %s/ allows %d new files per session", dir, quota.MaxFiles)
+		}
+		if quota.MaxBytes > 0 && bytes > quota.MaxBytes {
+			return errors.Newf(errors.ResourceLimit, "write quota for %s/ allows %d bytes per session, and this write would bring it to %d", dir, quota.MaxBytes, bytes)
+		}
+	}
+	return nil
+}
+
+// QuotaWrite is one file of a batch checked with CheckBatch
+type QuotaWrite struct {
+	Path   string // Repository-relative path
+	Size   int64  // Size of the file once written
+	Exists bool   // Whether the file is on disk already
+}
+
+// CheckBatch is Check for files written together, such as the entries of
+// an archive: each is checked as if the ones before it had been written,
+// so the batch as a whole is held to the quotas. Nothing is recorded.
+func (t *QuotaTracker) CheckBatch(writes []QuotaWrite, quotas map[string]config.WriteQuota) error {
+	t.mu.Lock()
+	planned := &QuotaTracker{sizes: make(map[string]int64, len(t.sizes)), created: make(map[string]bool, len(t.created))}
+	for path, size := range t.sizes {
+		planned.sizes[path] = size
+	}
+	for path := range t.created {
+		planned.created[path] = true
+	}
+	t.mu.Unlock()
+
+	for _, w := range writes {
+		if err := planned.Check(w.Path, w.Size, w.Exists, quotas); err != nil {
+			return err
+		}
+		planned.Record(w.Path, w.Size, !w.Exists)
+	}
+	return nil
+}
+
+// quotaDirs returns the quota directories relPath is under, outermost first
+func quotaDirs(relPath string, quotas map[string]config.WriteQuota) []string {
+	var dirs []string
+	for dir := range quotas {
+		if underDir(relPath, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// quotaDir normalizes a quota key such as "./docs/" to "docs"
+func quotaDir(dir string) string {
+	dir = strings.Trim(filepath.ToSlash(filepath.Clean(dir)), "/")
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+// underDir reports whether the repository-relative path is inside dir
+func underDir(path, dir string) bool {
+	return dir == "." || strings.HasPrefix(path, dir+"/")
+}
+
+// checkWriteQuota rejects a write or append that would exceed a directory's
+// quota. Returns nil if the command may proceed.
+func (e *Executor) checkWriteQuota(cmd scanner.Command) *scanner.ExecutionResult {
+	quotas := normalizedQuotas(e.config.WriteQuotas)
+	if len(quotas) == 0 {
+		return nil
+	}
+	safePath, err := sandbox.ValidatePath(cmd.Argument, e.config.RepositoryRoot, e.config.ExcludedPaths)
+	if err != nil {
+		// The command reports the path error
+		return nil
+	}
+	relPath := repoRelativePath(safePath, e.config.RepositoryRoot)
+
+	size := int64(len(cmd.Content))
+	if cmd.Encoding != "" {
+		data, err := DecodeContent(cmd.Encoding, cmd.Content)
+		if err != nil {
+			return nil
+		}
+		size = int64(len(data))
+	}
+	info, statErr := os.Stat(safePath)
+	exists := statErr == nil
+	if cmd.Type == "append" && exists {
+		size += info.Size() + 1
+	}
+
+	fullError := e.quotas.Check(relPath, size, exists, quotas)
+	if fullError == nil {
+		return nil
+	}
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error())
+	}
+	// Not sanitized: the message only holds repository-relative
+	// directories, which would be mangled as paths
+	return &scanner.ExecutionResult{
+		Command: cmd,
+		Success: false,
+		Error:   fullError,
+	}
+}
+
+// recordWriteQuota counts a successful write or append toward the quotas
+// of the directories it is in
+func (e *Executor) recordWriteQuota(result scanner.ExecutionResult) {
+	if !result.Success || len(e.config.WriteQuotas) == 0 {
+		return
+	}
+	safePath, err := sandbox.ValidatePath(result.Command.Argument, e.config.RepositoryRoot, e.config.ExcludedPaths)
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(safePath)
+	if err != nil {
+		return
+	}
+	e.quotas.Record(repoRelativePath(safePath, e.config.RepositoryRoot), info.Size(), result.Action == "CREATED")
+}
+
+// guardedWrite writes content to path as <write> does, for commands such
+// as <fetch dest=> that write a file as part of their work: the write is
+// refused if the file changed on disk since it was last opened or would
+// exceed a quota, and once written the file is tracked and counted
+func (e *Executor) guardedWrite(ctx context.Context, path, content string) scanner.ExecutionResult {
+	cmd := scanner.Command{Type: "write", Argument: path, Content: content}
+	if conflict := checkWriteConflict(path, e.config, e.tracker, e.auditLog); conflict != nil {
+		return *conflict
+	}
+	if exceeded := e.checkWriteQuota(cmd); exceeded != nil {
+		return *exceeded
+	}
+	result := executeWrite(ctx, path, content, "", e.config, e.auditLog, e.pool)
+	e.trackFile(result)
+	e.recordWriteQuota(result)
+	return result
+}
+
+// writeGuards are the executor's conflict tracking and write quotas, for
+// commands such as <unzip> and <archive> that write files without going
+// through executeWrite. A nil *writeGuards, as the standalone Execute
+// functions pass, checks and records nothing.
+type writeGuards struct {
+	tracker *FileTracker
+	quotas  *QuotaTracker
+}
+
+// guards returns the executor's write guards
+func (e *Executor) guards() *writeGuards {
+	return &writeGuards{tracker: e.tracker, quotas: e.quotas}
+}
+
+// checkConflict returns a CONFLICT error, and a diff of the changes, if
+// the file at safePath changed on disk since the LLM last opened or wrote
+// it
+func (g *writeGuards) checkConflict(safePath string, cfg *config.Config) (string, error) {
+	if g == nil || g.tracker == nil || !cfg.ConflictCheck || cfg.ForceWrite {
+		return "", nil
+	}
+	relPath := repoRelativePath(safePath, cfg.RepositoryRoot)
+	if diff, conflict := g.tracker.Check(safePath, relPath); conflict {
+		return diff, errors.Newf(errors.Conflict, "%s changed on disk since it was last opened; open it again before replacing it", relPath)
+	}
+	return "", nil
+}
+
+// checkQuotas checks writes against the write quotas as a batch, so a
+// command that would go over writes none of them
+func (g *writeGuards) checkQuotas(writes []QuotaWrite, cfg *config.Config) error {
+	quotas := normalizedQuotas(cfg.WriteQuotas)
+	if g == nil || g.quotas == nil || len(quotas) == 0 {
+		return nil
+	}
+	return g.quotas.CheckBatch(writes, quotas)
+}
+
+// wrote tracks the file written at safePath with its content on disk and
+// counts it toward the write quotas
+func (g *writeGuards) wrote(safePath string, created bool, cfg *config.Config) {
+	if g == nil {
+		return
+	}
+	data, err := os.ReadFile(safePath)
+	if g.tracker != nil {
+		if err != nil {
+			g.tracker.Forget(safePath)
+		} else {
+			g.tracker.Record(safePath, string(data))
+		}
+	}
+	if g.quotas != nil && err == nil && len(cfg.WriteQuotas) > 0 {
+		g.quotas.Record(repoRelativePath(safePath, cfg.RepositoryRoot), int64(len(data)), created)
+	}
+}
+
+// normalizedQuotas keys quotas by normalized directory
+func normalizedQuotas(quotas map[string]config.WriteQuota) map[string]config.WriteQuota {
+	if len(quotas) == 0 {
+		return nil
+	}
+	normalized := make(map[string]config.WriteQuota, len(quotas))
+	for dir, quota := range quotas {
+		normalized[quotaDir(dir)] = quota
+	}
+	return normalized
+}
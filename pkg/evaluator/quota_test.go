@@ -0,0 +1,120 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestQuotaTracker_Check(t *testing.T) {
+	quotas := normalizedQuotas(map[string]config.WriteQuota{
+		"./docs/":  {MaxFiles: 2},
+		"docs/api": {MaxBytes: 100},
+	})
+	tracker := NewQuotaTracker()
+	tracker.Record("docs/a.md", 10, true)
+	tracker.Record("docs/api/b.md", 60, true)
+	tracker.Record("src/main.go", 5000, true)
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int64
+		exists  bool
+		wantErr string
+	}{
+		{"outside quotas", "src/other.go", 5000, false, ""},
+		{"third new file", "docs/c.md", 1, false, "allows 2 new files"},
+		{"existing file", "docs/README.md", 1, true, ""},
+		{"rewrite of a created file", "docs/a.md", 1, true, ""},
+		{"nested bytes", "docs/api/b.md", 100, true, ""},
+		{"nested bytes over", "docs/api/b.md", 101, true, "allows 100 bytes"},
+		{"outer files before inner bytes", "docs/api/c.md", 500, false, "allows 2 new files"},
+		{"prefix is not a directory", "docs-old/c.md", 1, false, ""},
+	}
+	for _, tt := range tests {
+		err := tracker.Check(tt.path, tt.size, tt.exists, quotas)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.HasPrefix(err.Error(), "RESOURCE_LIMIT")):
+			t.Errorf("%s: error = %v, want RESOURCE_LIMIT containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExecutor_WriteQuota(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.WriteQuotas = map[string]config.WriteQuota{"docs": {MaxFiles: 1, MaxBytes: 20}}
+	os.MkdirAll(filepath.Join(tmpDir, "docs"), 0755)
+	if err := os.WriteFile(filepath.Join(tmpDir, "docs", "notes.md"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := &testAuditLog{}
+	e := NewExecutor(cfg, nil, audit.log, nil)
+	e.quotas.Record("docs/first.md", 5, true)
+
+	for _, cmd := range []scanner.Command{
+		{Type: "write", Argument: "docs/second.md", Content: "x"},
+		{Type: "write", Argument: "docs/notes.md", Content: strings.Repeat("x", 16)},
+		{Type: "append", Argument: "docs/notes.md", Content: strings.Repeat("x", 5)},
+	} {
+		result := e.Execute(cmd)
+		if result.Success || result.Error == nil || !strings.HasPrefix(result.Error.Error(), "RESOURCE_LIMIT: write quota for docs/") {
+			t.Errorf("%s %s: success=%v err=%v, want RESOURCE_LIMIT", cmd.Type, cmd.Argument, result.Success, result.Error)
+		}
+	}
+	if len(audit.entries) != 3 || audit.entries[2].cmdType != "append" || audit.entries[2].success {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tmpDir, "docs", "notes.md")); string(content) != "0123456789" {
+		t.Errorf("notes.md changed to %q", content)
+	}
+}
+
+func TestExecutor_WriteQuotaUnzipAndFetch(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.AllowedExtensions = nil
+	cfg.MaxArchiveEntries = 100
+	cfg.MaxArchiveSize = 1 << 20
+	cfg.WriteQuotas = map[string]config.WriteQuota{"docs": {MaxFiles: 3}}
+	files := make(map[string]string)
+	for i := 0; i < 5; i++ {
+		files[fmt.Sprintf("page%d.md", i)] = "x"
+	}
+	writeZip(t, filepath.Join(tmpDir, "pages.zip"), files)
+
+	e := NewExecutor(cfg, nil, nil, nil)
+	result := e.Execute(scanner.Command{Type: "unzip", Argument: "pages.zip docs"})
+	if result.Success || !strings.Contains(result.Error.Error(), "write quota for docs/") {
+		t.Fatalf("unzip of 5 files into a 3-file quota: success=%v err=%v", result.Success, result.Error)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(tmpDir, "docs")); len(entries) != 0 {
+		t.Errorf("unzip wrote %d files before failing", len(entries))
+	}
+
+	delete(files, "page3.md")
+	delete(files, "page4.md")
+	writeZip(t, filepath.Join(tmpDir, "pages.zip"), files)
+	if result := e.Execute(scanner.Command{Type: "unzip", Argument: "pages.zip docs"}); !result.Success {
+		t.Fatalf("unzip of 3 files = %v", result.Error)
+	}
+
+	// The extracted files count toward the quota of a later fetch
+	server := newFetchServer(t)
+	cfg.FetchAllowedDomains = []string{"127.0.0.1"}
+	cfg.FetchContentTypes = config.DefaultFetchContentTypes
+	cfg.FetchMaxSize = 1024
+	result = e.Execute(scanner.Command{Type: "fetch", Argument: server.URL + "/spec.json dest=docs/spec.json"})
+	if result.Success || !strings.Contains(result.Error.Error(), "write quota for docs/") {
+		t.Errorf("fetch over the quota: success=%v err=%v", result.Success, result.Error)
+	}
+}
@@ -0,0 +1,298 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/lsp"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// renameQuery is the argument of <rename-symbol>
+type renameQuery struct {
+	old     lspQuery // The symbol to rename, by name or position
+	target  string   // The old= value, to describe it by
+	newName string
+	scope   string // Such as ./pkg/...; empty for the whole repository
+}
+
+// symbolName matches the names a symbol can be renamed to
+var symbolName = regexp.MustCompile(`^[\pL_][\pL\pN_]*$`)
+
+// parseRenameQuery parses the argument of <rename-symbol>, such as
+// old=NewSession new=NewRuntimeSession scope=./...
+func parseRenameQuery(arg string) (renameQuery, error) {
+	var q renameQuery
+	var old, language string
+	for _, field := range strings.Fields(arg) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "old":
+			old = value
+		case "new":
+			q.newName = value
+		case "scope":
+			q.scope = value
+		case "lang":
+			language = value
+		default:
+			return q, errors.Newf(errors.ParseError, "unexpected %q; give old=NAME new=NAME, and optionally scope=./dir/...", field)
+		}
+	}
+	if old == "" || q.newName == "" {
+		return q, errors.New(errors.ParseError, "give the symbol to rename with old=, such as Type.Method or file.go:12:5, and its new name with new=")
+	}
+	if !symbolName.MatchString(q.newName) {
+		return q, errors.Newf(errors.ParseError, "%q is not a name", q.newName)
+	}
+	q.target = old
+	if language != "" {
+		old += " lang=" + language
+	}
+	var err error
+	q.old, err = parseLSPQuery(old)
+	return q, err
+}
+
+// renameScope is the part of the repository a rename may change
+type renameScope struct {
+	dir       string // Relative to the root, slash-separated; "" for the root
+	recursive bool   // Whether the directories below dir are in it, as with ./pkg/...
+}
+
+// renameScope parses scope, a directory, or a directory and those below it
+// as with ./pkg/...
+func (e *Executor) renameScope(scope string) (renameScope, error) {
+	s := renameScope{recursive: true}
+	dir, recursive := strings.CutSuffix(scope, "/...")
+	if scope == "" || scope == "..." || (recursive && dir == ".") {
+		return s, nil
+	}
+	s.recursive = recursive
+	safePath, err := sandbox.ValidateHostPath(dir, e.config.RepositoryRoot, e.config.ExcludedPaths)
+	if err != nil {
+		return s, errors.Wrap(errors.PathSecurity, err)
+	}
+	if info, err := os.Stat(safePath); err != nil || !info.IsDir() {
+		return s, errors.Newf(errors.FileNotFound, "scope %s is not a directory", dir)
+	}
+	if rel, _ := filepath.Rel(e.config.RepositoryRoot, safePath); rel != "." {
+		s.dir = filepath.ToSlash(rel)
+	}
+	return s, nil
+}
+
+// contains reports whether the file at rel, relative to the root, is in s
+func (s renameScope) contains(rel string) bool {
+	dir := path.Dir(rel)
+	if dir == "." {
+		dir = ""
+	}
+	return dir == s.dir || (s.recursive && (s.dir == "" || strings.HasPrefix(dir, s.dir+"/")))
+}
+
+// renamedFile is a file a rename changes
+type renamedFile struct {
+	rel           string // Relative to the root
+	before, after string
+	edits         int
+}
+
+// executeRenameSymbol handles the "rename-symbol" command. The language
+// server of the symbol's language works out the edits, which are checked
+// against the scope and exclusions for every file before any is written;
+// each file is then written as <write> writes it, with its backup,
+// formatting, and audit entry, and its diff returned.
+func (e *Executor) executeRenameSymbol(cmd scanner.Command) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{Command: cmd}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if e.auditLog != nil {
+			e.auditLog(cmd.Type, cmd.Argument, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	q, err := parseRenameQuery(cmd.Argument)
+	if err != nil {
+		return fail(err)
+	}
+	scope, err := e.renameScope(q.scope)
+	if err != nil {
+		return fail(err)
+	}
+	if err := e.lspFile(&q.old); err != nil {
+		return fail(err)
+	}
+	language, err := e.lspLanguage(q.old.language)
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(e.traceCtx, e.config.LSPTimeout)
+	defer cancel()
+	server, err := e.languageServer(ctx, language)
+	var edits map[string][]lsp.TextEdit
+	if err == nil {
+		server.sync(e.config.RepositoryRoot)
+		edits, err = e.renameEdits(ctx, server, q.old, q.newName, scope)
+	}
+	if err != nil {
+		return fail(e.lspError(ctx, language, err))
+	}
+	files, err := e.renameFiles(edits, scope)
+	if err != nil {
+		return fail(err)
+	}
+	if len(files) == 0 {
+		return fail(errors.Newf(errors.LSPFailed, "the %s language server found nothing to rename", language))
+	}
+	// The files are held to the write quotas together, so a rename that
+	// would go over changes none of them
+	planned := make([]QuotaWrite, 0, len(files))
+	for _, f := range files {
+		planned = append(planned, QuotaWrite{Path: f.rel, Size: int64(len(f.after)), Exists: true})
+	}
+	if err := e.guards().checkQuotas(planned, e.config); err != nil {
+		return fail(err)
+	}
+
+	// Files the LLM opened are not tracked as seen again, so a write of
+	// one from before the rename is caught as a conflict
+	var diffs strings.Builder
+	var written []string
+	edited := 0
+	for _, f := range files {
+		step := executeWrite(e.traceCtx, f.rel, f.after, "", e.config, e.auditLog, e.pool)
+		e.recordWriteQuota(step)
+		result.Steps = append(result.Steps, step)
+		if !step.Success {
+			if len(written) > 0 {
+				return fail(errors.Wrapf(errors.CodeOf(step.Error), step.Error, "%s (already renamed in %s)", f.rel, strings.Join(written, ", ")))
+			}
+			return fail(step.Error)
+		}
+		written = append(written, f.rel)
+		edited += f.edits
+		result.BytesWritten += step.BytesWritten
+		after := f.after
+		if data, err := os.ReadFile(filepath.Join(e.config.RepositoryRoot, f.rel)); err == nil {
+			after = string(data) // As formatted
+		}
+		diffs.WriteString(UnifiedDiff("a/"+f.rel, "b/"+f.rel, f.before, after))
+	}
+
+	result.Success = true
+	target := q.target
+	if q.old.path != "" {
+		target = "the symbol at " + target
+	}
+	result.Result = fmt.Sprintf("Renamed %s to %s: %s in %s\n%s", target, q.newName, pluralize(edited, "edit"), pluralize(len(files), "file"), diffs.String())
+	result.ExecutionTime = time.Since(startTime)
+	if e.auditLog != nil {
+		e.auditLog(cmd.Type, cmd.Argument, true, fmt.Sprintf("language:%s,files:%d,edits:%d", language, len(files), edited))
+	}
+	return result
+}
+
+// renameEdits asks server for the edits renaming the symbol q names to
+// newName. A name must match exactly one symbol in scope.
+func (e *Executor) renameEdits(ctx context.Context, server *languageServer, q lspQuery, newName string, scope renameScope) (map[string][]lsp.TextEdit, error) {
+	file, pos := q.path, q.pos
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !q.column {
+			pos.Character = firstWord(lineAt(string(data), pos.Line))
+		}
+		server.client.Open(file, server.language, string(data))
+		defer server.client.CloseFile(file)
+		return server.client.Rename(ctx, file, pos, newName)
+	}
+
+	symbols, err := server.client.Symbols(ctx, symbolQuery(q.symbol))
+	if err != nil {
+		return nil, err
+	}
+	var matches []lspLocation
+	var found []lsp.Symbol
+	for _, s := range matchSymbols(symbols, q.symbol) {
+		l := e.lspLocations([]lsp.Location{s.Location}, s.KindName())[0]
+		if l.path != "" && scope.contains(l.path) {
+			matches = append(matches, l)
+			found = append(found, s)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no %s symbol in scope matches %s", server.language, q.symbol)
+	case 1:
+		return server.client.Rename(ctx, lsp.Path(found[0].Location.URI), found[0].Location.Range.Start, newName)
+	}
+	var candidates []string
+	for _, l := range lspShown(matches) {
+		candidates = append(candidates, fmt.Sprintf("%s:%d:%d (%s)", l.path, l.line, l.col, l.kind))
+	}
+	if len(candidates) > lspMaxSymbols {
+		candidates = append(candidates[:lspMaxSymbols], "...")
+	}
+	return nil, errors.Newf(errors.LSPFailed, "%s matches %d symbols; give one as old=file:line:col or narrow scope=: %s", q.symbol, len(found), strings.Join(candidates, ", "))
+}
+
+// renameFiles applies edits to the files they change, refusing a rename
+// that changes any file outside scope or that the session may not write
+func (e *Executor) renameFiles(edits map[string][]lsp.TextEdit, scope renameScope) ([]renamedFile, error) {
+	var files []renamedFile
+	var outside []string
+	hidden := 0
+	for file, fileEdits := range edits {
+		if len(fileEdits) == 0 {
+			continue
+		}
+		if !e.lspVisible(file) {
+			hidden++
+			continue
+		}
+		rel, _ := filepath.Rel(e.config.RepositoryRoot, file)
+		rel = filepath.ToSlash(rel)
+		if !scope.contains(rel) {
+			outside = append(outside, rel)
+			continue
+		}
+		if err := sandbox.ValidateWriteExtension(rel, e.config.AllowedExtensions); err != nil {
+			return nil, errors.Wrap(errors.ExtensionDenied, err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(errors.LSPFailed, err)
+		}
+		after, err := lsp.ApplyEdits(string(data), fileEdits)
+		if err != nil {
+			return nil, errors.Wrapf(errors.LSPFailed, err, "%s", rel)
+		}
+		files = append(files, renamedFile{rel: rel, before: string(data), after: after, edits: len(fileEdits)})
+	}
+	if len(outside) > 0 || hidden > 0 {
+		sort.Strings(outside)
+		if hidden > 0 {
+			outside = append(outside, pluralize(hidden, "file")+" outside the repository or excluded")
+		}
+		return nil, errors.Newf(errors.RenameDenied, "the rename would also change %s; nothing was renamed", strings.Join(outside, ", "))
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, nil
+}
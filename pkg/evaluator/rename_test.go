@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestExecuteRenameSymbol(t *testing.T) {
+	cfg := lspRepo(t)
+	cfg.MaxWriteSize = 1 << 20
+	sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+	t.Cleanup(func() { sandbox.SetFakeBackend(nil) })
+	executor := NewExecutor(cfg, nil, nil, nil)
+	defer executor.Close()
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(cfg.RepositoryRoot, name))
+		return string(data)
+	}
+	before := read("main.go")
+
+	// A rename reaching outside its scope, or into an excluded file,
+	// changes nothing
+	denied := map[string]string{
+		"old=NewExecutor new=NewRunner scope=./core/...": "main.go",
+		"old=NewExecutor new=Secret":                     "1 file outside the repository or excluded",
+	}
+	for arg, want := range denied {
+		result := executor.Execute(scanner.Command{Type: "rename-symbol", Argument: arg})
+		if result.Success || !strings.Contains(result.Error.Error(), "RENAME_DENIED") || !strings.Contains(result.Error.Error(), want) {
+			t.Errorf("<rename-symbol %s> = %+v, want RENAME_DENIED naming %s", arg, result, want)
+		}
+	}
+	if read("main.go") != before {
+		t.Fatal("a refused rename changed main.go")
+	}
+
+	result := executor.Execute(scanner.Command{Type: "rename-symbol", Argument: "old=NewExecutor new=NewRunner scope=./..."})
+	if !result.Success {
+		t.Fatalf("<rename-symbol> error = %v", result.Error)
+	}
+	if !strings.HasPrefix(result.Result, "Renamed NewExecutor to NewRunner: 3 edits in 2 files\n--- a/core/core.go\n") || !strings.Contains(result.Result, "+var b = core.NewRunner()\n") {
+		t.Errorf("result =\n%s", result.Result)
+	}
+	if len(result.Steps) != 2 || result.Steps[0].Command.Type != "write" || result.Steps[1].Command.Argument != "main.go" {
+		t.Errorf("steps = %+v, want a write of each file", result.Steps)
+	}
+	if got := read("main.go"); got != strings.ReplaceAll(before, "NewExecutor", "NewRunner") {
+		t.Errorf("main.go =\n%s", got)
+	}
+	if got := read("core/core.go"); !strings.Contains(got, "func NewRunner()") {
+		t.Errorf("core/core.go =\n%s", got)
+	}
+
+	result = executor.Execute(scanner.Command{Type: "rename-symbol", Argument: "old=NewExecutor new=Other scope=./secrets/..."})
+	if result.Success || !strings.Contains(result.Error.Error(), "PATH_SECURITY") {
+		t.Errorf("rename scoped to an excluded directory = %+v, want PATH_SECURITY", result)
+	}
+}
+
+func TestExecuteRenameSymbol_QuotaBatch(t *testing.T) {
+	cfg := lspRepo(t)
+	cfg.MaxWriteSize = 1 << 20
+	// Room for either renamed file, but not both
+	cfg.WriteQuotas = map[string]config.WriteQuota{".": {MaxBytes: 100}}
+	sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+	t.Cleanup(func() { sandbox.SetFakeBackend(nil) })
+	executor := NewExecutor(cfg, nil, nil, nil)
+	defer executor.Close()
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(cfg.RepositoryRoot, name))
+		return string(data)
+	}
+	before := map[string]string{"main.go": read("main.go"), "core/core.go": read("core/core.go")}
+
+	result := executor.Execute(scanner.Command{Type: "rename-symbol", Argument: "old=NewExecutor new=NewRunner"})
+	if result.Success || !strings.Contains(result.Error.Error(), "RESOURCE_LIMIT") {
+		t.Fatalf("rename over the quota = %+v, want RESOURCE_LIMIT", result)
+	}
+	for name, content := range before {
+		if read(name) != content {
+			t.Errorf("a rename over the quota changed %s", name)
+		}
+	}
+}
+
+func TestParseRenameQuery(t *testing.T) {
+	tests := []struct {
+		arg string
+		ok  bool
+	}{
+		{"old=NewSession new=NewRuntimeSession scope=./...", true},
+		{"old=pkg/app/app.go:12:5 new=run lang=go", true},
+		{"old=NewSession", false},
+		{"old=NewSession new=two-words", false},
+		{"old=NewSession new=X force", false},
+	}
+	for _, tt := range tests {
+		if _, err := parseRenameQuery(tt.arg); (err == nil) != tt.ok {
+			t.Errorf("parseRenameQuery(%q) error = %v", tt.arg, err)
+		}
+	}
+}
+
+func TestRenameScope_Contains(t *testing.T) {
+	tests := []struct {
+		scope renameScope
+		file  string
+		want  bool
+	}{
+		{renameScope{recursive: true}, "pkg/app/app.go", true},
+		{renameScope{dir: "pkg", recursive: true}, "pkg/app/app.go", true},
+		{renameScope{dir: "pkg", recursive: true}, "pkgs/a.go", false},
+		{renameScope{dir: "pkg"}, "pkg/a.go", true},
+		{renameScope{dir: "pkg"}, "pkg/app/app.go", false},
+		{renameScope{}, "main.go", true},
+		{renameScope{}, "pkg/a.go", false},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.contains(tt.file); got != tt.want {
+			t.Errorf("%+v.contains(%q) = %v, want %v", tt.scope, tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// splitForce strips the force option from an open argument such as
+// "main.go force", which returns the content even if it is unchanged
+func splitForce(arg string) (string, bool) {
+	if rest, ok := strings.CutSuffix(strings.TrimSpace(arg), " force"); ok {
+		return strings.TrimSpace(rest), true
+	}
+	return arg, false
+}
+
+// openKey identifies what an open argument returns: the file, and the line
+// range or cursor after its path, so different ranges are kept apart
+func (e *Executor) openKey(arg string) (string, bool) {
+	path, _, _ := splitCursor(arg)
+	path, _, _, _ = splitLineRange(path)
+	safePath, err := sandbox.ValidateHostPath(path, e.config.RepositoryRoot, e.config.ExcludedPaths)
+	if err != nil {
+		return "", false
+	}
+	return safePath + strings.TrimPrefix(arg, path), true
+}
+
+// skipRepeatOpen replaces the content of a successful open with a short
+// notice when the same content was already returned this session, unless
+// force is set, and remembers it otherwise. Opens inside a pipe are left
+// alone, since later steps consume them. It reports whether the content
+// was replaced.
+func (e *Executor) skipRepeatOpen(result *scanner.ExecutionResult, force bool) bool {
+	if !e.config.SkipRepeatOpens || !result.Success || result.Action == "BINARY_SUMMARY" || e.piping > 0 {
+		return false
+	}
+	key, ok := e.openKey(result.Command.Argument)
+	if !ok {
+		return false
+	}
+
+	hash := CalculateContentHash(result.Result)
+	e.mu.Lock()
+	seen := e.opened[key]
+	e.opened[key] = hash
+	e.mu.Unlock()
+	if force || seen != hash {
+		return false
+	}
+
+	result.Action = "UNCHANGED"
+	result.Result = fmt.Sprintf("Unchanged since it was last opened this session (sha256 %s); use <open %s force> to see it again\n",
+		hash[:12], result.Command.Argument)
+	return true
+}
+
+// forgetOpen drops what an open returned, when only part of it reached the
+// LLM, so opening it again returns it in full
+func (e *Executor) forgetOpen(arg string) {
+	key, ok := e.openKey(arg)
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	delete(e.opened, key)
+	e.mu.Unlock()
+}
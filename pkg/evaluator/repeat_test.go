@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestSplitForce(t *testing.T) {
+	tests := map[string]struct {
+		path  string
+		force bool
+	}{
+		"main.go":            {"main.go", false},
+		"main.go force":      {"main.go", true},
+		"main.go:1-5  force": {"main.go:1-5", true},
+		"force":              {"force", false},
+	}
+	for arg, want := range tests {
+		if path, force := splitForce(arg); path != want.path || force != want.force {
+			t.Errorf("splitForce(%q) = %q, %v, want %q, %v", arg, path, force, want.path, want.force)
+		}
+	}
+}
+
+func TestExecute_SkipRepeatOpen(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+	t.Cleanup(func() { sandbox.SetFakeBackend(nil) })
+	cfg := newTestConfig(tmpDir)
+	cfg.SkipRepeatOpens = true
+	executor := NewExecutor(cfg, nil, nil, nil)
+	defer executor.Close()
+	open := func(arg string) scanner.ExecutionResult {
+		t.Helper()
+		result := executor.Execute(scanner.Command{Type: "open", Argument: arg})
+		if !result.Success {
+			t.Fatalf("open %s: %v", arg, result.Error)
+		}
+		return result
+	}
+
+	if result := open("main.go"); result.Action == "UNCHANGED" {
+		t.Fatalf("first open = %q, want the content", result.Result)
+	}
+	result := open("main.go")
+	if result.Action != "UNCHANGED" || !strings.Contains(result.Result, "use <open main.go force> to see it again") {
+		t.Errorf("repeated open = %q (%s), want a notice", result.Result, result.Action)
+	}
+	if result := open("main.go:1-1"); result.Result != "package main\n" {
+		t.Errorf("open of a range = %q, want its line", result.Result)
+	}
+	if result := open("main.go force"); result.Action == "UNCHANGED" || !strings.Contains(result.Result, "func main") {
+		t.Errorf("forced open = %q, want the content", result.Result)
+	}
+
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() { run() }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result := open("main.go"); !strings.Contains(result.Result, "run()") {
+		t.Errorf("open after a change = %q, want the new content", result.Result)
+	}
+
+	cfg.SkipRepeatOpens = false
+	if result := open("main.go"); result.Action == "UNCHANGED" {
+		t.Errorf("open with SkipRepeatOpens off = %q, want the content", result.Result)
+	}
+}
+
+func TestExecute_RepeatOpenAfterTruncation(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(numberedLines(50)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+	t.Cleanup(func() { sandbox.SetFakeBackend(nil) })
+	cfg := newTestConfig(tmpDir)
+	cfg.SkipRepeatOpens = true
+	cfg.OutputBudget = 132
+	executor := NewExecutor(cfg, nil, nil, nil)
+	defer executor.Close()
+
+	// Only part of the file reached the LLM, so it is returned again
+	for i := 0; i < 2; i++ {
+		executor.StartTurn()
+		result := executor.Execute(scanner.Command{Type: "open", Argument: "main.go"})
+		if result.Action == "UNCHANGED" || !strings.Contains(result.Result, "lines omitted") {
+			t.Errorf("open %d = %q (%s), want the truncated content", i+1, result.Result, result.Action)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// DefaultREPLLanguage is the language of a <repl> that names none
+const DefaultREPLLanguage = "python"
+
+// replLanguage is how the interpreter of a <repl> language is run: the
+// command reading snippets on stdin, and the line sent after each snippet
+// that makes the interpreter print a marker (%s) once it has run
+type replLanguage struct {
+	Command []string
+	End     string
+}
+
+// pythonKernel runs each snippet in one namespace, like a notebook kernel,
+// showing the value of a final expression. A traceback marks the snippet
+// failed.
+const pythonKernel = `import ast, sys, traceback
+ns = {"__name__": "__main__"}
+lines = []
+while True:
+    line = sys.stdin.readline()
+    if not line:
+        break
+    if not line.startswith("#llm-repl-end "):
+        lines.append(line)
+        continue
+    marker, status = line.split()[1], 0
+    try:
+        tree = ast.parse("".join(lines), "<repl>")
+        last = tree.body.pop() if tree.body and isinstance(tree.body[-1], ast.Expr) else None
+        exec(compile(tree, "<repl>", "exec"), ns)
+        if last is not None:
+            value = eval(compile(ast.Expression(last.value), "<repl>", "eval"), ns)
+            if value is not None:
+                print(repr(value))
+    except BaseException:
+        traceback.print_exc()
+        status = 1
+    lines = []
+    sys.stderr.flush()
+    print(marker, status, flush=True)
+`
+
+// replLanguages are the languages <repl> supports
+var replLanguages = map[string]replLanguage{
+	"python": {Command: []string{"python3", "-u", "-c", pythonKernel}, End: "#llm-repl-end %s"},
+	"go":     {Command: []string{"yaegi"}, End: `println("%s")`},
+}
+
+// replEnabled reports whether lang is one of the languages cfg enables
+func replEnabled(lang string, cfg *config.Config) bool {
+	for _, enabled := range cfg.REPLLanguages {
+		if strings.EqualFold(strings.TrimSpace(enabled), lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// executeREPL runs a <repl lang>code</repl> snippet in the session's
+// interpreter for lang, starting one on first use. The interpreter keeps
+// its state until <repl-reset>, the end of the session, or a snippet that
+// times out or kills it. Snippets have side effects, so they are never
+// retried.
+func (e *Executor) executeREPL(cmd scanner.Command) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{Command: cmd}
+	lang := strings.ToLower(strings.TrimSpace(cmd.Argument))
+	if lang == "" {
+		lang = DefaultREPLLanguage
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if e.auditLog != nil {
+			e.auditLog("repl", lang, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	language, ok := replLanguages[lang]
+	if !ok {
+		return fail(errors.Newf(errors.REPLDisabled, "unsupported language %q (want python or go)", lang))
+	}
+	if !replEnabled(lang, e.config) {
+		return fail(errors.Newf(errors.REPLDisabled, "%s repl is not enabled", lang))
+	}
+	if strings.TrimSpace(cmd.Content) == "" {
+		return fail(errors.New(errors.ParseError, "repl needs code: <repl python>print(1)</repl>"))
+	}
+
+	session, reused := e.repls[lang]
+	if !reused {
+		var err error
+		if session, err = e.startREPL(lang, language); err != nil {
+			return fail(err)
+		}
+	}
+
+	ctx := e.traceCtx
+	if e.config.REPLTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.REPLTimeout)
+		defer cancel()
+	}
+	out, err := session.Run(ctx, cmd.Content, language.End)
+	result.Stdout = out.Output
+	result.Result = out.Output
+	result.ExecutionTime = time.Since(startTime)
+
+	if err != nil {
+		// The interpreter is still running the snippet or is gone, so its
+		// state cannot be kept
+		e.resetREPL(lang)
+		switch {
+		case errors.Is(err, sandbox.ErrTimeout):
+			result.ExitCode = sandbox.TimeoutExitCode
+			result.Error = errors.Newf(errors.ExecTimeout, "snippet timed out after %v; the %s session was reset", e.config.REPLTimeout, lang)
+		case errors.Is(err, sandbox.ErrInterrupted):
+			result.ExitCode = sandbox.InterruptExitCode
+			result.Error = errors.Newf(errors.ExecInterrupted, "snippet stopped by interrupt; the %s session was reset", lang)
+		case errors.Is(err, sandbox.ErrREPLExited):
+			result.Error = errors.Newf(errors.ExecFailed, "the %s interpreter exited, possibly out of memory; the session was reset", lang)
+		default:
+			result.Error = errors.Wrap(errors.ExecError, err)
+		}
+	} else if out.Failed {
+		result.ExitCode = 1
+		result.Error = errors.New(errors.ExecFailed, "snippet raised an error")
+	} else {
+		result.Success = true
+	}
+
+	auditMsg := fmt.Sprintf("language:%s,duration:%.3fs", lang, result.ExecutionTime.Seconds())
+	if result.Success {
+		auditMsg += ",status:completed"
+	} else {
+		auditMsg += ",status:failed"
+	}
+	auditMsg += ",hash:" + sandbox.CommandHash(cmd.Content)
+	if !reused {
+		auditMsg += ",session:started"
+	}
+	if e.auditLog != nil {
+		e.auditLog("repl", lang, result.Success, auditMsg)
+	}
+	return result
+}
+
+// startREPL starts the interpreter for lang and keeps it for the session
+func (e *Executor) startREPL(lang string, language replLanguage) (*sandbox.REPLSession, error) {
+	if err := sandbox.CheckDockerAvailability(); err != nil {
+		return nil, errors.Wrap(errors.DockerUnavailable, err)
+	}
+	image := e.config.REPLImages[lang]
+	if image == "" {
+		image = config.DefaultREPLImages[lang]
+	}
+	if err := sandbox.PullDockerImage(image, e.config.Verbose); err != nil {
+		return nil, errors.Wrap(errors.DockerImage, err)
+	}
+
+	env, _ := sandbox.ExecEnv(e.config.ExecEnv, e.config.ExecEnvPassthrough, os.LookupEnv)
+	session, err := sandbox.StartREPL(e.traceCtx, sandbox.REPLConfig{
+		Image:       image,
+		Command:     language.Command,
+		RepoRoot:    e.config.RepositoryRoot,
+		MemoryLimit: e.config.REPLMemoryLimit,
+		CPULimit:    e.config.REPLCPULimit,
+		Env:         env,
+	})
+	if err != nil {
+		return nil, errors.Wrap(errors.ExecError, err)
+	}
+	if e.repls == nil {
+		e.repls = make(map[string]*sandbox.REPLSession)
+	}
+	e.repls[lang] = session
+	return session, nil
+}
+
+// resetREPL stops the interpreter for lang, if one is running, reporting
+// whether there was one
+func (e *Executor) resetREPL(lang string) bool {
+	session, ok := e.repls[lang]
+	if !ok {
+		return false
+	}
+	delete(e.repls, lang)
+	if err := session.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to stop %s repl: %v\n", lang, err)
+	}
+	return true
+}
+
+// executeREPLReset handles <repl-reset lang>, which discards the state of
+// the interpreter for lang, or of every interpreter if no language is
+// given. The next snippet starts a fresh one.
+func (e *Executor) executeREPLReset(cmd scanner.Command) scanner.ExecutionResult {
+	startTime := time.Now()
+	lang := strings.ToLower(strings.TrimSpace(cmd.Argument))
+
+	var langs []string
+	if lang == "" {
+		for running := range e.repls {
+			langs = append(langs, running)
+		}
+		sort.Strings(langs)
+	} else {
+		langs = []string{lang}
+	}
+	var reset []string
+	for _, l := range langs {
+		if e.resetREPL(l) {
+			reset = append(reset, l)
+		}
+	}
+
+	message := "No repl session was running\n"
+	if len(reset) > 0 {
+		message = fmt.Sprintf("Reset %s repl session\n", strings.Join(reset, ", "))
+	}
+	if e.auditLog != nil {
+		e.auditLog("repl-reset", cmd.Argument, true, fmt.Sprintf("reset:%d", len(reset)))
+	}
+	return scanner.ExecutionResult{
+		Command:       cmd,
+		Success:       true,
+		Result:        message,
+		ExecutionTime: time.Since(startTime),
+	}
+}
+
+// Close stops the session's repl interpreters and language servers
+func (e *Executor) Close() {
+	for lang := range e.repls {
+		e.resetREPL(lang)
+	}
+	for lang, server := range e.servers {
+		server.client.Close()
+		delete(e.servers, lang)
+	}
+}
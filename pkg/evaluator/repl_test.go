@@ -0,0 +1,40 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestExecuteREPL_Disabled(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	cfg.REPLLanguages = []string{"python"}
+	e := NewExecutor(cfg, nil, nil, nil)
+
+	tests := []struct {
+		name string
+		cmd  scanner.Command
+		want string
+	}{
+		{"not enabled", scanner.Command{Type: "repl", Argument: "go", Content: "println(1)"}, "REPL_DISABLED"},
+		{"unsupported", scanner.Command{Type: "repl", Argument: "ruby", Content: "puts 1"}, "REPL_DISABLED"},
+		{"no code", scanner.Command{Type: "repl", Argument: "python", Content: " "}, "PARSE_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := e.Execute(tt.cmd)
+			if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), tt.want) {
+				t.Errorf("result = %+v, want %s", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteREPLReset_NoSession(t *testing.T) {
+	e := NewExecutor(newTestConfig(t.TempDir()), nil, nil, nil)
+	result := e.Execute(scanner.Command{Type: "repl-reset"})
+	if !result.Success || result.Result != "No repl session was running\n" {
+		t.Errorf("result = %+v", result)
+	}
+}
@@ -0,0 +1,73 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// retryPolicy returns the policy for a command type: its own entry in
+// cfg.RetryPolicies if there is one, otherwise cfg.Retry
+func retryPolicy(cfg *config.Config, cmdType string) config.RetryPolicy {
+	policy := cfg.Retry
+	if p, ok := cfg.RetryPolicies[cmdType]; ok {
+		policy = p
+	}
+	if len(policy.RetryOn) == 0 {
+		policy.RetryOn = config.DefaultRetryOn
+	}
+	return policy
+}
+
+// errorCode returns the code of an error such as "EXEC_TIMEOUT"
+func errorCode(err error) string {
+	return string(errors.CodeOf(err))
+}
+
+// retryable reports whether a failed result should be retried under
+// policy, and its error code
+func retryable(result scanner.ExecutionResult, policy config.RetryPolicy) (string, bool) {
+	if result.Success || result.Error == nil {
+		return "", false
+	}
+	code := errorCode(result.Error)
+	for _, c := range policy.RetryOn {
+		if strings.EqualFold(strings.TrimSpace(c), code) {
+			return code, true
+		}
+	}
+	return code, false
+}
+
+// withRetry runs a command, retrying it under the command type's policy
+// while it fails with a retryable error. Each attempt is audited by the
+// command itself; each retry is also audited with the error that caused it.
+func (e *Executor) withRetry(cmd scanner.Command, run func() scanner.ExecutionResult) scanner.ExecutionResult {
+	policy := retryPolicy(e.config, cmd.Type)
+	backoff := policy.Backoff
+
+	result := run()
+	attempts := 1
+	for attempts <= policy.MaxRetries {
+		code, ok := retryable(result, policy)
+		if !ok {
+			break
+		}
+
+		if e.auditLog != nil {
+			e.auditLog("retry", cmd.Type+" "+cmd.Argument, true,
+				fmt.Sprintf("attempt:%d/%d,after:%s,backoff:%s", attempts+1, policy.MaxRetries+1, code, backoff))
+		}
+		e.sleep(backoff)
+		backoff *= 2
+
+		result = run()
+		attempts++
+	}
+
+	result.Attempts = attempts
+	return result
+}
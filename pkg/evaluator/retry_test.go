@@ -0,0 +1,130 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// newRetryExecutor returns an executor that records sleeps instead of
+// sleeping
+func newRetryExecutor(t *testing.T, policy config.RetryPolicy) (*Executor, *testAuditLog, *[]time.Duration) {
+	cfg := newTestConfig(t.TempDir())
+	cfg.Retry = policy
+	audit := &testAuditLog{}
+	executor := NewExecutor(cfg, nil, audit.log, nil)
+	var sleeps []time.Duration
+	executor.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	return executor, audit, &sleeps
+}
+
+// failingRun returns a run function that fails with the given errors in
+// turn and succeeds once they are used up
+func failingRun(calls *int, errs ...error) func() scanner.ExecutionResult {
+	return func() scanner.ExecutionResult {
+		*calls++
+		if *calls <= len(errs) {
+			return scanner.ExecutionResult{Success: false, Error: errs[*calls-1]}
+		}
+		return scanner.ExecutionResult{Success: true}
+	}
+}
+
+func TestWithRetry_RetriesRetryableErrors(t *testing.T) {
+	executor, audit, sleeps := newRetryExecutor(t, config.RetryPolicy{
+		MaxRetries: 3,
+		Backoff:    time.Second,
+		RetryOn:    []string{"EXEC_TIMEOUT"},
+	})
+
+	calls := 0
+	cmd := scanner.Command{Type: "exec", Argument: "go test ./..."}
+	result := executor.withRetry(cmd, failingRun(&calls,
+		errors.New(errors.ExecTimeout, "command timed out after 30s"),
+		errors.New(errors.ExecTimeout, "command timed out after 30s"),
+	))
+
+	if !result.Success {
+		t.Fatalf("expected success after retries, got %v", result.Error)
+	}
+	if calls != 3 || result.Attempts != 3 {
+		t.Errorf("calls = %d, Attempts = %d, want 3", calls, result.Attempts)
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != time.Second || (*sleeps)[1] != 2*time.Second {
+		t.Errorf("sleeps = %v, want [1s 2s]", *sleeps)
+	}
+
+	entries := audit.getEntries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 retry audit entries, got %d", len(entries))
+	}
+	if entries[0].cmdType != "retry" || !strings.Contains(entries[0].errMsg, "after:EXEC_TIMEOUT") {
+		t.Errorf("audit entry = %+v, want retry after EXEC_TIMEOUT", entries[0])
+	}
+}
+
+func TestWithRetry_StopsAtMaxRetries(t *testing.T) {
+	executor, _, _ := newRetryExecutor(t, config.RetryPolicy{MaxRetries: 2})
+
+	calls := 0
+	timeout := errors.New(errors.ExecTimeout, "command timed out after 30s")
+	result := executor.withRetry(scanner.Command{Type: "exec"}, failingRun(&calls, timeout, timeout, timeout, timeout))
+
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if calls != 3 || result.Attempts != 3 {
+		t.Errorf("calls = %d, Attempts = %d, want 3", calls, result.Attempts)
+	}
+}
+
+func TestWithRetry_DoesNotRetryOtherErrors(t *testing.T) {
+	executor, _, sleeps := newRetryExecutor(t, config.RetryPolicy{MaxRetries: 3, RetryOn: []string{"EXEC_TIMEOUT"}})
+
+	calls := 0
+	result := executor.withRetry(scanner.Command{Type: "exec"}, failingRun(&calls,
+		errors.New(errors.ExecFailed, "command exited with code 1"),
+	))
+
+	if result.Success || calls != 1 || result.Attempts != 1 {
+		t.Errorf("success = %v, calls = %d, Attempts = %d, want one failed attempt", result.Success, calls, result.Attempts)
+	}
+	if len(*sleeps) != 0 {
+		t.Errorf("sleeps = %v, want none", *sleeps)
+	}
+}
+
+func TestRetryPolicy_PerCommandOverride(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	cfg.Retry = config.RetryPolicy{MaxRetries: 1}
+	cfg.RetryPolicies = map[string]config.RetryPolicy{
+		"exec": {MaxRetries: 4, RetryOn: []string{"EXEC_FAILED"}},
+	}
+
+	if got := retryPolicy(cfg, "exec"); got.MaxRetries != 4 || got.RetryOn[0] != "EXEC_FAILED" {
+		t.Errorf("exec policy = %+v, want override", got)
+	}
+
+	open := retryPolicy(cfg, "open")
+	if open.MaxRetries != 1 {
+		t.Errorf("open MaxRetries = %d, want 1", open.MaxRetries)
+	}
+	if len(open.RetryOn) != len(config.DefaultRetryOn) {
+		t.Errorf("open RetryOn = %v, want defaults", open.RetryOn)
+	}
+}
+
+func TestExecute_NoRetryByDefault(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	executor := NewExecutor(cfg, nil, nil, nil)
+	executor.sleep = func(time.Duration) { t.Error("unexpected sleep") }
+
+	result := executor.Execute(scanner.Command{Type: "write", Argument: ".env", Content: "x"})
+	if result.Success || result.Attempts != 1 {
+		t.Errorf("success = %v, Attempts = %d, want one failed attempt", result.Success, result.Attempts)
+	}
+}
@@ -0,0 +1,92 @@
+package evaluator
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
+)
+
+// SetScratch sets the session's scratch space, which <open>, <write>, and
+// <append> reach at /scratch. Without one, /scratch paths are refused.
+func (e *Executor) SetScratch(space *scratch.Space) {
+	e.scratch = space
+}
+
+// executeScratch runs an <open>, <write>, or <append> of a file in space.
+// Scratch files are outside the repository, so the extension, quota,
+// conflict, and backup checks of repository files don't apply, only the
+// size limits.
+func executeScratch(cmd scanner.Command, space *scratch.Space, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{Command: cmd}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog(cmd.Type, cmd.Argument, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if space == nil {
+		return fail(errors.Newf(errors.ScratchDisabled, "%s is not available; the session has no scratch space", scratch.MountPath))
+	}
+	if _, err := space.HostPath(cmd.Argument); err != nil {
+		return fail(errors.Wrap(errors.PathSecurity, err))
+	}
+
+	current, err := space.ReadFile(cmd.Argument)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+
+	if cmd.Type == "open" {
+		if !exists {
+			return fail(errors.Newf(errors.FileNotFound, "file not found: %s", cmd.Argument))
+		}
+		if int64(len(current)) > cfg.MaxFileSize {
+			return fail(errors.Newf(errors.ResourceLimit, "file too large (%d bytes, max %d)", len(current), cfg.MaxFileSize))
+		}
+		result.Success = true
+		result.Result = string(current)
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("open", cmd.Argument, true, fmt.Sprintf("scratch,bytes:%d", len(current)))
+		}
+		return result
+	}
+
+	content := cmd.Content
+	if cmd.Type == "append" {
+		content = appendLines(string(current), content)
+	}
+	if int64(len(content)) > cfg.MaxWriteSize {
+		return fail(errors.Newf(errors.ResourceLimit, "content too large (%d bytes, max %d)", len(content), cfg.MaxWriteSize))
+	}
+	if err := space.WriteFile(cmd.Argument, []byte(content)); err != nil {
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+
+	switch {
+	case !exists:
+		result.Action = "CREATED"
+	case cmd.Type == "append":
+		result.Action = "APPENDED"
+	default:
+		result.Action = "UPDATED"
+	}
+	result.Success = true
+	result.BytesWritten = int64(len(content))
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog(cmd.Type, cmd.Argument, true, fmt.Sprintf("scratch,hash:%s,bytes:%d", CalculateContentHash(content), len(content)))
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
+)
+
+func TestExecute_Scratch(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{RepositoryRoot: root, MaxFileSize: 1024, MaxWriteSize: 64, AllowedExtensions: []string{".go"}}
+	space, err := scratch.New("host:1", false)
+	if err != nil {
+		t.Fatalf("scratch.New() error = %v", err)
+	}
+	defer space.Close()
+	e := NewExecutor(cfg, nil, nil, nil)
+	e.SetScratch(space)
+
+	// Extensions not allowed in the repository are fine in /scratch
+	result := e.Execute(scanner.Command{Type: "write", Argument: "/scratch/notes.md", Content: "first"})
+	if !result.Success || result.Action != "CREATED" {
+		t.Fatalf("write = %+v", result)
+	}
+	result = e.Execute(scanner.Command{Type: "append", Argument: "/scratch/notes.md", Content: "second"})
+	if !result.Success || result.Action != "APPENDED" {
+		t.Fatalf("append = %+v", result)
+	}
+	result = e.Execute(scanner.Command{Type: "open", Argument: "/scratch/notes.md"})
+	if !result.Success || result.Result != "first\nsecond\n" {
+		t.Errorf("open = %+v", result)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Errorf("repository has %d entries, want none", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(space.Dir, "notes.md")); err != nil {
+		t.Errorf("scratch file missing: %v", err)
+	}
+
+	result = e.Execute(scanner.Command{Type: "write", Argument: "/scratch/big.txt", Content: strings.Repeat("x", 65)})
+	if result.Success || !strings.Contains(result.Error.Error(), "RESOURCE_LIMIT") {
+		t.Errorf("oversized write = %+v, want RESOURCE_LIMIT", result)
+	}
+	result = e.Execute(scanner.Command{Type: "open", Argument: "/scratch/../etc/passwd"})
+	if result.Success {
+		t.Errorf("open outside /scratch succeeded")
+	}
+
+	e.SetScratch(nil)
+	result = e.Execute(scanner.Command{Type: "open", Argument: "/scratch/notes.md"})
+	if result.Success || !strings.Contains(result.Error.Error(), "SCRATCH_DISABLED") {
+		t.Errorf("open without scratch = %+v, want SCRATCH_DISABLED", result)
+	}
+}
@@ -1,18 +1,29 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
 	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
 	"github.com/computerscienceiscool/llm-runtime/pkg/search"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ExecuteSearch handles the "search" command
 func ExecuteSearch(query string, cfg *config.Config, searchCfg *search.SearchConfig, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeSearch(context.Background(), query, cfg, searchCfg, auditLog, pool)
+}
+
+// executeSearch is ExecuteSearch as part of the trace in ctx
+func executeSearch(ctx context.Context, query string, cfg *config.Config, searchCfg *search.SearchConfig, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
 	startTime := time.Now()
 	result := scanner.ExecutionResult{
 		Command: scanner.Command{Type: "search", Argument: query},
@@ -21,7 +32,7 @@ func ExecuteSearch(query string, cfg *config.Config, searchCfg *search.SearchCon
 	// Check if search is enabled
 	if searchCfg == nil || !searchCfg.Enabled {
 		result.Success = false
-		fullError := fmt.Errorf("SEARCH_DISABLED: search feature is not enabled")
+		fullError := errors.New(errors.SearchDisabled, "search feature is not enabled")
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -34,7 +45,7 @@ func ExecuteSearch(query string, cfg *config.Config, searchCfg *search.SearchCon
 	searchEngine, err := search.NewSearchEngine(searchCfg, cfg.RepositoryRoot)
 	if err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("SEARCH_INIT_FAILED: %w", err)
+		fullError := errors.Wrap(errors.SearchInitFailed, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -45,10 +56,18 @@ func ExecuteSearch(query string, cfg *config.Config, searchCfg *search.SearchCon
 	defer searchEngine.Close()
 
 	// Execute search
+	_, span := telemetry.Start(ctx, "search.query",
+		attribute.String("search.query", query),
+		attribute.Int("search.max_results", searchCfg.MaxResults),
+	)
+	searchStart := time.Now()
 	searchResults, err := searchEngine.Search(query)
+	metrics.SearchDuration.Observe(time.Since(searchStart).Seconds())
+	span.SetAttributes(attribute.Int("search.results", len(searchResults)))
+	telemetry.End(span, err)
 	if err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("SEARCH_FAILED: %w", err)
+		fullError := errors.Wrap(errors.SearchFailed, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -59,7 +78,13 @@ func ExecuteSearch(query string, cfg *config.Config, searchCfg *search.SearchCon
 
 	// Format results
 	result.Success = true
-	result.Result = formatSearchOutput(query, searchResults, searchCfg.MaxResults, time.Since(startTime))
+	var tokens func(search.SearchResult) int
+	if cfg.TokenAnnotations {
+		tokens = func(r search.SearchResult) int {
+			return fileTokens(cfg, filepath.Join(cfg.RepositoryRoot, r.FilePath), r.FileSize)
+		}
+	}
+	result.Result = formatSearchOutput(query, searchResults, searchCfg.MaxResults, time.Since(startTime), tokens)
 	result.ExecutionTime = time.Since(startTime)
 
 	// Log successful search
@@ -71,8 +96,9 @@ func ExecuteSearch(query string, cfg *config.Config, searchCfg *search.SearchCon
 	return result
 }
 
-// formatSearchOutput formats search results for output
-func formatSearchOutput(query string, results []search.SearchResult, maxResults int, duration time.Duration) string {
+// formatSearchOutput formats search results for output, annotating each
+// with the estimate of tokens when it is not nil
+func formatSearchOutput(query string, results []search.SearchResult, maxResults int, duration time.Duration, tokens func(search.SearchResult) int) string {
 	var output strings.Builder
 
 	output.WriteString(fmt.Sprintf("=== SEARCH: %s ===\n", query))
@@ -92,6 +118,9 @@ func formatSearchOutput(query string, results []search.SearchResult, maxResults
 		// File metadata
 		output.WriteString(fmt.Sprintf("   Lines: %d | Size: %s",
 			result.LineCount, formatFileSizeForSearch(result.FileSize)))
+		if tokens != nil {
+			output.WriteString(fmt.Sprintf(" | ≈%d tokens", tokens(result)))
+		}
 		output.WriteString("\n")
 
 		// Preview
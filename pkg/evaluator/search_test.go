@@ -267,7 +267,7 @@ func TestFormatSearchOutput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			output := formatSearchOutput(tt.query, tt.results, tt.maxResults, 0)
+			output := formatSearchOutput(tt.query, tt.results, tt.maxResults, 0, nil)
 
 			for _, expected := range tt.contains {
 				if !strings.Contains(output, expected) {
@@ -279,7 +279,7 @@ func TestFormatSearchOutput(t *testing.T) {
 }
 
 func TestFormatSearchOutput_Headers(t *testing.T) {
-	output := formatSearchOutput("query", []search.SearchResult{}, 10, 0)
+	output := formatSearchOutput("query", []search.SearchResult{}, 10, 0, nil)
 
 	if !strings.Contains(output, "=== SEARCH:") {
 		t.Error("output should contain SEARCH header")
@@ -418,7 +418,7 @@ func BenchmarkFormatSearchOutput_Empty(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		formatSearchOutput("query", results, 10, 0)
+		formatSearchOutput("query", results, 10, 0, nil)
 	}
 }
 
@@ -436,7 +436,7 @@ func BenchmarkFormatSearchOutput_ManyResults(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		formatSearchOutput("query", results, 10, 0)
+		formatSearchOutput("query", results, 10, 0, nil)
 	}
 }
 
@@ -460,7 +460,7 @@ func TestFormatSearchOutput_AllFields(t *testing.T) {
 		},
 	}
 
-	output := formatSearchOutput("main function", results, 10, 250*time.Millisecond)
+	output := formatSearchOutput("main function", results, 10, 250*time.Millisecond, nil)
 
 	checks := []string{
 		"=== SEARCH: main function ===",
@@ -489,7 +489,7 @@ func TestFormatSearchOutput_MultipleResults(t *testing.T) {
 		{FilePath: "c.go", Score: 0.75, LineCount: 300, FileSize: 3072, Preview: "preview c"},
 	}
 
-	output := formatSearchOutput("test", results, 10, 0)
+	output := formatSearchOutput("test", results, 10, 0, nil)
 
 	// Check ordering (should be 1, 2, 3)
 	if !strings.Contains(output, "1. a.go") {
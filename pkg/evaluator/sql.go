@@ -0,0 +1,271 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// sqlDrivers maps the driver names accepted in config to registered drivers
+var sqlDrivers = map[string]string{
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+	"mysql":      "mysql",
+	"sqlite3":    "sqlite3",
+	"sqlite":     "sqlite3",
+}
+
+// readStatements are the statement types that only read, and so run in a
+// read-only transaction that is rolled back
+var readStatements = map[string]bool{
+	"select": true, "with": true, "explain": true, "show": true,
+	"describe": true, "desc": true, "values": true, "pragma": true,
+}
+
+// sqlArgument matches an sql argument: name=NAME then the statement
+var sqlArgument = regexp.MustCompile(`(?s)^name=(\S+)\s+(.+)$`)
+
+// sqlComment matches comments before a statement's first keyword
+var sqlComment = regexp.MustCompile(`(?s)^(--[^\n]*\n?|/\*.*?\*/)`)
+
+// statementType returns the lowercase first keyword of query, skipping
+// whitespace, comments, and opening parentheses
+func statementType(query string) string {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n(")
+		loc := sqlComment.FindStringIndex(query)
+		if loc == nil {
+			break
+		}
+		query = query[loc[1]:]
+	}
+	end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		end = len(query)
+	}
+	return strings.ToLower(query[:end])
+}
+
+// checkStatement checks query is a single statement of a type db allows,
+// returning the statement without a trailing semicolon and its type
+func checkStatement(query string, db config.SQLDatabase) (string, string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+	if strings.Contains(query, ";") {
+		return "", "", errors.New(errors.SQLDenied, "only one statement may be run at a time")
+	}
+	stmt := statementType(query)
+	if stmt == "" {
+		return "", "", errors.New(errors.ParseError, "sql needs a statement")
+	}
+	allowed := db.AllowedStatements
+	if len(allowed) == 0 {
+		allowed = []string{"select"}
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSpace(a), stmt) {
+			return query, stmt, nil
+		}
+	}
+	return "", "", errors.Newf(errors.SQLDenied, "%s statements not allowed (allowed: %s)", strings.ToUpper(stmt), strings.Join(allowed, ", "))
+}
+
+// readOnlyDatabase reports whether every statement type db allows only reads
+func readOnlyDatabase(db config.SQLDatabase) bool {
+	for _, a := range db.AllowedStatements {
+		if !readStatements[strings.ToLower(strings.TrimSpace(a))] {
+			return false
+		}
+	}
+	return true
+}
+
+// ExecuteSQL handles the "sql" command
+func ExecuteSQL(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeSQL(context.Background(), arg, cfg, auditLog)
+}
+
+// executeSQL is ExecuteSQL as part of the trace in ctx. The statement runs
+// against the database named by name= from the host, in a transaction that
+// is rolled back unless the database allows statements that write. Rows
+// come back as a markdown table, cut to SQLMaxRows rows and SQLMaxBytes.
+func executeSQL(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "sql", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("sql", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	if len(cfg.SQLDatabases) == 0 {
+		return fail(errors.New(errors.SQLDisabled, "sql is disabled (no databases configured)"))
+	}
+	m := sqlArgument.FindStringSubmatch(strings.TrimSpace(arg))
+	if m == nil {
+		return fail(errors.New(errors.ParseError, "sql needs name=DATABASE and a statement"))
+	}
+	name := m[1]
+	dbCfg, ok := cfg.SQLDatabases[name]
+	if !ok {
+		return fail(errors.Newf(errors.SQLDenied, "unknown database: %s", name))
+	}
+	driver, ok := sqlDrivers[strings.ToLower(dbCfg.Driver)]
+	if !ok {
+		return fail(errors.Newf(errors.SQLFailed, "database %s has unsupported driver %q (want postgres, mysql, or sqlite3)", name, dbCfg.Driver))
+	}
+	query, stmt, err := checkStatement(m[2], dbCfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	if cfg.SQLTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.SQLTimeout)
+		defer cancel()
+	}
+	db, err := sql.Open(driver, dbCfg.DSN)
+	if err != nil {
+		return fail(errors.Wrap(errors.SQLFailed, err))
+	}
+	defer db.Close()
+
+	readOnly := readOnlyDatabase(dbCfg)
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return fail(errors.Wrap(errors.SQLFailed, err))
+	}
+	defer tx.Rollback()
+
+	var output, detail string
+	if readStatements[stmt] {
+		table, rows, err := querySQL(ctx, tx, query, cfg)
+		if err != nil {
+			return fail(errors.Wrap(errors.SQLFailed, err))
+		}
+		output = table
+		detail = fmt.Sprintf("db:%s,type:%s,rows:%d,bytes:%d", name, stmt, rows, len(table))
+	} else {
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return fail(errors.Wrap(errors.SQLFailed, err))
+		}
+		affected, _ := res.RowsAffected()
+		output = fmt.Sprintf("(%d rows affected)\n", affected)
+		detail = fmt.Sprintf("db:%s,type:%s,affected:%d", name, stmt, affected)
+	}
+	if !readOnly {
+		if err := tx.Commit(); err != nil {
+			return fail(errors.Wrap(errors.SQLFailed, err))
+		}
+	}
+
+	result.Success = true
+	result.Result = output
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("sql", arg, true, detail)
+	}
+	return result
+}
+
+// querySQL runs query and renders its rows as a markdown table, returning
+// the table and the number of rows in it
+func querySQL(ctx context.Context, tx *sql.Tx, query string, cfg *config.Config) (string, int, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", 0, err
+	}
+	var b strings.Builder
+	b.WriteString(markdownRow(columns))
+	b.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+
+	values := make([]any, len(columns))
+	targets := make([]any, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	count := 0
+	note := ""
+	for rows.Next() {
+		if cfg.SQLMaxRows > 0 && count == cfg.SQLMaxRows {
+			note = fmt.Sprintf("first %d rows shown; the query returned more", count)
+			break
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return "", 0, err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = formatSQLValue(v)
+		}
+		line := markdownRow(cells)
+		if cfg.SQLMaxBytes > 0 && int64(b.Len()+len(line)) > cfg.SQLMaxBytes {
+			note = fmt.Sprintf("%d rows shown; output truncated at %d bytes", count, cfg.SQLMaxBytes)
+			break
+		}
+		b.WriteString(line)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return "", 0, err
+	}
+
+	if note == "" {
+		note = fmt.Sprintf("%d rows", count)
+	}
+	fmt.Fprintf(&b, "\n(%s)\n", note)
+	return b.String(), count, nil
+}
+
+// markdownRow renders cells as a markdown table row
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		c = strings.ReplaceAll(c, "|", `\|`)
+		c = strings.ReplaceAll(c, "\r", "")
+		escaped[i] = strings.ReplaceAll(c, "\n", " ")
+	}
+	return "| " + strings.Join(escaped, " | ") + " |\n"
+}
+
+// formatSQLValue renders a scanned column value for a table cell
+func formatSQLValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(v) {
+			return fmt.Sprintf("(%d bytes)", len(v))
+		}
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
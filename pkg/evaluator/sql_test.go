@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestStatementType(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users", "select"},
+		{"  select 1", "select"},
+		{"-- recent\nSELECT 1", "select"},
+		{"/* note */ (SELECT 1)", "select"},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", "with"},
+		{"DELETE FROM users", "delete"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := statementType(tt.query); got != tt.want {
+			t.Errorf("statementType(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCheckStatement(t *testing.T) {
+	db := config.SQLDatabase{Driver: "sqlite3"}
+	if _, _, err := checkStatement("SELECT 1;", db); err != nil {
+		t.Errorf("SELECT with trailing semicolon: %v", err)
+	}
+	for _, query := range []string{"DELETE FROM users", "SELECT 1; DROP TABLE users", "/* x */ UPDATE users SET a = 1"} {
+		if _, _, err := checkStatement(query, db); err == nil || !strings.Contains(err.Error(), "SQL_DENIED") {
+			t.Errorf("checkStatement(%q) error = %v, want SQL_DENIED", query, err)
+		}
+	}
+
+	db.AllowedStatements = []string{"select", "insert"}
+	if _, _, err := checkStatement("INSERT INTO users VALUES (1)", db); err != nil {
+		t.Errorf("allowed INSERT: %v", err)
+	}
+}
+
+func newSQLConfig(t *testing.T) *config.Config {
+	dsn := filepath.Join(t.TempDir(), "dev.db")
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER, name TEXT, note TEXT);
+		INSERT INTO users VALUES (1, 'alice', 'a|b'), (2, 'bob', NULL), (3, 'carol', 'line1
+line2')`); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestConfig(t.TempDir())
+	cfg.SQLDatabases = map[string]config.SQLDatabase{"dev": {Driver: "sqlite3", DSN: dsn}}
+	cfg.SQLMaxRows = config.DefaultSQLMaxRows
+	cfg.SQLMaxBytes = config.DefaultSQLMaxBytes
+	cfg.SQLTimeout = config.DefaultSQLTimeout
+	return cfg
+}
+
+func TestExecuteSQL(t *testing.T) {
+	cfg := newSQLConfig(t)
+
+	audit := &testAuditLog{}
+	result := ExecuteSQL("name=dev SELECT id, name, note FROM users ORDER BY id", cfg, audit.log)
+	if !result.Success {
+		t.Fatalf("sql failed: %v", result.Error)
+	}
+	want := "| id | name | note |\n| --- | --- | --- |\n| 1 | alice | a\\|b |\n| 2 | bob | NULL |\n| 3 | carol | line1 line2 |\n\n(3 rows)\n"
+	if result.Result != want {
+		t.Errorf("Result = %q, want %q", result.Result, want)
+	}
+	if len(audit.entries) != 1 || !strings.Contains(audit.entries[0].errMsg, "rows:3") {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+}
+
+func TestExecuteSQL_Limits(t *testing.T) {
+	cfg := newSQLConfig(t)
+	cfg.SQLMaxRows = 2
+	result := ExecuteSQL("name=dev SELECT id FROM users ORDER BY id", cfg, nil)
+	if !result.Success || !strings.Contains(result.Result, "first 2 rows shown") || strings.Contains(result.Result, "| 3 |") {
+		t.Errorf("row limit: %+v", result)
+	}
+
+	cfg.SQLMaxRows = 0
+	cfg.SQLMaxBytes = 40
+	result = ExecuteSQL("name=dev SELECT name FROM users ORDER BY id", cfg, nil)
+	if !result.Success || !strings.Contains(result.Result, "truncated at 40 bytes") {
+		t.Errorf("byte limit: %+v", result)
+	}
+}
+
+func TestExecuteSQL_Errors(t *testing.T) {
+	cfg := newSQLConfig(t)
+	tests := []struct {
+		name string
+		arg  string
+		want string
+	}{
+		{"no name", "SELECT 1", "PARSE_ERROR"},
+		{"unknown database", "name=prod SELECT 1", "SQL_DENIED"},
+		{"write", "name=dev DELETE FROM users", "SQL_DENIED"},
+		{"bad query", "name=dev SELECT * FROM missing", "SQL_FAILED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExecuteSQL(tt.arg, cfg, nil)
+			if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), tt.want) {
+				t.Errorf("result = %+v, want %s", result, tt.want)
+			}
+		})
+	}
+
+	cfg.SQLDatabases = nil
+	if result := ExecuteSQL("name=dev SELECT 1", cfg, nil); result.Error == nil || !strings.Contains(result.Error.Error(), "SQL_DISABLED") {
+		t.Errorf("no databases: %+v", result)
+	}
+}
@@ -0,0 +1,108 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scratch"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+)
+
+// summarizeMaxInput bounds the bytes of output sent to the summarizer,
+// taken from the start and end of it, so it fits a small model's context
+const summarizeMaxInput = 48 * 1024
+
+// Summarizer condenses the output of a command to about maxTokens tokens
+type Summarizer interface {
+	Summarize(ctx context.Context, command, output string, maxTokens int) (string, error)
+}
+
+// SetSummarizer sets what condenses output over the remaining budget when
+// SummarizeOversize is on. Without one, such output is returned as is.
+func (e *Executor) SetSummarizer(s Summarizer) {
+	e.summarizer = s
+}
+
+// summarizeOversize replaces the output of a successful command estimated
+// over the remaining budget with a summary, keeping the full output in a
+// file the summary names. Opens are cut to the budget by fitOpen instead,
+// and output inside a pipe is left alone, since later steps consume it. If
+// the summary fails, the output is kept and a warning says why.
+func (e *Executor) summarizeOversize(result *scanner.ExecutionResult) {
+	if !e.config.SummarizeOversize || e.summarizer == nil || !result.Success || e.piping > 0 {
+		return
+	}
+	switch result.Command.Type {
+	case "open", "pipe", "if-success", "if-failure":
+		return
+	}
+	remaining, limited := e.remainingBudget()
+	tokens := e.estimate(result.Result)
+	if !limited || tokens <= remaining {
+		return
+	}
+
+	command := fmt.Sprintf("<%s %s>", result.Command.Type, result.Command.Argument)
+	artifact, err := e.saveArtifact(result.Result)
+	if err != nil {
+		e.summaryFailed(result, command, fmt.Errorf("saving the full output: %w", err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(e.traceCtx, config.DefaultSummarizeTimeout)
+	defer cancel()
+	summary, err := e.summarizer.Summarize(ctx, command, clipMiddle(result.Result, summarizeMaxInput), max(remaining-truncationReserve, 100))
+	if err != nil {
+		e.summaryFailed(result, command, err)
+		return
+	}
+
+	lines := strings.Count(result.Result, "\n")
+	result.Result = fmt.Sprintf("[Summary of %d lines (≈%d tokens) of output, over the %d tokens left this turn; the full output is in %s]\n%s\n",
+		lines, tokens, remaining, artifact, summary)
+	if e.auditLog != nil {
+		e.auditLog("summarize", command, true, fmt.Sprintf("tokens:%d,summary:%d,artifact:%s", tokens, e.estimate(summary), artifact))
+	}
+}
+
+// summaryFailed records why the output of command was not summarized
+func (e *Executor) summaryFailed(result *scanner.ExecutionResult, command string, err error) {
+	result.Warnings = append(result.Warnings, fmt.Sprintf("output over the budget was not summarized: %v", err))
+	if e.auditLog != nil {
+		e.auditLog("summarize", command, false, err.Error())
+	}
+}
+
+// saveArtifact keeps output that is about to be summarized and returns
+// where: in /scratch when the session has one, so later commands can read
+// it, and otherwise in OutputArtifactDir or the temporary directory
+func (e *Executor) saveArtifact(output string) (string, error) {
+	name := "output-" + telemetry.CorrelationID(e.traceCtx) + ".txt"
+	if e.scratch != nil {
+		p := scratch.MountPath + "/outputs/" + name
+		return p, e.scratch.WriteFile(p, []byte(output))
+	}
+	dir := e.config.OutputArtifactDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "llm-runtime-outputs")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	p := filepath.Join(dir, name)
+	return p, os.WriteFile(p, []byte(output), 0600)
+}
+
+// clipMiddle shortens s to about n bytes by dropping its middle, which
+// holds the least of what a summary needs in most command output
+func clipMiddle(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	half := n / 2
+	return s[:half] + fmt.Sprintf("\n[... %d bytes omitted ...]\n", len(s)-2*half) + s[len(s)-half:]
+}
@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// fakeSummarizer returns a fixed summary, or err, and records its input
+type fakeSummarizer struct {
+	summary string
+	err     error
+	command string
+	output  string
+}
+
+func (f *fakeSummarizer) Summarize(ctx context.Context, command, output string, maxTokens int) (string, error) {
+	f.command, f.output = command, output
+	return f.summary, f.err
+}
+
+func TestSummarizeOversize(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 30; i++ {
+		os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%02d.txt", i)), []byte(strings.Repeat("x", 40*(i+1))), 0644)
+	}
+	cfg := newTestConfig(tmpDir)
+	cfg.OutputBudget = 50
+	cfg.SummarizeOversize = true
+	cfg.OutputArtifactDir = t.TempDir()
+	audit := &testAuditLog{}
+	executor := NewExecutor(cfg, nil, audit.log, nil)
+	defer executor.Close()
+	summarizer := &fakeSummarizer{summary: "30 text files, the largest file29.txt"}
+	executor.SetSummarizer(summarizer)
+
+	result := executor.Execute(scanner.Command{Type: "tokens", Argument: "."})
+	if !result.Success || !strings.HasPrefix(result.Result, "[Summary of 22 lines (≈") || !strings.HasSuffix(result.Result, "]\n30 text files, the largest file29.txt\n") {
+		t.Fatalf("tokens = %v:\n%s", result.Error, result.Result)
+	}
+	if summarizer.command != "<tokens .>" || !strings.Contains(summarizer.output, "file29.txt") {
+		t.Errorf("summarizer got %s:\n%s", summarizer.command, summarizer.output)
+	}
+	artifacts, _ := filepath.Glob(filepath.Join(cfg.OutputArtifactDir, "output-*.txt"))
+	if len(artifacts) != 1 || !strings.Contains(result.Result, artifacts[0]) {
+		t.Fatalf("artifacts = %v, result:\n%s", artifacts, result.Result)
+	}
+	if full, _ := os.ReadFile(artifacts[0]); !strings.Contains(string(full), "... and 10 smaller files") {
+		t.Errorf("artifact = %q, want the full output", full)
+	}
+	if last := audit.entries[len(audit.entries)-1]; last.cmdType != "summarize" || !last.success {
+		t.Errorf("audit = %+v", last)
+	}
+
+	// Within the budget, the output is returned as is
+	executor.StartTurn()
+	if result := executor.Execute(scanner.Command{Type: "tokens", Argument: "file00.txt"}); strings.HasPrefix(result.Result, "[Summary") {
+		t.Errorf("small output summarized:\n%s", result.Result)
+	}
+
+	// A failed summary keeps the full output
+	executor.StartTurn()
+	summarizer.err = errors.New("connection refused")
+	result = executor.Execute(scanner.Command{Type: "tokens", Argument: "."})
+	if !strings.HasPrefix(result.Result, ".: ≈") || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "connection refused") {
+		t.Errorf("failed summary = %q, warnings %q", result.Result, result.Warnings)
+	}
+}
+
+func TestClipMiddle(t *testing.T) {
+	if got := clipMiddle("short", 10); got != "short" {
+		t.Errorf("clipMiddle(short) = %q", got)
+	}
+	if got := clipMiddle(strings.Repeat("a", 10)+strings.Repeat("b", 10), 8); got != "aaaa\n[... 12 bytes omitted ...]\nbbbb" {
+		t.Errorf("clipMiddle = %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SyntaxError describes a syntax problem found in content before writing
+type SyntaxError struct {
+	Language string
+	Line     int // 1-based, 0 if unknown
+	Column   int // 1-based, 0 if unknown
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s syntax error at line %d, column %d: %s", e.Language, e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%s syntax error at line %d: %s", e.Language, e.Line, e.Message)
+	default:
+		return fmt.Sprintf("%s syntax error: %s", e.Language, e.Message)
+	}
+}
+
+// CheckSyntax validates content for file types with a known parser (Go,
+// JSON, YAML). It returns nil for valid content and unknown file types.
+func CheckSyntax(filePath, content string) *SyntaxError {
+	lastDot := strings.LastIndex(filePath, ".")
+	if lastDot == -1 {
+		return nil
+	}
+
+	switch strings.ToLower(filePath[lastDot:]) {
+	case ".go":
+		return checkGoSyntax(filePath, content)
+	case ".json":
+		return checkJSONSyntax(content)
+	case ".yaml", ".yml":
+		return checkYAMLSyntax(content)
+	default:
+		return nil
+	}
+}
+
+// checkGoSyntax parses Go source and reports the first error
+func checkGoSyntax(filePath, content string) *SyntaxError {
+	fset := token.NewFileSet()
+	_, err := parser.ParseFile(fset, filePath, content, parser.AllErrors)
+	if err == nil {
+		return nil
+	}
+
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		first := list[0]
+		return &SyntaxError{
+			Language: "go",
+			Line:     first.Pos.Line,
+			Column:   first.Pos.Column,
+			Message:  first.Msg,
+		}
+	}
+
+	return &SyntaxError{Language: "go", Message: err.Error()}
+}
+
+// checkJSONSyntax decodes JSON and converts byte offsets to line/column
+func checkJSONSyntax(content string) *SyntaxError {
+	var data interface{}
+	err := json.Unmarshal([]byte(content), &data)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := offsetToLineColumn(content, syntaxErr.Offset)
+		return &SyntaxError{Language: "json", Line: line, Column: col, Message: syntaxErr.Error()}
+	}
+
+	return &SyntaxError{Language: "json", Message: err.Error()}
+}
+
+// yamlLinePattern extracts the line number from yaml.v3 error messages
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// checkYAMLSyntax decodes every document in a YAML stream
+func checkYAMLSyntax(content string) *SyntaxError {
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+	for {
+		var doc interface{}
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			msg := strings.TrimPrefix(err.Error(), "yaml: ")
+			line := 0
+			if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+				line, _ = strconv.Atoi(m[1])
+			}
+			return &SyntaxError{Language: "yaml", Line: line, Message: msg}
+		}
+	}
+}
+
+// offsetToLineColumn converts a byte offset into 1-based line and column.
+// JSON offsets point just past the offending byte.
+func offsetToLineColumn(content string, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+
+	line, col := 1, 1
+	for i := int64(0); i < offset-1; i++ {
+		if content[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+
+	return line, col
+}
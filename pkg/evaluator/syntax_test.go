@@ -0,0 +1,225 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestCheckSyntax_Valid(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+	}{
+		{"main.go", "package main\n\nfunc main() {}\n"},
+		{"data.json", `{"a": [1, 2, 3]}`},
+		{"config.yaml", "key: value\nlist:\n  - a\n  - b\n"},
+		{"multi.yml", "a: 1\n---\nb: 2\n"},
+		{"notes.txt", "anything { goes"},
+		{"Makefile", "all:\n\tgo build"},
+	}
+
+	for _, tt := range tests {
+		if err := CheckSyntax(tt.path, tt.content); err != nil {
+			t.Errorf("CheckSyntax(%q) unexpected error: %v", tt.path, err)
+		}
+	}
+}
+
+func TestCheckSyntax_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		language string
+		line     int
+		column   int
+	}{
+		{
+			name:     "go missing brace",
+			path:     "main.go",
+			content:  "package main\n\nfunc main() {\n\tfmt.Println(\"hi\"\n}\n",
+			language: "go",
+			line:     4,
+			column:   18,
+		},
+		{
+			name:     "go missing package",
+			path:     "lib.go",
+			content:  "func f() {}\n",
+			language: "go",
+			line:     1,
+			column:   1,
+		},
+		{
+			name:     "json trailing comma",
+			path:     "data.json",
+			content:  "{\n  \"a\": 1,\n}",
+			language: "json",
+			line:     3,
+			column:   1,
+		},
+		{
+			name:     "yaml bad indentation",
+			path:     "config.yaml",
+			content:  "key: value\n  bad: indent\n",
+			language: "yaml",
+			line:     2,
+		},
+		{
+			name:     "yaml error in second document",
+			path:     "multi.yml",
+			content:  "a: 1\n---\nb: [unclosed\n",
+			language: "yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSyntax(tt.path, tt.content)
+			if err == nil {
+				t.Fatal("expected syntax error, got nil")
+			}
+			if err.Language != tt.language {
+				t.Errorf("Language = %q, want %q", err.Language, tt.language)
+			}
+			if tt.line != 0 && err.Line != tt.line {
+				t.Errorf("Line = %d, want %d (%v)", err.Line, tt.line, err)
+			}
+			if tt.column != 0 && err.Column != tt.column {
+				t.Errorf("Column = %d, want %d (%v)", err.Column, tt.column, err)
+			}
+		})
+	}
+}
+
+func TestSyntaxError_Error(t *testing.T) {
+	tests := []struct {
+		err  SyntaxError
+		want string
+	}{
+		{SyntaxError{Language: "go", Line: 3, Column: 7, Message: "bad"}, "go syntax error at line 3, column 7: bad"},
+		{SyntaxError{Language: "yaml", Line: 2, Message: "bad"}, "yaml syntax error at line 2: bad"},
+		{SyntaxError{Language: "json", Message: "bad"}, "json syntax error: bad"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestOffsetToLineColumn(t *testing.T) {
+	content := "ab\ncd\nef"
+	tests := []struct {
+		offset int64
+		line   int
+		col    int
+	}{
+		{1, 1, 1},
+		{2, 1, 2},
+		{4, 2, 1},
+		{8, 3, 2},
+		{100, 3, 2},
+	}
+
+	for _, tt := range tests {
+		line, col := offsetToLineColumn(content, tt.offset)
+		if line != tt.line || col != tt.col {
+			t.Errorf("offsetToLineColumn(%d) = (%d, %d), want (%d, %d)", tt.offset, line, col, tt.line, tt.col)
+		}
+	}
+}
+
+func TestExecuteWrite_SyntaxCheckReject(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.SyntaxCheck = config.SyntaxCheckReject
+
+	auditLog := &testAuditLog{}
+	result := ExecuteWrite("broken.json", `{"a": }`, cfg, auditLog.log, nil)
+
+	if result.Success {
+		t.Fatal("expected write to be rejected")
+	}
+	if !strings.HasPrefix(result.Error.Error(), "SYNTAX_ERROR") {
+		t.Errorf("expected SYNTAX_ERROR, got: %v", result.Error)
+	}
+	if !strings.Contains(result.Error.Error(), "line 1, column") {
+		t.Errorf("expected line/column in error, got: %v", result.Error)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "broken.json")); !os.IsNotExist(err) {
+		t.Error("rejected write should not create the file")
+	}
+
+	entries := auditLog.getEntries()
+	if len(entries) != 1 || entries[0].success || !strings.Contains(entries[0].errMsg, "SYNTAX_ERROR") {
+		t.Errorf("expected failed SYNTAX_ERROR audit entry, got %+v", entries)
+	}
+}
+
+func TestExecuteWrite_SyntaxCheckRejectKeepsExistingFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.SyntaxCheck = config.SyntaxCheckReject
+
+	existing := filepath.Join(tmpDir, "main.go")
+	original := "package main\n"
+	if err := os.WriteFile(existing, []byte(original), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	result := ExecuteWrite("main.go", "package main\nfunc {", cfg, nil, nil)
+	if result.Success {
+		t.Fatal("expected write to be rejected")
+	}
+	if result.BackupFile != "" {
+		t.Error("rejected write should not create a backup")
+	}
+
+	content, _ := os.ReadFile(existing)
+	if string(content) != original {
+		t.Error("rejected write modified the existing file")
+	}
+
+	backups, _ := NewBackupManager(cfg).List("main.go")
+	if len(backups) != 0 {
+		t.Errorf("expected no backup files, found %v", backups)
+	}
+}
+
+func TestExecuteWrite_SyntaxCheckWarnProceeds(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.SyntaxCheck = config.SyntaxCheckWarn
+
+	result := ExecuteWrite("broken.json", `{"a": }`, cfg, nil, nil)
+
+	// The write itself may fail without Docker; the syntax problem must not
+	// be what stops it
+	if result.Error != nil && strings.HasPrefix(result.Error.Error(), "SYNTAX_ERROR") {
+		t.Fatalf("warn mode should not reject writes: %v", result.Error)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "json syntax error") {
+		t.Errorf("expected one json syntax warning, got %v", result.Warnings)
+	}
+}
+
+func TestExecuteWrite_SyntaxCheckOff(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.SyntaxCheck = config.SyntaxCheckOff
+
+	result := ExecuteWrite("broken.json", `{"a": }`, cfg, nil, nil)
+
+	if result.Error != nil && strings.HasPrefix(result.Error.Error(), "SYNTAX_ERROR") {
+		t.Fatalf("off mode should not check syntax: %v", result.Error)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", result.Warnings)
+	}
+}
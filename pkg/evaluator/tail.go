@@ -0,0 +1,235 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// DefaultTailLines is the number of lines a <tail> without lines= shows,
+// as tail(1) does
+const DefaultTailLines = 10
+
+// tailPollInterval is how often a followed file is checked for new lines
+var tailPollInterval = 500 * time.Millisecond
+
+// tailOption matches an option at the end of a tail argument, such as
+// " lines=100" or " follow=30s"
+var tailOption = regexp.MustCompile(`\s+(lines|follow)=(\S+)$`)
+
+// tailRequest is a parsed tail argument
+type tailRequest struct {
+	Path   string
+	Lines  int
+	Follow time.Duration // Zero to not follow
+}
+
+// parseTailArgument splits a tail argument such as
+// "logs/build.log lines=50 follow=30s" into the path and its options
+func parseTailArgument(arg string) (tailRequest, error) {
+	req := tailRequest{Path: strings.TrimSpace(arg), Lines: DefaultTailLines}
+	seen := make(map[string]bool)
+	for {
+		m := tailOption.FindStringSubmatchIndex(req.Path)
+		if m == nil {
+			break
+		}
+		name, value := req.Path[m[2]:m[3]], req.Path[m[4]:m[5]]
+		req.Path = req.Path[:m[0]]
+		if seen[name] {
+			return req, errors.Newf(errors.ParseError, "%s= given twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "lines":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return req, errors.Newf(errors.ParseError, "invalid lines=%s (want a positive number)", value)
+			}
+			req.Lines = n
+		case "follow":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return req, errors.Newf(errors.ParseError, "invalid follow=%s (want a duration such as 30s)", value)
+			}
+			req.Follow = d
+		}
+	}
+	if req.Path == "" {
+		return req, errors.New(errors.ParseError, "tail needs a file path")
+	}
+	return req, nil
+}
+
+// ExecuteTail handles the "tail" command. Follow is not available, since
+// there is nowhere to stream appended lines to.
+func ExecuteTail(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeTail(context.Background(), arg, cfg, auditLog, pool, nil)
+}
+
+// executeTail is ExecuteTail as part of the trace in ctx. A follow= option
+// watches the file for the given time, writing each appended line to
+// follow as it arrives; it is refused if follow is nil.
+func executeTail(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool, follow io.Writer) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "tail", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("tail", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	req, err := parseTailArgument(arg)
+	if err != nil {
+		return fail(err)
+	}
+	if req.Follow > 0 && follow == nil {
+		return fail(errors.New(errors.FollowDisabled, "follow= is only available in interactive mode"))
+	}
+
+	// Validate the path as open does
+	safePath, err := sandbox.ValidateHostPath(req.Path, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return fail(errors.Wrap(errors.PathSecurity, err))
+	}
+	if cfg.RespectIgnoreFiles {
+		if err := sandbox.CheckIgnored(safePath, cfg.RepositoryRoot); err != nil {
+			return fail(errors.Wrap(errors.PathSecurity, err))
+		}
+	}
+	fileInfo, err := os.Stat(safePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fail(errors.New(errors.FileNotFound, req.Path))
+		}
+		return fail(errors.Wrap(errors.PermissionDenied, err))
+	}
+	if contentInfo, err := DetectContent(safePath); err == nil {
+		result.ContentType = contentInfo.ContentType
+		if contentInfo.Binary && !cfg.AllowBinary {
+			result.Success = true
+			result.Action = "BINARY_SUMMARY"
+			result.Result = formatBinarySummary(req.Path, fileInfo.Size(), contentInfo, cfg.BinaryHexBytes)
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("tail", arg, true, fmt.Sprintf("binary:%s,bytes:%d", contentInfo.ContentType, fileInfo.Size()))
+			}
+			return result
+		}
+	}
+
+	content, err := sandbox.ReadFileTailInContainerPooled(ctx, pool, safePath, cfg.RepositoryRoot, req.Lines)
+	if err != nil {
+		return fail(errors.Wrap(errors.ReadContainer, err))
+	}
+	detail := fmt.Sprintf("lines:%d", req.Lines)
+
+	if req.Follow > 0 {
+		duration := req.Follow
+		if cfg.TailMaxFollow > 0 && duration > cfg.TailMaxFollow {
+			duration = cfg.TailMaxFollow
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		appended, note, err := followFile(ctx, safePath, fileInfo.Size(), duration, cfg, pool, follow)
+		if err != nil {
+			return fail(errors.Wrap(errors.ReadContainer, err))
+		}
+		content += appended + note
+		detail += fmt.Sprintf(",follow:%s,appended:%d", duration, len(appended))
+	}
+
+	result.Success = true
+	result.Result = content
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("tail", arg, true, detail)
+	}
+	return result
+}
+
+// followFile watches a file growing from offset for duration, or until ctx
+// ends, writing each complete line appended to w as it arrives. It returns
+// the lines appended and a closing note saying how the watch ended. A file
+// that shrinks was truncated or replaced and is read again from its start.
+// Following stops early once MaxFileSize bytes have been appended.
+func followFile(ctx context.Context, safePath string, offset int64, duration time.Duration, cfg *config.Config, pool *sandbox.ContainerPool, w io.Writer) (string, string, error) {
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+
+	var appended strings.Builder
+	partial := ""
+	emit := func(text string) {
+		io.WriteString(w, text)
+		appended.WriteString(text)
+	}
+	finish := func(note string) (string, string, error) {
+		if partial != "" {
+			emit(partial + "\n")
+		}
+		return appended.String(), note, nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return finish("[stopped following: interrupted]\n")
+		case <-deadline.C:
+			return finish(fmt.Sprintf("[followed for %s]\n", duration))
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(safePath)
+		if err != nil {
+			return finish("[stopped following: file removed]\n")
+		}
+		size := info.Size()
+		if size < offset {
+			emit("[file truncated]\n")
+			offset, partial = 0, ""
+		}
+		if size == offset {
+			continue
+		}
+
+		chunk, err := sandbox.ReadFileRangeInContainerPooled(ctx, pool, safePath, cfg.RepositoryRoot, offset, size-offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return finish("[stopped following: interrupted]\n")
+			}
+			return "", "", err
+		}
+		offset += int64(len(chunk))
+
+		// Only complete lines are written; the rest waits for its newline
+		text := partial + chunk
+		i := strings.LastIndexByte(text, '\n')
+		partial = text[i+1:]
+		if i >= 0 {
+			emit(text[:i+1])
+		}
+		if cfg.MaxFileSize > 0 && int64(appended.Len()) > cfg.MaxFileSize {
+			return finish(fmt.Sprintf("[stopped following: over %d bytes appended]\n", cfg.MaxFileSize))
+		}
+	}
+}
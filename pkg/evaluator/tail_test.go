@@ -0,0 +1,60 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTailArgument(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want tailRequest
+	}{
+		{"app.log", tailRequest{Path: "app.log", Lines: DefaultTailLines}},
+		{"app.log lines=100", tailRequest{Path: "app.log", Lines: 100}},
+		{"logs/my app.log follow=30s lines=5", tailRequest{Path: "logs/my app.log", Lines: 5, Follow: 30 * time.Second}},
+	}
+	for _, tt := range tests {
+		got, err := parseTailArgument(tt.arg)
+		if err != nil || got != tt.want {
+			t.Errorf("parseTailArgument(%q) = %+v, %v, want %+v", tt.arg, got, err, tt.want)
+		}
+	}
+
+	for _, arg := range []string{"app.log lines=0", "app.log lines=many", "app.log follow=forever", "app.log lines=1 lines=2", "  "} {
+		if _, err := parseTailArgument(arg); err == nil || !strings.Contains(err.Error(), "PARSE_ERROR") {
+			t.Errorf("parseTailArgument(%q) error = %v, want PARSE_ERROR", arg, err)
+		}
+	}
+}
+
+func TestExecuteTail_FollowDisabled(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	result := ExecuteTail("app.log follow=10s", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "FOLLOW_DISABLED") {
+		t.Errorf("result = %+v, want FOLLOW_DISABLED", result)
+	}
+}
+
+func TestExecuteTail_FileNotFound(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	audit := &testAuditLog{}
+	result := executeTail(context.Background(), "missing.log lines=5", cfg, audit.log, nil, &bytes.Buffer{})
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "FILE_NOT_FOUND") {
+		t.Errorf("result = %+v, want FILE_NOT_FOUND", result)
+	}
+	if entries := audit.getEntries(); len(entries) != 1 || entries[0].cmdType != "tail" || entries[0].success {
+		t.Errorf("audit entries = %+v", entries)
+	}
+}
+
+func TestExecuteTail_PathTraversal(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	result := ExecuteTail("../../etc/passwd lines=5", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "PATH_SECURITY") {
+		t.Errorf("result = %+v, want PATH_SECURITY", result)
+	}
+}
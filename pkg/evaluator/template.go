@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// templateVariable matches ${NAME} in a command argument
+var templateVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// setArgument matches the argument of <set name=NAME value=VALUE>
+var setArgument = regexp.MustCompile(`^name=([A-Za-z_][A-Za-z0-9_]*)\s+value=(.*)$`)
+
+// builtinVariables returns the variables every session starts with
+func builtinVariables(repoRoot, sessionID, date string) map[string]string {
+	return map[string]string{
+		"REPO_ROOT":  repoRoot,
+		"SESSION_ID": sessionID,
+		"DATE":       date,
+	}
+}
+
+// SetSessionID sets the ${SESSION_ID} built-in variable
+func (e *Executor) SetSessionID(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.builtins["SESSION_ID"] = id
+}
+
+// lookupVariable returns the value of a built-in or session variable
+func (e *Executor) lookupVariable(name string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if value, ok := e.builtins[name]; ok {
+		return value, true
+	}
+	value, ok := e.variables[name]
+	return value, ok
+}
+
+// expandTemplate replaces ${NAME} in s with the value of the variable.
+// Names that are not defined are left as written, so shell variables such
+// as ${HOME} in exec commands pass through untouched.
+func (e *Executor) expandTemplate(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return templateVariable.ReplaceAllStringFunc(s, func(match string) string {
+		name := templateVariable.FindStringSubmatch(match)[1]
+		if value, ok := e.lookupVariable(name); ok {
+			return value
+		}
+		return match
+	})
+}
+
+// executeSet handles <set name=NAME value=VALUE>, defining a variable for
+// the rest of the session. The value is expanded itself, so variables can
+// build on each other. Built-in variables cannot be redefined.
+func (e *Executor) executeSet(cmd scanner.Command) scanner.ExecutionResult {
+	result := scanner.ExecutionResult{
+		Command: cmd,
+		Success: false,
+	}
+
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		if e.auditLog != nil {
+			e.auditLog("set", cmd.Argument, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	match := setArgument.FindStringSubmatch(strings.TrimSpace(cmd.Argument))
+	if match == nil {
+		return fail(errors.New(errors.VariableError, "expected <set name=NAME value=VALUE>"))
+	}
+	name := match[1]
+	value := e.expandTemplate(strings.Trim(strings.TrimSpace(match[2]), `"`))
+
+	e.mu.Lock()
+	if _, ok := e.builtins[name]; ok {
+		e.mu.Unlock()
+		return fail(errors.Newf(errors.VariableError, "%s is a built-in variable and cannot be redefined", name))
+	}
+	e.variables[name] = value
+	e.mu.Unlock()
+
+	result.Success = true
+	result.Action = "SET"
+	result.Result = fmt.Sprintf("%s=%s", name, value)
+	if e.auditLog != nil {
+		e.auditLog("set", name, true, "value:"+value)
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	executor := NewExecutor(cfg, nil, nil, nil)
+	executor.SetSessionID("1234")
+	executor.variables["pkg"] = "internal/parser"
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no variables", "main.go", "main.go"},
+		{"session variable", "${pkg}/llm-parser.go", "internal/parser/llm-parser.go"},
+		{"built-in", "logs/${SESSION_ID}.txt", "logs/1234.txt"},
+		{"repo root", "${REPO_ROOT}/go.mod", cfg.RepositoryRoot + "/go.mod"},
+		{"undefined left as is", "echo ${HOME}", "echo ${HOME}"},
+		{"dollar without braces", "echo $pkg", "echo $pkg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := executor.expandTemplate(tt.in); got != tt.want {
+				t.Errorf("expandTemplate(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteSet(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	audit := &testAuditLog{}
+	executor := NewExecutor(cfg, nil, audit.log, nil)
+
+	result := executor.Execute(scanner.Command{Type: "set", Argument: "name=pkg value=internal/parser"})
+	if !result.Success || result.Action != "SET" {
+		t.Fatalf("set failed: %+v", result)
+	}
+
+	result = executor.Execute(scanner.Command{Type: "set", Argument: `name=file value="${pkg}/llm-parser.go"`})
+	if !result.Success || result.Result != "file=internal/parser/llm-parser.go" {
+		t.Fatalf("Result = %q, want value expanded from pkg", result.Result)
+	}
+
+	if got := executor.GetCommandsRun(); got != 0 {
+		t.Errorf("GetCommandsRun() = %d, want 0", got)
+	}
+
+	entries := audit.getEntries()
+	if len(entries) != 2 || entries[0].cmdType != "set" || entries[0].arg != "pkg" {
+		t.Errorf("audit entries = %+v, want two set entries", entries)
+	}
+}
+
+func TestExecuteSet_Errors(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	tests := []struct {
+		name     string
+		argument string
+	}{
+		{"missing value", "name=pkg"},
+		{"invalid name", "name=my-pkg value=x"},
+		{"built-in", "name=REPO_ROOT value=/tmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := executor.Execute(scanner.Command{Type: "set", Argument: tt.argument})
+			if result.Success || !strings.HasPrefix(result.Error.Error(), "VARIABLE_ERROR") {
+				t.Errorf("expected VARIABLE_ERROR, got success=%v err=%v", result.Success, result.Error)
+			}
+		})
+	}
+}
+
+func TestExecute_ExpandsBeforeValidation(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	executor.Execute(scanner.Command{Type: "set", Argument: "name=secrets value=.env"})
+
+	// The expanded path is excluded, so validation must see it
+	result := executor.Execute(scanner.Command{Type: "write", Argument: "${secrets}", Content: "x"})
+	if result.Success || !strings.HasPrefix(result.Error.Error(), "PATH_SECURITY") {
+		t.Fatalf("expected PATH_SECURITY for expanded path, got success=%v err=%v", result.Success, result.Error)
+	}
+	if result.Command.Argument != ".env" {
+		t.Errorf("result.Command.Argument = %q, want expanded path", result.Command.Argument)
+	}
+}
+
+func TestExecuteSet_KeepsGuardOutcome(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	executor := NewExecutor(cfg, nil, nil, nil)
+
+	executor.Execute(scanner.Command{Type: "write", Argument: ".env", Content: "x"})
+	executor.Execute(scanner.Command{Type: "set", Argument: "name=pkg value=internal"})
+
+	result := executor.Execute(scanner.Command{
+		Type:  "if-failure",
+		Steps: []scanner.Command{{Type: "set", Argument: "name=failed value=yes"}},
+	})
+	if result.Action != "CONDITION_MET" {
+		t.Errorf("Action = %q, want CONDITION_MET after the failed write", result.Action)
+	}
+}
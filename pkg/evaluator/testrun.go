@@ -0,0 +1,412 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// testFailureLines is the most lines of output kept for each failure
+const testFailureLines = 10
+
+// testFailure is one failed test and the output that explains it
+type testFailure struct {
+	Name    string
+	Message []string
+}
+
+// testReport is what a test runner's output says about a run
+type testReport struct {
+	Passed, Failed, Skipped int
+	Failures                []testFailure
+}
+
+// testParsers read the output of each language's test runner, reporting
+// false if it holds no results, as when the tests did not compile
+var testParsers = map[string]func(output string) (testReport, bool){
+	"go":         parseGoTest,
+	"python":     parsePytest,
+	"javascript": parseJest,
+}
+
+// targetExtensions maps source file extensions to the language <test> and
+// <lint> pick a runner for
+var targetExtensions = map[string]string{
+	".go": "go", ".py": "python",
+	".js": "javascript", ".jsx": "javascript", ".mjs": "javascript",
+	".ts": "javascript", ".tsx": "javascript",
+}
+
+// ExecuteTest handles the "test" command
+func ExecuteTest(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeTest(context.Background(), arg, cfg, auditLog, pool)
+}
+
+// executeTest is ExecuteTest as part of the trace in ctx. The target's
+// language picks the runner configured for it, which runs as an exec
+// command with the target and any further arguments; the result is the
+// pass, fail, and skip counts and the output of the first failures, or the
+// whole output if the runner reported no results.
+func executeTest(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "test", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("test", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	fields := strings.Fields(arg)
+	target, args := "", fields
+	if len(fields) > 0 && !strings.HasPrefix(fields[0], "-") {
+		target, args = fields[0], fields[1:]
+	}
+	lang, err := targetLanguage(target, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	runner := strings.TrimSpace(cfg.TestRunners[lang])
+	if runner == "" {
+		return fail(errors.Newf(errors.ExecValidation, "no test runner configured for %s", lang))
+	}
+	if lang == "go" {
+		target = goPackageTarget(target)
+	}
+	command := strings.Join(append([]string{runner, target}, args...), " ")
+	command = strings.Join(strings.Fields(command), " ")
+
+	// The runner runs as an exec command, with its checks, so its own audit
+	// entry is folded into the one for <test>
+	var execAudit string
+	execResult := executeExec(ctx, scanner.Command{Type: "exec", Argument: command}, cfg, func(_, _ string, _ bool, msg string) {
+		execAudit = msg
+	}, pool)
+	result.Stdout = execResult.Stdout
+	result.Stderr = execResult.Stderr
+	result.ExitCode = execResult.ExitCode
+	result.Success = execResult.Success
+	result.Error = execResult.Error
+	result.ExecutionTime = time.Since(startTime)
+
+	output := execResult.Stdout + execResult.Stderr
+	report, parsed := testParsers[lang](output)
+	if parsed {
+		result.Result = formatTestReport(command, report, execResult.ExitCode, cfg.TestMaxFailures)
+		if report.Failed > 0 && result.Error != nil && errors.CodeOf(result.Error) == errors.ExecFailed {
+			result.Error = errors.Newf(errors.ExecFailed, "%d tests failed", report.Failed)
+		}
+	} else {
+		result.Result = execResult.Result
+	}
+
+	if auditLog != nil {
+		msg := execAudit
+		if parsed {
+			msg += fmt.Sprintf(",passed:%d,failed:%d,skipped:%d", report.Passed, report.Failed, report.Skipped)
+		}
+		auditLog("test", command, result.Success, strings.TrimPrefix(msg, ","))
+	}
+	return result
+}
+
+// targetLanguage picks the language of a <test> or <lint> target: a file by
+// its extension, a directory by the source files in it, and otherwise by the
+// nearest manifest at or above it in the repository
+func targetLanguage(target string, cfg *config.Config) (string, error) {
+	root := filepath.Clean(cfg.RepositoryRoot)
+	dir := root
+	if target != "" {
+		// Strip runner syntax for selecting tests within a path
+		path := strings.TrimSuffix(strings.TrimSuffix(target, "..."), "/")
+		if i := strings.Index(path, "::"); i >= 0 {
+			path = path[:i]
+		}
+		if path == "" {
+			path = "."
+		}
+		safePath, err := sandbox.ValidateHostPath(path, cfg.RepositoryRoot, cfg.ExcludedPaths)
+		if err != nil {
+			return "", errors.Wrap(errors.PathSecurity, err)
+		}
+		info, err := os.Stat(safePath)
+		if err != nil {
+			return "", errors.New(errors.FileNotFound, path)
+		}
+		if !info.IsDir() {
+			if lang, ok := targetExtensions[strings.ToLower(filepath.Ext(safePath))]; ok {
+				return lang, nil
+			}
+			return "", errors.Newf(errors.ExecValidation, "no runner for %s files", filepath.Ext(safePath))
+		}
+		if lang := targetDirLanguage(safePath); lang != "" {
+			return lang, nil
+		}
+		dir = safePath
+	}
+	for {
+		for _, m := range []struct{ manifest, lang string }{
+			{"go.mod", "go"},
+			{"package.json", "javascript"},
+			{"pyproject.toml", "python"},
+			{"pytest.ini", "python"},
+			{"setup.py", "python"},
+		} {
+			if _, err := os.Stat(filepath.Join(dir, m.manifest)); err == nil {
+				return m.lang, nil
+			}
+		}
+		if dir == root || !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	return "", errors.New(errors.ExecValidation, "cannot tell the language to run; name a source file or a directory of them")
+}
+
+// targetDirLanguage returns the language of the source files directly in dir,
+// preferring the one with the most
+func targetDirLanguage(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	counts := make(map[string]int)
+	for _, e := range entries {
+		name := e.Name()
+		if lang, ok := targetExtensions[strings.ToLower(filepath.Ext(name))]; ok && !e.IsDir() {
+			counts[lang]++
+		}
+	}
+	best := ""
+	for lang, n := range counts {
+		if best == "" || n > counts[best] || n == counts[best] && lang < best {
+			best = lang
+		}
+	}
+	return best
+}
+
+// goPackageTarget turns a directory or source file into a package pattern
+// the go tools accept
+func goPackageTarget(target string) string {
+	if target == "" {
+		return "./..."
+	}
+	if strings.HasSuffix(target, ".go") {
+		target = filepath.Dir(target)
+	}
+	target = filepath.ToSlash(target)
+	if target == "." || strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") {
+		return target
+	}
+	return "./" + target
+}
+
+// formatTestReport renders a parsed run: the counts, then the first
+// maxFailures failures with their output
+func formatTestReport(command string, r testReport, exitCode, maxFailures int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d passed, %d failed, %d skipped (exit code %d)\n", command, r.Passed, r.Failed, r.Skipped, exitCode)
+	for i, f := range r.Failures {
+		if maxFailures > 0 && i == maxFailures {
+			fmt.Fprintf(&b, "\n(%d more failures not shown)\n", len(r.Failures)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\nFAIL %s\n", f.Name)
+		for _, line := range f.Message {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// trimFailureMessage drops blank lines at the ends of lines and keeps at
+// most testFailureLines of them
+func trimFailureMessage(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > testFailureLines {
+		lines = append(lines[:testFailureLines:testFailureLines], fmt.Sprintf("... %d more lines", len(lines)-testFailureLines))
+	}
+	return lines
+}
+
+// goTestResult matches the result line of a test in go test -v output
+var goTestResult = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+)`)
+
+// goTestEvent matches the lines of go test -v output that say which test
+// the lines after them belong to
+var goTestEvent = regexp.MustCompile(`^=== (RUN|CONT|PAUSE|NAME)\s+(\S+)`)
+
+// parseGoTest reads go test -v output. A test whose subtests report their
+// own results is counted through them alone.
+func parseGoTest(output string) (testReport, bool) {
+	type outcome struct {
+		name   string
+		status string
+	}
+	var outcomes []outcome
+	logs := make(map[string][]string)
+	current := ""
+	for _, line := range strings.Split(output, "\n") {
+		if m := goTestEvent.FindStringSubmatch(line); m != nil {
+			current = m[2]
+			continue
+		}
+		if m := goTestResult.FindStringSubmatch(line); m != nil {
+			outcomes = append(outcomes, outcome{name: m[2], status: m[1]})
+			continue
+		}
+		if current != "" && strings.HasPrefix(line, "    ") {
+			logs[current] = append(logs[current], strings.TrimSpace(line))
+		}
+	}
+	if len(outcomes) == 0 {
+		return testReport{}, false
+	}
+
+	parents := make(map[string]bool)
+	for _, o := range outcomes {
+		if i := strings.LastIndex(o.name, "/"); i >= 0 {
+			parents[o.name[:i]] = true
+		}
+	}
+	var r testReport
+	for _, o := range outcomes {
+		if parents[o.name] {
+			continue
+		}
+		switch o.status {
+		case "PASS":
+			r.Passed++
+		case "SKIP":
+			r.Skipped++
+		case "FAIL":
+			r.Failed++
+			r.Failures = append(r.Failures, testFailure{Name: o.name, Message: trimFailureMessage(logs[o.name])})
+		}
+	}
+	return r, true
+}
+
+// pytestSummary matches the final line of a pytest run, such as
+// "==== 1 failed, 3 passed in 0.12s ===="
+var pytestSummary = regexp.MustCompile(`(?m)^=+ (.*\d+ \w+.*) in [\d.]+s.* =+$`)
+
+// pytestCount matches one count in a pytest summary
+var pytestCount = regexp.MustCompile(`(\d+) (passed|failed|skipped|error|errors|xfailed|xpassed)`)
+
+// parsePytest reads pytest output, taking failure messages from the short
+// test summary that -rfE adds
+func parsePytest(output string) (testReport, bool) {
+	m := pytestSummary.FindAllStringSubmatch(output, -1)
+	if m == nil {
+		return testReport{}, false
+	}
+	var r testReport
+	for _, c := range pytestCount.FindAllStringSubmatch(m[len(m)-1][1], -1) {
+		n, _ := strconv.Atoi(c[1])
+		switch c[2] {
+		case "passed", "xfailed":
+			r.Passed += n
+		case "failed", "error", "errors", "xpassed":
+			r.Failed += n
+		case "skipped":
+			r.Skipped += n
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		for _, prefix := range []string{"FAILED ", "ERROR "} {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			name, message, _ := strings.Cut(strings.TrimPrefix(line, prefix), " - ")
+			f := testFailure{Name: name}
+			if message != "" {
+				f.Message = []string{message}
+			}
+			r.Failures = append(r.Failures, f)
+		}
+	}
+	return r, true
+}
+
+// jestSummary matches the tests line of a Jest summary, such as
+// "Tests:       1 failed, 4 passed, 5 total"
+var jestSummary = regexp.MustCompile(`(?m)^Tests:\s+(.*\d+ total)`)
+
+// jestCount matches one count in a Jest summary
+var jestCount = regexp.MustCompile(`(\d+) (passed|failed|skipped|todo)`)
+
+// parseJest reads Jest output, where each failure starts with a line
+// holding "●" and the test's name, followed by the assertion that failed
+func parseJest(output string) (testReport, bool) {
+	m := jestSummary.FindStringSubmatch(output)
+	if m == nil {
+		return testReport{}, false
+	}
+	var r testReport
+	for _, c := range jestCount.FindAllStringSubmatch(m[1], -1) {
+		n, _ := strconv.Atoi(c[1])
+		switch c[2] {
+		case "passed":
+			r.Passed += n
+		case "failed":
+			r.Failed += n
+		default:
+			r.Skipped += n
+		}
+	}
+
+	// Jest repeats failures in a summary after a run of several files
+	seen := make(map[string]bool)
+	var current *testFailure
+	add := func() {
+		if current != nil && !seen[current.Name] {
+			seen[current.Name] = true
+			current.Message = trimFailureMessage(current.Message)
+			r.Failures = append(r.Failures, *current)
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if name, ok := strings.CutPrefix(trimmed, "● "); ok {
+			add()
+			current = &testFailure{Name: name}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		// A failure's output runs until the next suite or the summary
+		if strings.HasPrefix(trimmed, "PASS ") || strings.HasPrefix(trimmed, "FAIL ") ||
+			strings.HasPrefix(trimmed, "Summary of all failing tests") || strings.HasPrefix(trimmed, "Test Suites:") {
+			add()
+			current = nil
+			continue
+		}
+		current.Message = append(current.Message, trimmed)
+	}
+	add()
+	return r, true
+}
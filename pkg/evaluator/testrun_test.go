@@ -0,0 +1,186 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGoTest(t *testing.T) {
+	output := `=== RUN   TestParseWrite
+=== RUN   TestParseWrite/empty
+    parser_test.go:12: got "", want "x"
+=== RUN   TestParseWrite/simple
+--- FAIL: TestParseWrite (0.00s)
+    --- FAIL: TestParseWrite/empty (0.00s)
+    --- PASS: TestParseWrite/simple (0.00s)
+=== RUN   TestSkipped
+    parser_test.go:30: needs docker
+--- SKIP: TestSkipped (0.00s)
+=== RUN   TestOK
+--- PASS: TestOK (0.00s)
+FAIL
+FAIL	example.com/app/internal/parser	0.003s
+`
+	r, ok := parseGoTest(output)
+	if !ok {
+		t.Fatal("no results parsed")
+	}
+	want := testReport{
+		Passed: 2, Failed: 1, Skipped: 1,
+		Failures: []testFailure{{Name: "TestParseWrite/empty", Message: []string{`parser_test.go:12: got "", want "x"`}}},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("report = %+v, want %+v", r, want)
+	}
+
+	if _, ok := parseGoTest("# example.com/app\n./main.go:3:1: syntax error\nFAIL\n"); ok {
+		t.Error("build failure parsed as results")
+	}
+}
+
+func TestParsePytest(t *testing.T) {
+	output := `============================= test session starts ==============================
+collected 5 items
+
+tests/test_app.py .F.s.                                                  [100%]
+
+=========================== short test summary info ============================
+FAILED tests/test_app.py::test_parse - AssertionError: assert 1 == 2
+=================== 1 failed, 3 passed, 1 skipped in 0.05s ====================
+`
+	r, ok := parsePytest(output)
+	if !ok {
+		t.Fatal("no results parsed")
+	}
+	want := testReport{
+		Passed: 3, Failed: 1, Skipped: 1,
+		Failures: []testFailure{{Name: "tests/test_app.py::test_parse", Message: []string{"AssertionError: assert 1 == 2"}}},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("report = %+v, want %+v", r, want)
+	}
+}
+
+func TestParseJest(t *testing.T) {
+	output := ` FAIL  src/sum.test.js
+  ● sum › adds negatives
+
+    expect(received).toBe(expected)
+
+    Expected: -3
+    Received: 3
+
+ PASS  src/app.test.js
+
+Summary of all failing tests
+ FAIL  src/sum.test.js
+  ● sum › adds negatives
+
+    expect(received).toBe(expected)
+
+Test Suites: 1 failed, 1 passed, 2 total
+Tests:       1 failed, 1 skipped, 4 passed, 6 total
+`
+	r, ok := parseJest(output)
+	if !ok {
+		t.Fatal("no results parsed")
+	}
+	want := testReport{
+		Passed: 4, Failed: 1, Skipped: 1,
+		Failures: []testFailure{{Name: "sum › adds negatives", Message: []string{
+			"expect(received).toBe(expected)", "", "Expected: -3", "Received: 3",
+		}}},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("report = %+v, want %+v", r, want)
+	}
+}
+
+func TestTargetLanguage(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":                    "module example.com/app\n",
+		"internal/parser/parser.go": "package parser\n",
+		"tests/test_app.py":         "def test_app(): pass\n",
+		"web/sum.test.js":           "test('sum', () => {})\n",
+		"docs/guide.md":             "# Guide\n",
+	} {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := newTestConfig(tmpDir)
+
+	for target, want := range map[string]string{
+		"":                            "go",
+		"./...":                       "go",
+		"./internal/parser":           "go",
+		"./internal/...":              "go",
+		"tests":                       "python",
+		"tests/test_app.py::test_app": "python",
+		"web/sum.test.js":             "javascript",
+		"docs":                        "go",
+	} {
+		lang, err := targetLanguage(target, cfg)
+		if err != nil || lang != want {
+			t.Errorf("targetLanguage(%q) = %q, %v; want %q", target, lang, err, want)
+		}
+	}
+	for _, target := range []string{"docs/guide.md", "missing", "../outside"} {
+		if lang, err := targetLanguage(target, cfg); err == nil {
+			t.Errorf("targetLanguage(%q) = %q, want error", target, lang)
+		}
+	}
+}
+
+func TestGoPackageTarget(t *testing.T) {
+	for target, want := range map[string]string{
+		"":                              "./...",
+		"./...":                         "./...",
+		"internal/parser":               "./internal/parser",
+		"internal/parser/parse_test.go": "./internal/parser",
+		"./pkg/...":                     "./pkg/...",
+	} {
+		if got := goPackageTarget(target); got != want {
+			t.Errorf("goPackageTarget(%q) = %q, want %q", target, got, want)
+		}
+	}
+}
+
+func TestFormatTestReport(t *testing.T) {
+	r := testReport{Passed: 4, Failed: 3, Failures: []testFailure{
+		{Name: "TestA", Message: []string{"a_test.go:5: bad"}},
+		{Name: "TestB"},
+		{Name: "TestC"},
+	}}
+	got := formatTestReport("go test -v ./...", r, 1, 2)
+	want := "go test -v ./...: 4 passed, 3 failed, 0 skipped (exit code 1)\n" +
+		"\nFAIL TestA\n    a_test.go:5: bad\n" +
+		"\nFAIL TestB\n" +
+		"\n(1 more failures not shown)\n"
+	if got != want {
+		t.Errorf("report =\n%s\nwant\n%s", got, want)
+	}
+
+	long := make([]string, testFailureLines+5)
+	for i := range long {
+		long[i] = "line"
+	}
+	trimmed := trimFailureMessage(append([]string{""}, long...))
+	if len(trimmed) != testFailureLines+1 || !strings.HasPrefix(trimmed[testFailureLines], "... 5 more") {
+		t.Errorf("trimFailureMessage = %q", trimmed)
+	}
+}
+
+func TestExecuteTest_NoRunner(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	result := ExecuteTest("", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "EXEC_VALIDATION") {
+		t.Errorf("result = %+v, want EXEC_VALIDATION", result)
+	}
+}
@@ -0,0 +1,262 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// tokensMaxFiles bounds the files a <tokens> result for a directory lists
+const tokensMaxFiles = 20
+
+// bpePiece splits text the way the pre-tokenizers of BPE tokenizers do:
+// contractions, words and numbers with their leading space, runs of
+// punctuation, and whitespace
+var bpePiece = regexp.MustCompile(`'(?:s|t|re|ve|m|ll|d)| ?\pL+| ?\pN+| ?[^\s\pL\pN]+|\s+`)
+
+// estimateTokens estimates the tokens text takes up with estimator, one of
+// the config.TokenEstimator modes. The bpe estimate splits text into the
+// pieces a BPE tokenizer starts from and charges each by its kind; it is
+// slower than the bytes estimate but closer on code and non-English text.
+func estimateTokens(estimator, text string) int {
+	if estimator != config.TokenEstimatorBPE {
+		return EstimateTokens(text)
+	}
+	tokens := 0
+	for _, piece := range bpePiece.FindAllString(text, -1) {
+		tokens += bpeTokens(piece)
+	}
+	return tokens
+}
+
+// bpeTokens estimates the tokens of one pre-tokenizer piece. Common words
+// are a single token and longer ones split every six letters or so; digits
+// are grouped in threes, punctuation in pairs, and letters outside ASCII
+// mostly take a token each.
+func bpeTokens(piece string) int {
+	word := strings.TrimPrefix(piece, " ")
+	r, _ := utf8.DecodeRuneInString(word)
+	n := utf8.RuneCountInString(word)
+	switch {
+	case strings.TrimSpace(piece) == "":
+		return 1
+	case r >= '0' && r <= '9':
+		return (n + 2) / 3
+	case len(word) > n:
+		// Not ASCII
+		return n
+	case isLetter(r):
+		return (n + 5) / 6
+	default:
+		return (n + 1) / 2
+	}
+}
+
+// isLetter reports whether r is an ASCII letter
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// estimate estimates the tokens of text with the configured estimator
+func (e *Executor) estimate(text string) int {
+	return estimateTokens(e.config.TokenEstimator, text)
+}
+
+// annotateTokens records the estimated tokens of a successful open on its
+// result, for the file header, when annotations are on
+func (e *Executor) annotateTokens(result *scanner.ExecutionResult) {
+	if !e.config.TokenAnnotations || !result.Success || result.Action == "BINARY_SUMMARY" {
+		return
+	}
+	result.Tokens = e.estimate(result.Result)
+}
+
+// fileTokens estimates the tokens of the file at absPath, which is size
+// bytes long. The bytes estimate needs only the size; the bpe estimate
+// reads the file, falling back to the size if it cannot.
+func fileTokens(cfg *config.Config, absPath string, size int64) int {
+	if cfg.TokenEstimator == config.TokenEstimatorBPE {
+		if data, err := os.ReadFile(absPath); err == nil {
+			return estimateTokens(cfg.TokenEstimator, string(data))
+		}
+	}
+	return int((size + bytesPerToken - 1) / bytesPerToken)
+}
+
+// tokenCount is the estimate of one file for a <tokens> result
+type tokenCount struct {
+	rel    string
+	tokens int
+}
+
+// ExecuteTokens handles the "tokens" command
+func ExecuteTokens(arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	return executeTokens(context.Background(), arg, cfg, auditLog)
+}
+
+// executeTokens is ExecuteTokens as part of the trace in ctx. It estimates
+// the tokens of a file, a line range of one, or every text file under a
+// directory, listing the largest, so the LLM can tell what fits its context
+// before opening it.
+func executeTokens(ctx context.Context, arg string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string)) scanner.ExecutionResult {
+	startTime := time.Now()
+	result := scanner.ExecutionResult{
+		Command: scanner.Command{Type: "tokens", Argument: arg},
+	}
+	fail := func(fullError error) scanner.ExecutionResult {
+		result.Success = false
+		result.Error = SanitizeError(fullError) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("tokens", arg, false, fullError.Error()) // Full error to audit
+		}
+		return result
+	}
+
+	target := strings.TrimSpace(arg)
+	if target == "" {
+		target = "."
+	}
+	path, first, last, ranged := splitLineRange(target)
+	if ranged && (first < 1 || last < first) {
+		return fail(errors.Newf(errors.InvalidRange, "invalid line range %d-%d", first, last))
+	}
+	safePath, err := sandbox.ValidateHostPath(path, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	if err != nil {
+		return fail(errors.Wrap(errors.PathSecurity, err))
+	}
+	if cfg.RespectIgnoreFiles && path != "." {
+		if err := sandbox.CheckIgnored(safePath, cfg.RepositoryRoot); err != nil {
+			return fail(errors.Wrap(errors.PathSecurity, err))
+		}
+	}
+	info, err := os.Stat(safePath)
+	if err != nil {
+		return fail(errors.Newf(errors.FileNotFound, "path not found: %s", path))
+	}
+
+	estimator := cfg.TokenEstimator
+	if estimator == "" {
+		estimator = config.TokenEstimatorBytes
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(safePath)
+		if err != nil {
+			return fail(errors.Wrap(errors.PermissionDenied, err))
+		}
+		text := string(data)
+		tokens := 0
+		if isBinaryContent(data, false) {
+			result.Result = fmt.Sprintf("%s: binary file (%s), not estimated\n", path, formatFileSizeForSearch(info.Size()))
+		} else {
+			if ranged {
+				if text, err = selectLines(text, first, last); err != nil {
+					return fail(err)
+				}
+			}
+			lines := strings.Count(text, "\n")
+			if text != "" && !strings.HasSuffix(text, "\n") {
+				lines++
+			}
+			tokens = estimateTokens(estimator, text)
+			result.Result = fmt.Sprintf("%s: ≈%d tokens (%s, %s) by the %s estimate\n",
+				target, tokens, pluralize(lines, "line"), formatFileSizeForSearch(int64(len(text))), estimator)
+		}
+		result.Success = true
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("tokens", arg, true, fmt.Sprintf("files:1,tokens:%d", tokens))
+		}
+		return result
+	}
+	if ranged {
+		return fail(errors.Newf(errors.InvalidRange, "%s is a directory", path))
+	}
+
+	var ignored *ignore.Matcher
+	if cfg.RespectIgnoreFiles {
+		ignored = ignore.NewMatcher(cfg.RepositoryRoot)
+	}
+	var counts []tokenCount
+	total, binary := 0, 0
+	err = filepath.WalkDir(safePath, func(absPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return skipEntry(d)
+		}
+		if ctx.Err() != nil {
+			return errors.New(errors.ExecInterrupted, "tokens stopped by interrupt")
+		}
+		rel, _ := filepath.Rel(cfg.RepositoryRoot, absPath)
+		if absPath != safePath {
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if _, err := sandbox.ValidatePath(rel, cfg.RepositoryRoot, cfg.ExcludedPaths); err != nil {
+				return skipEntry(d)
+			}
+			if ignored != nil && ignored.Match(rel, d.IsDir()) {
+				return skipEntry(d)
+			}
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil
+		}
+		if isBinaryContent(data, false) {
+			binary++
+			return nil
+		}
+		tokens := estimateTokens(estimator, string(data))
+		total += tokens
+		counts = append(counts, tokenCount{rel: filepath.ToSlash(rel), tokens: tokens})
+		return nil
+	})
+	if err != nil {
+		if errors.CodeOf(err) == "" {
+			err = errors.Wrap(errors.PermissionDenied, err)
+		}
+		return fail(err)
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].tokens != counts[j].tokens {
+			return counts[i].tokens > counts[j].tokens
+		}
+		return counts[i].rel < counts[j].rel
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: ≈%d tokens in %s by the %s estimate\n", target, total, pluralize(len(counts), "file"), estimator)
+	for _, c := range counts[:min(len(counts), tokensMaxFiles)] {
+		fmt.Fprintf(&b, "  %8d  %s\n", c.tokens, c.rel)
+	}
+	if len(counts) > tokensMaxFiles {
+		fmt.Fprintf(&b, "... and %d smaller files\n", len(counts)-tokensMaxFiles)
+	}
+	if binary > 0 {
+		fmt.Fprintf(&b, "(%s skipped as binary)\n", pluralize(binary, "file"))
+	}
+
+	result.Success = true
+	result.Result = b.String()
+	result.ExecutionTime = time.Since(startTime)
+	if auditLog != nil {
+		auditLog("tokens", arg, true, fmt.Sprintf("files:%d,tokens:%d", len(counts), total))
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/search"
+)
+
+func TestEstimateTokens_BPE(t *testing.T) {
+	tests := map[string]int{
+		"":                     0,
+		"hello world":          2,
+		"func main() {\n":      5,
+		"12345":                2,
+		"internationalization": 4,
+		"日本語":                  3,
+	}
+	for text, want := range tests {
+		if got := estimateTokens(config.TokenEstimatorBPE, text); got != want {
+			t.Errorf("estimateTokens(bpe, %q) = %d, want %d", text, got, want)
+		}
+	}
+	if got := estimateTokens(config.TokenEstimatorBytes, "hello world"); got != 3 {
+		t.Errorf("estimateTokens(bytes) = %d, want 3", got)
+	}
+}
+
+func TestExecuteTokens(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.go":         strings.Repeat("x", 39) + "\n" + strings.Repeat("y", 39) + "\n",
+		"pkg/a/a.go":      strings.Repeat("z", 400),
+		"pkg/a/logo.png":  "\x89PNG\x00\x01",
+		"secrets/key.txt": strings.Repeat("k", 4000),
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := newTestConfig(tmpDir)
+	cfg.ExcludedPaths = []string{"secrets/"}
+
+	tests := map[string]string{
+		"main.go":        "main.go: ≈20 tokens (2 lines, 80 B) by the bytes estimate\n",
+		"main.go:2-2":    "main.go:2-2: ≈10 tokens (1 line, 40 B) by the bytes estimate\n",
+		"pkg/a/logo.png": "pkg/a/logo.png: binary file (6 B), not estimated\n",
+		"": ".: ≈120 tokens in 2 files by the bytes estimate\n" +
+			"       100  pkg/a/a.go\n" +
+			"        20  main.go\n" +
+			"(1 file skipped as binary)\n",
+	}
+	for arg, want := range tests {
+		audit := &testAuditLog{}
+		result := ExecuteTokens(arg, cfg, audit.log)
+		if !result.Success || result.Result != want {
+			t.Errorf("tokens %q = %v:\n%s\nwant:\n%s", arg, result.Error, result.Result, want)
+		}
+		if len(audit.entries) != 1 {
+			t.Errorf("tokens %q audit = %+v", arg, audit.entries)
+		}
+	}
+
+	errorTests := map[string]string{
+		"secrets/key.txt": "PATH_SECURITY",
+		"../outside":      "PATH_SECURITY",
+		"no/such.go":      "FILE_NOT_FOUND",
+		"main.go:5-9":     "INVALID_RANGE",
+		"main.go:3-1":     "INVALID_RANGE",
+		"pkg:1-2":         "INVALID_RANGE",
+	}
+	for arg, code := range errorTests {
+		if result := ExecuteTokens(arg, cfg, nil); result.Success || !strings.Contains(result.Error.Error(), code) {
+			t.Errorf("tokens %s = %+v, want %s", arg, result, code)
+		}
+	}
+}
+
+func TestAnnotateTokens(t *testing.T) {
+	cfg := newTestConfig(t.TempDir())
+	e := NewExecutor(cfg, nil, nil, nil)
+
+	result := scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: "main.go"}, Success: true, Result: strings.Repeat("x", 40)}
+	e.annotateTokens(&result)
+	if result.Tokens != 0 {
+		t.Errorf("Tokens = %d with annotations off, want 0", result.Tokens)
+	}
+
+	cfg.TokenAnnotations = true
+	e.annotateTokens(&result)
+	if result.Tokens != 10 {
+		t.Errorf("Tokens = %d, want 10", result.Tokens)
+	}
+}
+
+func TestFormatSearchOutput_Tokens(t *testing.T) {
+	results := []search.SearchResult{{FilePath: "main.go", Score: 0.5, LineCount: 10, FileSize: 400}}
+	output := formatSearchOutput("main", results, 10, 0, func(r search.SearchResult) int {
+		return int(r.FileSize / 4)
+	})
+	if !strings.Contains(output, "   Lines: 10 | Size: 400 B | ≈100 tokens\n") {
+		t.Errorf("output missing token estimate:\n%s", output)
+	}
+}
@@ -0,0 +1,71 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+// openTruncated returns the start and end of a file too large to open,
+// cfg.TruncateKeep bytes of each cut to whole lines, under a banner giving
+// the file's size and the limit it is over. The part left out is marked
+// where it was.
+func openTruncated(ctx context.Context, safePath string, info os.FileInfo, limit int64, cfg *config.Config, pool *sandbox.ContainerPool) (string, string, error) {
+	keep := cfg.TruncateKeep
+	if keep <= 0 {
+		keep = config.DefaultTruncateKeep
+	}
+	size := info.Size()
+
+	head, err := sandbox.ReadFileRangeInContainerPooled(ctx, pool, safePath, cfg.RepositoryRoot, 0, keep)
+	if err != nil {
+		return "", "", errors.Wrap(errors.ReadContainer, err)
+	}
+	head = cutChunk(head, false)
+
+	tailStart := size - keep
+	if tailStart < int64(len(head)) {
+		tailStart = int64(len(head))
+	}
+	tail, err := sandbox.ReadFileRangeInContainerPooled(ctx, pool, safePath, cfg.RepositoryRoot, tailStart, size-tailStart)
+	if err != nil {
+		return "", "", errors.Wrap(errors.ReadContainer, err)
+	}
+	tail = trimToLineStart(tail)
+	omitted := size - int64(len(head)) - int64(len(tail))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[file truncated: %d bytes, over the %d byte limit; showing the first %d and last %d bytes]\n",
+		size, limit, len(head), len(tail))
+	b.WriteString(head)
+	if !strings.HasSuffix(head, "\n") && head != "" {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "[... %d bytes omitted ...]\n", omitted)
+	b.WriteString(tail)
+	if !strings.HasSuffix(tail, "\n") && tail != "" {
+		b.WriteString("\n")
+	}
+	return b.String(), fmt.Sprintf("truncated:head=%d,tail=%d,bytes:%d", len(head), len(tail), size), nil
+}
+
+// trimToLineStart drops the partial first line of text read from the
+// middle of a file, or, if it holds no line break, any bytes before its
+// first whole character
+func trimToLineStart(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 && i < len(text)-1 {
+		return text[i+1:]
+	}
+	for i := 0; i < utf8.UTFMax && i < len(text); i++ {
+		if utf8.RuneStart(text[i]) {
+			return text[i:]
+		}
+	}
+	return text
+}
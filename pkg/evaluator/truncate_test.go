@@ -0,0 +1,84 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+func TestTrimToLineStart(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"ial line\nnext\nlast\n", "next\nlast\n"},
+		{"no line break", "no line break"},
+		{"\x80\x80é tail", "é tail"},
+		{"only line\n", "only line\n"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := trimToLineStart(tt.text); got != tt.want {
+			t.Errorf("trimToLineStart(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteOpen_OversizeWithoutTruncation(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxFileSize = 100
+	cfg.TruncateOversize = true
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.txt"), []byte(strings.Repeat("x\n", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Line ranges still need the whole file, so they are refused as before
+	result := ExecuteOpen("app.txt:1-10", cfg, nil, nil)
+	if result.Success || result.Error == nil || !strings.HasPrefix(result.Error.Error(), "RESOURCE_LIMIT") {
+		t.Errorf("ranged open: %+v, want RESOURCE_LIMIT", result)
+	}
+}
+
+func TestExecuteOpen_TruncateOversize(t *testing.T) {
+	if sandbox.CheckDockerAvailability() != nil {
+		t.Skip("Docker not available")
+	}
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+	cfg.MaxFileSize = 1000
+	cfg.MaxChunkedSize = 0
+	cfg.TruncateOversize = true
+	cfg.TruncateKeep = 50
+
+	var lines []string
+	for i := 1; i <= 200; i++ {
+		lines = append(lines, fmt.Sprintf("line %03d", i))
+	}
+	content := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := &testAuditLog{}
+	result := ExecuteOpen("app.txt", cfg, audit.log, nil)
+	if !result.Success || result.Action != "TRUNCATED" {
+		t.Fatalf("open: success=%v action=%q err=%v", result.Success, result.Action, result.Error)
+	}
+	for _, want := range []string{
+		"[file truncated: 1800 bytes, over the 1000 byte limit; showing the first 45 and last 45 bytes]\nline 001\n",
+		"line 005\n[... 1710 bytes omitted ...]\nline 196\n",
+		"line 200\n",
+	} {
+		if !strings.Contains(result.Result, want) {
+			t.Errorf("result missing %q:\n%s", want, result.Result)
+		}
+	}
+	if len(audit.entries) != 1 || !strings.Contains(audit.entries[0].errMsg, "truncated:") {
+		t.Errorf("audit = %+v", audit.entries)
+	}
+}
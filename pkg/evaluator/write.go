@@ -1,23 +1,22 @@
 package evaluator
 
 import (
-	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"context"
-	"go/format"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/backup"
 	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
 
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
 	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
 )
 
-// CreateBackup creates a backup of an existing file
+// CreateBackup creates a backup of an existing file next to it. Writes use
+// the central backup directory via NewBackupManager instead.
 func CreateBackup(filePath string) (string, error) {
 	timestamp := time.Now().Unix()
 	backupPath := fmt.Sprintf("%s.bak.%d", filePath, timestamp)
@@ -35,55 +34,41 @@ func CreateBackup(filePath string) (string, error) {
 	return backupPath, nil
 }
 
-// FormatContent formats content based on file type
-func FormatContent(filePath, content string) (string, error) {
-	lastDot := strings.LastIndex(filePath, ".")
-	if lastDot == -1 {
-		return content, nil
-	}
-
-	ext := strings.ToLower(filePath[lastDot:])
-
-	switch ext {
-	case ".go":
-		formatted, err := format.Source([]byte(content))
-		if err != nil {
-			return content, nil
-		}
-		return string(formatted), nil
-	case ".json":
-		var jsonData interface{}
-		if err := json.Unmarshal([]byte(content), &jsonData); err != nil {
-			return content, nil
-		}
-		formatted, err := json.MarshalIndent(jsonData, "", "  ")
-		if err != nil {
-			return content, nil
-		}
-		return string(formatted), nil
-	default:
-		return content, nil
+// NewBackupManager returns the backup manager for the configured backup
+// directory and retention limits
+func NewBackupManager(cfg *config.Config) *backup.Manager {
+	m := backup.NewManager(cfg.RepositoryRoot, cfg.BackupDir, cfg.BackupMaxCount, cfg.BackupMaxAge)
+	if cfg.Deterministic {
+		m.SetClock(func() time.Time { return config.DeterministicTime })
 	}
+	return m
 }
 
-// CalculateContentHash calculates SHA256 hash of content
-func CalculateContentHash(content string) string {
-	hash := sha256.Sum256([]byte(content))
-	return fmt.Sprintf("%x", hash)
+// FormatContent formats content based on file type using the built-in
+// formatters
+func FormatContent(filePath, content string) (string, error) {
+	return DefaultFormatterRegistry().Format(filePath, content)
 }
 
 // ExecuteWrite handles the "write" command
 func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
+	return executeWrite(context.Background(), filePath, content, "", cfg, auditLog, pool)
+}
+
+// executeWrite is ExecuteWrite as part of the trace in ctx, giving the file
+// mode if it is set, as with <write path mode=0755>. Without a mode, an
+// existing file keeps its own.
+func executeWrite(ctx context.Context, filePath, content, mode string, cfg *config.Config, auditLog func(cmd, arg string, success bool, errMsg string), pool *sandbox.ContainerPool) scanner.ExecutionResult {
 	startTime := time.Now()
 	result := scanner.ExecutionResult{
-		Command: scanner.Command{Type: "write", Argument: filePath, Content: content},
+		Command: scanner.Command{Type: "write", Argument: filePath, Content: content, Mode: mode},
 	}
 
 	// Validate the path
-	safePath, err := sandbox.ValidatePath(filePath, cfg.RepositoryRoot, cfg.ExcludedPaths)
+	safePath, err := sandbox.ValidateHostPath(filePath, cfg.RepositoryRoot, cfg.ExcludedPaths)
 	if err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("PATH_SECURITY: %w", err)
+		fullError := errors.Wrap(errors.PathSecurity, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -95,7 +80,7 @@ func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cm
 	// Validate file extension
 	if err := sandbox.ValidateWriteExtension(filePath, cfg.AllowedExtensions); err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("EXTENSION_DENIED: %w", err)
+		fullError := errors.Wrap(errors.ExtensionDenied, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -108,7 +93,7 @@ func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cm
 	contentBytes := []byte(content)
 	if int64(len(contentBytes)) > cfg.MaxWriteSize {
 		result.Success = false
-		fullError := fmt.Errorf("RESOURCE_LIMIT: content too large (%d bytes, max %d)",
+		fullError := errors.Newf(errors.ResourceLimit, "content too large (%d bytes, max %d)",
 			len(contentBytes), cfg.MaxWriteSize)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
@@ -118,19 +103,12 @@ func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cm
 		return result
 	}
 
-	// Check if file exists
-	var backupPath string
-	fileExists := false
-	if _, err := os.Stat(safePath); err == nil {
-		fileExists = true
-		result.Action = "UPDATED"
-
-		// Create backup if configured
-		if cfg.BackupBeforeWrite {
-			backupPath, err = CreateBackup(safePath)
-			if err != nil {
+	// Validate syntax for known file types before touching the file
+	if cfg.SyntaxCheck == config.SyntaxCheckWarn || cfg.SyntaxCheck == config.SyntaxCheckReject {
+		if syntaxErr := CheckSyntax(filePath, content); syntaxErr != nil {
+			if cfg.SyntaxCheck == config.SyntaxCheckReject {
 				result.Success = false
-				fullError := fmt.Errorf("BACKUP_FAILED: %w", err)
+				fullError := errors.Wrap(errors.SyntaxError, syntaxErr)
 				result.Error = SanitizeError(fullError) // Sanitized for LLM
 				result.ExecutionTime = time.Since(startTime)
 				if auditLog != nil {
@@ -138,17 +116,38 @@ func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cm
 				}
 				return result
 			}
-			result.BackupFile = backupPath
+			result.Warnings = append(result.Warnings, syntaxErr.Error())
 		}
+	}
+
+	// Check if file exists
+	fileExists := false
+	info, err := os.Stat(safePath)
+	if err == nil {
+		fileExists = true
+		result.Action = "UPDATED"
 	} else {
+		info = nil
 		result.Action = "CREATED"
 	}
 
+	// Keep the file's mode, such as an executable bit, unless one is given
+	fileMode, err := writeFileMode(mode, info, cfg)
+	if err != nil {
+		result.Success = false
+		result.Error = SanitizeError(err) // Sanitized for LLM
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, false, err.Error()) // Full error to audit
+		}
+		return result
+	}
+
 	// Format content based on file type
-	formattedContent, err := FormatContent(filePath, content)
+	formattedContent, err := NewFormatterRegistryFromConfig(cfg).Format(safePath, content)
 	if err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("FORMATTING_ERROR: %w", err)
+		fullError := errors.Wrap(errors.FormattingError, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -157,17 +156,73 @@ func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cm
 		return result
 	}
 
+	// Match the existing file's line endings, final newline, and BOM
+	var existing *TextConvention
+	var current []byte
+	if fileExists {
+		if data, err := os.ReadFile(safePath); err == nil {
+			current = data
+			detected := DetectConvention(data)
+			existing = &detected
+		}
+	}
+	formattedContent, convention := ApplyConvention(formattedContent, existing, conventionOptions(cfg))
+	result.Convention = convention.String()
+
+	// Content the file already has is not written or backed up, so edit
+	// loops that repeat a write don't churn backups and modification times
+	if existing != nil && string(current) == formattedContent && info.Mode().Perm() == fileMode {
+		result.Success = true
+		result.Action = "UNCHANGED"
+		result.ExecutionTime = time.Since(startTime)
+		if auditLog != nil {
+			auditLog("write", filePath, true, fmt.Sprintf("hash:%s,bytes:0,action:unchanged", CalculateContentHash(formattedContent)))
+		}
+		return result
+	}
+
+	// Append-only files may gain lines but not lose or change them
+	if existing != nil {
+		if err := checkAppendOnly(safePath, current, formattedContent, cfg); err != nil {
+			result.Success = false
+			result.Error = SanitizeError(err) // Sanitized for LLM
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("write", filePath, false, err.Error()) // Full error to audit
+			}
+			return result
+		}
+	}
+
+	// Create backup if configured
+	var backupPath string
+	if fileExists && cfg.BackupBeforeWrite {
+		backupPath, err = NewBackupManager(cfg).Create(safePath)
+		if err != nil {
+			result.Success = false
+			fullError := errors.Wrap(errors.BackupFailed, err)
+			result.Error = SanitizeError(fullError) // Sanitized for LLM
+			result.ExecutionTime = time.Since(startTime)
+			if auditLog != nil {
+				auditLog("write", filePath, false, fullError.Error()) // Full error to audit
+			}
+			return result
+		}
+		result.BackupFile = backupPath
+	}
+
 	// Write file using container
 	err = sandbox.WriteFileInContainerPooled(
-		context.Background(),
+		ctx,
 		pool,
 		safePath,
 		formattedContent,
 		cfg.RepositoryRoot,
+		fileMode,
 	)
 	if err != nil {
 		result.Success = false
-		fullError := fmt.Errorf("WRITE_CONTAINER: %w", err)
+		fullError := errors.Wrap(errors.WriteContainer, err)
 		result.Error = SanitizeError(fullError) // Sanitized for LLM
 		result.ExecutionTime = time.Since(startTime)
 		if auditLog != nil {
@@ -176,6 +231,12 @@ func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cm
 		return result
 	}
 
+	// The container's user now owns the file; give it back to its owner
+	// where the host may, and otherwise leave it
+	if fileExists {
+		sandbox.RestoreOwner(safePath, info)
+	}
+
 	// Calculate content hash for audit log
 	contentHash := CalculateContentHash(formattedContent)
 
@@ -193,6 +254,12 @@ func ExecuteWrite(filePath, content string, cfg *config.Config, auditLog func(cm
 	if backupPath != "" {
 		auditMsg += fmt.Sprintf(",backup:%s", filepath.Base(backupPath))
 	}
+	if mode != "" {
+		auditMsg += fmt.Sprintf(",mode:%04o", fileMode)
+	}
+	if len(result.Warnings) > 0 {
+		auditMsg += ",syntax:warning"
+	}
 
 	if auditLog != nil {
 		auditLog("write", filePath, true, auditMsg)
@@ -198,7 +198,6 @@ func TestFormatContent_OtherExtensions(t *testing.T) {
 		{"test.txt", "plain text content"},
 		{"test.py", "def main():\n    pass"},
 		{"test.md", "# Markdown\n\nContent"},
-		{"test.yaml", "key: value"},
 		{"test.js", "function test() { return 1; }"},
 		{"noextension", "content without extension"},
 	}
@@ -802,7 +801,6 @@ func TestExecuteWrite_CaseInsensitiveExtension(t *testing.T) {
 }
 
 func TestExecuteWrite_SpecialCharactersInContent(t *testing.T) {
-	t.Skip("TODO: Fix special character handling in shell commands")
 	tmpDir := t.TempDir()
 	cfg := newTestConfig(tmpDir)
 
@@ -1237,3 +1235,32 @@ func TestFormatContent_EmptyContent(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteWrite_UnchangedContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := newTestConfig(tmpDir)
+
+	existingFile := filepath.Join(tmpDir, "same.txt")
+	if err := os.WriteFile(existingFile, []byte("same content\n"), 0644); err != nil {
+		t.Fatalf("failed to create existing file: %v", err)
+	}
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(existingFile, past, past)
+
+	audit := &testAuditLog{}
+	result := ExecuteWrite("same.txt", "same content\n", cfg, audit.log, nil)
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if result.Action != "UNCHANGED" || result.BytesWritten != 0 || result.BackupFile != "" {
+		t.Errorf("action=%q bytes=%d backup=%q, want UNCHANGED, 0, no backup", result.Action, result.BytesWritten, result.BackupFile)
+	}
+	if info, err := os.Stat(existingFile); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("file was touched: %v", err)
+	}
+
+	entries := audit.getEntries()
+	if len(entries) != 1 || !entries[0].success || !strings.Contains(entries[0].errMsg, "action:unchanged") {
+		t.Errorf("unexpected audit entries: %+v", entries)
+	}
+}
@@ -0,0 +1,399 @@
+// Package forge pushes branches and opens pull requests on GitHub and
+// merge requests on GitLab, and reads their issues, authenticating with a
+// token from the environment.
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Forges supported
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// tokenEnv lists the environment variables holding each forge's API
+// token, in the order they are tried
+var tokenEnv = map[string][]string{
+	GitHub: {"GITHUB_TOKEN", "GH_TOKEN"},
+	GitLab: {"GITLAB_TOKEN"},
+}
+
+// client sends API requests
+var client = &http.Client{Timeout: time.Minute}
+
+// maxResponse is the most of an API response read
+const maxResponse = 4 << 20
+
+// Remote is a repository on a forge
+type Remote struct {
+	Forge   string // GitHub or GitLab
+	Host    string
+	Project string // owner/repo, or a GitLab project path with its groups
+	APIURL  string // Base of the forge's REST API
+	HTTPS   bool   // Whether git reaches it over HTTPS, where pushes use the token
+}
+
+// Request is a pull or merge request to open
+type Request struct {
+	Title string
+	Body  string
+	Head  string // Branch to merge
+	Base  string // Branch to merge into
+	Draft bool
+}
+
+// Options configure Create
+type Options struct {
+	Dir    string // Directory in the repository
+	Remote string // Git remote to push to, such as origin
+	Forge  string // GitHub or GitLab; empty to tell from the remote's host
+	Head   string // Branch to push and merge
+	Base   string // Branch to merge into; empty for the remote's default branch
+	Title  string
+	Body   string
+	Draft  bool
+}
+
+// PullRequest is an opened pull or merge request
+type PullRequest struct {
+	URL    string
+	Forge  string
+	Head   string
+	Base   string
+	Commit string // Head commit pushed
+}
+
+// ParseRemote parses the URL of a git remote, in URL or scp-like
+// user@host:path form, as a repository on forge. An empty forge is told
+// from the host.
+func ParseRemote(rawURL, forge string) (*Remote, error) {
+	var host, project string
+	secure := false
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme != "" && u.Host != "" {
+		host, project = u.Hostname(), u.Path
+		secure = u.Scheme == "https"
+	} else if at, path, ok := strings.Cut(rawURL, ":"); ok && !strings.Contains(rawURL, "://") && !strings.Contains(at, "/") {
+		host = at[strings.LastIndex(at, "@")+1:]
+		project = path
+	} else {
+		return nil, fmt.Errorf("cannot parse remote URL %q", rawURL)
+	}
+	project = strings.TrimSuffix(strings.Trim(project, "/"), ".git")
+	if host == "" || project == "" {
+		return nil, fmt.Errorf("cannot parse remote URL %q", rawURL)
+	}
+
+	if forge == "" {
+		switch {
+		case strings.Contains(host, "github"):
+			forge = GitHub
+		case strings.Contains(host, "gitlab"):
+			forge = GitLab
+		default:
+			return nil, fmt.Errorf("cannot tell the forge of %s; set it to %s or %s", host, GitHub, GitLab)
+		}
+	}
+	remote := &Remote{Forge: forge, Host: host, Project: project, HTTPS: secure}
+	switch {
+	case forge == GitHub && host == "github.com":
+		remote.APIURL = "https://api.github.com"
+	case forge == GitHub:
+		// GitHub Enterprise Server
+		remote.APIURL = "https://" + host + "/api/v3"
+	case forge == GitLab:
+		remote.APIURL = "https://" + host + "/api/v4"
+	default:
+		return nil, fmt.Errorf("unknown forge %q (want %s or %s)", forge, GitHub, GitLab)
+	}
+	if forge == GitHub && strings.Count(project, "/") != 1 {
+		return nil, fmt.Errorf("%s is not a GitHub owner/repository", project)
+	}
+	return remote, nil
+}
+
+// Token returns the API token of forge from the environment, or "" when
+// none is set
+func Token(forge string) string {
+	for _, name := range tokenEnv[forge] {
+		if token := os.Getenv(name); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// TokenVariables names the environment variables Token reads for forge
+func TokenVariables(forge string) string {
+	return strings.Join(tokenEnv[forge], " or ")
+}
+
+// RemoteOf returns the repository the git remote named remote of the
+// repository in dir is on, as ParseRemote does for its URL
+func RemoteOf(ctx context.Context, dir, remote, forge string) (*Remote, error) {
+	remoteURL, err := git(ctx, dir, nil, "remote", "get-url", remote)
+	if err != nil {
+		return nil, fmt.Errorf("no remote %s: %w", remote, err)
+	}
+	return ParseRemote(remoteURL, forge)
+}
+
+// Create pushes opts.Head to opts.Remote and opens a request to merge it
+// into opts.Base
+func Create(ctx context.Context, opts Options) (*PullRequest, error) {
+	remote, err := RemoteOf(ctx, opts.Dir, opts.Remote, opts.Forge)
+	if err != nil {
+		return nil, err
+	}
+	// Checked before pushing, so a missing token does not leave a pushed
+	// branch behind
+	token := Token(remote.Forge)
+	if token == "" {
+		return nil, fmt.Errorf("no %s token; set %s", remote.Forge, TokenVariables(remote.Forge))
+	}
+	base := opts.Base
+	if base == "" {
+		if base, err = DefaultBranch(ctx, opts.Dir, opts.Remote); err != nil {
+			return nil, err
+		}
+	}
+	if base == opts.Head {
+		return nil, fmt.Errorf("cannot merge %s into itself", base)
+	}
+
+	commit, err := git(ctx, opts.Dir, nil, "rev-parse", "--verify", opts.Head)
+	if err != nil {
+		return nil, fmt.Errorf("no branch %s: %w", opts.Head, err)
+	}
+	if err := remote.Push(ctx, opts.Dir, opts.Remote, opts.Head, token); err != nil {
+		return nil, err
+	}
+	webURL, err := remote.Open(ctx, token, Request{Title: opts.Title, Body: opts.Body, Head: opts.Head, Base: base, Draft: opts.Draft})
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequest{URL: webURL, Forge: remote.Forge, Head: opts.Head, Base: base, Commit: commit}, nil
+}
+
+// DefaultBranch returns the default branch of remote: the one its HEAD
+// was last fetched as, or else the one it reports
+func DefaultBranch(ctx context.Context, dir, remote string) (string, error) {
+	if ref, err := git(ctx, dir, nil, "symbolic-ref", "--short", "-q", "refs/remotes/"+remote+"/HEAD"); err == nil && ref != "" {
+		return strings.TrimPrefix(ref, remote+"/"), nil
+	}
+	out, err := git(ctx, dir, nil, "ls-remote", "--symref", remote, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("cannot find the default branch of %s: %w", remote, err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if ref, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+			branch, _, _ := strings.Cut(ref, "\t")
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find the default branch of %s", remote)
+}
+
+// CurrentBranch returns the branch checked out in dir
+func CurrentBranch(ctx context.Context, dir string) (string, error) {
+	branch, err := git(ctx, dir, nil, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil || branch == "" {
+		return "", fmt.Errorf("HEAD of %s is not on a branch", dir)
+	}
+	return branch, nil
+}
+
+// Subject returns the subject of the last commit of branch
+func Subject(ctx context.Context, dir, branch string) (string, error) {
+	subject, err := git(ctx, dir, nil, "log", "-1", "--format=%s", "refs/heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("no branch %s: %w", branch, err)
+	}
+	return subject, nil
+}
+
+// Push pushes branch of the repository in dir to the same branch of
+// remote, never forcing it. Over HTTPS the token authenticates; over SSH
+// the user's keys do.
+func (r *Remote) Push(ctx context.Context, dir, remote, branch, token string) error {
+	var env []string
+	if r.HTTPS && token != "" {
+		user := "x-access-token"
+		if r.Forge == GitLab {
+			user = "oauth2"
+		}
+		// Passed in the environment rather than as arguments, which other
+		// users of the host can see
+		auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + token))
+		env = []string{
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.https://" + r.Host + "/.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic " + auth,
+		}
+	}
+	if _, err := git(ctx, dir, env, "push", "-q", "--no-verify", remote, "refs/heads/"+branch+":refs/heads/"+branch); err != nil {
+		return fmt.Errorf("cannot push %s to %s: %w", branch, remote, err)
+	}
+	return nil
+}
+
+// Open opens req and returns the address of its web page
+func (r *Remote) Open(ctx context.Context, token string, req Request) (string, error) {
+	var created struct {
+		HTMLURL string `json:"html_url"` // GitHub
+		WebURL  string `json:"web_url"`  // GitLab
+	}
+	switch r.Forge {
+	case GitHub:
+		payload := map[string]any{"title": req.Title, "body": req.Body, "head": req.Head, "base": req.Base, "draft": req.Draft}
+		if err := r.call(ctx, token, http.MethodPost, "/repos/"+r.Project+"/pulls", payload, &created); err != nil {
+			return "", err
+		}
+		return created.HTMLURL, nil
+	case GitLab:
+		title := req.Title
+		if req.Draft {
+			title = "Draft: " + title
+		}
+		payload := map[string]any{"title": title, "description": req.Body, "source_branch": req.Head, "target_branch": req.Base}
+		if err := r.call(ctx, token, http.MethodPost, r.projectPath()+"/merge_requests", payload, &created); err != nil {
+			return "", err
+		}
+		return created.WebURL, nil
+	}
+	return "", fmt.Errorf("unknown forge %q", r.Forge)
+}
+
+// projectPath is the API path of a GitLab project
+func (r *Remote) projectPath() string {
+	return "/projects/" + url.PathEscape(r.Project)
+}
+
+// call sends a request with payload, if not nil, as JSON to path under the
+// API and decodes the response into out. Without a token the request is
+// anonymous, which public repositories allow for reads.
+func (r *Remote) call(ctx context.Context, token, method, path string, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.APIURL+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case r.Forge == GitHub:
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	case token != "":
+		req.Header.Set("PRIVATE-TOKEN", token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s API: %w", r.Forge, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return fmt.Errorf("%s API: %w", r.Forge, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s API returned %s: %s", r.Forge, resp.Status, apiError(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s API: invalid response: %w", r.Forge, err)
+	}
+	return nil
+}
+
+// apiError extracts the reason from an API error response, such as that a
+// request for the branch already exists
+func apiError(body []byte) string {
+	var resp struct {
+		Message any `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Message == nil {
+		return strings.TrimSpace(string(body))
+	}
+	var reasons []string
+	switch m := resp.Message.(type) {
+	case string:
+		reasons = append(reasons, m)
+	case []any:
+		// GitLab lists several reasons
+		for _, item := range m {
+			reasons = append(reasons, fmt.Sprint(item))
+		}
+	default:
+		data, _ := json.Marshal(m)
+		reasons = append(reasons, string(data))
+	}
+	for _, e := range resp.Errors {
+		if e.Message != "" {
+			reasons = append(reasons, e.Message)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// BranchSummary describes in Markdown what head adds to base on remote:
+// its commits and the files they change
+func BranchSummary(ctx context.Context, dir, remote, base, head string) (string, error) {
+	span := remote + "/" + base + "..." + head
+	commits, err := git(ctx, dir, nil, "log", "--reverse", "--format=- %h %s", span)
+	if err != nil {
+		return "", err
+	}
+	stat, err := git(ctx, dir, nil, "diff", "--stat", span)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Commits\n\n%s\n\n## Files\n\n```\n%s\n```\n", commits, stat)
+	return b.String(), nil
+}
+
+// git runs git in dir with env added to its environment, and returns its
+// output without the final newline, or its error output as the error.
+// Hooks are disabled, so pushing cannot run code from the repository, and
+// git never prompts for credentials.
+func git(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir, "-c", "core.hooksPath=/dev/null"}, args...)...)
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
@@ -0,0 +1,144 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		url, forge           string
+		wantForge, wantProj  string
+		wantAPI              string
+		wantHTTPS, wantError bool
+	}{
+		{"https://github.com/acme/tools.git", "", GitHub, "acme/tools", "https://api.github.com", true, false},
+		{"git@github.com:acme/tools.git", "", GitHub, "acme/tools", "https://api.github.com", false, false},
+		{"ssh://git@gitlab.example.com/group/sub/tools", "", GitLab, "group/sub/tools", "https://gitlab.example.com/api/v4", false, false},
+		{"https://git.example.com/acme/tools", GitHub, GitHub, "acme/tools", "https://git.example.com/api/v3", true, false},
+		{"https://git.example.com/acme/tools", "", "", "", "", false, true},
+		{"https://github.com/acme/group/tools", "", "", "", "", false, true},
+		{"/srv/git/tools.git", "", "", "", "", false, true},
+		{"file:///srv/git/tools.git", "", "", "", "", false, true},
+	}
+	for _, tt := range tests {
+		remote, err := ParseRemote(tt.url, tt.forge)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("ParseRemote(%q) = %+v, want error", tt.url, remote)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRemote(%q) error = %v", tt.url, err)
+			continue
+		}
+		if remote.Forge != tt.wantForge || remote.Project != tt.wantProj || remote.APIURL != tt.wantAPI || remote.HTTPS != tt.wantHTTPS {
+			t.Errorf("ParseRemote(%q) = %+v", tt.url, remote)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	var got map[string]any
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization") + r.Header.Get("PRIVATE-TOKEN")
+		json.NewDecoder(r.Body).Decode(&got)
+		switch r.URL.EscapedPath() {
+		case "/repos/acme/tools/pulls":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"html_url": "https://github.com/acme/tools/pull/7"}`))
+		case "/projects/group%2Ftools/merge_requests":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message": ["Another open merge request already exists for this source branch"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	req := Request{Title: "Fix parser", Body: "Details", Head: "llm-runtime/session-1", Base: "main", Draft: true}
+
+	github := &Remote{Forge: GitHub, Project: "acme/tools", APIURL: server.URL}
+	webURL, err := github.Open(ctx, "secret", req)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if webURL != "https://github.com/acme/tools/pull/7" || auth != "Bearer secret" {
+		t.Errorf("Open() = %q with auth %q", webURL, auth)
+	}
+	if got["head"] != req.Head || got["base"] != "main" || got["draft"] != true || got["body"] != "Details" {
+		t.Errorf("request = %v", got)
+	}
+
+	gitlab := &Remote{Forge: GitLab, Project: "group/tools", APIURL: server.URL}
+	_, err = gitlab.Open(ctx, "secret", req)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Open() error = %v, want already exists", err)
+	}
+	if got["title"] != "Draft: Fix parser" || got["source_branch"] != req.Head || auth != "secret" {
+		t.Errorf("request = %v with auth %q", got, auth)
+	}
+}
+
+func TestPushAndDefaultBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	bare := filepath.Join(t.TempDir(), "origin.git")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+	for _, step := range []struct {
+		dir  string
+		args []string
+	}{
+		{dir, []string{"init", "-q", "--bare", "-b", "trunk", bare}},
+		{dir, []string{"init", "-q", "-b", "trunk"}},
+		{dir, []string{"add", "."}},
+		{dir, []string{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"}},
+		{dir, []string{"remote", "add", "origin", bare}},
+		{dir, []string{"push", "-q", "origin", "trunk"}},
+		{dir, []string{"switch", "-q", "-c", "feature"}},
+	} {
+		if _, err := git(ctx, step.dir, nil, step.args...); err != nil {
+			t.Fatalf("git %v: %v", step.args, err)
+		}
+	}
+
+	if base, err := DefaultBranch(ctx, dir, "origin"); err != nil || base != "trunk" {
+		t.Errorf("DefaultBranch() = %q, %v; want trunk", base, err)
+	}
+	remote := &Remote{Forge: GitHub}
+	if err := remote.Push(ctx, dir, "origin", "feature", "secret"); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	local, _ := git(ctx, dir, nil, "rev-parse", "feature")
+	if pushed, _ := git(ctx, bare, nil, "rev-parse", "feature"); pushed != local {
+		t.Errorf("pushed %q, want %q", pushed, local)
+	}
+}
+
+func TestCreate_NoToken(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	dir := t.TempDir()
+	git(context.Background(), dir, nil, "init", "-q")
+	git(context.Background(), dir, nil, "remote", "add", "origin", "https://github.com/acme/tools.git")
+
+	_, err := Create(context.Background(), Options{Dir: dir, Remote: "origin", Head: "feature", Title: "x"})
+	if err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("Create() error = %v, want missing token", err)
+	}
+}
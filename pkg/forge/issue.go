@@ -0,0 +1,150 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxComments is the most recent comments Issue fetches
+const maxComments = 100
+
+// Issue is an issue and its most recent comments
+type Issue struct {
+	Number   int
+	Title    string
+	State    string
+	Author   string
+	Labels   []string
+	URL      string
+	Body     string
+	Comments []Comment // Oldest first
+	Total    int       // Comments on the issue, including those not fetched
+}
+
+// Comment is a comment on an issue
+type Comment struct {
+	Author  string
+	Body    string
+	Created time.Time
+}
+
+// githubUser and gitlabUser are the authors in API responses
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+// Issue fetches issue number with its most recent comments, at most
+// comments of them and no more than maxComments
+func (r *Remote) Issue(ctx context.Context, token string, number, comments int) (*Issue, error) {
+	if comments > maxComments {
+		comments = maxComments
+	}
+	switch r.Forge {
+	case GitHub:
+		return r.githubIssue(ctx, token, number, comments)
+	case GitLab:
+		return r.gitlabIssue(ctx, token, number, comments)
+	}
+	return nil, fmt.Errorf("unknown forge %q", r.Forge)
+}
+
+func (r *Remote) githubIssue(ctx context.Context, token string, number, comments int) (*Issue, error) {
+	path := fmt.Sprintf("/repos/%s/issues/%d", r.Project, number)
+	var resp struct {
+		Number   int        `json:"number"`
+		Title    string     `json:"title"`
+		State    string     `json:"state"`
+		Body     string     `json:"body"`
+		HTMLURL  string     `json:"html_url"`
+		User     githubUser `json:"user"`
+		Comments int        `json:"comments"`
+		Labels   []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := r.call(ctx, token, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	issue := &Issue{Number: resp.Number, Title: resp.Title, State: resp.State, Author: resp.User.Login, URL: resp.HTMLURL, Body: resp.Body, Total: resp.Comments}
+	for _, label := range resp.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	if comments <= 0 || resp.Comments == 0 {
+		return issue, nil
+	}
+
+	// Comments come oldest first, so the most recent are on the last pages
+	type githubComment struct {
+		Body      string     `json:"body"`
+		User      githubUser `json:"user"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+	var fetched []githubComment
+	for page := (resp.Comments + maxComments - 1) / maxComments; page >= 1 && len(fetched) < comments; page-- {
+		var batch []githubComment
+		if err := r.call(ctx, token, http.MethodGet, fmt.Sprintf("%s/comments?per_page=%d&page=%d", path, maxComments, page), nil, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		fetched = append(batch, fetched...)
+	}
+	if len(fetched) > comments {
+		fetched = fetched[len(fetched)-comments:]
+	}
+	for _, c := range fetched {
+		issue.Comments = append(issue.Comments, Comment{Author: c.User.Login, Body: c.Body, Created: c.CreatedAt})
+	}
+	return issue, nil
+}
+
+func (r *Remote) gitlabIssue(ctx context.Context, token string, number, comments int) (*Issue, error) {
+	path := fmt.Sprintf("%s/issues/%d", r.projectPath(), number)
+	var resp struct {
+		IID         int        `json:"iid"`
+		Title       string     `json:"title"`
+		State       string     `json:"state"`
+		Description string     `json:"description"`
+		WebURL      string     `json:"web_url"`
+		Author      gitlabUser `json:"author"`
+		Labels      []string   `json:"labels"`
+		Notes       int        `json:"user_notes_count"`
+	}
+	if err := r.call(ctx, token, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	issue := &Issue{Number: resp.IID, Title: resp.Title, State: resp.State, Author: resp.Author.Username, Labels: resp.Labels, URL: resp.WebURL, Body: resp.Description, Total: resp.Notes}
+	if comments <= 0 || resp.Notes == 0 {
+		return issue, nil
+	}
+
+	// Notes include system ones, such as label changes, which are skipped
+	var notes []struct {
+		Body      string     `json:"body"`
+		Author    gitlabUser `json:"author"`
+		CreatedAt time.Time  `json:"created_at"`
+		System    bool       `json:"system"`
+	}
+	query := url.Values{"sort": {"desc"}, "order_by": {"created_at"}, "per_page": {fmt.Sprint(maxComments)}}
+	if err := r.call(ctx, token, http.MethodGet, path+"/notes?"+query.Encode(), nil, &notes); err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if note.System {
+			continue
+		}
+		issue.Comments = append([]Comment{{Author: note.Author.Username, Body: note.Body, Created: note.CreatedAt}}, issue.Comments...)
+		if len(issue.Comments) == comments {
+			break
+		}
+	}
+	return issue, nil
+}
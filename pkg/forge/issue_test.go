@@ -0,0 +1,82 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIssue_GitHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/tools/issues/12":
+			fmt.Fprint(w, `{"number": 12, "title": "Scanner hangs", "state": "open", "body": "Steps", "html_url": "https://github.com/acme/tools/issues/12",
+				"user": {"login": "alice"}, "comments": 103, "labels": [{"name": "bug"}]}`)
+		case "/repos/acme/tools/issues/12/comments":
+			// 103 comments: page 2 holds the last three
+			var comments []string
+			first, n := 1, 100
+			if r.URL.Query().Get("page") == "2" {
+				first, n = 101, 3
+			}
+			for i := first; i < first+n; i++ {
+				comments = append(comments, fmt.Sprintf(`{"body": "comment %d", "user": {"login": "bob"}, "created_at": "2026-10-01T12:00:00Z"}`, i))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(comments, ","))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	remote := &Remote{Forge: GitHub, Project: "acme/tools", APIURL: server.URL}
+	issue, err := remote.Issue(context.Background(), "", 12, 5)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if issue.Title != "Scanner hangs" || issue.Author != "alice" || issue.Total != 103 || len(issue.Labels) != 1 {
+		t.Errorf("Issue() = %+v", issue)
+	}
+	if len(issue.Comments) != 5 || issue.Comments[0].Body != "comment 99" || issue.Comments[4].Body != "comment 103" {
+		t.Errorf("comments = %+v, want 99 to 103", issue.Comments)
+	}
+
+	if _, err := remote.Issue(context.Background(), "", 13, 5); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing issue error = %v, want 404", err)
+	}
+}
+
+func TestIssue_GitLab(t *testing.T) {
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("PRIVATE-TOKEN")
+		switch r.URL.EscapedPath() {
+		case "/projects/group%2Ftools/issues/7":
+			fmt.Fprint(w, `{"iid": 7, "title": "Flaky test", "state": "opened", "description": "It fails", "web_url": "https://gitlab.com/group/tools/-/issues/7",
+				"author": {"username": "carol"}, "labels": ["ci"], "user_notes_count": 2}`)
+		case "/projects/group%2Ftools/issues/7/notes":
+			// Newest first
+			fmt.Fprint(w, `[{"body": "second", "author": {"username": "dan"}, "created_at": "2026-10-02T12:00:00Z"},
+				{"body": "added ~ci label", "author": {"username": "dan"}, "system": true, "created_at": "2026-10-01T13:00:00Z"},
+				{"body": "first", "author": {"username": "erin"}, "created_at": "2026-10-01T12:00:00Z"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	remote := &Remote{Forge: GitLab, Project: "group/tools", APIURL: server.URL}
+	issue, err := remote.Issue(context.Background(), "secret", 7, 10)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if issue.Body != "It fails" || issue.Author != "carol" || token != "secret" {
+		t.Errorf("Issue() = %+v with token %q", issue, token)
+	}
+	if len(issue.Comments) != 2 || issue.Comments[0].Body != "first" || issue.Comments[1].Author != "dan" {
+		t.Errorf("comments = %+v, want first then second", issue.Comments)
+	}
+}
@@ -0,0 +1,190 @@
+// Package hooks runs the scripts and webhooks configured to run before and
+// after commands. Each is given the command as JSON; a pre hook that fails
+// refuses the command, which lets users add policy, notifications, and CI
+// triggers without changing the runtime.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+// All is the command name of hooks run for every command, as in pre_all
+const All = "all"
+
+// eventName matches the name of a hook event: pre_ or post_ and a command
+// name with - written as _
+var eventName = regexp.MustCompile(`^(pre|post)_[a-z][a-z0-9_]*$`)
+
+// maxMessage is the most of a hook's output kept as its message
+const maxMessage = 1024
+
+// Event describes a command to its hooks. Post hooks are also given its
+// outcome.
+type Event struct {
+	Event         string `json:"event"` // Such as pre_write or post_exec
+	Session       string `json:"session"`
+	CorrelationID string `json:"correlation_id"`
+	Repository    string `json:"repository"`
+	Command       string `json:"command"`
+	Argument      string `json:"argument"`
+	Content       string `json:"content,omitempty"` // Body of write, append, and the like, or exec stdin
+
+	Success    *bool   `json:"success,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	ExitCode   int     `json:"exit_code,omitempty"`
+	Action     string  `json:"action,omitempty"`
+	DurationMS float64 `json:"duration_ms,omitempty"`
+}
+
+// Failure is a hook that exited non-zero, returned a non-2xx status, or
+// could not be run
+type Failure struct {
+	Event   string
+	Hook    string // The hook's command or URL
+	Message string // What it printed or returned, or why it could not run
+}
+
+func (f *Failure) Error() string {
+	if f.Message == "" {
+		return fmt.Sprintf("%s hook %s failed", f.Event, f.Hook)
+	}
+	return fmt.Sprintf("%s hook %s failed: %s", f.Event, f.Hook, f.Message)
+}
+
+// Validate checks hooks, as configured by event
+func Validate(hooks map[string][]config.HookConfig) error {
+	for event, list := range hooks {
+		if !eventName.MatchString(event) {
+			return fmt.Errorf("invalid hook event %q (want pre_ or post_ and a command name, such as pre_write or post_all)", event)
+		}
+		for i, hook := range list {
+			if (hook.Command == "") == (hook.URL == "") {
+				return fmt.Errorf("hooks.%s[%d] needs a command or a url, not both", event, i)
+			}
+			if hook.URL != "" && !strings.HasPrefix(hook.URL, "http://") && !strings.HasPrefix(hook.URL, "https://") {
+				return fmt.Errorf("hooks.%s[%d]: url must be http or https", event, i)
+			}
+		}
+	}
+	return nil
+}
+
+// For returns the hooks configured for phase ("pre" or "post") of commands
+// of type command: those of the command first, then those of every command
+func For(hooks map[string][]config.HookConfig, phase, command string) []config.HookConfig {
+	if len(hooks) == 0 {
+		return nil
+	}
+	name := phase + "_" + strings.ReplaceAll(command, "-", "_")
+	var list []config.HookConfig
+	list = append(list, hooks[name]...)
+	return append(list, hooks[phase+"_"+All]...)
+}
+
+// Run runs hooks in order with event. It stops at the first failure and
+// returns it as a *Failure.
+func Run(ctx context.Context, hooks []config.HookConfig, event Event) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		timeout := hook.Timeout
+		if timeout <= 0 {
+			timeout = config.DefaultHookTimeout
+		}
+		hookCtx, cancel := context.WithTimeout(ctx, timeout)
+		if hook.URL != "" {
+			err = post(hookCtx, hook.URL, event, payload)
+		} else {
+			err = run(hookCtx, hook.Command, event, payload)
+		}
+		cancel()
+		if err != nil {
+			name := hook.Command
+			if name == "" {
+				name = hook.URL
+			}
+			return &Failure{Event: event.Event, Hook: name, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// run runs command by the shell in the repository root, with payload on
+// stdin and the event in LLM_HOOK_EVENT
+func run(ctx context.Context, command string, event Event, payload []byte) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = event.Repository
+	cmd.Env = append(os.Environ(), "LLM_HOOK_EVENT="+event.Event)
+	cmd.Stdin = bytes.NewReader(payload)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out")
+	}
+	if err != nil {
+		if msg := message(output.Bytes()); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// post sends payload to url, with the event in the X-LLM-Hook-Event header
+func post(ctx context.Context, url string, event Event, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-LLM-Hook-Event", event.Event)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out")
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxMessage))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if msg := message(body); msg != "" {
+			return fmt.Errorf("%s: %s", resp.Status, msg)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+// message returns the first non-blank line of a hook's output, cut to
+// maxMessage bytes
+func message(output []byte) string {
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if len(line) > maxMessage {
+				line = line[:maxMessage]
+			}
+			return line
+		}
+	}
+	return ""
+}
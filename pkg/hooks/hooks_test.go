@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestRun_Command(t *testing.T) {
+	dir := t.TempDir()
+	event := Event{Event: "pre_exec", Repository: dir, Command: "exec", Argument: "go test ./..."}
+
+	tests := []struct {
+		name    string
+		command string
+		wantErr string
+	}{
+		{"allows", `grep -q '"argument":"go test ./..."' && [ "$LLM_HOOK_EVENT" = pre_exec ] && [ "$PWD" = "` + dir + `" ]`, ""},
+		{"vetoes", "echo; echo 'no tests on Fridays' >&2; exit 3", "no tests on Fridays"},
+		{"times out", "sleep 5", "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookConfig{{Command: tt.command, Timeout: 200 * time.Millisecond}}
+			err := Run(context.Background(), hooks, event)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Run() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRun_Webhook(t *testing.T) {
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-LLM-Hook-Event") != "post_write" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.Argument == "secret.txt" {
+			http.Error(w, "writes to secret.txt are logged and refused", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	hooks := []config.HookConfig{{URL: server.URL}}
+
+	if err := Run(context.Background(), hooks, Event{Event: "post_write", Command: "write", Argument: "main.go"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got.Command != "write" || got.Argument != "main.go" {
+		t.Errorf("webhook got %+v", got)
+	}
+	err := Run(context.Background(), hooks, Event{Event: "post_write", Command: "write", Argument: "secret.txt"})
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "logged and refused") {
+		t.Errorf("Run() error = %v, want 403 with the body", err)
+	}
+}
+
+func TestValidateAndFor(t *testing.T) {
+	configured := map[string][]config.HookConfig{
+		"pre_git_commit": {{Command: "a"}},
+		"pre_all":        {{URL: "https://example.com/hook"}},
+	}
+	if err := Validate(configured); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	got := For(configured, "pre", "git-commit")
+	if len(got) != 2 || got[0].Command != "a" || got[1].URL == "" {
+		t.Errorf("For() = %+v, want the command's hook then pre_all's", got)
+	}
+	if got := For(configured, "post", "git-commit"); len(got) != 0 {
+		t.Errorf("For(post) = %+v, want none", got)
+	}
+
+	for _, bad := range []map[string][]config.HookConfig{
+		{"before_write": {{Command: "a"}}},
+		{"pre_write": {{Command: "a", URL: "https://example.com"}}},
+		{"pre_write": {{}}},
+		{"pre_write": {{URL: "file:///etc/passwd"}}},
+	} {
+		if err := Validate(bad); err == nil {
+			t.Errorf("Validate(%v) = nil, want error", bad)
+		}
+	}
+}
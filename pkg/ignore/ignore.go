@@ -0,0 +1,279 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CaseInsensitive controls whether patterns ignore case. It defaults to true
+// on platforms whose default filesystems are case-insensitive.
+var CaseInsensitive = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// FileNames lists the ignore files honored in every directory, in the order
+// they are applied. Rules in .llmignore override .gitignore rules in the
+// same directory.
+var FileNames = []string{".gitignore", ".llmignore"}
+
+// Rule is a single parsed ignore pattern
+type Rule struct {
+	Pattern string // Original pattern text
+	Negate  bool   // Pattern started with '!' and re-includes matches
+	DirOnly bool   // Pattern ended with '/' and only matches directories
+	Base    string // Slash-separated directory of the ignore file, relative to root
+	re      *regexp.Regexp
+	fold    bool
+}
+
+// ParseRule parses one line of an ignore file. base is the slash-separated
+// directory containing the ignore file, relative to the repository root.
+// Returns false for blank lines, comments, and invalid patterns.
+func ParseRule(line, base string) (*Rule, bool) {
+	line = strings.TrimRight(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+
+	rule := &Rule{Pattern: line, Base: base, fold: CaseInsensitive}
+
+	if strings.HasPrefix(line, "!") {
+		rule.Negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.DirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, false
+	}
+
+	// Patterns without a slash match at any depth below the ignore file
+	if strings.HasPrefix(line, "/") {
+		line = line[1:]
+	} else if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+
+	re, err := compileGlob(line, rule.fold)
+	if err != nil {
+		return nil, false
+	}
+	rule.re = re
+
+	return rule, true
+}
+
+// Match reports whether the rule matches relPath, a slash-separated path
+// relative to the repository root
+func (r *Rule) Match(relPath string, isDir bool) bool {
+	if r.DirOnly && !isDir {
+		return false
+	}
+
+	if r.Base != "" {
+		prefix := r.Base + "/"
+		if len(relPath) <= len(prefix) {
+			return false
+		}
+		if r.fold && !strings.EqualFold(relPath[:len(prefix)], prefix) {
+			return false
+		}
+		if !r.fold && relPath[:len(prefix)] != prefix {
+			return false
+		}
+		relPath = relPath[len(prefix):]
+	}
+
+	return r.re.MatchString(norm.NFC.String(relPath))
+}
+
+// CompileGlob converts a slash-separated glob into an anchored regular
+// expression. '*' and '?' never match '/', while '**' spans directories
+// when it forms a whole path segment.
+func CompileGlob(pattern string) (*regexp.Regexp, error) {
+	return compileGlob(pattern, false)
+}
+
+// compileGlob implements CompileGlob, optionally ignoring case. The
+// pattern is read rune by rune and compared in NFC, so non-ASCII names
+// match whichever normalization form the ignore file was saved in.
+func compileGlob(pattern string, fold bool) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	if fold {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("^")
+
+	runes := []rune(norm.NFC.String(pattern))
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch ch {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				atStart := i == 0 || runes[i-1] == '/'
+				atEnd := i+2 == len(runes)
+				if atStart && atEnd {
+					sb.WriteString(".*")
+					i++
+					continue
+				}
+				if atStart && runes[i+2] == '/' {
+					sb.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := -1
+			for j := i + 1; j < len(runes); j++ {
+				if runes[j] == ']' {
+					end = j - i - 1
+					break
+				}
+			}
+			if end == -1 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := string(runes[i+1 : i+1+end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// trimTrailingSpaces removes unescaped trailing spaces
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-2] + " "
+	}
+	return line
+}
+
+// Matcher evaluates nested .gitignore and .llmignore files below a root
+// directory. Ignore files are loaded lazily and cached per directory.
+type Matcher struct {
+	root  string
+	mu    sync.Mutex
+	rules map[string][]*Rule
+}
+
+// NewMatcher creates a matcher for the repository rooted at root
+func NewMatcher(root string) *Matcher {
+	return &Matcher{
+		root:  root,
+		rules: make(map[string][]*Rule),
+	}
+}
+
+// Match reports whether relPath (relative to the root, either separator)
+// is ignored. A path inside an ignored directory is always ignored, as
+// with git, even if a later rule would re-include it.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == "." || relPath == "" {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchOne(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+
+	return m.matchOne(relPath, isDir)
+}
+
+// matchOne applies the rules of every ancestor directory to relPath; the
+// last matching rule wins
+func (m *Matcher) matchOne(relPath string, isDir bool) bool {
+	ignored := false
+
+	dir := ""
+	rest := relPath
+	for {
+		for _, rule := range m.rulesFor(dir) {
+			if rule.Match(relPath, isDir) {
+				ignored = !rule.Negate
+			}
+		}
+
+		idx := strings.IndexByte(rest, '/')
+		if idx == -1 {
+			break
+		}
+		dir = path.Join(dir, rest[:idx])
+		rest = rest[idx+1:]
+	}
+
+	return ignored
+}
+
+// rulesFor returns the cached rules for a directory, loading them on first use
+func (m *Matcher) rulesFor(dir string) []*Rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []*Rule
+	for _, name := range FileNames {
+		rules = append(rules, loadRules(filepath.Join(m.root, filepath.FromSlash(dir), name), dir)...)
+	}
+	m.rules[dir] = rules
+
+	return rules
+}
+
+// loadRules reads an ignore file; missing or unreadable files yield no rules
+func loadRules(filePath, base string) []*Rule {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []*Rule
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		if rule, ok := ParseRule(sc.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
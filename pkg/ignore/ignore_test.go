@@ -0,0 +1,176 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantOK  bool
+		negate  bool
+		dirOnly bool
+	}{
+		{name: "blank line", line: "", wantOK: false},
+		{name: "whitespace only", line: "   ", wantOK: false},
+		{name: "comment", line: "# comment", wantOK: false},
+		{name: "simple pattern", line: "*.log", wantOK: true},
+		{name: "negation", line: "!keep.log", wantOK: true, negate: true},
+		{name: "directory only", line: "build/", wantOK: true, dirOnly: true},
+		{name: "escaped hash", line: `\#file`, wantOK: true},
+		{name: "escaped bang", line: `\!file`, wantOK: true},
+		{name: "lone slash", line: "/", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := ParseRule(tt.line, "")
+			if ok != tt.wantOK {
+				t.Fatalf("ParseRule(%q) ok = %v, want %v", tt.line, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if rule.Negate != tt.negate {
+				t.Errorf("Negate = %v, want %v", rule.Negate, tt.negate)
+			}
+			if rule.DirOnly != tt.dirOnly {
+				t.Errorf("DirOnly = %v, want %v", rule.DirOnly, tt.dirOnly)
+			}
+		})
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		base    string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.log", "", "debug.log", false, true},
+		{"*.log", "", "logs/debug.log", false, true},
+		{"*.log", "", "debug.txt", false, false},
+		{"/debug.log", "", "debug.log", false, true},
+		{"/debug.log", "", "sub/debug.log", false, false},
+		{"build/", "", "build", true, true},
+		{"build/", "", "build", false, false},
+		{"build/", "", "src/build", true, true},
+		{"docs/*.md", "", "docs/a.md", false, true},
+		{"docs/*.md", "", "docs/sub/a.md", false, false},
+		{"**/secrets", "", "a/b/secrets", true, true},
+		{"logs/**", "", "logs/a/b.txt", false, true},
+		{"a/**/b", "", "a/b", false, true},
+		{"a/**/b", "", "a/x/y/b", false, true},
+		{"file?.txt", "", "file1.txt", false, true},
+		{"file[0-9].txt", "", "file5.txt", false, true},
+		{"file[!0-9].txt", "", "file5.txt", false, false},
+		{"*.tmp", "sub", "sub/x.tmp", false, true},
+		{"*.tmp", "sub", "other/x.tmp", false, false},
+		{"/out", "sub", "sub/out", true, true},
+		{"/out", "sub", "sub/deeper/out", true, false},
+	}
+
+	for _, tt := range tests {
+		rule, ok := ParseRule(tt.pattern, tt.base)
+		if !ok {
+			t.Fatalf("ParseRule(%q) failed", tt.pattern)
+		}
+		if got := rule.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("pattern %q (base %q) on %q: got %v, want %v", tt.pattern, tt.base, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_NestedAndNegation(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "node_modules/\n*.log\n!important.log\ndist\n")
+	writeFile(t, filepath.Join(root, "pkg", ".gitignore"), "generated.go\n")
+	writeFile(t, filepath.Join(root, "pkg", ".llmignore"), "!keep.log\nfixtures/\n")
+
+	m := NewMatcher(root)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"node_modules", true, true},
+		{"node_modules/lib/index.js", false, true},
+		{"web/node_modules/x.js", false, true},
+		{"debug.log", false, true},
+		{"important.log", false, false},
+		{"dist/app.js", false, true},
+		{"pkg/generated.go", false, true},
+		{"generated.go", false, false},
+		{"pkg/keep.log", false, false},
+		{"pkg/other.log", false, true},
+		{"pkg/fixtures/data.json", false, true},
+		{"fixtures/data.json", false, false},
+		{".", true, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_IgnoredParentCannotBeReincluded(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "build/\n!build/keep.txt\n")
+
+	m := NewMatcher(root)
+	if !m.Match("build/keep.txt", false) {
+		t.Error("expected file inside ignored directory to stay ignored")
+	}
+}
+
+func TestMatcher_NoIgnoreFiles(t *testing.T) {
+	m := NewMatcher(t.TempDir())
+	if m.Match("anything/at/all.go", false) {
+		t.Error("expected nothing to be ignored without ignore files")
+	}
+}
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/main.go", false},
+		{"**", "a/b/c", true},
+		{"**/x", "x", true},
+		{"a\\*b", "a*b", true},
+		{"a\\*b", "axb", false},
+		{"[abc", "[abc", true},
+	}
+
+	for _, tt := range tests {
+		re, err := CompileGlob(tt.glob)
+		if err != nil {
+			t.Fatalf("CompileGlob(%q) error: %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("CompileGlob(%q) on %q = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
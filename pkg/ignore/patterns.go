@@ -0,0 +1,62 @@
+package ignore
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Patterns is a compiled list of ExcludedPaths entries. Entries use
+// .gitignore syntax: a bare name such as "node_modules" or "*.key" matches
+// at any depth, a leading or embedded slash anchors the pattern to the
+// repository root, a trailing slash matches directories only, and "**"
+// spans any number of directories (e.g. "**/secrets/**").
+type Patterns struct {
+	rules []*Rule
+}
+
+// CompilePatterns parses ExcludedPaths entries; blank and invalid entries
+// are skipped
+func CompilePatterns(patterns []string) *Patterns {
+	p := &Patterns{}
+	for _, pattern := range patterns {
+		if rule, ok := ParseRule(pattern, ""); ok {
+			p.rules = append(p.rules, rule)
+		}
+	}
+	return p
+}
+
+// Match reports whether relPath (relative to the repository root, either
+// separator) or one of its parent directories is excluded. It returns the
+// matching pattern and whether the match was on a parent directory. Later
+// '!' patterns re-include paths excluded by earlier ones.
+func (p *Patterns) Match(relPath string, isDir bool) (pattern string, inParent bool, matched bool) {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == "." || relPath == "" || len(p.rules) == 0 {
+		return "", false, false
+	}
+
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if pattern, ok := p.matchOne(strings.Join(parts[:i], "/"), true); ok {
+			return pattern, true, true
+		}
+	}
+
+	pattern, matched = p.matchOne(relPath, isDir)
+	return pattern, false, matched
+}
+
+// matchOne applies every rule in order; the last matching rule wins
+func (p *Patterns) matchOne(relPath string, isDir bool) (string, bool) {
+	var last *Rule
+	for _, rule := range p.rules {
+		if rule.Match(relPath, isDir) {
+			last = rule
+		}
+	}
+	if last == nil || last.Negate {
+		return "", false
+	}
+	return last.Pattern, true
+}
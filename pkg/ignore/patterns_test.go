@@ -0,0 +1,142 @@
+package ignore
+
+import "testing"
+
+func TestPatterns_Match(t *testing.T) {
+	patterns := CompilePatterns([]string{
+		".git",
+		"*.key",
+		"node_modules",
+		"**/secrets/**",
+		"/build",
+		"config/local.yaml",
+		"docs/**/draft-*.md",
+		"tmp/",
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		isDir    bool
+		matched  bool
+		inParent bool
+		pattern  string
+	}{
+		{name: "exact name at root", path: ".git", matched: true, pattern: ".git"},
+		{name: "inside excluded dir", path: ".git/config", matched: true, inParent: true, pattern: ".git"},
+		{name: "extension at root", path: "server.key", matched: true, pattern: "*.key"},
+		{name: "extension nested", path: "certs/prod/server.key", matched: true, pattern: "*.key"},
+		{name: "bare name nested", path: "web/node_modules/react/index.js", matched: true, inParent: true, pattern: "node_modules"},
+		{name: "double star contents", path: "app/secrets/db.txt", matched: true, pattern: "**/secrets/**"},
+		{name: "double star at root", path: "secrets/db.txt", matched: true, pattern: "**/secrets/**"},
+		{name: "double star dir itself", path: "secrets", isDir: true, matched: false},
+		{name: "anchored at root", path: "build/out.bin", matched: true, inParent: true, pattern: "/build"},
+		{name: "anchored not nested", path: "src/build/out.bin", matched: false},
+		{name: "embedded slash anchored", path: "config/local.yaml", matched: true, pattern: "config/local.yaml"},
+		{name: "embedded slash not nested", path: "app/config/local.yaml", matched: false},
+		{name: "middle double star", path: "docs/a/b/draft-intro.md", matched: true, pattern: "docs/**/draft-*.md"},
+		{name: "middle double star zero dirs", path: "docs/draft-intro.md", matched: true, pattern: "docs/**/draft-*.md"},
+		{name: "dir only on file", path: "tmp", isDir: false, matched: false},
+		{name: "dir only on dir", path: "tmp", isDir: true, matched: true, pattern: "tmp/"},
+		{name: "dir only contents", path: "tmp/a.txt", matched: true, inParent: true, pattern: "tmp/"},
+		{name: "partial name", path: ".gitignore", matched: false},
+		{name: "unrelated", path: "main.go", matched: false},
+		{name: "root", path: ".", matched: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, inParent, matched := patterns.Match(tt.path, tt.isDir)
+			if matched != tt.matched {
+				t.Fatalf("Match(%q) matched = %v, want %v", tt.path, matched, tt.matched)
+			}
+			if inParent != tt.inParent {
+				t.Errorf("Match(%q) inParent = %v, want %v", tt.path, inParent, tt.inParent)
+			}
+			if pattern != tt.pattern {
+				t.Errorf("Match(%q) pattern = %q, want %q", tt.path, pattern, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestPatterns_Negation(t *testing.T) {
+	patterns := CompilePatterns([]string{"*.env", "!example.env"})
+
+	if _, _, matched := patterns.Match("prod.env", false); !matched {
+		t.Error("expected prod.env to be excluded")
+	}
+	if _, _, matched := patterns.Match("example.env", false); matched {
+		t.Error("expected example.env to be re-included")
+	}
+}
+
+func TestPatterns_Empty(t *testing.T) {
+	if _, _, matched := CompilePatterns(nil).Match("anything", false); matched {
+		t.Error("expected no match with no patterns")
+	}
+	if _, _, matched := CompilePatterns([]string{"", "  ", "# comment"}).Match("anything", false); matched {
+		t.Error("expected blank and comment entries to be skipped")
+	}
+}
+
+func TestPatterns_CaseHandling(t *testing.T) {
+	original := CaseInsensitive
+	defer func() { CaseInsensitive = original }()
+
+	CaseInsensitive = false
+	sensitive := CompilePatterns([]string{"Secrets/**", "*.KEY"})
+	if _, _, matched := sensitive.Match("secrets/a.txt", false); matched {
+		t.Error("expected case-sensitive match to fail on different case")
+	}
+	if _, _, matched := sensitive.Match("Secrets/a.txt", false); !matched {
+		t.Error("expected case-sensitive match on same case")
+	}
+
+	CaseInsensitive = true
+	insensitive := CompilePatterns([]string{"Secrets/**", "*.KEY"})
+	if _, _, matched := insensitive.Match("secrets/a.txt", false); !matched {
+		t.Error("expected case-insensitive match on different case")
+	}
+	if _, _, matched := insensitive.Match("certs/server.key", false); !matched {
+		t.Error("expected case-insensitive extension match")
+	}
+}
+
+func TestRule_CaseInsensitiveBase(t *testing.T) {
+	original := CaseInsensitive
+	defer func() { CaseInsensitive = original }()
+
+	CaseInsensitive = true
+	rule, ok := ParseRule("*.tmp", "Sub")
+	if !ok {
+		t.Fatal("ParseRule failed")
+	}
+	if !rule.Match("sub/x.TMP", false) {
+		t.Error("expected case-insensitive match across base and pattern")
+	}
+}
+
+func TestPatterns_Unicode(t *testing.T) {
+	patterns := CompilePatterns([]string{"机密/", "résumé-*.pdf", "?.txt"})
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		matched bool
+	}{
+		{"机密", true, true},
+		{"docs/机密/plan.md", false, true},
+		{"résumé-2024.pdf", false, true},
+		// The same name decomposed, as macOS may store it
+		{"re\u0301sume\u0301-2024.pdf", false, true},
+		{"resume-2024.pdf", false, false},
+		// ? matches one character, not one byte
+		{"文.txt", false, true},
+	}
+	for _, tt := range tests {
+		if _, _, matched := patterns.Match(tt.path, tt.isDir); matched != tt.matched {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, matched, tt.matched)
+		}
+	}
+}
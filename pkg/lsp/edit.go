@@ -0,0 +1,119 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextEdit replaces a range of a file with NewText
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit is a change to several files, as a rename answers with.
+// Servers give it as edits by URI, or as a list of document changes that
+// may also create, rename, or delete files.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes"`
+	DocumentChanges []struct {
+		Kind         string `json:"kind"` // create, rename, or delete; empty for edits
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Edits []TextEdit `json:"edits"`
+	} `json:"documentChanges"`
+}
+
+// Files returns the edits of w by file path. Changes other than edits to
+// files are refused, since the caller applies only edits.
+func (w WorkspaceEdit) Files() (map[string][]TextEdit, error) {
+	files := make(map[string][]TextEdit)
+	add := func(uri string, edits []TextEdit) error {
+		path := Path(uri)
+		if path == "" {
+			return fmt.Errorf("edit of %s, which is not a file", uri)
+		}
+		files[path] = append(files[path], edits...)
+		return nil
+	}
+	for uri, edits := range w.Changes {
+		if err := add(uri, edits); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range w.DocumentChanges {
+		if change.Kind != "" {
+			return nil, fmt.Errorf("the change would %s a file, which is not supported", change.Kind)
+		}
+		if err := add(change.TextDocument.URI, change.Edits); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// ApplyEdits returns text with edits made. Their ranges are of text as it
+// is, counting characters in UTF-16 code units as the protocol does, and
+// may not overlap.
+func ApplyEdits(text string, edits []TextEdit) (string, error) {
+	type span struct {
+		start, end int
+		newText    string
+	}
+	spans := make([]span, len(edits))
+	for i, edit := range edits {
+		start, err := Offset(text, edit.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := Offset(text, edit.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("edit ends before it starts at %d:%d", edit.Range.Start.Line+1, edit.Range.Start.Character+1)
+		}
+		spans[i] = span{start, end, edit.NewText}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			return "", fmt.Errorf("edits overlap at byte %d", s.start)
+		}
+		b.WriteString(text[last:s.start])
+		b.WriteString(s.newText)
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), nil
+}
+
+// Offset returns the byte offset of pos in text
+func Offset(text string, pos Position) (int, error) {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return 0, fmt.Errorf("line %d is past the end of the file", pos.Line+1)
+		}
+		offset += i + 1
+	}
+	for units := 0; units < pos.Character; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if size == 0 || r == '\n' || r == '\r' {
+			return 0, fmt.Errorf("character %d is past the end of line %d", pos.Character+1, pos.Line+1)
+		}
+		units++
+		if r >= 0x10000 {
+			units++
+		}
+		offset += size
+	}
+	return offset, nil
+}
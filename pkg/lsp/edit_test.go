@@ -0,0 +1,59 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyEdits(t *testing.T) {
+	edit := func(line, start, end int, text string) TextEdit {
+		return TextEdit{Range: Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: end}}, NewText: text}
+	}
+	text := "func NewSession() {}\n// 😀 NewSession\nx := NewSession()\n"
+	tests := []struct {
+		name  string
+		edits []TextEdit
+		want  string
+		ok    bool
+	}{
+		{"none", nil, text, true},
+		{"out of order", []TextEdit{edit(2, 5, 15, "NewRuntimeSession"), edit(0, 5, 15, "NewRuntimeSession")}, "func NewRuntimeSession() {}\n// 😀 NewSession\nx := NewRuntimeSession()\n", true},
+		// The emoji is two UTF-16 code units
+		{"past a surrogate pair", []TextEdit{edit(1, 6, 16, "Renamed")}, "func NewSession() {}\n// 😀 Renamed\nx := NewSession()\n", true},
+		{"insert at end", []TextEdit{edit(3, 0, 0, "y := 1\n")}, text + "y := 1\n", true},
+		{"overlapping", []TextEdit{edit(0, 5, 15, "A"), edit(0, 10, 12, "B")}, "", false},
+		{"past the line", []TextEdit{edit(0, 5, 40, "A")}, "", false},
+		{"past the file", []TextEdit{edit(9, 0, 0, "A")}, "", false},
+	}
+	for _, tt := range tests {
+		got, err := ApplyEdits(text, tt.edits)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("%s: ApplyEdits() = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestWorkspaceEdit_Files(t *testing.T) {
+	tests := map[string]int{
+		`{"changes":{"file:///a.go":[{"newText":"x"}],"file:///b.go":[{"newText":"y"}]}}`:           2,
+		`{"documentChanges":[{"textDocument":{"uri":"file:///a.go","version":3},"edits":[{},{}]}]}`: 1,
+		`{"documentChanges":[{"kind":"rename","oldUri":"file:///a.go","newUri":"file:///b.go"}]}`:   -1,
+		`{"changes":{"https://example.com/a.go":[{"newText":"x"}]}}`:                                -1,
+	}
+	for raw, want := range tests {
+		var w WorkspaceEdit
+		if err := json.Unmarshal([]byte(raw), &w); err != nil {
+			t.Fatal(err)
+		}
+		files, err := w.Files()
+		if want < 0 {
+			if err == nil {
+				t.Errorf("Files() of %s succeeded, want an error", raw)
+			}
+			continue
+		}
+		if err != nil || len(files) != want {
+			t.Errorf("Files() of %s = %v, %v; want %d files", raw, files, err, want)
+		}
+	}
+}
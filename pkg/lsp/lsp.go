@@ -0,0 +1,478 @@
+// Package lsp is a small client of the Language Server Protocol, enough to
+// ask a language server such as gopls where a symbol is defined, where it is
+// used, and how to rename it. The server runs as a child process speaking JSON-RPC over its
+// standard input and output, and keeps its index between requests.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Languages maps file extensions to the language identifiers servers know
+// them by
+var Languages = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "javascript",
+	".jsx":  "javascript",
+	".ts":   "typescript",
+	".tsx":  "typescript",
+	".rs":   "rust",
+	".java": "java",
+	".c":    "c",
+	".h":    "c",
+	".cc":   "cpp",
+	".cpp":  "cpp",
+	".hpp":  "cpp",
+	".rb":   "ruby",
+}
+
+// Position is a zero-based line and character offset in a file
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of a file
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a span of a file, which URI names
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// Symbol is a declaration found by a workspace symbol search
+type Symbol struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	ContainerName string   `json:"containerName"`
+	Location      Location `json:"location"`
+}
+
+// symbolKinds names the kinds of symbol the protocol numbers
+var symbolKinds = []string{"", "file", "module", "namespace", "package", "class", "method", "property", "field", "constructor", "enum", "interface", "function", "variable", "constant", "string", "number", "boolean", "array", "object", "key", "null", "enum member", "struct", "event", "operator", "type parameter"}
+
+// KindName returns the name of the kind of s, such as function or struct
+func (s Symbol) KindName() string {
+	if s.Kind > 0 && s.Kind < len(symbolKinds) {
+		return symbolKinds[s.Kind]
+	}
+	return "symbol"
+}
+
+// ErrClosed is returned by requests to a server that has exited
+var ErrClosed = errors.New("language server exited")
+
+// ResponseError is an error a server answered a request with
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// message is a JSON-RPC message read from the server: a response, or a
+// request or notification of its own
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+// Client is a connection to a running language server
+type Client struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *tailBuffer
+	root   string
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan message
+	done    chan struct{} // Closed once the server's output ends
+}
+
+// Start starts the language server command in root with the extra
+// environment env, and initializes it for the workspace root. options are
+// the server's initialization options, such as gopls settings; nil for
+// none. ctx bounds the initialization, not the server's life.
+func Start(ctx context.Context, command []string, root string, env []string, options any) (*Client, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no language server command")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  &tailBuffer{max: 4096},
+		root:    root,
+		pending: make(map[int]chan message),
+		done:    make(chan struct{}),
+	}
+	cmd.Stderr = c.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot start %s: %w", command[0], err)
+	}
+	go c.read(bufio.NewReader(stdout))
+
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"rootUri":   URI(root),
+		"workspaceFolders": []map[string]string{
+			{"uri": URI(root), "name": filepath.Base(root)},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"definition": map[string]any{"linkSupport": true},
+				"references": map[string]any{},
+				"rename":     map[string]any{},
+			},
+			"workspace": map[string]any{
+				"symbol":           map[string]any{},
+				"workspaceFolders": true,
+				"configuration":    true,
+			},
+		},
+		"initializationOptions": options,
+	}
+	if err := c.call(ctx, "initialize", params, nil); err != nil {
+		c.kill()
+		return nil, c.describe(fmt.Errorf("cannot initialize %s: %w", command[0], err))
+	}
+	if err := c.notify("initialized", map[string]any{}); err != nil {
+		c.kill()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Symbols searches the workspace for symbols matching query
+func (c *Client) Symbols(ctx context.Context, query string) ([]Symbol, error) {
+	var symbols []Symbol
+	err := c.call(ctx, "workspace/symbol", map[string]string{"query": query}, &symbols)
+	return symbols, err
+}
+
+// Definition returns where the symbol at pos in the file at path is defined
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	var raw json.RawMessage
+	if err := c.call(ctx, "textDocument/definition", positionParams(path, pos), &raw); err != nil {
+		return nil, err
+	}
+	return decodeLocations(raw)
+}
+
+// References returns where the symbol at pos in the file at path is used,
+// and where it is declared with includeDeclaration
+func (c *Client) References(ctx context.Context, path string, pos Position, includeDeclaration bool) ([]Location, error) {
+	params := positionParams(path, pos)
+	params["context"] = map[string]bool{"includeDeclaration": includeDeclaration}
+	var locations []Location
+	err := c.call(ctx, "textDocument/references", params, &locations)
+	return locations, err
+}
+
+// Rename returns the edits, by file path, that rename the symbol at pos in
+// the file at path to newName. The server makes no change itself.
+func (c *Client) Rename(ctx context.Context, path string, pos Position, newName string) (map[string][]TextEdit, error) {
+	params := positionParams(path, pos)
+	params["newName"] = newName
+	var edit WorkspaceEdit
+	if err := c.call(ctx, "textDocument/rename", params, &edit); err != nil {
+		return nil, err
+	}
+	return edit.Files()
+}
+
+// Open tells the server the file at path has text, for servers that only
+// answer about open files
+func (c *Client) Open(path, language, text string) error {
+	return c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": URI(path), "languageId": language, "version": 1, "text": text},
+	})
+}
+
+// CloseFile tells the server the file at path is no longer open, so it
+// reads it from disk again
+func (c *Client) CloseFile(path string) error {
+	return c.notify("textDocument/didClose", map[string]any{
+		"textDocument": map[string]string{"uri": URI(path)},
+	})
+}
+
+// Changed tells the server the files at paths changed on disk
+func (c *Client) Changed(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	changes := make([]map[string]any, len(paths))
+	for i, path := range paths {
+		changes[i] = map[string]any{"uri": URI(path), "type": 2}
+	}
+	return c.notify("workspace/didChangeWatchedFiles", map[string]any{"changes": changes})
+}
+
+// Close asks the server to shut down, and stops it if it has not within a
+// few seconds
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.call(ctx, "shutdown", nil, nil); err == nil {
+		c.notify("exit", nil)
+	}
+	c.stdin.Close()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+	}
+	return c.kill()
+}
+
+// kill stops the server and waits for it
+func (c *Client) kill() error {
+	c.cmd.Process.Kill()
+	c.cmd.Wait()
+	return nil
+}
+
+// describe adds what the server last wrote to stderr to err
+func (c *Client) describe(err error) error {
+	if detail := strings.TrimSpace(c.stderr.String()); detail != "" {
+		return fmt.Errorf("%w: %s", err, detail)
+	}
+	return err
+}
+
+// call sends a request and decodes its result into result, unless nil
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	reply := make(chan message, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		// Servers may stop the work; the answer is ignored either way
+		c.notify("$/cancelRequest", map[string]int{"id": id})
+		return ctx.Err()
+	case <-c.done:
+		return c.describe(ErrClosed)
+	case msg := <-reply:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	}
+}
+
+// notify sends a notification, which has no answer
+func (c *Client) notify(method string, params any) error {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	return c.write(msg)
+}
+
+// write sends msg with its Content-Length header
+func (c *Client) write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return c.describe(ErrClosed)
+	}
+	return nil
+}
+
+// read delivers the server's responses to the requests waiting for them
+// and answers its own requests, until its output ends
+func (c *Client) read(r *bufio.Reader) {
+	defer close(c.done)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			continue
+		}
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			c.answer(msg)
+		case msg.Method == "" && len(msg.ID) > 0:
+			id, err := strconv.Atoi(string(msg.ID))
+			if err != nil {
+				continue
+			}
+			c.mu.Lock()
+			reply := c.pending[id]
+			c.mu.Unlock()
+			if reply != nil {
+				reply <- msg
+			}
+		}
+		// Notifications, such as diagnostics and progress, are not needed
+	}
+}
+
+// answer replies to a request from the server. The client offers nothing,
+// so each gets an empty answer, which servers take as the defaults.
+func (c *Client) answer(msg message) {
+	var result any
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(msg.Params, &params)
+		result = make([]any, len(params.Items))
+	}
+	c.write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": result})
+}
+
+// readMessage reads the body of one message
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+// positionParams are the parameters of a request about pos in path
+func positionParams(path string, pos Position) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]string{"uri": URI(path)},
+		"position":     pos,
+	}
+}
+
+// decodeLocations decodes the answer to a definition request, which may be
+// a location, a list of them, or a list of location links
+func decodeLocations(raw json.RawMessage) ([]Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if raw[0] == '{' {
+		raw = append(append([]byte{'['}, raw...), ']')
+	}
+	var items []struct {
+		Location
+		TargetURI            string `json:"targetUri"`
+		TargetSelectionRange Range  `json:"targetSelectionRange"`
+	}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	locations := make([]Location, len(items))
+	for i, item := range items {
+		locations[i] = item.Location
+		if item.TargetURI != "" {
+			locations[i] = Location{URI: item.TargetURI, Range: item.TargetSelectionRange}
+		}
+	}
+	return locations, nil
+}
+
+// URI returns the file URI of path
+func URI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// Path returns the path a file URI names, or "" for other URIs
+func Path(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// tailBuffer keeps the last bytes written to it
+type tailBuffer struct {
+	mu   sync.Mutex
+	max  int
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.max {
+		b.data = b.data[len(b.data)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
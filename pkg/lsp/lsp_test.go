@@ -0,0 +1,168 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary stand in for a language server
+func TestMain(m *testing.M) {
+	if os.Getenv("LSP_TEST_SERVER") == "1" {
+		fakeServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeServer answers requests on stdin as a language server of a workspace
+// with one file, a.go, would
+func fakeServer() {
+	r := bufio.NewReader(os.Stdin)
+	send := func(msg any) {
+		body, _ := json.Marshal(msg)
+		fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	root, _ := os.Getwd()
+	file := URI(filepath.Join(root, "a.go"))
+	at := func(line, char int) map[string]any {
+		return map[string]any{"start": map[string]int{"line": line, "character": char}, "end": map[string]int{"line": line, "character": char + 3}}
+	}
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		var msg struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.Unmarshal(body, &msg)
+		var result any
+		switch msg.Method {
+		case "initialize":
+			// Servers ask for settings while starting up; the answer must
+			// come before initialization finishes
+			send(map[string]any{"jsonrpc": "2.0", "id": 99, "method": "workspace/configuration", "params": map[string]any{"items": []any{map[string]string{"section": "gopls"}}}})
+			if reply, err := readMessage(r); err != nil || string(reply) != `{"id":99,"jsonrpc":"2.0","result":[null]}` {
+				send(map[string]any{"jsonrpc": "2.0", "id": *msg.ID, "error": map[string]any{"code": -1, "message": "bad configuration reply: " + string(reply)}})
+				continue
+			}
+			send(map[string]any{"jsonrpc": "2.0", "method": "window/logMessage", "params": map[string]any{"type": 3, "message": "loaded"}})
+			result = map[string]any{"capabilities": map[string]any{}}
+		case "workspace/symbol":
+			result = []any{map[string]any{"name": "example.com/m.Server.Close", "kind": 6, "location": map[string]any{"uri": file, "range": at(4, 17)}}}
+		case "textDocument/definition":
+			result = []any{map[string]any{"targetUri": file, "targetRange": at(4, 0), "targetSelectionRange": at(4, 17)}}
+		case "textDocument/references":
+			result = []any{map[string]any{"uri": file, "range": at(9, 3)}, map[string]any{"uri": file, "range": at(12, 3)}}
+		case "textDocument/rename":
+			result = map[string]any{"changes": map[string]any{file: []any{map[string]any{"range": at(4, 17), "newText": "Stop"}, map[string]any{"range": at(9, 3), "newText": "Stop"}}}}
+		case "shutdown":
+		case "exit":
+			return
+		default:
+			if msg.ID != nil {
+				send(map[string]any{"jsonrpc": "2.0", "id": *msg.ID, "error": map[string]any{"code": -32601, "message": "method not found: " + msg.Method}})
+			}
+			continue
+		}
+		if msg.ID != nil {
+			send(map[string]any{"jsonrpc": "2.0", "id": *msg.ID, "result": result})
+		}
+	}
+}
+
+func startFake(t *testing.T) (*Client, string) {
+	t.Helper()
+	root := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Start(ctx, []string{exe}, root, []string{"LSP_TEST_SERVER=1"}, nil)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, root
+}
+
+func TestClient(t *testing.T) {
+	c, root := startFake(t)
+	ctx := context.Background()
+	file := filepath.Join(root, "a.go")
+
+	symbols, err := c.Symbols(ctx, "Close")
+	if err != nil || len(symbols) != 1 {
+		t.Fatalf("Symbols() = %v, %v", symbols, err)
+	}
+	if s := symbols[0]; s.Name != "example.com/m.Server.Close" || s.KindName() != "method" || Path(s.Location.URI) != file || s.Location.Range.Start.Line != 4 {
+		t.Errorf("Symbols() = %+v", s)
+	}
+
+	defs, err := c.Definition(ctx, file, Position{Line: 9, Character: 5})
+	if err != nil || len(defs) != 1 || defs[0].Range.Start != (Position{Line: 4, Character: 17}) {
+		t.Errorf("Definition() = %+v, %v; want the selection range of the link", defs, err)
+	}
+
+	refs, err := c.References(ctx, file, Position{Line: 4, Character: 17}, false)
+	if err != nil || len(refs) != 2 || refs[1].Range.Start.Line != 12 {
+		t.Errorf("References() = %+v, %v", refs, err)
+	}
+
+	edits, err := c.Rename(ctx, file, Position{Line: 4, Character: 17}, "Stop")
+	if err != nil || len(edits) != 1 || len(edits[file]) != 2 || edits[file][0].NewText != "Stop" {
+		t.Errorf("Rename() = %+v, %v", edits, err)
+	}
+
+	if err := c.call(ctx, "textDocument/hover", positionParams(file, Position{}), nil); err == nil {
+		t.Error("call() of an unknown method succeeded")
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := c.Symbols(ctx, "Close"); err == nil {
+		t.Error("Symbols() after Close() succeeded")
+	}
+}
+
+func TestStart_NotAServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := Start(ctx, []string{"sh", "-c", "echo not a server >&2"}, t.TempDir(), nil, nil); err == nil {
+		t.Error("Start() succeeded with a command that is not a language server")
+	}
+}
+
+func TestDecodeLocations(t *testing.T) {
+	tests := map[string]int{
+		`null`: 0,
+		`{"uri":"file:///a.go","range":{"start":{"line":1,"character":2},"end":{"line":1,"character":3}}}`: 1,
+		`[{"uri":"file:///a.go"},{"uri":"file:///b.go"}]`:                                                  2,
+	}
+	for raw, want := range tests {
+		locations, err := decodeLocations(json.RawMessage(raw))
+		if err != nil || len(locations) != want {
+			t.Errorf("decodeLocations(%s) = %v, %v; want %d", raw, locations, err, want)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	path := "/repo/dir with space/a.go"
+	if got := Path(URI(path)); got != path {
+		t.Errorf("Path(URI(%q)) = %q", path, got)
+	}
+	if got := Path("https://example.com/a.go"); got != "" {
+		t.Errorf("Path() of an https URI = %q, want empty", got)
+	}
+}
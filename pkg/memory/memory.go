@@ -0,0 +1,104 @@
+// Package memory keeps a session memory document: a Markdown file in the
+// repository that the runtime appends the files each session changed and
+// the notes the LLM records to, so a long or resumed session can read back
+// what was done and decided. Each session's entries follow a heading with
+// its ID and the time of its first entry.
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// title heads a new memory document
+const title = "# Session memory\n\nFiles changed and notes recorded by llm-runtime sessions, oldest first.\n"
+
+// File is a memory document, appended to by one session
+type File struct {
+	path    string
+	session string
+	now     func() time.Time
+
+	mu      sync.Mutex
+	started bool // Whether the session's heading was written
+}
+
+// Open returns the memory document at path for the session with ID
+// session, timestamping entries with now. Nothing is written until the
+// first entry.
+func Open(path, session string, now func() time.Time) *File {
+	return &File{path: path, session: session, now: now}
+}
+
+// Path returns where the document is kept
+func (f *File) Path() string {
+	return f.path
+}
+
+// Append records an entry, such as "wrote main.go" or a note, under the
+// session's heading, writing the heading first if this is the session's
+// first entry. Lines after the first are indented under it.
+func (f *File) Append(entry string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	var b strings.Builder
+	if !f.started {
+		if info, err := os.Stat(f.path); err != nil || info.Size() == 0 {
+			b.WriteString(title)
+		}
+		fmt.Fprintf(&b, "\n## Session %s, %s\n\n", f.session, now.Format("2006-01-02 15:04"))
+	}
+	lines := strings.Split(strings.TrimSpace(entry), "\n")
+	fmt.Fprintf(&b, "- %s %s\n", now.Format("15:04"), lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(b.String()); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	f.started = true
+	return nil
+}
+
+// Read returns the document, or its last maxBytes from the start of a line
+// with a note of what was left out when it is longer. A document not yet
+// written reads as empty.
+func (f *File) Read(maxBytes int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	text := string(data)
+	if maxBytes <= 0 || len(text) <= maxBytes {
+		return text, nil
+	}
+	tail := text[len(text)-maxBytes:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return fmt.Sprintf("[%d earlier bytes omitted]\n%s", len(text)-len(tail), tail), nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".llm-tools", "memory.md")
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	if text, err := Open(path, "s1", clock).Read(0); err != nil || text != "" {
+		t.Fatalf("Read() before any entry = %q, %v", text, err)
+	}
+
+	first := Open(path, "s1", clock)
+	if err := first.Append("wrote main.go (created)"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(5 * time.Minute)
+	if err := first.Append("note: keep the v1 API\nuntil callers move"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Open(path, "s2", clock).Append("appended to log.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := title +
+		"\n## Session s1, 2026-03-01 09:30\n\n" +
+		"- 09:30 wrote main.go (created)\n" +
+		"- 09:35 note: keep the v1 API\n" +
+		"  until callers move\n" +
+		"\n## Session s2, 2026-03-01 09:35\n\n" +
+		"- 09:35 appended to log.txt\n"
+	data, _ := os.ReadFile(path)
+	if string(data) != want {
+		t.Errorf("document =\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestRead_Tail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.md")
+	os.WriteFile(path, []byte("line one\nline two\nline three\n"), 0644)
+
+	text, err := Open(path, "s1", time.Now).Read(14)
+	if err != nil || text != "[18 earlier bytes omitted]\nline three\n" {
+		t.Errorf("Read(14) = %q, %v", text, err)
+	}
+	if text, _ := Open(path, "s1", time.Now).Read(0); !strings.HasPrefix(text, "line one") {
+		t.Errorf("Read(0) = %q, want all of it", text)
+	}
+}
@@ -0,0 +1,148 @@
+// Package metrics exposes Prometheus counters and histograms for
+// operational dashboards: commands run, exec and search latency, container
+// launches, bytes moved, and audit log failures.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+const namespace = "llm_runtime"
+
+// Registry holds every llm-runtime metric along with the Go runtime and
+// process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	// Commands counts executed commands by type and status (ok, failed,
+	// or skipped). Steps of pipes and guard blocks are counted too.
+	Commands = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commands_total",
+		Help:      "Commands executed, by type and status.",
+	}, []string{"type", "status"})
+
+	// ExecDuration observes how long exec commands take, including the
+	// container run
+	ExecDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "exec_duration_seconds",
+		Help:      "Duration of exec commands.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	})
+
+	// ContainerLaunch observes the time to create and start a container,
+	// by kind: exec, io, or pool
+	ContainerLaunch = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "container_launch_seconds",
+		Help:      "Time to create and start a container, by kind.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"kind"})
+
+	// BytesRead counts the bytes of files read by open commands
+	BytesRead = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "read_bytes_total",
+		Help:      "Bytes of files read.",
+	})
+
+	// BytesWritten counts the bytes written by write commands
+	BytesWritten = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "written_bytes_total",
+		Help:      "Bytes of files written.",
+	})
+
+	// SearchDuration observes how long semantic search queries take
+	SearchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "search_duration_seconds",
+		Help:      "Duration of search queries.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// SessionCommands is the number of commands the session has run, by
+	// outcome (ok, failed, or skipped). Unlike commands_total it leaves out
+	// pipe and guard steps.
+	SessionCommands = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "session_commands",
+		Help:      "Commands run this session, by outcome.",
+	}, []string{"outcome"})
+
+	// AuditErrors counts audit log entries that could not be written
+	AuditErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_errors_total",
+		Help:      "Audit log entries that failed to write.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		Commands, ExecDuration, ContainerLaunch, BytesRead, BytesWritten, SearchDuration, SessionCommands, AuditErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveCommand counts a command result. Pipe and guard steps record
+// themselves as they run, so only the result itself is counted.
+func ObserveCommand(result scanner.ExecutionResult) {
+	status := "ok"
+	switch {
+	case result.Action == "SKIPPED":
+		status = "skipped"
+	case !result.Success:
+		status = "failed"
+	}
+	Commands.WithLabelValues(result.Command.Type, status).Inc()
+
+	if result.Command.Type == "exec" && result.Action != "SKIPPED" {
+		ExecDuration.Observe(result.ExecutionTime.Seconds())
+	}
+	if result.BytesWritten > 0 {
+		BytesWritten.Add(float64(result.BytesWritten))
+	}
+}
+
+// ObserveLaunch records a container launch of kind that began at start
+func ObserveLaunch(kind string, start time.Time) {
+	ContainerLaunch.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Serve starts serving /metrics on addr, such as ":9090", in the
+// background. Close the returned server to stop it.
+func Serve(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serve metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Warning: metrics server stopped: %v\n", err)
+		}
+	}()
+	return server, nil
+}
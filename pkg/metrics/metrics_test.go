@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestObserveCommand(t *testing.T) {
+	okBefore := testutil.ToFloat64(Commands.WithLabelValues("write", "ok"))
+	failedBefore := testutil.ToFloat64(Commands.WithLabelValues("exec", "failed"))
+	skippedBefore := testutil.ToFloat64(Commands.WithLabelValues("write", "skipped"))
+	writtenBefore := testutil.ToFloat64(BytesWritten)
+
+	ObserveCommand(scanner.ExecutionResult{Command: scanner.Command{Type: "write"}, Success: true, BytesWritten: 42})
+	ObserveCommand(scanner.ExecutionResult{Command: scanner.Command{Type: "exec"}, ExecutionTime: time.Second})
+	ObserveCommand(scanner.ExecutionResult{Command: scanner.Command{Type: "write"}, Action: "SKIPPED"})
+
+	if got := testutil.ToFloat64(Commands.WithLabelValues("write", "ok")) - okBefore; got != 1 {
+		t.Errorf("write ok count increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(Commands.WithLabelValues("exec", "failed")) - failedBefore; got != 1 {
+		t.Errorf("exec failed count increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(Commands.WithLabelValues("write", "skipped")) - skippedBefore; got != 1 {
+		t.Errorf("write skipped count increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(BytesWritten) - writtenBefore; got != 42 {
+		t.Errorf("bytes written increased by %v, want 42", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ObserveLaunch("exec", time.Now())
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, name := range []string{
+		"llm_runtime_commands_total",
+		"llm_runtime_container_launch_seconds_bucket",
+		"llm_runtime_read_bytes_total",
+		"llm_runtime_audit_errors_total",
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), name) {
+			t.Errorf("metrics output is missing %s", name)
+		}
+	}
+}
+
+func TestServe_InvalidAddress(t *testing.T) {
+	if _, err := Serve("not-an-address"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
@@ -0,0 +1,366 @@
+// Package notify posts notable events of a session, such as a command
+// refused by policy or the end of the session, to Slack, Discord, or a
+// webhook. Messages are sent in the background, rendered from a template,
+// and rate limited for each target so a runaway session cannot flood a
+// channel.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Events a notifier can be configured to post
+const (
+	SessionStart   = "session_start"   // The session started
+	SessionEnd     = "session_end"     // The session ended
+	Denied         = "denied"          // A command was refused by policy
+	ExecFailed     = "exec_failed"     // An exec command failed or timed out
+	SensitiveWrite = "sensitive_write" // A write or append changed a file matching a sensitive path
+)
+
+// Events lists every event, in the order they are documented
+var Events = []string{SessionStart, SessionEnd, Denied, ExecFailed, SensitiveWrite}
+
+// deniedCodes are the error codes of commands refused by policy rather
+// than failing
+var deniedCodes = map[errors.Code]bool{
+	errors.PathSecurity:    true,
+	errors.ExtensionDenied: true,
+	errors.ModeDenied:      true,
+	errors.AppendOnly:      true,
+	errors.ExecValidation:  true,
+	errors.FetchDenied:     true,
+	errors.SQLDenied:       true,
+	errors.GitDenied:       true,
+	errors.HookDenied:      true,
+}
+
+// defaultTemplates render the message of each event without a template
+// of its target's own
+var defaultTemplates = map[string]string{
+	SessionStart:   `llm-runtime session {{.Session}} started in {{.Repository}}`,
+	SessionEnd:     `llm-runtime session {{.Session}} ended after {{.Duration}}: {{.Commands}} commands, {{.Failed}} failed`,
+	Denied:         `llm-runtime session {{.Session}} was denied <{{.Command}} {{.Argument}}>: {{.Error}}`,
+	ExecFailed:     `llm-runtime session {{.Session}}: <exec {{.Argument}}> failed with exit code {{.ExitCode}}: {{.Error}}`,
+	SensitiveWrite: `llm-runtime session {{.Session}} changed {{.Argument}} with <{{.Command}}>`,
+}
+
+// maxError is the most of a command's error a notification carries
+const maxError = 300
+
+// maxDiscord is the longest message Discord accepts
+const maxDiscord = 2000
+
+// Notification is an event as its template sees it and a webhook
+// receives it
+type Notification struct {
+	Event      string    `json:"event"`
+	Session    string    `json:"session"`
+	Repository string    `json:"repository"`
+	Time       time.Time `json:"time"`
+	Command    string    `json:"command,omitempty"`
+	Argument   string    `json:"argument,omitempty"`
+	Error      string    `json:"error,omitempty"` // First line of the command's error
+	ExitCode   int       `json:"exit_code,omitempty"`
+	Commands   int       `json:"commands,omitempty"` // Commands the session ran, at session_end
+	Failed     int       `json:"failed,omitempty"`   // Of those, how many failed
+	Duration   string    `json:"duration,omitempty"` // How long the session ran, at session_end
+
+	Message    string `json:"message"`              // Rendered from the template
+	Suppressed int    `json:"suppressed,omitempty"` // Notifications dropped by the rate limit since the last one sent
+}
+
+// Validate checks the configured targets, with their URLs resolved
+func Validate(targets []config.NotifierConfig) error {
+	for i, t := range targets {
+		switch t.Type {
+		case "slack", "discord", "webhook":
+		default:
+			return fmt.Errorf("notifications.targets[%d]: type must be slack, discord, or webhook, not %q", i, t.Type)
+		}
+		if !strings.HasPrefix(t.URL, "https://") && !strings.HasPrefix(t.URL, "http://") {
+			return fmt.Errorf("notifications.targets[%d]: url must be http or https", i)
+		}
+		for _, event := range t.Events {
+			if _, ok := defaultTemplates[event]; !ok {
+				return fmt.Errorf("notifications.targets[%d]: unknown event %q (want one of %s)", i, event, strings.Join(Events, ", "))
+			}
+		}
+		if t.Template != "" {
+			if _, err := template.New("message").Parse(t.Template); err != nil {
+				return fmt.Errorf("notifications.targets[%d]: invalid template: %w", i, err)
+			}
+		}
+		if t.RateLimit < 0 {
+			return fmt.Errorf("notifications.targets[%d]: rate_limit cannot be negative", i)
+		}
+	}
+	return nil
+}
+
+// Notifier posts a session's events to its targets
+type Notifier struct {
+	targets    []*target
+	sensitive  *ignore.Patterns // Nil for no sensitive paths
+	session    string
+	repository string
+	auditLog   func(cmd, arg string, success bool, errMsg string) // Records failed posts; may be nil
+	client     *http.Client
+	now        func() time.Time
+	wg         sync.WaitGroup // Posts in flight
+}
+
+// target is a configured target and its rate limit
+type target struct {
+	config.NotifierConfig
+	tmpl   *template.Template // Nil to use the event's default
+	events map[string]bool    // Nil for every event
+
+	mu         sync.Mutex
+	sent       []time.Time // Times of the messages sent in the last minute
+	suppressed int         // Messages dropped since the last one sent
+}
+
+// New returns a notifier posting the events of session to cfg's
+// notification targets. Posts that fail are recorded in auditLog.
+func New(cfg *config.Config, session string, auditLog func(cmd, arg string, success bool, errMsg string)) (*Notifier, error) {
+	if err := Validate(cfg.Notifiers); err != nil {
+		return nil, err
+	}
+	n := &Notifier{
+		session:    session,
+		repository: cfg.RepositoryRoot,
+		auditLog:   auditLog,
+		client:     &http.Client{Timeout: config.DefaultNotifyTimeout},
+		now:        time.Now,
+	}
+	if len(cfg.SensitivePaths) > 0 {
+		n.sensitive = ignore.CompilePatterns(cfg.SensitivePaths)
+	}
+	for _, c := range cfg.Notifiers {
+		t := &target{NotifierConfig: c}
+		if c.Template != "" {
+			t.tmpl = template.Must(template.New("message").Parse(c.Template))
+		}
+		if len(c.Events) > 0 {
+			t.events = map[string]bool{}
+			for _, event := range c.Events {
+				t.events[event] = true
+			}
+		}
+		if t.RateLimit == 0 {
+			t.RateLimit = config.DefaultNotifyRateLimit
+		}
+		n.targets = append(n.targets, t)
+	}
+	return n, nil
+}
+
+// SessionStart posts the start of the session
+func (n *Notifier) SessionStart() {
+	n.post(Notification{Event: SessionStart})
+}
+
+// SessionEnd posts the end of the session, which ran commands commands,
+// failed of them failing, in elapsed
+func (n *Notifier) SessionEnd(commands, failed int, elapsed time.Duration) {
+	n.post(Notification{
+		Event:    SessionEnd,
+		Commands: commands,
+		Failed:   failed,
+		Duration: elapsed.Round(time.Second).String(),
+	})
+}
+
+// Command posts the event result is, if any: a command refused by policy,
+// a failed exec, or a change to a sensitive file
+func (n *Notifier) Command(result scanner.ExecutionResult) {
+	cmd := result.Command
+	note := Notification{Command: cmd.Type, Argument: cmd.Argument}
+	code := errors.CodeOf(result.Error)
+	switch {
+	case deniedCodes[code]:
+		note.Event = Denied
+	case cmd.Type == "exec" && !result.Success && code != errors.ExecInterrupted:
+		note.Event = ExecFailed
+		note.ExitCode = result.ExitCode
+	case (cmd.Type == "write" || cmd.Type == "append") && result.Success && result.Action != "UNCHANGED" && n.isSensitive(cmd.Argument):
+		note.Event = SensitiveWrite
+	default:
+		return
+	}
+	if result.Error != nil {
+		note.Error = firstLine(result.Error.Error(), maxError)
+	}
+	n.post(note)
+}
+
+// Close waits for the posts in flight, or until ctx is done
+func (n *Notifier) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notifications still sending: %w", ctx.Err())
+	}
+}
+
+// isSensitive reports whether path, as a command names it, matches a
+// sensitive path
+func (n *Notifier) isSensitive(path string) bool {
+	if n.sensitive == nil {
+		return false
+	}
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(n.repository, path)
+		if err != nil {
+			return false
+		}
+		path = rel
+	}
+	_, _, matched := n.sensitive.Match(filepath.Clean(path), false)
+	return matched
+}
+
+// post sends note to each target taking its event, in the background
+func (n *Notifier) post(note Notification) {
+	note.Session = n.session
+	note.Repository = n.repository
+	note.Time = n.now()
+	for _, t := range n.targets {
+		if t.events != nil && !t.events[note.Event] {
+			continue
+		}
+		suppressed, ok := t.allow(note.Time)
+		if !ok {
+			continue
+		}
+		message, err := t.render(note)
+		if err != nil {
+			n.failed(note.Event, t, err)
+			continue
+		}
+		note := note
+		note.Message = message
+		note.Suppressed = suppressed
+		n.wg.Add(1)
+		go func(t *target) {
+			defer n.wg.Done()
+			if err := n.send(t, note); err != nil {
+				n.failed(note.Event, t, err)
+			}
+		}(t)
+	}
+}
+
+// allow reports whether t may send a message at now under its rate limit,
+// and how many it dropped since the last it sent
+func (t *target) allow(now time.Time) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	recent := t.sent[:0]
+	for _, sent := range t.sent {
+		if now.Sub(sent) < time.Minute {
+			recent = append(recent, sent)
+		}
+	}
+	t.sent = recent
+	if len(t.sent) >= t.RateLimit {
+		t.suppressed++
+		return 0, false
+	}
+	t.sent = append(t.sent, now)
+	suppressed := t.suppressed
+	t.suppressed = 0
+	return suppressed, true
+}
+
+// render returns the message of note for t
+func (t *target) render(note Notification) (string, error) {
+	tmpl := t.tmpl
+	if tmpl == nil {
+		tmpl = template.Must(template.New(note.Event).Parse(defaultTemplates[note.Event]))
+	}
+	var message bytes.Buffer
+	if err := tmpl.Execute(&message, note); err != nil {
+		return "", err
+	}
+	return message.String(), nil
+}
+
+// send posts note to t in the form its type takes
+func (n *Notifier) send(t *target, note Notification) error {
+	text := note.Message
+	if note.Suppressed > 0 {
+		text += fmt.Sprintf("\n(%d more notifications were dropped by the rate limit)", note.Suppressed)
+	}
+
+	var payload any
+	switch t.Type {
+	case "slack":
+		payload = map[string]string{"text": text}
+	case "discord":
+		if len(text) > maxDiscord {
+			text = text[:maxDiscord]
+		}
+		payload = map[string]string{"content": text}
+	default:
+		payload = note
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(t.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// Without the URL, which *url.Error would repeat
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+// failed records that posting event to t failed. The URL is left out,
+// since webhook URLs are themselves credentials.
+func (n *Notifier) failed(event string, t *target, err error) {
+	if n.auditLog != nil {
+		n.auditLog("notify", t.Type+":"+event, false, config.RedactSecrets(err.Error()))
+	}
+}
+
+// firstLine returns the first line of s, cut to max bytes
+func firstLine(s string, max int) string {
+	line, _, _ := strings.Cut(s, "\n")
+	if len(line) > max {
+		line = line[:max] + "..."
+	}
+	return line
+}
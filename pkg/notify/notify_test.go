@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// receiver records the JSON bodies posted to it
+type receiver struct {
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body map[string]any
+	json.NewDecoder(req.Body).Decode(&body)
+	r.mu.Lock()
+	r.bodies = append(r.bodies, body)
+	r.mu.Unlock()
+}
+
+func newNotifier(t *testing.T, targets ...config.NotifierConfig) *Notifier {
+	t.Helper()
+	cfg := &config.Config{RepositoryRoot: "/repo", Notifiers: targets, SensitivePaths: []string{".github/workflows/", "*.tf"}}
+	n, err := New(cfg, "s1", nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return n
+}
+
+func TestNotifier_Command(t *testing.T) {
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	n := newNotifier(t, config.NotifierConfig{Type: "webhook", URL: server.URL})
+
+	n.Command(scanner.ExecutionResult{Command: scanner.Command{Type: "exec", Argument: "rm -rf /"}, Error: errors.New(errors.ExecValidation, "rm not allowed")})
+	n.Command(scanner.ExecutionResult{Command: scanner.Command{Type: "exec", Argument: "go test"}, ExitCode: 1, Error: errors.New(errors.ExecFailed, "exit status 1\nFAIL pkg")})
+	n.Command(scanner.ExecutionResult{Command: scanner.Command{Type: "write", Argument: "infra/main.tf"}, Success: true, Action: "UPDATED"})
+	// Not notable
+	n.Command(scanner.ExecutionResult{Command: scanner.Command{Type: "write", Argument: "main.go"}, Success: true, Action: "UPDATED"})
+	n.Command(scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: "x.go"}, Error: errors.New(errors.FileNotFound, "x.go")})
+	n.Command(scanner.ExecutionResult{Command: scanner.Command{Type: "write", Argument: "infra/main.tf"}, Success: true, Action: "UNCHANGED"})
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	got := map[string]map[string]any{}
+	for _, body := range recv.bodies {
+		got[body["event"].(string)] = body
+	}
+	if len(recv.bodies) != 3 || len(got) != 3 {
+		t.Fatalf("posted %v, want denied, exec_failed, and sensitive_write", recv.bodies)
+	}
+	if msg := got[Denied]["message"]; msg != "llm-runtime session s1 was denied <exec rm -rf />: EXEC_VALIDATION: rm not allowed" {
+		t.Errorf("denied message = %q", msg)
+	}
+	if got[ExecFailed]["error"] != "EXEC_FAILED: exit status 1" || got[ExecFailed]["exit_code"] != 1.0 {
+		t.Errorf("exec_failed = %v", got[ExecFailed])
+	}
+	if got[SensitiveWrite]["argument"] != "infra/main.tf" {
+		t.Errorf("sensitive_write = %v", got[SensitiveWrite])
+	}
+}
+
+func TestNotifier_SlackTemplateAndRateLimit(t *testing.T) {
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	n := newNotifier(t, config.NotifierConfig{
+		Type:      "slack",
+		URL:       server.URL,
+		Events:    []string{SessionEnd, Denied},
+		Template:  "{{.Event}} {{.Argument}}",
+		RateLimit: 2,
+	})
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return clock }
+
+	n.SessionStart() // Not one of the target's events
+	for _, arg := range []string{"a", "b", "c", "d"} {
+		n.Command(scanner.ExecutionResult{Command: scanner.Command{Type: "open", Argument: arg}, Error: errors.New(errors.PathSecurity, "excluded")})
+	}
+	n.Close(context.Background())
+	clock = clock.Add(time.Minute)
+	n.SessionEnd(4, 4, time.Minute)
+	n.Close(context.Background())
+
+	var texts []string
+	for _, body := range recv.bodies {
+		texts = append(texts, body["text"].(string))
+	}
+	want := []string{"denied a", "denied b", "session_end \n(2 more notifications were dropped by the rate limit)"}
+	if len(texts) != len(want) {
+		t.Fatalf("posted %q, want %q", texts, want)
+	}
+	// The first two may arrive in either order
+	if !(texts[0] == want[0] && texts[1] == want[1] || texts[0] == want[1] && texts[1] == want[0]) || texts[2] != want[2] {
+		t.Errorf("posted %q, want %q", texts, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, bad := range []config.NotifierConfig{
+		{Type: "teams", URL: "https://example.com"},
+		{Type: "slack", URL: "env:SLACK_WEBHOOK"},
+		{Type: "slack", URL: "https://example.com", Events: []string{"write"}},
+		{Type: "slack", URL: "https://example.com", Template: "{{.Event"},
+		{Type: "slack", URL: "https://example.com", RateLimit: -1},
+	} {
+		if err := Validate([]config.NotifierConfig{bad}); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", bad)
+		}
+	}
+	ok := config.NotifierConfig{Type: "discord", URL: "https://discord.com/api/webhooks/1/x", Events: []string{Denied}}
+	if err := Validate([]config.NotifierConfig{ok}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
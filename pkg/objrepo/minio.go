@@ -0,0 +1,83 @@
+package objrepo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Endpoints of the stores the schemes name. Google Cloud Storage is used
+// through its S3-compatible XML API.
+const (
+	s3Endpoint  = "s3.amazonaws.com"
+	gcsEndpoint = "storage.googleapis.com"
+)
+
+// minioStore is a Store backed by an S3-compatible API
+type minioStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewStore connects to the bucket of loc: on AWS S3 for s3://, on Google
+// Cloud Storage for gs://, or on endpoint, such as http://localhost:9000
+// for a MinIO server, when it is set. Credentials come from
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, which for Google Cloud
+// Storage are an HMAC key, from the AWS credentials file, or from the
+// instance's IAM role.
+func NewStore(loc Location, endpoint string) (Store, error) {
+	host, secure := s3Endpoint, true
+	if loc.Scheme == "gs" {
+		host = gcsEndpoint
+	}
+	if endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid object store endpoint %q", endpoint)
+		}
+		host, secure = u.Host, u.Scheme != "http"
+	}
+
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.FileAWSCredentials{},
+		&credentials.IAM{},
+	})
+	client, err := minio.New(host, &minio.Options{Creds: creds, Secure: secure})
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", host, err)
+	}
+	return &minioStore{client: client, bucket: loc.Bucket}, nil
+}
+
+func (s *minioStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+		objects = append(objects, Object{Key: info.Key, Size: info.Size, ETag: strings.Trim(info.ETag, `"`)})
+	}
+	return objects, nil
+}
+
+func (s *minioStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *minioStore) Put(ctx context.Context, key string, r io.Reader, size int64) (Object, error) {
+	info, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{})
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Key: key, Size: info.Size, ETag: strings.Trim(info.ETag, `"`)}, nil
+}
+
+func (s *minioStore) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
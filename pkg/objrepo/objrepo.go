@@ -0,0 +1,359 @@
+// Package objrepo lets the runtime work on an object-store prefix, such as
+// an artifact bundle or dataset in S3 or Google Cloud Storage, as if it
+// were a local checkout. The prefix is copied into a local cache directory
+// that serves as the repository root, and the files changed in it can be
+// written back to the prefix, or to another prefix, when the session ends.
+package objrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MetaDir holds the manifest of a materialized prefix. It is the runtime's
+// own directory, excluded from commands by default, so objects under it
+// are neither downloaded nor written back.
+const MetaDir = ".llm-tools"
+
+// manifestFile records, under MetaDir, what was downloaded
+const manifestFile = "objects.json"
+
+// Location is a prefix in an object store bucket
+type Location struct {
+	Scheme string // "s3" or "gs"
+	Bucket string
+	Prefix string // Empty for the whole bucket, otherwise ending in "/"
+}
+
+// ParseURL parses an s3://bucket/prefix or gs://bucket/prefix URL
+func ParseURL(raw string) (Location, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid object store URL %q: %w", raw, err)
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return Location{}, fmt.Errorf("invalid object store URL %q: scheme must be s3 or gs", raw)
+	}
+	if u.Host == "" {
+		return Location{}, fmt.Errorf("invalid object store URL %q: no bucket", raw)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return Location{Scheme: u.Scheme, Bucket: u.Host, Prefix: prefix}, nil
+}
+
+// IsURL reports whether root names an object store prefix rather than a
+// local directory
+func IsURL(root string) bool {
+	return strings.HasPrefix(root, "s3://") || strings.HasPrefix(root, "gs://")
+}
+
+func (l Location) String() string {
+	return l.Scheme + "://" + l.Bucket + "/" + l.Prefix
+}
+
+// Object describes a stored object
+type Object struct {
+	Key  string
+	Size int64
+	ETag string // Changes whenever the content does
+}
+
+// Store reads and writes the objects of one bucket
+type Store interface {
+	List(ctx context.Context, prefix string) ([]Object, error) // Every object under prefix, at any depth
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Put(ctx context.Context, key string, r io.Reader, size int64) (Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheDir returns the directory a prefix is materialized in under base,
+// or under the user cache directory when base is empty
+func CacheDir(base string, loc Location) (string, error) {
+	if base == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("no cache directory for object store prefixes: %w", err)
+		}
+		base = filepath.Join(cache, "llm-runtime", "objects")
+	}
+	return filepath.Join(base, loc.Scheme, loc.Bucket, filepath.FromSlash(strings.TrimSuffix(loc.Prefix, "/"))), nil
+}
+
+// entry is what the manifest records of a downloaded object
+type entry struct {
+	ETag   string `json:"etag"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifest records the objects of Source as downloaded, by path relative
+// to the cache directory
+type manifest struct {
+	Source  string           `json:"source"`
+	Objects map[string]entry `json:"objects"`
+}
+
+// Repo is an object store prefix materialized in a local directory
+type Repo struct {
+	Dir      string
+	Source   Location
+	manifest manifest
+}
+
+// Changes lists the files written back by WriteBack, by path relative to
+// the repository root
+type Changes struct {
+	Written []string // Created or modified in the session
+	Deleted []string // Deleted in the session; removed from the store only when written back to the source
+}
+
+// Materialize makes dir a copy of the objects under source. Files already
+// in dir that match the object they came from are kept rather than
+// downloaded again; any others, including changes from an earlier session
+// that were not written back, are replaced or removed, so dir always
+// starts as the prefix is now.
+func Materialize(ctx context.Context, store Store, source Location, dir string) (*Repo, error) {
+	objects, err := store.List(ctx, source.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list %s: %w", source, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create cache directory: %w", err)
+	}
+
+	previous := readManifest(dir, source)
+	repo := &Repo{
+		Dir:      dir,
+		Source:   source,
+		manifest: manifest{Source: source.String(), Objects: make(map[string]entry)},
+	}
+
+	for _, obj := range objects {
+		rel, ok := relPath(source.Prefix, obj.Key)
+		if !ok {
+			continue
+		}
+		local := filepath.Join(dir, filepath.FromSlash(rel))
+
+		if prev, found := previous.Objects[rel]; found && prev.ETag == obj.ETag {
+			if sum, err := fileSHA256(local); err == nil && sum == prev.SHA256 {
+				repo.manifest.Objects[rel] = prev
+				continue
+			}
+		}
+
+		sum, err := download(ctx, store, obj.Key, local)
+		if err != nil {
+			return nil, fmt.Errorf("cannot download %s: %w", obj.Key, err)
+		}
+		repo.manifest.Objects[rel] = entry{ETag: obj.ETag, SHA256: sum}
+	}
+
+	// Anything else in the cache is left from an earlier session
+	err = walkFiles(dir, func(rel, local string) error {
+		if _, ok := repo.manifest.Objects[rel]; ok {
+			return nil
+		}
+		return os.Remove(local)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot clean cache directory: %w", err)
+	}
+
+	if err := repo.saveManifest(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// WriteBack writes the files created or modified since Materialize to
+// dest, at the same paths under its prefix. Deleted files are removed from
+// dest only when it is the source, since another prefix, such as one
+// collecting changes for review, holds only what changed.
+func (r *Repo) WriteBack(ctx context.Context, store Store, dest Location) (Changes, error) {
+	var changes Changes
+	toSource := dest == r.Source
+	seen := make(map[string]bool)
+
+	err := walkFiles(r.Dir, func(rel, local string) error {
+		seen[rel] = true
+		sum, err := fileSHA256(local)
+		if err != nil {
+			return err
+		}
+		if prev, ok := r.manifest.Objects[rel]; ok && prev.SHA256 == sum {
+			return nil
+		}
+
+		obj, err := upload(ctx, store, dest.Prefix+rel, local)
+		if err != nil {
+			return fmt.Errorf("cannot write %s: %w", dest.Prefix+rel, err)
+		}
+		changes.Written = append(changes.Written, rel)
+		if toSource {
+			r.manifest.Objects[rel] = entry{ETag: obj.ETag, SHA256: sum}
+		}
+		return nil
+	})
+	if err != nil {
+		return changes, err
+	}
+
+	for rel := range r.manifest.Objects {
+		if seen[rel] {
+			continue
+		}
+		if toSource {
+			if err := store.Delete(ctx, dest.Prefix+rel); err != nil {
+				return changes, fmt.Errorf("cannot delete %s: %w", dest.Prefix+rel, err)
+			}
+			delete(r.manifest.Objects, rel)
+		}
+		changes.Deleted = append(changes.Deleted, rel)
+	}
+	sort.Strings(changes.Deleted)
+
+	if toSource {
+		return changes, r.saveManifest()
+	}
+	return changes, nil
+}
+
+// relPath returns the path of key relative to prefix, or false for keys
+// that cannot be files in the repository: directory markers, the
+// runtime's own directory, and keys that would escape it
+func relPath(prefix, key string) (string, bool) {
+	rel := strings.TrimPrefix(key, prefix)
+	if rel == "" || strings.HasSuffix(rel, "/") || rel != path.Clean(rel) || !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return "", false
+	}
+	if rel == MetaDir || strings.HasPrefix(rel, MetaDir+"/") {
+		return "", false
+	}
+	return rel, true
+}
+
+// walkFiles calls fn with the slash-separated relative path and local path
+// of each regular file under dir, outside MetaDir
+func walkFiles(dir string, fn func(rel, local string) error) error {
+	return filepath.WalkDir(dir, func(local string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, local)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == MetaDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return fn(rel, local)
+	})
+}
+
+// download writes the object at key to local and returns its SHA-256
+func download(ctx context.Context, store Store, key, local string) (string, error) {
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return "", err
+	}
+	tmp := local + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, local)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// upload writes the file at local to key
+func upload(ctx context.Context, store Store, key, local string) (Object, error) {
+	f, err := os.Open(local)
+	if err != nil {
+		return Object{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Object{}, err
+	}
+	return store.Put(ctx, key, f, info.Size())
+}
+
+// fileSHA256 returns the SHA-256 of the file at path
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readManifest returns the manifest of an earlier Materialize of source
+// into dir, or an empty one
+func readManifest(dir string, source Location) manifest {
+	var m manifest
+	data, err := os.ReadFile(filepath.Join(dir, MetaDir, manifestFile))
+	if err != nil || json.Unmarshal(data, &m) != nil || m.Source != source.String() {
+		return manifest{}
+	}
+	return m
+}
+
+// saveManifest records what the cache directory holds
+func (r *Repo) saveManifest() error {
+	data, err := json.MarshalIndent(r.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	metaDir := filepath.Join(r.Dir, MetaDir)
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		return fmt.Errorf("cannot save object manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(metaDir, manifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("cannot save object manifest: %w", err)
+	}
+	return nil
+}
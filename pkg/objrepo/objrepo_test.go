@@ -0,0 +1,236 @@
+package objrepo
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// memStore is a Store holding its objects in memory
+type memStore struct {
+	objects map[string][]byte
+	gets    int
+}
+
+func newMemStore(objects map[string]string) *memStore {
+	s := &memStore{objects: make(map[string][]byte)}
+	for key, content := range objects {
+		s.objects[key] = []byte(content)
+	}
+	return s
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *memStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	for key, data := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, Object{Key: key, Size: int64(len(data)), ETag: etag(data)})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *memStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("no such key %s", key)
+	}
+	s.gets++
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStore) Put(ctx context.Context, key string, r io.Reader, size int64) (Object, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Object{}, err
+	}
+	s.objects[key] = data
+	return Object{Key: key, Size: size, ETag: etag(data)}, nil
+}
+
+func (s *memStore) Delete(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Location
+	}{
+		{"s3://bundles/release/v2", Location{"s3", "bundles", "release/v2/"}},
+		{"gs://datasets/", Location{"gs", "datasets", ""}},
+		{"s3://bundles", Location{"s3", "bundles", ""}},
+	}
+	for _, tt := range tests {
+		got, err := ParseURL(tt.raw)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, %v, want %+v", tt.raw, got, err, tt.want)
+		}
+		if again, _ := ParseURL(got.String()); again != got {
+			t.Errorf("ParseURL(%q) does not round trip: %+v", got.String(), again)
+		}
+	}
+
+	for _, raw := range []string{"/tmp/repo", "https://example.com/x", "s3:///prefix"} {
+		if _, err := ParseURL(raw); err == nil {
+			t.Errorf("ParseURL(%q) should fail", raw)
+		}
+	}
+	if !IsURL("gs://datasets/a") || IsURL("./s3") {
+		t.Error("IsURL() misclassified a root")
+	}
+}
+
+func TestMaterialize(t *testing.T) {
+	store := newMemStore(map[string]string{
+		"bundle/README.md":         "# Bundle\n",
+		"bundle/data/train.csv":    "a,b\n1,2\n",
+		"bundle/empty/":            "",
+		"bundle/.llm-tools/x.json": "{}",
+		"bundle/../escape.txt":     "outside",
+		"other/ignored.txt":        "not in the prefix",
+	})
+	source := Location{"s3", "b", "bundle/"}
+	dir := t.TempDir()
+
+	repo, err := Materialize(context.Background(), store, source, dir)
+	if err != nil {
+		t.Fatalf("Materialize() error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, "data", "train.csv")); got != "a,b\n1,2\n" {
+		t.Errorf("train.csv = %q", got)
+	}
+	var files []string
+	walkFiles(dir, func(rel, local string) error {
+		files = append(files, rel)
+		return nil
+	})
+	if want := []string{"README.md", "data/train.csv"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("materialized %q, want %q", files, want)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.txt")); err == nil {
+		t.Error("a key with .. was written outside the cache")
+	}
+	if repo.Dir != dir || repo.Source != source {
+		t.Errorf("Repo = %+v", repo)
+	}
+
+	// A second session keeps unchanged files, and restores the prefix as
+	// it is now over leftovers of the first
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("edited\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "scratch.txt"), []byte("left over\n"), 0644)
+	store.objects["bundle/data/train.csv"] = []byte("a,b\n3,4\n")
+	store.gets = 0
+
+	if _, err := Materialize(context.Background(), store, source, dir); err != nil {
+		t.Fatalf("Materialize() again error = %v", err)
+	}
+	if store.gets != 2 {
+		t.Errorf("downloaded %d objects again, want 2", store.gets)
+	}
+	if got := readFile(t, filepath.Join(dir, "README.md")); got != "# Bundle\n" {
+		t.Errorf("README.md = %q, want the stored version", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "data", "train.csv")); got != "a,b\n3,4\n" {
+		t.Errorf("train.csv = %q, want the new version", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scratch.txt")); !os.IsNotExist(err) {
+		t.Error("leftover file was not removed")
+	}
+}
+
+func TestWriteBack(t *testing.T) {
+	for _, toSource := range []bool{true, false} {
+		t.Run(fmt.Sprintf("to source %v", toSource), func(t *testing.T) {
+			store := newMemStore(map[string]string{
+				"bundle/keep.txt":   "keep\n",
+				"bundle/edit.txt":   "before\n",
+				"bundle/remove.txt": "remove\n",
+			})
+			source := Location{"s3", "b", "bundle/"}
+			dest := source
+			if !toSource {
+				dest = Location{"s3", "b", "changes/run-1/"}
+			}
+			dir := t.TempDir()
+			repo, err := Materialize(context.Background(), store, source, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			os.WriteFile(filepath.Join(dir, "edit.txt"), []byte("after\n"), 0644)
+			os.MkdirAll(filepath.Join(dir, "out"), 0755)
+			os.WriteFile(filepath.Join(dir, "out", "new.txt"), []byte("new\n"), 0644)
+			os.Remove(filepath.Join(dir, "remove.txt"))
+			os.WriteFile(filepath.Join(dir, MetaDir, "backup.bak"), []byte("runtime's own\n"), 0644)
+
+			changes, err := repo.WriteBack(context.Background(), store, dest)
+			if err != nil {
+				t.Fatalf("WriteBack() error = %v", err)
+			}
+			want := Changes{Written: []string{"edit.txt", "out/new.txt"}, Deleted: []string{"remove.txt"}}
+			if !reflect.DeepEqual(changes, want) {
+				t.Errorf("WriteBack() = %+v, want %+v", changes, want)
+			}
+
+			if got := string(store.objects[dest.Prefix+"edit.txt"]); got != "after\n" {
+				t.Errorf("%sedit.txt = %q", dest.Prefix, got)
+			}
+			if _, ok := store.objects[dest.Prefix+MetaDir+"/backup.bak"]; ok {
+				t.Error("the runtime's own directory was written back")
+			}
+			_, removed := store.objects["bundle/remove.txt"]
+			if removed == toSource {
+				t.Errorf("bundle/remove.txt still stored = %v", removed)
+			}
+			if !toSource && string(store.objects["bundle/edit.txt"]) != "before\n" {
+				t.Error("writing to a changes prefix modified the source")
+			}
+
+			// Nothing more to write once the source has the changes
+			changes, err = repo.WriteBack(context.Background(), store, dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if toSource && (len(changes.Written) != 0 || len(changes.Deleted) != 0) {
+				t.Errorf("second WriteBack() = %+v, want no changes", changes)
+			}
+		})
+	}
+}
+
+func TestCacheDir(t *testing.T) {
+	base := t.TempDir()
+	got, err := CacheDir(base, Location{"gs", "datasets", "images/v1/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(base, "gs", "datasets", "images", "v1"); got != want {
+		t.Errorf("CacheDir() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+// External is a plugin command run as a separate process. For each
+// request the executable receives one JSON object on stdin, the Request
+// fields plus an "action" of "validate" or "execute", and writes one JSON
+// object to stdout:
+//
+//	{"output": "text for the LLM", "error": "why the request failed"}
+//
+// A non-empty error rejects the request. Plugins run on the host, in the
+// repository root, with the environment of llm-runtime, so only install
+// plugins you trust.
+type External struct {
+	CommandName string
+	Path        string
+	Timeout     time.Duration
+}
+
+// message is the JSON sent to an external plugin
+type message struct {
+	Action string `json:"action"`
+	Request
+}
+
+// reply is the JSON an external plugin answers with
+type reply struct {
+	Output string `json:"output"`
+	Error  string `json:"error"`
+}
+
+// Name returns the command name
+func (e *External) Name() string {
+	return e.CommandName
+}
+
+// Validate asks the plugin whether it accepts the request
+func (e *External) Validate(req Request) error {
+	_, err := e.call(context.Background(), "validate", req)
+	return err
+}
+
+// Execute runs the request and returns the plugin's output
+func (e *External) Execute(ctx context.Context, req Request) (string, error) {
+	return e.call(ctx, "execute", req)
+}
+
+// call runs the plugin once for action
+func (e *External) call(ctx context.Context, action string, req Request) (string, error) {
+	input, err := json.Marshal(message{Action: action, Request: req})
+	if err != nil {
+		return "", err
+	}
+
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Path)
+	cmd.Dir = req.Repository
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// A timeout sends SIGTERM, then SIGKILL after the grace period, which
+	// also stops children left holding stdout
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = sandbox.TimeoutGracePeriod
+	runErr := cmd.Run()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("%w after %v", sandbox.ErrTimeout, e.Timeout)
+	}
+	return parseReply(stdout.Bytes(), stderr.String(), runErr)
+}
+
+// parseReply returns the output of a plugin run that wrote stdout and
+// stderr and ended with runErr
+func parseReply(stdout []byte, stderr string, runErr error) (string, error) {
+	var out reply
+	if err := json.Unmarshal(stdout, &out); err != nil {
+		if runErr != nil {
+			return "", fmt.Errorf("%v: %s", runErr, strings.TrimSpace(stderr))
+		}
+		return "", fmt.Errorf("invalid reply: %w", err)
+	}
+	if out.Error != "" {
+		return "", errors.New(out.Error)
+	}
+	if runErr != nil {
+		return "", fmt.Errorf("%v: %s", runErr, strings.TrimSpace(stderr))
+	}
+	return out.Output, nil
+}
+
+// Discover returns a command for each plugin in dir, named after the file
+// without its extension: dir/jira or dir/jira.py provides <jira>.
+// Executable files run as External plugins and .wasm modules as WASM
+// plugins, confined to what host returns for the command (nothing, if
+// host is nil). A missing directory has no plugins.
+func Discover(dir string, timeout time.Duration, host func(name string) *Host) ([]Command, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read plugins directory: %w", err)
+	}
+
+	var plugins []Command
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		switch {
+		case filepath.Ext(path) == ".wasm":
+			w := &WASM{CommandName: name, Path: path, Timeout: timeout}
+			if host != nil {
+				w.Host = host(name)
+			}
+			plugins = append(plugins, w)
+		case info.Mode().Perm()&0111 != 0:
+			plugins = append(plugins, &External{CommandName: name, Path: path, Timeout: timeout})
+		}
+	}
+	return plugins, nil
+}
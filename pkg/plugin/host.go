@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+// Capabilities a WASM plugin can be granted. Logging is always allowed.
+const (
+	CapabilityReadFile  = "read_file"
+	CapabilityWriteFile = "write_file"
+)
+
+// Host is what a WASM plugin may do outside its sandbox: read and write
+// files in the repository, if granted, and write log lines for the
+// operator, and how much memory it may use inside it. Files are read and
+// written on the host, so paths are checked as for built-in host commands,
+// symlinks included. The zero Host grants nothing, discards logs, and
+// leaves memory at wazero's limit.
+type Host struct {
+	Root              string          // Repository root; plugins see paths relative to it
+	ExcludedPaths     []string        // Paths plugins can never touch, as for built-in commands
+	AllowedExtensions []string        // Extensions plugins may write, as for <write>; empty for any
+	MaxFileSize       int64           // Largest file read or written; 0 for no limit
+	MemoryLimitPages  uint32          // Most 64 KiB pages of memory the module may use; 0 for wazero's limit
+	Capabilities      map[string]bool // Granted capabilities
+	Log               io.Writer       // Where log lines go
+
+	// Audit, if set, records each file a plugin writes, as the session
+	// audit log does for built-in commands
+	Audit func(command, argument string, success bool, errorMsg string)
+
+	// Write, if set, writes each file a plugin writes, given its path
+	// relative to Root, in place of the host writing it directly. The
+	// runtime sets it to the executor's write, so plugin writes get the
+	// same checks, backups, and audit entries as <write>; Audit and the
+	// checks here are then left to it.
+	Write func(path string, data []byte) error
+}
+
+// ParseCapabilities returns the capabilities listed under "capabilities"
+// in a plugin's config block
+func ParseCapabilities(config map[string]interface{}) (map[string]bool, error) {
+	caps := make(map[string]bool)
+	list, ok := config["capabilities"].([]interface{})
+	if !ok {
+		if config["capabilities"] != nil {
+			return nil, fmt.Errorf("capabilities must be a list")
+		}
+		return caps, nil
+	}
+	for _, item := range list {
+		name, _ := item.(string)
+		switch name {
+		case CapabilityReadFile, CapabilityWriteFile:
+			caps[name] = true
+		default:
+			return nil, fmt.Errorf("unknown capability %v", item)
+		}
+	}
+	return caps, nil
+}
+
+// ReadFile returns the contents of a repository file
+func (h *Host) ReadFile(path string) ([]byte, error) {
+	fullPath, err := h.resolve(CapabilityReadFile, path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if h.MaxFileSize > 0 && info.Size() > h.MaxFileSize {
+		return nil, fmt.Errorf("file too large: %d bytes (limit %d)", info.Size(), h.MaxFileSize)
+	}
+	return os.ReadFile(fullPath)
+}
+
+// WriteFile replaces the contents of a repository file, creating it and
+// its directories if needed
+func (h *Host) WriteFile(path string, data []byte) error {
+	fullPath, err := h.resolve(CapabilityWriteFile, path)
+	if err != nil {
+		return err
+	}
+	if h.Write != nil {
+		return h.Write(path, data)
+	}
+	err = h.writeFile(fullPath, data)
+	if h.Audit != nil {
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		}
+		h.Audit("plugin-write", path, err == nil, errMsg)
+	}
+	return err
+}
+
+// writeFile writes data to fullPath, a resolved repository path, if its
+// extension and size are allowed
+func (h *Host) writeFile(fullPath string, data []byte) error {
+	if err := sandbox.ValidateWriteExtension(fullPath, h.AllowedExtensions); err != nil {
+		return err
+	}
+	if h.MaxFileSize > 0 && int64(len(data)) > h.MaxFileSize {
+		return fmt.Errorf("content too large: %d bytes (limit %d)", len(data), h.MaxFileSize)
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, data, 0644)
+}
+
+// Logf writes a log line from the named plugin
+func (h *Host) Logf(name, format string, args ...interface{}) {
+	if h == nil || h.Log == nil {
+		return
+	}
+	fmt.Fprintf(h.Log, "[plugin %s] %s\n", name, fmt.Sprintf(format, args...))
+}
+
+// resolve checks that capability is granted and returns the absolute path
+// of a repository file
+func (h *Host) resolve(capability, path string) (string, error) {
+	if h == nil || !h.Capabilities[capability] {
+		return "", fmt.Errorf("capability %s not granted", capability)
+	}
+	return sandbox.ValidateHostPath(path, h.Root, h.ExcludedPaths)
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHost_Capabilities(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("SECRET=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	readOnly := &Host{Root: root, ExcludedPaths: []string{".env"}, Capabilities: map[string]bool{CapabilityReadFile: true}}
+	if data, err := readOnly.ReadFile("main.go"); err != nil || string(data) != "package main\n" {
+		t.Errorf("ReadFile = %q, %v", data, err)
+	}
+	if err := readOnly.WriteFile("out.txt", []byte("x")); err == nil {
+		t.Error("expected write without write_file to fail")
+	}
+
+	for _, path := range []string{".env", "../outside", "/etc/passwd"} {
+		if _, err := readOnly.ReadFile(path); err == nil {
+			t.Errorf("expected reading %s to fail", path)
+		}
+	}
+
+	readWrite := &Host{Root: root, MaxFileSize: 8, Capabilities: map[string]bool{CapabilityReadFile: true, CapabilityWriteFile: true}}
+	if err := readWrite.WriteFile("gen/out.txt", []byte("hello")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "gen", "out.txt")); string(data) != "hello" {
+		t.Errorf("wrote %q", data)
+	}
+	if err := readWrite.WriteFile("big.txt", []byte("too much data")); err == nil {
+		t.Error("expected write over MaxFileSize to fail")
+	}
+	if _, err := readWrite.ReadFile("main.go"); err == nil {
+		t.Error("expected read over MaxFileSize to fail")
+	}
+
+	audited := 0
+	restricted := &Host{
+		Root:              root,
+		AllowedExtensions: []string{".txt"},
+		Capabilities:      map[string]bool{CapabilityWriteFile: true},
+		Audit:             func(command, argument string, success bool, errorMsg string) { audited++ },
+	}
+	if err := restricted.WriteFile("gen/ok.txt", []byte("ok")); err != nil {
+		t.Errorf("WriteFile(.txt) = %v", err)
+	}
+	if err := restricted.WriteFile("run.sh", []byte("rm -rf /")); err == nil || !strings.Contains(err.Error(), "extension not allowed") {
+		t.Errorf("WriteFile(.sh) = %v, want an extension error", err)
+	}
+	if audited != 2 {
+		t.Errorf("audited %d writes, want 2", audited)
+	}
+
+	var none *Host
+	if _, err := none.ReadFile("main.go"); err == nil {
+		t.Error("expected nil host to grant nothing")
+	}
+	none.Logf("lint", "ignored")
+
+	var log bytes.Buffer
+	(&Host{Log: &log}).Logf("lint", "checked %d files", 3)
+	if log.String() != "[plugin lint] checked 3 files\n" {
+		t.Errorf("log = %q", log.String())
+	}
+}
+
+func TestHost_Symlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	if err := os.Symlink(outside, filepath.Join(root, "docs")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	host := &Host{Root: root, Capabilities: map[string]bool{CapabilityReadFile: true, CapabilityWriteFile: true}}
+	if err := host.WriteFile("docs/pwned.txt", []byte("x")); err == nil {
+		t.Error("expected a write through a symlink out of the repository to fail")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "pwned.txt")); err == nil {
+		t.Error("file written outside the repository")
+	}
+	if _, err := host.ReadFile("docs/secret.txt"); err == nil {
+		t.Error("expected a read through a symlink out of the repository to fail")
+	}
+}
+
+func TestHost_WriteHook(t *testing.T) {
+	root := t.TempDir()
+	var written []string
+	host := &Host{
+		Root:         root,
+		Capabilities: map[string]bool{CapabilityWriteFile: true},
+		Write: func(path string, data []byte) error {
+			written = append(written, path+"="+string(data))
+			return nil
+		},
+	}
+	if err := host.WriteFile("gen/out.txt", []byte("hello")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "gen", "out.txt")); err == nil {
+		t.Error("host wrote the file itself instead of leaving it to Write")
+	}
+
+	// Paths are still checked, and the capability still needed, first
+	if err := host.WriteFile("../outside.txt", []byte("x")); err == nil {
+		t.Error("expected a write outside the repository to fail")
+	}
+	readOnly := &Host{Root: root, Write: host.Write}
+	if err := readOnly.WriteFile("gen/other.txt", []byte("x")); err == nil {
+		t.Error("expected write without write_file to fail")
+	}
+	if strings.Join(written, ",") != "gen/out.txt=hello" {
+		t.Errorf("Write got %q", written)
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	caps, err := ParseCapabilities(map[string]interface{}{"capabilities": []interface{}{"read_file"}})
+	if err != nil || !caps[CapabilityReadFile] || caps[CapabilityWriteFile] {
+		t.Errorf("ParseCapabilities = %v, %v", caps, err)
+	}
+	if caps, err := ParseCapabilities(nil); err != nil || len(caps) != 0 {
+		t.Errorf("no config: %v, %v", caps, err)
+	}
+	if _, err := ParseCapabilities(map[string]interface{}{"capabilities": []interface{}{"network"}}); err == nil || !strings.Contains(err.Error(), "network") {
+		t.Errorf("expected unknown capability error, got %v", err)
+	}
+	if _, err := ParseCapabilities(map[string]interface{}{"capabilities": "read_file"}); err == nil {
+		t.Error("expected error for a non-list")
+	}
+}
+
+func TestWASM_Disabled(t *testing.T) {
+	w := &WASM{CommandName: "lint", Path: filepath.Join(t.TempDir(), "lint.wasm")}
+	if _, err := w.Execute(context.Background(), Request{}); err == nil {
+		t.Error("expected an error")
+	}
+}
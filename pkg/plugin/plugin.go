@@ -0,0 +1,125 @@
+// Package plugin lets teams add custom commands, such as <jira ISSUE-123>
+// or <deploy staging>. A command is either a Go value implementing Command,
+// registered from code with Register, or an executable in the plugins
+// directory that speaks JSON over stdio (see External).
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Request is a single invocation of a plugin command
+type Request struct {
+	Command    string                 `json:"command"`    // Command name, e.g. "jira"
+	Argument   string                 `json:"argument"`   // Everything after the name in the tag
+	Repository string                 `json:"repository"` // Absolute repository root
+	Config     map[string]interface{} `json:"config"`     // The command's config block, if any
+}
+
+// Command is a custom command. Validate runs first and rejects arguments
+// the command cannot handle; Execute runs it and returns its output for
+// the LLM.
+type Command interface {
+	Name() string
+	Validate(req Request) error
+	Execute(ctx context.Context, req Request) (string, error)
+}
+
+// Func adapts plain functions to the Command interface. A nil Check
+// accepts every request.
+type Func struct {
+	CommandName string
+	Check       func(req Request) error
+	Run         func(ctx context.Context, req Request) (string, error)
+}
+
+// Name returns f.CommandName
+func (f Func) Name() string {
+	return f.CommandName
+}
+
+// Validate calls f.Check, if set
+func (f Func) Validate(req Request) error {
+	if f.Check == nil {
+		return nil
+	}
+	return f.Check(req)
+}
+
+// Execute calls f.Run
+func (f Func) Execute(ctx context.Context, req Request) (string, error) {
+	return f.Run(ctx, req)
+}
+
+var (
+	compiledMu sync.Mutex
+	compiled   []Command
+)
+
+// Register adds a Go command to every registry created afterwards. Call it
+// from an init function, as database/sql drivers do.
+func Register(cmd Command) {
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	compiled = append(compiled, cmd)
+}
+
+// Registry maps command names to plugin commands
+type Registry struct {
+	commands map[string]Command
+}
+
+// NewRegistry creates a registry holding the commands added with Register
+func NewRegistry() (*Registry, error) {
+	r := &Registry{commands: make(map[string]Command)}
+
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	for _, cmd := range compiled {
+		if err := r.Add(cmd); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Add registers a command and teaches the scanner its tag. Names must not
+// clash with built-in commands or with each other.
+func (r *Registry) Add(cmd Command) error {
+	name := cmd.Name()
+	if _, ok := r.commands[name]; ok {
+		return fmt.Errorf("plugin command %q is defined twice", name)
+	}
+	if err := scanner.RegisterCommand(name); err != nil {
+		return fmt.Errorf("plugin command: %w", err)
+	}
+	r.commands[name] = cmd
+	return nil
+}
+
+// Lookup returns the command registered under name
+func (r *Registry) Lookup(name string) (Command, bool) {
+	if r == nil {
+		return nil, false
+	}
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// Names returns the registered command names, sorted
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
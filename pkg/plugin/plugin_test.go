@@ -0,0 +1,157 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestRegistry_Add(t *testing.T) {
+	r, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	t.Cleanup(func() { scanner.UnregisterCommand("jira") })
+
+	jira := Func{CommandName: "jira", Run: func(ctx context.Context, req Request) (string, error) {
+		return "ISSUE " + req.Argument, nil
+	}}
+	if err := r.Add(jira); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := r.Add(jira); err == nil {
+		t.Error("expected an error adding jira twice")
+	}
+	if err := r.Add(Func{CommandName: "exec"}); err == nil {
+		t.Error("expected an error adding a built-in name")
+	}
+
+	cmd, ok := r.Lookup("jira")
+	if !ok {
+		t.Fatal("jira not found")
+	}
+	out, err := cmd.Execute(context.Background(), Request{Argument: "ABC-1"})
+	if err != nil || out != "ISSUE ABC-1" {
+		t.Errorf("Execute = %q, %v", out, err)
+	}
+	if !scanner.IsRegistered("jira") {
+		t.Error("scanner does not recognize <jira>")
+	}
+	if got := r.Names(); len(got) != 1 || got[0] != "jira" {
+		t.Errorf("Names = %v", got)
+	}
+
+	var nilRegistry *Registry
+	if _, ok := nilRegistry.Lookup("jira"); ok {
+		t.Error("nil registry found a command")
+	}
+}
+
+// writePlugin writes an executable shell script plugin to dir
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExternal(t *testing.T) {
+	dir := t.TempDir()
+	// Rejects validation of arguments containing "bad", and echoes its
+	// request otherwise
+	path := writePlugin(t, dir, "echo.sh", `input=$(cat)
+case "$input" in
+*'"action":"validate"'*'bad'*) echo '{"error":"bad argument"}' ;;
+*) printf '{"output":"%s"}\n' "$(pwd)" ;;
+esac
+`)
+	p := &External{CommandName: "echo", Path: path, Timeout: 5 * time.Second}
+	req := Request{Command: "echo", Argument: "hello", Repository: dir}
+
+	if err := p.Validate(req); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	out, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if wd, _ := filepath.EvalSymlinks(dir); out != dir && out != wd {
+		t.Errorf("plugin ran in %q, want %q", out, dir)
+	}
+
+	req.Argument = "bad"
+	if err := p.Validate(req); err == nil || err.Error() != "bad argument" {
+		t.Errorf("Validate = %v, want bad argument", err)
+	}
+}
+
+func TestExternal_Failures(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		wantErr string
+	}{
+		{"garbage", "echo not json\n", 0, "invalid reply"},
+		{"crash", "echo boom >&2; exit 3\n", 0, "boom"},
+		{"slow", "sleep 5\n", 100 * time.Millisecond, "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &External{CommandName: tt.name, Path: writePlugin(t, dir, tt.name, tt.script), Timeout: tt.timeout}
+			_, err := p.Execute(context.Background(), Request{Repository: dir})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "jira.py", "")
+	writePlugin(t, dir, "deploy", "")
+	if err := os.WriteFile(filepath.Join(dir, "lint.wasm"), []byte("\x00asm"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	host := &Host{Root: dir}
+	plugins, err := Discover(dir, time.Second, func(name string) *Host { return host })
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	var names []string
+	for _, p := range plugins {
+		names = append(names, p.Name())
+	}
+	if strings.Join(names, ",") != "deploy,jira,lint" {
+		t.Errorf("discovered %v, want [deploy jira lint]", names)
+	}
+	if w, ok := plugins[2].(*WASM); !ok || w.Host != host {
+		t.Errorf("lint.wasm discovered as %#v, want a WASM plugin with the host", plugins[2])
+	}
+
+	plugins, err = Discover(filepath.Join(dir, "missing"), time.Second, nil)
+	if err != nil || plugins != nil {
+		t.Errorf("missing dir: %v, %v", plugins, err)
+	}
+	if _, err := Discover(filepath.Join(dir, "README"), time.Second, nil); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected an error reading a file as the plugins directory, got %v", err)
+	}
+}
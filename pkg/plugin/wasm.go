@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"context"
+	"time"
+)
+
+// WASM is a plugin command compiled to a WebAssembly module, for plugins
+// that should not be trusted with the host. The module is a WASI program
+// speaking the same JSON protocol as External on stdin and stdout, but it
+// has no file system, network, or environment. It can only call these
+// host functions, imported from the "llm_runtime" module:
+//
+//	read_file(path_ptr, path_len, buf_ptr, buf_cap i32) i32
+//	write_file(path_ptr, path_len, data_ptr, data_len i32) i32
+//	log(msg_ptr, msg_len i32)
+//
+// read_file copies a repository file into the buffer and returns its size;
+// if the file is larger than buf_cap nothing is copied, so the plugin can
+// retry with a bigger buffer. write_file returns 0. Both return -1 when
+// the path is invalid, the capability was not granted by Host, or the
+// write is refused. The module's memory is limited to the pages Host
+// allows.
+//
+// Running WASM plugins needs llm-runtime built with -tags wasmplugins.
+type WASM struct {
+	CommandName string
+	Path        string
+	Timeout     time.Duration
+	Host        *Host
+}
+
+// Name returns the command name
+func (w *WASM) Name() string {
+	return w.CommandName
+}
+
+// Validate asks the module whether it accepts the request
+func (w *WASM) Validate(req Request) error {
+	_, err := w.run(context.Background(), "validate", req)
+	return err
+}
+
+// Execute runs the request in the module and returns its output
+func (w *WASM) Execute(ctx context.Context, req Request) (string, error) {
+	return w.run(ctx, "execute", req)
+}
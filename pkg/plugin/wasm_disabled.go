@@ -0,0 +1,12 @@
+//go:build !wasmplugins
+
+package plugin
+
+import (
+	"context"
+	"errors"
+)
+
+func (w *WASM) run(ctx context.Context, action string, req Request) (string, error) {
+	return "", errors.New("WASM plugins are not supported by this build (rebuild with -tags wasmplugins)")
+}
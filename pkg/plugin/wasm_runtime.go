@@ -0,0 +1,114 @@
+//go:build wasmplugins
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// run instantiates the module in a fresh runtime for each call, so nothing
+// survives from one request to the next
+func (w *WASM) run(ctx context.Context, action string, req Request) (string, error) {
+	code, err := os.ReadFile(w.Path)
+	if err != nil {
+		return "", err
+	}
+	input, err := json.Marshal(message{Action: action, Request: req})
+	if err != nil {
+		return "", err
+	}
+
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if w.Host != nil && w.Host.MemoryLimitPages > 0 {
+		runtimeConfig = runtimeConfig.WithMemoryLimitPages(w.Host.MemoryLimitPages)
+	}
+	rt := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	defer rt.Close(context.Background())
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		return "", err
+	}
+	_, err = rt.NewHostModuleBuilder("llm_runtime").
+		NewFunctionBuilder().WithFunc(w.readFile).Export("read_file").
+		NewFunctionBuilder().WithFunc(w.writeFile).Export("write_file").
+		NewFunctionBuilder().WithFunc(w.log).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// No file system, environment, or arguments: only stdio
+	var stdout, stderr bytes.Buffer
+	moduleConfig := wazero.NewModuleConfig().
+		WithName(w.CommandName).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+	_, runErr := rt.InstantiateWithConfig(ctx, code, moduleConfig)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("timed out after %v", w.Timeout)
+	}
+	var exitErr *sys.ExitError
+	if errors.As(runErr, &exitErr) && exitErr.ExitCode() == 0 {
+		runErr = nil
+	}
+	return parseReply(stdout.Bytes(), stderr.String(), runErr)
+}
+
+func (w *WASM) readFile(ctx context.Context, m api.Module, pathPtr, pathLen, bufPtr, bufCap uint32) int32 {
+	path, ok := m.Memory().Read(pathPtr, pathLen)
+	if !ok {
+		return -1
+	}
+	data, err := w.Host.ReadFile(string(path))
+	if err != nil {
+		w.Host.Logf(w.CommandName, "read_file %s: %v", path, err)
+		return -1
+	}
+	if uint32(len(data)) > bufCap {
+		return int32(len(data))
+	}
+	if !m.Memory().Write(bufPtr, data) {
+		return -1
+	}
+	return int32(len(data))
+}
+
+func (w *WASM) writeFile(ctx context.Context, m api.Module, pathPtr, pathLen, dataPtr, dataLen uint32) int32 {
+	path, ok := m.Memory().Read(pathPtr, pathLen)
+	if !ok {
+		return -1
+	}
+	data, ok := m.Memory().Read(dataPtr, dataLen)
+	if !ok {
+		return -1
+	}
+	if err := w.Host.WriteFile(string(path), data); err != nil {
+		w.Host.Logf(w.CommandName, "write_file %s: %v", path, err)
+		return -1
+	}
+	return 0
+}
+
+func (w *WASM) log(ctx context.Context, m api.Module, msgPtr, msgLen uint32) {
+	if msg, ok := m.Memory().Read(msgPtr, msgLen); ok {
+		w.Host.Logf(w.CommandName, "%s", msg)
+	}
+}
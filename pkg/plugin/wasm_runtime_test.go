@@ -0,0 +1,31 @@
+//go:build wasmplugins
+
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWASM_MemoryLimit(t *testing.T) {
+	// A module declaring two pages of memory and nothing else
+	module := []byte("\x00asm\x01\x00\x00\x00\x05\x03\x01\x00\x02")
+	path := filepath.Join(t.TempDir(), "big.wasm")
+	if err := os.WriteFile(path, module, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &WASM{CommandName: "big", Path: path, Timeout: 10 * time.Second, Host: &Host{MemoryLimitPages: 1}}
+	if _, err := w.Execute(context.Background(), Request{}); err == nil || !strings.Contains(err.Error(), "over limit") {
+		t.Errorf("Execute = %v, want the module refused as over the memory limit", err)
+	}
+
+	w.Host.MemoryLimitPages = 4
+	if _, err := w.Execute(context.Background(), Request{}); err != nil && strings.Contains(err.Error(), "over limit") {
+		t.Errorf("Execute = %v, want the module within the memory limit", err)
+	}
+}
@@ -0,0 +1,64 @@
+package runtime_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/runtime"
+)
+
+func ExampleNew() {
+	session, err := runtime.New(runtime.Options{
+		Root:          "/path/to/repo",
+		ExecWhitelist: []string{"go test", "go build"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer session.Close()
+
+	ctx := context.Background()
+	result, err := session.Exec(ctx, "go test ./...")
+	if err != nil {
+		log.Fatal(err) // ctx ended
+	}
+	if !result.Success {
+		fmt.Println("tests failed:", result.ErrorCode, result.Error)
+	}
+	fmt.Print(result.Output)
+}
+
+// fixTypo is agent code written against the Executor interface, so a test
+// can run it on a fake instead of a Session
+func fixTypo(ctx context.Context, exec runtime.Executor, path string) error {
+	result, err := exec.Open(ctx, path)
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("cannot open %s: %s", path, result.Error)
+	}
+
+	result, err = exec.Write(ctx, path, strings.ReplaceAll(result.Output, "recieve", "receive"))
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("cannot write %s: %s", path, result.Error)
+	}
+	return nil
+}
+
+func ExampleExecutor() {
+	session, err := runtime.New(runtime.Options{Root: "/path/to/repo"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := fixTypo(context.Background(), session, "README.md"); err != nil {
+		log.Fatal(err)
+	}
+}
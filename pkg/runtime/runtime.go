@@ -0,0 +1,190 @@
+// Package runtime is the stable face of the runtime for Go programs that
+// embed it. A Session opens, writes, executes, and searches with the same
+// path validation, sandboxing, and audit logging as the command line, and
+// Options starts from the command line's defaults, so an embedding program
+// only sets what it needs to change.
+//
+// The types here keep their shape as the packages behind them change.
+// pkg/toolapi offers every command, for programs that want more.
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/toolapi"
+)
+
+// Default container images, as on the command line
+const (
+	DefaultExecImage = "python-go"
+	DefaultIOImage   = "llm-runtime-io:latest"
+)
+
+// I/O container limits of the command line, which are tighter than
+// config's container defaults
+const (
+	defaultIOTimeout = 60 * time.Second
+	defaultIOMemory  = "256m"
+)
+
+// Options configures a Session. Zero fields take the command line's
+// defaults.
+type Options struct {
+	Root              string        // Repository the session works in; required
+	ExcludedPaths     []string      // Paths no command may touch; default config.DefaultExcludedPaths
+	AllowedExtensions []string      // Extensions Write may create; default config.DefaultAllowedExtensions
+	MaxFileSize       int64         // Largest file Open reads whole; default 1MB
+	MaxWriteSize      int64         // Largest content Write accepts; default 100KB
+	DisableBackups    bool          // Overwrite files without keeping a backup
+	ExecWhitelist     []string      // Commands Exec may run, such as "go test"; empty refuses every command
+	ExecTimeout       time.Duration // Longest an Exec may run; default 30s
+	ExecImage         string        // Image Exec runs in; default DefaultExecImage
+	IOImage           string        // Image files are read and written in; default DefaultIOImage
+	IOTimeout         time.Duration // Longest a file read or write may take; default 60s
+}
+
+// Config returns the configuration the options stand for
+func (o Options) Config() (*config.Config, error) {
+	if o.Root == "" {
+		return nil, fmt.Errorf("no repository root given")
+	}
+
+	cfg := &config.Config{
+		RepositoryRoot:     o.Root,
+		MaxFileSize:        orDefault(o.MaxFileSize, config.DefaultMaxFileSize),
+		MaxChunkedSize:     config.DefaultMaxChunkedSize,
+		OpenChunkSize:      config.DefaultOpenChunkSize,
+		TruncateKeep:       config.DefaultTruncateKeep,
+		OpenManyMaxFiles:   config.DefaultOpenManyMaxFiles,
+		OpenManyMaxBytes:   config.DefaultOpenManyMaxBytes,
+		TailMaxFollow:      config.DefaultTailFollow,
+		MaxWriteSize:       orDefault(o.MaxWriteSize, config.DefaultMaxWriteSize),
+		MaxArchiveSize:     config.DefaultMaxArchiveSize,
+		MaxArchiveEntries:  config.DefaultMaxArchiveEntries,
+		ExcludedPaths:      o.ExcludedPaths,
+		RespectIgnoreFiles: true,
+		BackupBeforeWrite:  !o.DisableBackups,
+		BackupDir:          config.DefaultBackupDir,
+		BackupMaxCount:     config.MaxBackups,
+		BackupMaxAge:       config.DefaultBackupMaxAge,
+		AllowedExtensions:  o.AllowedExtensions,
+		AllowedModes:       config.DefaultAllowedModes,
+		BinaryHexBytes:     config.DefaultBinaryHexBytes,
+		ConflictCheck:      true,
+		SyntaxCheck:        config.SyntaxCheckReject,
+		LineEndings:        config.ConventionPreserve,
+		TrailingNewline:    config.ConventionPreserve,
+		BOM:                config.ConventionPreserve,
+		ExecWhitelist:      o.ExecWhitelist,
+		ExecValidation:     config.ExecValidationStrict,
+		ExecTimeout:        orDefault(o.ExecTimeout, config.DefaultExecTimeout),
+		ExecMemoryLimit:    config.DefaultContainerMemory,
+		ExecCPULimit:       1,
+		ExecContainerImage: o.ExecImage,
+		ExecCacheMounts:    config.DefaultExecCacheMounts,
+		FetchMaxSize:       config.DefaultFetchMaxSize,
+		FetchContentTypes:  config.DefaultFetchContentTypes,
+		FetchTimeout:       config.DefaultFetchTimeout,
+		TestRunners:        config.DefaultTestRunners,
+		TestMaxFailures:    config.DefaultTestMaxFailures,
+		Linters:            config.DefaultLinters,
+		LintMaxIssues:      config.DefaultLintMaxIssues,
+		IOContainerImage:   o.IOImage,
+		IOTimeout:          orDefault(o.IOTimeout, defaultIOTimeout),
+		IOMemoryLimit:      defaultIOMemory,
+		IOCPULimit:         1,
+		PluginTimeout:      config.DefaultPluginTimeout,
+		PluginMemoryPages:  config.DefaultPluginMemoryLimitPages,
+	}
+	if cfg.ExcludedPaths == nil {
+		cfg.ExcludedPaths = config.DefaultExcludedPaths
+	}
+	if cfg.AllowedExtensions == nil {
+		cfg.AllowedExtensions = config.DefaultAllowedExtensions
+	}
+	if cfg.ExecContainerImage == "" {
+		cfg.ExecContainerImage = DefaultExecImage
+	}
+	if cfg.IOContainerImage == "" {
+		cfg.IOContainerImage = DefaultIOImage
+	}
+	return cfg, nil
+}
+
+// orDefault returns value, or def when value is zero
+func orDefault[T int64 | time.Duration](value, def T) T {
+	if value == 0 {
+		return def
+	}
+	return value
+}
+
+// Result is the outcome of a command. A command that failed, such as an
+// open of an excluded path, is a Result with Success false and ErrorCode
+// set, such as PATH_SECURITY, not a Go error.
+type Result = toolapi.Result
+
+// Executor runs commands. Session implements it; programs can accept an
+// Executor to substitute a fake in their tests.
+type Executor interface {
+	Open(ctx context.Context, path string) (Result, error)
+	Write(ctx context.Context, path, content string) (Result, error)
+	Exec(ctx context.Context, command string) (Result, error)
+	Search(ctx context.Context, query string) (Result, error)
+}
+
+var _ Executor = (*Session)(nil)
+
+// Session runs commands against one repository. Each is validated,
+// sandboxed, and audited as it would be from the command line, and
+// write conflict tracking carries over from one call to the next. A
+// Session is safe for concurrent use but runs one command at a time.
+type Session struct {
+	runtime *toolapi.Runtime
+}
+
+// New starts a session. Close it when done to stop its containers and
+// flush the audit log.
+func New(opts Options) (*Session, error) {
+	cfg, err := opts.Config()
+	if err != nil {
+		return nil, err
+	}
+	r, err := toolapi.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{runtime: r}, nil
+}
+
+// Close stops the session's containers and closes its audit log
+func (s *Session) Close() error {
+	return s.runtime.Close()
+}
+
+// Open reads a file, optionally with a line range such as main.go:10-40.
+// The error is non-nil only when ctx ended.
+func (s *Session) Open(ctx context.Context, path string) (Result, error) {
+	return s.runtime.Open(ctx, path)
+}
+
+// Write creates or replaces a file with content. The error is non-nil only
+// when ctx ended.
+func (s *Session) Write(ctx context.Context, path, content string) (Result, error) {
+	return s.runtime.Write(ctx, path, content)
+}
+
+// Exec runs a whitelisted command line in the exec container. Canceling
+// ctx stops it. The error is non-nil only when ctx ended.
+func (s *Session) Exec(ctx context.Context, command string) (Result, error) {
+	return s.runtime.Exec(ctx, command)
+}
+
+// Search finds files related to query by semantic search. The error is
+// non-nil only when ctx ended.
+func (s *Session) Search(ctx context.Context, query string) (Result, error) {
+	return s.runtime.Search(ctx, query)
+}
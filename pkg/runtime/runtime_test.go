@@ -0,0 +1,119 @@
+package runtime_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/runtime"
+)
+
+// The public API, spelled out so that a change to any signature breaks
+// the build here before it breaks a program embedding the runtime
+var (
+	_ func(runtime.Options) (*runtime.Session, error)                                 = runtime.New
+	_ func(runtime.Options) (*config.Config, error)                                   = runtime.Options.Config
+	_ func(*runtime.Session) error                                                    = (*runtime.Session).Close
+	_ func(*runtime.Session, context.Context, string) (runtime.Result, error)         = (*runtime.Session).Open
+	_ func(*runtime.Session, context.Context, string, string) (runtime.Result, error) = (*runtime.Session).Write
+	_ func(*runtime.Session, context.Context, string) (runtime.Result, error)         = (*runtime.Session).Exec
+	_ func(*runtime.Session, context.Context, string) (runtime.Result, error)         = (*runtime.Session).Search
+	_ runtime.Executor                                                                = (*runtime.Session)(nil)
+)
+
+func TestResultFields(t *testing.T) {
+	// Programs read these fields, and transcripts and the toolapi protocol
+	// carry them as JSON; removing or renaming one breaks both
+	want := map[string]string{
+		"Command":       "command",
+		"Success":       "success",
+		"Action":        "action,omitempty",
+		"Output":        "output,omitempty",
+		"Error":         "error,omitempty",
+		"ErrorCode":     "error_code,omitempty",
+		"ExitCode":      "exit_code,omitempty",
+		"DurationMS":    "duration_ms",
+		"CorrelationID": "correlation_id,omitempty",
+		"Steps":         "steps,omitempty",
+	}
+	typ := reflect.TypeOf(runtime.Result{})
+	for name, tag := range want {
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			t.Errorf("Result has no field %s", name)
+			continue
+		}
+		if got := field.Tag.Get("json"); got != tag {
+			t.Errorf("Result.%s json tag = %q, want %q", name, got, tag)
+		}
+	}
+}
+
+func TestOptionsConfig(t *testing.T) {
+	if _, err := (runtime.Options{}).Config(); err == nil {
+		t.Error("Config() without a root should fail")
+	}
+
+	cfg, err := runtime.Options{Root: "/repo", ExecWhitelist: []string{"go test"}}.Config()
+	if err != nil {
+		t.Fatalf("Config() error = %v", err)
+	}
+	if cfg.RepositoryRoot != "/repo" || cfg.MaxFileSize != config.DefaultMaxFileSize || cfg.MaxWriteSize != config.DefaultMaxWriteSize {
+		t.Errorf("Config() sizes = %d, %d", cfg.MaxFileSize, cfg.MaxWriteSize)
+	}
+	if !reflect.DeepEqual(cfg.ExcludedPaths, config.DefaultExcludedPaths) || !reflect.DeepEqual(cfg.AllowedExtensions, config.DefaultAllowedExtensions) {
+		t.Errorf("Config() paths = %v, extensions = %v", cfg.ExcludedPaths, cfg.AllowedExtensions)
+	}
+	if !cfg.BackupBeforeWrite || !cfg.ConflictCheck || !cfg.RespectIgnoreFiles || cfg.SyntaxCheck != config.SyntaxCheckReject {
+		t.Errorf("Config() weakened a default safeguard: %+v", cfg)
+	}
+	if cfg.ExecValidation != config.ExecValidationStrict || cfg.ExecContainerImage != runtime.DefaultExecImage || cfg.IOContainerImage != runtime.DefaultIOImage {
+		t.Errorf("Config() exec = %s, %s, %s", cfg.ExecValidation, cfg.ExecContainerImage, cfg.IOContainerImage)
+	}
+
+	cfg, err = runtime.Options{Root: "/repo", MaxFileSize: 10, ExcludedPaths: []string{}, DisableBackups: true}.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxFileSize != 10 || len(cfg.ExcludedPaths) != 0 || cfg.BackupBeforeWrite {
+		t.Errorf("Config() ignored options: %+v", cfg)
+	}
+}
+
+func TestSession_Validation(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("SECRET=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	session, err := runtime.New(runtime.Options{Root: root})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer session.Close()
+	ctx := context.Background()
+
+	// Each is refused before anything runs in a container
+	tests := []struct {
+		name string
+		run  func() (runtime.Result, error)
+		code string
+	}{
+		{"open excluded path", func() (runtime.Result, error) { return session.Open(ctx, ".env") }, "PATH_SECURITY"},
+		{"open outside root", func() (runtime.Result, error) { return session.Open(ctx, "../outside.txt") }, "PATH_SECURITY"},
+		{"write disallowed extension", func() (runtime.Result, error) { return session.Write(ctx, "run.sh", "echo hi\n") }, "EXTENSION_DENIED"},
+		{"exec without whitelist", func() (runtime.Result, error) { return session.Exec(ctx, "rm -rf /") }, "EXEC_VALIDATION"},
+	}
+	for _, tt := range tests {
+		result, err := tt.run()
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if result.Success || result.ErrorCode != tt.code {
+			t.Errorf("%s: result = %+v, want %s", tt.name, result, tt.code)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// Cache sources besides host directories
+const (
+	CacheVolume = "volume" // A named Docker volume managed by llm-runtime
+	CacheNone   = "none"   // No cache; the tool downloads into the container's tmpfs
+)
+
+// cacheLabel marks the Docker volumes llm-runtime creates for caches
+const cacheLabel = "llm-runtime.cache"
+
+// dependencyCache is where a tool's cache lives in exec containers and the
+// environment that points the tool at it
+type dependencyCache struct {
+	target string
+	env    []string
+}
+
+// dependencyCaches are the caches exec containers can mount, by name
+var dependencyCaches = map[string]dependencyCache{
+	"go":  {"/cache/go", []string{"GOMODCACHE=/cache/go/mod", "GOCACHE=/cache/go/build"}},
+	"npm": {"/cache/npm", []string{"npm_config_cache=/cache/npm"}},
+	"pip": {"/cache/pip", []string{"PIP_CACHE_DIR=/cache/pip"}},
+}
+
+// CacheMount mounts a dependency cache into exec containers, so repeated
+// commands don't download the same modules and packages again
+type CacheMount struct {
+	Name   string // go, npm, or pip
+	Source string // CacheVolume, or a host directory
+}
+
+// CacheNames returns the names of the caches exec containers can mount
+func CacheNames() []string {
+	names := make([]string, 0, len(dependencyCaches))
+	for name := range dependencyCaches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CacheVolumeName returns the Docker volume holding a named cache
+func CacheVolumeName(name string) string {
+	return "llm-runtime-cache-" + name
+}
+
+// ParseCacheMounts checks a map of cache names to sources and returns the
+// mounts it describes, sorted by name. Sources are CacheVolume, CacheNone,
+// or a host directory, which may start with ~/.
+func ParseCacheMounts(sources map[string]string) ([]CacheMount, error) {
+	var mounts []CacheMount
+	for name, source := range sources {
+		if _, ok := dependencyCaches[name]; !ok {
+			return nil, fmt.Errorf("unknown cache %q (want one of %s)", name, strings.Join(CacheNames(), ", "))
+		}
+		switch source {
+		case CacheNone:
+			continue
+		case CacheVolume:
+		default:
+			dir, err := expandHome(source)
+			if err != nil {
+				return nil, err
+			}
+			if !filepath.IsAbs(dir) {
+				return nil, fmt.Errorf("cache %s: %q is not volume, none, or an absolute directory", name, source)
+			}
+			source = dir
+		}
+		mounts = append(mounts, CacheMount{Name: name, Source: source})
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Name < mounts[j].Name })
+	return mounts, nil
+}
+
+// expandHome replaces a leading ~/ with the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find home directory: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// cacheMounts prepares the caches for a container running image and
+// returns their mounts and environment
+func cacheMounts(ctx context.Context, cli *client.Client, image string, caches []CacheMount) ([]mount.Mount, []string, error) {
+	var mounts []mount.Mount
+	var env []string
+	for _, cache := range caches {
+		layout, ok := dependencyCaches[cache.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown cache %q", cache.Name)
+		}
+
+		if cache.Source == CacheVolume {
+			name := CacheVolumeName(cache.Name)
+			if err := ensureCacheVolume(ctx, cli, image, name); err != nil {
+				return nil, nil, fmt.Errorf("cache %s: %w", cache.Name, err)
+			}
+			mounts = append(mounts, mount.Mount{Type: mount.TypeVolume, Source: name, Target: layout.target})
+		} else {
+			if err := os.MkdirAll(cache.Source, 0755); err != nil {
+				return nil, nil, fmt.Errorf("cache %s: %w", cache.Name, err)
+			}
+			mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: HostMountPath(cache.Source), Target: layout.target})
+		}
+		env = append(env, layout.env...)
+	}
+	return mounts, env, nil
+}
+
+// ensureCacheVolume creates a cache volume the first time it is used.
+// Docker creates volumes owned by root, so a short-lived container hands
+// the new volume to the unprivileged user exec commands run as.
+func ensureCacheVolume(ctx context.Context, cli *client.Client, image, name string) error {
+	if _, err := cli.VolumeInspect(ctx, name); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	_, err := cli.VolumeCreate(ctx, volume.CreateOptions{
+		Name:   name,
+		Labels: map[string]string{cacheLabel: "true"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create volume: %w", err)
+	}
+
+	resp, err := cli.ContainerCreate(ctx,
+		&container.Config{
+			Image:  image,
+			Cmd:    strslice.StrSlice{"chown", "1000:1000", "/cache"},
+			User:   "0:0",
+			Labels: containerLabels("cache", "", ""),
+		},
+		&container.HostConfig{
+			NetworkMode: "none",
+			Mounts:      []mount.Mount{{Type: mount.TypeVolume, Source: name, Target: "/cache"}},
+			CapDrop:     strslice.StrSlice{"ALL"},
+			CapAdd:      strslice.StrSlice{"CHOWN"},
+			SecurityOpt: []string{"no-new-privileges"},
+		}, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create container: %w", err)
+	}
+	defer cli.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+
+	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("error waiting for container: %w", err)
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("chown exited with code %d", status.StatusCode)
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// CacheVolumeInfo describes a cache volume
+type CacheVolumeInfo struct {
+	Name      string
+	CreatedAt string
+}
+
+// ListCacheVolumes returns the cache volumes llm-runtime has created
+func ListCacheVolumes(ctx context.Context) ([]CacheVolumeInfo, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer cli.Close()
+
+	resp, err := cli.VolumeList(ctx, volume.ListOptions{Filters: filters.NewArgs(filters.Arg("label", cacheLabel))})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	var volumes []CacheVolumeInfo
+	for _, v := range resp.Volumes {
+		volumes = append(volumes, CacheVolumeInfo{Name: v.Name, CreatedAt: v.CreatedAt})
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes, nil
+}
+
+// PruneCacheVolumes removes the cache volumes llm-runtime has created and
+// returns their names. Host directories used as caches are left alone.
+func PruneCacheVolumes(ctx context.Context) ([]string, error) {
+	volumes, err := ListCacheVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer cli.Close()
+
+	var removed []string
+	for _, v := range volumes {
+		if err := cli.VolumeRemove(ctx, v.Name, false); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", v.Name, err)
+		}
+		removed = append(removed, v.Name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,46 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCacheMounts(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	mounts, err := ParseCacheMounts(map[string]string{
+		"go":  CacheVolume,
+		"npm": "~/.npm",
+		"pip": CacheNone,
+	})
+	if err != nil {
+		t.Fatalf("ParseCacheMounts: %v", err)
+	}
+	want := []CacheMount{
+		{Name: "go", Source: CacheVolume},
+		{Name: "npm", Source: filepath.Join(home, ".npm")},
+	}
+	if !reflect.DeepEqual(mounts, want) {
+		t.Errorf("ParseCacheMounts = %v, want %v", mounts, want)
+	}
+
+	for _, sources := range []map[string]string{
+		{"cargo": CacheVolume},
+		{"go": "relative/dir"},
+	} {
+		if _, err := ParseCacheMounts(sources); err == nil {
+			t.Errorf("ParseCacheMounts(%v) expected error", sources)
+		}
+	}
+}
+
+func TestCacheVolumeName(t *testing.T) {
+	if got := CacheVolumeName("go"); got != "llm-runtime-cache-go" {
+		t.Errorf("CacheVolumeName = %q", got)
+	}
+}
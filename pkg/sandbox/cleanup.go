@@ -0,0 +1,155 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// execTempPrefix names the scratch directories mounted at /tmp/workspace
+const execTempPrefix = "llm-exec-"
+
+// Leftover is a container or scratch directory removed by a cleanup
+type Leftover struct {
+	ID      string // Short container ID, or directory path
+	Kind    string // exec, io, pool, cache, or temp
+	Session string // Session that created it; empty if unknown
+	Repo    string // Repository the container mounted; empty for directories
+	Age     time.Duration
+	Size    int64 // Bytes freed, for directories
+}
+
+// CleanupContainers removes the containers llm-runtime created more than
+// olderThan ago. Containers whose process is still running on this host
+// are kept however old they are, so a long session keeps its pool.
+func CleanupContainers(ctx context.Context, olderThan time.Duration) ([]Leftover, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer cli.Close()
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", kindLabel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	var removed []Leftover
+	for _, c := range containers {
+		age := time.Since(time.Unix(c.Created, 0))
+		if age < olderThan || ownerRunning(c.Labels[ownerLabel], host) {
+			continue
+		}
+		if err := cli.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			return removed, fmt.Errorf("failed to remove container %s: %w", shortID(c.ID), err)
+		}
+		removed = append(removed, Leftover{
+			ID:      shortID(c.ID),
+			Kind:    c.Labels[kindLabel],
+			Session: c.Labels[sessionLabel],
+			Repo:    c.Labels[repoLabel],
+			Age:     age,
+		})
+	}
+	return removed, nil
+}
+
+// CleanupTempDirs removes exec scratch directories in the system temp
+// directory last modified more than olderThan ago
+func CleanupTempDirs(olderThan time.Duration) ([]Leftover, error) {
+	return cleanupTempDirs(os.TempDir(), olderThan)
+}
+
+func cleanupTempDirs(dir string, olderThan time.Duration) ([]Leftover, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Leftover
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), execTempPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		age := time.Since(info.ModTime())
+		if age < olderThan {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		size := DirSize(path)
+		if err := os.RemoveAll(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, Leftover{ID: path, Kind: "temp", Age: age, Size: size})
+	}
+	return removed, nil
+}
+
+// Owner identifies this process as host:pid, as containers are labeled,
+// for what a session leaves behind that cleanup must spare while it runs
+func Owner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// OwnerRunning reports whether the process Owner identified is still
+// running on this host
+func OwnerRunning(owner string) bool {
+	host, _ := os.Hostname()
+	return ownerRunning(owner, host)
+}
+
+// ownerRunning reports whether the process in an owner label is still
+// running on host
+func ownerRunning(owner, host string) bool {
+	i := strings.LastIndex(owner, ":")
+	if i < 0 || owner[:i] != host {
+		return false
+	}
+	pid, err := strconv.Atoi(owner[i+1:])
+	if err != nil {
+		return false
+	}
+	return pid == os.Getpid() || processRunning(pid)
+}
+
+// DirSize returns the total size of the files under dir. Entries that
+// cannot be read are skipped.
+func DirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// shortID returns the 12 character form of a container ID Docker prints
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,69 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanupTempDirs(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, execTempPrefix+"old")
+	recent := filepath.Join(dir, execTempPrefix+"recent")
+	other := filepath.Join(dir, "other-old")
+	for _, d := range []string{old, recent, other} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(old, "out.txt"), []byte("12345"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	for _, d := range []string{old, other} {
+		if err := os.Chtimes(d, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := cleanupTempDirs(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("cleanupTempDirs() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0].ID != old || removed[0].Size != 5 || removed[0].Kind != "temp" {
+		t.Fatalf("removed = %+v, want only %s with 5 bytes", removed, old)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("old scratch directory still exists")
+	}
+	for _, d := range []string{recent, other} {
+		if _, err := os.Stat(d); err != nil {
+			t.Errorf("%s was removed: %v", d, err)
+		}
+	}
+}
+
+func TestOwnerRunning(t *testing.T) {
+	host, _ := os.Hostname()
+	tests := []struct {
+		name  string
+		owner string
+		want  bool
+	}{
+		{"this process", fmt.Sprintf("%s:%d", host, os.Getpid()), true},
+		{"parent process", fmt.Sprintf("%s:%d", host, os.Getppid()), true},
+		{"other host", fmt.Sprintf("%s-other:%d", host, os.Getpid()), false},
+		{"no label", "", false},
+		{"bad pid", host + ":abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ownerRunning(tt.owner, host); got != tt.want {
+				t.Errorf("ownerRunning(%q) = %v, want %v", tt.owner, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,6 +11,9 @@ import (
 
 // CheckDockerAvailability verifies Docker is installed and accessible
 func CheckDockerAvailability() error {
+	if fake != nil {
+		return nil
+	}
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return fmt.Errorf("Docker not available: %w", err)
@@ -26,8 +29,32 @@ func CheckDockerAvailability() error {
 	return nil
 }
 
+// ImageExists reports whether image is available locally
+func ImageExists(image string) (bool, error) {
+	if fake != nil {
+		return true, nil
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return false, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer cli.Close()
+
+	_, _, err = cli.ImageInspectWithRaw(context.Background(), image)
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // PullDockerImage ensures the required image is available
 func PullDockerImage(image string, verbose bool) error {
+	if fake != nil {
+		return nil
+	}
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
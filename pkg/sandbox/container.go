@@ -2,6 +2,7 @@ package sandbox
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +14,10 @@ import (
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
 )
 
 // ContainerConfig holds configuration for running a container
@@ -24,6 +29,11 @@ type ContainerConfig struct {
 	CPULimit    int
 	Timeout     time.Duration
 	Stdin       string // NEW: stdin content to pass to container
+	Caches      []CacheMount
+	Env         []string // NAME=value pairs, overriding the caches' variables
+	WorkDir     string   // Working directory in the container; empty for /workspace
+	Scratch     string   // Host directory mounted writable at /scratch; empty for none
+	ScratchMem  bool     // Mount an in-memory /scratch of the container's own instead of Scratch
 }
 
 // ContainerResult holds the result of container execution
@@ -36,11 +46,36 @@ type ContainerResult struct {
 
 // RunContainer executes a command in a Docker container with security restrictions
 func RunContainer(cfg ContainerConfig) (ContainerResult, error) {
+	return RunContainerContext(context.Background(), cfg)
+}
+
+// RunContainerContext is RunContainer as part of the trace in ctx
+func RunContainerContext(ctx context.Context, cfg ContainerConfig) (ContainerResult, error) {
+	ctx, span := telemetry.Start(ctx, "sandbox.run_container",
+		attribute.String("container.image", cfg.Image),
+		attribute.String("exec.command", cfg.Command),
+		attribute.Int("exec.stdin_bytes", len(cfg.Stdin)),
+	)
+	result, err := runContainer(ctx, cfg)
+	span.SetAttributes(
+		attribute.Int("exec.exit_code", result.ExitCode),
+		attribute.Int("exec.stdout_bytes", len(result.Stdout)),
+		attribute.Int("exec.stderr_bytes", len(result.Stderr)),
+	)
+	telemetry.End(span, err)
+	return result, err
+}
+
+func runContainer(ctx context.Context, cfg ContainerConfig) (ContainerResult, error) {
+	if fake != nil {
+		return fake.run(ctx, cfg)
+	}
+
 	startTime := time.Now()
 	result := ContainerResult{}
 
 	// Create temporary directory for container writes
-	tempDir, err := os.MkdirTemp("", "llm-exec-")
+	tempDir, err := os.MkdirTemp("", execTempPrefix)
 	if err != nil {
 		return result, fmt.Errorf("failed to create temp dir: %w", err)
 	}
@@ -54,15 +89,27 @@ func RunContainer(cfg ContainerConfig) (ContainerResult, error) {
 	defer cli.Close()
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
 	defer cancel()
 
+	caches, cacheEnv, err := cacheMounts(ctx, cli, cfg.Image, cfg.Caches)
+	if err != nil {
+		return result, fmt.Errorf("failed to prepare caches: %w", err)
+	}
+
+	workDir := cfg.WorkDir
+	if workDir == "" {
+		workDir = "/workspace"
+	}
+
 	// Configure container
 	containerConfig := &container.Config{
 		Image:      cfg.Image,
 		Cmd:        strslice.StrSlice{"sh", "-c", cfg.Command},
-		WorkingDir: "/workspace",
+		Env:        mergeEnv(cacheEnv, cfg.Env),
+		WorkingDir: workDir,
 		User:       "1000:1000",
+		Labels:     commandLabels(ctx, "exec", cfg.RepoRoot, cfg.Command),
 	}
 
 	// Enable stdin if provided
@@ -82,13 +129,13 @@ func RunContainer(cfg ContainerConfig) (ContainerResult, error) {
 		Mounts: []mount.Mount{
 			{
 				Type:     mount.TypeBind,
-				Source:   cfg.RepoRoot,
+				Source:   HostMountPath(cfg.RepoRoot),
 				Target:   "/workspace",
 				ReadOnly: true,
 			},
 			{
 				Type:   mount.TypeBind,
-				Source: tempDir,
+				Source: HostMountPath(tempDir),
 				Target: "/tmp/workspace",
 			},
 		},
@@ -102,12 +149,25 @@ func RunContainer(cfg ContainerConfig) (ContainerResult, error) {
 		},
 	}
 
+	hostConfig.Mounts = append(hostConfig.Mounts, caches...)
+	if cfg.ScratchMem {
+		hostConfig.Tmpfs["/scratch"] = ""
+	} else if cfg.Scratch != "" {
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:   mount.TypeBind,
+			Source: HostMountPath(cfg.Scratch),
+			Target: "/scratch",
+		})
+	}
+
 	// Create container
+	launchStart := time.Now()
 	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
 	if err != nil {
 		return result, fmt.Errorf("failed to create container: %w", err)
 	}
-	defer cli.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+	// Removal runs after the timeout has expired ctx
+	defer cli.ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true})
 
 	// Attach stdin if provided
 	var hijackedResp types.HijackedResponse
@@ -129,6 +189,7 @@ func RunContainer(cfg ContainerConfig) (ContainerResult, error) {
 	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
 		return result, fmt.Errorf("failed to start container: %w", err)
 	}
+	metrics.ObserveLaunch("exec", launchStart)
 
 	// Write stdin if provided
 	if cfg.Stdin != "" {
@@ -140,21 +201,38 @@ func RunContainer(cfg ContainerConfig) (ContainerResult, error) {
 	}
 
 	// Wait for container to finish
+	var runErr error
+	stopped := false
 	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
 	select {
 	case err := <-errCh:
-		if err != nil {
+		if err != nil && ctx.Err() == nil {
 			return result, fmt.Errorf("error waiting for container: %w", err)
 		}
+		stopped = err != nil
 	case status := <-statusCh:
 		result.ExitCode = int(status.StatusCode)
 	case <-ctx.Done():
-		result.ExitCode = 124 // Standard timeout exit code
-		return result, fmt.Errorf("command timed out after %v", cfg.Timeout)
+		stopped = true
+	}
+	if stopped {
+		// Stop the command and keep the output it wrote until then
+		if err := stopContainer(cli, resp.ID); err != nil {
+			return result, fmt.Errorf("failed to stop container: %w", err)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.ExitCode = TimeoutExitCode
+			runErr = fmt.Errorf("command %w after %v", ErrTimeout, cfg.Timeout)
+		} else {
+			result.ExitCode = InterruptExitCode
+			runErr = fmt.Errorf("command %w", ErrInterrupted)
+		}
 	}
 
 	// Get container logs
-	logReader, err := cli.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{
+	logCtx, logCancel := context.WithTimeout(context.Background(), TimeoutGracePeriod+10*time.Second)
+	defer logCancel()
+	logReader, err := cli.ContainerLogs(logCtx, resp.ID, types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 	})
@@ -173,6 +251,9 @@ func RunContainer(cfg ContainerConfig) (ContainerResult, error) {
 	result.Stderr = stderr.String()
 	result.Duration = time.Since(startTime)
 
+	if runErr != nil {
+		return result, runErr
+	}
 	if result.ExitCode != 0 {
 		return result, fmt.Errorf("command exited with code %d", result.ExitCode)
 	}
@@ -234,6 +315,10 @@ func demuxLogs(reader io.Reader, stdout, stderr io.Writer) error {
 
 // ExecuteInPooledContainer executes a command using a container from the pool
 func ExecuteInPooledContainer(ctx context.Context, pool *ContainerPool, command string, repoRoot string) (string, error) {
+	if fake != nil {
+		result, err := runContainer(ctx, ContainerConfig{Command: command, RepoRoot: repoRoot})
+		return result.Stdout, err
+	}
 	if pool == nil {
 		// Fallback to creating a new container if pool not available
 		cfg := ContainerConfig{
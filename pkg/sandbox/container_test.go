@@ -1,6 +1,7 @@
 package sandbox
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -217,7 +218,7 @@ func TestRunContainer_Timeout(t *testing.T) {
 
 	cfg := ContainerConfig{
 		Image:       "alpine:latest",
-		Command:     "sleep 60", // Sleep longer than timeout
+		Command:     "echo started; sleep 60", // Sleep longer than timeout
 		RepoRoot:    tmpDir,
 		MemoryLimit: "128m",
 		CPULimit:    1,
@@ -229,8 +230,8 @@ func TestRunContainer_Timeout(t *testing.T) {
 	elapsed := time.Since(start)
 
 	// Should return error for timeout
-	if err == nil {
-		t.Error("expected error for timeout")
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
 	}
 
 	// Should have timed out, not run for 60 seconds
@@ -239,8 +240,13 @@ func TestRunContainer_Timeout(t *testing.T) {
 	}
 
 	// Exit code 124 is standard for timeout
-	if result.ExitCode != 124 {
-		t.Logf("timeout exit code: %d (expected 124)", result.ExitCode)
+	if result.ExitCode != TimeoutExitCode {
+		t.Errorf("timeout exit code: %d (expected %d)", result.ExitCode, TimeoutExitCode)
+	}
+
+	// Output written before the timeout is kept
+	if !strings.Contains(result.Stdout, "started") {
+		t.Errorf("expected partial stdout, got %q", result.Stdout)
 	}
 }
 
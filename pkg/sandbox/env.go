@@ -0,0 +1,65 @@
+package sandbox
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envName matches the environment variable names exec containers accept
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateEnvName checks that name can be set in an exec container
+func ValidateEnvName(name string) error {
+	if !envName.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	return nil
+}
+
+// ExecEnv returns the environment of an exec container: the static values,
+// then the allowed host variables looked up with lookup, which win over
+// static values of the same name. Host variables that are not set are left
+// out. It also returns the names it set, sorted, for the audit log.
+func ExecEnv(static map[string]string, passthrough []string, lookup func(string) (string, bool)) ([]string, []string) {
+	values := make(map[string]string, len(static)+len(passthrough))
+	for name, value := range static {
+		values[name] = value
+	}
+	for _, name := range passthrough {
+		if value, ok := lookup(name); ok {
+			values[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, name+"="+values[name])
+	}
+	return env, names
+}
+
+// mergeEnv returns base with the variables in overrides replacing those of
+// the same name, so no variable appears twice
+func mergeEnv(base, overrides []string) []string {
+	set := make(map[string]bool, len(overrides))
+	for _, kv := range overrides {
+		name, _, _ := strings.Cut(kv, "=")
+		set[name] = true
+	}
+
+	var env []string
+	for _, kv := range base {
+		if name, _, _ := strings.Cut(kv, "="); !set[name] {
+			env = append(env, kv)
+		}
+	}
+	return append(env, overrides...)
+}
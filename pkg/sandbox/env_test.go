@@ -0,0 +1,54 @@
+package sandbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExecEnv(t *testing.T) {
+	host := map[string]string{"CI": "true", "GOFLAGS": "-mod=mod", "AWS_SECRET_ACCESS_KEY": "secret"}
+	lookup := func(name string) (string, bool) {
+		value, ok := host[name]
+		return value, ok
+	}
+
+	env, names := ExecEnv(
+		map[string]string{"GOFLAGS": "-count=1", "TZ": "UTC"},
+		[]string{"CI", "GOFLAGS", "UNSET"},
+		lookup,
+	)
+
+	wantEnv := []string{"CI=true", "GOFLAGS=-mod=mod", "TZ=UTC"}
+	if !reflect.DeepEqual(env, wantEnv) {
+		t.Errorf("env = %v, want %v", env, wantEnv)
+	}
+	wantNames := []string{"CI", "GOFLAGS", "TZ"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("names = %v, want %v", names, wantNames)
+	}
+
+	if env, names := ExecEnv(nil, nil, lookup); len(env) != 0 || len(names) != 0 {
+		t.Errorf("empty allowlist passed %v", env)
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	got := mergeEnv([]string{"GOCACHE=/cache/go/build", "PIP_CACHE_DIR=/cache/pip"}, []string{"GOCACHE=/tmp/gocache", "CI=1"})
+	want := []string{"PIP_CACHE_DIR=/cache/pip", "GOCACHE=/tmp/gocache", "CI=1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEnv = %v, want %v", got, want)
+	}
+}
+
+func TestValidateEnvName(t *testing.T) {
+	for _, name := range []string{"CI", "_X", "go_flags2"} {
+		if err := ValidateEnvName(name); err != nil {
+			t.Errorf("ValidateEnvName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "2X", "A-B", "A=B"} {
+		if err := ValidateEnvName(name); err == nil {
+			t.Errorf("ValidateEnvName(%q) expected error", name)
+		}
+	}
+}
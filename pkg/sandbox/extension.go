@@ -1,8 +1,9 @@
 package sandbox
 
 import (
-	"fmt"
 	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
 )
 
 // ValidateWriteExtension checks if the file extension is allowed for writing
@@ -13,7 +14,7 @@ func ValidateWriteExtension(filePath string, allowedExtensions []string) error {
 
 	lastDot := strings.LastIndex(filePath, ".")
 	if lastDot == -1 {
-		return fmt.Errorf("file has no extension")
+		return errors.New(errors.ExtensionDenied, "file has no extension")
 	}
 
 	ext := strings.ToLower(filePath[lastDot:])
@@ -23,5 +24,5 @@ func ValidateWriteExtension(filePath string, allowedExtensions []string) error {
 		}
 	}
 
-	return fmt.Errorf("file extension not allowed: %s", ext)
+	return errors.Newf(errors.ExtensionDenied, "file extension not allowed: %s", ext)
 }
@@ -0,0 +1,189 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Runner runs commands the way RunContainer does: a nonzero exit code
+// comes with an error, and a command stopped by its timeout or by ctx
+// with ErrTimeout or ErrInterrupted wrapped in it
+type Runner interface {
+	Run(ctx context.Context, cfg ContainerConfig) (ContainerResult, error)
+}
+
+// FakeBackend stands in for Docker, for deterministic runs and tests that
+// need no daemon. File reads and writes that would go through an I/O
+// container go straight to the host, and commands that would run in a
+// container go to Runner.
+type FakeBackend struct {
+	Runner Runner // Runs commands; nil for every command to exit 0 with no output
+}
+
+// ErrFakeBackend is returned for what the fake backend cannot stand in
+// for, such as an interactive interpreter
+var ErrFakeBackend = errors.New("not available with the fake container backend")
+
+var fake *FakeBackend
+
+// SetFakeBackend routes container work to b from now on, or back to
+// Docker when b is nil
+func SetFakeBackend(b *FakeBackend) {
+	fake = b
+}
+
+// run runs a command that would run in a container
+func (b *FakeBackend) run(ctx context.Context, cfg ContainerConfig) (ContainerResult, error) {
+	if b.Runner == nil {
+		return ContainerResult{}, nil
+	}
+	return b.Runner.Run(ctx, cfg)
+}
+
+// FakeResponse is how a FakeRunner answers a command
+type FakeResponse struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Latency  time.Duration // How long the command seems to run; a timeout or ctx can cut it short
+	Err      error         // Failure to run at all, such as a missing image; returned without output
+}
+
+// FakeRunner is a scriptable Runner for tests. It answers each command
+// line from Responses, or with Default, and records what it was asked to
+// run.
+type FakeRunner struct {
+	Responses map[string]FakeResponse // By command line
+	Default   FakeResponse            // For command lines not in Responses
+
+	mu    sync.Mutex
+	calls []ContainerConfig
+}
+
+// Run answers cfg.Command after its latency, as a container would: with a
+// timeout error once cfg.Timeout passes, or an interrupt when ctx ends
+func (r *FakeRunner) Run(ctx context.Context, cfg ContainerConfig) (ContainerResult, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, cfg)
+	response, ok := r.Responses[cfg.Command]
+	if !ok {
+		response = r.Default
+	}
+	r.mu.Unlock()
+
+	if response.Err != nil {
+		return ContainerResult{}, response.Err
+	}
+	result := ContainerResult{
+		ExitCode: response.ExitCode,
+		Stdout:   response.Stdout,
+		Stderr:   response.Stderr,
+		Duration: response.Latency,
+	}
+
+	if response.Latency > 0 {
+		var timeout <-chan time.Time
+		if cfg.Timeout > 0 && cfg.Timeout < response.Latency {
+			timeout = time.After(cfg.Timeout)
+		}
+		select {
+		case <-time.After(response.Latency):
+		case <-timeout:
+			result.ExitCode = TimeoutExitCode
+			result.Duration = cfg.Timeout
+			return result, fmt.Errorf("command %w after %v", ErrTimeout, cfg.Timeout)
+		case <-ctx.Done():
+			result.ExitCode = InterruptExitCode
+			return result, fmt.Errorf("command %w", ErrInterrupted)
+		}
+	}
+
+	if result.ExitCode != 0 {
+		return result, fmt.Errorf("command exited with code %d", result.ExitCode)
+	}
+	return result, nil
+}
+
+// Calls returns the containers the runner was asked to run, in order
+func (r *FakeRunner) Calls() []ContainerConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ContainerConfig(nil), r.calls...)
+}
+
+// readFile reads length bytes of the file at path from offset, or all of
+// it from offset when length is negative
+func (b *FakeBackend) readFile(path string, offset, length int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	var r io.Reader = f
+	if length >= 0 {
+		r = io.LimitReader(f, length)
+	}
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+// readTail reads the last lines of the file at path, as tail -n does
+func (b *FakeBackend) readTail(path string, lines int) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if lines <= 0 {
+		return "", nil
+	}
+
+	// A final newline ends the last line rather than starting another
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	start := end
+	for n := 0; n < lines; n++ {
+		i := bytes.LastIndexByte(data[:start], '\n')
+		if i < 0 {
+			return string(data), nil
+		}
+		start = i
+	}
+	return string(data[start+1:]), nil
+}
+
+// writeFile writes data to path through a temporary file, as the I/O
+// container does, giving it mode unless mode is 0
+func (b *FakeBackend) writeFile(path string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if mode != 0 {
+		if err := os.Chmod(tmp, mode.Perm()); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func useFake(t *testing.T, b *FakeBackend) {
+	t.Helper()
+	SetFakeBackend(b)
+	t.Cleanup(func() { SetFakeBackend(nil) })
+}
+
+func TestFakeRunner(t *testing.T) {
+	r := &FakeRunner{
+		Responses: map[string]FakeResponse{
+			"go test ./...": {Stdout: "ok\n"},
+			"go vet ./...":  {Stderr: "vet: bad\n", ExitCode: 1},
+			"sleep 10":      {Stdout: "started\n", Latency: time.Hour},
+			"missing":       {Err: errors.New("no such image")},
+		},
+		Default: FakeResponse{Stdout: "default\n"},
+	}
+	useFake(t, &FakeBackend{Runner: r})
+
+	result, err := RunContainer(ContainerConfig{Command: "go test ./..."})
+	if err != nil || result.Stdout != "ok\n" {
+		t.Errorf("success = %+v, %v", result, err)
+	}
+	result, err = RunContainer(ContainerConfig{Command: "go vet ./..."})
+	if err == nil || result.ExitCode != 1 || result.Stderr != "vet: bad\n" {
+		t.Errorf("failure = %+v, %v, want exit code 1", result, err)
+	}
+	result, err = RunContainer(ContainerConfig{Command: "echo hi"})
+	if err != nil || result.Stdout != "default\n" {
+		t.Errorf("unscripted = %+v, %v, want Default", result, err)
+	}
+	if _, err := RunContainer(ContainerConfig{Command: "missing"}); err == nil || err.Error() != "no such image" {
+		t.Errorf("Err = %v", err)
+	}
+
+	// Latency is cut short as a container would be
+	result, err = RunContainer(ContainerConfig{Command: "sleep 10", Timeout: 10 * time.Millisecond})
+	if !errors.Is(err, ErrTimeout) || result.ExitCode != TimeoutExitCode || result.Stdout != "started\n" {
+		t.Errorf("timeout = %+v, %v", result, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = RunContainerContext(ctx, ContainerConfig{Command: "sleep 10"})
+	if !errors.Is(err, ErrInterrupted) || result.ExitCode != InterruptExitCode {
+		t.Errorf("interrupt = %+v, %v", result, err)
+	}
+
+	var commands []string
+	for _, cfg := range r.Calls() {
+		commands = append(commands, cfg.Command)
+	}
+	want := []string{"go test ./...", "go vet ./...", "echo hi", "missing", "sleep 10", "sleep 10"}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("Calls() = %q, want %q", commands, want)
+	}
+	if err := CheckDockerAvailability(); err != nil {
+		t.Errorf("CheckDockerAvailability() = %v", err)
+	}
+}
+
+func TestFakeBackend_NoRunner(t *testing.T) {
+	useFake(t, &FakeBackend{})
+	result, err := RunContainer(ContainerConfig{Command: "make all"})
+	if err != nil || result != (ContainerResult{}) {
+		t.Errorf("RunContainer() = %+v, %v, want exit 0 with no output", result, err)
+	}
+}
+
+func TestFakeBackend_Files(t *testing.T) {
+	useFake(t, &FakeBackend{})
+	root := t.TempDir()
+	path := filepath.Join(root, "logs", "app.log")
+
+	if err := writeFileInContainer(context.Background(), path, "one\ntwo\nthree\n", root, "", time.Second, "", 1, 0755); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, want 0755", info.Mode().Perm())
+	}
+
+	if got, err := ReadFileInContainer(path, root, "", time.Second, "", 1); err != nil || got != "one\ntwo\nthree\n" {
+		t.Errorf("read = %q, %v", got, err)
+	}
+	tails := map[int]string{0: "", 1: "three\n", 2: "two\nthree\n", 5: "one\ntwo\nthree\n"}
+	for lines, want := range tails {
+		if got, err := readFileTailInContainerPooled(context.Background(), nil, path, root, lines); err != nil || got != want {
+			t.Errorf("tail %d = %q, %v, want %q", lines, got, err, want)
+		}
+	}
+
+	if _, err := StartREPL(context.Background(), REPLConfig{Image: "python:3.12-slim"}); !errors.Is(err, ErrFakeBackend) {
+		t.Errorf("StartREPL() error = %v, want ErrFakeBackend", err)
+	}
+}
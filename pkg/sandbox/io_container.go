@@ -2,6 +2,7 @@ package sandbox
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"os"
@@ -14,17 +15,31 @@ import (
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
 )
 
 // RunIOContainer executes a containerized I/O operation
 func RunIOContainer(repoRoot, containerImage, command string, timeout time.Duration, memLimit string, cpuLimit int) (string, error) {
+	return runIOContainer(context.Background(), repoRoot, containerImage, command, timeout, memLimit, cpuLimit)
+}
+
+// runIOContainer is RunIOContainer for the command in ctx, whose
+// correlation ID the container is labeled with. Canceling ctx does not
+// stop the operation; only the timeout does.
+func runIOContainer(parent context.Context, repoRoot, containerImage, command string, timeout time.Duration, memLimit string, cpuLimit int) (string, error) {
+	if fake != nil {
+		return "", fmt.Errorf("I/O command %w", ErrFakeBackend)
+	}
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return "", fmt.Errorf("failed to create Docker client: %w", err)
 	}
 	defer cli.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), timeout)
 	defer cancel()
 
 	// Configure container
@@ -33,6 +48,7 @@ func RunIOContainer(repoRoot, containerImage, command string, timeout time.Durat
 		Cmd:        strslice.StrSlice{"/bin/sh", "-c", command},
 		WorkingDir: "/workspace",
 		User:       "1000:1000",
+		Labels:     commandLabels(parent, "io", repoRoot, command),
 	}
 
 	// Configure host
@@ -45,7 +61,7 @@ func RunIOContainer(repoRoot, containerImage, command string, timeout time.Durat
 		Mounts: []mount.Mount{
 			{
 				Type:     mount.TypeBind,
-				Source:   repoRoot,
+				Source:   HostMountPath(repoRoot),
 				Target:   "/workspace",
 				ReadOnly: true,
 			},
@@ -55,16 +71,18 @@ func RunIOContainer(repoRoot, containerImage, command string, timeout time.Durat
 	}
 
 	// Create container
+	launchStart := time.Now()
 	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
 	if err != nil {
 		return "", fmt.Errorf("failed to create container: %w", err)
 	}
-	defer cli.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+	defer cli.ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true})
 
 	// Start container
 	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
 		return "", fmt.Errorf("failed to start container: %w", err)
 	}
+	metrics.ObserveLaunch("io", launchStart)
 
 	// Wait for completion
 	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
@@ -76,7 +94,8 @@ func RunIOContainer(repoRoot, containerImage, command string, timeout time.Durat
 	case <-statusCh:
 		// Container finished
 	case <-ctx.Done():
-		return "", fmt.Errorf("I/O operation timed out after %v", timeout)
+		stopContainer(cli, resp.ID)
+		return "", fmt.Errorf("I/O operation %w after %v", ErrTimeout, timeout)
 	}
 
 	// Get logs
@@ -100,25 +119,38 @@ func RunIOContainer(repoRoot, containerImage, command string, timeout time.Durat
 
 // ReadFileInContainer reads a file using the I/O container
 func ReadFileInContainer(filePath, repoRoot, containerImage string, timeout time.Duration, memLimit string, cpuLimit int) (string, error) {
+	if fake != nil {
+		return fake.readFile(filePath, 0, -1)
+	}
+
 	// Make path relative to repo root for container
 	relPath, err := filepath.Rel(repoRoot, filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get relative path: %w", err)
 	}
 
-	command := fmt.Sprintf("cat /workspace/%s", relPath)
+	command := "cat " + shellQuote(ContainerPath(relPath))
 	return RunIOContainer(repoRoot, containerImage, command, timeout, memLimit, cpuLimit)
 }
 
 // WriteFileInContainer writes a file using the I/O container
 func WriteFileInContainer(filePath, content, repoRoot, containerImage string, timeout time.Duration, memLimit string, cpuLimit int) error {
+	return writeFileInContainer(context.Background(), filePath, content, repoRoot, containerImage, timeout, memLimit, cpuLimit, 0)
+}
+
+// writeFileInContainer is WriteFileInContainer for the command in ctx,
+// giving the file mode, or the container's default mode if mode is 0
+func writeFileInContainer(parent context.Context, filePath, content, repoRoot, containerImage string, timeout time.Duration, memLimit string, cpuLimit int, mode os.FileMode) error {
+	if fake != nil {
+		return fake.writeFile(filePath, []byte(content), mode)
+	}
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
 	defer cli.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), timeout)
 	defer cancel()
 
 	relPath, err := filepath.Rel(repoRoot, filePath)
@@ -128,8 +160,7 @@ func WriteFileInContainer(filePath, content, repoRoot, containerImage string, ti
 
 	// Write to temp file first, then move (atomic)
 	// Directory creation happens inside container
-	command := fmt.Sprintf("mkdir -p $(dirname /workspace/%s) && printf '%%s' %q > /workspace/%s.tmp && mv /workspace/%s.tmp /workspace/%s",
-		relPath, content, relPath, relPath, relPath)
+	command := writeBytesCommand(ContainerPath(relPath), []byte(content), mode)
 
 	// Configure container with read-write mount
 	containerConfig := &container.Config{
@@ -137,6 +168,7 @@ func WriteFileInContainer(filePath, content, repoRoot, containerImage string, ti
 		Cmd:        strslice.StrSlice{"/bin/sh", "-c", command},
 		WorkingDir: "/workspace",
 		User:       "1000:1000",
+		Labels:     commandLabels(parent, "io", repoRoot, command),
 	}
 
 	hostConfig := &container.HostConfig{
@@ -148,7 +180,7 @@ func WriteFileInContainer(filePath, content, repoRoot, containerImage string, ti
 		Mounts: []mount.Mount{
 			{
 				Type:     mount.TypeBind,
-				Source:   repoRoot,
+				Source:   HostMountPath(repoRoot),
 				Target:   "/workspace",
 				ReadOnly: false, // Read-write for writes
 			},
@@ -158,16 +190,18 @@ func WriteFileInContainer(filePath, content, repoRoot, containerImage string, ti
 	}
 
 	// Create container
+	launchStart := time.Now()
 	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
 	if err != nil {
 		return fmt.Errorf("failed to create container: %w", err)
 	}
-	defer cli.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+	defer cli.ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true})
 
 	// Start container
 	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
 		return fmt.Errorf("failed to start container: %w", err)
 	}
+	metrics.ObserveLaunch("io", launchStart)
 
 	// Wait for completion
 	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
@@ -179,7 +213,8 @@ func WriteFileInContainer(filePath, content, repoRoot, containerImage string, ti
 	case <-statusCh:
 		// Container finished
 	case <-ctx.Done():
-		return fmt.Errorf("write operation timed out after %v", timeout)
+		stopContainer(cli, resp.ID)
+		return fmt.Errorf("write operation %w after %v", ErrTimeout, timeout)
 	}
 
 	return nil
@@ -264,25 +299,125 @@ func readDockerLogs(reader io.Reader, stdout io.Writer) error {
 
 // ReadFileInContainerPooled reads a file using a pooled container
 func ReadFileInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, repoRoot string) (string, error) {
+	ctx, span := telemetry.Start(ctx, "sandbox.read_file",
+		attribute.String("file.path", filePath),
+		attribute.Bool("container.pooled", pool != nil),
+	)
+	content, err := readFileInContainerPooled(ctx, pool, filePath, repoRoot)
+	metrics.BytesRead.Add(float64(len(content)))
+	span.SetAttributes(attribute.Int("file.bytes", len(content)))
+	telemetry.End(span, err)
+	return content, err
+}
+
+func readFileInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, repoRoot string) (string, error) {
+	if fake != nil {
+		return fake.readFile(filePath, 0, -1)
+	}
+	relPath, err := filepath.Rel(repoRoot, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path: %w", err)
+	}
+
+	command := "cat " + shellQuote(ContainerPath(relPath))
 	if pool == nil {
 		// Fallback to non-pooled version
-		return ReadFileInContainer(filePath, repoRoot, "llm-runtime-io:latest", 60*time.Second, "256m", 1)
+		return runIOContainer(ctx, repoRoot, "llm-runtime-io:latest", command, 60*time.Second, "256m", 1)
 	}
+	return ExecuteInPooledContainer(ctx, pool, command, repoRoot)
+}
 
+// ReadFileRangeInContainerPooled reads up to length bytes of a file from
+// offset using a pooled container, so part of a large file can be read
+// without copying all of it out of the container
+func ReadFileRangeInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, repoRoot string, offset, length int64) (string, error) {
+	ctx, span := telemetry.Start(ctx, "sandbox.read_file",
+		attribute.String("file.path", filePath),
+		attribute.Int64("file.offset", offset),
+		attribute.Bool("container.pooled", pool != nil),
+	)
+	content, err := readFileRangeInContainerPooled(ctx, pool, filePath, repoRoot, offset, length)
+	metrics.BytesRead.Add(float64(len(content)))
+	span.SetAttributes(attribute.Int("file.bytes", len(content)))
+	telemetry.End(span, err)
+	return content, err
+}
+
+func readFileRangeInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, repoRoot string, offset, length int64) (string, error) {
+	if fake != nil {
+		return fake.readFile(filePath, offset, length)
+	}
 	relPath, err := filepath.Rel(repoRoot, filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get relative path: %w", err)
 	}
 
-	command := fmt.Sprintf("cat /workspace/%s", relPath)
+	command := readRangeCommand(ContainerPath(relPath), offset, length)
+	if pool == nil {
+		return runIOContainer(ctx, repoRoot, "llm-runtime-io:latest", command, 60*time.Second, "256m", 1)
+	}
+
 	return ExecuteInPooledContainer(ctx, pool, command, repoRoot)
 }
 
-// WriteFileInContainerPooled writes a file using a pooled container
-func WriteFileInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, content, repoRoot string) error {
+// readRangeCommand returns the shell command that prints length bytes of
+// target starting at offset, which is counted from 0
+func readRangeCommand(target string, offset, length int64) string {
+	return fmt.Sprintf("tail -c +%d %s | head -c %d", offset+1, shellQuote(target), length)
+}
+
+// ReadFileTailInContainerPooled reads the last lines of a file using a
+// pooled container
+func ReadFileTailInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, repoRoot string, lines int) (string, error) {
+	ctx, span := telemetry.Start(ctx, "sandbox.read_file",
+		attribute.String("file.path", filePath),
+		attribute.Int("file.tail_lines", lines),
+		attribute.Bool("container.pooled", pool != nil),
+	)
+	content, err := readFileTailInContainerPooled(ctx, pool, filePath, repoRoot, lines)
+	metrics.BytesRead.Add(float64(len(content)))
+	span.SetAttributes(attribute.Int("file.bytes", len(content)))
+	telemetry.End(span, err)
+	return content, err
+}
+
+func readFileTailInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, repoRoot string, lines int) (string, error) {
+	if fake != nil {
+		return fake.readTail(filePath, lines)
+	}
+	relPath, err := filepath.Rel(repoRoot, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path: %w", err)
+	}
+
+	command := fmt.Sprintf("tail -n %d %s", lines, shellQuote(ContainerPath(relPath)))
+	if pool == nil {
+		return runIOContainer(ctx, repoRoot, "llm-runtime-io:latest", command, 60*time.Second, "256m", 1)
+	}
+
+	return ExecuteInPooledContainer(ctx, pool, command, repoRoot)
+}
+
+// WriteFileInContainerPooled writes a file using a pooled container, giving
+// it mode, or the container's default mode if mode is 0
+func WriteFileInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, content, repoRoot string, mode os.FileMode) error {
+	ctx, span := telemetry.Start(ctx, "sandbox.write_file",
+		attribute.String("file.path", filePath),
+		attribute.Int("file.bytes", len(content)),
+		attribute.Bool("container.pooled", pool != nil),
+	)
+	err := writeFileInContainerPooled(ctx, pool, filePath, content, repoRoot, mode)
+	telemetry.End(span, err)
+	return err
+}
+
+func writeFileInContainerPooled(ctx context.Context, pool *ContainerPool, filePath, content, repoRoot string, mode os.FileMode) error {
+	if fake != nil {
+		return fake.writeFile(filePath, []byte(content), mode)
+	}
 	if pool == nil {
 		// Fallback to non-pooled version
-		return WriteFileInContainer(filePath, content, repoRoot, "llm-runtime-io:latest", 60*time.Second, "256m", 1)
+		return writeFileInContainer(ctx, filePath, content, repoRoot, "llm-runtime-io:latest", 60*time.Second, "256m", 1, mode)
 	}
 
 	relPath, err := filepath.Rel(repoRoot, filePath)
@@ -291,9 +426,63 @@ func WriteFileInContainerPooled(ctx context.Context, pool *ContainerPool, filePa
 	}
 
 	// Atomic write: write to temp file then move
-	command := fmt.Sprintf("mkdir -p $(dirname /workspace/%s) && printf '%%s' %q > /workspace/%s.tmp && mv /workspace/%s.tmp /workspace/%s",
-		relPath, content, relPath, relPath, relPath)
+	command := writeBytesCommand(ContainerPath(relPath), []byte(content), mode)
 
 	_, err = ExecuteInPooledContainer(ctx, pool, command, repoRoot)
 	return err
 }
+
+// WriteBytesInContainerPooled writes raw bytes using a pooled container.
+// The data travels base64-encoded and is decoded inside the container, so
+// binary content survives the shell untouched. The file gets mode, or the
+// container's default mode if mode is 0.
+func WriteBytesInContainerPooled(ctx context.Context, pool *ContainerPool, filePath string, data []byte, repoRoot string, mode os.FileMode) error {
+	ctx, span := telemetry.Start(ctx, "sandbox.write_file",
+		attribute.String("file.path", filePath),
+		attribute.Int("file.bytes", len(data)),
+		attribute.Bool("container.pooled", pool != nil),
+	)
+	err := writeBytesInContainerPooled(ctx, pool, filePath, data, repoRoot, mode)
+	telemetry.End(span, err)
+	return err
+}
+
+func writeBytesInContainerPooled(ctx context.Context, pool *ContainerPool, filePath string, data []byte, repoRoot string, mode os.FileMode) error {
+	if fake != nil {
+		return fake.writeFile(filePath, data, mode)
+	}
+	relPath, err := filepath.Rel(repoRoot, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+
+	command := writeBytesCommand(ContainerPath(relPath), data, mode)
+	if pool == nil {
+		_, err = runIOContainer(ctx, repoRoot, "llm-runtime-io:latest", command, 60*time.Second, "256m", 1)
+		return err
+	}
+
+	_, err = ExecuteInPooledContainer(ctx, pool, command, repoRoot)
+	return err
+}
+
+// writeBytesCommand returns the shell command that atomically writes data
+// to target. Text is written this way too, so carriage returns, NUL bytes,
+// and shell metacharacters in the content arrive unchanged. A mode other
+// than 0 is set on the temporary file, so target never has another.
+func writeBytesCommand(target string, data []byte, mode os.FileMode) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	tmp := shellQuote(target + ".tmp")
+	chmod := ""
+	if mode != 0 {
+		chmod = fmt.Sprintf(" && chmod %04o %s", mode.Perm(), tmp)
+	}
+	return fmt.Sprintf("mkdir -p \"$(dirname %s)\" && printf '%%s' '%s' | base64 -d > %s%s && mv %s %s",
+		shellQuote(target), encoded, tmp, chmod, tmp, shellQuote(target))
+}
+
+// shellQuote quotes s as a single word for sh -c, so paths with spaces,
+// quotes, or other metacharacters reach the command intact
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -1,8 +1,10 @@
 package sandbox
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -333,3 +335,67 @@ func BenchmarkReadFile_LargeFile_Containerized(b *testing.B) {
 		ReadFileInContainer(testFile, tmpDir, "alpine:latest", 5*time.Second, "128m", 1)
 	}
 }
+
+func TestWriteBytesCommand(t *testing.T) {
+	data := []byte{0x89, 'P', 'N', 'G', 0x00, '\'', '\n'}
+	command := writeBytesCommand("/workspace/img/logo.png", data, 0)
+
+	if !strings.Contains(command, "'iVBORwAnCg=='") {
+		t.Errorf("expected base64 payload in single quotes, got: %s", command)
+	}
+	if !strings.Contains(command, "| base64 -d > '/workspace/img/logo.png.tmp' && mv '/workspace/img/logo.png.tmp' '/workspace/img/logo.png'") {
+		t.Errorf("expected atomic decode and move, got: %s", command)
+	}
+}
+
+func TestWriteBytesCommand_Mode(t *testing.T) {
+	command := writeBytesCommand("/workspace/run.sh", []byte("#!/bin/sh\n"), 0755)
+
+	if !strings.HasSuffix(command, "base64 -d > '/workspace/run.sh.tmp' && chmod 0755 '/workspace/run.sh.tmp' && mv '/workspace/run.sh.tmp' '/workspace/run.sh'") {
+		t.Errorf("expected chmod of the temp file before the move, got: %s", command)
+	}
+}
+
+// TestWriteBytesCommand_PreservesBytes runs the command with the host's
+// shell to check that CRLF line endings and a BOM, which <write> keeps on
+// existing files, reach the file unchanged
+func TestWriteBytesCommand_PreservesBytes(t *testing.T) {
+	if _, err := exec.LookPath("base64"); err != nil {
+		t.Skip("base64 not available")
+	}
+
+	target := filepath.Join(t.TempDir(), "sub", "notes.txt")
+	data := []byte("\xef\xbb\xbfline 1\r\nline '2' %s\\r\r\n")
+	command := writeBytesCommand(target, data, 0)
+
+	if out, err := exec.Command("/bin/sh", "-c", command).CombinedOutput(); err != nil {
+		t.Fatalf("command failed: %v\n%s", err, out)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("written bytes = %q, want %q", got, data)
+	}
+}
+
+func TestWriteBytesCommand_QuotesPath(t *testing.T) {
+	command := writeBytesCommand("/workspace/my notes/it's $HOME.txt", []byte("x"), 0)
+
+	want := `mv '/workspace/my notes/it'\''s $HOME.txt.tmp' '/workspace/my notes/it'\''s $HOME.txt'`
+	if !strings.HasSuffix(command, want) {
+		t.Errorf("expected quoted path, got: %s", command)
+	}
+	if !strings.HasPrefix(command, `mkdir -p "$(dirname '/workspace/my notes/it'\''s $HOME.txt')"`) {
+		t.Errorf("expected quoted directory, got: %s", command)
+	}
+}
+
+func TestReadRangeCommand(t *testing.T) {
+	command := readRangeCommand("/workspace/logs/app log.txt", 0, 65536)
+	want := `tail -c +1 '/workspace/logs/app log.txt' | head -c 65536`
+	if command != want {
+		t.Errorf("readRangeCommand() = %s, want %s", command, want)
+	}
+}
@@ -0,0 +1,61 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+)
+
+// Labels set on every container llm-runtime creates, so cleanup and
+// `docker ps --filter label=llm-tools.session=ID` can tell which session
+// started a container and why
+const (
+	kindLabel        = "llm-tools.kind"         // exec, io, pool, cache, or repl
+	sessionLabel     = "llm-tools.session"      // ID of the session that created it
+	commandHashLabel = "llm-tools.command-hash" // CommandHash of the command it runs; empty for pool and cache containers
+	correlationLabel = "llm-tools.correlation"  // Correlation ID of the command that started it; empty for pool and cache containers
+	repoLabel        = "llm-tools.repo"         // Repository root mounted at /workspace
+	ownerLabel       = "llm-tools.owner"        // host:pid of the process that created it
+)
+
+var sessionID string
+
+// SetSessionID sets the session ID recorded on containers created from now
+// on
+func SetSessionID(id string) {
+	sessionID = id
+}
+
+// CommandHash returns the short hash of command recorded on its container,
+// which the audit log records too
+func CommandHash(command string) string {
+	sum := sha256.Sum256([]byte(command))
+	return hex.EncodeToString(sum[:6])
+}
+
+// containerLabels returns the labels for a new container of kind running
+// command against repo. command and repo may be empty.
+func containerLabels(kind, repo, command string) map[string]string {
+	labels := map[string]string{
+		kindLabel:    kind,
+		sessionLabel: sessionID,
+		repoLabel:    repo,
+		ownerLabel:   Owner(),
+	}
+	if command != "" {
+		labels[commandHashLabel] = CommandHash(command)
+	}
+	return labels
+}
+
+// commandLabels returns containerLabels plus the correlation ID of the
+// command in ctx, which its audit entries and spans carry too
+func commandLabels(ctx context.Context, kind, repo, command string) map[string]string {
+	labels := containerLabels(kind, repo, command)
+	if id := telemetry.CorrelationID(ctx); id != "" {
+		labels[correlationLabel] = id
+	}
+	return labels
+}
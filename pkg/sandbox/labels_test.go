@@ -0,0 +1,58 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/telemetry"
+)
+
+func TestContainerLabels(t *testing.T) {
+	SetSessionID("1234")
+	defer SetSessionID("")
+
+	labels := containerLabels("exec", "/repo", "go test ./...")
+	want := map[string]string{
+		kindLabel:        "exec",
+		sessionLabel:     "1234",
+		repoLabel:        "/repo",
+		commandHashLabel: CommandHash("go test ./..."),
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, labels[k], v)
+		}
+	}
+	host, _ := os.Hostname()
+	if !ownerRunning(labels[ownerLabel], host) {
+		t.Errorf("owner label %q does not name this process", labels[ownerLabel])
+	}
+
+	if _, ok := containerLabels("pool", "/repo", "")[commandHashLabel]; ok {
+		t.Error("pool container should not have a command hash")
+	}
+}
+
+func TestCommandLabels(t *testing.T) {
+	ctx := telemetry.WithCorrelationID(context.Background(), "0123456789abcdef")
+	if got := commandLabels(ctx, "exec", "/repo", "go test")[correlationLabel]; got != "0123456789abcdef" {
+		t.Errorf("correlation label = %q, want the ID in ctx", got)
+	}
+	if _, ok := commandLabels(context.Background(), "exec", "/repo", "go test")[correlationLabel]; ok {
+		t.Error("container outside a command should not have a correlation label")
+	}
+}
+
+func TestCommandHash(t *testing.T) {
+	a, b := CommandHash("go test ./..."), CommandHash("go vet ./...")
+	if len(a) != 12 {
+		t.Errorf("CommandHash() = %q, want 12 hex digits", a)
+	}
+	if a == b {
+		t.Error("different commands should hash differently")
+	}
+	if a != CommandHash("go test ./...") {
+		t.Error("CommandHash() should be stable")
+	}
+}
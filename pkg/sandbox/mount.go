@@ -0,0 +1,54 @@
+package sandbox
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// HostMountPath converts a host directory into the form the Docker daemon
+// expects for bind mount sources. On Windows, Docker Desktop wants
+// forward-slash paths with the drive letter lowered into a leading
+// component (C:\Users\me\repo -> /c/Users/me/repo) and UNC shares written
+// as //server/share/...; on other platforms the path is returned unchanged.
+func HostMountPath(hostPath string) string {
+	return hostMountPath(hostPath, runtime.GOOS)
+}
+
+// hostMountPath implements HostMountPath for the given GOOS
+func hostMountPath(hostPath, goos string) string {
+	if goos != "windows" {
+		return hostPath
+	}
+
+	p := strings.ReplaceAll(hostPath, `\`, "/")
+
+	// UNC share: \\server\share\dir -> //server/share/dir
+	if strings.HasPrefix(p, "//") {
+		return "//" + strings.TrimLeft(p, "/")
+	}
+
+	// Drive letter: C:/dir -> /c/dir
+	if hasDriveLetter(p) {
+		rest := strings.TrimPrefix(p[2:], "/")
+		return "/" + strings.ToLower(p[:1]) + "/" + rest
+	}
+
+	return p
+}
+
+// ContainerPath returns the path of a repository-relative file inside the
+// container workspace, always using forward slashes
+func ContainerPath(relPath string) string {
+	return "/workspace/" + strings.TrimPrefix(filepath.ToSlash(relPath), "/")
+}
+
+// hasDriveLetter reports whether p starts with a Windows drive letter such
+// as "C:"
+func hasDriveLetter(p string) bool {
+	if len(p) < 2 || p[1] != ':' {
+		return false
+	}
+	c := p[0]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,52 @@
+package sandbox
+
+import "testing"
+
+func TestHostMountPath_Windows(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "drive letter", in: `C:\Users\me\repo`, want: "/c/Users/me/repo"},
+		{name: "lowercase drive", in: `d:\work`, want: "/d/work"},
+		{name: "drive root", in: `C:\`, want: "/c/"},
+		{name: "forward slashes", in: "C:/Users/me/repo", want: "/c/Users/me/repo"},
+		{name: "UNC share", in: `\\server\share\repo`, want: "//server/share/repo"},
+		{name: "already unix style", in: "/c/Users/me", want: "/c/Users/me"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostMountPath(tt.in, "windows"); got != tt.want {
+				t.Errorf("hostMountPath(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostMountPath_Unix(t *testing.T) {
+	for _, goos := range []string{"linux", "darwin"} {
+		in := "/home/me/repo"
+		if got := hostMountPath(in, goos); got != in {
+			t.Errorf("hostMountPath(%q, %s) = %q, want unchanged", in, goos, got)
+		}
+	}
+}
+
+func TestContainerPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"README.md", "/workspace/README.md"},
+		{"src/main.go", "/workspace/src/main.go"},
+		{"/leading/slash.go", "/workspace/leading/slash.go"},
+	}
+
+	for _, tt := range tests {
+		if got := ContainerPath(tt.in); got != tt.want {
+			t.Errorf("ContainerPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package sandbox
+
+import "os"
+
+// RestoreOwner gives the file at path the owner recorded in info. Files
+// have no Unix owner here, so there is nothing to restore.
+func RestoreOwner(path string, info os.FileInfo) error {
+	return nil
+}
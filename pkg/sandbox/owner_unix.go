@@ -0,0 +1,28 @@
+//go:build unix
+
+package sandbox
+
+import (
+	"os"
+	"syscall"
+)
+
+// RestoreOwner gives the file at path the owner and group recorded in
+// info, the file it replaced. Files written through the I/O container
+// belong to the container's user, and only a host process allowed to chown,
+// such as one running as root, can give them back, so callers treat
+// failure as expected.
+func RestoreOwner(path string, info os.FileInfo) error {
+	was, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	current, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if now, ok := current.Sys().(*syscall.Stat_t); ok && now.Uid == was.Uid && now.Gid == was.Gid {
+		return nil
+	}
+	return os.Lchown(path, int(was.Uid), int(was.Gid))
+}
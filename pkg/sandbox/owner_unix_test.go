@@ -0,0 +1,27 @@
+//go:build unix
+
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreOwner_Unchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same owner: nothing to change, so no privileges are needed
+	if err := RestoreOwner(path, info); err != nil {
+		t.Errorf("RestoreOwner() = %v", err)
+	}
+	if err := RestoreOwner(filepath.Join(filepath.Dir(path), "missing"), info); err == nil {
+		t.Error("RestoreOwner() of a missing file succeeded")
+	}
+}
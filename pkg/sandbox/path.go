@@ -1,14 +1,32 @@
 package sandbox
 
 import (
-	"fmt"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
 )
 
+// ValidatePath resolves requestedPath against the repository root and
+// rejects paths that escape the repository or match excludedPaths.
+// Backslashes are treated as separators on every platform so Windows-style
+// traversal such as "..\..\etc" is caught everywhere. Paths are compared
+// in Unicode NFC, so "café.txt" typed precomposed or decomposed names the
+// same file; an existing file is returned under the name it has on disk.
 func ValidatePath(requestedPath string, repositoryRoot string, excludedPaths []string) (string, error) {
+	normalized, err := normalizeRequestedPath(requestedPath, runtime.GOOS)
+	if err != nil {
+		return "", err
+	}
+
 	// Clean the path to resolve . and .. and remove redundant separators
-	cleanPath := filepath.Clean(requestedPath)
+	cleanPath := filepath.Clean(normalized)
 
 	// Build absolute path
 	var absPath string
@@ -23,27 +41,202 @@ func ValidatePath(requestedPath string, repositoryRoot string, excludedPaths []s
 
 	// CRITICAL: Ensure the resolved path is within repository
 	// This prevents ALL traversal attacks (..../, ..;/, etc.)
-	if !strings.HasPrefix(absPath, repositoryRoot+string(filepath.Separator)) && absPath != repositoryRoot {
-		return "", fmt.Errorf("path is not within repository: %s", requestedPath)
+	if !isWithinRoot(absPath, repositoryRoot) {
+		return "", errors.Newf(errors.PathSecurity, "path is not within repository: %s", requestedPath)
 	}
 
+	if absPath == repositoryRoot {
+		return absPath, nil
+	}
+	relPath, err := filepath.Rel(repositoryRoot, absPath)
+	if err != nil {
+		return "", errors.Newf(errors.PathSecurity, "path is not within repository: %s", requestedPath)
+	}
+	absPath = filepath.Join(repositoryRoot, onDiskName(repositoryRoot, relPath))
+
 	// Check against excluded paths (business logic - protect secrets)
-	for _, excluded := range excludedPaths {
-		// Check exact match with glob pattern
-		matched, err := filepath.Match(excluded, filepath.Base(absPath))
-		if err != nil {
-			continue
+	// Patterns use .gitignore syntax, including ** and root-anchored entries
+	if len(excludedPaths) > 0 {
+		isDir := false
+		if info, err := os.Stat(absPath); err == nil {
+			isDir = info.IsDir()
+		}
+
+		pattern, inParent, matched := ignore.CompilePatterns(excludedPaths).Match(relPath, isDir)
+		if matched && inParent {
+			return "", errors.Newf(errors.PathSecurity, "path is in excluded directory: %s", pattern)
 		}
 		if matched {
-			return "", fmt.Errorf("path is in excluded list: %s", filepath.Base(absPath))
+			return "", errors.Newf(errors.PathSecurity, "path is in excluded list: %s", filepath.Base(absPath))
 		}
+	}
+
+	return absPath, nil
+}
 
-		// Check if path is within excluded directory
-		excludedAbs := filepath.Join(repositoryRoot, excluded)
-		if strings.HasPrefix(absPath, excludedAbs+string(filepath.Separator)) {
-			return "", fmt.Errorf("path is in excluded directory: %s", excluded)
+// ValidateHostPath is ValidatePath for commands that read or write on the
+// host rather than in a container, where a symlink in the repository would
+// otherwise be followed out of it: the path is also checked with
+// HostPathWithinRepo
+func ValidateHostPath(requestedPath string, repositoryRoot string, excludedPaths []string) (string, error) {
+	absPath, err := ValidatePath(requestedPath, repositoryRoot, excludedPaths)
+	if err != nil {
+		return "", err
+	}
+	if err := HostPathWithinRepo(absPath, repositoryRoot); err != nil {
+		return "", err
+	}
+	return absPath, nil
+}
+
+// HostPathWithinRepo returns an error if absPath leaves the repository
+// once the symlinks in it are resolved. The part of absPath that does not
+// exist yet is taken as is.
+func HostPathWithinRepo(absPath, root string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return errors.Wrap(errors.PathSecurity, err)
+	}
+	existing, rest := absPath, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
 		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
 	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return errors.Wrap(errors.PathSecurity, err)
+	}
+	rel, err := filepath.Rel(realRoot, filepath.Join(real, rest))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.Newf(errors.PathSecurity, "path leaves the repository through a symlink: %s", absPath)
+	}
+	return nil
+}
 
-	return absPath, nil
+// normalizeRequestedPath converts a requested path to NFC and the host's
+// separator and rejects path forms that cannot be validated safely: NUL
+// bytes, invalid UTF-8, bidirectional control characters that make a name
+// display differently from what it is, Windows device namespaces (\\?\ and
+// \\.\), drive-relative paths such as "C:foo", and drive-letter paths on
+// non-Windows hosts
+func normalizeRequestedPath(requestedPath, goos string) (string, error) {
+	if strings.ContainsRune(requestedPath, 0) {
+		return "", errors.New(errors.PathSecurity, "path contains null byte")
+	}
+	if !utf8.ValidString(requestedPath) {
+		return "", errors.New(errors.PathSecurity, "path is not valid UTF-8")
+	}
+	if i := strings.IndexFunc(requestedPath, isBidiControl); i >= 0 {
+		return "", errors.Newf(errors.PathSecurity, "path contains bidirectional control character %U", []rune(requestedPath[i:])[0])
+	}
+	requestedPath = norm.NFC.String(requestedPath)
+
+	slashed := strings.ReplaceAll(requestedPath, `\`, "/")
+
+	if strings.HasPrefix(slashed, "//?/") || strings.HasPrefix(slashed, "//./") {
+		return "", errors.Newf(errors.PathSecurity, "device paths are not allowed: %s", requestedPath)
+	}
+
+	if hasDriveLetter(slashed) {
+		if len(slashed) == 2 || slashed[2] != '/' {
+			return "", errors.Newf(errors.PathSecurity, "drive-relative paths are not allowed: %s", requestedPath)
+		}
+		if goos != "windows" {
+			return "", errors.Newf(errors.PathSecurity, "drive-letter paths are not supported on %s: %s", goos, requestedPath)
+		}
+	}
+
+	if goos == "windows" {
+		return strings.ReplaceAll(slashed, "/", `\`), nil
+	}
+	return slashed, nil
+}
+
+// isBidiControl reports whether r is a Unicode bidirectional embedding,
+// override, or isolate control
+func isBidiControl(r rune) bool {
+	return (r >= '\u202A' && r <= '\u202E') || (r >= '\u2066' && r <= '\u2069')
+}
+
+// onDiskName returns relPath, an NFC path below root, with each component
+// that does not exist replaced by the entry of its directory that has the
+// same NFC form, such as a decomposed name written on macOS. Components
+// with no such entry, as for a file about to be created, stay NFC.
+func onDiskName(root, relPath string) string {
+	if isASCII(relPath) {
+		return relPath
+	}
+
+	parts := strings.Split(relPath, string(filepath.Separator))
+	dir := root
+	for i, part := range parts {
+		if _, err := os.Lstat(filepath.Join(dir, part)); err != nil {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				break
+			}
+			for _, entry := range entries {
+				if norm.NFC.String(entry.Name()) == part {
+					parts[i] = entry.Name()
+					break
+				}
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return filepath.Join(parts...)
+}
+
+// isASCII reports whether s has only ASCII characters, which are the same
+// in every normalization form
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// isWithinRoot reports whether absPath is the repository root or below it.
+// Comparison ignores case on Windows, where volumes are case-insensitive.
+func isWithinRoot(absPath, repositoryRoot string) bool {
+	root := filepath.Clean(repositoryRoot)
+	prefix := root + string(filepath.Separator)
+	if strings.HasSuffix(root, string(filepath.Separator)) {
+		prefix = root
+	}
+
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(absPath, root) ||
+			(len(absPath) > len(prefix) && strings.EqualFold(absPath[:len(prefix)], prefix))
+	}
+	return absPath == root || strings.HasPrefix(absPath, prefix)
+}
+
+// CheckIgnored returns an error if absPath is excluded by a .gitignore or
+// .llmignore file anywhere between the repository root and the path
+func CheckIgnored(absPath string, repositoryRoot string) error {
+	relPath, err := filepath.Rel(repositoryRoot, absPath)
+	if err != nil {
+		return errors.Newf(errors.PathSecurity, "path is not within repository: %s", absPath)
+	}
+
+	isDir := false
+	if info, err := os.Stat(absPath); err == nil {
+		isDir = info.IsDir()
+	}
+
+	if ignore.NewMatcher(repositoryRoot).Match(relPath, isDir) {
+		return errors.Newf(errors.PathSecurity, "path is ignored by ignore file: %s", filepath.ToSlash(relPath))
+	}
+
+	return nil
 }
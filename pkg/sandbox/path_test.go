@@ -484,3 +484,242 @@ func TestValidatePath_NestedExclusions(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckIgnored(t *testing.T) {
+	repoRoot := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(repoRoot, ".gitignore"), []byte("build/\n*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, ".llmignore"), []byte("!keep.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .llmignore: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(repoRoot, "build"), 0755); err != nil {
+		t.Fatalf("Failed to create build dir: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "regular file", path: "main.go", wantErr: false},
+		{name: "ignored directory", path: "build", wantErr: true},
+		{name: "file in ignored directory", path: "build/out.bin", wantErr: true},
+		{name: "ignored extension", path: "logs/debug.log", wantErr: true},
+		{name: "negated by llmignore", path: "keep.log", wantErr: false},
+		{name: "ignore file itself", path: ".gitignore", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckIgnored(filepath.Join(repoRoot, tt.path), repoRoot)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckIgnored(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "ignored") {
+				t.Errorf("expected error to mention ignore file, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidatePath_GlobExclusions(t *testing.T) {
+	repoRoot := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(repoRoot, "app", "secrets"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	excluded := []string{"**/secrets/**", "/dist", "node_modules", "*.pem"}
+
+	tests := []struct {
+		name          string
+		requestedPath string
+		wantErr       bool
+		errContains   string
+	}{
+		{name: "double star nested", requestedPath: "app/secrets/token.txt", wantErr: true, errContains: "excluded list"},
+		{name: "double star deep", requestedPath: "a/b/c/secrets/x/y.txt", wantErr: true},
+		{name: "anchored directory", requestedPath: "dist/bundle.js", wantErr: true, errContains: "excluded directory"},
+		{name: "anchored pattern not nested", requestedPath: "web/dist/bundle.js", wantErr: false},
+		{name: "bare name at depth", requestedPath: "web/node_modules/x/index.js", wantErr: true, errContains: "excluded directory"},
+		{name: "extension at depth", requestedPath: "certs/ca/root.pem", wantErr: true},
+		{name: "allowed file", requestedPath: "app/main.go", wantErr: false},
+		{name: "similar name not excluded", requestedPath: "app/secretsfile.txt", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidatePath(tt.requestedPath, repoRoot, excluded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePath(%q) error = %v, wantErr %v", tt.requestedPath, err, tt.wantErr)
+			}
+			if err != nil && tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("ValidatePath(%q) error = %v, want error containing %q", tt.requestedPath, err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestNormalizeRequestedPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		goos    string
+		want    string
+		wantErr string
+	}{
+		{name: "unix relative", path: "src/main.go", goos: "linux", want: "src/main.go"},
+		{name: "backslashes on unix", path: `src\main.go`, goos: "linux", want: "src/main.go"},
+		{name: "backslash traversal on unix", path: `..\..\etc\passwd`, goos: "linux", want: "../../etc/passwd"},
+		{name: "slashes on windows", path: "src/main.go", goos: "windows", want: `src\main.go`},
+		{name: "drive absolute on windows", path: `C:\repo\main.go`, goos: "windows", want: `C:\repo\main.go`},
+		{name: "UNC on windows", path: `\\server\share\x`, goos: "windows", want: `\\server\share\x`},
+		{name: "drive absolute on unix", path: `C:\repo\main.go`, goos: "linux", wantErr: "not supported"},
+		{name: "drive relative", path: "C:main.go", goos: "windows", wantErr: "drive-relative"},
+		{name: "bare drive", path: "C:", goos: "windows", wantErr: "drive-relative"},
+		{name: "device namespace", path: `\\?\C:\Windows`, goos: "windows", wantErr: "device paths"},
+		{name: "dot device namespace", path: `\\.\PhysicalDrive0`, goos: "windows", wantErr: "device paths"},
+		{name: "null byte", path: "a\x00b", goos: "linux", wantErr: "null byte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeRequestedPath(tt.path, tt.goos)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("normalizeRequestedPath(%q) error = %v, want error containing %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeRequestedPath(%q) unexpected error: %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeRequestedPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePath_BackslashTraversal(t *testing.T) {
+	repoRoot := t.TempDir()
+
+	attacks := []string{
+		`..\..\etc\passwd`,
+		`src\..\..\etc\passwd`,
+		`.\..\secret`,
+		`a\b\..\..\..\x`,
+	}
+
+	for _, attack := range attacks {
+		t.Run(attack, func(t *testing.T) {
+			if _, err := ValidatePath(attack, repoRoot, nil); err == nil {
+				t.Errorf("ValidatePath() should reject backslash traversal %q", attack)
+			}
+		})
+	}
+
+	result, err := ValidatePath(`src\main.go`, repoRoot, nil)
+	if err != nil {
+		t.Fatalf("ValidatePath() unexpected error for backslash path: %v", err)
+	}
+	if result != filepath.Join(repoRoot, "src", "main.go") {
+		t.Errorf("ValidatePath() = %q, want %q", result, filepath.Join(repoRoot, "src", "main.go"))
+	}
+}
+
+func TestValidatePath_BackslashExclusions(t *testing.T) {
+	repoRoot := t.TempDir()
+
+	if _, err := ValidatePath(`.git\config`, repoRoot, []string{".git"}); err == nil {
+		t.Error("ValidatePath() should apply exclusions to backslash-separated paths")
+	}
+}
+
+func TestValidatePath_UnicodeNormalization(t *testing.T) {
+	repoRoot := t.TempDir()
+	const nfc = "caf\u00e9.txt"  // é precomposed
+	const nfd = "cafe\u0301.txt" // e and a combining acute accent
+
+	// A decomposed name on disk, as macOS may store it, is found whichever
+	// form is asked for
+	if err := os.MkdirAll(filepath.Join(repoRoot, "d\u00e9j\u00e0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	onDisk := filepath.Join(repoRoot, "d\u00e9j\u00e0", nfd)
+	if err := os.WriteFile(onDisk, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, requested := range []string{"d\u00e9j\u00e0/" + nfc, "de\u0301ja\u0300/" + nfd} {
+		got, err := ValidatePath(requested, repoRoot, nil)
+		if err != nil {
+			t.Fatalf("ValidatePath(%q) error = %v", requested, err)
+		}
+		if got != onDisk {
+			t.Errorf("ValidatePath(%q) = %q, want %q", requested, got, onDisk)
+		}
+	}
+
+	// A new file is named in NFC
+	got, err := ValidatePath("new/u\u0308ber.md", repoRoot, nil)
+	if err != nil {
+		t.Fatalf("ValidatePath() error = %v", err)
+	}
+	if want := filepath.Join(repoRoot, "new", "\u00fcber.md"); got != want {
+		t.Errorf("ValidatePath() = %q, want %q", got, want)
+	}
+
+	// Exclusions match either form
+	if _, err := ValidatePath("secre\u0301t/key", repoRoot, []string{"secr\u00e9t"}); err == nil {
+		t.Error("ValidatePath() allowed a decomposed excluded directory")
+	}
+}
+
+func TestValidatePath_InvalidUnicode(t *testing.T) {
+	repoRoot := t.TempDir()
+	tests := []struct {
+		name        string
+		path        string
+		errContains string
+	}{
+		{"invalid UTF-8", "bad\xff.txt", "not valid UTF-8"},
+		{"right-to-left override", "invoice\u202Etxt.exe", "bidirectional control character U+202E"},
+		{"isolate", "a\u2066b.txt", "bidirectional control character U+2066"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidatePath(tt.path, repoRoot, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("ValidatePath(%q) error = %v, want error containing %q", tt.path, err, tt.errContains)
+			}
+		})
+	}
+
+	if _, err := ValidatePath("文件.txt", repoRoot, nil); err != nil {
+		t.Errorf("ValidatePath() rejected a CJK name: %v", err)
+	}
+}
+
+func TestValidateHostPath_Symlinks(t *testing.T) {
+	repoRoot := t.TempDir()
+	outside := t.TempDir()
+	os.MkdirAll(filepath.Join(repoRoot, "src"), 0755)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	if err := os.Symlink(outside, filepath.Join(repoRoot, "docs")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	os.Symlink(filepath.Join(repoRoot, "src"), filepath.Join(repoRoot, "code"))
+
+	for _, path := range []string{"docs/secret.txt", "docs/new/pwned.txt", "docs"} {
+		if _, err := ValidateHostPath(path, repoRoot, nil); err == nil || !strings.Contains(err.Error(), "through a symlink") {
+			t.Errorf("ValidateHostPath(%q) error = %v, want a symlink error", path, err)
+		}
+	}
+	for _, path := range []string{"src/main.go", "code/main.go", "new/dir/file.txt"} {
+		if _, err := ValidateHostPath(path, repoRoot, nil); err != nil {
+			t.Errorf("ValidateHostPath(%q) = %v, want it allowed", path, err)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
 )
 
 // PooledContainer represents a container in the pool
@@ -232,17 +234,18 @@ func (p *ContainerPool) Return(ctx context.Context, container *PooledContainer)
 func (p *ContainerPool) createContainer(ctx context.Context) (*PooledContainer, error) {
 	// Create minimal container config - just keeps the container running
 	containerConfig := &container.Config{
-		Image: p.config.Image,
-		Cmd:   []string{"sleep", "infinity"},
-		Tty:   true,
-		User:  "1000:1000",
+		Image:  p.config.Image,
+		Cmd:    []string{"sleep", "infinity"},
+		Tty:    true,
+		User:   "1000:1000",
+		Labels: containerLabels("pool", p.config.RepoRoot, ""),
 	}
 
 	hostConfig := &container.HostConfig{
 		Mounts: []mount.Mount{
 			{
 				Type:     mount.TypeBind,
-				Source:   p.config.RepoRoot,
+				Source:   HostMountPath(p.config.RepoRoot),
 				Target:   "/workspace",
 				ReadOnly: false,
 			},
@@ -256,6 +259,7 @@ func (p *ContainerPool) createContainer(ctx context.Context) (*PooledContainer,
 		SecurityOpt: []string{"no-new-privileges"},
 	}
 
+	launchStart := time.Now()
 	resp, err := p.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create container: %w", err)
@@ -265,6 +269,7 @@ func (p *ContainerPool) createContainer(ctx context.Context) (*PooledContainer,
 		p.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
 		return nil, fmt.Errorf("failed to start container: %w", err)
 	}
+	metrics.ObserveLaunch("pool", launchStart)
 
 	pooledContainer := &PooledContainer{
 		ID:         resp.ID,
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package sandbox
+
+// processRunning reports whether a process with pid exists. It cannot be
+// checked here, so leftovers are found by age alone.
+func processRunning(pid int) bool {
+	return false
+}
@@ -0,0 +1,244 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
+)
+
+// ErrREPLExited is returned when an interpreter exits, or is killed for
+// running out of memory, while running a snippet
+var ErrREPLExited = errors.New("interpreter exited")
+
+// REPLConfig describes an interpreter kept running in its own container
+type REPLConfig struct {
+	Image       string
+	Command     []string // Interpreter reading snippets on stdin; replaces the image's entrypoint
+	RepoRoot    string
+	MemoryLimit string
+	CPULimit    int
+	Env         []string // NAME=value pairs
+}
+
+// REPLSession is an interpreter running in a container for the rest of a
+// session, so variables and imports from one snippet are there for the
+// next. Its container has the restrictions of exec containers.
+type REPLSession struct {
+	cli         *client.Client
+	containerID string
+	conn        types.HijackedResponse
+	out         *replOutput
+	mu          sync.Mutex // One snippet runs at a time
+}
+
+// REPLResult is the output of one snippet
+type REPLResult struct {
+	Output string // Standard output and error, interleaved
+	Failed bool   // The interpreter reported the snippet failed
+}
+
+// replOutput collects what the interpreter writes and wakes the snippet
+// waiting for it
+type replOutput struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	changed chan struct{}
+	done    chan struct{} // Closed when the interpreter's output ends
+}
+
+func (o *replOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	o.buf.Write(p)
+	o.mu.Unlock()
+	select {
+	case o.changed <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// StartREPL starts the interpreter described by cfg in a new container
+func StartREPL(ctx context.Context, cfg REPLConfig) (*REPLSession, error) {
+	if fake != nil {
+		return nil, fmt.Errorf("interpreter %w", ErrFakeBackend)
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+
+	containerConfig := &container.Config{
+		Image:        cfg.Image,
+		Entrypoint:   strslice.StrSlice(cfg.Command),
+		Env:          append([]string{"HOME=/tmp"}, cfg.Env...),
+		WorkingDir:   "/workspace",
+		User:         "1000:1000",
+		Labels:       commandLabels(ctx, "repl", cfg.RepoRoot, strings.Join(cfg.Command, " ")),
+		OpenStdin:    true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	hostConfig := &container.HostConfig{
+		NetworkMode: "none",
+		Resources: container.Resources{
+			Memory:   parseMemoryLimit(cfg.MemoryLimit),
+			NanoCPUs: int64(cfg.CPULimit) * 1000000000,
+		},
+		Mounts: []mount.Mount{
+			{
+				Type:     mount.TypeBind,
+				Source:   HostMountPath(cfg.RepoRoot),
+				Target:   "/workspace",
+				ReadOnly: true,
+			},
+		},
+		CapDrop:        strslice.StrSlice{"ALL"},
+		SecurityOpt:    []string{"no-new-privileges"},
+		ReadonlyRootfs: true,
+		Tmpfs: map[string]string{
+			"/tmp": "exec",
+		},
+	}
+
+	launchStart := time.Now()
+	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	if err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+	s := &REPLSession{cli: cli, containerID: resp.ID}
+
+	s.conn, err = cli.ContainerAttach(ctx, resp.ID, types.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		s.remove()
+		return nil, fmt.Errorf("failed to attach to container: %w", err)
+	}
+	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		s.conn.Close()
+		s.remove()
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+	metrics.ObserveLaunch("repl", launchStart)
+
+	s.out = &replOutput{changed: make(chan struct{}, 1), done: make(chan struct{})}
+	go func() {
+		stdcopy.StdCopy(s.out, s.out, s.conn.Reader)
+		close(s.out.done)
+	}()
+	return s, nil
+}
+
+// Run sends code to the interpreter, followed by end with %s replaced by
+// a marker the interpreter prints once the snippet has run, and returns
+// what it wrote before the marker. A word after the marker other than 0
+// marks the snippet failed. If ctx ends first the snippet is still running
+// and the session must be closed.
+func (s *REPLSession) Run(ctx context.Context, code, end string) (REPLResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marker, err := replMarker()
+	if err != nil {
+		return REPLResult{}, err
+	}
+	s.out.mu.Lock()
+	s.out.buf.Reset()
+	s.out.mu.Unlock()
+
+	input := code
+	if !strings.HasSuffix(input, "\n") {
+		input += "\n"
+	}
+	input += fmt.Sprintf(end, marker) + "\n"
+	if _, err := s.conn.Conn.Write([]byte(input)); err != nil {
+		return REPLResult{}, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	for {
+		s.out.mu.Lock()
+		output := s.out.buf.String()
+		s.out.mu.Unlock()
+		if result, ok := parseREPLOutput(output, marker); ok {
+			return result, nil
+		}
+
+		select {
+		case <-s.out.changed:
+		case <-s.out.done:
+			s.out.mu.Lock()
+			output = s.out.buf.String()
+			s.out.mu.Unlock()
+			if result, ok := parseREPLOutput(output, marker); ok {
+				return result, nil
+			}
+			return REPLResult{Output: output, Failed: true}, ErrREPLExited
+		case <-ctx.Done():
+			s.out.mu.Lock()
+			output = s.out.buf.String()
+			s.out.mu.Unlock()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return REPLResult{Output: output, Failed: true}, ErrTimeout
+			}
+			return REPLResult{Output: output, Failed: true}, ErrInterrupted
+		}
+	}
+}
+
+// Close stops the interpreter and removes its container
+func (s *REPLSession) Close() error {
+	s.conn.Close()
+	return s.remove()
+}
+
+func (s *REPLSession) remove() error {
+	defer s.cli.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.cli.ContainerRemove(ctx, s.containerID, types.ContainerRemoveOptions{Force: true})
+}
+
+// parseREPLOutput splits output at marker into what the snippet wrote and
+// whether it failed, reporting false until the marker's line is complete
+func parseREPLOutput(output, marker string) (REPLResult, bool) {
+	i := strings.Index(output, marker)
+	if i < 0 {
+		return REPLResult{}, false
+	}
+	rest := output[i+len(marker):]
+	eol := strings.IndexByte(rest, '\n')
+	if eol < 0 {
+		return REPLResult{}, false
+	}
+	status := strings.TrimSpace(rest[:eol])
+	return REPLResult{Output: output[:i], Failed: status != "" && status != "0"}, true
+}
+
+// replMarker returns a marker no snippet output will contain by chance
+func replMarker() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to create marker: %w", err)
+	}
+	return "__llm_repl_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,28 @@
+package sandbox
+
+import "testing"
+
+func TestParseREPLOutput(t *testing.T) {
+	const marker = "__llm_repl_0123456789abcdef"
+	tests := []struct {
+		name   string
+		output string
+		ok     bool
+		want   REPLResult
+	}{
+		{"pending", "partial output", false, REPLResult{}},
+		{"marker line incomplete", "42\n" + marker + " 0", false, REPLResult{}},
+		{"success", "42\n" + marker + " 0\n", true, REPLResult{Output: "42\n"}},
+		{"failure", "Traceback...\n" + marker + " 1\n", true, REPLResult{Output: "Traceback...\n", Failed: true}},
+		{"no status", "hi\n" + marker + "\n", true, REPLResult{Output: "hi\n"}},
+		{"no trailing newline in output", "hi" + marker + " 0\n", true, REPLResult{Output: "hi"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseREPLOutput(tt.output, marker)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseREPLOutput() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// TimeoutExitCode is the exit code of a command stopped by its timeout,
+// the same code timeout(1) uses
+const TimeoutExitCode = 124
+
+// TimeoutGracePeriod is how long a timed out command has to exit after
+// SIGTERM before it is sent SIGKILL
+const TimeoutGracePeriod = 2 * time.Second
+
+// InterruptExitCode is the exit code of a command stopped because its
+// context was canceled, as by Ctrl+C, the code a shell reports for SIGINT
+const InterruptExitCode = 130
+
+// ErrTimeout is wrapped by the errors of commands stopped by their timeout
+var ErrTimeout = errors.New("timed out")
+
+// ErrInterrupted is wrapped by the errors of commands stopped because
+// their context was canceled before the timeout
+var ErrInterrupted = errors.New("interrupted")
+
+// stopContainer sends SIGTERM to a container and SIGKILL once
+// TimeoutGracePeriod has passed, for timeouts and interrupts alike. It
+// runs on its own context, since the command's context has usually ended
+// by the time it is called.
+func stopContainer(cli *client.Client, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), TimeoutGracePeriod+10*time.Second)
+	defer cancel()
+
+	grace := int(TimeoutGracePeriod / time.Second)
+	return cli.ContainerStop(ctx, id, container.StopOptions{Signal: "SIGTERM", Timeout: &grace})
+}
@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// builtinCommands are the tags the scanner always recognizes. Open, write,
+// exec, and search are matched by prefix, so they also shadow any name
+// starting with them.
+var builtinCommands = []string{"open", "open-many", "write", "append", "exec", "search", "set", "pipe", "pipe-to", "if-success", "if-failure", "meta", "tail", "hash", "outline", "overview", "deps", "test", "lint", "coverage", "unzip", "archive", "fetch", "sql", "repl", "repl-reset", "git-branch", "git-commit", "pr", "issue", "def", "refs", "rename-symbol", "ast-grep", "tokens", "note", "memory"}
+
+// commandName matches a valid plugin command name, such as "jira" or
+// "deploy-status"
+var commandName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+var (
+	registeredMu sync.RWMutex
+	registered   = make(map[string]bool)
+)
+
+// RegisterCommand makes every scanner recognize <name argument> as a
+// command of type name, with everything up to the '>' as its argument.
+// Plugins use it to add commands such as <jira ISSUE-123>.
+func RegisterCommand(name string) error {
+	if !commandName.MatchString(name) {
+		return fmt.Errorf("invalid command name %q (want lowercase letters, digits, and hyphens)", name)
+	}
+	for _, builtin := range builtinCommands {
+		if name == builtin {
+			return fmt.Errorf("command %q is built in", name)
+		}
+		switch builtin {
+		case "open", "write", "exec", "search":
+			if strings.HasPrefix(name, builtin) {
+				return fmt.Errorf("command %q would be parsed as <%s>", name, builtin)
+			}
+		}
+	}
+
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registered[name] = true
+	return nil
+}
+
+// UnregisterCommand stops scanners recognizing a command added with
+// RegisterCommand
+func UnregisterCommand(name string) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	delete(registered, name)
+}
+
+// IsRegistered reports whether name was added with RegisterCommand
+func IsRegistered(name string) bool {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return registered[name]
+}
@@ -3,6 +3,8 @@ package scanner
 import (
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
 	"bufio"
+	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -22,6 +24,37 @@ const (
 	StateExecBody                      // Accumulating exec body
 	StateSearch                        // Parsing <search query>
 	StateExecute                       // Ready to execute command
+	StateBlockBody                     // Accumulating a <pipe> or guard block until its closing tag
+	StateDiscard                       // Skipping an oversized command body until its closing tag
+	StateHeredoc                       // Accumulating a write body until its delimiter line
+	StateSet                           // Parsing <set name=NAME value=VALUE>
+	StatePlugin                        // Parsing a plugin command such as <jira ISSUE-123>
+	StateTail                          // Parsing <tail filepath>
+	StateHash                          // Parsing <hash filepath>
+	StateUnzip                         // Parsing <unzip archive dest>
+	StateArchive                       // Parsing <archive dest source>
+	StateFetch                         // Parsing <fetch url>
+	StateSQL                           // Parsing <sql name=NAME statement>
+	StateREPL                          // Parsing <repl language>, before its code
+	StateREPLReset                     // Parsing <repl-reset language>
+	StateOutline                       // Parsing <outline filepath>
+	StateOverview                      // Parsing <overview depth=N>
+	StateDeps                          // Parsing <deps package>
+	StateTest                          // Parsing <test target args>
+	StateCoverage                      // Parsing <coverage profile>
+	StateLint                          // Parsing <lint path>
+	StateAppend                        // Parsing <append filepath>, before its content
+	StateOpenMany                      // Parsing <open-many pattern max_files=N>
+	StateGitBranch                     // Parsing <git-branch name>
+	StateGitCommit                     // Parsing <git-commit message>
+	StatePR                            // Parsing <pr title>, before its description
+	StateIssue                         // Parsing <issue number>
+	StateDef                           // Parsing <def symbol>
+	StateRefs                          // Parsing <refs symbol>
+	StateRenameSymbol                  // Parsing <rename-symbol old=NAME new=NAME>
+	StateASTGrep                       // Parsing <ast-grep pattern>
+	StateTokens                        // Parsing <tokens path>
+	StateNote                          // Parsing <note text>
 )
 
 // String returns the name of the state (for debugging)
@@ -45,6 +78,68 @@ func (s ScannerState) String() string {
 		return "StateSearch"
 	case StateExecute:
 		return "StateExecute"
+	case StateBlockBody:
+		return "StateBlockBody"
+	case StateDiscard:
+		return "StateDiscard"
+	case StateHeredoc:
+		return "StateHeredoc"
+	case StateSet:
+		return "StateSet"
+	case StatePlugin:
+		return "StatePlugin"
+	case StateTail:
+		return "StateTail"
+	case StateHash:
+		return "StateHash"
+	case StateUnzip:
+		return "StateUnzip"
+	case StateArchive:
+		return "StateArchive"
+	case StateFetch:
+		return "StateFetch"
+	case StateSQL:
+		return "StateSQL"
+	case StateREPL:
+		return "StateREPL"
+	case StateREPLReset:
+		return "StateREPLReset"
+	case StateOutline:
+		return "StateOutline"
+	case StateOverview:
+		return "StateOverview"
+	case StateDeps:
+		return "StateDeps"
+	case StateTest:
+		return "StateTest"
+	case StateCoverage:
+		return "StateCoverage"
+	case StateLint:
+		return "StateLint"
+	case StateAppend:
+		return "StateAppend"
+	case StateOpenMany:
+		return "StateOpenMany"
+	case StateGitBranch:
+		return "StateGitBranch"
+	case StateGitCommit:
+		return "StateGitCommit"
+	case StatePR:
+		return "StatePR"
+	case StateIssue:
+		return "StateIssue"
+	case StateDef:
+		return "StateDef"
+	case StateRefs:
+		return "StateRefs"
+	case StateRenameSymbol:
+		return "StateRenameSymbol"
+	case StateASTGrep:
+		return "StateASTGrep"
+	case StateTokens:
+		return "StateTokens"
+	case StateNote:
+		return "StateNote"
 	default:
 		return "StateUnknown"
 	}
@@ -57,11 +152,23 @@ type Scanner struct {
 	currentCmd  *Command
 	reader      *bufio.Reader
 	showPrompts bool
+	maxBodySize int
+	pending     string // Unprocessed input left on the line after the last command
+	strict      bool   // Ignore commands in markdown code
+	parseErrors bool   // Report malformed commands instead of dropping them
+	fence       string // Opening marker of the code fence being skipped
+	inlineTicks int    // Backtick count of the open inline code span
+	atLineStart bool
+	prev        byte   // Previous byte seen while scanning for commands
+	line        int    // Newlines consumed so far
+	cmdLine     int    // Line on which the current command started
+	delim       string // Delimiter line ending the current heredoc write
+	lineStart   int    // Offset in buffer of the current heredoc line
 }
 
 // checkBufferLimit returns true if buffer is within limits
 func (s *Scanner) checkBufferLimit() bool {
-	return s.buffer.Len() < maxScannerBufferSize
+	return s.buffer.Len() < s.maxBodySize
 }
 
 // NewScanner creates a new state-machine scanner
@@ -70,9 +177,55 @@ func NewScanner(reader *bufio.Reader, showPrompts bool) *Scanner {
 		state:       StateScanning,
 		reader:      reader,
 		showPrompts: showPrompts,
+		maxBodySize: maxScannerBufferSize,
+		atLineStart: true,
 	}
 }
 
+// SetStrict enables strict parsing, in which commands inside markdown code
+// fences and inline code spans are ignored so they can be shown as
+// examples. A backslash before '<' escapes a command in either mode.
+func (s *Scanner) SetStrict(strict bool) {
+	s.strict = strict
+}
+
+// SetParseErrors makes malformed or unclosed commands come back with a
+// ParseError instead of being dropped, and requires an opening tag to be
+// closed on the line it opens
+func (s *Scanner) SetParseErrors(on bool) {
+	s.parseErrors = on
+}
+
+// SetMaxCommandSize sets the largest command body the scanner buffers.
+// Larger bodies are discarded and the command is returned with Oversized
+// set. Zero or less restores the default.
+func (s *Scanner) SetMaxCommandSize(n int) {
+	if n <= 0 {
+		n = maxScannerBufferSize
+	}
+	s.maxBodySize = n
+}
+
+// discardCommand drops the body of the current command once it exceeds the
+// size limit and skips input until the command's closing tag
+func (s *Scanner) discardCommand() {
+	s.currentCmd.Oversized = true
+	s.currentCmd.Content = ""
+	s.transitionTo(StateDiscard)
+	s.buffer.Reset()
+}
+
+// readChunk returns the next line of input, or the next bufio buffer's
+// worth of it if the line is longer, so a single huge line is never held
+// in memory twice
+func (s *Scanner) readChunk() (string, error) {
+	chunk, err := s.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		err = nil
+	}
+	return string(chunk), err
+}
+
 // transitionTo changes state
 func (s *Scanner) transitionTo(newState ScannerState) {
 	s.state = newState
@@ -82,6 +235,8 @@ func (s *Scanner) transitionTo(newState ScannerState) {
 func (s *Scanner) resetCommand() {
 	s.currentCmd = nil
 	s.buffer.Reset()
+	s.delim = ""
+	s.lineStart = 0
 }
 
 // startCommand initializes a new command
@@ -96,21 +251,83 @@ func (s *Scanner) startCommand(cmdType string) {
 // Returns nil when EOF or no command found
 func (s *Scanner) Scan() *Command {
 	for {
-		line, err := s.reader.ReadString('\n')
-		if err != nil {
-			// EOF - return any incomplete write command as nil
-			if line == "" {
-				return nil
+		var line string
+		if s.pending != "" {
+			line, s.pending = s.pending, ""
+		} else {
+			var err error
+			line, err = s.readChunk()
+			if err != nil {
+				// EOF - report or drop any incomplete command
+				if line == "" {
+					return s.finishAtEOF()
+				}
 			}
 		}
 
+		// In interactive mode, a ":name" line is a meta-command for the
+		// operator, such as ":help" or ":quit"
+		if s.showPrompts && s.state == StateScanning && s.atLineStart {
+			if match := metaCommand.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+				s.atLineStart = strings.HasSuffix(line, "\n")
+				if s.atLineStart {
+					s.line++
+				}
+				return &Command{Type: "meta", Argument: match[1]}
+			}
+		}
+
+		// In strict mode, fenced code blocks are skipped a line at a time
+		if s.strict && s.state == StateScanning && s.skipFencedLine(line) {
+			s.atLineStart = strings.HasSuffix(line, "\n")
+			if s.atLineStart {
+				s.line++
+			}
+			continue
+		}
+
 		// Process the line based on current state
 		for i := 0; i < len(line); i++ {
 			ch := line[i]
+			s.atLineStart = ch == '\n'
+			if ch == '\n' {
+				s.line++
+			}
+
+			// With parse errors on, a tag must be closed on the line it opens
+			if s.parseErrors && ch == '\n' && s.inTag() {
+				if cmd := s.unterminatedTag(); cmd != nil {
+					s.pending = line[i+1:]
+					return cmd
+				}
+				continue
+			}
 
 			switch s.state {
 			case StateScanning:
-				if ch == '<' {
+				escaped := s.prev == '\\'
+				s.prev = ch
+
+				if ch == '\n' {
+					// Inline code spans end at the end of the line
+					s.inlineTicks = 0
+				}
+				if s.strict && ch == '`' {
+					n := 1
+					for i+n < len(line) && line[i+n] == '`' {
+						n++
+					}
+					if s.inlineTicks == 0 {
+						s.inlineTicks = n
+					} else if n == s.inlineTicks {
+						s.inlineTicks = 0
+					}
+					i += n - 1
+					break
+				}
+
+				if ch == '<' && !escaped && s.inlineTicks == 0 {
+					s.cmdLine = s.line + 1
 					s.transitionTo(StateTagOpen)
 					s.buffer.Reset()
 					s.buffer.WriteByte(ch)
@@ -122,7 +339,17 @@ func (s *Scanner) Scan() *Command {
 
 				// Wait until we have enough characters to determine command type
 				if ch == ' ' || ch == '>' {
-					if strings.HasPrefix(buffered, "<open") {
+					if s.parseErrors {
+						if cmd := s.emptyTag(buffered); cmd != nil {
+							s.pending = line[i+1:]
+							return cmd
+						}
+					}
+					if buffered == "<open-many " {
+						s.startCommand("open-many")
+						s.transitionTo(StateOpenMany)
+						s.buffer.Reset()
+					} else if strings.HasPrefix(buffered, "<open") {
 						s.startCommand("open")
 						s.transitionTo(StateOpen)
 						s.buffer.Reset()
@@ -138,6 +365,192 @@ func (s *Scanner) Scan() *Command {
 						s.startCommand("search")
 						s.transitionTo(StateSearch)
 						s.buffer.Reset()
+					} else if buffered == "<tail " {
+						s.startCommand("tail")
+						s.transitionTo(StateTail)
+						s.buffer.Reset()
+					} else if buffered == "<hash " {
+						s.startCommand("hash")
+						s.transitionTo(StateHash)
+						s.buffer.Reset()
+					} else if buffered == "<outline " {
+						s.startCommand("outline")
+						s.transitionTo(StateOutline)
+						s.buffer.Reset()
+					} else if buffered == "<overview>" {
+						s.startCommand("overview")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<overview " {
+						s.startCommand("overview")
+						s.transitionTo(StateOverview)
+						s.buffer.Reset()
+					} else if buffered == "<deps>" {
+						// The whole module
+						s.startCommand("deps")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<deps " {
+						s.startCommand("deps")
+						s.transitionTo(StateDeps)
+						s.buffer.Reset()
+					} else if buffered == "<test>" {
+						// The whole repository
+						s.startCommand("test")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<test " {
+						s.startCommand("test")
+						s.transitionTo(StateTest)
+						s.buffer.Reset()
+					} else if buffered == "<lint>" {
+						// The whole repository
+						s.startCommand("lint")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<lint " {
+						s.startCommand("lint")
+						s.transitionTo(StateLint)
+						s.buffer.Reset()
+					} else if buffered == "<coverage>" {
+						// The first profile found
+						s.startCommand("coverage")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<coverage " {
+						s.startCommand("coverage")
+						s.transitionTo(StateCoverage)
+						s.buffer.Reset()
+					} else if buffered == "<append " {
+						s.startCommand("append")
+						s.transitionTo(StateAppend)
+						s.buffer.Reset()
+					} else if buffered == "<unzip " {
+						s.startCommand("unzip")
+						s.transitionTo(StateUnzip)
+						s.buffer.Reset()
+					} else if buffered == "<archive " {
+						s.startCommand("archive")
+						s.transitionTo(StateArchive)
+						s.buffer.Reset()
+					} else if buffered == "<fetch " {
+						s.startCommand("fetch")
+						s.transitionTo(StateFetch)
+						s.buffer.Reset()
+					} else if buffered == "<sql " {
+						s.startCommand("sql")
+						s.transitionTo(StateSQL)
+						s.buffer.Reset()
+					} else if buffered == "<git-branch " {
+						s.startCommand("git-branch")
+						s.transitionTo(StateGitBranch)
+						s.buffer.Reset()
+					} else if buffered == "<git-commit " {
+						s.startCommand("git-commit")
+						s.transitionTo(StateGitCommit)
+						s.buffer.Reset()
+					} else if buffered == "<pr " {
+						s.startCommand("pr")
+						s.transitionTo(StatePR)
+						s.buffer.Reset()
+					} else if buffered == "<issue " {
+						s.startCommand("issue")
+						s.transitionTo(StateIssue)
+						s.buffer.Reset()
+					} else if buffered == "<def " {
+						s.startCommand("def")
+						s.transitionTo(StateDef)
+						s.buffer.Reset()
+					} else if buffered == "<refs " {
+						s.startCommand("refs")
+						s.transitionTo(StateRefs)
+						s.buffer.Reset()
+					} else if buffered == "<rename-symbol " {
+						s.startCommand("rename-symbol")
+						s.transitionTo(StateRenameSymbol)
+						s.buffer.Reset()
+					} else if buffered == "<ast-grep " {
+						s.startCommand("ast-grep")
+						s.transitionTo(StateASTGrep)
+						s.buffer.Reset()
+					} else if buffered == "<tokens>" {
+						// The whole repository
+						s.startCommand("tokens")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<tokens " {
+						s.startCommand("tokens")
+						s.transitionTo(StateTokens)
+						s.buffer.Reset()
+					} else if buffered == "<note " {
+						s.startCommand("note")
+						s.transitionTo(StateNote)
+						s.buffer.Reset()
+					} else if buffered == "<memory>" {
+						s.startCommand("memory")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<repl " || buffered == "<repl>" {
+						s.startCommand("repl")
+						if ch == '>' {
+							// <repl> runs the default language
+							s.transitionTo(StateBlockBody)
+						} else {
+							s.transitionTo(StateREPL)
+						}
+						s.buffer.Reset()
+					} else if buffered == "<repl-reset>" {
+						// Resets every language
+						s.startCommand("repl-reset")
+						s.transitionTo(StateScanning)
+						cmd := s.currentCmd
+						s.resetCommand()
+						s.pending = line[i+1:]
+						return cmd
+					} else if buffered == "<repl-reset " {
+						s.startCommand("repl-reset")
+						s.transitionTo(StateREPLReset)
+						s.buffer.Reset()
+					} else if buffered == "<set " {
+						s.startCommand("set")
+						s.transitionTo(StateSet)
+						s.buffer.Reset()
+					} else if buffered == "<pipe>" || buffered == "<if-success>" || buffered == "<if-failure>" {
+						s.startCommand(strings.Trim(buffered, "<>"))
+						s.transitionTo(StateBlockBody)
+						s.buffer.Reset()
+					} else if name := buffered[1 : len(buffered)-1]; IsRegistered(name) {
+						s.startCommand(name)
+						if ch == '>' {
+							// A plugin command may take no argument
+							s.transitionTo(StateScanning)
+							cmd := s.currentCmd
+							s.resetCommand()
+							s.pending = line[i+1:]
+							return cmd
+						}
+						s.transitionTo(StatePlugin)
 					} else {
 						// Not a valid command, go back to scanning
 						s.transitionTo(StateScanning)
@@ -151,6 +564,7 @@ func (s *Scanner) Scan() *Command {
 					s.transitionTo(StateScanning)
 					cmd := s.currentCmd
 					s.resetCommand()
+					s.pending = line[i+1:]
 					return cmd
 				} else {
 					s.buffer.WriteByte(ch)
@@ -159,39 +573,90 @@ func (s *Scanner) Scan() *Command {
 			case StateWrite:
 				if ch == '>' {
 					s.currentCmd.Argument = strings.TrimSpace(s.buffer.String())
-					s.transitionTo(StateWriteBody)
 					s.buffer.Reset()
+					path, delim, heredoc := heredocDelimiter(s.currentCmd.Argument)
+					if heredoc {
+						s.currentCmd.Argument = path
+					}
+					// Attributes may come in either order
+					for {
+						if path, encoding, ok := writeEncoding(s.currentCmd.Argument); ok {
+							s.currentCmd.Argument = path
+							s.currentCmd.Encoding = encoding
+						} else if path, mode, ok := writeMode(s.currentCmd.Argument); ok {
+							s.currentCmd.Argument = path
+							s.currentCmd.Mode = mode
+						} else {
+							break
+						}
+					}
+					if heredoc {
+						s.delim = delim
+						s.lineStart = 0
+						s.transitionTo(StateHeredoc)
+					} else {
+						s.transitionTo(StateWriteBody)
+					}
 				} else {
 					s.buffer.WriteByte(ch)
 				}
 			case StateWriteBody:
 				// Protect against buffer overflow
 				if !s.checkBufferLimit() {
-					// Abort this command, reset, and continue scanning
-					s.transitionTo(StateScanning)
-					s.resetCommand()
+					// Drop the body and skip to </write>
+					s.discardCommand()
 					break // Exit switch, continue loop
 				}
 
 				// KEY STATE: accumulate everything until </write>
 				s.buffer.WriteByte(ch)
 
-				buffered := s.buffer.String()
-				if strings.Contains(buffered, "</write>") {
-					idx := strings.Index(buffered, "</write>")
-					content := buffered[:idx]
+				// Only a '>' can complete the closing tag, so the body is
+				// not rescanned for every byte
+				if ch == '>' && strings.HasSuffix(s.buffer.String(), "</write>") {
+					content := strings.TrimSuffix(s.buffer.String(), "</write>")
 					s.currentCmd.Content = strings.TrimSpace(content)
 					s.transitionTo(StateScanning)
 					cmd := s.currentCmd
 					s.resetCommand()
+					s.pending = line[i+1:]
+					return cmd
+				}
+
+			case StateHeredoc:
+				// Past the size limit only the current line is kept, so the
+				// delimiter can still be found
+				if !s.currentCmd.Oversized && !s.checkBufferLimit() {
+					current := s.buffer.String()[s.lineStart:]
+					s.currentCmd.Oversized = true
+					s.buffer.Reset()
+					s.buffer.WriteString(current)
+					s.lineStart = 0
+				}
+
+				s.buffer.WriteByte(ch)
+				if ch != '\n' {
+					break
+				}
+				if s.heredocEnded() {
+					cmd := s.finishHeredoc()
+					s.pending = line[i+1:]
 					return cmd
 				}
+				if s.currentCmd.Oversized {
+					s.buffer.Reset()
+				}
+				s.lineStart = s.buffer.Len()
 
 			case StateExec:
 				if ch == '>' {
 					// Save the command argument
 					s.currentCmd.Argument = strings.TrimSpace(s.buffer.String())
 					s.buffer.Reset()
+					if dir, command, ok := execDir(s.currentCmd.Argument); ok {
+						s.currentCmd.Dir = dir
+						s.currentCmd.Argument = command
+					}
 
 					// Peek ahead to see if there's content after '>'
 					remainingLine := strings.TrimSpace(line[i+1:])
@@ -204,6 +669,7 @@ func (s *Scanner) Scan() *Command {
 						s.transitionTo(StateScanning)
 						cmd := s.currentCmd
 						s.resetCommand()
+						s.pending = line[i+1:]
 						return cmd
 					}
 				} else {
@@ -213,9 +679,8 @@ func (s *Scanner) Scan() *Command {
 			case StateExecBody:
 				// Protect against buffer overflow
 				if !s.checkBufferLimit() {
-					// Abort this command, reset, and continue scanning
-					s.transitionTo(StateScanning)
-					s.resetCommand()
+					// Drop the body and skip to </exec>
+					s.discardCommand()
 					break // Exit switch, continue loop
 				}
 
@@ -224,7 +689,7 @@ func (s *Scanner) Scan() *Command {
 				buffered := s.buffer.String()
 
 				// Check if this line starts with </exec> (means it was single-line)
-				trimmed := strings.TrimSpace(buffered)
+				trimmed := strings.TrimLeft(buffered, " \t\r\n")
 				if trimmed == "" || strings.HasPrefix(trimmed, "</exec>") {
 					if strings.HasPrefix(trimmed, "</exec>") {
 						// Empty stdin case: <exec cmd>\n</exec>
@@ -234,30 +699,387 @@ func (s *Scanner) Scan() *Command {
 					s.transitionTo(StateScanning)
 					cmd := s.currentCmd
 					s.resetCommand()
+					s.pending = line[i+1:]
 					return cmd
 				}
 
 				// Check for closing tag in the middle of content
-				if strings.Contains(buffered, "</exec>") {
-					idx := strings.Index(buffered, "</exec>")
-					content := buffered[:idx]
+				if ch == '>' && strings.HasSuffix(buffered, "</exec>") {
+					content := strings.TrimSuffix(buffered, "</exec>")
 					s.currentCmd.Content = strings.TrimSpace(content)
 					s.transitionTo(StateScanning)
 					cmd := s.currentCmd
 					s.resetCommand()
+					s.pending = line[i+1:]
 					return cmd
 				}
-			case StateSearch:
+			case StateSearch, StateSet, StatePlugin, StateTail, StateHash, StateUnzip, StateArchive, StateFetch, StateSQL, StateREPLReset, StateOutline, StateOverview, StateDeps, StateTest, StateCoverage, StateLint, StateOpenMany, StateGitBranch, StateGitCommit, StateIssue, StateDef, StateRefs, StateRenameSymbol, StateASTGrep, StateTokens, StateNote:
 				if ch == '>' {
 					s.currentCmd.Argument = strings.TrimSpace(s.buffer.String())
 					s.transitionTo(StateScanning)
 					cmd := s.currentCmd
 					s.resetCommand()
+					s.pending = line[i+1:]
 					return cmd
 				} else {
 					s.buffer.WriteByte(ch)
 				}
+
+			case StateREPL, StateAppend, StatePR:
+				if ch == '>' {
+					s.currentCmd.Argument = strings.TrimSpace(s.buffer.String())
+					s.buffer.Reset()
+					s.transitionTo(StateBlockBody)
+				} else {
+					s.buffer.WriteByte(ch)
+				}
+
+			case StateBlockBody:
+				// Protect against buffer overflow
+				if !s.checkBufferLimit() {
+					s.discardCommand()
+					break
+				}
+
+				// Accumulate the block until its closing tag
+				s.buffer.WriteByte(ch)
+				closing := "</" + s.currentCmd.Type + ">"
+				if ch == '>' && strings.HasSuffix(s.buffer.String(), closing) {
+					body := strings.TrimSuffix(s.buffer.String(), closing)
+					s.currentCmd.Content = strings.TrimSpace(body)
+					if s.currentCmd.Type == "repl" {
+						// Only blank lines are trimmed, since indentation
+						// matters to Python
+						s.currentCmd.Content = strings.TrimRight(strings.TrimLeft(body, "\r\n"), " \t\r\n")
+					} else if s.currentCmd.Type == "append" || s.currentCmd.Type == "pr" {
+						// Lines to add or a description, trimmed like write
+						// content
+					} else if s.currentCmd.Type == "pipe" {
+						s.currentCmd.Steps = parsePipeSteps(body)
+						s.currentCmd.Argument = fmt.Sprintf("%d steps", len(s.currentCmd.Steps))
+					} else {
+						s.currentCmd.Steps = parseCommands(body)
+						s.currentCmd.Argument = fmt.Sprintf("%d commands", len(s.currentCmd.Steps))
+					}
+					s.transitionTo(StateScanning)
+					cmd := s.currentCmd
+					s.resetCommand()
+					s.pending = line[i+1:]
+					return cmd
+				}
+
+			case StateDiscard:
+				// Closing tags contain a single '<', so only the text since
+				// the last '<' needs to be kept
+				if ch == '<' {
+					s.buffer.Reset()
+				}
+				s.buffer.WriteByte(ch)
+				if ch == '>' && s.buffer.String() == "</"+s.currentCmd.Type+">" {
+					s.transitionTo(StateScanning)
+					cmd := s.currentCmd
+					s.resetCommand()
+					s.pending = line[i+1:]
+					return cmd
+				}
 			}
 		}
 	}
 }
+
+// commandTags maps the opening tag of each command with an argument to a
+// description of the argument it needs
+var commandTags = map[string]string{
+	"open":       "a file path",
+	"open-many":  "a glob pattern",
+	"write":      "a file path",
+	"append":     "a file path",
+	"exec":       "a command",
+	"search":     "a query",
+	"set":        "name=NAME value=VALUE",
+	"tail":       "a file path",
+	"hash":       "a file path",
+	"outline":    "a file path",
+	"test":       "a test path and runner arguments",
+	"unzip":      "an archive path",
+	"archive":    "DEST SOURCE",
+	"fetch":      "a URL",
+	"sql":        "name=NAME and a statement",
+	"git-branch": "a branch name",
+	"git-commit": "a commit message",
+	"pr":         "a title",
+	"issue":      "an issue number",
+	"def":        "a symbol or file:line:col",
+	"refs":       "a symbol or file:line:col",
+	"rename-symbol": "old=NAME and new=NAME",
+	"ast-grep":   "a Go pattern",
+	"note":       "the text of a note",
+}
+
+// inTag reports whether the scanner is inside an opening tag, before its '>'
+func (s *Scanner) inTag() bool {
+	switch s.state {
+	case StateTagOpen, StateOpen, StateWrite, StateExec, StateSearch, StateSet, StatePlugin, StateTail, StateHash, StateUnzip, StateArchive, StateFetch, StateSQL, StateREPL, StateREPLReset, StateOutline, StateOverview, StateDeps, StateTest, StateCoverage, StateLint, StateAppend, StateOpenMany, StateGitBranch, StateGitCommit, StatePR, StateIssue, StateDef, StateRefs, StateRenameSymbol, StateASTGrep, StateTokens, StateNote:
+		return true
+	}
+	return false
+}
+
+// parseError returns a command carrying a parse error for the current
+// command and resets the scanner so scanning can resume
+func (s *Scanner) parseError(cmdType, argument, message, hint string) *Command {
+	cmd := &Command{
+		Type:     cmdType,
+		Argument: argument,
+		ParseError: &ParseError{
+			Tag:     cmdType,
+			Line:    s.cmdLine,
+			Message: message,
+			Hint:    hint,
+		},
+	}
+	s.transitionTo(StateScanning)
+	s.resetCommand()
+	return cmd
+}
+
+// emptyTag returns a parse error for a command tag with no argument, such
+// as "<open>", or nil if buffered is not one
+func (s *Scanner) emptyTag(buffered string) *Command {
+	name := strings.TrimSpace(strings.Trim(buffered, "<>"))
+	need, ok := commandTags[name]
+	if !ok || !strings.HasSuffix(buffered, ">") {
+		return nil
+	}
+	return s.parseError(name, "", fmt.Sprintf("<%s> is missing %s", name, need),
+		fmt.Sprintf("write it as <%s %s>", name, strings.TrimPrefix(strings.TrimPrefix(need, "a "), "an ")))
+}
+
+// unterminatedTag handles a newline inside an opening tag. A command tag
+// yields a parse error; anything else was not a command and is dropped.
+func (s *Scanner) unterminatedTag() *Command {
+	if s.state == StateTagOpen {
+		buffered := strings.TrimSpace(s.buffer.String())
+		name := strings.TrimPrefix(buffered, "<")
+		if _, ok := commandTags[name]; !ok {
+			s.transitionTo(StateScanning)
+			s.buffer.Reset()
+			return nil
+		}
+		return s.parseError(name, "", fmt.Sprintf("<%s tag is not closed with '>' on the same line", name),
+			fmt.Sprintf("put the whole <%s ...> tag on one line", name))
+	}
+
+	cmdType := s.currentCmd.Type
+	argument := strings.TrimSpace(s.buffer.String())
+	tag := openingTag(cmdType, argument)
+	return s.parseError(cmdType, argument, fmt.Sprintf("%s tag is not closed with '>' on the same line", strings.TrimSuffix(tag, ">")),
+		fmt.Sprintf("end the tag with '>': %s", tag))
+}
+
+// finishAtEOF handles a command still open when input ends. An exec with
+// no stdin is complete; with parse errors on anything else yields a parse
+// error, otherwise it is dropped.
+func (s *Scanner) finishAtEOF() *Command {
+	if s.state == StateScanning {
+		return nil
+	}
+
+	if s.state == StateExecBody && strings.TrimSpace(s.buffer.String()) == "" {
+		s.transitionTo(StateScanning)
+		cmd := s.currentCmd
+		s.resetCommand()
+		return cmd
+	}
+
+	if s.state == StateHeredoc && s.heredocEnded() {
+		return s.finishHeredoc()
+	}
+
+	if !s.parseErrors {
+		s.transitionTo(StateScanning)
+		s.resetCommand()
+		return nil
+	}
+
+	switch s.state {
+	case StateTagOpen, StateOpen, StateWrite, StateExec, StateSearch, StateSet, StatePlugin, StateTail, StateHash, StateUnzip, StateArchive, StateFetch, StateSQL, StateREPL, StateREPLReset, StateOutline, StateOverview, StateDeps, StateTest, StateCoverage, StateLint, StateAppend, StateOpenMany, StateGitBranch, StateGitCommit, StatePR, StateIssue, StateDef, StateRefs, StateRenameSymbol, StateASTGrep, StateTokens, StateNote:
+		return s.unterminatedTag()
+	}
+
+	cmdType := s.currentCmd.Type
+	argument := s.currentCmd.Argument
+	closing := "</" + cmdType + ">"
+	if s.state == StateHeredoc {
+		argument += " " + s.delim
+		closing = "a line containing only " + s.delim
+	}
+	return s.parseError(cmdType, argument, fmt.Sprintf("%s is never closed with %s", openingTag(cmdType, argument), closing),
+		fmt.Sprintf("end the content with %s; nothing was executed", closing))
+}
+
+// metaCommand matches an interactive meta-command line, such as ":help"
+var metaCommand = regexp.MustCompile(`^:([a-z]+)$`)
+
+// heredocTag matches a write argument ending in a heredoc delimiter, such
+// as "notes.md EOF"
+var heredocTag = regexp.MustCompile(`^(.+?)\s+([A-Z][A-Z0-9_]*)$`)
+
+// heredocDelimiter splits a write argument into its path and heredoc
+// delimiter, if it has one
+func heredocDelimiter(argument string) (string, string, bool) {
+	match := heredocTag.FindStringSubmatch(argument)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// encodingAttr matches a write argument ending in an encoding attribute,
+// such as "logo.png encoding=base64"
+var encodingAttr = regexp.MustCompile(`^(.+?)\s+encoding=(\S+)$`)
+
+// writeEncoding splits a write argument into its path and content
+// encoding, if it has one
+func writeEncoding(argument string) (string, string, bool) {
+	match := encodingAttr.FindStringSubmatch(argument)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], strings.ToLower(match[2]), true
+}
+
+// modeAttr matches a write argument ending in a mode attribute, such as
+// "run.sh mode=0755"
+var modeAttr = regexp.MustCompile(`^(.+?)\s+mode=(\S+)$`)
+
+// writeMode splits a write argument into its path and the file mode to
+// set, if it has one
+func writeMode(argument string) (string, string, bool) {
+	match := modeAttr.FindStringSubmatch(argument)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// dirAttr matches an exec argument starting with a working directory
+// attribute, such as "dir=services/api go test ./..."
+var dirAttr = regexp.MustCompile(`^dir=(\S+)\s+(.+)$`)
+
+// execDir splits an exec argument into its working directory and command,
+// if it has a directory
+func execDir(argument string) (string, string, bool) {
+	match := dirAttr.FindStringSubmatch(argument)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// heredocEnded reports whether the current heredoc line is the delimiter
+func (s *Scanner) heredocEnded() bool {
+	return strings.TrimSpace(s.buffer.String()[s.lineStart:]) == s.delim
+}
+
+// finishHeredoc completes a heredoc write. The content is every line
+// between the tag and the delimiter, byte for byte; the rest of the tag's
+// own line is dropped if it is blank.
+func (s *Scanner) finishHeredoc() *Command {
+	if !s.currentCmd.Oversized {
+		content := s.buffer.String()[:s.lineStart]
+		if idx := strings.IndexByte(content, '\n'); idx >= 0 && strings.TrimSpace(content[:idx]) == "" {
+			content = content[idx+1:]
+		}
+		s.currentCmd.Content = content
+	}
+
+	s.transitionTo(StateScanning)
+	cmd := s.currentCmd
+	s.resetCommand()
+	return cmd
+}
+
+// openingTag formats the opening tag of a command
+func openingTag(cmdType, argument string) string {
+	if argument == "" {
+		return "<" + cmdType + ">"
+	}
+	return "<" + cmdType + " " + argument + ">"
+}
+
+// skipFencedLine reports whether line is a code fence marker or lies inside
+// a fenced code block, updating the fence state. Only called while scanning
+// for commands in strict mode.
+func (s *Scanner) skipFencedLine(line string) bool {
+	if s.atLineStart {
+		if marker, rest, ok := fenceMarker(line); ok {
+			if s.fence == "" {
+				s.fence = marker
+				return true
+			}
+			if marker[0] == s.fence[0] && len(marker) >= len(s.fence) && strings.TrimSpace(rest) == "" {
+				s.fence = ""
+				return true
+			}
+		}
+	}
+	return s.fence != ""
+}
+
+// fenceMarker returns the run of backticks or tildes opening a markdown
+// code fence line (indented at most three spaces) and the rest of the line
+func fenceMarker(line string) (string, string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return "", "", false
+	}
+
+	n := 0
+	for n < len(trimmed) && trimmed[n] == trimmed[0] {
+		n++
+	}
+	if n < 3 {
+		return "", "", false
+	}
+	return trimmed[:n], trimmed[n:], true
+}
+
+// parsePipeSteps parses the body of a <pipe> block into its steps. Steps
+// separated by </pipe-to> receive the previous step's output: a write or
+// exec after the separator with no body of its own gets "$last" as its
+// content, and a trailing <write path> needs no closing tag.
+func parsePipeSteps(body string) []Command {
+	var steps []Command
+
+	for i, segment := range strings.Split(body, "</pipe-to>") {
+		segment = strings.TrimSpace(segment)
+		if strings.HasPrefix(segment, "<write") && !strings.Contains(segment, "</write>") {
+			segment += "\n</write>"
+		}
+
+		sub := NewScanner(bufio.NewReader(strings.NewReader(segment+"\n")), false)
+		first := true
+		for cmd := sub.Scan(); cmd != nil; cmd = sub.Scan() {
+			if i > 0 && first && cmd.Content == "" && (cmd.Type == "write" || cmd.Type == "exec") {
+				cmd.Content = "$last"
+			}
+			first = false
+			steps = append(steps, *cmd)
+		}
+	}
+
+	return steps
+}
+
+// parseCommands parses the body of a guard block into its commands
+func parseCommands(body string) []Command {
+	var cmds []Command
+
+	sub := NewScanner(bufio.NewReader(strings.NewReader(body+"\n")), false)
+	for cmd := sub.Scan(); cmd != nil; cmd = sub.Scan() {
+		cmds = append(cmds, *cmd)
+	}
+
+	return cmds
+}
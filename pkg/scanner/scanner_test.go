@@ -21,6 +21,36 @@ func TestScannerStateString(t *testing.T) {
 		{StateExecBody, "StateExecBody"},
 		{StateSearch, "StateSearch"},
 		{StateExecute, "StateExecute"},
+		{StateBlockBody, "StateBlockBody"},
+		{StateDiscard, "StateDiscard"},
+		{StateHeredoc, "StateHeredoc"},
+		{StateSet, "StateSet"},
+		{StateTail, "StateTail"},
+		{StateHash, "StateHash"},
+		{StateUnzip, "StateUnzip"},
+		{StateArchive, "StateArchive"},
+		{StateFetch, "StateFetch"},
+		{StateSQL, "StateSQL"},
+		{StateREPL, "StateREPL"},
+		{StateREPLReset, "StateREPLReset"},
+		{StateOutline, "StateOutline"},
+		{StateOverview, "StateOverview"},
+		{StateDeps, "StateDeps"},
+		{StateTest, "StateTest"},
+		{StateCoverage, "StateCoverage"},
+		{StateLint, "StateLint"},
+		{StateAppend, "StateAppend"},
+		{StateOpenMany, "StateOpenMany"},
+		{StateGitBranch, "StateGitBranch"},
+		{StateGitCommit, "StateGitCommit"},
+		{StatePR, "StatePR"},
+		{StateIssue, "StateIssue"},
+		{StateDef, "StateDef"},
+		{StateRefs, "StateRefs"},
+		{StateRenameSymbol, "StateRenameSymbol"},
+		{StateASTGrep, "StateASTGrep"},
+		{StateTokens, "StateTokens"},
+		{StateNote, "StateNote"},
 	}
 
 	for _, tt := range tests {
@@ -533,3 +563,738 @@ func BenchmarkScan_MultipleCommands(b *testing.B) {
 		}
 	}
 }
+
+// TestScan_PipeCommand verifies a pipe block is parsed into its steps
+func TestScan_PipeCommand(t *testing.T) {
+	input := "<pipe><exec go vet ./...></pipe-to><write vet-report.txt></pipe>\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+
+	cmd := scanner.Scan()
+	if cmd == nil {
+		t.Fatal("Scan() returned nil")
+	}
+	if cmd.Type != "pipe" {
+		t.Fatalf("Type = %q, want pipe", cmd.Type)
+	}
+	if len(cmd.Steps) != 2 {
+		t.Fatalf("len(Steps) = %d, want 2", len(cmd.Steps))
+	}
+
+	if cmd.Steps[0].Type != "exec" || cmd.Steps[0].Argument != "go vet ./..." || cmd.Steps[0].Content != "" {
+		t.Errorf("Steps[0] = %+v, want exec of go vet with no content", cmd.Steps[0])
+	}
+	if cmd.Steps[1].Type != "write" || cmd.Steps[1].Argument != "vet-report.txt" || cmd.Steps[1].Content != "$last" {
+		t.Errorf("Steps[1] = %+v, want write of vet-report.txt with $last", cmd.Steps[1])
+	}
+}
+
+// TestScan_PipeCommandVariables verifies steps with explicit bodies keep
+// their content so result variables can be expanded later
+func TestScan_PipeCommandVariables(t *testing.T) {
+	input := `<pipe>
+<exec go test ./...>
+<exec go vet ./...>
+<write summary.txt>
+Tests: $1
+Vet: $last
+</write>
+</pipe>`
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "pipe" {
+		t.Fatalf("Scan() = %+v, want pipe command", cmd)
+	}
+	if len(cmd.Steps) != 3 {
+		t.Fatalf("len(Steps) = %d, want 3", len(cmd.Steps))
+	}
+	if cmd.Steps[1].Content != "" {
+		t.Errorf("Steps[1].Content = %q, want empty", cmd.Steps[1].Content)
+	}
+	if cmd.Steps[2].Content != "Tests: $1\nVet: $last" {
+		t.Errorf("Steps[2].Content = %q, want variables kept", cmd.Steps[2].Content)
+	}
+}
+
+// TestScan_CommandAfterPipe verifies scanning resumes after a pipe block
+func TestScan_CommandAfterPipe(t *testing.T) {
+	input := "<pipe><open a.go></pipe>\n<open b.go>\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "pipe" {
+		t.Fatalf("first Scan() = %+v, want pipe", cmd)
+	}
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "open" || cmd.Argument != "b.go" {
+		t.Fatalf("second Scan() = %+v, want open b.go", cmd)
+	}
+}
+
+// TestScan_GuardCommands verifies if-success and if-failure blocks are
+// parsed into their commands
+func TestScan_GuardCommands(t *testing.T) {
+	input := `<exec go test ./...>
+<if-success>
+<write DONE.md>
+All tests pass
+</write>
+</if-success>
+<if-failure><open test.log></if-failure>
+`
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "exec" {
+		t.Fatalf("first Scan() = %+v, want exec", cmd)
+	}
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "if-success" {
+		t.Fatalf("second Scan() = %+v, want if-success", cmd)
+	}
+	if len(cmd.Steps) != 1 || cmd.Steps[0].Type != "write" || cmd.Steps[0].Argument != "DONE.md" {
+		t.Errorf("if-success Steps = %+v, want write DONE.md", cmd.Steps)
+	}
+
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Type != "if-failure" {
+		t.Fatalf("third Scan() = %+v, want if-failure", cmd)
+	}
+	if len(cmd.Steps) != 1 || cmd.Steps[0].Type != "open" || cmd.Steps[0].Argument != "test.log" {
+		t.Errorf("if-failure Steps = %+v, want open test.log", cmd.Steps)
+	}
+}
+
+// TestScan_OversizedWriteIsDiscarded verifies a body over the size limit is
+// dropped, tags inside it are ignored, and scanning resumes after it
+func TestScan_OversizedWriteIsDiscarded(t *testing.T) {
+	input := "<write big.txt>\n" + strings.Repeat("x", 100) + "<open secret.txt>\n</write>\n<open after.txt>\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+	scanner.SetMaxCommandSize(50)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "write" || cmd.Argument != "big.txt" {
+		t.Fatalf("first Scan() = %+v, want write big.txt", cmd)
+	}
+	if !cmd.Oversized || cmd.Content != "" {
+		t.Errorf("Oversized = %v, Content length = %d, want discarded body", cmd.Oversized, len(cmd.Content))
+	}
+
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Type != "open" || cmd.Argument != "after.txt" {
+		t.Fatalf("second Scan() = %+v, want open after.txt", cmd)
+	}
+}
+
+// TestScan_LongLine verifies commands on lines longer than the reader's
+// buffer are still parsed
+func TestScan_LongLine(t *testing.T) {
+	body := strings.Repeat("y", 20000)
+	input := "prefix " + strings.Repeat("z", 10000) + " <write long.txt>" + body + "</write> <open next.txt>\n"
+	reader := bufio.NewReaderSize(strings.NewReader(input), 16)
+	scanner := NewScanner(reader, false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "write" {
+		t.Fatalf("first Scan() = %+v, want write", cmd)
+	}
+	if cmd.Content != body {
+		t.Errorf("Content length = %d, want %d", len(cmd.Content), len(body))
+	}
+
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Type != "open" || cmd.Argument != "next.txt" {
+		t.Fatalf("second Scan() = %+v, want open next.txt", cmd)
+	}
+}
+
+// TestSetMaxCommandSize_Default verifies non-positive sizes restore the
+// default limit
+func TestSetMaxCommandSize_Default(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("")), false)
+	scanner.SetMaxCommandSize(10)
+	scanner.SetMaxCommandSize(0)
+	if scanner.maxBodySize != maxScannerBufferSize {
+		t.Errorf("maxBodySize = %d, want %d", scanner.maxBodySize, maxScannerBufferSize)
+	}
+}
+
+// TestScan_StrictIgnoresCode verifies commands in code fences and inline
+// code are ignored in strict mode while commands outside are still found
+func TestScan_StrictIgnoresCode(t *testing.T) {
+	input := "Example:\n" +
+		"```\n" +
+		"<open example.go>\n" +
+		"<write example.txt>\nnot real\n</write>\n" +
+		"```\n" +
+		"Use `<open inline.go>` or ``<exec `ls`>`` to read files.\n" +
+		"~~~~markdown\n<open tilde.go>\n~~~\nstill fenced <open tilde2.go>\n~~~~\n" +
+		"<open real.go>\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+	scanner.SetStrict(true)
+
+	var got []string
+	for cmd := scanner.Scan(); cmd != nil; cmd = scanner.Scan() {
+		got = append(got, cmd.Type+" "+cmd.Argument)
+	}
+
+	if len(got) != 1 || got[0] != "open real.go" {
+		t.Errorf("commands = %v, want [open real.go]", got)
+	}
+}
+
+// TestScan_NonStrictParsesCode verifies code is not special outside strict
+// mode
+func TestScan_NonStrictParsesCode(t *testing.T) {
+	input := "```\n<open example.go>\n```\nUse `<open inline.go>`\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+
+	var got []string
+	for cmd := scanner.Scan(); cmd != nil; cmd = scanner.Scan() {
+		got = append(got, cmd.Argument)
+	}
+
+	if len(got) != 2 || got[0] != "example.go" || got[1] != "inline.go" {
+		t.Errorf("commands = %v, want [example.go inline.go]", got)
+	}
+}
+
+// TestScan_EscapedCommand verifies a backslash before '<' escapes a command
+func TestScan_EscapedCommand(t *testing.T) {
+	input := "To read a file write \\<open path> like this. <open real.go>\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Argument != "real.go" {
+		t.Fatalf("Scan() = %+v, want open real.go", cmd)
+	}
+	if cmd = scanner.Scan(); cmd != nil {
+		t.Errorf("unexpected second command %+v", cmd)
+	}
+}
+
+// TestScan_MultipleCommandsPerLine verifies input after a command on the
+// same line is still scanned
+func TestScan_MultipleCommandsPerLine(t *testing.T) {
+	input := "<open a.go> then <open b.go>\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+	scanner := NewScanner(reader, false)
+
+	first := scanner.Scan()
+	second := scanner.Scan()
+	if first == nil || second == nil || first.Argument != "a.go" || second.Argument != "b.go" {
+		t.Errorf("Scan() = %+v, %+v, want a.go then b.go", first, second)
+	}
+}
+
+// TestScan_ParseErrors verifies malformed commands produce parse errors
+// when parse errors are on
+func TestScan_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantType string
+		wantLine int
+		wantMsg  string
+	}{
+		{"unclosed write", "intro\n<write notes.md>\nsome notes\n", "write", 2, "never closed with </write>"},
+		{"unclosed pipe", "<pipe><open a.go>\n", "pipe", 1, "never closed with </pipe>"},
+		{"open missing path", "<open>\n", "open", 1, "missing a file path"},
+		{"open not closed on line", "a\nb <open main.go\nmore text\n", "open", 2, "not closed with '>'"},
+		{"exec tag split", "<exec\ngo test>\n", "exec", 1, "not closed with '>'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(bufio.NewReader(strings.NewReader(tt.input)), false)
+			scanner.SetParseErrors(true)
+
+			cmd := scanner.Scan()
+			if cmd == nil || cmd.ParseError == nil {
+				t.Fatalf("Scan() = %+v, want parse error", cmd)
+			}
+			if cmd.Type != tt.wantType || cmd.ParseError.Line != tt.wantLine {
+				t.Errorf("Type = %q, Line = %d, want %q on line %d", cmd.Type, cmd.ParseError.Line, tt.wantType, tt.wantLine)
+			}
+			if !strings.Contains(cmd.ParseError.Message, tt.wantMsg) {
+				t.Errorf("Message = %q, want it to contain %q", cmd.ParseError.Message, tt.wantMsg)
+			}
+			if cmd.ParseError.Hint == "" {
+				t.Error("expected a hint")
+			}
+		})
+	}
+}
+
+// TestScan_RecoversAfterParseError verifies scanning continues after a
+// malformed tag
+func TestScan_RecoversAfterParseError(t *testing.T) {
+	input := "<open broken.go\n<open fine.go>\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+	scanner.SetParseErrors(true)
+
+	if cmd := scanner.Scan(); cmd == nil || cmd.ParseError == nil {
+		t.Fatalf("first Scan() = %+v, want parse error", cmd)
+	}
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.ParseError != nil || cmd.Argument != "fine.go" {
+		t.Fatalf("second Scan() = %+v, want open fine.go", cmd)
+	}
+}
+
+// TestScan_UnclosedWriteDroppedWithoutParseErrors verifies the lenient
+// default, which strict parsing alone does not change
+func TestScan_UnclosedWriteDroppedWithoutParseErrors(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("<write notes.md>\nnotes\n")), false)
+	scanner.SetStrict(true)
+	if cmd := scanner.Scan(); cmd != nil {
+		t.Errorf("Scan() = %+v, want nil", cmd)
+	}
+}
+
+// TestScan_ExecAtEOFWithoutNewline verifies a final exec is not lost
+func TestScan_ExecAtEOFWithoutNewline(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("run <exec go test ./...>")), false)
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "exec" || cmd.Argument != "go test ./..." {
+		t.Errorf("Scan() = %+v, want exec go test ./...", cmd)
+	}
+}
+
+// TestScan_HeredocWrite verifies a write with a delimiter keeps its content
+// byte for byte, including a literal </write>
+func TestScan_HeredocWrite(t *testing.T) {
+	input := "<write docs/example.md EOF>\n" +
+		"Close writes with </write>.\n" +
+		"  indented line\n" +
+		"\n" +
+		"EOF is only special alone on a line\n" +
+		"EOF\n" +
+		"<open after.go>\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "write" || cmd.Argument != "docs/example.md" {
+		t.Fatalf("Scan() = %+v, want write docs/example.md", cmd)
+	}
+	want := "Close writes with </write>.\n  indented line\n\nEOF is only special alone on a line\n"
+	if cmd.Content != want {
+		t.Errorf("Content = %q, want %q", cmd.Content, want)
+	}
+
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Argument != "after.go" {
+		t.Fatalf("second Scan() = %+v, want open after.go", cmd)
+	}
+}
+
+// TestScan_HeredocDelimiterAtEOF verifies a delimiter without a trailing
+// newline still ends the write
+func TestScan_HeredocDelimiterAtEOF(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("<write a.txt END_OF_FILE>\nhello\nEND_OF_FILE")), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Argument != "a.txt" || cmd.Content != "hello\n" {
+		t.Errorf("Scan() = %+v, want write a.txt with hello", cmd)
+	}
+}
+
+// TestScan_HeredocUnclosedParseError verifies a missing delimiter is reported
+func TestScan_HeredocUnclosedParseError(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("<write a.txt EOF>\nhello\n</write>\n")), false)
+	scanner.SetParseErrors(true)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.ParseError == nil {
+		t.Fatalf("Scan() = %+v, want parse error", cmd)
+	}
+	if !strings.Contains(cmd.ParseError.Message, "only EOF") {
+		t.Errorf("Message = %q, want mention of the delimiter", cmd.ParseError.Message)
+	}
+}
+
+// TestHeredocDelimiter verifies which write arguments carry a delimiter
+func TestHeredocDelimiter(t *testing.T) {
+	tests := []struct {
+		argument string
+		path     string
+		delim    string
+		ok       bool
+	}{
+		{"notes.md EOF", "notes.md", "EOF", true},
+		{"my file.txt END_2", "my file.txt", "END_2", true},
+		{"notes.md", "", "", false},
+		{"my file.txt", "", "", false},
+		{"notes.md eof", "", "", false},
+	}
+
+	for _, tt := range tests {
+		path, delim, ok := heredocDelimiter(tt.argument)
+		if path != tt.path || delim != tt.delim || ok != tt.ok {
+			t.Errorf("heredocDelimiter(%q) = %q, %q, %v, want %q, %q, %v", tt.argument, path, delim, ok, tt.path, tt.delim, tt.ok)
+		}
+	}
+}
+
+func TestScan_WriteEncoding(t *testing.T) {
+	input := "<write img/logo.png encoding=base64>iVBORw0KGgo=</write>\n" +
+		"<write img/icon.gif encoding=BASE64 EOF>\nR0lGODlh\nEOF\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Argument != "img/logo.png" || cmd.Encoding != "base64" {
+		t.Fatalf("Scan() = %+v, want write img/logo.png with base64 encoding", cmd)
+	}
+	if cmd.Content != "iVBORw0KGgo=" {
+		t.Errorf("Content = %q", cmd.Content)
+	}
+
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Argument != "img/icon.gif" || cmd.Encoding != "base64" {
+		t.Fatalf("second Scan() = %+v, want heredoc write img/icon.gif with base64 encoding", cmd)
+	}
+	if cmd.Content != "R0lGODlh\n" {
+		t.Errorf("Content = %q", cmd.Content)
+	}
+}
+
+func TestScan_WriteMode(t *testing.T) {
+	input := "<write bin/run.sh mode=0755>#!/bin/sh\n</write>\n" +
+		"<write bin/tool mode=0755 encoding=base64>f0VMRg==</write>\n" +
+		"<write bin/deploy.sh encoding=base64 mode=0700 EOF>\nIyEvYmluL3No\nEOF\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	for _, want := range []Command{
+		{Argument: "bin/run.sh", Mode: "0755"},
+		{Argument: "bin/tool", Mode: "0755", Encoding: "base64"},
+		{Argument: "bin/deploy.sh", Mode: "0700", Encoding: "base64"},
+	} {
+		cmd := scanner.Scan()
+		if cmd == nil || cmd.Argument != want.Argument || cmd.Mode != want.Mode || cmd.Encoding != want.Encoding {
+			t.Errorf("Scan() = %+v, want write %s with mode %s and encoding %q", cmd, want.Argument, want.Mode, want.Encoding)
+		}
+	}
+}
+
+func TestWriteEncoding(t *testing.T) {
+	tests := []struct {
+		argument string
+		path     string
+		encoding string
+		ok       bool
+	}{
+		{"logo.png encoding=base64", "logo.png", "base64", true},
+		{"my logo.png encoding=Base64", "my logo.png", "base64", true},
+		{"logo.png", "", "", false},
+		{"encoding=base64", "", "", false},
+	}
+
+	for _, tt := range tests {
+		path, encoding, ok := writeEncoding(tt.argument)
+		if path != tt.path || encoding != tt.encoding || ok != tt.ok {
+			t.Errorf("writeEncoding(%q) = %q, %q, %v, want %q, %q, %v", tt.argument, path, encoding, ok, tt.path, tt.encoding, tt.ok)
+		}
+	}
+}
+
+func TestScan_SetCommand(t *testing.T) {
+	input := "<set name=pkg value=internal/parser> then <open ${pkg}/llm-parser.go>\n<settings> is not a command\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "set" || cmd.Argument != "name=pkg value=internal/parser" {
+		t.Fatalf("Scan() = %+v, want set command", cmd)
+	}
+
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Type != "open" || cmd.Argument != "${pkg}/llm-parser.go" {
+		t.Fatalf("second Scan() = %+v, want open with unexpanded argument", cmd)
+	}
+
+	if cmd = scanner.Scan(); cmd != nil {
+		t.Errorf("third Scan() = %+v, want nil", cmd)
+	}
+}
+
+func TestScan_TailCommand(t *testing.T) {
+	input := "<tail logs/build.log lines=50 follow=30s>\n<tailor> is not a command\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "tail" || cmd.Argument != "logs/build.log lines=50 follow=30s" {
+		t.Fatalf("Scan() = %+v, want tail command", cmd)
+	}
+	if cmd = scanner.Scan(); cmd != nil {
+		t.Errorf("second Scan() = %+v, want nil", cmd)
+	}
+}
+
+func TestScan_REPLCommand(t *testing.T) {
+	input := "<repl python>\ndef f(x):\n    return x > 1\n</repl>\n<repl>print(1)</repl>\n<repl-reset>\n<repl-reset go>\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "repl" || cmd.Argument != "python" || cmd.Content != "def f(x):\n    return x > 1" {
+		t.Fatalf("Scan() = %+v, want python repl keeping indentation", cmd)
+	}
+	if cmd = scanner.Scan(); cmd == nil || cmd.Type != "repl" || cmd.Argument != "" || cmd.Content != "print(1)" {
+		t.Fatalf("second Scan() = %+v, want repl in the default language", cmd)
+	}
+	if cmd = scanner.Scan(); cmd == nil || cmd.Type != "repl-reset" || cmd.Argument != "" {
+		t.Fatalf("third Scan() = %+v, want repl-reset", cmd)
+	}
+	if cmd = scanner.Scan(); cmd == nil || cmd.Type != "repl-reset" || cmd.Argument != "go" {
+		t.Fatalf("fourth Scan() = %+v, want repl-reset go", cmd)
+	}
+}
+
+func TestScan_OverviewAndDepsCommands(t *testing.T) {
+	input := "Start with <overview> and then <overview depth=3>\n<deps>\n<deps pkg/config>\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "overview" || cmd.Argument != "" {
+		t.Fatalf("Scan() = %+v, want overview", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "overview" || cmd.Argument != "depth=3" {
+		t.Fatalf("second Scan() = %+v, want overview depth=3", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "deps" || cmd.Argument != "" {
+		t.Fatalf("third Scan() = %+v, want deps", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "deps" || cmd.Argument != "pkg/config" {
+		t.Fatalf("fourth Scan() = %+v, want deps pkg/config", cmd)
+	}
+}
+
+func TestScan_TestLintAndCoverageCommands(t *testing.T) {
+	input := "<test ./internal/parser -run TestParseWrite> then <coverage>\n<test>\n<lint> and <lint web/src>\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "test" || cmd.Argument != "./internal/parser -run TestParseWrite" {
+		t.Fatalf("Scan() = %+v, want test ./internal/parser", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "coverage" || cmd.Argument != "" {
+		t.Fatalf("second Scan() = %+v, want coverage", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "test" || cmd.Argument != "" {
+		t.Fatalf("third Scan() = %+v, want test", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "lint" || cmd.Argument != "" {
+		t.Fatalf("fourth Scan() = %+v, want lint", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "lint" || cmd.Argument != "web/src" {
+		t.Fatalf("fifth Scan() = %+v, want lint web/src", cmd)
+	}
+}
+
+func TestScan_AppendCommand(t *testing.T) {
+	input := "Adding an entry <append CHANGELOG.md>\n## 1.2.0\n- Fixed <open> of empty files\n</append> done\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "append" || cmd.Argument != "CHANGELOG.md" {
+		t.Fatalf("Scan() = %+v, want append CHANGELOG.md", cmd)
+	}
+	if want := "## 1.2.0\n- Fixed <open> of empty files"; cmd.Content != want || len(cmd.Steps) != 0 {
+		t.Errorf("content = %q, steps = %d; want %q and no steps", cmd.Content, len(cmd.Steps), want)
+	}
+	if cmd := scanner.Scan(); cmd != nil {
+		t.Errorf("Scan() after append = %+v, want nil", cmd)
+	}
+}
+
+// TestScan_OpenManyCommand tests that <open-many> is not taken for <open>
+func TestScan_OpenManyCommand(t *testing.T) {
+	input := "<open-many internal/parser/*.go max_files=5> then <open main.go>"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "open-many" || cmd.Argument != "internal/parser/*.go max_files=5" {
+		t.Fatalf("Scan() = %+v, want open-many internal/parser/*.go max_files=5", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "open" || cmd.Argument != "main.go" {
+		t.Errorf("second Scan() = %+v, want open main.go", cmd)
+	}
+}
+
+// TestScan_GitCommands tests <git-branch> and <git-commit>
+func TestScan_GitCommands(t *testing.T) {
+	input := "<git-branch fix/parser-eof>\n<git-commit Handle EOF inside a tag>"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "git-branch" || cmd.Argument != "fix/parser-eof" {
+		t.Fatalf("Scan() = %+v, want git-branch fix/parser-eof", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "git-commit" || cmd.Argument != "Handle EOF inside a tag" {
+		t.Errorf("second Scan() = %+v, want git-commit Handle EOF inside a tag", cmd)
+	}
+}
+
+func TestScan_IssueCommand(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("Start from <issue #123> please")), false)
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "issue" || cmd.Argument != "#123" {
+		t.Errorf("Scan() = %+v, want issue #123", cmd)
+	}
+}
+
+func TestScan_LSPCommands(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("See <def internal/core.NewCommandExecutor> and <refs pkg/app/app.go:120:5 lang=go>")), false)
+	want := []Command{
+		{Type: "def", Argument: "internal/core.NewCommandExecutor"},
+		{Type: "refs", Argument: "pkg/app/app.go:120:5 lang=go"},
+	}
+	for _, w := range want {
+		if cmd := scanner.Scan(); cmd == nil || cmd.Type != w.Type || cmd.Argument != w.Argument {
+			t.Errorf("Scan() = %+v, want %s %s", cmd, w.Type, w.Argument)
+		}
+	}
+}
+
+func TestScan_RenameSymbolCommand(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("Then <rename-symbol old=NewSession new=NewRuntimeSession scope=./...> it")), false)
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "rename-symbol" || cmd.Argument != "old=NewSession new=NewRuntimeSession scope=./..." {
+		t.Errorf("Scan() = %+v, want rename-symbol old=NewSession new=NewRuntimeSession scope=./...", cmd)
+	}
+}
+
+func TestScan_ASTGrepCommand(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("Find <ast-grep if err != nil { return $$$ } path=pkg/> now")), false)
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "ast-grep" || cmd.Argument != "if err != nil { return $$$ } path=pkg/" {
+		t.Errorf("Scan() = %+v, want ast-grep with its pattern", cmd)
+	}
+}
+
+func TestScan_TokensCommand(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("Sizes: <tokens pkg/app> and <tokens> then")), false)
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "tokens" || cmd.Argument != "pkg/app" {
+		t.Errorf("Scan() = %+v, want tokens pkg/app", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "tokens" || cmd.Argument != "" {
+		t.Errorf("Scan() = %+v, want tokens of the repository", cmd)
+	}
+}
+
+func TestScan_NoteAndMemoryCommands(t *testing.T) {
+	scanner := NewScanner(bufio.NewReader(strings.NewReader("Recording <note keep the v1 API until callers move> and reading <memory> back")), false)
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "note" || cmd.Argument != "keep the v1 API until callers move" {
+		t.Errorf("Scan() = %+v, want the note", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "memory" || cmd.Argument != "" {
+		t.Errorf("Scan() = %+v, want memory", cmd)
+	}
+}
+
+func TestScan_PRCommand(t *testing.T) {
+	input := "Opening it <pr Handle EOF inside a tag>\nFixes #12. A <write> left open no longer hangs.\n</pr> done\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "pr" || cmd.Argument != "Handle EOF inside a tag" {
+		t.Fatalf("Scan() = %+v, want pr Handle EOF inside a tag", cmd)
+	}
+	if want := "Fixes #12. A <write> left open no longer hangs."; cmd.Content != want || len(cmd.Steps) != 0 {
+		t.Errorf("content = %q, steps = %d; want %q and no steps", cmd.Content, len(cmd.Steps), want)
+	}
+}
+
+// TestScan_MetaCommand tests that ":name" lines are meta-commands only in
+// interactive mode
+func TestScan_MetaCommand(t *testing.T) {
+	input := ":reload\n<open test.go>\n  :help  \nsee :quit\n"
+
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), true)
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "meta" || cmd.Argument != "reload" {
+		t.Fatalf("Scan() = %+v, want meta reload", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "open" {
+		t.Fatalf("second Scan() = %+v, want open", cmd)
+	}
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "meta" || cmd.Argument != "help" {
+		t.Fatalf("third Scan() = %+v, want meta help", cmd)
+	}
+	if cmd := scanner.Scan(); cmd != nil {
+		t.Errorf("fourth Scan() = %+v, want nil for mid-line colon", cmd)
+	}
+
+	scanner = NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+	if cmd := scanner.Scan(); cmd == nil || cmd.Type != "open" {
+		t.Errorf("Scan() without prompts = %+v, want open", cmd)
+	}
+}
+
+func TestScan_RegisteredCommand(t *testing.T) {
+	if err := RegisterCommand("jira"); err != nil {
+		t.Fatalf("RegisterCommand() error = %v", err)
+	}
+	defer UnregisterCommand("jira")
+
+	input := "Look up <jira ISSUE-123> and <deploy staging>, then <jira>\n<pipe>\n<jira ISSUE-9>\n</pipe>\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "jira" || cmd.Argument != "ISSUE-123" {
+		t.Fatalf("Scan() = %+v, want jira ISSUE-123", cmd)
+	}
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Type != "jira" || cmd.Argument != "" {
+		t.Fatalf("second Scan() = %+v, want jira with no argument (deploy is not registered)", cmd)
+	}
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Type != "pipe" || len(cmd.Steps) != 1 || cmd.Steps[0].Type != "jira" {
+		t.Fatalf("third Scan() = %+v, want pipe with a jira step", cmd)
+	}
+}
+
+func TestRegisterCommand_Invalid(t *testing.T) {
+	for _, name := range []string{"", "Jira", "open", "opener", "exec-remote", "pipe", "has space"} {
+		if err := RegisterCommand(name); err == nil {
+			UnregisterCommand(name)
+			t.Errorf("RegisterCommand(%q) succeeded, want error", name)
+		}
+	}
+}
+
+func TestScan_ExecDir(t *testing.T) {
+	input := "<exec dir=services/api go test ./...>\n<exec go vet ./...>\n"
+	scanner := NewScanner(bufio.NewReader(strings.NewReader(input)), false)
+
+	cmd := scanner.Scan()
+	if cmd == nil || cmd.Type != "exec" || cmd.Dir != "services/api" || cmd.Argument != "go test ./..." {
+		t.Fatalf("Scan() = %+v, want exec go test ./... in services/api", cmd)
+	}
+
+	cmd = scanner.Scan()
+	if cmd == nil || cmd.Dir != "" || cmd.Argument != "go vet ./..." {
+		t.Fatalf("second Scan() = %+v, want exec go vet ./... without a directory", cmd)
+	}
+}
+
+func TestExecDir(t *testing.T) {
+	tests := []struct {
+		argument string
+		dir      string
+		command  string
+		ok       bool
+	}{
+		{"dir=services/api go test ./...", "services/api", "go test ./...", true},
+		{"dir=. make", ".", "make", true},
+		{"go test ./...", "", "", false},
+		{"dir=services/api", "", "", false},
+		{"echo dir=x y", "", "", false},
+	}
+
+	for _, tt := range tests {
+		dir, command, ok := execDir(tt.argument)
+		if dir != tt.dir || command != tt.command || ok != tt.ok {
+			t.Errorf("execDir(%q) = %q, %q, %v, want %q, %q, %v", tt.argument, dir, command, ok, tt.dir, tt.command, tt.ok)
+		}
+	}
+}
@@ -6,12 +6,26 @@ import (
 
 // Command represents a parsed command from LLM output
 type Command struct {
-	Type     string
-	Argument string
-	Content  string
-	StartPos int
-	EndPos   int
-	Original string
+	Type       string      `json:"type"`
+	Argument   string      `json:"argument,omitempty"`
+	Content    string      `json:"content,omitempty"`
+	StartPos   int         `json:"start_pos"`
+	EndPos     int         `json:"end_pos"`
+	Original   string      `json:"original,omitempty"`
+	Encoding   string      `json:"encoding,omitempty"`    // Encoding of a write's content, e.g. "base64"; empty for plain text
+	Mode       string      `json:"mode,omitempty"`        // File mode a write sets, e.g. "0755"; empty to keep the file's own
+	Dir        string      `json:"dir,omitempty"`         // Working directory of an exec, relative to the repository root; empty for the root
+	Steps      []Command   `json:"steps,omitempty"`       // Commands of a <pipe> or guard block, in order
+	Oversized  bool        `json:"oversized,omitempty"`   // Body exceeded the maximum command size and was discarded
+	ParseError *ParseError `json:"parse_error,omitempty"` // Set in strict mode when the command is malformed
+}
+
+// ParseError describes a malformed or unclosed command
+type ParseError struct {
+	Tag     string `json:"tag"`     // Command type, e.g. "write"
+	Line    int    `json:"line"`    // Input line on which the command started
+	Message string `json:"message"` // What was wrong
+	Hint    string `json:"hint"`    // How to fix it
 }
 
 // ExecutionResult holds the result of a command execution
@@ -28,4 +42,11 @@ type ExecutionResult struct {
 	Stdout        string
 	Stderr        string
 	ContainerID   string
+	ContentType   string
+	Warnings      []string
+	Convention    string
+	Attempts      int               // Times the command ran, including retries
+	Tokens        int               // Estimated tokens of an opened file, when annotated
+	CorrelationID string            // Shared with the command's audit entries, spans, and containers
+	Steps         []ExecutionResult // Results of the steps of a pipe or guard block
 }
@@ -0,0 +1,199 @@
+// Package scratch provides each session a scratch space, /scratch to the
+// commands it runs, for intermediate files that belong to neither the
+// repository nor its search index. The space is a temporary directory on
+// the host, removed when the session ends, whose files may be encrypted
+// with a key that never leaves the process.
+package scratch
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+)
+
+// MountPath is where the scratch space appears to commands
+const MountPath = "/scratch"
+
+// dirPrefix names the temporary directories of scratch spaces
+const dirPrefix = "llm-scratch-"
+
+// ownerFile holds the owner of a scratch space, beside its files, so
+// those of sessions no longer running can be told apart
+const ownerFile = "owner"
+
+// Space is a session's scratch space
+type Space struct {
+	Dir string // Host directory holding the files of /scratch
+
+	base string      // Temporary directory holding Dir and the owner file
+	aead cipher.AEAD // Encrypts files; nil to store them as they are
+}
+
+// New creates a scratch space in the temporary directory for the session
+// of owner, as sandbox.Owner returns it. With encrypt, files are stored
+// encrypted with AES-GCM under a key generated for the space.
+func New(owner string, encrypt bool) (*Space, error) {
+	base, err := os.MkdirTemp("", dirPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create scratch space: %w", err)
+	}
+	s := &Space{Dir: filepath.Join(base, "files"), base: base}
+	if err := os.Mkdir(s.Dir, 0700); err != nil {
+		os.RemoveAll(base)
+		return nil, fmt.Errorf("cannot create scratch space: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(base, ownerFile), []byte(owner), 0600); err != nil {
+		os.RemoveAll(base)
+		return nil, fmt.Errorf("cannot create scratch space: %w", err)
+	}
+
+	if encrypt {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			os.RemoveAll(base)
+			return nil, fmt.Errorf("cannot generate scratch key: %w", err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			os.RemoveAll(base)
+			return nil, err
+		}
+		if s.aead, err = cipher.NewGCM(block); err != nil {
+			os.RemoveAll(base)
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// IsPath reports whether p names a file in the scratch space, such as
+// /scratch/out.json
+func IsPath(p string) bool {
+	p = path.Clean(filepath.ToSlash(p))
+	return p == MountPath || strings.HasPrefix(p, MountPath+"/")
+}
+
+// Encrypted reports whether the space's files are stored encrypted
+func (s *Space) Encrypted() bool {
+	return s.aead != nil
+}
+
+// HostPath returns the host path of the file p names in the space. Paths
+// leaving the space, such as /scratch/../etc/passwd, are refused, and so
+// are those that leave it through a symlink: every exec container can
+// write to the space, so a command could plant one pointing anywhere on
+// the host.
+func (s *Space) HostPath(p string) (string, error) {
+	if !IsPath(p) {
+		return "", fmt.Errorf("%s is not under %s", p, MountPath)
+	}
+	rel := strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), MountPath)
+	rel = strings.TrimPrefix(rel, "/")
+	if rel == "" {
+		return "", fmt.Errorf("%s names the scratch space, not a file in it", p)
+	}
+	hostPath := filepath.Join(s.Dir, filepath.FromSlash(rel))
+	if err := sandbox.HostPathWithinRepo(hostPath, s.Dir); err != nil {
+		return "", fmt.Errorf("%s leaves the scratch space through a symlink", p)
+	}
+	return hostPath, nil
+}
+
+// ReadFile returns the contents of the file p names
+func (s *Space) ReadFile(p string) ([]byte, error) {
+	hostPath, err := s.HostPath(p)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(hostPath)
+	if err != nil {
+		return nil, err
+	}
+	if s.aead == nil {
+		return data, nil
+	}
+
+	size := s.aead.NonceSize()
+	if len(data) < size {
+		return nil, fmt.Errorf("%s is not encrypted with this session's key", p)
+	}
+	// The path is authenticated too, so files cannot be swapped around
+	plain, err := s.aead.Open(nil, data[:size], data[size:], []byte(path.Clean(filepath.ToSlash(p))))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not encrypted with this session's key", p)
+	}
+	return plain, nil
+}
+
+// WriteFile replaces the file p names with data, creating the directories
+// it is in
+func (s *Space) WriteFile(p string, data []byte) error {
+	hostPath, err := s.HostPath(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(hostPath), 0700); err != nil {
+		return err
+	}
+	if s.aead != nil {
+		nonce := make([]byte, s.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		data = s.aead.Seal(nonce, nonce, data, []byte(path.Clean(filepath.ToSlash(p))))
+	}
+	return os.WriteFile(hostPath, data, 0600)
+}
+
+// Close removes the space and everything in it
+func (s *Space) Close() error {
+	return os.RemoveAll(s.base)
+}
+
+// Abandoned describes a scratch space removed because the process that
+// created it is gone
+type Abandoned struct {
+	Dir  string
+	Size int64 // Bytes its files took
+}
+
+// Prune removes the scratch spaces in the temporary directory whose owner
+// is no longer running, as running reports it
+func Prune(running func(owner string) bool) ([]Abandoned, error) {
+	return prune(os.TempDir(), running)
+}
+
+func prune(dir string, running func(owner string) bool) ([]Abandoned, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []Abandoned
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), dirPrefix) {
+			continue
+		}
+		base := filepath.Join(dir, entry.Name())
+		owner, err := os.ReadFile(filepath.Join(base, ownerFile))
+		if err != nil || running(string(owner)) {
+			continue
+		}
+		size := sandbox.DirSize(base)
+		if err := os.RemoveAll(base); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pruned = append(pruned, Abandoned{Dir: base, Size: size})
+	}
+	return pruned, errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package scratch
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPath(t *testing.T) {
+	for p, want := range map[string]bool{
+		"/scratch":           true,
+		"/scratch/out.json":  true,
+		"/scratch/a/../b":    true,
+		"/scratchpad/x":      false,
+		"scratch/out.json":   false,
+		"/workspace/scratch": false,
+	} {
+		if got := IsPath(p); got != want {
+			t.Errorf("IsPath(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestSpace(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		s, err := New("host:1", encrypt)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer s.Close()
+
+		if err := s.WriteFile("/scratch/out/result.json", []byte(`{"ok": true}`)); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		got, err := s.ReadFile("/scratch/out//result.json")
+		if err != nil || string(got) != `{"ok": true}` {
+			t.Errorf("ReadFile() = %q, %v", got, err)
+		}
+		onDisk, _ := os.ReadFile(filepath.Join(s.Dir, "out", "result.json"))
+		if plain := bytes.Contains(onDisk, []byte(`"ok"`)); plain == encrypt {
+			t.Errorf("encrypt %v: file on disk is %q", encrypt, onDisk)
+		}
+
+		for _, p := range []string{"/scratch", "/scratch/../etc/passwd", "/etc/passwd"} {
+			if _, err := s.HostPath(p); err == nil {
+				t.Errorf("HostPath(%q) succeeded, want error", p)
+			}
+		}
+	}
+}
+
+func TestSpace_Symlinks(t *testing.T) {
+	s, err := New("host:1", false)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "id_rsa")
+	if err := os.WriteFile(secret, []byte("PRIVATE KEY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// As a command in an exec container could leave them
+	os.Symlink(secret, filepath.Join(s.Dir, "k"))
+	os.Symlink(outside, filepath.Join(s.Dir, "outputs"))
+	os.WriteFile(filepath.Join(s.Dir, "notes.txt"), []byte("notes"), 0600)
+	os.Symlink("notes.txt", filepath.Join(s.Dir, "inside"))
+
+	if data, err := s.ReadFile("/scratch/k"); err == nil {
+		t.Errorf("ReadFile() through a symlink out of the space = %q, want error", data)
+	}
+	if err := s.WriteFile("/scratch/k", []byte("overwritten")); err == nil {
+		t.Error("WriteFile() through a symlink out of the space succeeded, want error")
+	}
+	if err := s.WriteFile("/scratch/outputs/result.txt", []byte("x")); err == nil {
+		t.Error("WriteFile() under a symlinked directory succeeded, want error")
+	}
+	if data, _ := os.ReadFile(secret); string(data) != "PRIVATE KEY" {
+		t.Errorf("file outside the space = %q, want it unchanged", data)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "result.txt")); err == nil {
+		t.Error("file created outside the space")
+	}
+
+	// Symlinks that stay in the space are followed
+	if data, err := s.ReadFile("/scratch/inside"); err != nil || string(data) != "notes" {
+		t.Errorf("ReadFile() through a symlink in the space = %q, %v", data, err)
+	}
+}
+
+func TestSpace_EncryptedFileMoved(t *testing.T) {
+	s, err := New("host:1", true)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	s.WriteFile("/scratch/a.txt", []byte("secret"))
+	os.Rename(filepath.Join(s.Dir, "a.txt"), filepath.Join(s.Dir, "b.txt"))
+	if _, err := s.ReadFile("/scratch/b.txt"); err == nil {
+		t.Error("ReadFile() of a moved file succeeded, want error")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	for name, owner := range map[string]string{"llm-scratch-1": "host:1", "llm-scratch-2": "host:2", "other": "host:1"} {
+		os.MkdirAll(filepath.Join(dir, name, "files"), 0700)
+		os.WriteFile(filepath.Join(dir, name, ownerFile), []byte(owner), 0600)
+		os.WriteFile(filepath.Join(dir, name, "files", "data"), []byte("12345"), 0600)
+	}
+
+	pruned, err := prune(dir, func(owner string) bool { return owner == "host:2" })
+	if err != nil {
+		t.Fatalf("prune() error = %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].Dir != filepath.Join(dir, "llm-scratch-1") || pruned[0].Size == 0 {
+		t.Errorf("prune() = %+v, want llm-scratch-1 only", pruned)
+	}
+	for _, name := range []string{"llm-scratch-2", "other"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s removed: %v", name, err)
+		}
+	}
+}
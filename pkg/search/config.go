@@ -13,4 +13,5 @@ type SearchConfig struct {
 	OllamaURL           string   `yaml:"ollama_url"`
 	IndexExtensions     []string `yaml:"index_extensions"`
 	MaxFileSize         int64    `yaml:"max_file_size"`
+	RespectIgnoreFiles  bool     `yaml:"respect_ignore_files"`
 }
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
 )
 
 // IndexStats holds statistics about indexing operation
@@ -31,23 +33,34 @@ func IndexRepository(db *sql.DB, cfg *SearchConfig, repoRoot string, excludedPat
 		fmt.Fprintf(os.Stderr, "Starting repository indexing...\n")
 	}
 
+	ignored := newIgnoreFilter(cfg, repoRoot)
+
 	// Walk through repository
 	err := filepath.Walk(repoRoot, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip directories
+		// Get relative path
+		relPath, err := filepath.Rel(repoRoot, path)
+		if err != nil {
+			relPath = path
+		}
+
+		// Skip directories, pruning ignored ones entirely
 		if info.IsDir() {
+			if ignored(relPath, true) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		stats.TotalFiles++
 
-		// Get relative path
-		relPath, err := filepath.Rel(repoRoot, path)
-		if err != nil {
-			relPath = path
+		// Check ignore files
+		if ignored(relPath, false) {
+			stats.SkippedFiles++
+			return nil
 		}
 
 		// Check if file should be indexed
@@ -134,18 +147,30 @@ func shouldIndexFile(filePath string, indexExtensions []string, excludedPaths []
 	}
 
 	// Check if path is excluded
-	for _, excluded := range excludedPaths {
-		if matched, _ := filepath.Match(excluded, filepath.Base(filePath)); matched {
-			return false
-		}
-		if strings.HasPrefix(filePath, excluded+string(filepath.Separator)) {
-			return false
-		}
+	if _, _, matched := ignore.CompilePatterns(excludedPaths).Match(filePath, false); matched {
+		return false
 	}
 
 	return true
 }
 
+// newIgnoreFilter returns a function reporting whether a repository-relative
+// path is excluded by .gitignore/.llmignore files. When ignore files are not
+// honored the filter never matches.
+func newIgnoreFilter(cfg *SearchConfig, repoRoot string) func(relPath string, isDir bool) bool {
+	if !cfg.RespectIgnoreFiles {
+		return func(string, bool) bool { return false }
+	}
+
+	matcher := ignore.NewMatcher(repoRoot)
+	return func(relPath string, isDir bool) bool {
+		if relPath == "." {
+			return false
+		}
+		return matcher.Match(relPath, isDir)
+	}
+}
+
 // fileNeedsIndexing checks if a file needs to be indexed or re-indexed
 func fileNeedsIndexing(db *sql.DB, filePath string, info os.FileInfo, forceReindex bool) (bool, error) {
 	if forceReindex {
@@ -220,19 +245,29 @@ func UpdateIndex(db *sql.DB, cfg *SearchConfig, repoRoot string, excludedPaths [
 	// Track files that still exist
 	existingFiles := make(map[string]bool)
 
+	ignored := newIgnoreFilter(cfg, repoRoot)
+
 	// Walk through repository to find changed/new files
 	err = filepath.Walk(repoRoot, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		relPath, err := filepath.Rel(repoRoot, path)
+		if err != nil {
+			relPath = path
+		}
+
 		if info.IsDir() {
+			if ignored(relPath, true) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		relPath, err := filepath.Rel(repoRoot, path)
-		if err != nil {
-			relPath = path
+		// Ignored files are treated as gone so stale entries get removed
+		if ignored(relPath, false) {
+			return nil
 		}
 
 		existingFiles[relPath] = true
@@ -668,3 +668,30 @@ func TestIsTextFile_NonexistentFile(t *testing.T) {
 		t.Error("isTextFile() should return false for nonexistent file")
 	}
 }
+
+// TestNewIgnoreFilter tests that .gitignore/.llmignore rules are applied when enabled
+func TestNewIgnoreFilter(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("node_modules/\n*.gen.go\n"), 0644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+
+	enabled := newIgnoreFilter(&SearchConfig{RespectIgnoreFiles: true}, tmpDir)
+	if !enabled("node_modules", true) {
+		t.Error("expected node_modules directory to be ignored")
+	}
+	if !enabled(filepath.Join("pkg", "api.gen.go"), false) {
+		t.Error("expected generated file to be ignored")
+	}
+	if enabled("main.go", false) {
+		t.Error("expected main.go not to be ignored")
+	}
+	if enabled(".", true) {
+		t.Error("expected repository root never to be ignored")
+	}
+
+	disabled := newIgnoreFilter(&SearchConfig{RespectIgnoreFiles: false}, tmpDir)
+	if disabled("node_modules", true) {
+		t.Error("expected nothing to be ignored when ignore files are not honored")
+	}
+}
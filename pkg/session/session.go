@@ -4,23 +4,55 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/metrics"
 )
 
-// Session manages a tool execution session
+// Session manages a tool execution session. Its counters and audit log
+// are safe for concurrent use.
 type Session struct {
 	ID          string
 	Config      *config.Config
-	CommandsRun int
 	StartTime   time.Time
 	AuditLogger *log.Logger
+
+	mu        sync.Mutex
+	stats     Stats
+	auditFile *os.File // Closed by Close; nil if the audit log could not be opened
+}
+
+// Outcome of a recorded command
+const (
+	OutcomeOK      = "ok"
+	OutcomeFailed  = "failed"
+	OutcomeSkipped = "skipped"
+)
+
+// Tally counts commands by outcome and the time they took
+type Tally struct {
+	Run      int // All recorded commands, whatever their outcome
+	Failed   int
+	Skipped  int
+	Duration time.Duration
+}
+
+// Stats is a snapshot of the commands a session has run
+type Stats struct {
+	Tally
+	ByType map[string]Tally
 }
 
 // NewSession creates a new execution session
 func NewSession(cfg *config.Config) *Session {
 	sessionID := fmt.Sprintf("%d", time.Now().UnixNano())
+	startTime := time.Now()
+	if cfg.Deterministic {
+		sessionID = config.DeterministicSessionID
+		startTime = config.DeterministicTime
+	}
 
 	// Setup audit logging
 	auditFile, err := os.OpenFile("audit.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -33,14 +65,87 @@ func NewSession(cfg *config.Config) *Session {
 	return &Session{
 		ID:          sessionID,
 		Config:      cfg,
-		StartTime:   time.Now(),
+		StartTime:   startTime,
 		AuditLogger: auditLogger,
+		auditFile:   auditFile,
+	}
+}
+
+// RecordCommand counts a command of cmdType that ended with outcome after
+// d. Steps of pipes and guard blocks are not recorded on their own.
+func (s *Session) RecordCommand(cmdType, outcome string, d time.Duration) {
+	s.mu.Lock()
+	if s.stats.ByType == nil {
+		s.stats.ByType = make(map[string]Tally)
+	}
+	byType := s.stats.ByType[cmdType]
+	for _, t := range []*Tally{&s.stats.Tally, &byType} {
+		t.Run++
+		switch outcome {
+		case OutcomeFailed:
+			t.Failed++
+		case OutcomeSkipped:
+			t.Skipped++
+		}
+		t.Duration += d
+	}
+	s.stats.ByType[cmdType] = byType
+	total := s.stats.Tally
+	s.mu.Unlock()
+
+	metrics.SessionCommands.WithLabelValues(OutcomeOK).Set(float64(total.Run - total.Failed - total.Skipped))
+	metrics.SessionCommands.WithLabelValues(OutcomeFailed).Set(float64(total.Failed))
+	metrics.SessionCommands.WithLabelValues(OutcomeSkipped).Set(float64(total.Skipped))
+}
+
+// CommandsRun returns the number of commands recorded
+func (s *Session) CommandsRun() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats.Run
+}
+
+// Stats returns a copy of the session's counters
+func (s *Session) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{Tally: s.stats.Tally, ByType: make(map[string]Tally, len(s.stats.ByType))}
+	for cmdType, t := range s.stats.ByType {
+		stats.ByType[cmdType] = t
 	}
+	return stats
 }
 
-// LogAudit writes an audit log entry
+// Close syncs the audit log to disk and closes it. Entries logged after
+// Close are dropped.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.auditFile == nil {
+		return nil
+	}
+	s.AuditLogger = nil
+	if err := s.auditFile.Sync(); err != nil {
+		s.auditFile.Close()
+		return fmt.Errorf("cannot flush audit log: %w", err)
+	}
+	return s.auditFile.Close()
+}
+
+// LogAudit writes an audit log entry for an event outside any command,
+// such as a config reload
 func (s *Session) LogAudit(command, argument string, success bool, errorMsg string) {
-	if s.AuditLogger == nil {
+	s.LogCommandAudit("", command, argument, success, errorMsg)
+}
+
+// LogCommandAudit writes an audit log entry for a command, with the
+// correlation ID its spans and containers carry too
+func (s *Session) LogCommandAudit(correlationID, command, argument string, success bool, errorMsg string) {
+	s.mu.Lock()
+	logger := s.AuditLogger
+	s.mu.Unlock()
+	if logger == nil {
 		return
 	}
 
@@ -49,14 +154,19 @@ func (s *Session) LogAudit(command, argument string, success bool, errorMsg stri
 		status = "failed"
 	}
 
-	logEntry := fmt.Sprintf("%s|session:%s|%s|%s|%s|%s",
+	logEntry := fmt.Sprintf("%s|session:%s|%s|%s|%s|%s|cid:%s",
 		time.Now().Format(time.RFC3339),
 		s.ID,
 		command,
 		argument,
 		status,
 		errorMsg,
+		correlationID,
 	)
 
-	s.AuditLogger.Println(logEntry)
+	// Secrets resolved from the config can turn up in arguments and
+	// errors, such as a DSN in a driver's error
+	if err := logger.Output(2, config.RedactSecrets(logEntry)); err != nil {
+		metrics.AuditErrors.Inc()
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -47,8 +48,8 @@ func TestNewSession(t *testing.T) {
 			t.Error("Session StartTime should be set")
 		}
 
-		if session.CommandsRun != 0 {
-			t.Errorf("CommandsRun = %d, want 0", session.CommandsRun)
+		if got := session.CommandsRun(); got != 0 {
+			t.Errorf("CommandsRun() = %d, want 0", got)
 		}
 	})
 
@@ -224,9 +225,9 @@ func TestSession_LogAudit(t *testing.T) {
 		line := strings.TrimSpace(string(data))
 		parts := strings.Split(line, "|")
 
-		// Expected: timestamp|session:ID|command|argument|status|errorMsg
-		if len(parts) != 6 {
-			t.Errorf("Log line should have 6 parts, got %d: %q", len(parts), line)
+		// Expected: timestamp|session:ID|command|argument|status|errorMsg|cid:ID
+		if len(parts) != 7 {
+			t.Errorf("Log line should have 7 parts, got %d: %q", len(parts), line)
 			return
 		}
 
@@ -251,6 +252,27 @@ func TestSession_LogAudit(t *testing.T) {
 		if parts[4] != "success" {
 			t.Errorf("Fifth part should be 'success', got %q", parts[4])
 		}
+
+		if parts[6] != "cid:" {
+			t.Errorf("Last part should be an empty 'cid:' outside a command, got %q", parts[6])
+		}
+	})
+
+	t.Run("command entries carry the correlation ID", func(t *testing.T) {
+		if err := os.Chdir(t.TempDir()); err != nil {
+			t.Fatalf("Failed to change directory: %v", err)
+		}
+
+		session := NewSession(&config.Config{})
+		session.LogCommandAudit("0123456789abcdef", "exec", "go test", true, "exit_code:0")
+
+		data, err := os.ReadFile("audit.log")
+		if err != nil {
+			t.Fatalf("Failed to read audit log: %v", err)
+		}
+		if line := strings.TrimSpace(string(data)); !strings.HasSuffix(line, "|exec|go test|success|exit_code:0|cid:0123456789abcdef") {
+			t.Errorf("audit line = %q, want the correlation ID last", line)
+		}
 	})
 
 	t.Run("multiple log entries", func(t *testing.T) {
@@ -284,18 +306,18 @@ func TestSession_Fields(t *testing.T) {
 	os.Chdir(tempDir)
 	defer os.Chdir(origDir)
 
-	t.Run("CommandsRun can be incremented", func(t *testing.T) {
+	t.Run("CommandsRun counts recorded commands", func(t *testing.T) {
 		cfg := &config.Config{}
 		session := NewSession(cfg)
 
-		if session.CommandsRun != 0 {
-			t.Errorf("Initial CommandsRun = %d, want 0", session.CommandsRun)
+		if got := session.CommandsRun(); got != 0 {
+			t.Errorf("Initial CommandsRun() = %d, want 0", got)
 		}
 
-		session.CommandsRun++
+		session.RecordCommand("open", OutcomeOK, time.Millisecond)
 
-		if session.CommandsRun != 1 {
-			t.Errorf("CommandsRun after increment = %d, want 1", session.CommandsRun)
+		if got := session.CommandsRun(); got != 1 {
+			t.Errorf("CommandsRun() after RecordCommand = %d, want 1", got)
 		}
 	})
 
@@ -363,3 +385,92 @@ func TestSession_AuditLogCreation(t *testing.T) {
 		}
 	})
 }
+
+func TestSession_Close(t *testing.T) {
+	origDir, _ := os.Getwd()
+	tempDir := t.TempDir()
+	os.Chdir(tempDir)
+	defer os.Chdir(origDir)
+
+	session := NewSession(&config.Config{})
+	session.LogAudit("exec", "go test", true, "")
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// Entries after Close are dropped rather than failing
+	session.LogAudit("exec", "go vet", true, "")
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to read audit.log: %v", err)
+	}
+	if !strings.Contains(string(data), "go test") || strings.Contains(string(data), "go vet") {
+		t.Errorf("audit.log = %q, want only the entry before Close", data)
+	}
+
+	if err := (&Session{}).Close(); err != nil {
+		t.Errorf("Close() without an audit log error = %v", err)
+	}
+}
+
+func TestSession_Stats(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	session := NewSession(&config.Config{})
+	session.RecordCommand("open", OutcomeOK, time.Second)
+	session.RecordCommand("exec", OutcomeFailed, 2*time.Second)
+	session.RecordCommand("exec", OutcomeSkipped, 0)
+	session.RecordCommand("open", OutcomeOK, time.Second)
+
+	stats := session.Stats()
+	if want := (Tally{Run: 4, Failed: 1, Skipped: 1, Duration: 4 * time.Second}); stats.Tally != want {
+		t.Errorf("totals = %+v, want %+v", stats.Tally, want)
+	}
+	if want := (Tally{Run: 2, Duration: 2 * time.Second}); stats.ByType["open"] != want {
+		t.Errorf("open = %+v, want %+v", stats.ByType["open"], want)
+	}
+	if want := (Tally{Run: 2, Failed: 1, Skipped: 1, Duration: 2 * time.Second}); stats.ByType["exec"] != want {
+		t.Errorf("exec = %+v, want %+v", stats.ByType["exec"], want)
+	}
+
+	// The snapshot is a copy
+	stats.ByType["open"] = Tally{}
+	if session.Stats().ByType["open"].Run != 2 {
+		t.Error("changing a snapshot changed the session")
+	}
+}
+
+func TestSession_RecordCommandConcurrent(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	session := NewSession(&config.Config{})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				session.RecordCommand([]string{"open", "write", "exec"}[j%3], OutcomeOK, time.Millisecond)
+				session.LogAudit("open", "main.go", true, "")
+				_ = session.Stats()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := session.CommandsRun(); got != 1000 {
+		t.Errorf("CommandsRun() = %d, want 1000", got)
+	}
+	stats := session.Stats()
+	sum := 0
+	for _, t := range stats.ByType {
+		sum += t.Run
+	}
+	if sum != 1000 {
+		t.Errorf("per-type runs add up to %d, want 1000", sum)
+	}
+}
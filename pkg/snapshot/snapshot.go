@@ -0,0 +1,493 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDir is the snapshot location relative to the repository root
+const DefaultDir = ".llm-tools/snapshots"
+
+// idFormat names snapshots by the time they were taken
+const idFormat = "20060102-150405"
+
+// Sources of the file list a snapshot holds
+const (
+	SourceGit  = "git"  // Tracked and untracked files git does not ignore
+	SourceWalk = "walk" // Every file under the repository root
+)
+
+// Snapshot describes a copy of the working tree
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+	Source  string    `json:"source"` // SourceGit or SourceWalk
+	Files   int       `json:"files"`
+	Bytes   int64     `json:"bytes"`  // Total size of the files, before compression
+	SHA256  string    `json:"sha256"` // Digest of the archive, checked before a restore
+	Path    string    `json:"-"`      // Absolute path of the archive
+}
+
+// RestoreResult describes what a restore changed
+type RestoreResult struct {
+	Snapshot Snapshot // The snapshot restored
+	Safety   Snapshot // The snapshot of the tree taken just before
+	Restored int      // Files written from the snapshot
+	Removed  []string // Files not in the snapshot that were deleted, relative to the root
+}
+
+// Manager stores snapshots of a repository's working tree as gzipped tar
+// archives, each with a JSON manifest beside it
+type Manager struct {
+	repoRoot string
+	dir      string
+	now      func() time.Time
+}
+
+// NewManager creates a snapshot manager. dir is resolved against repoRoot
+// when relative (empty means DefaultDir).
+func NewManager(repoRoot, dir string) *Manager {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoRoot, dir)
+	}
+	return &Manager{repoRoot: repoRoot, dir: dir, now: time.Now}
+}
+
+// Dir returns the absolute snapshot directory
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Create archives the working tree. In a git repository it holds the files
+// git tracks plus untracked ones it does not ignore; elsewhere every file.
+// Snapshots, backups, and .git are never included.
+func (m *Manager) Create(message string) (Snapshot, error) {
+	files, source, err := m.files()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	taken := m.now().UTC()
+	id := taken.Format(idFormat)
+	for n := 2; m.exists(id); n++ {
+		id = fmt.Sprintf("%s-%d", taken.Format(idFormat), n)
+	}
+	snap := Snapshot{ID: id, Time: taken, Message: message, Source: source, Path: m.archivePath(id)}
+
+	tmp, err := os.CreateTemp(m.dir, ".snapshot-*")
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	digest := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(tmp, digest))
+	tw := tar.NewWriter(gz)
+	for _, rel := range files {
+		size, err := m.addFile(tw, rel)
+		if err != nil {
+			tmp.Close()
+			return Snapshot{}, err
+		}
+		snap.Files++
+		snap.Bytes += size
+	}
+	if err := tw.Close(); err != nil {
+		tmp.Close()
+		return Snapshot{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return Snapshot{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	snap.SHA256 = hex.EncodeToString(digest.Sum(nil))
+
+	if err := os.Rename(tmp.Name(), snap.Path); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	manifest, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := os.WriteFile(m.manifestPath(id), append(manifest, '\n'), 0644); err != nil {
+		os.Remove(snap.Path)
+		return Snapshot{}, fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return snap, nil
+}
+
+// List returns the snapshots, newest first
+func (m *Manager) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snaps []Snapshot
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		snap, err := m.load(id)
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		if !snaps[i].Time.Equal(snaps[j].Time) {
+			return snaps[i].Time.After(snaps[j].Time)
+		}
+		return snaps[i].ID > snaps[j].ID
+	})
+	return snaps, nil
+}
+
+// Get returns the snapshot with the given id, or the newest if id is empty
+func (m *Manager) Get(id string) (Snapshot, error) {
+	if id != "" {
+		snap, err := m.load(id)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("snapshot %s not found", id)
+		}
+		return snap, nil
+	}
+	snaps, err := m.List()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(snaps) == 0 {
+		return Snapshot{}, fmt.Errorf("no snapshots found in %s", m.dir)
+	}
+	return snaps[0], nil
+}
+
+// Restore puts the working tree back as it was in the snapshot with the
+// given id, or the newest if id is empty: its files are rewritten and files
+// that were not in it are deleted. The archive is checked against its
+// digest first, and the current tree is snapshotted so a restore can
+// itself be undone.
+func (m *Manager) Restore(id string) (RestoreResult, error) {
+	snap, err := m.Get(id)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	if err := verify(snap); err != nil {
+		return RestoreResult{}, err
+	}
+
+	safety, err := m.Create("before restoring " + snap.ID)
+	if err != nil {
+		return RestoreResult{}, fmt.Errorf("failed to snapshot current tree: %w", err)
+	}
+	result := RestoreResult{Snapshot: snap, Safety: safety}
+
+	kept, err := m.extract(snap, &result)
+	if err != nil {
+		return result, err
+	}
+
+	current, _, err := m.files()
+	if err != nil {
+		return result, err
+	}
+	for _, rel := range current {
+		if kept[rel] {
+			continue
+		}
+		target := filepath.Join(m.repoRoot, filepath.FromSlash(rel))
+		if err := os.Remove(target); err != nil {
+			return result, fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		result.Removed = append(result.Removed, rel)
+		m.removeEmptyParents(filepath.Dir(target))
+	}
+	return result, nil
+}
+
+// files lists the files a snapshot holds, relative to the root with
+// forward slashes, and where the list came from
+func (m *Manager) files() ([]string, string, error) {
+	var files []string
+	source := SourceGit
+	out, err := exec.Command("git", "-C", m.repoRoot, "ls-files", "-z", "--cached", "--others", "--exclude-standard").Output()
+	if err == nil {
+		for _, rel := range strings.Split(string(out), "\x00") {
+			if rel == "" {
+				continue
+			}
+			// Files deleted from the working tree are still listed as
+			// cached, and submodules are listed as their directories
+			if info, err := os.Lstat(filepath.Join(m.repoRoot, filepath.FromSlash(rel))); err != nil || info.IsDir() {
+				continue
+			}
+			files = append(files, rel)
+		}
+	} else {
+		source = SourceWalk
+		err = filepath.WalkDir(m.repoRoot, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p == m.repoRoot || !(d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0 || d.IsDir()) {
+				return nil
+			}
+			rel, err := filepath.Rel(m.repoRoot, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				if m.skipped(rel) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			files = append(files, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list files: %w", err)
+		}
+	}
+
+	kept := files[:0]
+	for _, rel := range files {
+		if !m.skipped(rel) {
+			kept = append(kept, rel)
+		}
+	}
+	sort.Strings(kept)
+	return kept, source, nil
+}
+
+// skipped reports whether the relative path is .git, runtime state under
+// .llm-tools, or the snapshot directory, or inside one of them
+func (m *Manager) skipped(rel string) bool {
+	dirs := []string{".git", ".llm-tools"}
+	if snapRel, err := filepath.Rel(m.repoRoot, m.dir); err == nil && !strings.HasPrefix(snapRel, "..") {
+		dirs = append(dirs, filepath.ToSlash(snapRel))
+	}
+	for _, dir := range dirs {
+		if rel == dir || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// addFile writes the file or symlink at rel to tw and returns its size
+func (m *Manager) addFile(tw *tar.Writer, rel string) (int64, error) {
+	p := filepath.Join(m.repoRoot, filepath.FromSlash(rel))
+	info, err := os.Lstat(p)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+	} else if !info.Mode().IsRegular() {
+		return 0, nil
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive %s: %w", rel, err)
+	}
+	header.Name = rel
+	if err := tw.WriteHeader(header); err != nil {
+		return 0, fmt.Errorf("failed to archive %s: %w", rel, err)
+	}
+	if link != "" {
+		return 0, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	defer f.Close()
+	n, err := io.Copy(tw, io.LimitReader(f, info.Size()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive %s: %w", rel, err)
+	}
+	if n < info.Size() {
+		return 0, fmt.Errorf("failed to archive %s: file shrank while being read", rel)
+	}
+	return n, nil
+}
+
+// extract writes the snapshot's files into the repository and returns the
+// set of paths it holds
+func (m *Manager) extract(snap Snapshot, result *RestoreResult) (map[string]bool, error) {
+	f, err := os.Open(snap.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s is corrupt: %w", snap.ID, err)
+	}
+	defer gz.Close()
+
+	root, err := filepath.EvalSymlinks(m.repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository root: %w", err)
+	}
+	kept := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s is corrupt: %w", snap.ID, err)
+		}
+		rel := path.Clean(header.Name)
+		if path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") || m.skipped(rel) {
+			return nil, fmt.Errorf("snapshot %s holds an unsafe path: %s", snap.ID, header.Name)
+		}
+		target := filepath.Join(m.repoRoot, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		// A directory replaced by a symlink since the snapshot must not
+		// carry the restore out of the repository
+		if dir, err := filepath.EvalSymlinks(filepath.Dir(target)); err != nil || (dir != root && !strings.HasPrefix(dir, root+string(filepath.Separator))) {
+			return nil, fmt.Errorf("refusing to restore %s: its directory is outside the repository", rel)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			if current, err := os.Readlink(target); err != nil || current != header.Linkname {
+				os.RemoveAll(target)
+				if err := os.Symlink(header.Linkname, target); err != nil {
+					return nil, fmt.Errorf("failed to restore %s: %w", rel, err)
+				}
+			}
+		case tar.TypeReg:
+			if err := restoreFile(target, tr, header.FileInfo().Mode().Perm()); err != nil {
+				return nil, fmt.Errorf("failed to restore %s: %w", rel, err)
+			}
+		default:
+			continue
+		}
+		kept[rel] = true
+		result.Restored++
+	}
+	return kept, nil
+}
+
+// restoreFile replaces target with the contents of r through a temporary
+// file, so a failed restore never leaves a file half written
+func restoreFile(target string, r io.Reader, mode os.FileMode) error {
+	if info, err := os.Lstat(target); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is a directory", target)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// removeEmptyParents removes dir and its parents, up to the root, while
+// they are empty
+func (m *Manager) removeEmptyParents(dir string) {
+	for {
+		rel, err := filepath.Rel(m.repoRoot, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") || os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// verify checks the snapshot's archive against the digest in its manifest
+func verify(snap Snapshot) error {
+	f, err := os.Open(snap.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+	digest := sha256.New()
+	if _, err := io.Copy(digest, f); err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if got := hex.EncodeToString(digest.Sum(nil)); got != snap.SHA256 {
+		return fmt.Errorf("snapshot %s is corrupt: sha256 is %s, manifest says %s", snap.ID, got, snap.SHA256)
+	}
+	return nil
+}
+
+// load reads the manifest of the snapshot with the given id
+func (m *Manager) load(id string) (Snapshot, error) {
+	if id != filepath.Base(id) {
+		return Snapshot{}, fmt.Errorf("invalid snapshot id: %s", id)
+	}
+	data, err := os.ReadFile(m.manifestPath(id))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid manifest for snapshot %s: %w", id, err)
+	}
+	snap.Path = m.archivePath(snap.ID)
+	return snap, nil
+}
+
+func (m *Manager) exists(id string) bool {
+	_, err := os.Stat(m.manifestPath(id))
+	return err == nil
+}
+
+func (m *Manager) archivePath(id string) string {
+	return filepath.Join(m.dir, id+".tar.gz")
+}
+
+func (m *Manager) manifestPath(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
@@ -0,0 +1,182 @@
+package snapshot
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+// newTestManager returns a manager whose clock advances one minute per call
+func newTestManager(root string) *Manager {
+	m := NewManager(root, "")
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return m
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(root, "pkg", "util", "util.go"), "package util\n")
+	writeFile(t, filepath.Join(root, ".llm-tools", "backups", "main.go.bak.1"), "old\n")
+	if err := os.Chmod(filepath.Join(root, "main.go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestManager(root)
+	snap, err := m.Create("before agent run")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if snap.ID != "20240101-000100" || snap.Files != 2 || snap.Bytes != 26 || snap.Message != "before agent run" || len(snap.SHA256) != 64 {
+		t.Errorf("Create() = %+v", snap)
+	}
+
+	// An agent run edits one file, deletes another, and adds a third
+	writeFile(t, filepath.Join(root, "main.go"), "package broken\n")
+	if err := os.Remove(filepath.Join(root, "pkg", "util", "util.go")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "scratch", "notes.txt"), "temp\n")
+
+	result, err := m.Restore("")
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if result.Snapshot.ID != snap.ID || result.Restored != 2 || strings.Join(result.Removed, ",") != "scratch/notes.txt" {
+		t.Errorf("Restore() = %+v", result)
+	}
+	if got := readFile(t, filepath.Join(root, "main.go")); got != "package main\n" {
+		t.Errorf("main.go = %q", got)
+	}
+	if info, err := os.Stat(filepath.Join(root, "main.go")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("main.go mode = %v, %v; want 0755", info, err)
+	}
+	if got := readFile(t, filepath.Join(root, "pkg", "util", "util.go")); got != "package util\n" {
+		t.Errorf("util.go = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "scratch")); !os.IsNotExist(err) {
+		t.Errorf("scratch/ should be removed, stat error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(root, ".llm-tools", "backups", "main.go.bak.1")); got != "old\n" {
+		t.Errorf("backup touched by restore: %q", got)
+	}
+
+	// The tree before the restore was kept, so the restore can be undone
+	if _, err := m.Restore(result.Safety.ID); err != nil {
+		t.Fatalf("undoing restore: %v", err)
+	}
+	if got := readFile(t, filepath.Join(root, "scratch", "notes.txt")); got != "temp\n" {
+		t.Errorf("scratch/notes.txt after undo = %q", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "a\n")
+
+	m := newTestManager(root)
+	if snaps, err := m.List(); err != nil || len(snaps) != 0 {
+		t.Fatalf("List() before any snapshot = %v, %v", snaps, err)
+	}
+	for _, message := range []string{"first", "second"} {
+		if _, err := m.Create(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snaps, err := m.List()
+	if err != nil || len(snaps) != 2 {
+		t.Fatalf("List() = %v, %v; want 2 snapshots", snaps, err)
+	}
+	if snaps[0].Message != "second" || snaps[1].Message != "first" {
+		t.Errorf("List() order = %s, %s; want newest first", snaps[0].Message, snaps[1].Message)
+	}
+	if _, err := m.Get("missing"); err == nil {
+		t.Error("Get() of an unknown id should fail")
+	}
+	if _, err := m.Get("../a"); err == nil {
+		t.Error("Get() of a path should fail")
+	}
+}
+
+func TestRestore_Corrupt(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "a\n")
+
+	m := newTestManager(root)
+	snap, err := m.Create("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(snap.Path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("garbage")
+	f.Close()
+	writeFile(t, filepath.Join(root, "a.txt"), "changed\n")
+
+	if _, err := m.Restore(snap.ID); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("Restore() error = %v, want corrupt", err)
+	}
+	if got := readFile(t, filepath.Join(root, "a.txt")); got != "changed\n" {
+		t.Errorf("a.txt = %q, a failed restore should change nothing", got)
+	}
+}
+
+func TestCreate_GitIgnored(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeFile(t, filepath.Join(root, ".gitignore"), "build/\n")
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(root, "build", "app"), "binary\n")
+
+	m := newTestManager(root)
+	snap, err := m.Create("")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if snap.Source != SourceGit || snap.Files != 2 {
+		t.Errorf("Create() = %+v, want .gitignore and main.go from git", snap)
+	}
+
+	// Ignored files are neither restored nor removed
+	writeFile(t, filepath.Join(root, "build", "app"), "rebuilt\n")
+	if _, err := m.Restore(snap.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(root, "build", "app")); got != "rebuilt\n" {
+		t.Errorf("build/app = %q, want it left alone", got)
+	}
+}
@@ -0,0 +1,38 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// CorrelationAttribute is the span attribute holding a command's
+// correlation ID
+const CorrelationAttribute = "correlation.id"
+
+type correlationKey struct{}
+
+// NewCorrelationID returns a random ID for one command, which its audit
+// entries, spans, and containers all carry
+func NewCorrelationID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID id
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID in ctx, or "" if it has none
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
@@ -0,0 +1,71 @@
+package toolapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Request is one line of the wire protocol: a command, in the JSON form
+// transcripts record commands, and an ID echoed in its response
+type Request struct {
+	ID string `json:"id,omitempty"`
+	scanner.Command
+}
+
+// Response answers one request. Error is set instead of Result when the
+// request could not be run at all, such as a line that is not JSON.
+type Response struct {
+	ID     string  `json:"id,omitempty"`
+	Result *Result `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// Serve reads requests from r, one JSON object per line, runs each in
+// turn, and writes one response line to w per request. It returns nil at
+// the end of r, or ctx's error once ctx ends.
+func (r *Runtime) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	reader := bufio.NewScanner(in)
+	reader.Buffer(make([]byte, 0, 64*1024), config.DefaultScanBufferSize)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	for reader.Scan() {
+		line := reader.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp Response
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else if req.Type == "" {
+			resp.ID = req.ID
+			resp.Error = "invalid request: missing command type"
+		} else {
+			resp.ID = req.ID
+			result, err := r.Run(ctx, req.Command)
+			if err != nil && result.Command.Type == "" {
+				return err
+			}
+			resp.Result = &result
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("cannot write response: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return fmt.Errorf("cannot read requests: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// Package toolapi embeds the runtime as a library. Each command is a
+// method taking a context, so Go agent frameworks can call the runtime
+// directly instead of shelling out to the binary and parsing its output.
+// Serve speaks the same commands as JSON lines, for frameworks in other
+// languages; docs/toolapi.md describes the protocol.
+package toolapi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+	"github.com/computerscienceiscool/llm-runtime/pkg/evaluator"
+	"github.com/computerscienceiscool/llm-runtime/pkg/sandbox"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+	"github.com/computerscienceiscool/llm-runtime/pkg/session"
+	"github.com/computerscienceiscool/llm-runtime/pkg/transcript"
+)
+
+// Result is the outcome of a command, in the form transcripts record it.
+// A command that failed, such as an open of a missing file, is a result
+// with Success false and Error and ErrorCode set, not a Go error.
+type Result = transcript.Result
+
+// Runtime runs commands against one repository as a single session:
+// variables, repl sessions, and write conflict tracking carry over from
+// one call to the next, and every call is audited. It is safe for
+// concurrent use, but runs one command at a time.
+type Runtime struct {
+	mu       sync.Mutex
+	session  *session.Session
+	executor *evaluator.Executor
+	pool     *sandbox.ContainerPool
+}
+
+// New starts a session for cfg. Close the runtime when done with it to
+// stop its containers and flush the audit log.
+func New(cfg *config.Config) (*Runtime, error) {
+	absRoot, err := filepath.Abs(cfg.RepositoryRoot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve repository root: %w", err)
+	}
+	if _, err := os.Stat(absRoot); err != nil {
+		return nil, fmt.Errorf("repository root does not exist: %w", err)
+	}
+	cfg.RepositoryRoot = absRoot
+	if cfg.Deterministic {
+		sandbox.SetFakeBackend(&sandbox.FakeBackend{})
+		cfg.ContainerPool.Enabled = false
+	}
+
+	var pool *sandbox.ContainerPool
+	if cfg.ContainerPool.Enabled {
+		pool, err = sandbox.NewContainerPool(context.Background(), sandbox.PoolConfig{
+			Size:                cfg.ContainerPool.Size,
+			MaxUsesPerContainer: cfg.ContainerPool.MaxUsesPerContainer,
+			IdleTimeout:         cfg.ContainerPool.IdleTimeout,
+			HealthCheckInterval: cfg.ContainerPool.HealthCheckInterval,
+			StartupContainers:   cfg.ContainerPool.StartupContainers,
+			Image:               cfg.IOContainerImage,
+			MemoryLimit:         cfg.IOMemoryLimit,
+			CPULimit:            cfg.IOCPULimit,
+			RepoRoot:            cfg.RepositoryRoot,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create container pool: %w", err)
+		}
+	}
+
+	sess := session.NewSession(cfg)
+	sandbox.SetSessionID(sess.ID)
+	exec := evaluator.NewExecutor(cfg, config.LoadSearchConfig(), sess.LogAudit, pool)
+	exec.SetCorrelatedAuditLog(sess.LogCommandAudit)
+	exec.SetSessionID(sess.ID)
+
+	return &Runtime{session: sess, executor: exec, pool: pool}, nil
+}
+
+// Close stops the runtime's containers and closes its audit log
+func (r *Runtime) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.session.Close()
+	r.executor.Close()
+	if r.pool != nil {
+		if poolErr := r.pool.Close(); err == nil {
+			err = poolErr
+		}
+	}
+	return err
+}
+
+// Run executes cmd, which may be any command the scanner parses, including
+// pipes, guard blocks, and plugin commands. It returns an error only when
+// ctx ended, before or while the command ran; the result then holds
+// whatever the command finished.
+func (r *Runtime) Run(ctx context.Context, cmd scanner.Command) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executor.SetContext(ctx)
+	result := r.executor.Execute(cmd)
+	r.executor.SetContext(context.Background())
+
+	return transcript.NewResult(result), ctx.Err()
+}
+
+// run executes the command of cmdType with the tag argument made of the
+// non-empty args
+func (r *Runtime) run(ctx context.Context, cmdType, content string, args ...string) (Result, error) {
+	var parts []string
+	for _, arg := range args {
+		if arg != "" {
+			parts = append(parts, arg)
+		}
+	}
+	return r.Run(ctx, scanner.Command{Type: cmdType, Argument: strings.Join(parts, " "), Content: content})
+}
+
+// option formats a key=value tag option, or "" when value is zero
+func option(key string, value int) string {
+	if value == 0 {
+		return ""
+	}
+	return key + "=" + strconv.Itoa(value)
+}
+
+// Open reads a file, optionally with a line range such as main.go:10-40
+func (r *Runtime) Open(ctx context.Context, path string) (Result, error) {
+	return r.run(ctx, "open", "", path)
+}
+
+// OpenMany reads the files matching a glob pattern or under a directory,
+// at most maxFiles of them, or the configured limit when maxFiles is 0
+func (r *Runtime) OpenMany(ctx context.Context, pattern string, maxFiles int) (Result, error) {
+	return r.run(ctx, "open-many", "", pattern, option("max_files", maxFiles))
+}
+
+// Write creates or replaces a file with content
+func (r *Runtime) Write(ctx context.Context, path, content string) (Result, error) {
+	return r.run(ctx, "write", content, path)
+}
+
+// Append adds content to the end of a file, creating it if needed
+func (r *Runtime) Append(ctx context.Context, path, content string) (Result, error) {
+	return r.run(ctx, "append", content, path)
+}
+
+// Exec runs a whitelisted command line in the exec container
+func (r *Runtime) Exec(ctx context.Context, command string) (Result, error) {
+	return r.run(ctx, "exec", "", command)
+}
+
+// Search finds files related to query by semantic search
+func (r *Runtime) Search(ctx context.Context, query string) (Result, error) {
+	return r.run(ctx, "search", "", query)
+}
+
+// Set defines a variable that later arguments refer to as ${name}
+func (r *Runtime) Set(ctx context.Context, name, value string) (Result, error) {
+	return r.run(ctx, "set", "", "name="+name, "value="+value)
+}
+
+// Tail reads the last lines of a file, or the default number when lines
+// is 0
+func (r *Runtime) Tail(ctx context.Context, path string, lines int) (Result, error) {
+	return r.run(ctx, "tail", "", path, option("lines", lines))
+}
+
+// Hash returns the checksum of a file
+func (r *Runtime) Hash(ctx context.Context, path string) (Result, error) {
+	return r.run(ctx, "hash", "", path)
+}
+
+// Outline lists the declarations of a source file
+func (r *Runtime) Outline(ctx context.Context, path string) (Result, error) {
+	return r.run(ctx, "outline", "", path)
+}
+
+// Overview summarizes the repository layout to depth directories, or the
+// default depth when depth is 0
+func (r *Runtime) Overview(ctx context.Context, depth int) (Result, error) {
+	return r.run(ctx, "overview", "", option("depth", depth))
+}
+
+// Deps lists the dependencies of a Go package, or of the module when pkg
+// is empty
+func (r *Runtime) Deps(ctx context.Context, pkg string) (Result, error) {
+	return r.run(ctx, "deps", "", pkg)
+}
+
+// Def finds the definition of symbol, a name such as Session.LogAudit or a
+// position such as pkg/app/app.go:120:5
+func (r *Runtime) Def(ctx context.Context, symbol string) (Result, error) {
+	return r.run(ctx, "def", "", symbol)
+}
+
+// Refs finds the references to symbol, a name or a position as for Def
+func (r *Runtime) Refs(ctx context.Context, symbol string) (Result, error) {
+	return r.run(ctx, "refs", "", symbol)
+}
+
+// RenameSymbol renames old, a name or a position as for Def, to newName in
+// every file that uses it. Scope, such as ./pkg/..., limits the files the
+// rename may change; empty for the whole repository.
+func (r *Runtime) RenameSymbol(ctx context.Context, old, newName, scope string) (Result, error) {
+	if scope != "" {
+		scope = "scope=" + scope
+	}
+	return r.run(ctx, "rename-symbol", "", "old="+old, "new="+newName, scope)
+}
+
+// ASTGrep finds the Go code matching pattern, such as RunContainer($CFG),
+// under path, or in the whole repository when path is empty
+func (r *Runtime) ASTGrep(ctx context.Context, pattern, path string) (Result, error) {
+	if path != "" {
+		path = "path=" + path
+	}
+	return r.run(ctx, "ast-grep", "", pattern, path)
+}
+
+// Tokens estimates the tokens of path, which may be a file, a line range
+// such as main.go:10-20, or a directory; the whole repository when empty
+func (r *Runtime) Tokens(ctx context.Context, path string) (Result, error) {
+	return r.run(ctx, "tokens", "", path)
+}
+
+// Note records text in the session memory document
+func (r *Runtime) Note(ctx context.Context, text string) (Result, error) {
+	return r.run(ctx, "note", "", text)
+}
+
+// Memory reads the session memory document
+func (r *Runtime) Memory(ctx context.Context) (Result, error) {
+	return r.run(ctx, "memory", "")
+}
+
+// Test runs the tests of target, or of the whole repository when target is
+// empty, passing args to the test runner
+func (r *Runtime) Test(ctx context.Context, target string, args ...string) (Result, error) {
+	return r.run(ctx, "test", "", append([]string{target}, args...)...)
+}
+
+// Lint runs the linter on path, or on the whole repository when path is
+// empty
+func (r *Runtime) Lint(ctx context.Context, path string) (Result, error) {
+	return r.run(ctx, "lint", "", path)
+}
+
+// Coverage summarizes a coverage profile, or the default one when profile
+// is empty
+func (r *Runtime) Coverage(ctx context.Context, profile string) (Result, error) {
+	return r.run(ctx, "coverage", "", profile)
+}
+
+// Unzip extracts an archive into dest
+func (r *Runtime) Unzip(ctx context.Context, archive, dest string) (Result, error) {
+	return r.run(ctx, "unzip", "", archive, dest)
+}
+
+// Archive packs source, a file or directory, into the archive dest
+func (r *Runtime) Archive(ctx context.Context, dest, source string) (Result, error) {
+	return r.run(ctx, "archive", "", dest, source)
+}
+
+// Fetch downloads url, saving it to dest when dest is not empty
+func (r *Runtime) Fetch(ctx context.Context, url, dest string) (Result, error) {
+	if dest != "" {
+		dest = "dest=" + dest
+	}
+	return r.run(ctx, "fetch", "", url, dest)
+}
+
+// SQL runs statement against the configured database called name
+func (r *Runtime) SQL(ctx context.Context, name, statement string) (Result, error) {
+	return r.run(ctx, "sql", "", "name="+name, statement)
+}
+
+// GitBranch creates the branch name at HEAD and switches to it
+func (r *Runtime) GitBranch(ctx context.Context, name string) (Result, error) {
+	return r.run(ctx, "git-branch", "", name)
+}
+
+// GitCommit commits the session's changes to the current branch with
+// message
+func (r *Runtime) GitCommit(ctx context.Context, message string) (Result, error) {
+	return r.run(ctx, "git-commit", "", message)
+}
+
+// PR pushes the current branch and opens a pull request of it with title
+// and body
+func (r *Runtime) PR(ctx context.Context, title, body string) (Result, error) {
+	return r.run(ctx, "pr", body, title)
+}
+
+// Issue returns the issue numbered number with its most recent comments
+func (r *Runtime) Issue(ctx context.Context, number int) (Result, error) {
+	return r.run(ctx, "issue", "", strconv.Itoa(number))
+}
+
+// REPL runs code in the language's scratch session, which keeps its state
+// between calls
+func (r *Runtime) REPL(ctx context.Context, language, code string) (Result, error) {
+	return r.run(ctx, "repl", code, language)
+}
+
+// REPLReset stops the language's scratch session, or every one when
+// language is empty
+func (r *Runtime) REPLReset(ctx context.Context, language string) (Result, error) {
+	return r.run(ctx, "repl-reset", "", language)
+}
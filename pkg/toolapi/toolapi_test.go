@@ -0,0 +1,107 @@
+package toolapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/config"
+)
+
+func newTestRuntime(t *testing.T) (*Runtime, string) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "config.json"), []byte(`{"port": 8080}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(&config.Config{
+		RepositoryRoot: root,
+		MaxFileSize:    1024 * 1024,
+		ExcludedPaths:  []string{".git", "*.key"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r, root
+}
+
+func TestRuntime_Commands(t *testing.T) {
+	r, _ := newTestRuntime(t)
+	ctx := context.Background()
+
+	result, err := r.Set(ctx, "file", "config.json")
+	if err != nil || !result.Success {
+		t.Fatalf("Set() = %+v, %v", result, err)
+	}
+
+	// Variables carry over from one call to the next
+	result, err = r.Hash(ctx, "${file}")
+	if err != nil || !result.Success || !strings.Contains(result.Output, "config.json") {
+		t.Fatalf("Hash() = %+v, %v", result, err)
+	}
+	if result.Command.Type != "hash" || result.Command.Argument != "config.json" || result.CorrelationID == "" {
+		t.Errorf("Hash() command = %+v, correlation ID %q", result.Command, result.CorrelationID)
+	}
+
+	// A failed command is a result, not an error
+	result, err = r.Hash(ctx, "missing.json")
+	if err != nil || result.Success || result.ErrorCode == "" {
+		t.Errorf("Hash() of a missing file = %+v, %v", result, err)
+	}
+}
+
+func TestRuntime_Canceled(t *testing.T) {
+	r, _ := newTestRuntime(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := r.Hash(ctx, "config.json"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Hash() with a canceled context error = %v", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	r, _ := newTestRuntime(t)
+	in := strings.Join([]string{
+		`{"id": "1", "type": "hash", "argument": "config.json"}`,
+		``,
+		`not json`,
+		`{"id": "3", "argument": "config.json"}`,
+		`{"id": "4", "type": "rm", "argument": "config.json"}`,
+	}, "\n")
+
+	var out strings.Builder
+	if err := r.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var responses []Response
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var resp Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("response %q is not JSON: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("got %d responses, want 4:\n%s", len(responses), out.String())
+	}
+
+	if resp := responses[0]; resp.ID != "1" || resp.Result == nil || !resp.Result.Success {
+		t.Errorf("hash response = %+v", resp)
+	}
+	if resp := responses[1]; resp.Result != nil || !strings.HasPrefix(resp.Error, "invalid request") {
+		t.Errorf("response to a line that is not JSON = %+v", resp)
+	}
+	if resp := responses[2]; resp.ID != "3" || !strings.Contains(resp.Error, "missing command type") {
+		t.Errorf("response to a request without a type = %+v", resp)
+	}
+	if resp := responses[3]; resp.ID != "4" || resp.Result == nil || resp.Result.Success || resp.Result.ErrorCode != "UNKNOWN_COMMAND" {
+		t.Errorf("response to an unknown command = %+v", resp)
+	}
+}
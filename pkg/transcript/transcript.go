@@ -0,0 +1,210 @@
+// Package transcript records sessions as JSON lines: every chunk of input,
+// every command parsed from it, and every result, in order. A transcript
+// can be replayed against a fresh run to reproduce a session or to check
+// that its results have not changed.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Entry types
+const (
+	TypeStart   = "start"   // Session settings needed to replay it
+	TypeInput   = "input"   // A chunk of input as it was read
+	TypeCommand = "command" // A command as parsed, before template expansion
+	TypeResult  = "result"  // The result of a command
+)
+
+// Entry is one line of a transcript
+type Entry struct {
+	Seq         int              `json:"seq"`
+	Time        time.Time        `json:"time"`
+	Type        string           `json:"type"`
+	Interactive bool             `json:"interactive,omitempty"` // Start: meta-commands were recognized
+	Repository  string           `json:"repository,omitempty"`  // Start: repository root
+	Input       string           `json:"input,omitempty"`
+	Command     *scanner.Command `json:"command,omitempty"`
+	Result      *Result          `json:"result,omitempty"`
+}
+
+// Result is the recorded outcome of a command
+type Result struct {
+	Command       scanner.Command `json:"command"` // As executed, after template expansion
+	Success       bool            `json:"success"`
+	Action        string          `json:"action,omitempty"`
+	Output        string          `json:"output,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	ErrorCode     string          `json:"error_code,omitempty"`
+	ExitCode      int             `json:"exit_code,omitempty"`
+	DurationMS    int64           `json:"duration_ms"`
+	CorrelationID string          `json:"correlation_id,omitempty"` // Matches the command's audit entries and spans; not compared on replay
+	Steps         []Result        `json:"steps,omitempty"`
+}
+
+// NewResult converts an execution result for recording
+func NewResult(result scanner.ExecutionResult) Result {
+	r := Result{
+		Command:       result.Command,
+		Success:       result.Success,
+		Action:        result.Action,
+		Output:        result.Result,
+		ExitCode:      result.ExitCode,
+		DurationMS:    result.ExecutionTime.Milliseconds(),
+		CorrelationID: result.CorrelationID,
+	}
+	if result.Error != nil {
+		r.Error = result.Error.Error()
+		r.ErrorCode = string(errors.CodeOf(result.Error))
+	}
+	for _, step := range result.Steps {
+		r.Steps = append(r.Steps, NewResult(step))
+	}
+	return r
+}
+
+// Recorder appends entries to a transcript. It is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+	seq    int
+	err    error
+	now    func() time.Time
+}
+
+// NewRecorder writes a transcript to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: bufio.NewWriter(w), now: time.Now}
+}
+
+// Create starts a transcript in a new file at path
+func Create(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create transcript: %w", err)
+	}
+	r := NewRecorder(file)
+	r.closer = file
+	return r, nil
+}
+
+// Start records the session settings; it should be the first entry
+func (r *Recorder) Start(repository string, interactive bool) {
+	r.write(Entry{Type: TypeStart, Repository: repository, Interactive: interactive})
+}
+
+// Input records a chunk of input
+func (r *Recorder) Input(chunk string) {
+	if chunk != "" {
+		r.write(Entry{Type: TypeInput, Input: chunk})
+	}
+}
+
+// Command records a parsed command
+func (r *Recorder) Command(cmd scanner.Command) {
+	r.write(Entry{Type: TypeCommand, Command: &cmd})
+}
+
+// Result records the result of a command
+func (r *Recorder) Result(result scanner.ExecutionResult) {
+	recorded := NewResult(result)
+	r.write(Entry{Type: TypeResult, Result: &recorded})
+}
+
+// Reader returns a reader that records everything read from in as input
+func (r *Recorder) Reader(in io.Reader) io.Reader {
+	return &inputReader{in: in, r: r}
+}
+
+type inputReader struct {
+	in io.Reader
+	r  *Recorder
+}
+
+func (ir *inputReader) Read(p []byte) (int, error) {
+	n, err := ir.in.Read(p)
+	ir.r.Input(string(p[:n]))
+	return n, err
+}
+
+// write appends an entry, flushing it so the transcript survives a crash.
+// The first error is kept and reported by Close.
+func (r *Recorder) write(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+
+	r.seq++
+	entry.Seq = r.seq
+	entry.Time = r.now()
+	line, err := json.Marshal(entry)
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	if err == nil {
+		err = r.w.Flush()
+	}
+	r.err = err
+}
+
+// Close finishes the transcript and returns the first error writing it
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.err
+	if flushErr := r.w.Flush(); err == nil {
+		err = flushErr
+	}
+	if r.closer != nil {
+		if closeErr := r.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("transcript write failed: %w", err)
+	}
+	return nil
+}
+
+// Read parses a transcript
+func Read(in io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 256*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("transcript line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read transcript: %w", err)
+	}
+	return entries, nil
+}
+
+// ReadFile parses the transcript at path
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open transcript: %w", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
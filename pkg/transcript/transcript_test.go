@@ -0,0 +1,85 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/internal/errors"
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf)
+	r.Start("/repo", true)
+
+	input, err := io.ReadAll(r.Reader(strings.NewReader("<open main.go>\n")))
+	if err != nil || string(input) != "<open main.go>\n" {
+		t.Fatalf("Reader passed through %q, %v", input, err)
+	}
+	r.Command(scanner.Command{Type: "open", Argument: "main.go"})
+	r.Result(scanner.ExecutionResult{
+		Command:       scanner.Command{Type: "pipe"},
+		Error:         errors.New(errors.PipeFailed, "step 1 (exec) failed"),
+		ExecutionTime: 1500 * time.Millisecond,
+		Steps:         []scanner.ExecutionResult{{Command: scanner.Command{Type: "exec", Argument: "go test"}, ExitCode: 1, Result: "FAIL"}},
+	})
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	entries, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	types := make([]string, len(entries))
+	for i, entry := range entries {
+		types[i] = entry.Type
+		if entry.Seq != i+1 {
+			t.Errorf("entry %d has seq %d", i, entry.Seq)
+		}
+	}
+	if got := strings.Join(types, ","); got != "start,input,command,result" {
+		t.Fatalf("entry types = %s", got)
+	}
+
+	if !entries[0].Interactive || entries[0].Repository != "/repo" {
+		t.Errorf("start = %+v", entries[0])
+	}
+	if entries[1].Input != "<open main.go>\n" || entries[2].Command.Argument != "main.go" {
+		t.Errorf("input and command = %+v, %+v", entries[1], entries[2].Command)
+	}
+	result := entries[3].Result
+	if result.Error != "PIPE_FAILED: step 1 (exec) failed" || result.ErrorCode != "PIPE_FAILED" || result.DurationMS != 1500 {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Steps) != 1 || result.Steps[0].ExitCode != 1 || result.Steps[0].Output != "FAIL" {
+		t.Errorf("steps = %+v", result.Steps)
+	}
+}
+
+func TestRead_InvalidLine(t *testing.T) {
+	_, err := Read(strings.NewReader(`{"seq":1,"type":"start"}` + "\n\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Read() error = %v, want one naming line 3", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+func TestRecorder_WriteError(t *testing.T) {
+	r := NewRecorder(failingWriter{})
+	r.Input("first")
+	r.Input("second")
+	if err := r.Close(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Close() error = %v, want the write error", err)
+	}
+}
@@ -0,0 +1,352 @@
+// Package tui implements the full-screen dashboard for operators supervising
+// long agent runs. The dashboard follows the model/update/view design: all
+// events arrive as messages, Update applies them to the Model, and View
+// renders the Model as a screen of text.
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// Msg is an event that changes the dashboard
+type Msg interface{}
+
+// CommandStarted reports that a command began executing
+type CommandStarted struct {
+	Command scanner.Command
+}
+
+// CommandFinished reports the result of a command
+type CommandFinished struct {
+	Result scanner.ExecutionResult
+}
+
+// ApprovalRequested queues a command for the operator to approve or deny.
+// The decision is sent on Reply, which must be buffered.
+type ApprovalRequested struct {
+	Command scanner.Command
+	Reply   chan<- bool
+}
+
+// ContainersLaunched reports the number of containers started this session
+type ContainersLaunched struct {
+	Count int64
+}
+
+// InputClosed reports that the agent's input has ended
+type InputClosed struct{}
+
+// keyMsg is a key pressed by the operator, such as "y" or "up"
+type keyMsg struct {
+	key string
+}
+
+// tickMsg redraws elapsed times and picks up terminal resizes
+type tickMsg struct {
+	now           time.Time
+	width, height int
+}
+
+// Stats are the session totals shown in the header
+type Stats struct {
+	CommandsRun        int
+	Failed             int
+	BytesWritten       int64
+	ContainersLaunched int64
+}
+
+// logEntry is a finished command in the command log
+type logEntry struct {
+	at       time.Time
+	command  scanner.Command
+	status   string
+	duration time.Duration
+}
+
+// maxLogEntries bounds the command log kept for scrolling
+const maxLogEntries = 1000
+
+// Model is the state of the dashboard
+type Model struct {
+	log          []logEntry
+	running      *scanner.Command
+	runningSince time.Time
+	execTitle    string
+	execOutput   string
+	pending      []ApprovalRequested
+	stats        Stats
+	started      time.Time
+	now          time.Time
+	width        int
+	height       int
+	scroll       int // Log lines scrolled back from the newest entry
+	inputDone    bool
+	quit         bool
+}
+
+// NewModel returns an empty dashboard of the given size
+func NewModel(width, height int, now time.Time) *Model {
+	return &Model{width: width, height: height, started: now, now: now}
+}
+
+// Stats returns the session totals
+func (m *Model) Stats() Stats {
+	return m.stats
+}
+
+// Quit reports whether the operator asked to leave the dashboard
+func (m *Model) Quit() bool {
+	return m.quit
+}
+
+// Update applies a message to the model
+func (m *Model) Update(msg Msg) {
+	switch msg := msg.(type) {
+	case CommandStarted:
+		cmd := msg.Command
+		m.running = &cmd
+		m.runningSince = m.now
+		if cmd.Type == "exec" {
+			m.execTitle = cmd.Argument
+			m.execOutput = ""
+		}
+
+	case CommandFinished:
+		m.finish(msg.Result)
+
+	case ApprovalRequested:
+		m.pending = append(m.pending, msg)
+
+	case ContainersLaunched:
+		m.stats.ContainersLaunched = msg.Count
+
+	case InputClosed:
+		m.inputDone = true
+
+	case tickMsg:
+		m.now = msg.now
+		if msg.width > 0 && msg.height > 0 {
+			m.width, m.height = msg.width, msg.height
+		}
+
+	case keyMsg:
+		m.handleKey(msg.key)
+	}
+}
+
+// finish records a command result in the log, stats, and exec pane
+func (m *Model) finish(result scanner.ExecutionResult) {
+	m.running = nil
+
+	status := "ok"
+	switch {
+	case result.Action == "SKIPPED":
+		status = "skip"
+	case !result.Success:
+		status = "FAIL"
+		m.stats.Failed++
+	}
+	if status != "skip" {
+		m.stats.CommandsRun++
+	}
+	m.stats.BytesWritten += bytesWritten(result)
+
+	m.log = append(m.log, logEntry{at: m.now, command: result.Command, status: status, duration: result.ExecutionTime})
+	if len(m.log) > maxLogEntries {
+		m.log = m.log[len(m.log)-maxLogEntries:]
+	}
+
+	if result.Command.Type == "exec" {
+		m.execTitle = fmt.Sprintf("%s (exit %d, %.2fs)", result.Command.Argument, result.ExitCode, result.ExecutionTime.Seconds())
+		m.execOutput = result.Stdout + result.Stderr
+		if m.execOutput == "" {
+			m.execOutput = result.Result
+		}
+	}
+}
+
+// bytesWritten totals the bytes written by a result and its steps
+func bytesWritten(result scanner.ExecutionResult) int64 {
+	total := result.BytesWritten
+	for _, step := range result.Steps {
+		total += bytesWritten(step)
+	}
+	return total
+}
+
+// handleKey applies an operator key press
+func (m *Model) handleKey(key string) {
+	switch key {
+	case "y", "n":
+		if len(m.pending) == 0 {
+			return
+		}
+		m.pending[0].Reply <- key == "y"
+		m.pending = m.pending[1:]
+	case "up", "k":
+		if m.scroll < len(m.log)-1 {
+			m.scroll++
+		}
+	case "down", "j":
+		if m.scroll > 0 {
+			m.scroll--
+		}
+	case "q", "ctrl+c":
+		// Deny everything still waiting so the session can wind down
+		for _, p := range m.pending {
+			p.Reply <- false
+		}
+		m.pending = nil
+		m.quit = true
+	}
+}
+
+// View renders the dashboard as width x height lines of text
+func (m *Model) View() string {
+	if m.width <= 0 || m.height <= 0 {
+		return ""
+	}
+
+	var lines []string
+	lines = append(lines, m.header())
+
+	approvalRows := 0
+	if len(m.pending) > 0 {
+		approvalRows = min(len(m.pending), 5) + 1
+	}
+	// Header, footer, and two section titles
+	body := m.height - 4 - approvalRows
+	logRows := max(body*3/5, 1)
+	execRows := max(body-logRows, 1)
+
+	lines = append(lines, m.title("Command log"))
+	lines = append(lines, m.logLines(logRows)...)
+	lines = append(lines, m.title("Exec output: "+m.execTitle))
+	lines = append(lines, lastLines(m.execOutput, execRows)...)
+
+	if approvalRows > 0 {
+		lines = append(lines, m.title(fmt.Sprintf("Pending approval (%d)", len(m.pending))))
+		for i, p := range m.pending[:approvalRows-1] {
+			marker := "  "
+			if i == 0 {
+				marker = "> "
+			}
+			lines = append(lines, marker+describe(p.Command))
+		}
+	}
+
+	footer := "y approve · n deny · j/k scroll · q quit"
+	if m.inputDone {
+		footer = "input finished · " + footer
+	}
+
+	// Pad or trim to fill the screen exactly, keeping the footer last
+	for len(lines) < m.height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:m.height-1], footer)
+
+	for i, line := range lines {
+		lines[i] = fit(line, m.width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// header summarizes the session on one line
+func (m *Model) header() string {
+	elapsed := m.now.Sub(m.started).Round(time.Second)
+	parts := []string{
+		"llm-runtime",
+		fmt.Sprintf("%d commands (%d failed)", m.stats.CommandsRun, m.stats.Failed),
+		formatBytes(m.stats.BytesWritten) + " written",
+		fmt.Sprintf("%d containers", m.stats.ContainersLaunched),
+		elapsed.String(),
+	}
+	if m.running != nil {
+		parts = append(parts, fmt.Sprintf("running %s for %s", describe(*m.running), m.now.Sub(m.runningSince).Round(time.Second)))
+	}
+	return strings.Join(parts, " │ ")
+}
+
+// title renders a section divider
+func (m *Model) title(name string) string {
+	return "── " + name + " " + strings.Repeat("─", max(m.width-len([]rune(name))-4, 0))
+}
+
+// logLines renders the newest rows of the command log, shifted back by the
+// scroll offset
+func (m *Model) logLines(rows int) []string {
+	end := len(m.log) - m.scroll
+	start := max(end-rows, 0)
+
+	lines := make([]string, 0, rows)
+	for _, entry := range m.log[start:end] {
+		lines = append(lines, fmt.Sprintf("%s  %-4s  %s  %.2fs",
+			entry.at.Format("15:04:05"), entry.status, describe(entry.command), entry.duration.Seconds()))
+	}
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// describe renders a command as the agent wrote it, with the size of any
+// content
+func describe(cmd scanner.Command) string {
+	s := fmt.Sprintf("<%s %s>", cmd.Type, cmd.Argument)
+	if cmd.Content != "" {
+		s += fmt.Sprintf(" (%s)", formatBytes(int64(len(cmd.Content))))
+	}
+	if len(cmd.Steps) > 0 {
+		s += fmt.Sprintf(" (%d steps)", len(cmd.Steps))
+	}
+	return s
+}
+
+// lastLines returns the final rows lines of text, padded to rows
+func lastLines(text string, rows int) []string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+	if len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// fit trims a line to width columns, expanding tabs and dropping control
+// characters that would disturb the screen
+func fit(line string, width int) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range strings.ReplaceAll(line, "\t", "    ") {
+		if r < ' ' || r == 0x7f {
+			continue
+		}
+		if n == width {
+			break
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return b.String()
+}
+
+// formatBytes renders a byte count for the header
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+func TestModel_CommandLifecycle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewModel(120, 20, now)
+
+	exec := scanner.Command{Type: "exec", Argument: "go test ./..."}
+	m.Update(CommandStarted{Command: exec})
+	if !strings.Contains(m.View(), "running <exec go test ./...>") {
+		t.Errorf("header does not show running command:\n%s", m.View())
+	}
+
+	m.Update(CommandFinished{Result: scanner.ExecutionResult{
+		Command:       exec,
+		Success:       true,
+		Stdout:        "ok  \tpkg/app\t0.01s\n",
+		ExecutionTime: 1500 * time.Millisecond,
+	}})
+	m.Update(CommandFinished{Result: scanner.ExecutionResult{
+		Command:      scanner.Command{Type: "write", Argument: "main.go", Content: "package main"},
+		Success:      true,
+		BytesWritten: 2048,
+	}})
+	m.Update(CommandFinished{Result: scanner.ExecutionResult{
+		Command: scanner.Command{Type: "open", Argument: "missing.go"},
+		Error:   errors.New("FILE_NOT_FOUND"),
+	}})
+	m.Update(ContainersLaunched{Count: 3})
+
+	stats := m.Stats()
+	if stats.CommandsRun != 3 || stats.Failed != 1 || stats.BytesWritten != 2048 || stats.ContainersLaunched != 3 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	view := m.View()
+	for _, want := range []string{
+		"3 commands (1 failed) │ 2.0 KB written │ 3 containers",
+		"ok    <exec go test ./...>  1.50s",
+		"FAIL  <open missing.go>",
+		"── Exec output: go test ./... (exit 0, 1.50s)",
+		"ok      pkg/app    0.01s",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "running") {
+		t.Errorf("header still shows a running command:\n%s", view)
+	}
+}
+
+func TestModel_Approvals(t *testing.T) {
+	m := NewModel(80, 20, time.Now())
+	first, second := make(chan bool, 1), make(chan bool, 1)
+	m.Update(ApprovalRequested{Command: scanner.Command{Type: "write", Argument: "a.go"}, Reply: first})
+	m.Update(ApprovalRequested{Command: scanner.Command{Type: "write", Argument: "b.go"}, Reply: second})
+
+	view := m.View()
+	if !strings.Contains(view, "Pending approval (2)") || !strings.Contains(view, "> <write a.go>") {
+		t.Errorf("approval queue not shown:\n%s", view)
+	}
+
+	m.Update(keyMsg{key: "y"})
+	if approved := <-first; !approved {
+		t.Error("first write was not approved")
+	}
+
+	m.Update(keyMsg{key: "q"})
+	if approved := <-second; approved {
+		t.Error("pending write was approved on quit")
+	}
+	if !m.Quit() {
+		t.Error("Quit() = false after q")
+	}
+}
+
+func TestModel_ViewFitsScreen(t *testing.T) {
+	m := NewModel(40, 10, time.Now())
+	for i := 0; i < 30; i++ {
+		m.Update(CommandFinished{Result: scanner.ExecutionResult{
+			Command: scanner.Command{Type: "open", Argument: strings.Repeat("x", 60)},
+			Success: true,
+		}})
+	}
+	m.Update(InputClosed{})
+
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("view has %d lines, want 10", len(lines))
+	}
+	for _, line := range lines {
+		if n := len([]rune(line)); n > 40 {
+			t.Errorf("line of %d columns exceeds width: %q", n, line)
+		}
+	}
+	if !strings.HasPrefix(lines[9], "input finished") {
+		t.Errorf("footer = %q", lines[9])
+	}
+
+	m.Update(keyMsg{key: "k"})
+	m.Update(keyMsg{key: "k"})
+	if m.scroll != 2 {
+		t.Errorf("scroll = %d after two k presses, want 2", m.scroll)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := map[string]string{
+		"y":      "y",
+		"\x1b[A": "up",
+		"\x1b[B": "down",
+		"\x03":   "ctrl+c",
+		"\x1b":   "",
+	}
+	for input, want := range tests {
+		if got := parseKey([]byte(input)); got != want {
+			t.Errorf("parseKey(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
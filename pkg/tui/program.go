@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/scanner"
+)
+
+// tickInterval is how often elapsed times are redrawn and the terminal
+// size is checked
+const tickInterval = 250 * time.Millisecond
+
+// Program runs the dashboard on a terminal. Other goroutines report
+// progress with Send and ask for approvals with RequestApproval.
+type Program struct {
+	tty   *os.File
+	model *Model
+	msgs  chan Msg
+	done  chan struct{}
+}
+
+// NewProgram creates a dashboard drawn on, and reading keys from, tty
+func NewProgram(tty *os.File) *Program {
+	return &Program{
+		tty:  tty,
+		msgs: make(chan Msg, 64),
+		done: make(chan struct{}),
+	}
+}
+
+// Send delivers a message to the dashboard. It is dropped once the
+// dashboard has exited.
+func (p *Program) Send(msg Msg) {
+	select {
+	case p.msgs <- msg:
+	case <-p.done:
+	}
+}
+
+// RequestApproval queues cmd for the operator and waits for their decision.
+// Commands are denied if the dashboard exits first.
+func (p *Program) RequestApproval(cmd scanner.Command) bool {
+	reply := make(chan bool, 1)
+	p.Send(ApprovalRequested{Command: cmd, Reply: reply})
+
+	select {
+	case approved := <-reply:
+		return approved
+	case <-p.done:
+		return false
+	}
+}
+
+// Run draws the dashboard until the operator quits, and returns the final
+// model
+func (p *Program) Run() (*Model, error) {
+	defer close(p.done)
+
+	restore, err := makeRaw(int(p.tty.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot control terminal: %w", err)
+	}
+	defer restore()
+
+	// Alternate screen, hidden cursor; both undone on exit
+	fmt.Fprint(p.tty, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(p.tty, "\x1b[?25h\x1b[?1049l")
+
+	width, height := terminalSize(int(p.tty.Fd()))
+	p.model = NewModel(width, height, time.Now())
+
+	go p.readKeys()
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for !p.model.Quit() {
+		p.render()
+		select {
+		case msg := <-p.msgs:
+			p.model.Update(msg)
+		case now := <-ticker.C:
+			width, height := terminalSize(int(p.tty.Fd()))
+			p.model.Update(tickMsg{now: now, width: width, height: height})
+		}
+	}
+	return p.model, nil
+}
+
+// render redraws the whole screen from the top left corner
+func (p *Program) render() {
+	fmt.Fprint(p.tty, "\x1b[H\x1b[2J"+p.model.View())
+}
+
+// readKeys turns terminal input into key messages
+func (p *Program) readKeys() {
+	buf := make([]byte, 16)
+	for {
+		n, err := p.tty.Read(buf)
+		if err != nil {
+			return
+		}
+		if key := parseKey(buf[:n]); key != "" {
+			p.Send(keyMsg{key: key})
+		}
+	}
+}
+
+// parseKey names the key in a chunk of raw terminal input, or returns ""
+// for keys the dashboard ignores
+func parseKey(b []byte) string {
+	switch string(b) {
+	case "\x1b[A":
+		return "up"
+	case "\x1b[B":
+		return "down"
+	case "\x03":
+		return "ctrl+c"
+	}
+	if len(b) == 1 && b[0] >= ' ' && b[0] < 0x7f {
+		return string(b)
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+// Package usage keeps a ledger of the resources sessions used: containers
+// and their CPU time, bytes written, and the model tokens of agent
+// sessions. Each session appends one JSON line when it ends, and Report
+// totals the lines by user, repository, label, model, or day, so teams can
+// attribute and budget agent activity.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is the usage of one session
+type Entry struct {
+	Session    string    `json:"session"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	User       string    `json:"user"`
+	Repository string    `json:"repository"`
+	Label      string    `json:"label,omitempty"` // Such as a team or project, from usage.label
+
+	Commands     int     `json:"commands"`
+	Containers   int64   `json:"containers"`
+	CPUSeconds   float64 `json:"cpu_seconds"` // Time of each container command times the CPUs it was allowed
+	BytesWritten int64   `json:"bytes_written"`
+
+	Model        string  `json:"model,omitempty"` // Agent sessions only
+	InputTokens  int     `json:"input_tokens,omitempty"`
+	OutputTokens int     `json:"output_tokens,omitempty"`
+	Cost         float64 `json:"cost,omitempty"` // In dollars, at the model's prices
+}
+
+// DefaultLedger returns the ledger file used when none is configured,
+// usage.jsonl in the llm-runtime directory of the user config directory
+func DefaultLedger() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no config directory for the usage ledger: %w", err)
+	}
+	return filepath.Join(dir, "llm-runtime", "usage.jsonl"), nil
+}
+
+// CurrentUser returns the name usage is attributed to
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Append adds entry to the ledger at path, creating it if need be
+func Append(path string, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("cannot create usage ledger: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open usage ledger: %w", err)
+	}
+	// One write, so entries of sessions ending together don't interleave
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("cannot write usage ledger: %w", err)
+	}
+	return file.Close()
+}
+
+// Read returns the entries of the ledger at path that ended at or after
+// since. A missing ledger has no entries; malformed lines are skipped.
+func Read(path string, since time.Time) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			continue
+		}
+		if !entry.End.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, sc.Err()
+}
+
+// ParseSince parses how far back a report goes: a duration such as 12h,
+// a number of days or weeks such as 7d or 2w, or a date such as
+// 2024-05-01. It returns the earliest time included.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				break
+			}
+			return now.Add(-time.Duration(count) * unit), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q (want a duration such as 12h or 7d, or a date such as 2024-05-01)", s)
+	}
+	return now.Add(-d), nil
+}
+
+// Groupings a report can total by
+var Groupings = []string{"user", "repository", "label", "model", "day"}
+
+// Total is the usage of a group of sessions
+type Total struct {
+	Group        string  `json:"group"`
+	Sessions     int     `json:"sessions"`
+	Commands     int     `json:"commands"`
+	Containers   int64   `json:"containers"`
+	CPUSeconds   float64 `json:"cpu_seconds"`
+	BytesWritten int64   `json:"bytes_written"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// add counts entry in t
+func (t *Total) add(entry Entry) {
+	t.Sessions++
+	t.Commands += entry.Commands
+	t.Containers += entry.Containers
+	t.CPUSeconds += entry.CPUSeconds
+	t.BytesWritten += entry.BytesWritten
+	t.InputTokens += entry.InputTokens
+	t.OutputTokens += entry.OutputTokens
+	t.Cost += entry.Cost
+}
+
+// Report totals entries by the grouping by, one of Groupings, in order of
+// group, and returns them with the total of all
+func Report(entries []Entry, by string) ([]Total, Total, error) {
+	key, err := groupKey(by)
+	if err != nil {
+		return nil, Total{}, err
+	}
+	groups := map[string]*Total{}
+	all := Total{Group: "total"}
+	for _, entry := range entries {
+		name := key(entry)
+		if name == "" {
+			name = "(none)"
+		}
+		if groups[name] == nil {
+			groups[name] = &Total{Group: name}
+		}
+		groups[name].add(entry)
+		all.add(entry)
+	}
+
+	totals := make([]Total, 0, len(groups))
+	for _, t := range groups {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Group < totals[j].Group })
+	return totals, all, nil
+}
+
+// groupKey returns the function naming the group of an entry under by
+func groupKey(by string) (func(Entry) string, error) {
+	switch by {
+	case "user":
+		return func(e Entry) string { return e.User }, nil
+	case "repository":
+		return func(e Entry) string { return e.Repository }, nil
+	case "label":
+		return func(e Entry) string { return e.Label }, nil
+	case "model":
+		return func(e Entry) string { return e.Model }, nil
+	case "day":
+		return func(e Entry) string { return e.End.Local().Format("2006-01-02") }, nil
+	}
+	return nil, fmt.Errorf("invalid --by %q (want one of %s)", by, strings.Join(Groupings, ", "))
+}
+
+// WriteText writes totals and their total as a table
+func WriteText(w io.Writer, by string, totals []Total, all Total) {
+	fmt.Fprintf(w, "%-30s %8s %8s %10s %10s %12s %12s %12s %10s\n",
+		strings.ToUpper(by), "SESSIONS", "COMMANDS", "CONTAINERS", "CPU-SEC", "BYTES", "TOKENS-IN", "TOKENS-OUT", "COST")
+	for _, t := range append(totals, all) {
+		fmt.Fprintf(w, "%-30s %8d %8d %10d %10.1f %12d %12d %12d %10s\n",
+			t.Group, t.Sessions, t.Commands, t.Containers, t.CPUSeconds, t.BytesWritten, t.InputTokens, t.OutputTokens, fmt.Sprintf("$%.4f", t.Cost))
+	}
+}
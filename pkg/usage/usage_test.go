@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendRead(t *testing.T) {
+	ledger := filepath.Join(t.TempDir(), "llm-runtime", "usage.jsonl")
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	for _, e := range []Entry{
+		{Session: "old", End: now.Add(-10 * 24 * time.Hour)},
+		{Session: "new", End: now.Add(-time.Hour)},
+	} {
+		if err := Append(ledger, e); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	// Lines not written by Append are skipped
+	f, _ := os.OpenFile(ledger, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("not json\n")
+	f.Close()
+
+	entries, err := Read(ledger, now.Add(-7*24*time.Hour))
+	if err != nil || len(entries) != 1 || entries[0].Session != "new" {
+		t.Errorf("Read() = %+v, %v; want the new session", entries, err)
+	}
+	if entries, err := Read(filepath.Join(t.TempDir(), "missing.jsonl"), time.Time{}); err != nil || entries != nil {
+		t.Errorf("Read(missing) = %v, %v; want none", entries, err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"7d", now.Add(-7 * 24 * time.Hour)},
+		{"2w", now.Add(-14 * 24 * time.Hour)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"2026-05-01", time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "7x", "-3d", "-1h", "yesterday"} {
+		if _, err := ParseSince(bad, now); err == nil {
+			t.Errorf("ParseSince(%q) = nil error, want error", bad)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	entries := []Entry{
+		{User: "ana", Model: "gpt-4o", Commands: 3, CPUSeconds: 1.5, InputTokens: 100, Cost: 0.01},
+		{User: "bo", Commands: 2, Containers: 2, BytesWritten: 50},
+		{User: "ana", Model: "gpt-4o", Commands: 1, CPUSeconds: 0.5, InputTokens: 50, Cost: 0.02},
+	}
+	totals, all, err := Report(entries, "user")
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if len(totals) != 2 || totals[0].Group != "ana" || totals[0].Sessions != 2 || totals[0].Commands != 4 || totals[0].CPUSeconds != 2 || totals[0].InputTokens != 150 {
+		t.Errorf("Report() groups = %+v", totals)
+	}
+	if all.Sessions != 3 || all.Commands != 6 || all.BytesWritten != 50 {
+		t.Errorf("Report() total = %+v", all)
+	}
+
+	totals, _, _ = Report(entries, "model")
+	if len(totals) != 2 || totals[0].Group != "(none)" || totals[1].Group != "gpt-4o" {
+		t.Errorf("Report(model) groups = %+v", totals)
+	}
+	if _, _, err := Report(entries, "team"); err == nil {
+		t.Error("Report(team) = nil error, want error")
+	}
+
+	var out bytes.Buffer
+	WriteText(&out, "user", totals, all)
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 4 || !strings.HasPrefix(lines[3], "total") {
+		t.Errorf("WriteText() =\n%s", out.String())
+	}
+}
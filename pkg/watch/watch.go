@@ -0,0 +1,175 @@
+// Package watch reports changes to the files of a repository, gathered
+// until the repository has been quiet for a while, so a command script can
+// be re-run once per burst of edits rather than once per file saved.
+// Paths excluded by the configuration, .gitignore, or .llmignore, and the
+// .git directory, are not watched.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/computerscienceiscool/llm-runtime/pkg/ignore"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher watches the files below a root directory
+type Watcher struct {
+	root     string
+	debounce time.Duration
+	excluded *ignore.Patterns
+	fs       *fsnotify.Watcher
+	settled  map[string]time.Time // Paths written by the caller, at the time they were
+}
+
+// New watches the files below root. A change is reported once no other
+// has followed it for debounce. Paths matching excluded, in .gitignore
+// syntax, are not watched.
+func New(root string, debounce time.Duration, excluded []string) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("cannot watch files: %w", err)
+	}
+	w := &Watcher{
+		root:     root,
+		debounce: debounce,
+		excluded: ignore.CompilePatterns(excluded),
+		fs:       fw,
+		settled:  make(map[string]time.Time),
+	}
+	if err := w.add(root); err != nil {
+		fw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// add watches dir and the directories below it that are not ignored.
+// fsnotify watches one directory at a time, so each needs adding.
+func (w *Watcher) add(dir string) error {
+	matcher := ignore.NewMatcher(w.root)
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Removed while walking, or unreadable: nothing to watch
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != w.root && w.ignored(matcher, path, true) {
+			return filepath.SkipDir
+		}
+		if err := w.fs.Add(path); err != nil {
+			return fmt.Errorf("cannot watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// ignored reports whether path is not watched
+func (w *Watcher) ignored(matcher *ignore.Matcher, path string, isDir bool) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return true
+	}
+	if _, _, matched := w.excluded.Match(rel, isDir); matched {
+		return true
+	}
+	return matcher.Match(rel, isDir)
+}
+
+// Settle marks paths, relative to the root, as written by the caller, such
+// as by the script a change ran. They are not reported as changed unless
+// something changes them again.
+func (w *Watcher) Settle(paths []string) {
+	for _, path := range paths {
+		w.settled[filepath.ToSlash(path)] = ModTime(filepath.Join(w.root, path))
+	}
+}
+
+// Next waits for files to change and returns their paths, relative to the
+// root and sorted, once no change has followed for the debounce time
+func (w *Watcher) Next(ctx context.Context) ([]string, error) {
+	changed := map[string]bool{}
+	var quiet <-chan time.Time
+	// Ignore files may have changed since the last call
+	matcher := ignore.NewMatcher(w.root)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-w.fs.Errors:
+			return nil, fmt.Errorf("watching files: %w", err)
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil, fmt.Errorf("watcher closed")
+			}
+			if rel, ok := w.change(matcher, event); ok {
+				changed[rel] = true
+				quiet = time.After(w.debounce)
+			}
+		case <-quiet:
+			paths := make([]string, 0, len(changed))
+			for path := range changed {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			return paths, nil
+		}
+	}
+}
+
+// change returns the path event changed, relative to the root, and false
+// for events that are not changes worth reporting
+func (w *Watcher) change(matcher *ignore.Matcher, event fsnotify.Event) (string, bool) {
+	if event.Op == fsnotify.Chmod {
+		return "", false
+	}
+	info, err := os.Stat(event.Name)
+	isDir := err == nil && info.IsDir()
+	if w.ignored(matcher, event.Name, isDir) {
+		return "", false
+	}
+	if isDir && event.Has(fsnotify.Create) {
+		// Files may already be in it, before its watch is added
+		w.add(event.Name)
+	}
+
+	rel, _ := filepath.Rel(w.root, event.Name)
+	rel = filepath.ToSlash(rel)
+	if at, ok := w.settled[rel]; ok {
+		if ModTime(event.Name).Equal(at) {
+			return "", false
+		}
+		delete(w.settled, rel)
+	}
+	return rel, true
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+// ModTime returns the modification time of path, or the zero time if it
+// does not exist
+func ModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
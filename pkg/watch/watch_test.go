@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// next waits for the watcher's next change, failing the test after a while
+func next(t *testing.T, w *Watcher) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	paths, err := w.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	return paths
+}
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, ".gitignore"), "build/\n")
+	write(t, filepath.Join(root, "pkg", "a.go"), "package pkg")
+	write(t, filepath.Join(root, "build", "out"), "x")
+	write(t, filepath.Join(root, "secrets", "key"), "x")
+	write(t, filepath.Join(root, ".git", "HEAD"), "ref")
+
+	w, err := New(root, 50*time.Millisecond, []string{"secrets/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+
+	// A burst of changes is reported once; ignored paths are not reported
+	write(t, filepath.Join(root, "build", "out"), "y")
+	write(t, filepath.Join(root, "secrets", "key"), "y")
+	write(t, filepath.Join(root, ".git", "HEAD"), "other")
+	write(t, filepath.Join(root, "pkg", "a.go"), "package pkg // changed")
+	write(t, filepath.Join(root, "b.txt"), "new")
+	if got, want := next(t, w), []string{"b.txt", "pkg/a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+
+	// Files in new directories are watched
+	write(t, filepath.Join(root, "cmd", "main.go"), "package main")
+	if got := next(t, w); len(got) == 0 || got[0] != "cmd" {
+		t.Errorf("Next() = %v, want the new directory", got)
+	}
+	write(t, filepath.Join(root, "cmd", "main.go"), "package main // changed")
+	if got, want := next(t, w), []string{"cmd/main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestWatcher_Settle(t *testing.T) {
+	root := t.TempDir()
+	w, err := New(root, 50*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+
+	// A file the caller wrote is not a change, until it changes again
+	write(t, filepath.Join(root, "summary.md"), "ran")
+	w.Settle([]string{"summary.md"})
+	write(t, filepath.Join(root, "a.go"), "package a")
+	if got, want := next(t, w), []string{"a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	write(t, filepath.Join(root, "summary.md"), "edited")
+	if got, want := next(t, w), []string{"summary.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestWatcher_Canceled(t *testing.T) {
+	w, err := New(t.TempDir(), time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.Next(ctx); err != context.Canceled {
+		t.Errorf("Next() error = %v, want context.Canceled", err)
+	}
+}
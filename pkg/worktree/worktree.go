@@ -0,0 +1,246 @@
+// Package worktree runs sessions in git worktrees of the target
+// repository, each on a branch of its own, so every change a session makes
+// lands on that branch rather than in the checkout being worked on, ready
+// to review and open a pull request from.
+package worktree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// BranchPrefix starts the name of every session branch
+const BranchPrefix = "llm-runtime/"
+
+// lockReason starts the lock reason of every session worktree, followed by
+// the owner of the session, so abandoned ones can be told apart
+const lockReason = "llm-runtime session "
+
+// excludeMeta keeps the runtime's own directory, with its backups and
+// snapshots, out of session commits
+const excludeMeta = ":(exclude,glob)**/.llm-tools/**"
+
+// ErrNotRepository is returned for a directory outside any git repository
+var ErrNotRepository = errors.New("not in a git repository")
+
+// Worktree is a session's checkout of a repository
+type Worktree struct {
+	Dir    string // Top level of the worktree
+	Root   string // Directory in it matching the repository root the session was given
+	Branch string
+	Base   string // Commit the branch started from
+
+	repo string // Top level of the main checkout
+}
+
+// Summary describes the branch a session leaves behind
+type Summary struct {
+	Branch   string
+	Commit   string // Head of the branch; empty when the session changed nothing and the branch was deleted
+	DiffStat string // git diff --stat of the branch against its base
+}
+
+// Abandoned describes a session worktree removed because the process that
+// created it is gone
+type Abandoned struct {
+	Dir       string
+	Branch    string
+	Committed bool // Whether changes left in it were committed to the branch first
+}
+
+// Create checks out HEAD of the repository holding root into a new
+// worktree under dir, or under the temporary directory when dir is empty,
+// on the branch BranchPrefix+name. The worktree is locked with owner, as
+// sandbox.Owner returns it, until Finish.
+func Create(root, dir, name, owner string) (*Worktree, error) {
+	top, err := topLevel(root)
+	if err != nil {
+		return nil, err
+	}
+	// git reports the top level with links resolved
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(top, resolved)
+	if err != nil {
+		return nil, err
+	}
+	base, err := git(top, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("repository has no commits to branch from: %w", err)
+	}
+
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "llm-runtime-worktrees")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create worktree directory: %w", err)
+	}
+	wt := &Worktree{
+		Dir:    filepath.Join(dir, name),
+		Branch: BranchPrefix + name,
+		Base:   base,
+		repo:   top,
+	}
+	wt.Root = filepath.Join(wt.Dir, rel)
+
+	if _, err := git(top, "worktree", "add", "--lock", "--reason", lockReason+owner, "-b", wt.Branch, wt.Dir, base); err != nil {
+		return nil, fmt.Errorf("cannot create worktree: %w", err)
+	}
+	return wt, nil
+}
+
+// Origin returns the repository root the session was given, in the main
+// checkout, that Root stands in for
+func (w *Worktree) Origin() string {
+	rel, err := filepath.Rel(w.Dir, w.Root)
+	if err != nil {
+		return w.repo
+	}
+	return filepath.Join(w.repo, rel)
+}
+
+// Finish commits what the session changed to the branch checked out in
+// the worktree with message, which is not Branch if the session switched
+// to another, removes the worktree, and summarizes that branch. Branch is
+// deleted if it was left with no commits of its own.
+func (w *Worktree) Finish(message string) (Summary, error) {
+	summary := Summary{Branch: w.Branch}
+	if current, err := git(w.Dir, "symbolic-ref", "--short", "-q", "HEAD"); err == nil && current != "" {
+		summary.Branch = current
+	}
+	if _, err := commitAll(w.Dir, message); err != nil {
+		return summary, err
+	}
+	head, err := git(w.Dir, "rev-parse", "HEAD")
+	if err != nil {
+		return summary, err
+	}
+	if err := remove(w.repo, w.Dir); err != nil {
+		return summary, err
+	}
+
+	if tip, _ := git(w.repo, "rev-parse", "--verify", "-q", w.Branch); tip == w.Base {
+		if _, err := git(w.repo, "branch", "-D", w.Branch); err != nil {
+			return summary, fmt.Errorf("cannot delete unchanged branch %s: %w", w.Branch, err)
+		}
+	}
+	if head == w.Base {
+		return summary, nil
+	}
+	summary.Commit = head
+	summary.DiffStat, err = git(w.repo, "diff", "--stat", w.Base, head)
+	return summary, err
+}
+
+// Prune removes the session worktrees of the repository holding root
+// whose owners are no longer running, committing changes left in them to
+// their branches first so no work is lost. running reports whether an
+// owner is, as sandbox.OwnerRunning does.
+func Prune(root string, running func(owner string) bool) ([]Abandoned, error) {
+	top, err := topLevel(root)
+	if err != nil {
+		return nil, err
+	}
+	// Forget worktrees whose directories were deleted, such as with the
+	// temporary directory
+	if _, err := git(top, "worktree", "prune"); err != nil {
+		return nil, err
+	}
+	out, err := git(top, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []Abandoned
+	for _, block := range strings.Split(out, "\n\n") {
+		var dir, branch, reason string
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				dir = value
+			case "branch":
+				branch = strings.TrimPrefix(value, "refs/heads/")
+			case "locked":
+				reason = value
+			}
+		}
+		owner, ok := strings.CutPrefix(reason, lockReason)
+		if !ok || !strings.HasPrefix(branch, BranchPrefix) || running(owner) {
+			continue
+		}
+
+		committed, err := commitAll(dir, "Uncommitted changes of an abandoned llm-runtime session")
+		if err != nil {
+			return pruned, fmt.Errorf("cannot save changes in %s: %w", dir, err)
+		}
+		if err := remove(top, dir); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, Abandoned{Dir: dir, Branch: branch, Committed: committed})
+	}
+	return pruned, nil
+}
+
+// topLevel returns the top level of the working tree holding dir
+func topLevel(dir string) (string, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", dir, ErrNotRepository)
+	}
+	return top, nil
+}
+
+// commitAll commits every change in the worktree at dir, outside the
+// runtime's own directory, and reports whether there were any
+func commitAll(dir, message string) (bool, error) {
+	if _, err := git(dir, "add", "-A", "--", ".", excludeMeta); err != nil {
+		return false, err
+	}
+	if _, err := git(dir, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+
+	args := []string{"commit", "-q", "--no-verify", "-m", message}
+	// Commit as the runtime where no identity is configured, as in a
+	// container or CI
+	if email, _ := git(dir, "config", "user.email"); email == "" {
+		args = append([]string{"-c", "user.name=LLM Runtime", "-c", "user.email=llm-runtime@localhost"}, args...)
+	}
+	if _, err := git(dir, args...); err != nil {
+		return false, fmt.Errorf("cannot commit session changes: %w", err)
+	}
+	return true, nil
+}
+
+// remove unlocks and removes the worktree at dir
+func remove(repo, dir string) error {
+	git(repo, "worktree", "unlock", dir)
+	if _, err := git(repo, "worktree", "remove", "--force", dir); err != nil {
+		return fmt.Errorf("cannot remove worktree %s: %w", dir, err)
+	}
+	return nil
+}
+
+// git runs git in dir and returns its output without the final newline,
+// or its error output as the error
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
@@ -0,0 +1,130 @@
+package worktree
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo creates a git repository with one commit holding pkg/main.go
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	os.MkdirAll(filepath.Join(repo, "pkg"), 0755)
+	os.WriteFile(filepath.Join(repo, "pkg", "main.go"), []byte("package main\n"), 0644)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		if _, err := git(repo, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return repo
+}
+
+func TestCreateFinish(t *testing.T) {
+	repo := newRepo(t)
+	wt, err := Create(filepath.Join(repo, "pkg"), t.TempDir(), "session-1", "host:1")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if wt.Branch != "llm-runtime/session-1" || filepath.Base(wt.Root) != "pkg" {
+		t.Errorf("Create() = %+v", wt)
+	}
+	if _, err := os.Stat(filepath.Join(wt.Root, "main.go")); err != nil {
+		t.Fatalf("worktree is not checked out: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(wt.Root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	os.MkdirAll(filepath.Join(wt.Root, ".llm-tools", "backups"), 0755)
+	os.WriteFile(filepath.Join(wt.Root, ".llm-tools", "backups", "main.go.bak"), []byte("package main\n"), 0644)
+
+	summary, err := wt.Finish("session changes")
+	if err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if summary.Commit == "" || !strings.Contains(summary.DiffStat, "pkg/main.go") {
+		t.Errorf("Finish() = %+v", summary)
+	}
+	if strings.Contains(summary.DiffStat, ".llm-tools") {
+		t.Errorf("runtime's own files were committed: %s", summary.DiffStat)
+	}
+	if _, err := os.Stat(wt.Dir); !os.IsNotExist(err) {
+		t.Error("worktree was not removed")
+	}
+
+	// The checkout itself is untouched
+	if data, _ := os.ReadFile(filepath.Join(repo, "pkg", "main.go")); string(data) != "package main\n" {
+		t.Errorf("main checkout changed: %q", data)
+	}
+	if _, err := git(repo, "rev-parse", "--verify", summary.Branch); err != nil {
+		t.Errorf("branch %s missing: %v", summary.Branch, err)
+	}
+}
+
+func TestFinish_NoChanges(t *testing.T) {
+	repo := newRepo(t)
+	wt, err := Create(repo, t.TempDir(), "session-2", "host:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := wt.Finish("nothing")
+	if err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if summary.Commit != "" {
+		t.Errorf("Finish() = %+v, want no commit", summary)
+	}
+	if _, err := git(repo, "rev-parse", "--verify", wt.Branch); err == nil {
+		t.Error("unchanged branch was kept")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	repo := newRepo(t)
+	dir := t.TempDir()
+	live, err := Create(repo, dir, "live", "host:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead, err := Create(repo, dir, "dead", "host:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dead.Root, "notes.txt"), []byte("unsaved\n"), 0644)
+
+	pruned, err := Prune(repo, func(owner string) bool { return owner == "host:1" })
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].Branch != dead.Branch || !pruned[0].Committed {
+		t.Fatalf("Prune() = %+v", pruned)
+	}
+	if _, err := os.Stat(dead.Dir); !os.IsNotExist(err) {
+		t.Error("abandoned worktree was not removed")
+	}
+	if _, err := os.Stat(live.Dir); err != nil {
+		t.Error("running session's worktree was removed")
+	}
+	if files, _ := git(repo, "show", "--name-only", "--format=", dead.Branch); files != "notes.txt" {
+		t.Errorf("abandoned changes committed = %q, want notes.txt", files)
+	}
+}
+
+func TestCreate_NotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := Create(t.TempDir(), t.TempDir(), "session", "host:1")
+	if !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Create() error = %v, want ErrNotRepository", err)
+	}
+}